@@ -21,7 +21,7 @@ var (
 func main() {
 	cli.SetVersionInfo(Version, GitCommit, BuildTime)
 	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
@@ -8,9 +8,11 @@ import (
 	"io"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
@@ -19,8 +21,9 @@ import (
 
 // CommitRequest represents a request to generate a commit message
 type CommitRequest struct {
-	Language string // Output language
-	Context  string // User-provided context (optional)
+	Language    string        // Output language
+	Context     string        // User-provided context (optional)
+	MaxDuration time.Duration // Wall-clock budget for the run (0 = unlimited)
 }
 
 // CommitInfo represents the structured commit information from LLM tool call
@@ -93,6 +96,9 @@ func CommitInfoFromToolParams(params *tools.SubmitCommitParams) *CommitInfo {
 type CommitResponse struct {
 	CommitInfo       *CommitInfo
 	Message          string
+	Partial          bool   // True if the message was salvaged after a failure
+	PartialReason    string // Why the message is partial
+	Offline          bool   // True if the model was unreachable and the message was built from the diff stats
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
@@ -100,13 +106,17 @@ type CommitResponse struct {
 
 // CommitAgentOptions contains configuration for CommitAgent
 type CommitAgentOptions struct {
-	Language    string
-	GitExecutor git.Executor
-	LLMProvider llm.Provider
-	Printer     *ui.StreamPrinter
-	Output      io.Writer
-	Debug       bool
-	RetryConfig llm.RetryConfig
+	Language             string
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Output               io.Writer
+	Debug                bool
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	MaxDiffLines         int    // Staged diff lines above which git_diff_cached returns per-file statistics (0 = default, negative = no limit)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
+	OfflineFallback      bool   // Build the message from the diff stats when the model is unreachable
 }
 
 // Validate validates the options and sets defaults
@@ -170,44 +180,6 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 		log.Debug(msg)
 	}
 
-	printToolCall := func(name string) {
-		if printer != nil {
-			_ = printer.PrintToolCall(name, nil)
-		}
-		log.Debug("Tool call: %s", name)
-	}
-
-	// estimateTokenCount estimates token count from text
-	// This is a simple heuristic: ~4 chars per token for English, ~1.5 chars per token for Chinese
-	// For mixed content, we use a weighted average
-	estimateTokenCount := func(text string) int {
-		if len(text) == 0 {
-			return 0
-		}
-		// Count Chinese characters (CJK unified ideographs)
-		chineseChars := 0
-		for _, r := range text {
-			if r >= 0x4E00 && r <= 0x9FFF {
-				chineseChars++
-			}
-		}
-		// Estimate: Chinese ~1.5 chars/token, others ~4 chars/token
-		otherChars := len([]rune(text)) - chineseChars
-		tokens := (chineseChars * 2 / 3) + (otherChars / 4)
-		if tokens == 0 && len(text) > 0 {
-			tokens = 1 // At least 1 token for non-empty text
-		}
-		return tokens
-	}
-
-	printToolResult := func(name string, result string) {
-		if printer != nil {
-			bytes := len(result)
-			tokens := estimateTokenCount(result)
-			_ = printer.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
-		}
-	}
-
 	printInfo := func(msg string) {
 		if printer != nil {
 			_ = printer.PrintInfo(msg)
@@ -220,6 +192,17 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 		}
 	}
 
+	// Dependency-only changes are described exactly from the parsed manifests.
+	// Other languages and extra context still go through the model.
+	if (req.Language == "" || req.Language == "en") && req.Context == "" {
+		if update := dependencyOnlyUpdate(ctx, a.opts.GitExecutor); update != nil {
+			printInfo("Only dependencies changed, generating the message from the manifests")
+			commitInfo := dependencyCommitInfo(update)
+			printSuccess("Commit message generated")
+			return &CommitResponse{CommitInfo: commitInfo, Message: commitInfo.Message()}, nil
+		}
+	}
+
 	// Create LLM chat model
 	if a.opts.LLMProvider == nil {
 		return nil, fmt.Errorf("LLM provider is not configured")
@@ -239,7 +222,7 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 
 	// Create git tools
 	gitStatusTool := tools.NewGitStatusTool(a.opts.GitExecutor)
-	gitDiffCachedTool := tools.NewGitDiffCachedTool(a.opts.GitExecutor)
+	gitDiffCachedTool := tools.NewGitDiffCachedTool(a.opts.GitExecutor, diffLineLimit(a.opts.LLMProvider, a.opts.MaxDiffLines))
 	gitLogTool := tools.NewGitLogTool(a.opts.GitExecutor)
 
 	// Define tool schemas
@@ -250,26 +233,30 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
 		},
 		{
-			Name:        "git_diff_cached",
-			Desc:        gitDiffCachedTool.Description(),
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
+			Name: "git_diff_cached",
+			Desc: gitDiffCachedTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"path": {Type: schema.String, Desc: "Show only the diff of this file or directory (optional)", Required: false},
+			}),
 		},
 		{
 			Name: "git_log",
 			Desc: gitLogTool.Description(),
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"count": {Type: schema.Integer, Desc: "Number of commits to retrieve (default 5)", Required: false},
+				"count":  {Type: schema.Integer, Desc: "Number of commits to retrieve (default 5)", Required: false},
+				"format": {Type: schema.String, Desc: "Output format: text (default) or json with changed files and insert/delete counts", Required: false},
 			}),
 		},
 		{
 			Name: "submit_commit",
 			Desc: "Submit the structured commit information. Call this when you have analyzed the changes and are ready to generate the commit message.",
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"type":        {Type: schema.String, Desc: "Commit type: feat, fix, docs, style, refactor, perf, test, chore, build, ci, or revert", Required: true},
-				"scope":       {Type: schema.String, Desc: "Commit scope (optional)", Required: false},
-				"description": {Type: schema.String, Desc: "Short description (max 50 chars preferred)", Required: true},
-				"body":        {Type: schema.String, Desc: "Detailed description (optional)", Required: false},
-				"footer":      {Type: schema.String, Desc: "Footer for breaking changes or issue references (optional)", Required: false},
+				"type":         {Type: schema.String, Desc: "Commit type: feat, fix, docs, style, refactor, perf, test, chore, build, ci, or revert", Required: true},
+				"scope":        {Type: schema.String, Desc: "Commit scope (optional)", Required: false},
+				"description":  {Type: schema.String, Desc: "Short description (max 50 chars preferred)", Required: true},
+				"body":         {Type: schema.String, Desc: "Detailed description (optional)", Required: false},
+				"footer":       {Type: schema.String, Desc: "Footer for breaking changes or issue references (optional)", Required: false},
+				"not_breaking": {Type: schema.String, Desc: "Why the likely breaking changes listed in the request don't break callers, when there is no BREAKING CHANGE footer (optional)", Required: false},
 			}),
 		},
 	}
@@ -279,8 +266,36 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 		return nil, fmt.Errorf("failed to bind tools: %w", err)
 	}
 
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+		RestartBrokenStreams: true, // Nothing is salvaged from a commit response cut short
+	})
+	defer runner.PrintDiagnostics()
+	runner.Register("git_status", func(ctx context.Context, _ schema.ToolCall) (string, error) {
+		return gitStatusTool.Execute(ctx, nil)
+	})
+	runner.Register("git_diff_cached", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		result, err := tools.Bind[tools.GitDiffCachedParams](gitDiffCachedTool.Execute)(ctx, call)
+		// Compare the whole result, so a diff containing the message doesn't match
+		if err == nil && tools.IsNoStagedChanges(result) {
+			return "", abortRun(fmt.Errorf("no staged changes found"))
+		}
+		return result, err
+	})
+	runner.Register("git_log", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		var params tools.GitLogParams
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
+			// Use default params if parsing fails
+			params = tools.GitLogParams{Count: 5}
+		}
+		return gitLogTool.Execute(ctx, &params)
+	})
+
 	// Build system prompt
-	systemPrompt := BuildSystemPrompt(req.Language, req.Context)
+	systemPrompt := ExtendSystemPrompt(BuildSystemPrompt(req.Language, req.Context), a.opts.PromptExtension)
 	printInfo(fmt.Sprintf("Language: %s", req.Language))
 	if req.Context != "" {
 		printInfo(fmt.Sprintf("Context: %s", req.Context))
@@ -288,206 +303,96 @@ func (a *CommitAgent) GenerateCommitMessage(ctx context.Context, req CommitReque
 
 	// Initial messages
 	userMsg := "Please generate a commit message for the staged changes. Use the available tools to analyze the changes first."
+	facts := stagedDiffFacts(ctx, a.opts.GitExecutor, nil)
+	if len(facts) > 0 {
+		printInfo(fmt.Sprintf("Found %d API and dependency change(s) in the diff", len(facts)))
+		userMsg += "\n\n" + analysis.FormatFacts(facts)
+	}
+
+	// Likely breaking changes must be declared in a footer or explained
+	var breaking []string
+	for _, fact := range analysis.BreakingFacts(facts) {
+		breaking = append(breaking, fmt.Sprintf("%s: %s: %s", fact.File, fact.Kind, fact.Detail))
+	}
+	if len(breaking) > 0 {
+		printInfo(fmt.Sprintf("Found %d likely breaking change(s)", len(breaking)))
+		userMsg += "\n\nThe facts include likely breaking changes to exported APIs. Either add a \"BREAKING CHANGE: <description>\" footer describing them, or, if callers are not affected (e.g. the API is unused outside this module or the old form is still accepted), explain why in not_breaking."
+	}
 
 	messages := []*schema.Message{
 		{Role: schema.System, Content: systemPrompt},
 		{Role: schema.User, Content: userMsg},
 	}
 
-	var promptTokens, completionTokens, totalTokens int
-	maxIterations := 10
-
-	// Agent loop
-	for i := 0; i < maxIterations; i++ {
-		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
-
-		// Stream LLM response with retry
-		streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
-			return chatModel.Stream(ctx, messages)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("LLM stream failed: %w", err)
-		}
-
-		var fullContent strings.Builder
-		var toolCalls []*schema.ToolCall
-		var toolArgStarted bool
-
-		printInfo("LLM Response:")
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Read stream
-		for {
-			chunk, err := streamReader.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				streamReader.Close()
-				return nil, fmt.Errorf("stream read error: %w", err)
-			}
-
-			if chunk.Content != "" {
-				fullContent.WriteString(chunk.Content)
-				if printer != nil {
-					_ = printer.PrintLLMContent(chunk.Content)
-				}
-			}
-
-			// Collect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				for _, tc := range chunk.ToolCalls {
-					idx := 0
-					if tc.Index != nil {
-						idx = *tc.Index
-					}
-
-					for len(toolCalls) <= idx {
-						toolCalls = append(toolCalls, &schema.ToolCall{Function: schema.FunctionCall{}})
-					}
-
-					// Collect tool call ID
-					if tc.ID != "" {
-						toolCalls[idx].ID = tc.ID
-					}
-
-					if tc.Function.Name != "" {
-						if toolCalls[idx].Function.Name == "" {
-							printToolCall(tc.Function.Name)
-							if printer != nil {
-								_ = printer.PrintToolArgStart()
-							}
-							toolArgStarted = true
-						}
-						toolCalls[idx].Function.Name = tc.Function.Name
-					}
-					if tc.Function.Arguments != "" {
-						toolCalls[idx].Function.Arguments += tc.Function.Arguments
-						if printer != nil && toolArgStarted {
-							_ = printer.PrintToolArgChunk(tc.Function.Arguments)
-						}
-					}
-				}
-			}
-
-			// Collect token usage
-			if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
-				usage := chunk.ResponseMeta.Usage
-				promptTokens += usage.PromptTokens
-				completionTokens += usage.CompletionTokens
-				totalTokens += usage.TotalTokens
+	var submitted tools.SubmitCommitParams
+	messages, err = runner.Run(ctx, messages, RunOptions{
+		MaxIterations: 10,
+		Deadline:      NewDeadline(req.MaxDuration),
+		AgentType:     "commit",
+		NoToolsError:  fmt.Errorf("commit agent requires tool usage to generate proper commit message"),
+		SubmitTool:    "submit_commit",
+		Submit: func(call schema.ToolCall) error {
+			var params tools.SubmitCommitParams
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
+				return fmt.Errorf("invalid parameters: %w", err)
 			}
-		}
-		streamReader.Close()
-
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Add assistant message to history
-		var toolCallsValue []schema.ToolCall
-		for _, tc := range toolCalls {
-			if tc != nil {
-				toolCallsValue = append(toolCallsValue, *tc)
+			if err := params.Validate(); err != nil {
+				return err
 			}
-		}
-		assistantMsg := &schema.Message{
-			Role:      schema.Assistant,
-			Content:   fullContent.String(),
-			ToolCalls: toolCallsValue,
-		}
-		messages = append(messages, assistantMsg)
-
-		// Process tool calls - use intelligent fallback if no tools called
-		if len(toolCalls) == 0 {
-			if err := HandleNoToolCallsResponse(fullContent.String(), "commit"); err != nil {
-				return nil, err
+			if err := params.ValidateBreakingChange(breaking); err != nil {
+				printProgress("Commit message does not address the likely breaking changes, asking the agent to revise it")
+				return err
 			}
-			// If we reach here, the response was accepted without tools
-			// For commit agent, we still need a commit info, so we should return error
-			return nil, fmt.Errorf("commit agent requires tool usage to generate proper commit message")
+			submitted = params
+			return nil
+		},
+	})
+	usage := runner.Usage()
+	if err == nil {
+		if submitted.NotBreaking != "" && !submitted.IsBreaking() {
+			printInfo(fmt.Sprintf("Not marked as breaking: %s", submitted.NotBreaking))
 		}
+		commitInfo := CommitInfoFromToolParams(&submitted)
+		printSuccess("Commit message generated successfully")
+		return &CommitResponse{
+			CommitInfo:       commitInfo,
+			Message:          commitInfo.Message(),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}, nil
+	}
+	if isAborted(err) {
+		return nil, err
+	}
 
-		for _, tc := range toolCalls {
-			if tc.Function.Name == "" {
-				continue
-			}
-
-			// Check if it's the final submit_commit call
-			if tc.Function.Name == "submit_commit" {
-				var params tools.SubmitCommitParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					log.Debug("Failed to parse submit_commit arguments: %v", err)
-					continue
-				}
-
-				if err := params.Validate(); err != nil {
-					log.Debug("Invalid commit params: %v", err)
-					continue
-				}
-
-				commitInfo := CommitInfoFromToolParams(&params)
-				printSuccess("Commit message generated successfully")
-
-				return &CommitResponse{
-					CommitInfo:       commitInfo,
-					Message:          commitInfo.Message(),
-					PromptTokens:     promptTokens,
-					CompletionTokens: completionTokens,
-					TotalTokens:      totalTokens,
-				}, nil
-			}
-
-			// Execute other tools
-			var result string
-			var toolErr error
-
-			switch tc.Function.Name {
-			case "git_status":
-				result, toolErr = gitStatusTool.Execute(ctx, nil)
-
-			case "git_diff_cached":
-				result, toolErr = gitDiffCachedTool.Execute(ctx, nil)
-				// Check if result starts with "No staged changes" (not just contains)
-				// This prevents false positives when the diff itself contains this string
-				if toolErr == nil && strings.HasPrefix(result, "No staged changes") {
-					return nil, fmt.Errorf("no staged changes found")
-				}
-
-			case "git_log":
-				var params tools.GitLogParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					// Use default params if parsing fails
-					params = tools.GitLogParams{Count: 5}
-				}
-				result, toolErr = gitLogTool.Execute(ctx, &params)
-
-			default:
-				toolErr = fmt.Errorf("unknown tool: %s", tc.Function.Name)
-			}
-
-			// Build tool result message
-			var toolResult string
-			if toolErr != nil {
-				toolResult = fmt.Sprintf("Error: %v", toolErr)
-				log.Debug("Tool %s error: %v", tc.Function.Name, toolErr)
-			} else {
-				toolResult = result
-				printToolResult(tc.Function.Name, result)
-			}
-
-			// Add tool result to messages
-			messages = append(messages, &schema.Message{
-				Role:       schema.Tool,
-				Content:    toolResult,
-				ToolCallID: tc.ID,
-			})
+	// Salvage a partial commit message from the message history
+	var commitInfo *CommitInfo
+	var params tools.SubmitCommitParams
+	if salvageToolArguments(messages, "submit_commit", &params) && params.Validate() == nil {
+		commitInfo = CommitInfoFromToolParams(&params)
+	} else {
+		commitInfo = salvageCommitInfo(lastAssistantContent(messages))
+	}
+	if commitInfo == nil && a.opts.OfflineFallback && llm.IsUnavailable(err) {
+		if commitInfo = offlineCommitInfo(ctx, a.opts.GitExecutor); commitInfo != nil {
+			printInfo(fmt.Sprintf("The model is unreachable (%v), generating the message from the diff stats", err))
+			return &CommitResponse{CommitInfo: commitInfo, Message: commitInfo.Message(), Offline: true}, nil
 		}
 	}
-
-	return nil, fmt.Errorf("agent loop exceeded maximum iterations")
+	if commitInfo == nil {
+		return nil, err
+	}
+	printProgress(fmt.Sprintf("Returning partial commit message: %v", err))
+	return &CommitResponse{
+		CommitInfo:       commitInfo,
+		Message:          commitInfo.Message(),
+		Partial:          true,
+		PartialReason:    err.Error(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}, nil
 }
 
 // ResponseAnalysis represents analysis of an LLM response
@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// artifactFieldSeparator separates the fields of an artifact, e.g. a PR title
+// from its description
+const artifactFieldSeparator = "\n\n"
+
+// artifactStream writes the string fields of a submit tool's arguments to the
+// printer's raw stream as the arguments are generated (--raw-stream). Only the
+// first call of the tool is streamed, and Finish completes the artifact once
+// it is known. A nil artifactStream does nothing.
+type artifactStream struct {
+	printer *ui.StreamPrinter
+	tool    string
+	key     *regexp.Regexp // Start of a streamed field's value
+
+	call     int             // Index of the streamed tool call in its response, -1 before it starts
+	started  bool            // The streamed call was seen; later calls are ignored
+	args     strings.Builder // Arguments of the streamed call so far
+	pos      int             // Position in args up to which it was scanned
+	inValue  bool            // pos is inside a streamed field's value
+	fields   int             // Fields started so far
+	streamed strings.Builder // Text written to the raw stream
+}
+
+// newArtifactStream streams fields of tool's arguments, in the order the model
+// writes them, when printer streams the artifact raw; otherwise it returns nil
+func newArtifactStream(printer *ui.StreamPrinter, tool string, fields ...string) *artifactStream {
+	if printer == nil || !printer.RawStream() {
+		return nil
+	}
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	return &artifactStream{
+		printer: printer,
+		tool:    tool,
+		key:     regexp.MustCompile(`"(?:` + strings.Join(quoted, "|") + `)"\s*:\s*"`),
+		call:    -1,
+	}
+}
+
+// Feed records a chunk of the arguments of the tool call at index index of
+// the current response, named name
+func (s *artifactStream) Feed(index int, name, chunk string) {
+	if s == nil || name != s.tool {
+		return
+	}
+	if !s.started {
+		s.started = true
+		s.call = index
+	}
+	if index != s.call {
+		return
+	}
+	s.args.WriteString(chunk)
+	s.scan()
+}
+
+// EndResponse stops streaming at the end of the response the streamed call is
+// part of, so that a call at the same index in a later response isn't mixed in
+func (s *artifactStream) EndResponse() {
+	if s != nil && s.started {
+		s.call = -1
+	}
+}
+
+// scan writes the decoded text of the field values received so far
+func (s *artifactStream) scan() {
+	args := s.args.String()
+	for s.pos < len(args) {
+		if !s.inValue {
+			loc := s.key.FindStringIndex(args[s.pos:])
+			if loc == nil {
+				return
+			}
+			s.pos += loc[1]
+			s.inValue = true
+			if s.fields > 0 {
+				s.write(artifactFieldSeparator)
+			}
+			s.fields++
+			continue
+		}
+
+		end := s.pos
+		for end < len(args) && args[end] != '"' && args[end] != '\\' {
+			end++
+		}
+		s.write(args[s.pos:end])
+		s.pos = end
+		if end == len(args) {
+			return
+		}
+		if args[end] == '"' {
+			s.pos++
+			s.inValue = false
+			continue
+		}
+
+		escape, ok := escapeSequence(args[end:])
+		if !ok {
+			// Wait for the rest of the escape sequence
+			return
+		}
+		var decoded string
+		if err := json.Unmarshal([]byte(`"`+escape+`"`), &decoded); err == nil {
+			s.write(decoded)
+		}
+		s.pos += len(escape)
+	}
+}
+
+// escapeSequence returns the complete JSON escape sequence at the start of s,
+// including the low half of a surrogate pair
+func escapeSequence(s string) (string, bool) {
+	if len(s) < 2 {
+		return "", false
+	}
+	if s[1] != 'u' {
+		return s[:2], true
+	}
+	if len(s) < 6 {
+		return "", false
+	}
+	if high := strings.ToLower(s[2:4]); high >= "d8" && high <= "db" {
+		if len(s) < 12 {
+			return "", false
+		}
+		return s[:12], true
+	}
+	return s[:6], true
+}
+
+func (s *artifactStream) write(text string) {
+	if text == "" {
+		return
+	}
+	s.streamed.WriteString(text)
+	_ = s.printer.PrintArtifact(text)
+}
+
+// Finish completes the artifact on the raw stream: the rest of artifact when
+// the streamed text is its beginning, or all of it after a blank line when the
+// final artifact differs from what was streamed, e.g. because it was
+// resubmitted. It reports whether the artifact was written to the raw stream.
+func (s *artifactStream) Finish(artifact string) bool {
+	if s == nil {
+		return false
+	}
+	streamed := s.streamed.String()
+	if strings.HasPrefix(artifact, streamed) {
+		s.write(artifact[len(streamed):])
+	} else {
+		s.write(artifactFieldSeparator + artifact)
+	}
+	if !strings.HasSuffix(s.streamed.String(), "\n") {
+		s.write("\n")
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// feedInChunks feeds args to stream in chunks of size bytes
+func feedInChunks(stream *artifactStream, index int, name, args string, size int) {
+	for start := 0; start < len(args); start += size {
+		end := start + size
+		if end > len(args) {
+			end = len(args)
+		}
+		stream.Feed(index, name, args[start:end])
+	}
+}
+
+func newRawPrinter(raw *bytes.Buffer) *ui.StreamPrinter {
+	return ui.NewStreamPrinter(&bytes.Buffer{}, ui.WithRawStream(raw))
+}
+
+func TestArtifactStream_DecodesFieldsAcrossChunks(t *testing.T) {
+	params := SubmitPRParams{
+		Title:       `Fix "quoted" title`,
+		Description: "Line one\nTab\there, back\\slash, émoji 😀 and é",
+	}
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+
+	// Every chunk size splits keys, escapes and surrogate pairs somewhere
+	for size := 1; size <= 13; size++ {
+		var raw bytes.Buffer
+		stream := newArtifactStream(newRawPrinter(&raw), "submit_pr", "title", "description")
+		feedInChunks(stream, 0, "submit_pr", string(data), size)
+		assert.Equal(t, params.artifact(), raw.String(), "chunk size %d", size)
+
+		assert.True(t, stream.Finish(params.artifact()))
+		assert.Equal(t, params.artifact()+"\n", raw.String())
+	}
+}
+
+func TestArtifactStream_IgnoresOtherCalls(t *testing.T) {
+	var raw bytes.Buffer
+	stream := newArtifactStream(newRawPrinter(&raw), "submit_report", "content")
+
+	stream.Feed(0, "read_file", `{"content": "not the report"}`)
+	stream.Feed(1, "submit_report", `{"title": "T", "content": "first`)
+	stream.Feed(2, "submit_report", `{"content": "parallel call"}`)
+	stream.EndResponse()
+	stream.Feed(1, "submit_report", `{"content": "resubmitted"}`)
+
+	assert.Equal(t, "first", raw.String())
+}
+
+func TestArtifactStream_Finish(t *testing.T) {
+	t.Run("appends the rest of the artifact", func(t *testing.T) {
+		var raw bytes.Buffer
+		stream := newArtifactStream(newRawPrinter(&raw), "submit_report", "content")
+		stream.Feed(0, "submit_report", `{"content": "# Report"}`)
+
+		assert.True(t, stream.Finish("# Report\n\n## Breaking Changes\n"))
+		assert.Equal(t, "# Report\n\n## Breaking Changes\n", raw.String())
+	})
+
+	t.Run("writes a different artifact after a blank line", func(t *testing.T) {
+		var raw bytes.Buffer
+		stream := newArtifactStream(newRawPrinter(&raw), "submit_report", "content")
+		stream.Feed(0, "submit_report", `{"content": "draft"}`)
+
+		assert.True(t, stream.Finish("final"))
+		assert.Equal(t, "draft\n\nfinal\n", raw.String())
+	})
+
+	t.Run("writes an artifact that was not streamed", func(t *testing.T) {
+		var raw bytes.Buffer
+		stream := newArtifactStream(newRawPrinter(&raw), "submit_report", "content")
+
+		assert.True(t, stream.Finish("partial report\n"))
+		assert.Equal(t, "partial report\n", raw.String())
+	})
+}
+
+func TestArtifactStream_DisabledWithoutRawStream(t *testing.T) {
+	stream := newArtifactStream(ui.NewStreamPrinter(&bytes.Buffer{}), "submit_report", "content")
+	assert.Nil(t, stream)
+
+	stream.Feed(0, "submit_report", `{"content": "x"}`)
+	stream.EndResponse()
+	assert.False(t, stream.Finish("x"))
+}
@@ -0,0 +1,302 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotsDirName is the directory under .gitbuddy-backups holding session snapshots
+	snapshotsDirName = "snapshots"
+	// snapshotManifestName is the manifest file of a snapshot
+	snapshotManifestName = "manifest.json"
+	// rollbackTempSuffix marks restored content staged next to its target
+	rollbackTempSuffix = ".gitbuddy-rollback"
+	// rollbackAsideSuffix marks the current content of a file moved aside
+	// while the rollback replaces it
+	rollbackAsideSuffix = ".gitbuddy-rollback-undo"
+)
+
+// SnapshotEntry is a file captured by a session snapshot
+type SnapshotEntry struct {
+	Path    string `json:"path"`    // Relative to the working directory
+	Existed bool   `json:"existed"` // False if the session created the file
+}
+
+// Snapshot records the original state of every file a session touched
+type Snapshot struct {
+	SessionID    string          `json:"session_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	RolledBackAt *time.Time      `json:"rolled_back_at,omitempty"`
+	Files        []SnapshotEntry `json:"files"`
+}
+
+// SnapshotFile captures the current state of a file before a session first
+// modifies it. Later calls for the same file are no-ops, so the snapshot
+// always holds the state from before the session started editing.
+func (m *BackupManager) SnapshotFile(ctx context.Context, sessionID, filePath string) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("snapshot cancelled: %w", ctx.Err())
+	default:
+	}
+
+	relPath, err := m.relativePath(filePath)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := m.LoadSnapshot(sessionID)
+	if os.IsNotExist(err) {
+		snapshot = &Snapshot{SessionID: sessionID, CreatedAt: time.Now()}
+	} else if err != nil {
+		return err
+	}
+
+	for _, entry := range snapshot.Files {
+		if entry.Path == relPath {
+			return nil
+		}
+	}
+
+	entry := SnapshotEntry{Path: relPath}
+	absPath := filepath.Join(m.workDir, relPath)
+	if stat, err := os.Stat(absPath); err == nil {
+		content, err := os.ReadFile(absPath)
+		if err != nil {
+			return fmt.Errorf("failed to read file for snapshot: %w", err)
+		}
+		backupPath := filepath.Join(m.snapshotDir(sessionID), "files", relPath)
+		if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(backupPath, content, stat.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		entry.Existed = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check file: %w", err)
+	}
+
+	snapshot.Files = append(snapshot.Files, entry)
+	snapshot.UpdatedAt = time.Now()
+	return m.saveSnapshot(snapshot)
+}
+
+// LoadSnapshot loads the snapshot of a session. The error satisfies
+// os.IsNotExist when the session has no snapshot.
+func (m *BackupManager) LoadSnapshot(sessionID string) (*Snapshot, error) {
+	if err := validateSessionID(sessionID); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.snapshotDir(sessionID), snapshotManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots returns all session snapshots, newest first
+func (m *BackupManager) ListSnapshots() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(m.workDir, ".gitbuddy-backups", snapshotsDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		snapshot, err := m.LoadSnapshot(entry.Name())
+		if err != nil {
+			// Skip incomplete snapshots but continue processing
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Rollback restores every file of a session snapshot to its original state and
+// removes files the session created. All restored content is staged first, so
+// nothing is touched if any backup cannot be read, and the current files are
+// moved aside until every file is restored, so they are put back if one of
+// them cannot be replaced.
+func (m *BackupManager) Rollback(ctx context.Context, sessionID string) (*Snapshot, error) {
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("rollback cancelled: %w", ctx.Err())
+	default:
+	}
+
+	snapshot, err := m.LoadSnapshot(sessionID)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot found for session %s", sessionID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Stage restored content next to each target
+	var staged []string
+	cleanup := func() {
+		for _, path := range staged {
+			_ = os.Remove(path)
+		}
+	}
+	for _, entry := range snapshot.Files {
+		if !entry.Existed {
+			continue
+		}
+		backupPath := filepath.Join(m.snapshotDir(sessionID), "files", entry.Path)
+		targetPath := filepath.Join(m.workDir, entry.Path)
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to create directory for %s: %w", entry.Path, err)
+		}
+		tempPath := targetPath + rollbackTempSuffix
+		if err := copyWithMode(backupPath, tempPath); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to stage %s: %w", entry.Path, err)
+		}
+		staged = append(staged, tempPath)
+	}
+
+	// Move the current files aside and the staged files into place; files
+	// the session created are only moved aside
+	type replacement struct {
+		target string
+		aside  string // Empty when the target didn't exist
+	}
+	var replaced []replacement
+	undo := func() {
+		for i := len(replaced) - 1; i >= 0; i-- {
+			if replaced[i].aside == "" {
+				_ = os.Remove(replaced[i].target)
+			} else {
+				_ = os.Rename(replaced[i].aside, replaced[i].target)
+			}
+		}
+		cleanup()
+	}
+	for _, entry := range snapshot.Files {
+		targetPath := filepath.Join(m.workDir, entry.Path)
+		asidePath := targetPath + rollbackAsideSuffix
+		if err := os.Rename(targetPath, asidePath); os.IsNotExist(err) {
+			asidePath = ""
+		} else if err != nil {
+			undo()
+			return nil, fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+		replaced = append(replaced, replacement{target: targetPath, aside: asidePath})
+
+		if !entry.Existed {
+			continue
+		}
+		if err := os.Rename(targetPath+rollbackTempSuffix, targetPath); err != nil {
+			undo()
+			return nil, fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+		}
+	}
+	for _, r := range replaced {
+		if r.aside != "" {
+			_ = os.Remove(r.aside)
+		}
+	}
+
+	now := time.Now()
+	snapshot.RolledBackAt = &now
+	if err := m.saveSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// saveSnapshot writes the manifest atomically
+func (m *BackupManager) saveSnapshot(snapshot *Snapshot) error {
+	if err := validateSessionID(snapshot.SessionID); err != nil {
+		return err
+	}
+
+	dir := m.snapshotDir(snapshot.SessionID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(dir, snapshotManifestName)
+	if err := os.WriteFile(manifestPath+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	if err := os.Rename(manifestPath+".tmp", manifestPath); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return nil
+}
+
+// snapshotDir returns the directory holding a session snapshot
+func (m *BackupManager) snapshotDir(sessionID string) string {
+	return filepath.Join(m.workDir, ".gitbuddy-backups", snapshotsDirName, sessionID)
+}
+
+// relativePath returns filePath relative to the working directory, rejecting
+// paths outside of it
+func (m *BackupManager) relativePath(filePath string) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("file path is required")
+	}
+	absPath := filePath
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(m.workDir, absPath)
+	}
+	relPath, err := filepath.Rel(m.workDir, filepath.Clean(absPath))
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside the working directory: %s", filePath)
+	}
+	return relPath, nil
+}
+
+// validateSessionID rejects IDs that cannot be used as a directory name
+func validateSessionID(sessionID string) error {
+	if sessionID == "" || sessionID == "." || sessionID == ".." || strings.ContainsAny(sessionID, `/\`) {
+		return fmt.Errorf("invalid session ID: %q", sessionID)
+	}
+	return nil
+}
+
+// copyWithMode copies a file, preserving its permissions
+func copyWithMode(src, dst string) error {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, stat.Mode().Perm())
+}
@@ -0,0 +1,125 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupManager_SnapshotAndRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewBackupManager(tmpDir)
+	ctx := context.Background()
+
+	mainFile := filepath.Join(tmpDir, "main.go")
+	nestedFile := filepath.Join(tmpDir, "pkg", "util.go")
+	require.NoError(t, os.WriteFile(mainFile, []byte("original main"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Dir(nestedFile), 0755))
+	require.NoError(t, os.WriteFile(nestedFile, []byte("original util"), 0600))
+
+	// Simulate a session editing two files and creating a third
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-1", mainFile))
+	require.NoError(t, os.WriteFile(mainFile, []byte("edit 1"), 0644))
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-1", "main.go"), "second snapshot of the same file is a no-op")
+	require.NoError(t, os.WriteFile(mainFile, []byte("edit 2"), 0644))
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-1", "pkg/util.go"))
+	require.NoError(t, os.WriteFile(nestedFile, []byte("edited util"), 0600))
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-1", "new.go"))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte("created"), 0644))
+
+	snapshot, err := manager.LoadSnapshot("chat-1")
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotEntry{
+		{Path: "main.go", Existed: true},
+		{Path: filepath.Join("pkg", "util.go"), Existed: true},
+		{Path: "new.go", Existed: false},
+	}, snapshot.Files)
+
+	restored, err := manager.Rollback(ctx, "chat-1")
+	require.NoError(t, err)
+	require.NotNil(t, restored.RolledBackAt)
+
+	content, err := os.ReadFile(mainFile)
+	require.NoError(t, err)
+	assert.Equal(t, "original main", string(content), "state from before the first edit is restored")
+	content, err = os.ReadFile(nestedFile)
+	require.NoError(t, err)
+	assert.Equal(t, "original util", string(content))
+	stat, err := os.Stat(nestedFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	assert.NoFileExists(t, filepath.Join(tmpDir, "new.go"), "files created by the session are removed")
+
+	snapshots, err := manager.ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "chat-1", snapshots[0].SessionID)
+}
+
+func TestBackupManager_RollbackIsAllOrNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewBackupManager(tmpDir)
+	ctx := context.Background()
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+		require.NoError(t, manager.SnapshotFile(ctx, "chat-2", path))
+		require.NoError(t, os.WriteFile(path, []byte("edited"), 0644))
+	}
+
+	// Lose one of the backups
+	require.NoError(t, os.Remove(filepath.Join(manager.snapshotDir("chat-2"), "files", "b.txt")))
+
+	_, err := manager.Rollback(ctx, "chat-2")
+	require.Error(t, err)
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "edited", string(content), "no file is restored when staging fails")
+	assert.NoFileExists(t, filepath.Join(tmpDir, "a.txt"+rollbackTempSuffix))
+}
+
+func TestBackupManager_RollbackUndoesRestoredFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewBackupManager(tmpDir)
+	ctx := context.Background()
+
+	path := filepath.Join(tmpDir, "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-3", path))
+	require.NoError(t, os.WriteFile(path, []byte("edited"), 0644))
+	require.NoError(t, manager.SnapshotFile(ctx, "chat-3", filepath.Join("gen", "out.txt")))
+
+	// The created file can't be removed once its directory became a file
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "gen"), []byte("not a directory"), 0644))
+
+	_, err := manager.Rollback(ctx, "chat-3")
+	require.Error(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", string(content), "the restored file is put back")
+	assert.NoFileExists(t, path+rollbackTempSuffix)
+	assert.NoFileExists(t, path+rollbackAsideSuffix)
+}
+
+func TestBackupManager_SnapshotValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewBackupManager(tmpDir)
+	ctx := context.Background()
+
+	assert.ErrorContains(t, manager.SnapshotFile(ctx, "chat-1", "../outside.txt"), "outside the working directory")
+	assert.ErrorContains(t, manager.SnapshotFile(ctx, "../escape", "a.txt"), "invalid session ID")
+
+	_, err := manager.Rollback(ctx, "missing")
+	assert.ErrorContains(t, err, "no snapshot found")
+
+	snapshots, err := manager.ListSnapshots()
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/apidiff"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// breakingChangesHeading matches a Breaking Changes heading in a PR description
+var breakingChangesHeading = regexp.MustCompile(`(?im)^#{1,6}\s*breaking changes?\s*$`)
+
+// detectBreakingChanges compares the API surface between base and head (the
+// staged changes when head is empty), limited to files when given. Failures
+// only lose the check, so they are logged and otherwise ignored.
+func detectBreakingChanges(ctx context.Context, workDir, base, head string, files []string) []apidiff.Change {
+	if workDir == "" {
+		return nil
+	}
+	changes, err := apidiff.Detect(ctx, workDir, base, head)
+	if err != nil {
+		log.Debug("Failed to compare API surfaces: %v", err)
+		return nil
+	}
+	if len(files) == 0 {
+		return changes
+	}
+
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		wanted[file] = true
+	}
+	var filtered []apidiff.Change
+	for _, change := range changes {
+		if wanted[change.File] {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// BreakingChangeIssues reports breaking API changes as error-severity review issues
+func BreakingChangeIssues(changes []apidiff.Change) []ReviewIssue {
+	issues := make([]ReviewIssue, 0, len(changes))
+	for _, change := range changes {
+		description := fmt.Sprintf("%s %s, which breaks existing callers or clients.", change.Symbol, change.Kind)
+		switch {
+		case change.Before != "" && change.After != "":
+			description += fmt.Sprintf("\nBefore: %s\nAfter:  %s", change.Before, change.After)
+		case change.Before != "":
+			description += "\nBefore: " + change.Before
+		case change.After != "":
+			description += "\nAdded: " + change.After
+		}
+		issues = append(issues, ReviewIssue{
+			Severity:    SeverityError,
+			Category:    CategoryBreakingChange,
+			File:        change.File,
+			Title:       fmt.Sprintf("Breaking API change: %s %s", change.Symbol, change.Kind),
+			Description: description,
+			Suggestion:  "Keep the old API (e.g. deprecate it next to the new one), or release the change as a new major version and note it in the changelog.",
+		})
+	}
+	return issues
+}
+
+// AppendBreakingChanges adds a Breaking Changes section listing changes to a
+// PR description, unless the description already has one
+func AppendBreakingChanges(description string, changes []apidiff.Change) string {
+	if len(changes) == 0 || breakingChangesHeading.MatchString(description) {
+		return description
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(description, "\n"))
+	b.WriteString("\n\n## Breaking Changes\n\n")
+	for _, change := range changes {
+		line := fmt.Sprintf("- `%s` %s", change.Symbol, change.Kind)
+		switch {
+		case change.Before != "" && change.After != "":
+			line += fmt.Sprintf(": `%s` → `%s`", change.Before, change.After)
+		case change.Before != "":
+			line += fmt.Sprintf(": `%s`", change.Before)
+		case change.After != "":
+			line += fmt.Sprintf(": `%s`", change.After)
+		}
+		b.WriteString(line + fmt.Sprintf(" (%s)\n", change.File))
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/apidiff"
+)
+
+var testBreakingChanges = []apidiff.Change{
+	{File: "api/api.go", Symbol: "api.Get", Kind: apidiff.KindChanged, Before: "func(int) string", After: "func(string) string"},
+	{File: "openapi.yaml", Symbol: "DELETE /users/{id}", Kind: apidiff.KindRemoved},
+}
+
+func TestBreakingChangeIssues(t *testing.T) {
+	issues := BreakingChangeIssues(testBreakingChanges)
+	require.Len(t, issues, 2)
+
+	assert.Equal(t, SeverityError, issues[0].Severity)
+	assert.Equal(t, CategoryBreakingChange, issues[0].Category)
+	assert.Equal(t, "api/api.go", issues[0].File)
+	assert.Equal(t, "Breaking API change: api.Get changed", issues[0].Title)
+	assert.Contains(t, issues[0].Description, "Before: func(int) string\nAfter:  func(string) string")
+	assert.Equal(t, "Breaking API change: DELETE /users/{id} removed", issues[1].Title)
+
+	// Breaking changes survive the severity filter
+	assert.Len(t, filterIssuesBySeverity(issues, SeverityError), 2)
+}
+
+func TestAppendBreakingChanges(t *testing.T) {
+	description := AppendBreakingChanges("## Summary\n\nChanges the API.\n", testBreakingChanges)
+	assert.Equal(t, "## Summary\n\nChanges the API.\n\n## Breaking Changes\n\n"+
+		"- `api.Get` changed: `func(int) string` → `func(string) string` (api/api.go)\n"+
+		"- `DELETE /users/{id}` removed (openapi.yaml)\n", description)
+
+	existing := "## Summary\n\n### Breaking changes\n\nNone.\n"
+	assert.Equal(t, existing, AppendBreakingChanges(existing, testBreakingChanges), "an existing section is kept")
+	assert.Equal(t, "text", AppendBreakingChanges("text", nil))
+}
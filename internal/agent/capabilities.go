@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+)
+
+const (
+	// diffLineTokens is a generous estimate of the tokens of a diff line
+	diffLineTokens = 12
+	// minDiffLines is the lowest diff line limit a small context window leads to
+	minDiffLines = 200
+)
+
+// diffLineLimit returns the staged diff lines git_diff_cached returns in full,
+// lowered from maxLines (see tools.NewGitDiffCachedTool) so that the diff
+// takes at most half of the context window of the model
+func diffLineLimit(provider llm.Provider, maxLines int) int {
+	if maxLines < 0 || provider == nil {
+		return maxLines
+	}
+	if maxLines == 0 {
+		maxLines = tools.DefaultMaxDiffLines
+	}
+	window := llm.CapabilitiesOf(provider).MaxContext
+	if window <= 0 {
+		return maxLines
+	}
+	return max(min(maxLines, window/2/diffLineTokens), minDiffLines)
+}
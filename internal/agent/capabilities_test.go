@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+func TestDiffLineLimit(t *testing.T) {
+	gpt4o := &MockLLMProvider{cfg: config.ModelConfig{Provider: "openai", Model: "gpt-4o"}}
+	assert.Equal(t, tools.DefaultMaxDiffLines, diffLineLimit(gpt4o, 0), "large windows keep the limit")
+	assert.Equal(t, 500, diffLineLimit(gpt4o, 500))
+	assert.Equal(t, -1, diffLineLimit(gpt4o, -1))
+
+	small := &MockLLMProvider{cfg: config.ModelConfig{
+		Provider:     "ollama",
+		Model:        "llama3.1",
+		Capabilities: &config.CapabilitiesConfig{MaxContext: 16384},
+	}}
+	assert.Equal(t, 682, diffLineLimit(small, 0))
+	assert.Equal(t, minDiffLines, diffLineLimit(&MockLLMProvider{cfg: config.ModelConfig{Provider: "ollama", Model: "llava"}}, 0))
+
+	assert.Equal(t, tools.DefaultMaxDiffLines, diffLineLimit(&MockLLMProvider{}, 0), "unknown windows keep the limit")
+	assert.Equal(t, 100, diffLineLimit(nil, 100))
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/agent/backup"
 	"github.com/huimingz/gitbuddy-go/internal/agent/session"
 	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
 	"github.com/huimingz/gitbuddy-go/internal/git"
@@ -37,7 +37,8 @@ type ChatResponse struct {
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
-	SessionID        string // Session ID for resuming
+	SessionID        string   // Session ID for resuming
+	ModifiedFiles    []string // Files changed by the session, restorable with `gitbuddy rollback`
 }
 
 // ChatAgentOptions contains configuration for ChatAgent
@@ -52,22 +53,27 @@ type ChatAgentOptions struct {
 	WorkDir         string
 	MaxLinesPerRead int
 	RetryConfig     llm.RetryConfig
+	PromptExtension string // Project-specific guidance appended to the system prompt
+	RepositoryMap   string // Overview of the repository appended to the system prompt
 	SessionManager  *session.Manager
 }
 
 // ChatAgent is an AI agent for interactive chat with tool support
 type ChatAgent struct {
-	options       ChatAgentOptions
-	messages      []*schema.Message
-	toolInstances map[string]interface{}
+	options      ChatAgentOptions
+	messages     []*schema.Message
+	toolRegistry *tools.ToolRegistry
+	fileVersions *FileVersionTracker
+	backups      *backup.BackupManager
+	sessionID    string // Session whose snapshot receives files before they are edited
 }
 
 // NewChatAgent creates a new ChatAgent
 func NewChatAgent(options ChatAgentOptions) *ChatAgent {
 	return &ChatAgent{
-		options:       options,
-		messages:      []*schema.Message{},
-		toolInstances: make(map[string]interface{}),
+		options:      options,
+		messages:     []*schema.Message{},
+		toolRegistry: tools.NewToolRegistry(),
 	}
 }
 
@@ -84,13 +90,9 @@ func (a *ChatAgent) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 
 	// Initialize message history from session if resuming
 	if req.Session != nil && len(req.Session.Messages) > 0 {
+		// Keep tool calls and tool call IDs so the restored history stays valid
 		a.messages = make([]*schema.Message, 0, len(req.Session.Messages))
-		for _, msg := range req.Session.Messages {
-			a.messages = append(a.messages, &schema.Message{
-				Role:    msg.Role,
-				Content: msg.Content,
-			})
-		}
+		a.messages = append(a.messages, req.Session.Messages...)
 	} else {
 		a.messages = []*schema.Message{
 			{
@@ -107,15 +109,13 @@ func (a *ChatAgent) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 	}
 
 	// Initialize tools
+	a.sessionID = req.PreGeneratedSessionID
 	if err := a.initializeTools(ctx, req.WorkDir); err != nil {
 		return nil, fmt.Errorf("failed to initialize tools: %w", err)
 	}
 
-	// Build tool infos for the chat model
-	toolInfos := a.buildToolInfos()
-
 	// Bind tools to chat model
-	if err := chatModel.BindTools(toolInfos); err != nil {
+	if err := chatModel.BindTools(a.toolRegistry.Infos()); err != nil {
 		return nil, fmt.Errorf("failed to bind tools: %w", err)
 	}
 
@@ -131,67 +131,39 @@ func (a *ChatAgent) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 		Content: req.Query,
 	})
 
-	// Run the chat loop
+	// Run the chat loop; the content is forwarded to the callback as it arrives
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:   chatModel,
+		RetryConfig: a.options.RetryConfig,
+		OnContent:   req.OnStreamChunk,
+	})
 	var iterationCount int
-	var promptTokens, completionTokens, totalTokens int
-
 	for iterationCount = 0; iterationCount < maxIterations; iterationCount++ {
-		// Stream LLM response
-		streamReader, err := chatModel.Stream(ctx, a.messages)
-		if err != nil {
-			return nil, fmt.Errorf("failed to stream response: %w", err)
-		}
-
-		if streamReader == nil {
-			break
-		}
-
-		// Collect the response by accumulating all content chunks
-		var responseContent strings.Builder
-		var response *schema.Message
-
-		for {
-			msg, err := streamReader.Recv()
-			if err != nil {
-				break
-			}
-			if msg != nil {
-				response = msg
-				if msg.Content != "" {
-					// Append content to accumulate all chunks
-					responseContent.WriteString(msg.Content)
-					// Call streaming callback if provided
-					if req.OnStreamChunk != nil {
-						req.OnStreamChunk(msg.Content)
-					}
-				}
-			}
+		// Flag read_file results for files changed since they were read
+		if changed := a.fileVersions.MarkStale(a.messages); len(changed) > 0 {
+			a.messages = append(a.messages, StaleFilesNotice(changed))
 		}
 
-		// Close the stream
-		streamReader.Close()
-
-		// Create complete response message with accumulated content
-		if response != nil && responseContent.Len() > 0 {
-			response.Content = responseContent.String()
-			a.messages = append(a.messages, response)
+		var result *StreamResult
+		a.messages, result, err = runner.Stream(ctx, a.messages)
+		if err != nil {
+			return nil, err
 		}
 
-		// If there are no tool calls, the agent has finished
-		if response == nil || len(response.ToolCalls) == 0 {
+		// Without tool calls the agent has finished
+		if len(result.Message.ToolCalls) == 0 {
 			break
 		}
 
-		// Process tool calls (for now, just add them to messages)
-		// Actual tool execution would happen here
-		for _, toolCall := range response.ToolCalls {
+		for _, toolCall := range result.Message.ToolCalls {
 			a.messages = append(a.messages, &schema.Message{
 				Role:       schema.Tool,
-				Content:    "Tool execution result",
+				Content:    a.executeTool(ctx, toolCall),
 				ToolCallID: toolCall.ID,
 			})
 		}
 	}
+	usage := runner.Usage()
 
 	// Compress message history if needed
 	if req.EnableCompression && len(a.messages) > req.CompressionThreshold {
@@ -209,12 +181,8 @@ func (a *ChatAgent) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 			UpdatedAt:      time.Now(),
 			IterationCount: iterationCount,
 			MaxIterations:  maxIterations,
-			TokenUsage: session.TokenUsage{
-				PromptTokens:     promptTokens,
-				CompletionTokens: completionTokens,
-				TotalTokens:      totalTokens,
-			},
-			Metadata: make(map[string]string),
+			TokenUsage:     usage,
+			Metadata:       map[string]string{session.MetadataStatus: session.StatusCompleted},
 		}
 		_ = a.options.SessionManager.Save(sess)
 	}
@@ -228,95 +196,207 @@ func (a *ChatAgent) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse,
 		}
 	}
 
+	var modifiedFiles []string
+	if sessionID != "" {
+		if snapshot, err := a.backups.LoadSnapshot(sessionID); err == nil {
+			for _, entry := range snapshot.Files {
+				modifiedFiles = append(modifiedFiles, entry.Path)
+			}
+		}
+	}
+
 	return &ChatResponse{
 		Response:         finalResponse,
 		MessageCount:     len(a.messages),
 		IterationCount:   iterationCount,
-		PromptTokens:     promptTokens,
-		CompletionTokens: completionTokens,
-		TotalTokens:      totalTokens,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
 		SessionID:        sessionID,
+		ModifiedFiles:    modifiedFiles,
 	}, nil
 }
 
 // initializeTools initializes all available tools for the agent
 func (a *ChatAgent) initializeTools(ctx context.Context, workDir string) error {
-	// File system tools
-	a.toolInstances["read_file"] = tools.NewReadFileTool(workDir, a.options.MaxLinesPerRead)
-	a.toolInstances["list_files"] = tools.NewListFilesTool(workDir, tools.DefaultMaxFiles)
-	a.toolInstances["list_directory"] = tools.NewListDirectoryTool(workDir)
-
-	// File editing tools
-	a.toolInstances["write_file"] = tools.NewWriteFileTool(workDir)
-	a.toolInstances["edit_file"] = tools.NewEditFileTool(workDir)
-	a.toolInstances["append_file"] = tools.NewAppendFileTool(workDir)
-
-	// Search tools
-	a.toolInstances["grep_file"] = tools.NewGrepFileTool(workDir, tools.DefaultMaxResults)
-	a.toolInstances["grep_directory"] = tools.NewGrepDirectoryTool(workDir, tools.DefaultMaxResults, 100, tools.DefaultGrepTimeout)
-
-	// Git tools
-	a.toolInstances["git_status"] = tools.NewGitStatusTool(a.options.GitExecutor)
-	a.toolInstances["git_log"] = tools.NewGitLogTool(a.options.GitExecutor)
-	a.toolInstances["git_show"] = tools.NewGitShowTool(a.options.GitExecutor)
-	a.toolInstances["git_branch"] = tools.NewGitBranchTool(a.options.GitExecutor)
-
-	return nil
-}
-
-// buildToolInfos builds the tool info list for Eino
-func (a *ChatAgent) buildToolInfos() []*schema.ToolInfo {
-	return []*schema.ToolInfo{
-		{
-			Name: "read_file",
-			Desc: "Read file contents",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"file_path":  {Type: schema.String, Desc: "Path to the file", Required: true},
-				"start_line": {Type: schema.Integer, Desc: "Starting line (1-indexed)", Required: false},
-				"end_line":   {Type: schema.Integer, Desc: "Ending line (1-indexed)", Required: false},
-			}),
+	if workDir == "" {
+		workDir = a.options.WorkDir
+	}
+	a.backups = backup.NewBackupManager(workDir)
+
+	readFileTool := tools.NewReadFileTool(workDir, a.options.MaxLinesPerRead)
+	writeFileTool := tools.NewWriteFileTool(workDir)
+	editFileTool := tools.NewEditFileTool(workDir)
+	appendFileTool := tools.NewAppendFileTool(workDir)
+	listFilesTool := tools.NewListFilesTool(workDir, tools.DefaultMaxFiles)
+	grepFileTool := tools.NewGrepFileTool(workDir, tools.DefaultMaxResults)
+	gitStatusTool := tools.NewGitStatusTool(a.options.GitExecutor)
+	gitLogTool := tools.NewGitLogTool(a.options.GitExecutor)
+
+	a.toolRegistry = tools.NewToolRegistry(
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "read_file",
+				Desc: "Read file contents",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path":     {Type: schema.String, Desc: "Path to the file", Required: true},
+					"start_line":    {Type: schema.Integer, Desc: "Starting line (1-indexed)", Required: false},
+					"end_line":      {Type: schema.Integer, Desc: "Ending line (1-indexed)", Required: false},
+					"around_symbol": {Type: schema.String, Desc: "Function, method, type or class to read instead of a line range", Required: false},
+					"head":          {Type: schema.Integer, Desc: "Read only the first N lines", Required: false},
+					"tail":          {Type: schema.Integer, Desc: "Read only the last N lines", Required: false},
+				}),
+			},
+			Execute: func(ctx context.Context, call schema.ToolCall) (string, error) {
+				var params tools.ReadFileParams
+				if err := unmarshalToolArgs(call.Function.Arguments, &params); err != nil {
+					return "", err
+				}
+				result, err := readFileTool.Execute(ctx, &params)
+				if err == nil {
+					a.fileVersions.RecordRead(call.ID, params.FilePath)
+				}
+				return result, err
+			},
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "write_file",
+				Desc: "Create or overwrite a file",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the file", Required: true},
+					"content":   {Type: schema.String, Desc: "File content", Required: true},
+				}),
+			},
+			Execute: a.snapshotFirst(tools.Bind[tools.WriteFileParams](writeFileTool.Execute)),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "edit_file",
+				Desc: "Replace, insert or delete lines in an existing file",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path":  {Type: schema.String, Desc: "Path to the file", Required: true},
+					"operation":  {Type: schema.String, Desc: "One of: replace, insert, delete", Required: true},
+					"start_line": {Type: schema.Integer, Desc: "First line to edit (1-indexed)", Required: true},
+					"end_line":   {Type: schema.Integer, Desc: "Last line to replace or delete (1-indexed, inclusive)", Required: false},
+					"content":    {Type: schema.String, Desc: "New content for replace and insert", Required: false},
+				}),
+			},
+			Execute: a.snapshotFirst(tools.Bind[tools.EditFileParams](editFileTool.Execute)),
 		},
-		{
-			Name: "write_file",
-			Desc: "Create or overwrite a file",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"file_path": {Type: schema.String, Desc: "Path to the file", Required: true},
-				"content":   {Type: schema.String, Desc: "File content", Required: true},
-			}),
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "append_file",
+				Desc: "Append content to the end of a file",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the file", Required: true},
+					"content":   {Type: schema.String, Desc: "Content to append", Required: true},
+				}),
+			},
+			Execute: a.snapshotFirst(tools.Bind[tools.AppendFileParams](appendFileTool.Execute)),
 		},
-		{
-			Name: "list_files",
-			Desc: "List files in a directory",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"directory": {Type: schema.String, Desc: "Directory path", Required: true},
-			}),
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "list_files",
+				Desc: "Find files matching a glob pattern",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"pattern":        {Type: schema.String, Desc: "Glob pattern, e.g. *.go", Required: true},
+					"path":           {Type: schema.String, Desc: "Directory to search in", Required: false},
+					"modified_since": {Type: schema.String, Desc: "Only files modified within a period, e.g. 2 days", Required: false},
+					"larger_than":    {Type: schema.String, Desc: "Only files larger than a size, e.g. 1MB", Required: false},
+					"sort_by":        {Type: schema.String, Desc: "name, mtime (newest first) or size (largest first)", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ListFilesParams](listFilesTool.Execute),
 		},
-		{
-			Name: "grep_file",
-			Desc: "Search for patterns in a file",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"file_path": {Type: schema.String, Desc: "Path to the file", Required: true},
-				"pattern":   {Type: schema.String, Desc: "Search pattern (regex)", Required: true},
-			}),
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "grep_file",
+				Desc: "Search for patterns in a file",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the file", Required: true},
+					"pattern":   {Type: schema.String, Desc: "Search pattern (regex)", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.GrepFileParams](grepFileTool.Execute),
 		},
-		{
-			Name:        "git_status",
-			Desc:        "Show git repository status",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name:        "git_status",
+				Desc:        "Show git repository status",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
+			},
+			Execute: func(ctx context.Context, _ schema.ToolCall) (string, error) {
+				return gitStatusTool.Execute(ctx, nil)
+			},
 		},
-		{
-			Name: "git_log",
-			Desc: "Show git commit history",
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"limit": {Type: schema.Integer, Desc: "Number of commits", Required: false},
-			}),
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "git_log",
+				Desc: "Show git commit history",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"count":  {Type: schema.Integer, Desc: "Number of commits", Required: false},
+					"format": {Type: schema.String, Desc: "text (default) or json with per-file stats", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.GitLogParams](gitLogTool.Execute),
 		},
+	)
+
+	// Keep tracking file versions across turns of the same conversation
+	if a.fileVersions == nil {
+		a.fileVersions = NewFileVersionTracker(workDir)
 	}
+
+	return nil
+}
+
+// executeTool runs a tool call and returns its result, or the error for the model to see
+func (a *ChatAgent) executeTool(ctx context.Context, tc schema.ToolCall) string {
+	result, err := a.toolRegistry.Execute(ctx, tc)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return GuardToolOutput(tc.Function.Name, result)
+}
+
+// snapshotFirst wraps the handler of a file change tool to capture the file
+// in the session snapshot before it is changed
+func (a *ChatAgent) snapshotFirst(next tools.Handler) tools.Handler {
+	return func(ctx context.Context, call schema.ToolCall) (string, error) {
+		var target struct {
+			FilePath string `json:"file_path"`
+		}
+		if err := unmarshalToolArgs(call.Function.Arguments, &target); err != nil {
+			return "", err
+		}
+		if err := a.snapshotBeforeWrite(ctx, target.FilePath); err != nil {
+			return "", err
+		}
+		return next(ctx, call)
+	}
+}
+
+// snapshotBeforeWrite captures a file in the session snapshot before its first
+// modification, so the whole session can be undone with `gitbuddy rollback`
+func (a *ChatAgent) snapshotBeforeWrite(ctx context.Context, filePath string) error {
+	if a.sessionID == "" || a.backups == nil || filePath == "" {
+		return nil
+	}
+	if err := a.backups.SnapshotFile(ctx, a.sessionID, filePath); err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// unmarshalToolArgs decodes tool call arguments; empty arguments leave params at their zero value
+func unmarshalToolArgs(args string, params interface{}) error {
+	return tools.DecodeArgs(args, params)
 }
 
 // getSystemPrompt returns the system prompt for chat
 func (a *ChatAgent) getSystemPrompt(language string) string {
-	return GetChatSystemPrompt(language)
+	return ExtendWithRepositoryMap(ExtendSystemPrompt(GetChatSystemPrompt(language), a.options.PromptExtension), a.options.RepositoryMap)
 }
 
 // compressMessages compresses the message history by keeping recent messages
@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cloudwego/eino/schema"
@@ -18,7 +21,7 @@ func TestChatAgent_NewChatAgent(t *testing.T) {
 	agent := NewChatAgent(options)
 	require.NotNil(t, agent)
 	assert.NotNil(t, agent.messages)
-	assert.NotNil(t, agent.toolInstances)
+	assert.NotNil(t, agent.toolRegistry)
 }
 
 // TestGetMessages tests retrieving message history
@@ -84,3 +87,53 @@ func TestChatRequest_QueryValidation(t *testing.T) {
 		})
 	}
 }
+
+// TestChatAgent_WriteMarksEarlierReadStale tests that writing a file flags earlier reads of it
+func TestChatAgent_WriteMarksEarlierReadStale(t *testing.T) {
+	dir := t.TempDir()
+	agent := NewChatAgent(ChatAgentOptions{Language: "en"})
+	require.NoError(t, agent.initializeTools(context.Background(), dir))
+
+	call := func(id, name, args string) *schema.Message {
+		tc := schema.ToolCall{ID: id, Function: schema.FunctionCall{Name: name, Arguments: args}}
+		return &schema.Message{Role: schema.Tool, ToolCallID: id, Content: agent.executeTool(context.Background(), tc)}
+	}
+
+	agent.messages = append(agent.messages,
+		call("call-1", "write_file", `{"file_path":"notes.txt","content":"first\n"}`),
+		call("call-2", "read_file", `{"file_path":"notes.txt"}`),
+	)
+	assert.Contains(t, agent.messages[1].Content, "first")
+	assert.Empty(t, agent.fileVersions.MarkStale(agent.messages))
+
+	agent.messages = append(agent.messages, call("call-3", "write_file", `{"file_path":"notes.txt","content":"second\n"}`))
+	assert.Equal(t, []string{"notes.txt"}, agent.fileVersions.MarkStale(agent.messages))
+	assert.Contains(t, agent.messages[1].Content, staleReadMarker)
+
+	assert.Contains(t, agent.executeTool(context.Background(), schema.ToolCall{Function: schema.FunctionCall{Name: "nope"}}), "unknown tool")
+}
+
+// TestChatAgent_WriteToolsSnapshotSession tests that edits are captured in the session snapshot
+func TestChatAgent_WriteToolsSnapshotSession(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+
+	agent := NewChatAgent(ChatAgentOptions{Language: "en", WorkDir: dir})
+	agent.sessionID = "chat-test"
+	require.NoError(t, agent.initializeTools(context.Background(), ""))
+
+	for _, tc := range []schema.ToolCall{
+		{ID: "call-1", Function: schema.FunctionCall{Name: "write_file", Arguments: `{"file_path":"main.go","content":"package changed\n"}`}},
+		{ID: "call-2", Function: schema.FunctionCall{Name: "append_file", Arguments: `{"file_path":"main.go","content":"// more\n"}`}},
+		{ID: "call-3", Function: schema.FunctionCall{Name: "write_file", Arguments: `{"file_path":"new.go","content":"package main\n"}`}},
+	} {
+		assert.NotContains(t, agent.executeTool(context.Background(), tc), "Error")
+	}
+
+	_, err := agent.backups.Rollback(context.Background(), "chat-test")
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "new.go"))
+}
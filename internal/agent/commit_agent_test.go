@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/eino/components/model"
@@ -263,6 +264,43 @@ func TestBuildSystemPrompt(t *testing.T) {
 	})
 }
 
+func TestExtendSystemPrompt(t *testing.T) {
+	base := BuildSystemPrompt("en", "")
+
+	t.Run("without extension", func(t *testing.T) {
+		assert.Equal(t, base, ExtendSystemPrompt(base, "  \n"))
+	})
+
+	t.Run("with extension", func(t *testing.T) {
+		prompt := ExtendSystemPrompt(base, "Never suggest panic(), we use errors.Wrap\n")
+		assert.True(t, strings.HasPrefix(prompt, strings.TrimRight(base, "\n")))
+		assert.Contains(t, prompt, "## Project-Specific Guidelines")
+		assert.True(t, strings.HasSuffix(prompt, "Never suggest panic(), we use errors.Wrap\n"))
+	})
+}
+
+func TestExtendWithOwnerConventions(t *testing.T) {
+	base := BuildReviewSystemPrompt("en", "", "", "", "")
+	assert.Equal(t, base, ExtendWithOwnerConventions(base, nil))
+
+	prompt := ExtendWithOwnerConventions(base, map[string]string{
+		"@acme/payments": "Amounts are int64 cents.\n",
+		"@acme/auth":     "Never log tokens.",
+	})
+	assert.Contains(t, prompt, "## Code Owner Conventions")
+	assert.Less(t, strings.Index(prompt, "### @acme/auth"), strings.Index(prompt, "### @acme/payments"), "owners are sorted")
+	assert.True(t, strings.HasSuffix(prompt, "### @acme/payments\n\nAmounts are int64 cents.\n"))
+}
+
+func TestExtendWithRepositoryMap(t *testing.T) {
+	base := GetChatSystemPrompt("en")
+	assert.Equal(t, base, ExtendWithRepositoryMap(base, "\n"))
+
+	prompt := ExtendWithRepositoryMap(base, "- internal/store/ (2 files, package store)\n")
+	assert.Contains(t, prompt, "## Repository Map")
+	assert.True(t, strings.HasSuffix(prompt, "- internal/store/ (2 files, package store)\n"))
+}
+
 // MockGitExecutor is a mock implementation of git.Executor for testing
 type MockGitExecutor struct {
 	DiffCachedResult   string
@@ -280,7 +318,7 @@ func (m *MockGitExecutor) DiffCached(ctx context.Context) (string, error) {
 	return m.DiffCachedResult, m.DiffCachedErr
 }
 
-func (m *MockGitExecutor) DiffBranches(ctx context.Context, base, head string) (string, error) {
+func (m *MockGitExecutor) DiffBranches(ctx context.Context, base, head string, opts git.DiffOptions) (string, error) {
 	return "", nil
 }
 
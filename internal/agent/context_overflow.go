@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+)
+
+const (
+	// overflowKeepTurns is the number of recent assistant turns whose tool results survive eviction
+	overflowKeepTurns = 2
+	// overflowMaxToolResultTokens caps the size of each surviving tool result
+	overflowMaxToolResultTokens = 2000
+	// minOverflowSavings is the minimum number of bytes a truncation must save
+	minOverflowSavings = 256
+)
+
+// recoverContextOverflow handles a context-length error by evicting tool
+// results from the history. It returns the shrunk history and true when a
+// retry is worthwhile; any other error is left to the caller.
+func recoverContextOverflow(err error, messages []*schema.Message, printProgress func(string)) ([]*schema.Message, bool) {
+	if !llm.IsContextLengthError(err) {
+		return messages, false
+	}
+
+	compacted, dropped := evictForContextOverflow(messages, overflowKeepTurns, overflowMaxToolResultTokens)
+	if len(dropped) == 0 {
+		return messages, false
+	}
+
+	printProgress(fmt.Sprintf("Context window exceeded, retrying with a smaller history. Dropped: %s", strings.Join(dropped, "; ")))
+	return compacted, true
+}
+
+// evictForContextOverflow replaces tool results older than the last keepTurns
+// assistant turns with short stubs and truncates the remaining results to
+// maxTokens. Message order and tool call IDs are preserved so the history
+// stays valid for the provider. It returns the new history and a description
+// of what was dropped; the input messages are not modified.
+func evictForContextOverflow(messages []*schema.Message, keepTurns, maxTokens int) ([]*schema.Message, []string) {
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+		}
+	}
+
+	// Tool results before cutoff belong to older turns
+	cutoff := 0
+	turns := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.Assistant && len(messages[i].ToolCalls) > 0 {
+			turns++
+			if turns == keepTurns {
+				cutoff = i
+				break
+			}
+		}
+	}
+
+	result := make([]*schema.Message, len(messages))
+	evicted := make(map[string]int) // tool name -> evicted tokens
+	evictedCount := 0
+	var truncated []string
+
+	for i, msg := range messages {
+		result[i] = msg
+		if msg.Role != schema.Tool {
+			continue
+		}
+
+		name := toolNames[msg.ToolCallID]
+		if name == "" {
+			name = "unknown"
+		}
+		tokens := estimateTokenCount(msg.Content)
+
+		if i < cutoff {
+			stub := fmt.Sprintf("[%s result (~%d tokens) evicted to fit the context window; call the tool again if it is still needed]", name, tokens)
+			if len(stub) >= len(msg.Content) {
+				continue
+			}
+			evicted[name] += tokens
+			evictedCount++
+			copied := *msg
+			copied.Content = stub
+			result[i] = &copied
+			continue
+		}
+
+		if tokens > maxTokens {
+			content := truncateToTokens(msg.Content, maxTokens) +
+				fmt.Sprintf("\n\n[truncated from ~%d tokens to fit the context window]", tokens)
+			if len(msg.Content)-len(content) < minOverflowSavings {
+				continue // Already truncated
+			}
+			copied := *msg
+			copied.Content = content
+			result[i] = &copied
+			truncated = append(truncated, fmt.Sprintf("truncated %s result (~%d -> %d tokens)", name, tokens, maxTokens))
+		}
+	}
+
+	var dropped []string
+	if evictedCount > 0 {
+		names := make([]string, 0, len(evicted))
+		total := 0
+		for name, tokens := range evicted {
+			names = append(names, name)
+			total += tokens
+		}
+		sort.Strings(names)
+		dropped = append(dropped, fmt.Sprintf("evicted %d older tool result(s) from %s (~%d tokens)",
+			evictedCount, strings.Join(names, ", "), total))
+	}
+	dropped = append(dropped, truncated...)
+
+	if len(dropped) == 0 {
+		return messages, nil
+	}
+	return result, dropped
+}
+
+// truncateToTokens cuts text to roughly maxTokens, assuming ~4 characters per token
+func truncateToTokens(text string, maxTokens int) string {
+	runes := []rune(text)
+	limit := maxTokens * 4
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
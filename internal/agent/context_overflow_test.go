@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvictForContextOverflow(t *testing.T) {
+	oldResult := strings.Repeat("o", 4000)  // ~1000 tokens
+	bigResult := strings.Repeat("b", 40000) // ~10000 tokens
+	messages := []*schema.Message{
+		{Role: schema.System, Content: "system"},
+		{Role: schema.User, Content: "investigate"},
+		toolCallMessage("call-1", "grep_directory"),
+		{Role: schema.Tool, ToolCallID: "call-1", Content: oldResult},
+		toolCallMessage("call-2", "list_directory"),
+		{Role: schema.Tool, ToolCallID: "call-2", Content: "a.go"},
+		toolCallMessage("call-3", "read_file"),
+		{Role: schema.Tool, ToolCallID: "call-3", Content: bigResult},
+	}
+
+	compacted, dropped := evictForContextOverflow(messages, 2, 2000)
+	require.Len(t, compacted, len(messages))
+	require.Len(t, dropped, 2)
+	assert.Contains(t, dropped[0], "evicted 1 older tool result(s) from grep_directory (~1000 tokens)")
+	assert.Contains(t, dropped[1], "truncated read_file result (~10000 -> 2000 tokens)")
+
+	assert.Contains(t, compacted[3].Content, "grep_directory result (~1000 tokens) evicted")
+	assert.Equal(t, "call-1", compacted[3].ToolCallID)
+	assert.Equal(t, "a.go", compacted[5].Content, "recent small results are kept")
+	assert.Less(t, len(compacted[7].Content), 8100)
+	assert.Contains(t, compacted[7].Content, "truncated from ~10000 tokens")
+
+	assert.Equal(t, bigResult, messages[7].Content, "input messages are not modified")
+}
+
+func TestRecoverContextOverflow(t *testing.T) {
+	messages := []*schema.Message{
+		{Role: schema.User, Content: "review"},
+		toolCallMessage("call-1", "git_diff_cached"),
+		{Role: schema.Tool, ToolCallID: "call-1", Content: strings.Repeat("d", 40000)},
+	}
+	var progress []string
+	printProgress := func(msg string) { progress = append(progress, msg) }
+
+	_, ok := recoverContextOverflow(errors.New("rate limited"), messages, printProgress)
+	assert.False(t, ok, "other errors are not recovered")
+
+	compacted, ok := recoverContextOverflow(errors.New("maximum context length exceeded"), messages, printProgress)
+	require.True(t, ok)
+	require.Len(t, progress, 1)
+	assert.Contains(t, progress[0], "truncated git_diff_cached result")
+
+	_, ok = recoverContextOverflow(errors.New("maximum context length exceeded"), compacted, printProgress)
+	assert.False(t, ok, "nothing left to drop, so no retry")
+}
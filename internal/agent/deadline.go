@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+)
+
+// Deadline is a wall-clock budget for an agent run (--max-duration). It is
+// checked between iterations, so a model call in flight when it expires
+// still completes. A nil Deadline never expires.
+type Deadline struct {
+	Max time.Duration
+
+	start   time.Time
+	now     func() time.Time
+	wrapped bool
+}
+
+// NewDeadline starts a deadline of max from now, or returns nil if max is not positive
+func NewDeadline(max time.Duration) *Deadline {
+	if max <= 0 {
+		return nil
+	}
+	return &Deadline{Max: max, start: time.Now(), now: time.Now}
+}
+
+// Elapsed returns the time since the deadline was started
+func (d *Deadline) Elapsed() time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.now().Sub(d.start)
+}
+
+// Usage returns the elapsed fraction of the time budget
+func (d *Deadline) Usage() float64 {
+	if d == nil {
+		return 0
+	}
+	return float64(d.Elapsed()) / float64(d.Max)
+}
+
+// Exceeded reports whether the time budget is used up
+func (d *Deadline) Exceeded() bool {
+	return d != nil && d.Elapsed() >= d.Max
+}
+
+// WrapUp reports, once, that the time budget is nearly used up and the
+// agent should submit its result now
+func (d *Deadline) WrapUp() bool {
+	if d == nil || d.wrapped || d.Usage() < BudgetForceReportRatio {
+		return false
+	}
+	d.wrapped = true
+	return true
+}
+
+// Extend adds extra time to the budget and re-arms the wrap-up notice
+func (d *Deadline) Extend(extra time.Duration) {
+	if d == nil {
+		return
+	}
+	d.Max += extra
+	d.wrapped = false
+}
+
+// Err describes the exceeded time budget, the cause of a partial result
+func (d *Deadline) Err() error {
+	return fmt.Errorf("time budget of %s exceeded after %s", d.Max, d.Elapsed().Round(time.Second))
+}
+
+// WrapUpMessage returns the message telling the LLM to call submitTool now
+func (d *Deadline) WrapUpMessage(submitTool string) string {
+	return fmt.Sprintf("⏱️ The time budget of this run is nearly used up (%s of %s). Stop investigating and call %s NOW with what you have; "+
+		"mention anything you could not check.", d.Elapsed().Round(time.Second), d.Max, submitTool)
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDeadline returns a deadline of max whose clock is advanced by moving *now
+func fakeDeadline(max time.Duration, now *time.Time) *Deadline {
+	return &Deadline{Max: max, start: *now, now: func() time.Time { return *now }}
+}
+
+func TestNewDeadline_Unlimited(t *testing.T) {
+	deadline := NewDeadline(0)
+	assert.Nil(t, deadline)
+	assert.False(t, deadline.Exceeded())
+	assert.False(t, deadline.WrapUp())
+	assert.Zero(t, deadline.Usage())
+	deadline.Extend(time.Minute)
+}
+
+func TestDeadline_WrapUpAndExceeded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	deadline := fakeDeadline(10*time.Minute, &now)
+
+	now = now.Add(5 * time.Minute)
+	assert.InDelta(t, 0.5, deadline.Usage(), 0.001)
+	assert.False(t, deadline.WrapUp())
+
+	now = now.Add(4 * time.Minute)
+	assert.True(t, deadline.WrapUp())
+	assert.False(t, deadline.WrapUp(), "the wrap-up notice is returned once")
+	assert.False(t, deadline.Exceeded())
+	assert.Contains(t, deadline.WrapUpMessage("submit_review"), "submit_review")
+	assert.Contains(t, deadline.WrapUpMessage("submit_review"), "9m0s of 10m0s")
+
+	now = now.Add(time.Minute)
+	assert.True(t, deadline.Exceeded())
+	assert.EqualError(t, deadline.Err(), "time budget of 10m0s exceeded after 10m0s")
+
+	deadline.Extend(5 * time.Minute)
+	assert.False(t, deadline.Exceeded())
+	assert.False(t, deadline.WrapUp())
+}
+
+func TestIterationBudget_Deadline(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	budget := NewIterationBudget(100, 0)
+	budget.Deadline = fakeDeadline(10*time.Minute, &now)
+
+	assert.Equal(t, BudgetActionNone, budget.Check(1, 0))
+	now = now.Add(7 * time.Minute)
+	assert.Equal(t, BudgetActionWarn, budget.Check(2, 0))
+	assert.Contains(t, budget.WarningMessage(2, 0, nil), "time 7m0s/10m0s")
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, BudgetActionForceReport, budget.Check(3, 0))
+	now = now.Add(time.Minute)
+	assert.Equal(t, BudgetActionExhausted, budget.Check(4, 0))
+
+	budget.Extend(50)
+	assert.Equal(t, 15*time.Minute, budget.Deadline.Max)
+	assert.Equal(t, BudgetActionNone, budget.Check(5, 0))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
 	"github.com/huimingz/gitbuddy-go/internal/ui"
 )
 
@@ -28,22 +29,28 @@ type MessageModifier func(messages []*schema.Message) []*schema.Message
 
 // DebugRequest contains the input for debugging
 type DebugRequest struct {
-	Issue                  string           // Issue description from user
-	Language               string           // Output language
-	Context                string           // Additional context
-	Files                  []string         // Specific files to investigate
-	WorkDir                string           // Working directory
-	IssuesDir              string           // Directory to save reports
-	MaxLines               int              // Maximum lines per file read
-	MaxIterations          int              // Maximum number of agent iterations
-	Interactive            bool             // Enable interactive feedback
-	EnableCompression      bool             // Enable message history compression
-	CompressionThreshold   int              // Number of messages before compression
-	CompressionKeepRecent  int              // Number of recent messages to keep after compression
-	ShowCompressionSummary bool             // Show compression summary to user
-	MessageModifier        MessageModifier  // Optional message modifier function
-	Session                *session.Session // Optional session to resume from
-	PreGeneratedSessionID  string           // Optional pre-generated session ID
+	Issue                  string            // Issue description from user
+	Language               string            // Output language
+	Context                string            // Additional context
+	Files                  []string          // Specific files to investigate
+	WorkDir                string            // Working directory
+	IssuesDir              string            // Directory to save reports
+	MaxLines               int               // Maximum lines per file read
+	MaxIterations          int               // Maximum number of agent iterations
+	MaxTokens              int               // Token budget for the session (0 = unlimited)
+	MaxDuration            time.Duration     // Wall-clock budget for the run (0 = unlimited)
+	Interactive            bool              // Enable interactive feedback
+	RunTests               bool              // Let the agent run targeted tests with run_command in the verification phase
+	ApprovePlan            bool              // Ask the user to approve the investigation plan before executing it (interactive only)
+	RelatedReports         []*reports.Report // Earlier reports on similar issues to start from
+	EnableCompression      bool              // Enable message history compression
+	CompressionThreshold   int               // Number of messages before compression
+	CompressionKeepRecent  int               // Number of recent messages to keep after compression
+	ShowCompressionSummary bool              // Show compression summary to user
+	ToolResultLimits       ToolResultLimits  // Truncation of long tool results by the default message modifier
+	MessageModifier        MessageModifier   // Optional message modifier function
+	Session                *session.Session  // Optional session to resume from
+	PreGeneratedSessionID  string            // Optional pre-generated session ID
 }
 
 // DebugResponse contains the result of debugging
@@ -51,6 +58,8 @@ type DebugResponse struct {
 	Report           string
 	FilePath         string // Path to saved report file
 	SessionID        string // Session ID for resuming
+	Partial          bool   // True if the report was salvaged after the budget was exhausted
+	Streamed         bool   // True if the report was written to the printer's raw stream
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
@@ -58,18 +67,26 @@ type DebugResponse struct {
 
 // DebugAgentOptions contains configuration for DebugAgent
 type DebugAgentOptions struct {
-	Language        string
-	GitExecutor     git.Executor
-	LLMProvider     llm.Provider
-	Printer         *ui.StreamPrinter
-	Output          io.Writer
-	Input           io.Reader
-	Debug           bool
-	WorkDir         string
-	IssuesDir       string
-	MaxLinesPerRead int
-	RetryConfig     llm.RetryConfig
-	SessionManager  *session.Manager
+	Language             string
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Output               io.Writer
+	Input                io.Reader
+	Debug                bool
+	WorkDir              string
+	IssuesDir            string
+	MaxLinesPerRead      int
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	MaxDiffLines         int    // Staged diff lines above which git_diff_cached returns per-file statistics (0 = default, negative = no limit)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
+	RepositoryMap        string // Overview of the repository appended to the system prompt
+	SessionManager       *session.Manager
+	TestCommands         []string      // Command prefixes run_command accepts with RunTests (default: tools.DefaultTestCommands)
+	CommandTimeout       time.Duration // Timeout of a run_command call (default: tools.DefaultCommandTimeout)
+	Notifier             *ui.Notifier  // Announces questions to the user once the run has taken long (optional)
+	DisableAnswerReuse   bool          // Always ask request_feedback questions, without reusing earlier answers
 }
 
 // DebugPhase represents the current phase of the debugging process
@@ -120,6 +137,15 @@ func NewExecutionPlan() *ExecutionPlan {
 	}
 }
 
+// NewTaskPlan creates an execution plan that tracks tasks without debugging phases
+func NewTaskPlan() *ExecutionPlan {
+	return &ExecutionPlan{
+		Tasks:        []PlanTask{},
+		PhaseHistory: []PhaseTransition{},
+		LastUpdated:  time.Now(),
+	}
+}
+
 // TransitionToPhase transitions to a new debugging phase
 func (p *ExecutionPlan) TransitionToPhase(newPhase string, reason string) {
 	phase := DebugPhase(newPhase)
@@ -145,6 +171,25 @@ func (p *ExecutionPlan) GetCurrentPhase() string {
 	return string(p.CurrentPhase)
 }
 
+// PhaseSequence returns the phases entered so far, in order
+func (p *ExecutionPlan) PhaseSequence() []string {
+	if len(p.PhaseHistory) == 0 {
+		if p.CurrentPhase == "" {
+			return nil
+		}
+		return []string{string(p.CurrentPhase)}
+	}
+
+	var phases []string
+	if from := p.PhaseHistory[0].FromPhase; from != "" {
+		phases = append(phases, string(from))
+	}
+	for _, transition := range p.PhaseHistory {
+		phases = append(phases, string(transition.ToPhase))
+	}
+	return phases
+}
+
 // GetPhaseDescription returns a human-readable description of the current phase
 func (p *ExecutionPlan) GetPhaseDescription() string {
 	descriptions := map[DebugPhase]string{
@@ -208,8 +253,10 @@ func (p *ExecutionPlan) GetSummary() string {
 	var summary strings.Builder
 
 	// Show current phase
-	summary.WriteString(p.GetPhaseDescription())
-	summary.WriteString("\n\n")
+	if p.CurrentPhase != "" {
+		summary.WriteString(p.GetPhaseDescription())
+		summary.WriteString("\n\n")
+	}
 
 	if len(p.Tasks) == 0 {
 		summary.WriteString("No tasks defined yet.")
@@ -387,21 +434,6 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		log.Debug(msg)
 	}
 
-	printToolCall := func(name string) {
-		if printer != nil {
-			_ = printer.PrintToolCall(name, nil)
-		}
-		log.Debug("Tool call: %s", name)
-	}
-
-	printToolResult := func(name string, result string) {
-		if printer != nil {
-			bytes := len(result)
-			tokens := estimateTokenCount(result)
-			_ = printer.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
-		}
-	}
-
 	printInfo := func(msg string) {
 		if printer != nil {
 			_ = printer.PrintInfo(msg)
@@ -438,6 +470,18 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		return nil, fmt.Errorf("chat model is nil (provider: %s)", providerName)
 	}
 
+	// Adapt the session to what the model supports
+	capabilities := llm.CapabilitiesOf(a.opts.LLMProvider)
+	if req.Interactive && !capabilities.Tools {
+		// Feedback and plan approval rely on the model calling tools reliably
+		printInfo(fmt.Sprintf("⚠️  %s has no reliable tool calling, interactive mode is disabled (set capabilities.tools in the model configuration to override)", modelName))
+		req.Interactive = false
+		req.ApprovePlan = false
+	}
+	if req.MaxTokens > 0 && !capabilities.StreamUsage {
+		log.Warn("%s may not report token usage, the token budget of %d tokens may not be enforced", modelName, req.MaxTokens)
+	}
+
 	// Create tools
 	workDir := req.WorkDir
 	if workDir == "" {
@@ -465,12 +509,19 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 
 	// Git tools
 	gitStatusTool := tools.NewGitStatusTool(a.opts.GitExecutor)
-	gitDiffCachedTool := tools.NewGitDiffCachedTool(a.opts.GitExecutor)
+	gitDiffCachedTool := tools.NewGitDiffCachedTool(a.opts.GitExecutor, diffLineLimit(a.opts.LLMProvider, a.opts.MaxDiffLines))
 	gitLogTool := tools.NewGitLogTool(a.opts.GitExecutor)
 	gitShowTool := tools.NewGitShowTool(a.opts.GitExecutor)
+	gitRevListCountTool := tools.NewGitRevListCountTool(a.opts.GitExecutor)
+	gitMergeTreeTool := tools.NewGitMergeTreeTool(a.opts.GitExecutor)
 
 	// Interactive and reporting tools
-	requestFeedbackTool := tools.NewRequestFeedbackTool(a.opts.Input, a.opts.Output)
+	// Earlier answers are reused when the agent repeats a question
+	var feedbackHistory *tools.FeedbackHistory
+	if !a.opts.DisableAnswerReuse {
+		feedbackHistory = tools.NewFeedbackHistory()
+	}
+	requestFeedbackTool := tools.NewRequestFeedbackTool(a.opts.Input, a.opts.Output, feedbackHistory)
 	submitReportTool := tools.NewSubmitReportTool(issuesDir)
 
 	// Execution plan and phase management tools
@@ -478,6 +529,14 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 	updateExecutionPlanTool := tools.NewUpdateExecutionPlanTool(executionPlan)
 	transitionPhaseTool := tools.NewTransitionPhaseTool(executionPlan)
 
+	// Test tools, only with RunTests
+	var testVerifier *testVerification
+	var fileOutlineTool *tools.FileOutlineTool
+	if req.RunTests {
+		testVerifier = newTestVerification(workDir, a.opts.TestCommands, a.opts.CommandTimeout, executionPlan)
+		fileOutlineTool = tools.NewFileOutlineTool(workDir)
+	}
+
 	// Define tool schemas
 	toolInfos := []*schema.ToolInfo{
 		{
@@ -494,19 +553,27 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			Name: "list_files",
 			Desc: listFilesTool.Description(),
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"pattern":      {Type: schema.String, Desc: "Glob pattern to match files (e.g., '*.go', '**/*.py')", Required: true},
-				"path":         {Type: schema.String, Desc: "Base path to search from", Required: true},
-				"exclude_dirs": {Type: schema.Array, Desc: "Directories to exclude (e.g., ['node_modules', '.git'])", Required: false},
-				"max_results":  {Type: schema.Integer, Desc: "Maximum number of results", Required: false},
+				"pattern":        {Type: schema.String, Desc: "Glob pattern to match files (e.g., '*.go', '**/*.py')", Required: true},
+				"path":           {Type: schema.String, Desc: "Base path to search from", Required: true},
+				"exclude_dirs":   {Type: schema.Array, Desc: "Directories to exclude (e.g., ['node_modules', '.git'])", Required: false},
+				"max_results":    {Type: schema.Integer, Desc: "Maximum number of results", Required: false},
+				"modified_since": {Type: schema.String, Desc: "Only files modified within a period or since a date (e.g., '2 days', '3h', '2024-01-15')", Required: false},
+				"larger_than":    {Type: schema.String, Desc: "Only files larger than a size (e.g., '1MB', '500KB')", Required: false},
+				"smaller_than":   {Type: schema.String, Desc: "Only files smaller than a size", Required: false},
+				"content_type":   {Type: schema.String, Desc: "Only 'text' or 'binary' files", Required: false},
+				"sort_by":        {Type: schema.String, Desc: "Sort by 'name' (default), 'mtime' (newest first) or 'size' (largest first)", Required: false},
 			}),
 		},
 		{
 			Name: "read_file",
 			Desc: readFileTool.Description(),
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"file_path":  {Type: schema.String, Desc: "Path to the file to read", Required: true},
-				"start_line": {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
-				"end_line":   {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+				"file_path":     {Type: schema.String, Desc: "Path to the file to read", Required: true},
+				"start_line":    {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
+				"end_line":      {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+				"around_symbol": {Type: schema.String, Desc: "Function, method, type or class to read, e.g. \"Execute\" or \"ReadFileTool.Execute\"", Required: false},
+				"head":          {Type: schema.Integer, Desc: "Read only the first N lines", Required: false},
+				"tail":          {Type: schema.Integer, Desc: "Read only the last N lines", Required: false},
 			}),
 		},
 		{
@@ -527,8 +594,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 				"directory":      {Type: schema.String, Desc: "Path to the directory to search", Required: true},
 				"pattern":        {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+				"patterns":       {Type: schema.Array, Desc: "More patterns; a line matching any pattern matches", Required: false},
+				"not_pattern":    {Type: schema.String, Desc: "Leave out lines that also match this pattern", Required: false},
 				"recursive":      {Type: schema.Boolean, Desc: "Search subdirectories recursively", Required: false},
 				"file_pattern":   {Type: schema.String, Desc: "Glob pattern to filter files (e.g., '*.go')", Required: false},
+				"exclude_paths":  {Type: schema.Array, Desc: "Globs of files or directories to skip (e.g., ['*_test.go', 'testdata'])", Required: false},
 				"ignore_case":    {Type: schema.Boolean, Desc: "Perform case-insensitive search", Required: false},
 				"before_context": {Type: schema.Integer, Desc: "Number of lines to show before each match", Required: false},
 				"after_context":  {Type: schema.Integer, Desc: "Number of lines to show after each match", Required: false},
@@ -542,9 +612,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
 		},
 		{
-			Name:        "git_diff_cached",
-			Desc:        gitDiffCachedTool.Description(),
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
+			Name: "git_diff_cached",
+			Desc: gitDiffCachedTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"path": {Type: schema.String, Desc: "Show only the diff of this file or directory (optional)", Required: false},
+			}),
 		},
 		{
 			Name: "git_log",
@@ -552,6 +624,7 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 				"max_count": {Type: schema.Integer, Desc: "Maximum number of commits to show", Required: false},
 				"since":     {Type: schema.String, Desc: "Show commits more recent than a specific date", Required: false},
+				"format":    {Type: schema.String, Desc: "Output format: text (default) or json with changed files and insert/delete counts", Required: false},
 			}),
 		},
 		{
@@ -561,6 +634,22 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 				"commit": {Type: schema.String, Desc: "Commit hash or reference to show", Required: true},
 			}),
 		},
+		{
+			Name: "git_rev_list_count",
+			Desc: gitRevListCountTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"base": {Type: schema.String, Desc: "Branch to compare against (e.g., origin/main)", Required: true},
+				"head": {Type: schema.String, Desc: "Branch to measure (defaults to HEAD)", Required: false},
+			}),
+		},
+		{
+			Name: "git_merge_tree",
+			Desc: gitMergeTreeTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"base": {Type: schema.String, Desc: "Branch to merge into (e.g., origin/main)", Required: true},
+				"head": {Type: schema.String, Desc: "Branch to merge (defaults to HEAD)", Required: false},
+			}),
+		},
 		{
 			Name: "submit_report",
 			Desc: submitReportTool.Description(),
@@ -590,6 +679,23 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		printInfo("ℹ️  Non-interactive mode - Agent will work autonomously without requesting feedback")
 	}
 
+	if testVerifier != nil {
+		toolInfos = append(toolInfos, &schema.ToolInfo{
+			Name: "file_outline",
+			Desc: fileOutlineTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"file_path": {Type: schema.String, Desc: "Path to the source file", Required: true},
+			}),
+		}, &schema.ToolInfo{
+			Name: "run_command",
+			Desc: testVerifier.command.Description() + "\nOnly available in the verification phase.",
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"command": {Type: schema.String, Desc: "Test command to run, e.g. go test ./pkg/ -run TestName", Required: true},
+			}),
+		})
+		printInfo("🧪 Test execution enabled - Agent can run targeted tests in the verification phase")
+	}
+
 	// Add execution plan tool
 	toolInfos = append(toolInfos, &schema.ToolInfo{
 		Name: "update_execution_plan",
@@ -617,6 +723,8 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		return nil, fmt.Errorf("failed to bind tools: %w", err)
 	}
 
+	fileVersions := NewFileVersionTracker(workDir)
+
 	// Format request parameters for prompt
 	filesStr := ""
 	if len(req.Files) > 0 {
@@ -624,7 +732,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 	}
 
 	// Build system prompt
-	systemPrompt := BuildDebugSystemPrompt(req.Language, req.Context, req.Issue, filesStr)
+	systemPrompt := ExtendSystemPrompt(BuildDebugSystemPrompt(req.Language, req.Context, req.Issue, filesStr), a.opts.PromptExtension)
+	systemPrompt = ExtendWithRepositoryMap(systemPrompt, a.opts.RepositoryMap)
+	if testVerifier != nil {
+		systemPrompt = ExtendWithTestVerification(systemPrompt, a.opts.TestCommands)
+	}
 	printInfo("Starting debugging session...")
 
 	// Initial messages
@@ -635,6 +747,13 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 	if req.Context != "" {
 		userMessage += fmt.Sprintf("\n\nAdditional context: %s", req.Context)
 	}
+	if related := relatedReportsContext(req.RelatedReports); related != "" {
+		userMessage += "\n\n" + related
+	}
+	if req.Interactive && req.ApprovePlan {
+		userMessage += "\n\nThe investigation plan will be shown to me for approval when you leave the investigation plan phase. " +
+			"Keep each task concrete so I can decide which ones are worth running."
+	}
 
 	messages := []*schema.Message{
 		{Role: schema.System, Content: systemPrompt},
@@ -653,12 +772,13 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 	if req.MessageModifier == nil {
 		// Create a default modifier chain that:
 		// 1. Adds progress context to help LLM understand where it is
-		// 2. Summarizes very long tool results
+		// 2. Truncates very long tool results
 		// 3. Deduplicates consecutive identical messages
-		req.MessageModifier = MessageModifierChain(
-			SummarizeToolResults(5000), // Limit tool results to 5000 chars
-			DeduplicateMessages(),
-		)
+		truncate, err := TruncateToolResults(req.ToolResultLimits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool result limits: %w", err)
+		}
+		req.MessageModifier = MessageModifierChain(truncate, DeduplicateMessages())
 	}
 
 	// Initialize session management
@@ -671,13 +791,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		currentSession = req.Session
 		sessionID = currentSession.ID
 
-		// Restore execution plan and messages from session if available
-		if len(currentSession.ExecutionPlan) > 0 {
-			if err := json.Unmarshal(currentSession.ExecutionPlan, executionPlan); err != nil {
-				log.Debug("Failed to restore execution plan from session: %v", err)
-			} else {
-				printProgress("Restored execution plan from session")
-			}
+		// Restore the execution plan, the phase and the test runs from the session
+		if restored, err := restoreDebugState(currentSession, executionPlan, testVerifier); err != nil {
+			log.Debug("Failed to restore execution plan from session: %v", err)
+		} else if restored {
+			printProgress(fmt.Sprintf("Restored execution plan from session (phase: %s, %d tasks)", executionPlan.GetCurrentPhase(), len(executionPlan.Tasks)))
 		}
 
 		// Use messages from session if available
@@ -711,6 +829,9 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			Metadata:       make(map[string]string),
 		}
 
+		// Previous sessions for the same issue are matched by this description
+		currentSession.Metadata[debugIssueMetadataKey] = req.Issue
+
 		// Store request as JSON
 		reqBytes, err := json.Marshal(req)
 		if err != nil {
@@ -723,8 +844,192 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 		iterationCount = 0
 	}
 
+	// Token usage breakdown per tool and phase, restored when resuming
+	if currentSession.Metadata == nil {
+		currentSession.Metadata = make(map[string]string)
+	}
+	tokenBreakdown := LoadTokenBreakdown(currentSession.Metadata, messages)
+	defer printTokenBreakdown(printer, tokenBreakdown)
+
+	// Describe the investigation in the front matter of the saved report
+	submitReportTool.SetMetadata(func() reports.Metadata {
+		return reports.Metadata{
+			Issue:   currentSession.Metadata[debugIssueMetadataKey],
+			Session: sessionID,
+			Files:   fileVersions.Paths(),
+			Phases:  executionPlan.PhaseSequence(),
+		}
+	})
+
+	// The plan approval gate, already passed when resuming an approved session
+	var planApproval *PlanApprovalGate
+	if req.Interactive && req.ApprovePlan {
+		planApproval = NewPlanApprovalGate(a.opts.Input, a.opts.Output, currentSession.Metadata[planApprovalMetadataKey] == "true")
+	}
+
+	if req.Interactive && feedbackHistory != nil {
+		feedbackHistory.LoadMessages(messages, "")
+		if n := a.loadPreviousFeedback(feedbackHistory, currentSession.Metadata[debugIssueMetadataKey], sessionID); n > 0 {
+			printProgress(fmt.Sprintf("Loaded %d answer(s) from previous sessions for this issue", n))
+		}
+	}
+
+	// Adaptive iteration budget
+	budget := NewIterationBudget(maxIterations, req.MaxTokens)
+	if iterationCount >= budget.MaxIterations {
+		// Resumed session already used its budget; grant a fresh batch
+		budget.Extend(budget.MaxIterations)
+		maxIterations = budget.MaxIterations
+	}
+	budget.Deadline = NewDeadline(req.MaxDuration)
+
+	artifact := newArtifactStream(printer, "submit_report", "content")
+
+	// partialResponse builds a partial report from the work done so far
+	partialResponse := func(reason string) (*DebugResponse, error) {
+		printProgress(fmt.Sprintf("Generating partial report: %s", reason))
+		report := buildPartialDebugReport(req.Issue, reason, executionPlan, messages)
+		if testVerifier != nil {
+			report = testVerifier.AddToReport(report)
+		}
+		var filePath string
+		if saved, err := submitReportTool.Save(&tools.SubmitReportParams{
+			Title:   "Partial report " + req.Issue,
+			Content: report,
+		}); err != nil {
+			log.Debug("Failed to save partial report: %v", err)
+		} else {
+			filePath = saved.FilePath
+		}
+
+		if currentSession != nil {
+			currentSession.SetStatus(session.StatusPartial)
+		}
+		a.saveDebugSession(currentSession, messages, iterationCount, maxIterations, promptTokens, completionTokens, totalTokens, executionPlan, testVerifier)
+
+		return &DebugResponse{
+			Report:           report,
+			FilePath:         filePath,
+			SessionID:        sessionID,
+			Partial:          true,
+			Streamed:         artifact.Finish(report),
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      totalTokens,
+		}, nil
+	}
+
 	// Agent loop
 	lastPlanSnapshot := executionPlan.Clone().(*ExecutionPlan)
+	planTracker := NewPlanTracker(executionPlan)
+	planShown := false
+
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+		// Add progress context before applying the request's modifier
+		MessageModifier: func(messages []*schema.Message) []*schema.Message {
+			progressModifier := CreateProgressContextModifier(executionPlan, iterationCount, maxIterations)
+			return MessageModifierChain(progressModifier, req.MessageModifier)(messages)
+		},
+		Artifact: artifact,
+		Phase:    executionPlan.GetCurrentPhase,
+		Usage:    currentSession.TokenUsage,
+	})
+	defer runner.PrintDiagnostics()
+	runner.Register("list_directory", tools.Bind[tools.ListDirectoryParams](listDirectoryTool.Execute))
+	runner.Register("list_files", tools.Bind[tools.ListFilesParams](listFilesTool.Execute))
+	runner.Register("read_file", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		var params tools.ReadFileParams
+		if err := unmarshalToolArgs(call.Function.Arguments, &params); err != nil {
+			return "", err
+		}
+		result, err := readFileTool.Execute(ctx, &params)
+		if err == nil {
+			fileVersions.RecordRead(call.ID, params.FilePath)
+		}
+		return result, err
+	})
+	runner.Register("grep_file", tools.Bind[tools.GrepFileParams](grepFileTool.Execute))
+	runner.Register("grep_directory", tools.Bind[tools.GrepDirectoryParams](grepDirectoryTool.Execute))
+	runner.Register("git_status", func(ctx context.Context, _ schema.ToolCall) (string, error) {
+		return gitStatusTool.Execute(ctx, nil)
+	})
+	runner.Register("git_diff_cached", tools.Bind[tools.GitDiffCachedParams](gitDiffCachedTool.Execute))
+	runner.Register("git_log", tools.Bind[tools.GitLogParams](gitLogTool.Execute))
+	runner.Register("git_show", tools.Bind[tools.GitShowParams](gitShowTool.Execute))
+	runner.Register("git_rev_list_count", tools.Bind[tools.GitRevListCountParams](gitRevListCountTool.Execute))
+	runner.Register("git_merge_tree", tools.Bind[tools.GitMergeTreeParams](gitMergeTreeTool.Execute))
+	runner.Register("file_outline", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		if testVerifier == nil {
+			return "", fmt.Errorf("file_outline is not available; tests can't be run in this session")
+		}
+		return tools.Bind[tools.FileOutlineParams](fileOutlineTool.Execute)(ctx, call)
+	})
+	runner.Register("run_command", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		if testVerifier == nil {
+			return "", fmt.Errorf("run_command is not available; tests can't be run in this session")
+		}
+		var params tools.RunCommandParams
+		if err := unmarshalToolArgs(call.Function.Arguments, &params); err != nil {
+			return "", err
+		}
+		printInfo("$ " + params.Command)
+		return testVerifier.Execute(ctx, &params)
+	})
+	runner.Register("request_feedback", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		if !req.Interactive {
+			return "", fmt.Errorf("interactive mode is not enabled")
+		}
+		return tools.Bind[tools.RequestFeedbackParams](func(ctx context.Context, params *tools.RequestFeedbackParams) (string, error) {
+			a.opts.Notifier.Notify("gitbuddy debug needs your feedback", params.Title)
+			return requestFeedbackTool.Execute(ctx, params)
+		})(ctx, call)
+	})
+	runner.Register("update_execution_plan", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		result, err := tools.Bind[tools.UpdateExecutionPlanParams](updateExecutionPlanTool.Execute)(ctx, call)
+		if err == nil {
+			// Show plan changes compactly instead of the whole plan
+			for _, change := range executionPlan.GetChanges(lastPlanSnapshot) {
+				printInfo(change)
+			}
+			lastPlanSnapshot = executionPlan.Clone().(*ExecutionPlan)
+		}
+		return result, err
+	})
+	runner.Register("transition_phase", func(ctx context.Context, call schema.ToolCall) (string, error) {
+		var params tools.TransitionPhaseParams
+		if err := unmarshalToolArgs(call.Function.Arguments, &params); err != nil {
+			return "", err
+		}
+		var result string
+		var err error
+		if planApproval.Required(executionPlan, params.NewPhase) {
+			var approved bool
+			var note string
+			a.opts.Notifier.Notify("gitbuddy debug needs your approval", "Review the investigation plan")
+			approved, note, err = planApproval.Review(ctx, executionPlan)
+			switch {
+			case err != nil:
+			case approved:
+				currentSession.Metadata[planApprovalMetadataKey] = "true"
+				result, err = transitionPhaseTool.Execute(ctx, &params)
+				result = note + "\n\n" + result
+			default:
+				result = note
+			}
+		} else {
+			result, err = transitionPhaseTool.Execute(ctx, &params)
+		}
+		if err == nil {
+			planTracker.Observe(executionPlan)
+			printInfo(executionPlan.GetPhaseDescription())
+			emitProgress(printer, ui.ProgressEvent{Event: ui.EventPhase, Phase: executionPlan.GetCurrentPhase()})
+		}
+		return result, err
+	})
 
 	for {
 		// Check if context was cancelled (e.g., due to Ctrl+C)
@@ -742,23 +1047,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 					TotalTokens:      totalTokens,
 				}
 
-				// Store execution plan
-				planBytes, err := json.Marshal(executionPlan)
-				if err != nil {
-					log.Debug("Failed to marshal execution plan: %v", err)
-				} else {
-					currentSession.ExecutionPlan = planBytes
-				}
-
-				// Store phase history
-				phaseHistoryBytes, err := json.Marshal(executionPlan.PhaseHistory)
-				if err != nil {
-					log.Debug("Failed to marshal phase history: %v", err)
-				} else {
-					currentSession.PhaseHistory = phaseHistoryBytes
-				}
+				// Store the execution plan and the test runs
+				storeDebugState(currentSession, executionPlan, testVerifier)
 
 				// Save session on cancellation
+				currentSession.SetStatus(session.StatusInterrupted)
 				if err := a.opts.SessionManager.Save(currentSession); err != nil {
 					log.Debug("Failed to save session on cancellation: %v", err)
 				} else {
@@ -778,154 +1071,81 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 
 		iterationCount++
 
-		// Check if we've exceeded max iterations
-		if iterationCount > maxIterations {
-			printProgress(fmt.Sprintf("Reached maximum iterations (%d)", maxIterations))
+		// Check the budget: warn, force reporting, or stop when exhausted
+		switch budget.Check(iterationCount, totalTokens) {
+		case BudgetActionExhausted:
+			printProgress(fmt.Sprintf("Reached analysis budget (iterations: %d/%d, tokens: %d)", iterationCount-1, budget.MaxIterations, totalTokens))
 
 			// Ask user if they want to continue (only in interactive mode)
-			if req.Interactive {
-				fmt.Fprintf(a.opts.Output, "\n")
-				shouldContinue, err := ui.ConfirmWithDefault("Continue debugging for another batch of iterations?", false, a.opts.Input, a.opts.Output)
-				if err != nil || !shouldContinue {
-					return nil, fmt.Errorf("debugging stopped after %d iterations", iterationCount-1)
+			if !req.Interactive {
+				if budget.Deadline.Exceeded() {
+					return partialResponse(budget.Deadline.Err().Error())
 				}
-				// Reset max iterations for another batch
-				maxIterations = iterationCount + 30
-				printProgress("Continuing debugging session...")
-			} else {
-				return nil, fmt.Errorf("agent loop exceeded maximum iterations (%d)", maxIterations)
+				return partialResponse(fmt.Sprintf("analysis budget exhausted after %d iterations", iterationCount-1))
 			}
-		}
-
-		// Display execution plan at the start of each iteration (every 3 iterations or when changed)
-		if iterationCount == 1 || iterationCount%3 == 0 {
-			changes := executionPlan.GetChanges(lastPlanSnapshot)
-			if len(changes) > 0 || iterationCount == 1 {
-				printExecutionPlan(executionPlan)
-				lastPlanSnapshot = executionPlan.Clone().(*ExecutionPlan)
+			fmt.Fprintf(a.opts.Output, "\n")
+			shouldContinue, err := ui.ConfirmWithDefault("Continue debugging for another batch of iterations?", false, a.opts.Input, a.opts.Output)
+			if err != nil || !shouldContinue {
+				return partialResponse(fmt.Sprintf("debugging stopped after %d iterations", iterationCount-1))
 			}
-		}
+			// Extend the budget for another batch
+			budget.Extend(30)
+			maxIterations = budget.MaxIterations
+			printProgress("Continuing debugging session...")
 
-		printProgress(fmt.Sprintf("Agent iteration %d...", iterationCount))
+		case BudgetActionWarn:
+			printProgress(fmt.Sprintf("%.0f%% of the analysis budget used, asking the agent to wrap up", budget.Usage(iterationCount, totalTokens)*100))
+			messages = append(messages, &schema.Message{
+				Role:    schema.User,
+				Content: budget.WarningMessage(iterationCount, totalTokens, executionPlan),
+			})
 
-		// Apply message modifier with progress context (similar to Eino's MessageModifier)
-		messagesToSend := messages
-		if req.MessageModifier != nil {
-			// Add progress context before applying user's modifier
-			progressModifier := CreateProgressContextModifier(executionPlan, iterationCount, maxIterations)
-			combinedModifier := MessageModifierChain(progressModifier, req.MessageModifier)
-			messagesToSend = combinedModifier(messages)
-			log.Debug("MessageModifier applied, messages count: %d -> %d", len(messages), len(messagesToSend))
+		case BudgetActionForceReport:
+			printProgress("Analysis budget nearly exhausted, switching to reporting phase")
+			executionPlan.TransitionToPhase(string(PhaseReporting), "analysis budget nearly exhausted")
+			emitProgress(printer, ui.ProgressEvent{Event: ui.EventPhase, Phase: executionPlan.GetCurrentPhase(), Message: "analysis budget nearly exhausted"})
+			messages = append(messages, &schema.Message{
+				Role:    schema.User,
+				Content: budget.ForceReportMessage(),
+			})
 		}
 
-		// Stream LLM response with retry
-		streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
-			return chatModel.Stream(ctx, messagesToSend)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("LLM stream failed: %w", err)
+		// Flag read_file results for files changed since they were read
+		if changed := fileVersions.MarkStale(messages); len(changed) > 0 {
+			printProgress(fmt.Sprintf("Files changed since last read: %s", strings.Join(changed, ", ")))
+			runner.Invalidate("read_file")
+			messages = append(messages, StaleFilesNotice(changed))
 		}
 
-		var fullContent strings.Builder
-		var toolCalls []*schema.ToolCall
-		var toolArgStarted bool
-
-		printInfo("LLM Response:")
-		if printer != nil {
-			_ = printer.Newline()
+		// Show the full plan once, then a compact status line per iteration
+		if !planShown {
+			printExecutionPlan(executionPlan)
+			planShown = true
 		}
+		printProgress(planTracker.StatusLine(executionPlan, tokenBreakdown, iterationCount, maxIterations))
+		emitProgress(printer, ui.ProgressEvent{Event: ui.EventIteration, Iteration: iterationCount, MaxIterations: maxIterations, Phase: executionPlan.GetCurrentPhase()})
 
-		// Read stream
-		for {
-			chunk, err := streamReader.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				streamReader.Close()
-				return nil, fmt.Errorf("stream read error: %w", err)
-			}
-
-			if chunk.Content != "" {
-				fullContent.WriteString(chunk.Content)
-				if printer != nil {
-					_ = printer.PrintLLMContent(chunk.Content)
-				}
-			}
-
-			// Collect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				for _, tc := range chunk.ToolCalls {
-					idx := 0
-					if tc.Index != nil {
-						idx = *tc.Index
-					}
-
-					for len(toolCalls) <= idx {
-						toolCalls = append(toolCalls, &schema.ToolCall{Function: schema.FunctionCall{}})
-					}
-
-					if tc.ID != "" {
-						toolCalls[idx].ID = tc.ID
-					}
-
-					if tc.Function.Name != "" {
-						if toolCalls[idx].Function.Name == "" {
-							printToolCall(tc.Function.Name)
-							if printer != nil {
-								_ = printer.PrintToolArgStart()
-							}
-							toolArgStarted = true
-						}
-						toolCalls[idx].Function.Name = tc.Function.Name
-					}
-					if tc.Function.Arguments != "" {
-						toolCalls[idx].Function.Arguments += tc.Function.Arguments
-						if printer != nil && toolArgStarted {
-							_ = printer.PrintToolArgChunk(tc.Function.Arguments)
-						}
-					}
-				}
-			}
-
-			// Collect token usage
-			if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
-				usage := chunk.ResponseMeta.Usage
-				promptTokens += usage.PromptTokens
-				completionTokens += usage.CompletionTokens
-				totalTokens += usage.TotalTokens
-			}
-		}
-		streamReader.Close()
-
-		if printer != nil {
-			_ = printer.Newline()
+		var result *StreamResult
+		messages, result, err = runner.Stream(ctx, messages)
+		if err != nil {
+			return nil, err
 		}
+		usage := runner.Usage()
+		promptTokens, completionTokens, totalTokens = usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens
 
-		// Add assistant message to history
-		var toolCallsValue []schema.ToolCall
-		for _, tc := range toolCalls {
-			if tc != nil {
-				toolCallsValue = append(toolCallsValue, *tc)
-			}
-		}
-		assistantMsg := &schema.Message{
-			Role:      schema.Assistant,
-			Content:   fullContent.String(),
-			ToolCalls: toolCallsValue,
-		}
-		messages = append(messages, assistantMsg)
+		tokenBreakdown.RecordLLMCall(executionPlan.GetCurrentPhase(), result.Sent, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+		tokenBreakdown.StoreIn(currentSession.Metadata)
 
 		// Process tool calls - use intelligent fallback if no tools called
-		if len(toolCalls) == 0 {
-			if err := HandleNoToolCallsResponse(fullContent.String(), "debug"); err != nil {
+		if len(result.Message.ToolCalls) == 0 {
+			if err := HandleNoToolCallsResponse(result.Message.Content, "debug"); err != nil {
 				return nil, err
 			}
 			// If we reach here, the response was accepted without tools (should rarely happen for debug)
 			return nil, fmt.Errorf("debug agent requires systematic analysis using tools")
 		}
 
-		for _, tc := range toolCalls {
+		for _, tc := range result.Message.ToolCalls {
 			if tc.Function.Name == "" {
 				continue
 			}
@@ -935,19 +1155,19 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 				var params tools.SubmitReportParams
 				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
 					log.Debug("Failed to parse submit_report arguments: %v", err)
+					messages = append(messages, toolReply(tc, fmt.Sprintf("Error: invalid parameters: %v", err)))
 					continue
 				}
 
-				// Execute submit_report to save the report
-				result, err := submitReportTool.Execute(ctx, &params)
-				if err != nil {
-					return nil, fmt.Errorf("failed to submit report: %w", err)
+				// Add the outcomes of the tests run during verification
+				if testVerifier != nil {
+					params.Content = testVerifier.AddToReport(params.Content)
 				}
 
-				// Parse result to get file path
-				var reportResult tools.DebugReport
-				if err := json.Unmarshal([]byte(result), &reportResult); err != nil {
-					log.Debug("Failed to parse report result: %v", err)
+				// Save the report
+				reportResult, err := submitReportTool.Save(&params)
+				if err != nil {
+					return nil, fmt.Errorf("failed to submit report: %w", err)
 				}
 
 				printSuccess("Debugging session completed successfully")
@@ -963,23 +1183,11 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 						TotalTokens:      totalTokens,
 					}
 
-					// Store execution plan
-					planBytes, err := json.Marshal(executionPlan)
-					if err != nil {
-						log.Debug("Failed to marshal execution plan: %v", err)
-					} else {
-						currentSession.ExecutionPlan = planBytes
-					}
-
-					// Store phase history
-					phaseHistoryBytes, err := json.Marshal(executionPlan.PhaseHistory)
-					if err != nil {
-						log.Debug("Failed to marshal phase history: %v", err)
-					} else {
-						currentSession.PhaseHistory = phaseHistoryBytes
-					}
+					// Store the execution plan and the test runs
+					storeDebugState(currentSession, executionPlan, testVerifier)
 
 					// Save final session
+					currentSession.SetStatus(session.StatusCompleted)
 					if err := a.opts.SessionManager.Save(currentSession); err != nil {
 						log.Debug("Failed to save final session: %v", err)
 					} else {
@@ -991,138 +1199,18 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 					Report:           params.Content,
 					FilePath:         reportResult.FilePath,
 					SessionID:        sessionID,
+					Streamed:         artifact.Finish(params.Content),
 					PromptTokens:     promptTokens,
 					CompletionTokens: completionTokens,
 					TotalTokens:      totalTokens,
 				}, nil
 			}
 
-			// Execute other tools
-			var result string
-			var toolErr error
-
-			switch tc.Function.Name {
-			case "list_directory":
-				var params tools.ListDirectoryParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = listDirectoryTool.Execute(ctx, &params)
-				}
-
-			case "list_files":
-				var params tools.ListFilesParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = listFilesTool.Execute(ctx, &params)
-				}
-
-			case "read_file":
-				var params tools.ReadFileParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = readFileTool.Execute(ctx, &params)
-				}
-
-			case "grep_file":
-				var params tools.GrepFileParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = grepFileTool.Execute(ctx, &params)
-				}
-
-			case "grep_directory":
-				var params tools.GrepDirectoryParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = grepDirectoryTool.Execute(ctx, &params)
-				}
-
-			case "git_status":
-				result, toolErr = gitStatusTool.Execute(ctx, nil)
-
-			case "git_diff_cached":
-				result, toolErr = gitDiffCachedTool.Execute(ctx, nil)
-
-			case "git_log":
-				var params tools.GitLogParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = gitLogTool.Execute(ctx, &params)
-				}
-
-			case "git_show":
-				var params tools.GitShowParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = gitShowTool.Execute(ctx, &params)
-				}
-
-			case "request_feedback":
-				if !req.Interactive {
-					toolErr = fmt.Errorf("interactive mode is not enabled")
-				} else {
-					var params tools.RequestFeedbackParams
-					if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-						toolErr = fmt.Errorf("invalid parameters: %w", err)
-					} else {
-						result, toolErr = requestFeedbackTool.Execute(ctx, &params)
-					}
-				}
-
-			case "update_execution_plan":
-				var params tools.UpdateExecutionPlanParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = updateExecutionPlanTool.Execute(ctx, &params)
-				}
-
-			case "transition_phase":
-				var params tools.TransitionPhaseParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = transitionPhaseTool.Execute(ctx, &params)
-				}
-
-			default:
-				toolErr = fmt.Errorf("unknown tool: %s", tc.Function.Name)
-			}
-
-			// Build tool result message
-			var toolResult string
-			if toolErr != nil {
-				toolResult = fmt.Sprintf("Error: %v", toolErr)
-				log.Debug("Tool %s error: %v", tc.Function.Name, toolErr)
-			} else {
-				toolResult = result
-				printToolResult(tc.Function.Name, result)
-			}
-
-			// Add tool result to messages
-			messages = append(messages, &schema.Message{
-				Role:       schema.Tool,
-				Content:    toolResult,
-				ToolCallID: tc.ID,
-			})
-
-			// Display execution plan after certain tool executions
-			if toolErr == nil {
-				if tc.Function.Name == "update_execution_plan" {
-					// Plan was updated, show the changes
-					printExecutionPlan(executionPlan)
-				} else if tc.Function.Name == "transition_phase" {
-					// Phase transitioned, show the new phase and plan
-					printExecutionPlan(executionPlan)
-				}
+			reply, err := runner.Execute(ctx, tc)
+			if err != nil {
+				return partialResponse(err.Error())
 			}
+			messages = append(messages, reply)
 		}
 
 		// Compress message history if enabled and threshold is reached
@@ -1145,8 +1233,9 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 			}
 		}
 
-		// Update session with current state periodically (every few iterations)
-		if a.opts.SessionManager != nil && currentSession != nil && iterationCount%3 == 0 {
+		// Save the session every few iterations and checkpoint it in between,
+		// so a crash loses at most the current iteration
+		if a.opts.SessionManager != nil && currentSession != nil {
 			currentSession.Messages = messages
 			currentSession.IterationCount = iterationCount
 			currentSession.MaxIterations = maxIterations
@@ -1156,32 +1245,85 @@ func (a *DebugAgent) Debug(ctx context.Context, req DebugRequest) (*DebugRespons
 				TotalTokens:      totalTokens,
 			}
 
-			// Store execution plan
-			planBytes, err := json.Marshal(executionPlan)
-			if err != nil {
-				log.Debug("Failed to marshal execution plan: %v", err)
-			} else {
-				currentSession.ExecutionPlan = planBytes
-			}
-
-			// Store phase history
-			phaseHistoryBytes, err := json.Marshal(executionPlan.PhaseHistory)
-			if err != nil {
-				log.Debug("Failed to marshal phase history: %v", err)
-			} else {
-				currentSession.PhaseHistory = phaseHistoryBytes
-			}
+			// Store the execution plan and the test runs
+			storeDebugState(currentSession, executionPlan, testVerifier)
 
-			// Save session
-			if err := a.opts.SessionManager.Save(currentSession); err != nil {
+			currentSession.SetStatus(session.StatusRunning)
+			if iterationCount%3 != 0 {
+				if err := a.opts.SessionManager.Checkpoint(currentSession); err != nil {
+					log.Debug("Failed to checkpoint session: %v", err)
+				}
+			} else if err := a.opts.SessionManager.Save(currentSession); err != nil {
 				log.Debug("Failed to save session: %v", err)
 			} else {
 				log.Debug("Session %s saved at iteration %d", sessionID, iterationCount)
 			}
 		}
 	}
+}
+
+// saveDebugSession persists the current debug state to the session manager
+func (a *DebugAgent) saveDebugSession(sess *session.Session, messages []*schema.Message, iterationCount, maxIterations, promptTokens, completionTokens, totalTokens int, plan *ExecutionPlan, tests *testVerification) {
+	if a.opts.SessionManager == nil || sess == nil {
+		return
+	}
+
+	sess.Messages = messages
+	sess.IterationCount = iterationCount
+	sess.MaxIterations = maxIterations
+	sess.TokenUsage = session.TokenUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      totalTokens,
+	}
+
+	storeDebugState(sess, plan, tests)
+
+	if err := a.opts.SessionManager.Save(sess); err != nil {
+		log.Debug("Failed to save session: %v", err)
+	}
+}
+
+// buildPartialDebugReport assembles a partial report from the execution plan
+// and the agent's findings when the loop ends without a submitted report
+func buildPartialDebugReport(issue, reason string, plan *ExecutionPlan, messages []*schema.Message) string {
+	const maxFindings = 5
+
+	var sb strings.Builder
+	sb.WriteString("# Partial Debug Report\n\n")
+	sb.WriteString(fmt.Sprintf("> ⚠️ This report is partial: %s. The findings below were collected before the analysis stopped.\n\n", reason))
+
+	sb.WriteString("## Problem Description\n\n")
+	sb.WriteString(issue)
+	sb.WriteString("\n\n")
+
+	if plan != nil {
+		sb.WriteString("## Progress\n\n")
+		sb.WriteString(plan.GetSummary())
+		sb.WriteString("\n\n")
+	}
+
+	var findings []string
+	for i := len(messages) - 1; i >= 0 && len(findings) < maxFindings; i-- {
+		msg := messages[i]
+		if msg.Role == schema.Assistant && strings.TrimSpace(msg.Content) != "" {
+			findings = append([]string{strings.TrimSpace(msg.Content)}, findings...)
+		}
+	}
+
+	sb.WriteString("## Findings So Far\n\n")
+	if len(findings) == 0 {
+		sb.WriteString("No findings were recorded.\n")
+	} else {
+		for _, finding := range findings {
+			sb.WriteString(finding)
+			sb.WriteString("\n\n")
+		}
+	}
 
-	return nil, fmt.Errorf("agent loop exceeded maximum iterations")
+	sb.WriteString("## Unresolved Items\n\n")
+	sb.WriteString("The root cause has not been confirmed. Resume the session or rerun with a larger budget to continue the investigation.\n")
+	return sb.String()
 }
 
 // compressMessageHistoryWithLLM uses LLM to intelligently compress old message history
@@ -1294,7 +1436,7 @@ func compressMessageHistoryWithLLM(ctx context.Context, chatModel interface{}, m
 			if err == io.EOF {
 				break
 			}
-			return nil, "", fmt.Errorf("stream read error: %w", err)
+			return nil, "", llm.ExplainError("stream read error", err)
 		}
 
 		// Extract content from chunk
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+const (
+	// debugIssueMetadataKey stores the issue description in debug session metadata
+	debugIssueMetadataKey = "issue"
+	// maxFeedbackSessions is the number of recent sessions searched for earlier answers
+	maxFeedbackSessions = 20
+)
+
+// loadPreviousFeedback adds the answers given in recent debug sessions for a
+// similar issue to history and returns the number of answers found
+func (a *DebugAgent) loadPreviousFeedback(history *tools.FeedbackHistory, issue, currentID string) int {
+	if a.opts.SessionManager == nil || issue == "" {
+		return 0
+	}
+
+	infos, err := a.opts.SessionManager.List(session.Filter{AgentType: "debug"})
+	if err != nil {
+		log.Debug("Failed to list sessions for earlier answers: %v", err)
+		return 0
+	}
+
+	found, searched := 0, 0
+	for _, info := range infos {
+		if info.ID == currentID {
+			continue
+		}
+		if searched++; searched > maxFeedbackSessions {
+			break
+		}
+
+		sess, err := a.opts.SessionManager.Load(info.ID)
+		if err != nil {
+			log.Debug("Failed to load session %s: %v", info.ID, err)
+			continue
+		}
+		if tools.TextSimilarity(issue, sess.Metadata[debugIssueMetadataKey]) < tools.DefaultFeedbackSimilarity {
+			continue
+		}
+		found += history.LoadMessages(sess.Messages, sess.ID)
+	}
+	return found
+}
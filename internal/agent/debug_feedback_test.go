@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveFeedbackSession(t *testing.T, mgr *session.Manager, id, agentType, issue string) {
+	t.Helper()
+
+	require.NoError(t, mgr.Save(&session.Session{
+		ID:        id,
+		AgentType: agentType,
+		CreatedAt: time.Now(),
+		Metadata:  map[string]string{debugIssueMetadataKey: issue},
+		Messages: []*schema.Message{
+			{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{
+				ID:       "call-1",
+				Function: schema.FunctionCall{Name: "request_feedback", Arguments: `{"title":"Log File Path","prompt":"Please provide the log file path"}`},
+			}}},
+			{Role: schema.Tool, ToolCallID: "call-1", Content: `{"user_response":"/var/log/` + id + `.log"}`},
+		},
+	}))
+}
+
+func TestDebugAgent_LoadPreviousFeedback(t *testing.T) {
+	mgr := session.NewManager(t.TempDir())
+	saveFeedbackSession(t, mgr, "debug-same", "debug", "Login fails with 500 error")
+	saveFeedbackSession(t, mgr, "debug-other", "debug", "Memory leak in background worker")
+	saveFeedbackSession(t, mgr, "debug-current", "debug", "Login fails with 500 error")
+	saveFeedbackSession(t, mgr, "chat-same", "chat", "Login fails with 500 error")
+
+	a := &DebugAgent{opts: DebugAgentOptions{SessionManager: mgr}}
+	history := tools.NewFeedbackHistory()
+
+	n := a.loadPreviousFeedback(history, "login fails with a 500 error", "debug-current")
+	assert.Equal(t, 1, n)
+
+	answer, _ := history.Find(&tools.RequestFeedbackParams{Title: "Log File Path", Prompt: "Please provide the log file path"})
+	require.NotNil(t, answer)
+	assert.Equal(t, "debug-same", answer.Source)
+	assert.Equal(t, "/var/log/debug-same.log", answer.Answer)
+
+	assert.Zero(t, a.loadPreviousFeedback(tools.NewFeedbackHistory(), "", "debug-current"), "sessions without an issue are not matched")
+}
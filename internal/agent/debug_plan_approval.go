@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+)
+
+// planApprovalMetadataKey records in the session that the user approved the
+// investigation plan, so a resumed session doesn't ask again
+const planApprovalMetadataKey = "plan_approved"
+
+// Choices offered when the investigation plan is presented
+const (
+	planChoiceApprove = iota
+	planChoiceSkip
+	planChoiceRevise
+)
+
+// PlanApprovalGate makes the user approve the investigation plan before the
+// debug agent starts executing it. The user can approve it, skip expensive
+// tasks up front, or send it back with feedback.
+type PlanApprovalGate struct {
+	feedback *tools.RequestFeedbackTool
+	approved bool
+}
+
+// NewPlanApprovalGate creates a gate asking on input and output. Answers are
+// never reused from the feedback history, since each plan is new.
+func NewPlanApprovalGate(input io.Reader, output io.Writer, approved bool) *PlanApprovalGate {
+	return &PlanApprovalGate{
+		feedback: tools.NewRequestFeedbackTool(input, output, nil),
+		approved: approved,
+	}
+}
+
+// Approved reports whether the user approved the plan
+func (g *PlanApprovalGate) Approved() bool {
+	return g != nil && g.approved
+}
+
+// Required reports whether moving plan to newPhase needs the user's approval:
+// entering execution, or leaving the investigation plan for a later phase
+func (g *PlanApprovalGate) Required(plan *ExecutionPlan, newPhase string) bool {
+	if g == nil || g.approved {
+		return false
+	}
+	switch DebugPhase(newPhase) {
+	case PhaseExecution:
+		return true
+	case PhaseVerification, PhaseReporting:
+		return plan.CurrentPhase == PhaseInvestigationPlan
+	}
+	return false
+}
+
+// Review presents the plan and returns whether the user approved it, and a
+// note for the model describing the outcome
+func (g *PlanApprovalGate) Review(ctx context.Context, plan *ExecutionPlan) (bool, string, error) {
+	if len(openTasks(plan)) == 0 {
+		return false, "The user must approve the investigation plan before it is executed, but the plan has no open tasks. " +
+			"Add the investigation tasks with update_execution_plan, then call transition_phase again to present the plan.", nil
+	}
+
+	_, choice, err := g.feedback.Ask(ctx, &tools.RequestFeedbackParams{
+		Title:   "Approve the investigation plan",
+		Content: plan.GetSummary(),
+		Prompt:  "The investigation starts once you approve the plan",
+		Options: []string{
+			"Approve and start the investigation",
+			"Skip some tasks, then start",
+			"Send the plan back with feedback",
+		},
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	switch choice {
+	case planChoiceSkip:
+		answer, _, err := g.feedback.Ask(ctx, &tools.RequestFeedbackParams{
+			Title:   "Skip tasks",
+			Content: plan.GetSummary(),
+			Prompt:  "Numbers of the tasks to skip, separated by commas (e.g. 2,4)",
+		})
+		if err != nil {
+			return false, "", err
+		}
+		skipped := skipTasks(plan, answer)
+		g.approved = true
+		if len(skipped) == 0 {
+			return true, "The user approved the investigation plan.", nil
+		}
+		return true, fmt.Sprintf("The user approved the investigation plan but skipped these tasks; don't investigate them:\n- %s", strings.Join(skipped, "\n- ")), nil
+
+	case planChoiceRevise:
+		answer, _, err := g.feedback.Ask(ctx, &tools.RequestFeedbackParams{
+			Title:   "Plan feedback",
+			Content: plan.GetSummary(),
+			Prompt:  "What should change in the plan?",
+		})
+		if err != nil {
+			return false, "", err
+		}
+		if answer == "" {
+			answer = "(no details given)"
+		}
+		return false, fmt.Sprintf("The user did not approve the investigation plan. Their feedback: %s\n"+
+			"Revise the plan with update_execution_plan, then call transition_phase again to present it.", answer), nil
+	}
+
+	g.approved = true
+	return true, "The user approved the investigation plan.", nil
+}
+
+// openTasks returns the tasks that are neither completed nor skipped
+func openTasks(plan *ExecutionPlan) []PlanTask {
+	var open []PlanTask
+	for _, task := range plan.Tasks {
+		if task.Status == "pending" || task.Status == "in_progress" {
+			open = append(open, task)
+		}
+	}
+	return open
+}
+
+// skipTasks marks the open tasks with the given 1-based numbers (as shown
+// in the plan summary) skipped and returns their descriptions
+func skipTasks(plan *ExecutionPlan, numbers string) []string {
+	var skipped []string
+	for _, field := range strings.FieldsFunc(numbers, func(r rune) bool { return r == ',' || r == ' ' }) {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 || n > len(plan.Tasks) {
+			continue
+		}
+		task := plan.Tasks[n-1]
+		if task.Status != "pending" && task.Status != "in_progress" {
+			continue
+		}
+		plan.UpdateTask(task.ID, "skipped")
+		skipped = append(skipped, task.Description)
+	}
+	return skipped
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPlanForApproval() *ExecutionPlan {
+	plan := NewExecutionPlan()
+	plan.TransitionToPhase(string(PhaseInvestigationPlan), "hypotheses ready")
+	plan.AddTask("logs", "Check the service logs")
+	plan.AddTask("trace", "Trace the request through the cache")
+	plan.AddTask("bisect", "Bisect the last 200 commits")
+	return plan
+}
+
+// newGate answers the gate's questions with input, one byte at a time so
+// successive prompts each read their own line
+func newGate(input string) *PlanApprovalGate {
+	return NewPlanApprovalGate(iotest.OneByteReader(strings.NewReader(input)), &bytes.Buffer{}, false)
+}
+
+func TestPlanApprovalGate_Required(t *testing.T) {
+	plan := newPlanForApproval()
+	gate := newGate("")
+
+	assert.True(t, gate.Required(plan, "execution"))
+	assert.True(t, gate.Required(plan, "reporting"), "skipping execution still needs approval")
+	assert.False(t, gate.Required(plan, "root_cause_hypothesis"), "going back doesn't")
+
+	plan.TransitionToPhase(string(PhaseRootCauseHypothesis), "")
+	assert.False(t, gate.Required(plan, "reporting"))
+
+	var disabled *PlanApprovalGate
+	assert.False(t, disabled.Required(plan, "execution"))
+	assert.False(t, NewPlanApprovalGate(nil, nil, true).Required(plan, "execution"), "approved in a resumed session")
+}
+
+func TestPlanApprovalGate_Review(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("approve", func(t *testing.T) {
+		plan := newPlanForApproval()
+		gate := newGate("1\n")
+		approved, note, err := gate.Review(ctx, plan)
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.True(t, gate.Approved())
+		assert.Contains(t, note, "approved")
+		assert.False(t, gate.Required(plan, "execution"))
+	})
+
+	t.Run("skip tasks", func(t *testing.T) {
+		plan := newPlanForApproval()
+		gate := newGate("2\n3, 9, x\n")
+		approved, note, err := gate.Review(ctx, plan)
+		require.NoError(t, err)
+		assert.True(t, approved)
+		assert.Contains(t, note, "Bisect the last 200 commits")
+		assert.NotContains(t, note, "Check the service logs")
+		assert.Equal(t, "skipped", plan.Tasks[2].Status)
+		assert.Equal(t, "pending", plan.Tasks[0].Status)
+	})
+
+	t.Run("revise", func(t *testing.T) {
+		plan := newPlanForApproval()
+		gate := newGate("3\nDrop the bisect, check the config first\n")
+		approved, note, err := gate.Review(ctx, plan)
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.False(t, gate.Approved())
+		assert.Contains(t, note, "Drop the bisect, check the config first")
+		assert.Equal(t, PhaseInvestigationPlan, plan.CurrentPhase)
+	})
+
+	t.Run("no open tasks", func(t *testing.T) {
+		plan := NewExecutionPlan()
+		approved, note, err := newGate("").Review(ctx, plan)
+		require.NoError(t, err)
+		assert.False(t, approved)
+		assert.Contains(t, note, "update_execution_plan")
+	})
+}
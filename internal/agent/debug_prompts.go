@@ -21,7 +21,7 @@ You MUST use it liberally throughout the debugging process:
 You have access to powerful tools to explore the codebase:
 - **File System Tools**: list_directory, list_files, read_file
 - **Search Tools**: grep_file, grep_directory
-- **Git Tools**: git_status, git_diff, git_log, git_show
+- **Git Tools**: git_status, git_diff, git_log, git_show, git_rev_list_count, git_merge_tree
 - **Interactive Tools**: 
   * **request_feedback** (🚨 USE THIS LIBERALLY - ask user for direction and gather critical information)
 - **Planning Tools**: 
@@ -266,13 +266,14 @@ Use **request_feedback** proactively in these situations:
 ### When to Use Each Tool
 
 - **list_directory**: Explore project structure, understand organization
-- **list_files**: Find files by pattern (*.go, *_test.go, etc.)
+- **list_files**: Find files by pattern (*.go, *_test.go, etc.); use modified_since/sort_by "mtime" to find recently changed files and larger_than to spot suspiciously large ones
 - **grep_directory**: Search for function/variable usage across files
 - **grep_file**: Search within a specific file
-- **read_file**: Read source code for detailed analysis
-- **git_log**: Check recent changes, find related commits
+- **read_file**: Read source code for detailed analysis (use around_symbol to read a single function by name, or head/tail for the start or end of a file)
+- **git_log**: Check recent changes, find related commits (format "json" lists the files each commit changed)
 - **git_diff**: See what changed in specific commits
 - **git_show**: View complete commit details
+- **git_rev_list_count** / **git_merge_tree**: Check how far a branch diverged from another and which files would conflict when merging
 - **request_feedback**: Gather information, validate findings, get direction
 - **update_execution_plan**: Add/update/remove tasks, mark progress
 - **transition_phase**: Move to next phase when current phase is complete
@@ -385,3 +386,15 @@ Respond in {{.Language}} language. All analysis, explanations, and the final rep
 - Call submit_report
 
 Begin your investigation now! Start with Phase 1: Problem Definition.`
+
+// DebugTestVerificationPrompt is appended to the debug system prompt when the
+// agent may run tests in the verification phase
+const DebugTestVerificationPrompt = `## Test Execution in Phase 6 (Verification)
+
+In this session you can run tests with **run_command** to check the root cause against the real behavior of the code. It is only available in Phase 6 (Verification), and only accepts commands starting with: %s.
+
+1. Select the tests that cover the suspected root cause instead of running the whole suite: find the tests of the affected functions with **grep_directory** (e.g. the function name in *_test.go files), and list the test functions of a test file with **file_outline**
+2. Run them as narrowly as possible, e.g. ` + "`go test ./internal/auth/ -run TestLogin`" + `; a handful of runs is enough
+3. Read the output: a failing test that matches the symptoms confirms the root cause; passing tests show what is not covered and belong in the recommended tests
+4. In the report's Verification section, state which tests you ran, whether they passed, and what that means for the root cause. The commands and their exit codes are added to that section automatically; don't claim results you didn't get
+`
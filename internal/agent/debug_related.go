@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+)
+
+// relatedReportsContext introduces earlier reports on similar issues in the
+// initial message, so a recurring problem starts from what was already found
+func relatedReportsContext(related []*reports.Report) string {
+	if len(related) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Earlier investigations that may be related:\n")
+	for _, report := range related {
+		fmt.Fprintf(&b, "\n### #%03d %s (%s)\nReport: %s\n", report.ID, report.Title, report.Date, report.Path)
+		if len(report.Files) > 0 {
+			fmt.Fprintf(&b, "Files investigated: %s\n", strings.Join(report.Files, ", "))
+		}
+		if summary := reports.Summary(report); summary != "" {
+			b.WriteString(summary + "\n")
+		}
+	}
+	b.WriteString("\nFirst check whether this is the same problem. If it is, verify the earlier findings still hold instead of re-investigating from scratch; read the full report with read_file if needed.")
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelatedReportsContext(t *testing.T) {
+	assert.Empty(t, relatedReportsContext(nil))
+
+	context := relatedReportsContext([]*reports.Report{{
+		ID:       2,
+		Path:     "issues/issue-002-login-fails-2026-10-01.md",
+		Metadata: reports.Metadata{Title: "Login fails", Date: "2026-10-01", Files: []string{"auth.go"}},
+		Body:     "# Login fails\n\n## Summary\nThe token cache expired early.\n",
+	}})
+	assert.Contains(t, context, "### #002 Login fails (2026-10-01)\nReport: issues/issue-002-login-fails-2026-10-01.md\n")
+	assert.Contains(t, context, "Files investigated: auth.go\nThe token cache expired early.\n")
+	assert.Contains(t, context, "verify the earlier findings")
+}
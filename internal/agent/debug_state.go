@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// debugState is the state of a debug session saved to resume it
+type debugState struct {
+	Plan     *ExecutionPlan        `json:"plan"`
+	TestRuns []tools.CommandResult `json:"test_runs,omitempty"`
+}
+
+// storeDebugState stores the execution plan and the test runs of a debug
+// session in sess. tests is nil when the session runs no tests.
+func storeDebugState(sess *session.Session, plan *ExecutionPlan, tests *testVerification) {
+	state := debugState{Plan: plan}
+	if tests != nil {
+		state.TestRuns = tests.runs
+	}
+	if err := sess.SetState(state); err != nil {
+		log.Debug("Failed to store the debug session state: %v", err)
+	}
+}
+
+// restoreDebugState restores the execution plan and the test runs of a
+// resumed debug session into plan and tests. Sessions saved before the state
+// was stored only have their plan restored. It returns false when sess has
+// nothing to restore.
+func restoreDebugState(sess *session.Session, plan *ExecutionPlan, tests *testVerification) (bool, error) {
+	state := debugState{Plan: plan}
+	ok, err := sess.LoadState(&state)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		if len(sess.ExecutionPlan) == 0 {
+			return false, nil
+		}
+		if err := json.Unmarshal(sess.ExecutionPlan, plan); err != nil {
+			return false, fmt.Errorf("failed to unmarshal execution plan: %w", err)
+		}
+		return true, nil
+	}
+	if tests != nil {
+		tests.runs = state.TestRuns
+	}
+	return true, nil
+}
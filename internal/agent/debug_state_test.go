@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+)
+
+func TestDebugState(t *testing.T) {
+	plan := NewExecutionPlan()
+	plan.Tasks = append(plan.Tasks, PlanTask{ID: "1", Description: "Check the token expiry", Status: "completed"})
+	plan.TransitionToPhase(string(PhaseImpactAnalysis), "symptoms are clear")
+	plan.TransitionToPhase(string(PhaseVerification), "root cause found")
+	tests := &testVerification{runs: []tools.CommandResult{{Command: "go test ./auth/", ExitCode: 1}}}
+
+	manager := session.NewManager(t.TempDir())
+	sess := &session.Session{ID: session.GenerateSessionID("debug"), AgentType: "debug", CreatedAt: time.Now()}
+	storeDebugState(sess, plan, tests)
+	require.NoError(t, manager.Save(sess))
+	resumed, err := manager.Load(sess.ID)
+	require.NoError(t, err)
+
+	restoredPlan := NewExecutionPlan()
+	restoredTests := &testVerification{}
+	ok, err := restoreDebugState(resumed, restoredPlan, restoredTests)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, string(PhaseVerification), restoredPlan.GetCurrentPhase())
+	assert.Equal(t, []string{"problem_definition", "impact_analysis", "verification"}, restoredPlan.PhaseSequence())
+	require.Len(t, restoredPlan.Tasks, 1)
+	assert.Equal(t, "completed", restoredPlan.Tasks[0].Status)
+	assert.Equal(t, tests.runs, restoredTests.runs)
+
+	// A resumed session without tests still restores its plan
+	ok, err = restoreDebugState(resumed, NewExecutionPlan(), nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRestoreDebugState_EarlierSessions(t *testing.T) {
+	plan := NewExecutionPlan()
+	plan.TransitionToPhase(string(PhaseExecution), "plan ready")
+	legacy, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	restored := NewExecutionPlan()
+	ok, err := restoreDebugState(&session.Session{ExecutionPlan: legacy}, restored, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, string(PhaseExecution), restored.GetCurrentPhase())
+
+	ok, err = restoreDebugState(&session.Session{}, NewExecutionPlan(), nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "nothing to restore")
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+)
+
+// testOutputTailLines is the number of output lines of a failed test run
+// quoted in the report
+const testOutputTailLines = 15
+
+// verificationHeadingPattern matches the heading of the verification section
+// of a debug report, in English or Chinese
+var verificationHeadingPattern = regexp.MustCompile(`(?i)^(#{1,6})\s+.*(verification|验证)`)
+
+// testVerification runs the tests the debug agent selects in the
+// verification phase and keeps their outcomes for the report
+type testVerification struct {
+	command *tools.RunCommandTool
+	plan    *ExecutionPlan
+	runs    []tools.CommandResult
+}
+
+// newTestVerification creates the test runner of a debug session in workDir
+func newTestVerification(workDir string, commands []string, timeout time.Duration, plan *ExecutionPlan) *testVerification {
+	return &testVerification{
+		command: tools.NewRunCommandTool(workDir, commands, timeout),
+		plan:    plan,
+	}
+}
+
+// ExtendWithTestVerification appends the test execution instructions for the
+// allowed command prefixes to the debug system prompt
+func ExtendWithTestVerification(prompt string, commands []string) string {
+	if len(commands) == 0 {
+		commands = tools.DefaultTestCommands
+	}
+	return strings.TrimRight(prompt, "\n") + "\n\n" + fmt.Sprintf(DebugTestVerificationPrompt, strings.Join(commands, ", "))
+}
+
+// Execute runs a test command. Tests verify a root cause, so they are only
+// run once the session reached the verification phase.
+func (v *testVerification) Execute(ctx context.Context, params *tools.RunCommandParams) (string, error) {
+	if phase := v.plan.GetCurrentPhase(); phase != string(PhaseVerification) {
+		return "", fmt.Errorf("run_command is only available in the verification phase (current phase: %s); call transition_phase with \"verification\" first", phase)
+	}
+	result, err := v.command.Run(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	v.runs = append(v.runs, result)
+	return result.String(), nil
+}
+
+// AddToReport adds the outcomes of the test runs to the verification section
+// of report, or as a section of their own when the report has none. The
+// report is returned unchanged when no tests were run.
+func (v *testVerification) AddToReport(report string) string {
+	if len(v.runs) == 0 {
+		return report
+	}
+
+	lines := strings.Split(report, "\n")
+	level, start := 2, -1
+	for i, line := range lines {
+		if m := verificationHeadingPattern.FindStringSubmatch(line); m != nil {
+			level, start = len(m[1]), i
+			break
+		}
+	}
+	if start < 0 {
+		return strings.TrimRight(report, "\n") + "\n\n" + v.section(level) + "\n"
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if m := headingLevel(lines[i]); m > 0 && m <= level {
+			end = i
+			break
+		}
+	}
+	before := strings.TrimRight(strings.Join(lines[:end], "\n"), "\n")
+	after := strings.Join(lines[end:], "\n")
+	if after == "" {
+		return before + "\n\n" + v.section(level+1) + "\n"
+	}
+	return before + "\n\n" + v.section(level+1) + "\n\n" + after
+}
+
+// section formats the test runs as a report section with a heading of level
+func (v *testVerification) section(level int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s Test Runs\n\nTests run during verification:\n", strings.Repeat("#", level))
+	for _, run := range v.runs {
+		if run.ExitCode == 0 {
+			fmt.Fprintf(&b, "\n- ✅ `%s` passed\n", run.Command)
+			continue
+		}
+		fmt.Fprintf(&b, "\n- ❌ `%s` failed (exit code %d)\n", run.Command, run.ExitCode)
+		if tail := lastLines(strings.TrimRight(run.Output, "\n"), testOutputTailLines); tail != "" {
+			b.WriteString("\n  ```text\n")
+			for _, line := range strings.Split(tail, "\n") {
+				b.WriteString("  " + line + "\n")
+			}
+			b.WriteString("  ```\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// headingLevel returns the level of a Markdown heading line, 0 for other lines
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || len(line) == level || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+)
+
+func TestTestVerification_Execute(t *testing.T) {
+	plan := NewExecutionPlan()
+	verifier := newTestVerification(t.TempDir(), []string{"git --version", "git unknown-command"}, 0, plan)
+
+	_, err := verifier.Execute(context.Background(), &tools.RunCommandParams{Command: "git --version"})
+	assert.ErrorContains(t, err, "only available in the verification phase")
+
+	plan.TransitionToPhase(string(PhaseVerification), "root cause found")
+	result, err := verifier.Execute(context.Background(), &tools.RunCommandParams{Command: "git --version"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Exit code: 0")
+	_, err = verifier.Execute(context.Background(), &tools.RunCommandParams{Command: "git unknown-command"})
+	require.NoError(t, err)
+	_, err = verifier.Execute(context.Background(), &tools.RunCommandParams{Command: "go test ./..."})
+	assert.ErrorContains(t, err, "command not allowed")
+
+	require.Len(t, verifier.runs, 2, "only commands that ran are recorded")
+	assert.Equal(t, 0, verifier.runs[0].ExitCode)
+	assert.NotEqual(t, 0, verifier.runs[1].ExitCode)
+}
+
+func TestTestVerification_AddToReport(t *testing.T) {
+	verifier := &testVerification{runs: []tools.CommandResult{
+		{Command: "go test ./auth/ -run TestLogin", Output: "ok  \tauth\t0.01s\n", ExitCode: 0},
+		{Command: "go test ./session/ -run TestExpiry", Output: "--- FAIL: TestExpiry\n    expiry_test.go:12: token expired early\nFAIL\n", ExitCode: 1},
+	}}
+
+	report := "# Report\n\n## 5. Solutions\n\nFix it.\n\n## 6. Verification Plan\n\nRun the tests.\n\n### Manual checks\n\nLog in.\n\n## 7. Prevention Measures\n\nReview.\n"
+	got := verifier.AddToReport(report)
+	assert.Equal(t, "# Report\n\n## 5. Solutions\n\nFix it.\n\n## 6. Verification Plan\n\nRun the tests.\n\n### Manual checks\n\nLog in.\n\n"+
+		"### Test Runs\n\nTests run during verification:\n\n"+
+		"- ✅ `go test ./auth/ -run TestLogin` passed\n\n"+
+		"- ❌ `go test ./session/ -run TestExpiry` failed (exit code 1)\n\n"+
+		"  ```text\n  --- FAIL: TestExpiry\n      expiry_test.go:12: token expired early\n  FAIL\n  ```\n\n"+
+		"## 7. Prevention Measures\n\nReview.\n", got)
+
+	// The verification section is the last one
+	got = verifier.AddToReport("# Report\n\n## 验证方案\n\n运行测试。\n")
+	assert.True(t, strings.HasPrefix(got, "# Report\n\n## 验证方案\n\n运行测试。\n\n### Test Runs\n"))
+	assert.True(t, strings.HasSuffix(got, "  ```\n"))
+
+	// No verification section
+	got = verifier.AddToReport("# Report\n\nSomething.")
+	assert.True(t, strings.HasPrefix(got, "# Report\n\nSomething.\n\n## Test Runs\n"))
+
+	assert.Equal(t, "# Report", (&testVerification{}).AddToReport("# Report"))
+}
+
+func TestExtendWithTestVerification(t *testing.T) {
+	prompt := ExtendWithTestVerification("Debug.\n", []string{"go test", "make test"})
+	assert.True(t, strings.HasPrefix(prompt, "Debug.\n\n## Test Execution in Phase 6"))
+	assert.Contains(t, prompt, "only accepts commands starting with: go test, make test.")
+	assert.Contains(t, ExtendWithTestVerification("Debug.", nil), "cargo test")
+}
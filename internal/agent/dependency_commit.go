@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// maxListedDependencies caps the dependencies listed in a generated commit body
+const maxListedDependencies = 20
+
+// dependencyOnlyUpdate returns the dependency changes of the staged diff when
+// it changes nothing else, so the commit message can be generated from them
+// instead of having the model read raw manifest and lockfile diffs
+func dependencyOnlyUpdate(ctx context.Context, executor git.Executor) *analysis.DependencyUpdate {
+	if executor == nil {
+		return nil
+	}
+	diff, err := executor.DiffCached(ctx)
+	if err != nil {
+		log.Debug("Failed to read staged changes for dependency analysis: %v", err)
+		return nil
+	}
+	update := analysis.SummarizeDependencies(diff)
+	if update == nil || !update.OnlyDependencies {
+		return nil
+	}
+	return update
+}
+
+// dependencyCommitInfo builds a conventional commit message listing the
+// dependency changes with their old and new versions
+func dependencyCommitInfo(update *analysis.DependencyUpdate) *CommitInfo {
+	changes := update.Direct
+	if len(changes) == 0 {
+		changes = update.Transitive
+	}
+
+	info := &CommitInfo{Type: "build", Scope: "deps", Description: dependencyDescription(changes)}
+
+	files := make(map[string]bool)
+	for _, change := range changes {
+		files[change.File] = true
+	}
+
+	var body []string
+	if majors := update.Majors(); len(majors) > 0 {
+		names := make([]string, len(majors))
+		for i, change := range majors {
+			names[i] = change.Name
+		}
+		body = append(body, "Major version updates, check for breaking changes: "+strings.Join(names, ", "), "")
+	}
+	for i, change := range changes {
+		if i == maxListedDependencies {
+			body = append(body, fmt.Sprintf("- ... and %d more", len(changes)-maxListedDependencies))
+			break
+		}
+		line := "- " + dependencyChangeText(change)
+		if len(files) > 1 {
+			line += fmt.Sprintf(" (%s)", change.File)
+		}
+		body = append(body, line)
+	}
+	if len(update.Direct) > 0 && len(update.Transitive) > 0 {
+		body = append(body, "", fmt.Sprintf("Also updates %d transitive package(s) in the lockfile.", len(update.Transitive)))
+	}
+	info.Body = strings.Join(body, "\n")
+	return info
+}
+
+// dependencyDescription summarizes the changes in a commit subject
+func dependencyDescription(changes []analysis.DependencyChange) string {
+	if len(changes) == 1 {
+		change := changes[0]
+		switch change.Status {
+		case analysis.DependencyAdded:
+			return "add " + change.Name
+		case analysis.DependencyRemoved:
+			return "remove " + change.Name
+		}
+		if change.From != "" && change.To != "" {
+			return fmt.Sprintf("bump %s from %s to %s", change.Name, change.From, change.To)
+		}
+		return "bump " + change.Name
+	}
+
+	for _, change := range changes {
+		if change.Status != analysis.DependencyUpdated {
+			return fmt.Sprintf("update %d dependencies", len(changes))
+		}
+	}
+	return fmt.Sprintf("bump %d dependencies", len(changes))
+}
+
+// dependencyChangeText describes a change, e.g. "axios: 1.6.0 -> 2.0.0 (major)"
+func dependencyChangeText(change analysis.DependencyChange) string {
+	switch change.Status {
+	case analysis.DependencyAdded:
+		return strings.TrimSpace(fmt.Sprintf("%s: added %s", change.Name, change.To))
+	case analysis.DependencyRemoved:
+		return change.Name + ": removed"
+	}
+	from, to := change.From, change.To
+	if from == "" {
+		from = "unpinned"
+	}
+	if to == "" {
+		to = "unpinned"
+	}
+	text := fmt.Sprintf("%s: %s -> %s", change.Name, from, to)
+	if change.Major() {
+		text += " (major)"
+	}
+	return text
+}
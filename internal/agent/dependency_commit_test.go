@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+const goModBumpDiff = `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,6 +3,6 @@ go 1.24
+ require (
+-	github.com/spf13/cobra v1.8.0
++	github.com/spf13/cobra v1.9.1
+-	github.com/fatih/color v1.16.0
++	github.com/fatih/color v2.0.0+incompatible
+ )
+diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1,2 +1,2 @@
+-github.com/spf13/cobra v1.8.0 h1:abc=
++github.com/spf13/cobra v1.9.1 h1:def=
+`
+
+func TestGenerateCommitMessage_DependencyOnly(t *testing.T) {
+	// The mock provider has no chat model, so reaching the model would fail
+	commitAgent, err := NewCommitAgent(CommitAgentOptions{
+		LLMProvider: &MockLLMProvider{cfg: config.ModelConfig{Provider: "mock", Model: "test"}},
+		GitExecutor: git.NewDiffExecutor(goModBumpDiff),
+	})
+	require.NoError(t, err)
+
+	response, err := commitAgent.GenerateCommitMessage(context.Background(), CommitRequest{Language: "en"})
+	require.NoError(t, err)
+	assert.Equal(t, `build(deps): bump 2 dependencies
+
+Major version updates, check for breaking changes: github.com/fatih/color
+
+- github.com/fatih/color: v1.16.0 -> v2.0.0+incompatible (major)
+- github.com/spf13/cobra: v1.8.0 -> v1.9.1`, response.Message)
+	assert.Zero(t, response.TotalTokens)
+
+	// Other languages still go through the model
+	_, err = commitAgent.GenerateCommitMessage(context.Background(), CommitRequest{Language: "zh"})
+	assert.Error(t, err)
+}
+
+func TestDependencyCommitInfo(t *testing.T) {
+	info := dependencyCommitInfo(&analysis.DependencyUpdate{
+		Direct: []analysis.DependencyChange{
+			{File: "package.json", Name: "axios", Status: analysis.DependencyUpdated, From: "^1.6.0", To: "^1.7.2"},
+		},
+		Transitive: []analysis.DependencyChange{
+			{File: "package-lock.json", Name: "proxy-from-env", Status: analysis.DependencyAdded, To: "1.1.0"},
+		},
+	})
+	assert.Equal(t, "build(deps): bump axios from ^1.6.0 to ^1.7.2", info.Title())
+	assert.Equal(t, "- axios: ^1.6.0 -> ^1.7.2\n\nAlso updates 1 transitive package(s) in the lockfile.", info.Body)
+
+	info = dependencyCommitInfo(&analysis.DependencyUpdate{
+		Direct: []analysis.DependencyChange{
+			{File: "requirements.txt", Name: "httpx", Status: analysis.DependencyAdded, To: ">=0.27"},
+			{File: "requirements.txt", Name: "requests", Status: analysis.DependencyRemoved, From: "2.31.0"},
+		},
+	})
+	assert.Equal(t, "build(deps): update 2 dependencies", info.Title())
+	assert.Equal(t, "- httpx: added >=0.27\n- requests: removed", info.Body)
+}
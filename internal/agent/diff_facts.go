@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// stagedDiffFacts analyzes the staged changes, limited to files when given.
+// Failures only lose the facts, so they are logged and otherwise ignored.
+func stagedDiffFacts(ctx context.Context, executor git.Executor, files []string) []analysis.Fact {
+	if executor == nil {
+		return nil
+	}
+	diff, err := executor.DiffCached(ctx)
+	if err != nil {
+		log.Debug("Failed to read staged changes for analysis: %v", err)
+		return nil
+	}
+
+	facts := analysis.Analyze(diff)
+	if len(files) == 0 {
+		return facts
+	}
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		wanted[file] = true
+	}
+	var filtered []analysis.Fact
+	for _, fact := range facts {
+		if wanted[fact.File] {
+			filtered = append(filtered, fact)
+		}
+	}
+	return filtered
+}
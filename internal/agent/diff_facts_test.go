@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagedDiffFacts(t *testing.T) {
+	executor := git.NewDiffExecutor(`diff --git a/api.go b/api.go
+--- a/api.go
++++ b/api.go
+@@ -1 +1,2 @@
++func Parse(s string) error {
+diff --git a/util.py b/util.py
+--- a/util.py
++++ b/util.py
+@@ -1 +1,2 @@
++def slugify(text):
+`)
+	ctx := context.Background()
+
+	facts := stagedDiffFacts(ctx, executor, nil)
+	require.Len(t, facts, 2)
+
+	facts = stagedDiffFacts(ctx, executor, []string{"util.py"})
+	require.Len(t, facts, 1)
+	assert.Equal(t, "def slugify(text)", facts[0].Detail)
+
+	assert.Empty(t, stagedDiffFacts(ctx, nil, nil))
+}
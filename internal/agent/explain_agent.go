@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// ExplainRequest contains the input for explaining a range of code
+type ExplainRequest struct {
+	FilePath  string // File to explain, relative to WorkDir
+	StartLine int    // First line of the range (1-indexed)
+	EndLine   int    // Last line of the range (1-indexed, inclusive)
+	Question  string // Optional question about the code
+	Language  string // Output language
+	WorkDir   string // Working directory
+}
+
+// ExplainResponse contains the result of a code explanation
+type ExplainResponse struct {
+	Explanation      string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// ExplainAgentOptions contains configuration for ExplainAgent
+type ExplainAgentOptions struct {
+	Language        string
+	LLMProvider     llm.Provider
+	Printer         *ui.StreamPrinter
+	MaxLinesPerRead int
+	RetryConfig     llm.RetryConfig
+	PromptExtension string // Project-specific guidance appended to the system prompt
+}
+
+// ExplainAgent explains a range of code using a single LLM call
+type ExplainAgent struct {
+	opts ExplainAgentOptions
+}
+
+// NewExplainAgent creates a new ExplainAgent
+func NewExplainAgent(opts ExplainAgentOptions) *ExplainAgent {
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.MaxLinesPerRead <= 0 {
+		opts.MaxLinesPerRead = tools.DefaultMaxLinesPerRead
+	}
+	return &ExplainAgent{opts: opts}
+}
+
+// BuildExplainSystemPrompt builds the system prompt for code explanation
+func BuildExplainSystemPrompt(language, filePath string, startLine, endLine int, question string) string {
+	tmpl, err := template.New("explain_prompt").Parse(ExplainSystemPrompt)
+	if err != nil {
+		return ExplainSystemPrompt
+	}
+
+	var buf bytes.Buffer
+	data := map[string]interface{}{
+		"Language":  language,
+		"FilePath":  filePath,
+		"StartLine": startLine,
+		"EndLine":   endLine,
+		"Question":  question,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return ExplainSystemPrompt
+	}
+	return buf.String()
+}
+
+// Explain explains the requested range of code
+func (a *ExplainAgent) Explain(ctx context.Context, req ExplainRequest) (*ExplainResponse, error) {
+	printer := a.opts.Printer
+
+	if req.FilePath == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+	if a.opts.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider is not configured")
+	}
+
+	language := req.Language
+	if language == "" {
+		language = a.opts.Language
+	}
+
+	// Read the requested range with the same tool the agents use
+	readFileTool := tools.NewReadFileTool(req.WorkDir, a.opts.MaxLinesPerRead)
+	excerpt, err := readFileTool.Execute(ctx, &tools.ReadFileParams{
+		FilePath:  req.FilePath,
+		StartLine: req.StartLine,
+		EndLine:   req.EndLine,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	providerName := a.opts.LLMProvider.Name()
+	if printer != nil {
+		_ = printer.PrintProgress(fmt.Sprintf("Initializing LLM provider (%s/%s)...", providerName, a.opts.LLMProvider.GetConfig().Model))
+	}
+
+	chatModel, err := a.opts.LLMProvider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat model: %w", err)
+	}
+	if chatModel == nil {
+		return nil, fmt.Errorf("chat model is nil (provider: %s)", providerName)
+	}
+
+	messages := []*schema.Message{
+		{Role: schema.System, Content: ExtendSystemPrompt(BuildExplainSystemPrompt(language, req.FilePath, req.StartLine, req.EndLine, req.Question), a.opts.PromptExtension)},
+		{Role: schema.User, Content: excerpt},
+	}
+
+	streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
+		return chatModel.Stream(ctx, messages)
+	})
+	if err != nil {
+		return nil, llm.ExplainError("LLM stream failed", err)
+	}
+	defer streamReader.Close()
+
+	var promptTokens, completionTokens, totalTokens int
+	var explanation strings.Builder
+	for {
+		chunk, err := streamReader.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, llm.ExplainError("stream read error", err)
+		}
+
+		if chunk.Content != "" {
+			explanation.WriteString(chunk.Content)
+			if printer != nil {
+				_ = printer.PrintLLMContent(chunk.Content)
+			}
+		}
+
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			usage := chunk.ResponseMeta.Usage
+			promptTokens += usage.PromptTokens
+			completionTokens += usage.CompletionTokens
+			totalTokens += usage.TotalTokens
+		}
+	}
+
+	if explanation.Len() == 0 {
+		return nil, fmt.Errorf("LLM returned an empty explanation")
+	}
+	log.Debug("Explanation generated (%d chars)", explanation.Len())
+
+	return &ExplainResponse{
+		Explanation:      explanation.String(),
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      totalTokens,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// staleReadMarker prefixes read_file results whose file changed after the read
+const staleReadMarker = "[STALE]"
+
+// fileRead is a read_file result and the file version it was read from
+type fileRead struct {
+	path    string // Path as given to the tool
+	absPath string
+	hash    string // Empty when the file did not exist
+	stale   bool
+}
+
+// FileVersionTracker remembers which file version each read_file result was
+// taken from, so results can be flagged once the file changes on disk (through
+// a write tool, or the user editing it mid-session)
+type FileVersionTracker struct {
+	workDir string
+	reads   map[string]*fileRead // tool call ID -> read
+}
+
+// NewFileVersionTracker creates a tracker resolving relative paths against workDir
+func NewFileVersionTracker(workDir string) *FileVersionTracker {
+	return &FileVersionTracker{
+		workDir: workDir,
+		reads:   make(map[string]*fileRead),
+	}
+}
+
+// RecordRead records the current version of path for a read_file tool call
+func (t *FileVersionTracker) RecordRead(toolCallID, path string) {
+	if t == nil || toolCallID == "" || path == "" {
+		return
+	}
+	absPath := t.resolve(path)
+	t.reads[toolCallID] = &fileRead{path: path, absPath: absPath, hash: fileHash(absPath)}
+}
+
+// Paths returns the distinct paths read, sorted
+func (t *FileVersionTracker) Paths() []string {
+	if t == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, read := range t.reads {
+		if !seen[read.path] {
+			seen[read.path] = true
+			paths = append(paths, read.path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// MarkStale flags read_file results whose file changed since they were read.
+// Flagged results in messages are replaced by copies prefixed with a stale
+// notice. It returns the changed paths, each reported only once.
+func (t *FileVersionTracker) MarkStale(messages []*schema.Message) []string {
+	if t == nil || len(t.reads) == 0 {
+		return nil
+	}
+
+	current := make(map[string]string) // abs path -> hash, computed once per call
+	changed := make(map[string]bool)
+	for i, msg := range messages {
+		if msg.Role != schema.Tool {
+			continue
+		}
+		read, ok := t.reads[msg.ToolCallID]
+		if !ok || read.stale {
+			continue
+		}
+
+		hash, ok := current[read.absPath]
+		if !ok {
+			hash = fileHash(read.absPath)
+			current[read.absPath] = hash
+		}
+		if hash == read.hash {
+			continue
+		}
+
+		read.stale = true
+		changed[read.path] = true
+		copied := *msg
+		copied.Content = fmt.Sprintf("%s %s has changed since this result was read; read it again before relying on it.\n%s",
+			staleReadMarker, read.path, msg.Content)
+		messages[i] = &copied
+	}
+
+	paths := make([]string, 0, len(changed))
+	for path := range changed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// StaleFilesNotice returns the message telling the model which files changed
+func StaleFilesNotice(paths []string) *schema.Message {
+	return &schema.Message{
+		Role: schema.User,
+		Content: fmt.Sprintf("Note: %s changed since last read. Earlier read_file results for these files are outdated and marked %s.",
+			strings.Join(paths, ", "), staleReadMarker),
+	}
+}
+
+func (t *FileVersionTracker) resolve(path string) string {
+	if !filepath.IsAbs(path) && t.workDir != "" {
+		path = filepath.Join(t.workDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// fileHash returns the content hash of a file, or "" if it cannot be read
+func fileHash(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileVersionTracker_MarkStale(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0644))
+
+	tracker := NewFileVersionTracker(dir)
+	tracker.RecordRead("call-1", "a.go")
+	tracker.RecordRead("call-2", "b.go")
+	messages := []*schema.Message{
+		toolCallMessage("call-1", "read_file"),
+		{Role: schema.Tool, ToolCallID: "call-1", Content: "package a"},
+		toolCallMessage("call-2", "read_file"),
+		{Role: schema.Tool, ToolCallID: "call-2", Content: "package b"},
+	}
+	original := messages[1]
+
+	assert.Empty(t, tracker.MarkStale(messages), "unchanged files are not reported")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0644))
+	assert.Equal(t, []string{"a.go"}, tracker.MarkStale(messages))
+	assert.True(t, strings.HasPrefix(messages[1].Content, staleReadMarker+" a.go has changed"))
+	assert.Equal(t, "package a", original.Content, "the original message is not modified")
+	assert.Equal(t, "package b", messages[3].Content)
+
+	assert.Empty(t, tracker.MarkStale(messages), "stale results are only reported once")
+	assert.Equal(t, []string{"a.go", "b.go"}, tracker.Paths())
+
+	// A fresh read of the changed file is tracked again
+	tracker.RecordRead("call-3", "a.go")
+	messages = append(messages, toolCallMessage("call-3", "read_file"),
+		&schema.Message{Role: schema.Tool, ToolCallID: "call-3", Content: "package a\n\nfunc A() {}"})
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.go")))
+	assert.Equal(t, []string{"a.go"}, tracker.MarkStale(messages), "deleted files count as changed")
+
+	notice := StaleFilesNotice([]string{"a.go"})
+	assert.Equal(t, schema.User, notice.Role)
+	assert.Contains(t, notice.Content, "a.go changed since last read")
+}
+
+func TestFileVersionTracker_Nil(t *testing.T) {
+	var tracker *FileVersionTracker
+	tracker.RecordRead("call-1", "a.go")
+	assert.Empty(t, tracker.MarkStale(nil))
+	assert.Empty(t, tracker.Paths())
+}
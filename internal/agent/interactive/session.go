@@ -32,12 +32,19 @@ type CommandHistory struct {
 	Type      CommandType `json:"type"`
 }
 
+// Modification is a change of the report made in the interactive session
+type Modification struct {
+	Request   string    `json:"request"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // InteractiveSession manages the post-execution interactive mode
 type InteractiveSession struct {
 	workingDirectory string
 	isRunning        bool
 	reportContent    string
 	commandHistory   []CommandHistory
+	modifications    []Modification
 	llmProvider      llm.Provider // LLM provider for question answering
 	ctx              context.Context // Context for cancellation
 }
@@ -82,6 +89,11 @@ func (s *InteractiveSession) GetCommandHistory() []CommandHistory {
 	return s.commandHistory
 }
 
+// GetModifications returns the modifications applied to the report, oldest first
+func (s *InteractiveSession) GetModifications() []Modification {
+	return s.modifications
+}
+
 // Start begins the interactive session loop
 func (s *InteractiveSession) Start(ctx context.Context, input io.Reader, output io.Writer) error {
 	s.isRunning = true
@@ -335,8 +347,14 @@ Please provide the complete modified report incorporating the requested changes.
 	}
 	streamReader.Close()
 
+	if strings.TrimSpace(modifiedReport.String()) == "" {
+		fmt.Fprintln(output, "Error: the model returned an empty report; the report was not changed.")
+		return nil
+	}
+
 	// Update the stored report content with the modified version
 	s.SetReportContent(modifiedReport.String())
+	s.modifications = append(s.modifications, Modification{Request: modificationRequest, Timestamp: time.Now()})
 
 	fmt.Fprintln(output)
 	fmt.Fprintln(output, "✓ Report has been successfully modified and updated.")
@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -291,4 +294,47 @@ func TestInteractiveSession_ErrorHandling(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// replyModel streams a fixed reply
+type replyModel struct {
+	reply string
+}
+
+func (m *replyModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(m.reply, nil), nil
+}
+
+func (m *replyModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage(m.reply, nil)}), nil
+}
+
+func (m *replyModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+type replyProvider struct {
+	chatModel *replyModel
+}
+
+func (p *replyProvider) Name() string                  { return "reply" }
+func (p *replyProvider) GetConfig() config.ModelConfig { return config.ModelConfig{Provider: "reply"} }
+func (p *replyProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return p.chatModel, nil
+}
+
+func TestInteractiveSession_Modifications(t *testing.T) {
+	session := NewInteractiveSession(t.TempDir())
+	session.SetReportContent("# Report\n\nRoot cause: unknown\n")
+	chatModel := &replyModel{reply: "# Report\n\nRoot cause: expired key\n"}
+	session.SetLLMProvider(&replyProvider{chatModel: chatModel})
+
+	output := &strings.Builder{}
+	require.NoError(t, session.ProcessCommand(context.Background(), "modify name the root cause", output))
+	assert.Equal(t, "# Report\n\nRoot cause: expired key\n", session.GetReportContent())
+	require.Len(t, session.GetModifications(), 1)
+	assert.Equal(t, "name the root cause", session.GetModifications()[0].Request)
+
+	// An empty reply keeps the report
+	chatModel.reply = ""
+	require.NoError(t, session.ProcessCommand(context.Background(), "modify shorten it", output))
+	assert.Equal(t, "# Report\n\nRoot cause: expired key\n", session.GetReportContent())
+	assert.Len(t, session.GetModifications(), 1)
+}
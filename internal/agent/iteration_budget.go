@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// BudgetWarnRatio is the budget usage at which the agent is warned to wrap up
+	BudgetWarnRatio = 0.7
+	// BudgetForceReportRatio is the budget usage at which the agent is forced into reporting
+	BudgetForceReportRatio = 0.9
+)
+
+// BudgetAction is the action the agent loop should take for the current budget usage
+type BudgetAction int
+
+const (
+	// BudgetActionNone means the agent can continue normally
+	BudgetActionNone BudgetAction = iota
+	// BudgetActionWarn means the agent should be told to start wrapping up
+	BudgetActionWarn
+	// BudgetActionForceReport means the agent must submit its report now
+	BudgetActionForceReport
+	// BudgetActionExhausted means the budget is used up
+	BudgetActionExhausted
+)
+
+// IterationBudget adaptively controls the agent loop based on iterations, token spend
+// and elapsed time. Warn and force-report actions are returned only once each.
+type IterationBudget struct {
+	MaxIterations int
+	MaxTokens     int       // 0 = no token limit
+	Deadline      *Deadline // nil = no time limit
+
+	warned bool
+	forced bool
+}
+
+// NewIterationBudget creates a new IterationBudget
+func NewIterationBudget(maxIterations, maxTokens int) *IterationBudget {
+	if maxIterations <= 0 {
+		maxIterations = 30
+	}
+	if maxTokens < 0 {
+		maxTokens = 0
+	}
+	return &IterationBudget{MaxIterations: maxIterations, MaxTokens: maxTokens}
+}
+
+// Usage returns the consumed fraction of the budget, the largest of iteration, token and time usage
+func (b *IterationBudget) Usage(iteration, tokens int) float64 {
+	usage := float64(iteration) / float64(b.MaxIterations)
+	if b.MaxTokens > 0 {
+		if tokenUsage := float64(tokens) / float64(b.MaxTokens); tokenUsage > usage {
+			usage = tokenUsage
+		}
+	}
+	if timeUsage := b.Deadline.Usage(); timeUsage > usage {
+		usage = timeUsage
+	}
+	return usage
+}
+
+// Check returns the action for the given iteration (1-indexed) and total tokens spent
+func (b *IterationBudget) Check(iteration, tokens int) BudgetAction {
+	if iteration > b.MaxIterations || (b.MaxTokens > 0 && tokens >= b.MaxTokens) || b.Deadline.Exceeded() {
+		return BudgetActionExhausted
+	}
+
+	usage := b.Usage(iteration, tokens)
+	// Always leave at least the last two iterations for reporting
+	if !b.forced && (usage >= BudgetForceReportRatio || b.MaxIterations-iteration < 2) {
+		b.forced = true
+		b.warned = true
+		return BudgetActionForceReport
+	}
+	if !b.warned && usage >= BudgetWarnRatio {
+		b.warned = true
+		return BudgetActionWarn
+	}
+	return BudgetActionNone
+}
+
+// Extend adds iterations to the budget and re-arms the warnings
+func (b *IterationBudget) Extend(iterations int) {
+	// Grow the token and time budgets proportionally so that the extension is meaningful
+	if b.MaxTokens > 0 {
+		b.MaxTokens += b.MaxTokens * iterations / b.MaxIterations
+	}
+	if b.Deadline != nil {
+		b.Deadline.Extend(b.Deadline.Max * time.Duration(iterations) / time.Duration(b.MaxIterations))
+	}
+	b.MaxIterations += iterations
+	b.warned = false
+	b.forced = false
+}
+
+// WarningMessage returns the message sent to the LLM when the warning threshold is reached
+func (b *IterationBudget) WarningMessage(iteration, tokens int, plan *ExecutionPlan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ Budget notice: %.0f%% of the analysis budget has been used (iteration %d/%d",
+		b.Usage(iteration, tokens)*100, iteration, b.MaxIterations))
+	if b.MaxTokens > 0 {
+		sb.WriteString(fmt.Sprintf(", tokens %d/%d", tokens, b.MaxTokens))
+	}
+	if b.Deadline != nil {
+		sb.WriteString(fmt.Sprintf(", time %s/%s", b.Deadline.Elapsed().Round(time.Second), b.Deadline.Max))
+	}
+	sb.WriteString(").")
+	if plan != nil {
+		sb.WriteString(fmt.Sprintf(" Current phase: %s.", plan.GetCurrentPhase()))
+	}
+	sb.WriteString(" Focus on the most promising hypothesis, avoid broad exploration, and move towards verification and reporting.")
+	return sb.String()
+}
+
+// ForceReportMessage returns the message sent to the LLM when it must submit its report
+func (b *IterationBudget) ForceReportMessage() string {
+	return "🛑 The analysis budget is nearly exhausted. Stop investigating and call submit_report NOW with your current findings. " +
+		"If the root cause is not confirmed, clearly mark the report as partial and list the open questions under Unresolved Items."
+}
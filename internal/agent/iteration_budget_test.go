@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterationBudget_IterationThresholds(t *testing.T) {
+	budget := NewIterationBudget(10, 0)
+
+	var actions []BudgetAction
+	for i := 1; i <= 11; i++ {
+		actions = append(actions, budget.Check(i, 0))
+	}
+
+	assert.Equal(t, []BudgetAction{
+		BudgetActionNone, BudgetActionNone, BudgetActionNone, BudgetActionNone, BudgetActionNone, BudgetActionNone,
+		BudgetActionWarn, BudgetActionNone,
+		BudgetActionForceReport, BudgetActionNone,
+		BudgetActionExhausted,
+	}, actions)
+}
+
+func TestIterationBudget_TokenThresholds(t *testing.T) {
+	budget := NewIterationBudget(100, 1000)
+
+	assert.Equal(t, BudgetActionNone, budget.Check(1, 100))
+	assert.Equal(t, BudgetActionWarn, budget.Check(2, 750))
+	assert.Equal(t, BudgetActionForceReport, budget.Check(3, 920))
+	assert.Equal(t, BudgetActionExhausted, budget.Check(4, 1000))
+}
+
+func TestIterationBudget_Extend(t *testing.T) {
+	budget := NewIterationBudget(10, 1000)
+	for i := 1; i <= 10; i++ {
+		budget.Check(i, 0)
+	}
+	assert.Equal(t, BudgetActionExhausted, budget.Check(11, 0))
+
+	budget.Extend(10)
+	assert.Equal(t, 20, budget.MaxIterations)
+	assert.Equal(t, 2000, budget.MaxTokens)
+	assert.Equal(t, BudgetActionNone, budget.Check(11, 0))
+	assert.Equal(t, BudgetActionWarn, budget.Check(14, 0))
+}
+
+func TestIterationBudget_Messages(t *testing.T) {
+	budget := NewIterationBudget(10, 1000)
+	plan := NewExecutionPlan()
+
+	warning := budget.WarningMessage(7, 500, plan)
+	assert.Contains(t, warning, "70%")
+	assert.Contains(t, warning, "iteration 7/10")
+	assert.Contains(t, warning, "tokens 500/1000")
+	assert.Contains(t, warning, string(PhaseProblemDefinition))
+
+	assert.Contains(t, budget.ForceReportMessage(), "submit_report")
+}
+
+func TestBuildPartialDebugReport(t *testing.T) {
+	plan := NewExecutionPlan()
+	plan.AddTask("1", "Check the login handler")
+	messages := []*schema.Message{
+		{Role: schema.System, Content: "system prompt"},
+		{Role: schema.User, Content: "debug it"},
+		{Role: schema.Assistant, Content: "The handler returns 500 when the session is nil."},
+		{Role: schema.Tool, Content: "file contents"},
+		{Role: schema.Assistant, Content: ""},
+	}
+
+	report := buildPartialDebugReport("Login fails", "budget exhausted", plan, messages)
+
+	assert.Contains(t, report, "# Partial Debug Report")
+	assert.Contains(t, report, "budget exhausted")
+	assert.Contains(t, report, "Login fails")
+	assert.Contains(t, report, "Check the login handler")
+	assert.Contains(t, report, "The handler returns 500 when the session is nil.")
+	assert.NotContains(t, report, "system prompt")
+	assert.NotContains(t, report, "file contents")
+}
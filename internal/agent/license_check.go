@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/license"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// CategoryLicense is the category of issues found by the license check
+const CategoryLicense = "license"
+
+// LicensePolicy configures the license check run alongside a review
+type LicensePolicy struct {
+	Header      string   // Header new files must start with; {year} matches any year (empty = no header check)
+	HeaderPaths []string // Path patterns of files that need the header
+	Disallowed  []string // SPDX identifiers dependencies must not be licensed under
+}
+
+// Enabled reports whether the policy checks anything
+func (p *LicensePolicy) Enabled() bool {
+	return p != nil && (strings.TrimSpace(p.Header) != "" || len(p.Disallowed) > 0)
+}
+
+// checkLicenses applies a license policy to a diff with local rules: new files
+// matching HeaderPaths must carry the header, and dependencies added or
+// updated in go.mod or package.json must not use a disallowed license.
+// Dependency licenses are looked up in vendor/, the Go module cache and
+// node_modules under workDir, so dependencies that aren't installed locally
+// are only reported when they're new and a disallowed list is configured.
+func checkLicenses(diff, workDir string, policy *LicensePolicy, files []string) []ReviewIssue {
+	if !policy.Enabled() || diff == "" {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		wanted[file] = true
+	}
+	included := func(file string) bool {
+		return len(wanted) == 0 || wanted[file]
+	}
+
+	var issues []ReviewIssue
+	if strings.TrimSpace(policy.Header) != "" {
+		added := make(map[string]bool)
+		for _, file := range git.DiffFiles(diff) {
+			if file.Status == "added" {
+				added[file.Path] = true
+			}
+		}
+		for _, file := range analysis.ParseDiff(diff) {
+			if !added[file.Path] || !included(file.Path) || !matchesAnyPath(policy.HeaderPaths, file.Path) {
+				continue
+			}
+			if license.HasHeader(file.Added, policy.Header) {
+				continue
+			}
+			issues = append(issues, ReviewIssue{
+				Severity:    SeverityWarning,
+				Category:    CategoryLicense,
+				File:        file.Path,
+				Line:        1,
+				Title:       "Missing license header",
+				Description: "This new file doesn't start with the license header required by the project:\n" + policy.Header,
+				Suggestion:  "Add the license header at the top of the file, as a comment in the file's language.",
+			})
+		}
+	}
+
+	if len(policy.Disallowed) == 0 {
+		return issues
+	}
+	update := analysis.SummarizeDependencies(diff)
+	if update == nil {
+		return issues
+	}
+	for _, change := range update.Direct {
+		if change.Status == analysis.DependencyRemoved || !included(change.File) {
+			continue
+		}
+		id := dependencyLicense(workDir, change)
+		if id == "" {
+			if change.Status == analysis.DependencyAdded {
+				issues = append(issues, ReviewIssue{
+					Severity:    SeverityInfo,
+					Category:    CategoryLicense,
+					File:        change.File,
+					Title:       fmt.Sprintf("Unknown license for new dependency %s", change.Name),
+					Description: fmt.Sprintf("The license of %s couldn't be determined locally, so it wasn't checked against the disallowed licenses (%s).", change.Name, strings.Join(policy.Disallowed, ", ")),
+					Suggestion:  "Download or install the dependency and review again, or check its license manually.",
+				})
+			}
+			continue
+		}
+		if matched, ok := license.Disallowed(id, policy.Disallowed); ok {
+			version := change.To
+			if version == "" {
+				version = "unpinned"
+			}
+			issues = append(issues, ReviewIssue{
+				Severity:    SeverityError,
+				Category:    CategoryLicense,
+				File:        change.File,
+				Title:       fmt.Sprintf("Dependency %s uses disallowed license %s", change.Name, id),
+				Description: fmt.Sprintf("%s (%s) is licensed under %s, which matches the disallowed license %s.", change.Name, version, id, matched),
+				Suggestion:  "Replace the dependency with one under an allowed license, or get the license approved and update the policy.",
+			})
+		}
+	}
+	return issues
+}
+
+// dependencyLicense looks up the license of a direct dependency change
+func dependencyLicense(workDir string, change analysis.DependencyChange) string {
+	dir := filepath.Join(workDir, filepath.FromSlash(path.Dir(change.File)))
+	switch path.Base(change.File) {
+	case "go.mod":
+		if change.To == "" {
+			return ""
+		}
+		return license.GoModule(dir, change.Name, change.To)
+	case "package.json":
+		return license.NPMPackage(dir, change.Name)
+	}
+	return ""
+}
+
+// matchesAnyPath reports whether file matches one of the path patterns
+func matchesAnyPath(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// stagedLicenseIssues runs the license check on the staged changes. Failures
+// only lose the check, so they are logged and otherwise ignored.
+func stagedLicenseIssues(ctx context.Context, executor git.Executor, workDir string, policy *LicensePolicy, files []string) []ReviewIssue {
+	if executor == nil || !policy.Enabled() {
+		return nil
+	}
+	diff, err := executor.DiffCached(ctx)
+	if err != nil {
+		log.Debug("Failed to read staged changes for the license check: %v", err)
+		return nil
+	}
+	return checkLicenses(diff, workDir, policy, files)
+}
+
+// formatLicenseIssues lists license issues for the model
+func formatLicenseIssues(issues []ReviewIssue) string {
+	var b strings.Builder
+	b.WriteString("License check findings:\n")
+	for _, issue := range issues {
+		fmt.Fprintf(&b, "- [%s] %s: %s\n", issue.Severity, issue.File, issue.Title)
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const licenseTestDiff = `diff --git a/cmd/tool/main.go b/cmd/tool/main.go
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/cmd/tool/main.go
+@@ -0,0 +1,3 @@
++package main
++
++func main() {}
+diff --git a/pkg/util/util.go b/pkg/util/util.go
+new file mode 100644
+index 0000000..2222222
+--- /dev/null
++++ b/pkg/util/util.go
+@@ -0,0 +1,4 @@
++// Copyright 2024 Acme Inc.
++// SPDX-License-Identifier: Apache-2.0
++
++package util
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1 @@
++# Tool
+diff --git a/go.mod b/go.mod
+index 4444444..5555555 100644
+--- a/go.mod
++++ b/go.mod
+@@ -3,4 +3,6 @@ module example.com/tool
+ require (
+ 	github.com/stretchr/testify v1.9.0
++	example.com/copyleft v1.2.0
++	example.com/unknown v0.1.0
+ )
+`
+
+func TestCheckLicenses(t *testing.T) {
+	workDir := t.TempDir()
+	t.Setenv("GOMODCACHE", filepath.Join(workDir, "modcache"))
+	licenseFile := filepath.Join(workDir, "vendor", "example.com", "copyleft", "LICENSE")
+	require.NoError(t, os.MkdirAll(filepath.Dir(licenseFile), 0755))
+	require.NoError(t, os.WriteFile(licenseFile, []byte("GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"), 0644))
+
+	policy := &LicensePolicy{
+		Header:      "Copyright {year} Acme Inc.\nSPDX-License-Identifier: Apache-2.0",
+		HeaderPaths: []string{"*.go"},
+		Disallowed:  []string{"GPL-3.0", "AGPL-3.0"},
+	}
+	issues := checkLicenses(licenseTestDiff, workDir, policy, nil)
+	require.Len(t, issues, 3)
+
+	assert.Equal(t, "cmd/tool/main.go", issues[0].File)
+	assert.Equal(t, SeverityWarning, issues[0].Severity)
+	assert.Equal(t, CategoryLicense, issues[0].Category)
+	assert.Equal(t, "Missing license header", issues[0].Title)
+
+	assert.Equal(t, "go.mod", issues[1].File)
+	assert.Equal(t, SeverityError, issues[1].Severity)
+	assert.Contains(t, issues[1].Title, "example.com/copyleft")
+	assert.Contains(t, issues[1].Title, "GPL-3.0")
+
+	assert.Equal(t, SeverityInfo, issues[2].Severity)
+	assert.Contains(t, issues[2].Title, "example.com/unknown")
+
+	// Limited to the requested files
+	issues = checkLicenses(licenseTestDiff, workDir, policy, []string{"pkg/util/util.go"})
+	assert.Empty(t, issues)
+
+	// Only the configured checks run
+	issues = checkLicenses(licenseTestDiff, workDir, &LicensePolicy{Disallowed: []string{"MIT"}}, nil)
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityInfo, issues[0].Severity)
+	assert.Empty(t, checkLicenses(licenseTestDiff, workDir, nil, nil))
+	assert.Empty(t, checkLicenses(licenseTestDiff, workDir, &LicensePolicy{}, nil))
+}
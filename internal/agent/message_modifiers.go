@@ -2,6 +2,7 @@ package agent
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cloudwego/eino/schema"
@@ -133,28 +134,14 @@ func FilterToolResults(keepTools ...string) MessageModifier {
 }
 
 // SummarizeToolResults creates a modifier that summarizes long tool results
-// Tool results longer than maxLength will be truncated with a summary
+// Tool results longer than maxLength keep their start and end, and the error
+// and warning lines in between; see TruncateToolResults
 func SummarizeToolResults(maxLength int) MessageModifier {
-	return func(messages []*schema.Message) []*schema.Message {
-		result := make([]*schema.Message, len(messages))
-
-		for i, msg := range messages {
-			if msg.Role == schema.Tool && len(msg.Content) > maxLength {
-				// Create a summarized version
-				summary := msg.Content[:maxLength]
-				remaining := len(msg.Content) - maxLength
-
-				newMsg := *msg
-				newMsg.Content = fmt.Sprintf("%s\n\n[... %d more characters truncated for brevity ...]",
-					summary, remaining)
-				result[i] = &newMsg
-			} else {
-				result[i] = msg
-			}
-		}
-
-		return result
-	}
+	return truncateToolResults(toolResultLimit{
+		maxLength: maxLength,
+		tailRatio: DefaultToolResultTailRatio,
+		keep:      []*regexp.Regexp{defaultKeepPattern},
+	}, nil)
 }
 
 // AddContextToSystemMessage creates a modifier that appends context to the system message
@@ -66,14 +66,15 @@ func TestSummarizeToolResults(t *testing.T) {
 
 	// Long tool result should be truncated
 	// The truncation adds a message, so total length might be slightly longer
-	// but the original content should be cut to maxLength
+	// but the original content should be cut to maxLength, from its start and end
 	if !strings.Contains(result[1].Content, "truncated") {
 		t.Error("Expected truncation message")
 	}
-	// Check that the first part is exactly 50 chars (maxLength)
-	firstPart := strings.Split(result[1].Content, "\n\n[...")[0]
-	if len(firstPart) != 50 {
-		t.Errorf("Expected first part to be exactly 50 chars, got %d", len(firstPart))
+	// Check that the first and last parts are exactly 50 chars (maxLength)
+	parts := strings.Split(result[1].Content, "\n\n")
+	firstPart, lastPart := parts[0], parts[len(parts)-1]
+	if len(firstPart)+len(lastPart) != 50 || len(lastPart) != 15 {
+		t.Errorf("Expected first and last parts of 35 and 15 chars, got %d and %d", len(firstPart), len(lastPart))
 	}
 
 	// Short tool result should be unchanged
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// maxListedOfflineFiles caps the files listed in an offline commit body
+const maxListedOfflineFiles = 20
+
+// genericScopes are top-level directories too common to make a useful scope;
+// the directory below them is used instead
+var genericScopes = map[string]bool{"internal": true, "pkg": true, "src": true, "lib": true, "cmd": true, "app": true}
+
+// offlineCommitInfo builds a commit message from the staged diff without the
+// model, or returns nil when the staged changes can't be read
+func offlineCommitInfo(ctx context.Context, executor git.Executor) *CommitInfo {
+	diff, err := executor.DiffCached(ctx)
+	if err != nil {
+		log.Debug("Failed to read staged changes for the offline commit message: %v", err)
+		return nil
+	}
+	return diffStatsCommitInfo(diff)
+}
+
+// diffStatsCommitInfo builds a degraded commit message from the paths and
+// line counts of a diff: the type is inferred from the kind of files, the
+// scope from their common top-level directory
+func diffStatsCommitInfo(diff string) *CommitInfo {
+	files := git.DiffFiles(diff)
+	if len(files) == 0 {
+		return nil
+	}
+	if update := analysis.SummarizeDependencies(diff); update != nil && update.OnlyDependencies {
+		return dependencyCommitInfo(update)
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	info := &CommitInfo{
+		Type:        offlineCommitType(files),
+		Scope:       offlineCommitScope(paths),
+		Description: offlineDescription(files),
+	}
+	if info.Scope == info.Type {
+		// e.g. docs/ for a docs commit
+		info.Scope = ""
+	}
+
+	added, deleted := diffLineCounts(diff)
+	body := []string{fmt.Sprintf("%d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)", len(files), added, deleted), ""}
+	for i, file := range files {
+		if i == maxListedOfflineFiles {
+			body = append(body, fmt.Sprintf("- ... and %d more", len(files)-maxListedOfflineFiles))
+			break
+		}
+		body = append(body, fmt.Sprintf("- %s (%s)", file.Path, file.Status))
+	}
+	info.Body = strings.Join(body, "\n")
+	return info
+}
+
+// offlineCommitType infers the conventional commit type from the changed
+// files: a type only applies when every file is of its kind
+func offlineCommitType(files []git.DiffFile) string {
+	kinds := []struct {
+		commitType string
+		match      func(string) bool
+	}{
+		{"test", IsTestFile},
+		{"ci", isCIFile},
+		{"build", isBuildFile},
+		{"docs", isDocFile},
+	}
+	for _, kind := range kinds {
+		if allFiles(files, func(f git.DiffFile) bool { return kind.match(f.Path) }) {
+			return kind.commitType
+		}
+	}
+	if allFiles(files, func(f git.DiffFile) bool { return f.Status == "added" }) {
+		return "feat"
+	}
+	return "chore"
+}
+
+// offlineCommitScope returns the top-level directory shared by all paths, or
+// "" when they don't share one
+func offlineCommitScope(paths []string) string {
+	var common []string
+	for i, p := range paths {
+		dirs := strings.Split(path.Dir(p), "/")
+		if dirs[0] == "." {
+			return ""
+		}
+		if i == 0 {
+			common = dirs
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(dirs) && common[n] == dirs[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	for len(common) > 1 && genericScopes[common[0]] {
+		common = common[1:]
+	}
+	if len(common) == 0 || genericScopes[common[0]] || strings.HasPrefix(common[0], ".") {
+		return ""
+	}
+	return common[0]
+}
+
+// offlineDescription summarizes the changed files in a commit subject
+func offlineDescription(files []git.DiffFile) string {
+	verbs := map[string]string{"added": "add", "deleted": "remove", "renamed": "rename"}
+	if len(files) == 1 {
+		verb, ok := verbs[files[0].Status]
+		if !ok {
+			verb = "update"
+		}
+		return verb + " " + path.Base(files[0].Path)
+	}
+	verb := "update"
+	for status, v := range verbs {
+		if allFiles(files, func(f git.DiffFile) bool { return f.Status == status }) {
+			verb = v
+		}
+	}
+	return fmt.Sprintf("%s %d files", verb, len(files))
+}
+
+// hunkHeaderPattern matches a hunk header, e.g. "@@ -12,7 +12,9 @@"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// diffLineCounts counts the added and deleted lines of a unified diff. Hunk
+// headers give the number of lines of each hunk, so file headers ("--- a/x")
+// are not mistaken for deletions.
+func diffLineCounts(diff string) (added, deleted int) {
+	oldLeft, newLeft := 0, 0
+	for _, line := range strings.Split(diff, "\n") {
+		if oldLeft == 0 && newLeft == 0 {
+			if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+				oldLeft, newLeft = hunkLength(m[1]), hunkLength(m[2])
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+			newLeft--
+		case strings.HasPrefix(line, "-"):
+			deleted++
+			oldLeft--
+		case strings.HasPrefix(line, "\\"):
+			// "\ No newline at end of file"
+		default:
+			oldLeft--
+			newLeft--
+		}
+	}
+	return added, deleted
+}
+
+// hunkLength parses the line count of a hunk header, which is 1 when omitted
+func hunkLength(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+func allFiles(files []git.DiffFile, match func(git.DiffFile) bool) bool {
+	for _, f := range files {
+		if !match(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDocFile(p string) bool {
+	base := strings.ToLower(path.Base(p))
+	switch path.Ext(base) {
+	case ".md", ".rst", ".adoc", ".txt":
+		return true
+	}
+	return strings.HasPrefix(p, "docs/") || strings.HasPrefix(p, "doc/") ||
+		strings.HasPrefix(base, "license") || strings.HasPrefix(base, "changelog")
+}
+
+func isCIFile(p string) bool {
+	return strings.HasPrefix(p, ".github/workflows/") || strings.HasPrefix(p, ".circleci/") ||
+		strings.HasPrefix(p, ".gitlab-ci") || p == "Jenkinsfile" || p == ".travis.yml" || p == "azure-pipelines.yml"
+}
+
+func isBuildFile(p string) bool {
+	switch path.Base(p) {
+	case "Makefile", "Dockerfile", "go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock",
+		"pnpm-lock.yaml", "Cargo.toml", "Cargo.lock", "pom.xml", "build.gradle", "requirements.txt", "pyproject.toml":
+		return true
+	}
+	return strings.HasSuffix(p, ".mk") || strings.HasSuffix(p, ".dockerfile")
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+)
+
+const offlineDiff = `diff --git a/internal/cli/sessions.go b/internal/cli/sessions.go
+--- a/internal/cli/sessions.go
++++ b/internal/cli/sessions.go
+@@ -1,3 +1,4 @@
+ package cli
+-// old
++// new
++// more
+
+diff --git a/internal/cli/usage.go b/internal/cli/usage.go
+new file mode 100644
+--- /dev/null
++++ b/internal/cli/usage.go
+@@ -0,0 +1,2 @@
++package cli
++--- not a header
+`
+
+// unreachableModel fails every request as if the provider were down
+type unreachableModel struct{}
+
+func (m *unreachableModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, m.err()
+}
+
+func (m *unreachableModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, m.err()
+}
+
+func (m *unreachableModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+func (m *unreachableModel) err() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
+
+type unreachableProvider struct{ MockLLMProvider }
+
+func (p *unreachableProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return &unreachableModel{}, nil
+}
+
+func TestGenerateCommitMessage_OfflineFallback(t *testing.T) {
+	opts := CommitAgentOptions{
+		LLMProvider: &unreachableProvider{MockLLMProvider{cfg: config.ModelConfig{Provider: "mock", Model: "test"}}},
+		GitExecutor: git.NewDiffExecutor(offlineDiff),
+	}
+
+	commitAgent, err := NewCommitAgent(opts)
+	require.NoError(t, err)
+	_, err = commitAgent.GenerateCommitMessage(context.Background(), CommitRequest{Language: "en"})
+	assert.True(t, llm.IsUnavailable(err), "without the fallback the error is returned: %v", err)
+
+	opts.OfflineFallback = true
+	commitAgent, err = NewCommitAgent(opts)
+	require.NoError(t, err)
+	response, err := commitAgent.GenerateCommitMessage(context.Background(), CommitRequest{Language: "en"})
+	require.NoError(t, err)
+	assert.True(t, response.Offline)
+	assert.Equal(t, `chore(cli): update 2 files
+
+2 file(s) changed, 4 insertion(s)(+), 1 deletion(s)(-)
+
+- internal/cli/sessions.go (modified)
+- internal/cli/usage.go (added)`, response.Message)
+}
+
+func TestDiffStatsCommitInfo(t *testing.T) {
+	file := func(path, status string) string {
+		header := "diff --git a/" + path + " b/" + path + "\n"
+		if status == "added" {
+			return header + "new file mode 100644\n--- /dev/null\n+++ b/" + path + "\n@@ -0,0 +1 @@\n+x\n"
+		}
+		return header + "--- a/" + path + "\n+++ b/" + path + "\n@@ -1 +1 @@\n-x\n+y\n"
+	}
+
+	tests := []struct {
+		name  string
+		diff  string
+		title string
+	}{
+		{name: "tests", diff: file("internal/git/diff_test.go", "modified") + file("internal/git/testdata/a.diff", "added"), title: "test(git): update 2 files"},
+		{name: "docs", diff: file("README.md", "modified"), title: "docs: update README.md"},
+		{name: "docs directory", diff: file("docs/usage.md", "added") + file("docs/setup.md", "added"), title: "docs: add 2 files"},
+		{name: "ci", diff: file(".github/workflows/test.yml", "modified"), title: "ci: update test.yml"},
+		{name: "build", diff: file("Makefile", "modified") + file("Dockerfile", "modified"), title: "build: update 2 files"},
+		{name: "new feature", diff: file("internal/notify/slack.go", "added") + file("internal/notify/slack_test.go", "added"), title: "feat(notify): add 2 files"},
+		{name: "mixed directories", diff: file("internal/cli/root.go", "modified") + file("internal/git/executor.go", "modified"), title: "chore: update 2 files"},
+		{name: "top-level file", diff: file("main.go", "modified"), title: "chore: update main.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := diffStatsCommitInfo(tt.diff)
+			require.NotNil(t, info)
+			require.NoError(t, info.Validate())
+			assert.Equal(t, tt.title, info.Title())
+		})
+	}
+
+	assert.Nil(t, diffStatsCommitInfo(""))
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// lastAssistantContent returns the most recent non-empty assistant message content
+func lastAssistantContent(messages []*schema.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg != nil && msg.Role == schema.Assistant {
+			if content := strings.TrimSpace(msg.Content); content != "" {
+				return content
+			}
+		}
+	}
+	return ""
+}
+
+// salvageToolArguments decodes the arguments of the most recent call to toolName
+// into v. Truncated JSON (e.g. from a cut-off stream) is repaired on a best-effort basis.
+func salvageToolArguments(messages []*schema.Message, toolName string, v interface{}) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg == nil || msg.Role != schema.Assistant {
+			continue
+		}
+		for j := len(msg.ToolCalls) - 1; j >= 0; j-- {
+			tc := msg.ToolCalls[j]
+			if tc.Function.Name != toolName || strings.TrimSpace(tc.Function.Arguments) == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), v); err == nil {
+				return true
+			}
+			if err := json.Unmarshal([]byte(repairTruncatedJSON(tc.Function.Arguments)), v); err == nil {
+				log.Debug("Salvaged truncated %s arguments", toolName)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repairTruncatedJSON closes unterminated strings, arrays and objects of a truncated JSON document
+func repairTruncatedJSON(s string) string {
+	var closers []byte
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		}
+	}
+
+	repaired := s
+	if escaped {
+		repaired = repaired[:len(repaired)-1]
+	}
+	if inString {
+		repaired += `"`
+	}
+	repaired = strings.TrimRight(repaired, " \t\r\n")
+	// Drop a dangling separator or key without value
+	repaired = strings.TrimSuffix(repaired, ",")
+	if strings.HasSuffix(repaired, ":") {
+		repaired += "null"
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		repaired += string(closers[i])
+	}
+	return repaired
+}
+
+// conventionalTitlePattern matches a conventional commit title line
+var conventionalTitlePattern = regexp.MustCompile(`^(\w+)(?:\(([^)]+)\))?!?:\s+(.+)$`)
+
+// salvageCommitInfo extracts a commit message from a conventional commit title found in text
+func salvageCommitInfo(text string) *CommitInfo {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "`*")
+		matches := conventionalTitlePattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		info := &CommitInfo{
+			Type:        strings.ToLower(matches[1]),
+			Scope:       matches[2],
+			Description: strings.TrimSpace(matches[3]),
+		}
+		if info.Validate() == nil {
+			return info
+		}
+	}
+	return nil
+}
+
+// newAssistantMessage builds an assistant message from streamed content and tool calls
+func newAssistantMessage(content string, toolCalls []*schema.ToolCall) *schema.Message {
+	var toolCallsValue []schema.ToolCall
+	for _, tc := range toolCalls {
+		if tc != nil {
+			toolCallsValue = append(toolCallsValue, *tc)
+		}
+	}
+	return &schema.Message{
+		Role:      schema.Assistant,
+		Content:   content,
+		ToolCalls: toolCallsValue,
+	}
+}
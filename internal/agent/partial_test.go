@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairTruncatedJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"complete", `{"summary":"ok"}`},
+		{"unterminated string", `{"summary":"looks go`},
+		{"open array", `{"issues":[{"severity":"error","title":"nil deref"}`},
+		{"dangling comma", `{"summary":"ok",`},
+		{"dangling key", `{"summary":"ok","issues":`},
+		{"trailing escape", `{"summary":"line\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(repairTruncatedJSON(tt.input)), &v))
+		})
+	}
+}
+
+func TestSalvageToolArguments(t *testing.T) {
+	messages := []*schema.Message{
+		{Role: schema.User, Content: "review"},
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{Function: schema.FunctionCall{Name: "git_diff_cached"}},
+		}},
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{Function: schema.FunctionCall{
+				Name:      "submit_review",
+				Arguments: `{"summary":"Two problems","issues":[{"severity":"error","title":"nil deref","file":"main.go"},{"severity":"warn`,
+			}},
+		}},
+	}
+
+	var params SubmitReviewParams
+	require.True(t, salvageToolArguments(messages, "submit_review", &params))
+	assert.Equal(t, "Two problems", params.Summary)
+	require.Len(t, params.Issues, 2)
+	assert.Equal(t, "nil deref", params.Issues[0].Title)
+
+	var prParams SubmitPRParams
+	assert.False(t, salvageToolArguments(messages, "submit_pr", &prParams))
+}
+
+func TestLastAssistantContent(t *testing.T) {
+	messages := []*schema.Message{
+		{Role: schema.Assistant, Content: "first draft"},
+		{Role: schema.Tool, Content: "tool output"},
+		{Role: schema.Assistant, Content: "  "},
+	}
+	assert.Equal(t, "first draft", lastAssistantContent(messages))
+	assert.Empty(t, lastAssistantContent(nil))
+}
+
+func TestSalvageCommitInfo(t *testing.T) {
+	info := salvageCommitInfo("Here is the message:\n\n**feat(cli): add print-only flag**\n\nMore text")
+	require.NotNil(t, info)
+	assert.Equal(t, "feat", info.Type)
+	assert.Equal(t, "cli", info.Scope)
+	assert.Equal(t, "add print-only flag", info.Description)
+
+	assert.Nil(t, salvageCommitInfo("I will look at the staged changes first."))
+	assert.Nil(t, salvageCommitInfo("Note: nothing is staged"))
+}
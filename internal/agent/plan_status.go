@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// debugPhaseOrder is the order the debugging phases are normally worked through
+var debugPhaseOrder = []DebugPhase{
+	PhaseProblemDefinition,
+	PhaseImpactAnalysis,
+	PhaseRootCauseHypothesis,
+	PhaseInvestigationPlan,
+	PhaseExecution,
+	PhaseVerification,
+	PhaseReporting,
+}
+
+// minETAProgress is the progress a run must make before an ETA is estimated
+const minETAProgress = 0.05
+
+// PlanProgress estimates how far the debugging process is, from 0 to 1. It
+// averages the position of the current phase with the share of finished tasks.
+func PlanProgress(plan *ExecutionPlan) float64 {
+	progress := float64(max(debugPhaseIndex(plan.CurrentPhase), 0)) / float64(len(debugPhaseOrder))
+	if len(plan.Tasks) == 0 {
+		return progress
+	}
+	return (progress + float64(finishedTasks(plan))/float64(len(plan.Tasks))) / 2
+}
+
+// debugPhaseIndex returns the position of phase in debugPhaseOrder, or -1
+func debugPhaseIndex(phase DebugPhase) int {
+	for i, p := range debugPhaseOrder {
+		if p == phase {
+			return i
+		}
+	}
+	return -1
+}
+
+// finishedTasks counts the completed and skipped tasks of plan
+func finishedTasks(plan *ExecutionPlan) int {
+	done := 0
+	for _, task := range plan.Tasks {
+		if task.Status == "completed" || task.Status == "skipped" {
+			done++
+		}
+	}
+	return done
+}
+
+// PlanTracker measures the time spent in each phase during a run and
+// estimates the remaining time from the progress of the plan. Only the
+// current run is measured, so resumed sessions don't count the time between runs.
+type PlanTracker struct {
+	startedAt      time.Time
+	startProgress  float64
+	phase          DebugPhase
+	phaseStartedAt time.Time
+	durations      map[DebugPhase]time.Duration
+	now            func() time.Time
+}
+
+// NewPlanTracker starts tracking plan from its current state
+func NewPlanTracker(plan *ExecutionPlan) *PlanTracker {
+	return newPlanTracker(plan, time.Now)
+}
+
+func newPlanTracker(plan *ExecutionPlan, now func() time.Time) *PlanTracker {
+	start := now()
+	return &PlanTracker{
+		startedAt:      start,
+		startProgress:  PlanProgress(plan),
+		phase:          plan.CurrentPhase,
+		phaseStartedAt: start,
+		durations:      make(map[DebugPhase]time.Duration),
+		now:            now,
+	}
+}
+
+// Observe accounts the time since the last phase change to the phase it was
+// spent in. Call it whenever the plan may have changed phase.
+func (t *PlanTracker) Observe(plan *ExecutionPlan) {
+	if plan.CurrentPhase == t.phase {
+		return
+	}
+	now := t.now()
+	t.durations[t.phase] += now.Sub(t.phaseStartedAt)
+	t.phase = plan.CurrentPhase
+	t.phaseStartedAt = now
+}
+
+// Elapsed returns the time since tracking started
+func (t *PlanTracker) Elapsed() time.Duration {
+	return t.now().Sub(t.startedAt)
+}
+
+// PhaseDuration returns the time spent in phase during this run
+func (t *PlanTracker) PhaseDuration(phase DebugPhase) time.Duration {
+	d := t.durations[phase]
+	if phase == t.phase {
+		d += t.now().Sub(t.phaseStartedAt)
+	}
+	return d
+}
+
+// ETA estimates the remaining time by extrapolating the progress made during
+// this run. It returns false until there is enough progress to extrapolate.
+func (t *PlanTracker) ETA(plan *ExecutionPlan) (time.Duration, bool) {
+	progress := PlanProgress(plan)
+	made := progress - t.startProgress
+	if made < minETAProgress {
+		return 0, false
+	}
+	return time.Duration(float64(t.Elapsed()) * (1 - progress) / made), true
+}
+
+// StatusLine renders a one-line summary of the phase, tasks, iterations,
+// time and tokens. breakdown may be nil.
+func (t *PlanTracker) StatusLine(plan *ExecutionPlan, breakdown *TokenBreakdown, iteration, maxIterations int) string {
+	t.Observe(plan)
+
+	parts := []string{fmt.Sprintf("[%s %d/%d]", plan.CurrentPhase, debugPhaseIndex(plan.CurrentPhase)+1, len(debugPhaseOrder))}
+	if len(plan.Tasks) > 0 {
+		parts = append(parts, fmt.Sprintf("tasks %d/%d", finishedTasks(plan), len(plan.Tasks)))
+	}
+	parts = append(parts, fmt.Sprintf("iteration %d/%d", iteration, maxIterations))
+	parts = append(parts, fmt.Sprintf("%s (phase %s)", formatStatusDuration(t.Elapsed()), formatStatusDuration(t.PhaseDuration(plan.CurrentPhase))))
+
+	if breakdown != nil {
+		total, phase := 0, 0
+		for name, stats := range breakdown.Phases {
+			tokens := stats.PromptTokens + stats.CompletionTokens
+			total += tokens
+			if name == string(plan.CurrentPhase) {
+				phase = tokens
+			}
+		}
+		if total > 0 {
+			parts = append(parts, fmt.Sprintf("%s tokens (phase %s)", formatTokenCount(total), formatTokenCount(phase)))
+		}
+	}
+
+	if eta, ok := t.ETA(plan); ok {
+		parts = append(parts, "ETA ~"+formatStatusDuration(eta))
+	} else {
+		parts = append(parts, "ETA --")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatStatusDuration formats d rounded to seconds, e.g. "1m40s"
+func formatStatusDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// formatTokenCount formats a token count compactly, e.g. "12.3k"
+func formatTokenCount(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("%.1fk", float64(n)/1000)
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock returns a controllable time source
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestPlanProgress(t *testing.T) {
+	plan := NewExecutionPlan()
+	assert.Equal(t, 0.0, PlanProgress(plan))
+
+	plan.TransitionToPhase(string(PhaseExecution), "")
+	assert.InDelta(t, 4.0/7, PlanProgress(plan), 0.001)
+
+	plan.AddTask("1", "Read logs")
+	plan.AddTask("2", "Check config")
+	plan.UpdateTask("1", "completed")
+	assert.InDelta(t, (4.0/7+0.5)/2, PlanProgress(plan), 0.001)
+}
+
+func TestExecutionPlan_PhaseSequence(t *testing.T) {
+	plan := NewExecutionPlan()
+	assert.Equal(t, []string{"problem_definition"}, plan.PhaseSequence())
+
+	plan.TransitionToPhase(string(PhaseImpactAnalysis), "")
+	plan.TransitionToPhase(string(PhaseReporting), "")
+	assert.Equal(t, []string{"problem_definition", "impact_analysis", "reporting"}, plan.PhaseSequence())
+
+	assert.Empty(t, NewTaskPlan().PhaseSequence())
+}
+
+func TestPlanTracker_PhaseDurationsAndETA(t *testing.T) {
+	now, advance := fakeClock()
+	plan := NewExecutionPlan()
+	tracker := newPlanTracker(plan, now)
+
+	advance(30 * time.Second)
+	_, ok := tracker.ETA(plan)
+	assert.False(t, ok, "no ETA before any progress")
+
+	plan.TransitionToPhase(string(PhaseRootCauseHypothesis), "")
+	tracker.Observe(plan)
+	advance(10 * time.Second)
+
+	assert.Equal(t, 30*time.Second, tracker.PhaseDuration(PhaseProblemDefinition))
+	assert.Equal(t, 10*time.Second, tracker.PhaseDuration(PhaseRootCauseHypothesis))
+	assert.Equal(t, 40*time.Second, tracker.Elapsed())
+
+	// 2/7 done in 40s leaves 5/7, i.e. 100s
+	eta, ok := tracker.ETA(plan)
+	assert.True(t, ok)
+	assert.Equal(t, 100*time.Second, eta.Round(time.Second))
+}
+
+func TestPlanTracker_StatusLine(t *testing.T) {
+	now, advance := fakeClock()
+	plan := NewExecutionPlan()
+	tracker := newPlanTracker(plan, now)
+
+	plan.TransitionToPhase(string(PhaseExecution), "")
+	plan.AddTask("1", "Read logs")
+	plan.AddTask("2", "Check config")
+	plan.UpdateTask("1", "completed")
+	advance(90 * time.Second)
+
+	breakdown := NewTokenBreakdown()
+	breakdown.RecordLLMCall(string(PhaseProblemDefinition), nil, 9000, 1000)
+	breakdown.RecordLLMCall(string(PhaseExecution), nil, 2000, 500)
+
+	line := tracker.StatusLine(plan, breakdown, 7, 30)
+	assert.Equal(t, "[execution 5/7] · tasks 1/2 · iteration 7/30 · 1m30s (phase 0s) · 12.5k tokens (phase 2.5k) · ETA ~1m18s", line)
+
+	assert.Contains(t, newPlanTracker(NewExecutionPlan(), now).StatusLine(NewExecutionPlan(), nil, 1, 30), "ETA --")
+}
@@ -6,12 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"text/template"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/apidiff"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
@@ -20,10 +21,15 @@ import (
 
 // PRRequest contains the input for PR description generation
 type PRRequest struct {
-	BaseBranch string // Target branch (e.g., main, develop)
-	HeadBranch string // Source branch (current branch)
-	Language   string // Output language
-	Context    string // Additional context from user
+	BaseBranch  string        // Target branch (e.g., main, develop)
+	HeadBranch  string        // Source branch (current branch)
+	Language    string        // Output language
+	Context     string        // Additional context from user
+	WorkDir     string        // Working directory, used to compare the API surface (empty = skip)
+	MaxDuration time.Duration // Wall-clock budget for the run (0 = unlimited)
+
+	Changelog      string   // Format of the changelog block appended to the description, see ChangelogFormatYAML (empty = none)
+	MigrationPaths []string // Migration files listed in the changelog, as review.migrations.paths
 }
 
 // PRInfo contains PR information
@@ -37,6 +43,9 @@ type PRResponse struct {
 	PRInfo           *PRInfo
 	Title            string
 	Description      string
+	Partial          bool   // True if the description was salvaged after a failure
+	PartialReason    string // Why the description is partial
+	Streamed         bool   // True if the title and description were written to the printer's raw stream
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
@@ -54,14 +63,16 @@ func (r *PRResponse) GetDescription() string {
 
 // PRAgentOptions contains configuration for PRAgent
 type PRAgentOptions struct {
-	Language    string
-	Template    string // Custom PR template, if empty uses default
-	GitExecutor git.Executor
-	LLMProvider llm.Provider
-	Printer     *ui.StreamPrinter
-	Output      io.Writer
-	Debug       bool
-	RetryConfig llm.RetryConfig
+	Language             string
+	Template             string // Custom PR template, if empty uses default
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Output               io.Writer
+	Debug                bool
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
 }
 
 // PRAgent generates PR descriptions using LLM
@@ -83,6 +94,11 @@ type SubmitPRParams struct {
 	Description string `json:"description"`
 }
 
+// artifact returns the title and description as written to the raw stream
+func (p *SubmitPRParams) artifact() string {
+	return p.Title + artifactFieldSeparator + p.Description
+}
+
 // ToPRInfo converts SubmitPRParams to PRInfo
 func (p *SubmitPRParams) ToPRInfo() *PRInfo {
 	return &PRInfo{
@@ -129,24 +145,6 @@ func (a *PRAgent) GeneratePRDescription(ctx context.Context, req PRRequest) (*PR
 		log.Debug(msg)
 	}
 
-	printToolCall := func(name string) {
-		if printer != nil {
-			_ = printer.PrintToolCall(name, nil)
-		}
-		log.Debug("Tool call: %s", name)
-	}
-
-	// estimateTokenCount estimates token count from text
-	// This is a simple heuristic: ~4 chars per token for English, ~1.5 chars per token for Chinese
-	// For mixed content, we use a weighted average
-	printToolResult := func(name string, result string) {
-		if printer != nil {
-			bytes := len(result)
-			tokens := estimateTokenCount(result)
-			_ = printer.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
-		}
-	}
-
 	printInfo := func(msg string) {
 		if printer != nil {
 			_ = printer.PrintInfo(msg)
@@ -163,6 +161,9 @@ func (a *PRAgent) GeneratePRDescription(ctx context.Context, req PRRequest) (*PR
 	if a.opts.LLMProvider == nil {
 		return nil, fmt.Errorf("LLM provider is not configured")
 	}
+	if err := ValidateChangelogFormat(req.Changelog); err != nil {
+		return nil, err
+	}
 
 	providerName := a.opts.LLMProvider.Name()
 	modelName := a.opts.LLMProvider.GetConfig().Model
@@ -187,8 +188,11 @@ func (a *PRAgent) GeneratePRDescription(ctx context.Context, req PRRequest) (*PR
 			Name: "git_diff_branches",
 			Desc: gitDiffBranchesTool.Description(),
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"base": {Type: schema.String, Desc: "Base branch to compare from", Required: true},
-				"head": {Type: schema.String, Desc: "Head branch to compare to (defaults to HEAD)", Required: false},
+				"base":      {Type: schema.String, Desc: "Base branch to compare from", Required: true},
+				"head":      {Type: schema.String, Desc: "Head branch to compare to (defaults to HEAD)", Required: false},
+				"mode":      {Type: schema.String, Desc: "three-dot (default, diff against the merge base) or two-dot (diff the branch tips)", Required: false},
+				"stat_only": {Type: schema.Boolean, Desc: "Only return the per-file summary of changes", Required: false},
+				"paths":     {Type: schema.Array, ElemInfo: &schema.ParameterInfo{Type: schema.String}, Desc: "Limit the diff to these files or directories", Required: false},
 			}),
 		},
 		{
@@ -219,225 +223,97 @@ func (a *PRAgent) GeneratePRDescription(ctx context.Context, req PRRequest) (*PR
 		return nil, fmt.Errorf("failed to bind tools: %w", err)
 	}
 
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+		Artifact:             newArtifactStream(printer, "submit_pr", "title", "description"),
+	})
+	defer runner.PrintDiagnostics()
+	runner.Register("git_diff_branches", tools.Bind[tools.GitDiffBranchesParams](gitDiffBranchesTool.Execute))
+	runner.Register("git_log_range", tools.Bind[tools.GitLogRangeParams](gitLogRangeTool.Execute))
+	runner.Register("git_status", func(ctx context.Context, _ schema.ToolCall) (string, error) {
+		return gitStatusTool.Execute(ctx, nil)
+	})
+
 	// Build system prompt
-	systemPrompt := BuildPRSystemPrompt(req.Language, req.Context, req.BaseBranch, req.HeadBranch, a.opts.Template)
+	systemPrompt := ExtendSystemPrompt(BuildPRSystemPrompt(req.Language, req.Context, req.BaseBranch, req.HeadBranch, a.opts.Template), a.opts.PromptExtension)
 	printInfo(fmt.Sprintf("Generating PR: %s → %s", req.HeadBranch, req.BaseBranch))
 
 	// Initial messages
+	userMessage := fmt.Sprintf("Please generate a PR description for merging branch '%s' into '%s'. Use the available tools to analyze the changes.", req.HeadBranch, req.BaseBranch)
+	breaking := detectBreakingChanges(ctx, req.WorkDir, req.BaseBranch, req.HeadBranch, nil)
+	if len(breaking) > 0 {
+		printInfo(fmt.Sprintf("Found %d breaking API change(s)", len(breaking)))
+		userMessage += "\n\n" + apidiff.FormatChanges(breaking) + "\nA Breaking Changes section listing them is added to the description automatically, so don't write one; do mention the breaking changes in the summary."
+	}
+	changelog := a.prChangelog(ctx, req, breaking)
+	if changelog != nil {
+		userMessage += "\n\nA machine-readable changelog block is appended to the description automatically, so don't write one."
+	}
 	messages := []*schema.Message{
 		{Role: schema.System, Content: systemPrompt},
-		{Role: schema.User, Content: fmt.Sprintf("Please generate a PR description for merging branch '%s' into '%s'. Use the available tools to analyze the changes.", req.HeadBranch, req.BaseBranch)},
+		{Role: schema.User, Content: userMessage},
 	}
 
-	var promptTokens, completionTokens, totalTokens int
-	maxIterations := 10
-
-	// Agent loop
-	for i := 0; i < maxIterations; i++ {
-		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
-
-		// Stream LLM response with retry
-		streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
-			return chatModel.Stream(ctx, messages)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("LLM stream failed: %w", err)
-		}
-
-		var fullContent strings.Builder
-		var toolCalls []*schema.ToolCall
-		var toolArgStarted bool
-
-		printInfo("LLM Response:")
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Read stream
-		for {
-			chunk, err := streamReader.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				streamReader.Close()
-				return nil, fmt.Errorf("stream read error: %w", err)
-			}
-
-			if chunk.Content != "" {
-				fullContent.WriteString(chunk.Content)
-				if printer != nil {
-					_ = printer.PrintLLMContent(chunk.Content)
-				}
-			}
-
-			// Collect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				for _, tc := range chunk.ToolCalls {
-					idx := 0
-					if tc.Index != nil {
-						idx = *tc.Index
-					}
-
-					for len(toolCalls) <= idx {
-						toolCalls = append(toolCalls, &schema.ToolCall{Function: schema.FunctionCall{}})
-					}
-
-					// Collect tool call ID
-					if tc.ID != "" {
-						toolCalls[idx].ID = tc.ID
-					}
-
-					if tc.Function.Name != "" {
-						if toolCalls[idx].Function.Name == "" {
-							printToolCall(tc.Function.Name)
-							if printer != nil {
-								_ = printer.PrintToolArgStart()
-							}
-							toolArgStarted = true
-						}
-						toolCalls[idx].Function.Name = tc.Function.Name
-					}
-					if tc.Function.Arguments != "" {
-						toolCalls[idx].Function.Arguments += tc.Function.Arguments
-						if printer != nil && toolArgStarted {
-							_ = printer.PrintToolArgChunk(tc.Function.Arguments)
-						}
-					}
-				}
-			}
-
-			// Collect token usage
-			if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
-				usage := chunk.ResponseMeta.Usage
-				promptTokens += usage.PromptTokens
-				completionTokens += usage.CompletionTokens
-				totalTokens += usage.TotalTokens
-			}
-		}
-		streamReader.Close()
-
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Add assistant message to history
-		// Convert []*schema.ToolCall to []schema.ToolCall
-		var toolCallsValue []schema.ToolCall
-		for _, tc := range toolCalls {
-			if tc != nil {
-				toolCallsValue = append(toolCallsValue, *tc)
+	// response returns the PR description of params with the tokens used so far
+	response := func(params *SubmitPRParams) *PRResponse {
+		params.Description = AppendBreakingChanges(params.Description, breaking)
+		if changelog != nil {
+			if description, err := AppendChangelog(params.Description, *changelog, req.Changelog); err != nil {
+				log.Debug("Failed to append the changelog: %v", err)
+			} else {
+				params.Description = description
 			}
 		}
-		assistantMsg := &schema.Message{
-			Role:      schema.Assistant,
-			Content:   fullContent.String(),
-			ToolCalls: toolCallsValue,
+		usage := runner.Usage()
+		return &PRResponse{
+			PRInfo:           params.ToPRInfo(),
+			Title:            params.Title,
+			Description:      params.Description,
+			Streamed:         runner.opts.Artifact.Finish(params.artifact()),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
 		}
-		messages = append(messages, assistantMsg)
-
-		// Process tool calls - use intelligent fallback if no tools called
-		if len(toolCalls) == 0 {
-			if err := HandleNoToolCallsResponse(fullContent.String(), "pr"); err != nil {
-				return nil, err
-			}
-			// If we reach here, the response was accepted without tools
-			// For PR agent, we still need structured PR info, so we should return error
-			return nil, fmt.Errorf("PR agent requires tool usage to analyze changes and generate proper PR description")
-		}
-
-		for _, tc := range toolCalls {
-			if tc.Function.Name == "" {
-				continue
-			}
-
-			// Check if it's the final submit_pr call
-			if tc.Function.Name == "submit_pr" {
-				var params SubmitPRParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					log.Debug("Failed to parse submit_pr arguments: %v", err)
-					continue
-				}
-
-				prInfo := params.ToPRInfo()
-				printSuccess("PR description generated successfully")
-
-				return &PRResponse{
-					PRInfo:           prInfo,
-					Title:            params.Title,
-					Description:      params.Description,
-					PromptTokens:     promptTokens,
-					CompletionTokens: completionTokens,
-					TotalTokens:      totalTokens,
-				}, nil
-			}
-
-			// Execute other tools
-			var result string
-			var toolErr error
-
-			switch tc.Function.Name {
-			case "git_diff_branches":
-				var params tools.GitDiffBranchesParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = gitDiffBranchesTool.Execute(ctx, &params)
-				}
-
-			case "git_log_range":
-				var params tools.GitLogRangeParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = gitLogRangeTool.Execute(ctx, &params)
-				}
-
-			case "git_status":
-				result, toolErr = gitStatusTool.Execute(ctx, nil)
-
-			default:
-				toolErr = fmt.Errorf("unknown tool: %s", tc.Function.Name)
-			}
+	}
 
-			// Build tool result message
-			var toolResult string
-			if toolErr != nil {
-				toolResult = fmt.Sprintf("Error: %v", toolErr)
-				log.Debug("Tool %s error: %v", tc.Function.Name, toolErr)
-			} else {
-				toolResult = result
-				printToolResult(tc.Function.Name, result)
+	var submitted SubmitPRParams
+	messages, err = runner.Run(ctx, messages, RunOptions{
+		MaxIterations: 10,
+		Deadline:      NewDeadline(req.MaxDuration),
+		AgentType:     "pr",
+		NoToolsError:  fmt.Errorf("PR agent requires tool usage to analyze changes and generate proper PR description"),
+		SubmitTool:    "submit_pr",
+		Submit: func(call schema.ToolCall) error {
+			var params SubmitPRParams
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
+				return fmt.Errorf("invalid parameters: %w", err)
 			}
-
-			// Add tool result to messages
-			messages = append(messages, &schema.Message{
-				Role:       schema.Tool,
-				Content:    toolResult,
-				ToolCallID: tc.ID,
-			})
-		}
+			submitted = params
+			return nil
+		},
+	})
+	if err == nil {
+		printSuccess("PR description generated successfully")
+		return response(&submitted), nil
 	}
-
-	return nil, fmt.Errorf("agent loop exceeded maximum iterations")
-}
-
-func estimateTokenCount(text string) int {
-	if len(text) == 0 {
-		return 0
+	if isAborted(err) {
+		return nil, err
 	}
-	// Count Chinese characters (CJK unified ideographs)
-	chineseChars := 0
-	for _, r := range text {
-		if r >= 0x4E00 && r <= 0x9FFF {
-			chineseChars++
-		}
+
+	// Salvage a partial PR description from the message history
+	var params SubmitPRParams
+	if !salvageToolArguments(messages, "submit_pr", &params) {
+		params.Description = lastAssistantContent(messages)
 	}
-	// Estimate: Chinese ~1.5 chars/token, others ~4 chars/token
-	otherChars := len([]rune(text)) - chineseChars
-	tokens := (chineseChars * 2 / 3) + (otherChars / 4)
-	if tokens == 0 && len(text) > 0 {
-		tokens = 1 // At least 1 token for non-empty text
+	if params.Title == "" && params.Description == "" {
+		return nil, err
 	}
-	return tokens
+	printProgress(fmt.Sprintf("Returning partial PR description: %v", err))
+	partial := response(&params)
+	partial.Partial = true
+	partial.PartialReason = err.Error()
+	return partial, nil
 }
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/apidiff"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"go.yaml.in/yaml/v3"
+)
+
+// Formats of the changelog block appended to PR descriptions
+const (
+	ChangelogFormatYAML = "yaml"
+	ChangelogFormatJSON = "json"
+)
+
+// ChangelogMarker precedes the changelog block in PR descriptions, so release
+// tooling can find it
+const ChangelogMarker = "<!-- gitbuddy:changelog -->"
+
+// ValidateChangelogFormat checks the format of a changelog block, "" meaning none
+func ValidateChangelogFormat(format string) error {
+	switch format {
+	case "", ChangelogFormatYAML, ChangelogFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid changelog format: %s (valid: yaml, json)", format)
+}
+
+// AppendChangelog appends changelog to description as a fenced block in
+// format (yaml or json) after ChangelogMarker. A description that already
+// has a changelog block is returned unchanged.
+func AppendChangelog(description string, changelog analysis.Changelog, format string) (string, error) {
+	if strings.Contains(description, ChangelogMarker) {
+		return description, nil
+	}
+
+	var block []byte
+	switch format {
+	case ChangelogFormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(changelog); err != nil {
+			return "", fmt.Errorf("failed to encode changelog: %w", err)
+		}
+		block = buf.Bytes()
+	case ChangelogFormatJSON:
+		data, err := json.MarshalIndent(changelog, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode changelog: %w", err)
+		}
+		block = data
+	default:
+		return "", ValidateChangelogFormat(format)
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n```%s\n%s\n```\n", strings.TrimRight(description, "\n"), ChangelogMarker, format, strings.TrimRight(string(block), "\n")), nil
+}
+
+// prChangelog summarizes the changes of the branch for the changelog block,
+// or returns nil when req asks for none or the diff can't be read
+func (a *PRAgent) prChangelog(ctx context.Context, req PRRequest, breaking []apidiff.Change) *analysis.Changelog {
+	if req.Changelog == "" {
+		return nil
+	}
+	diff, err := a.opts.GitExecutor.DiffBranches(ctx, req.BaseBranch, req.HeadBranch, git.DiffOptions{})
+	if err != nil {
+		log.Debug("Failed to read the branch diff for the changelog: %v", err)
+		return nil
+	}
+	changelog := analysis.BuildChangelog(diff, func(path string) bool {
+		return len(MigrationFiles([]string{path}, req.MigrationPaths)) > 0
+	})
+	changelog.Breaking = changelog.Breaking || len(breaking) > 0
+	return &changelog
+}
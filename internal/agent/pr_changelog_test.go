@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestAppendChangelog(t *testing.T) {
+	changelog := analysis.Changelog{
+		SchemaVersion:     analysis.ChangelogSchemaVersion,
+		Modules:           []string{"internal/checkout"},
+		Migrations:        []string{},
+		FeatureFlags:      []string{"new-checkout"},
+		AddedDependencies: []string{},
+	}
+
+	description, err := AppendChangelog("## Summary\n\nAdds currencies.\n", changelog, ChangelogFormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, "## Summary\n\nAdds currencies.\n\n"+ChangelogMarker+"\n```yaml\n"+
+		"schema_version: 1\nmodules:\n  - internal/checkout\nmigrations: []\nfeature_flags:\n  - new-checkout\nadded_dependencies: []\nbreaking: false\n```\n", description)
+
+	var parsed analysis.Changelog
+	block := strings.TrimSuffix(strings.SplitN(description, "```yaml\n", 2)[1], "```\n")
+	require.NoError(t, yaml.Unmarshal([]byte(block), &parsed))
+	assert.Equal(t, changelog, parsed)
+
+	again, err := AppendChangelog(description, changelog, ChangelogFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, description, again, "an existing block is kept")
+
+	description, err = AppendChangelog("Adds currencies.", changelog, ChangelogFormatJSON)
+	require.NoError(t, err)
+	block = strings.TrimSuffix(strings.SplitN(description, "```json\n", 2)[1], "```\n")
+	require.NoError(t, json.Unmarshal([]byte(block), &parsed))
+	assert.Equal(t, changelog, parsed)
+
+	_, err = AppendChangelog("Adds currencies.", changelog, "toml")
+	assert.EqualError(t, err, "invalid changelog format: toml (valid: yaml, json)")
+}
@@ -76,7 +76,9 @@ You have access to the following tools to analyze the changes:
 
 2. **git_diff_branches**: Get the code diff between base and head branches
    - Use this to see the actual code changes
-   - Parameters: base (required), head (optional, defaults to HEAD)
+   - Parameters: base (required), head (optional, defaults to HEAD), mode (optional), stat_only (optional), paths (optional)
+   - The default three-dot mode only shows changes made on head, not upstream changes merged into base
+   - For large branches, call it with stat_only first, then fetch the diff of the relevant paths
 
 3. **git_status**: Get the current repository status
    - Use if needed to understand the current state
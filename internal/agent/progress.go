@@ -0,0 +1,11 @@
+package agent
+
+import "github.com/huimingz/gitbuddy-go/internal/ui"
+
+// emitProgress writes a structured progress event when the printer emits
+// JSON progress (--progress-json)
+func emitProgress(printer *ui.StreamPrinter, event ui.ProgressEvent) {
+	if printer != nil {
+		_ = printer.Emit(event)
+	}
+}
@@ -1,5 +1,11 @@
 package agent
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // CommitSystemPrompt is the system prompt for commit message generation
 const CommitSystemPrompt = `You are a Git commit message generator. Your task is to analyze staged changes and generate commit messages following the Conventional Commits specification.
 
@@ -56,7 +62,7 @@ You have access to the following tools:
 
 4. **submit_commit**: Submit the final commit message
    - Call this when you have analyzed the changes and are ready to commit
-   - Parameters: type, scope (optional), description, body (optional), footer (optional)
+   - Parameters: type, scope (optional), description, body (optional), footer (optional), not_breaking (optional)
 
 ## Workflow
 
@@ -93,6 +99,7 @@ You have access to the following tools:
 2. Use imperative mood in the description
 3. Do not end the description with a period
 4. The body should explain what and why (not how)
+5. Mark breaking changes with a "BREAKING CHANGE: <description>" footer
 
 ## IMPORTANT
 - You MUST use the tools to analyze the changes before submitting
@@ -100,3 +107,54 @@ You have access to the following tools:
 - Do NOT output the commit message as plain text
 - Remember: ALL your output must be in {{.Language}}
 `
+
+// ExtendSystemPrompt appends project-specific guidance (from prompt_extensions) to a system prompt
+func ExtendSystemPrompt(prompt, extension string) string {
+	extension = strings.TrimSpace(extension)
+	if extension == "" {
+		return prompt
+	}
+	return strings.TrimRight(prompt, "\n") + "\n\n## Project-Specific Guidelines\n\nThe team working on this repository requires you to follow these additional guidelines:\n\n" + extension + "\n"
+}
+
+// ExtendWithOwnerConventions appends the documented conventions of the code
+// owners whose areas a change touches, keyed by owner, to a system prompt
+func ExtendWithOwnerConventions(prompt string, conventions map[string]string) string {
+	if len(conventions) == 0 {
+		return prompt
+	}
+	owners := make([]string, 0, len(conventions))
+	for owner := range conventions {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(prompt, "\n"))
+	b.WriteString("\n\n## Code Owner Conventions\n\nThe changes touch code owned by these teams. Check the changes in their areas against their conventions and report violations:\n")
+	for _, owner := range owners {
+		fmt.Fprintf(&b, "\n### %s\n\n%s\n", owner, strings.TrimSpace(conventions[owner]))
+	}
+	return b.String()
+}
+
+// ExtendWithTestConventions appends the conventions of a project's existing
+// tests to a review system prompt, so suggestions about tests fit them
+func ExtendWithTestConventions(prompt string, conventions *TestConventions) string {
+	if conventions == nil || len(conventions.Languages) == 0 {
+		return prompt
+	}
+	return strings.TrimRight(prompt, "\n") + "\n\n## Test Conventions\n\nThe project's existing tests use these frameworks and patterns, with the number of sampled test files using each:\n\n" +
+		conventions.Format() +
+		"\nWhen suggesting tests or reviewing test code, follow these conventions: don't recommend a framework, assertion library or style the project doesn't use, and report new tests that depart from them.\n"
+}
+
+// ExtendWithRepositoryMap appends a map of the repository's structure to a
+// system prompt, so the model starts with an overview instead of exploring
+func ExtendWithRepositoryMap(prompt, repoMap string) string {
+	repoMap = strings.TrimSpace(repoMap)
+	if repoMap == "" {
+		return prompt
+	}
+	return strings.TrimRight(prompt, "\n") + "\n\n## Repository Map\n\nAn overview of the repository's directories, key types and functions, built from its source files. Use it to decide where to look first, and read the files before relying on details:\n\n" + repoMap + "\n"
+}
@@ -0,0 +1,495 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// DefaultRefactorPlanIterations is the default iteration limit of the refactor planning agent
+const DefaultRefactorPlanIterations = 30
+
+// RefactorPlanRequest contains the input for planning a refactoring
+type RefactorPlanRequest struct {
+	Goal          string        // What the refactoring should achieve
+	Scope         []string      // Paths to focus on (empty = whole repository)
+	Context       string        // Additional context from user
+	Language      string        // Output language
+	WorkDir       string        // Working directory
+	MaxIterations int           // Maximum number of agent iterations
+	MaxDuration   time.Duration // Wall-clock budget for the run (0 = unlimited)
+}
+
+// RefactorFileChange is a change to one file in a refactor phase
+type RefactorFileChange struct {
+	File   string `json:"file"`
+	Action string `json:"action"` // create, modify, delete or move
+	Change string `json:"change"` // What changes in the file
+}
+
+// RefactorPhase is a step of a refactoring that leaves the code working
+type RefactorPhase struct {
+	Title   string               `json:"title"`
+	Goal    string               `json:"goal"`
+	Changes []RefactorFileChange `json:"changes"`
+	Risks   []string             `json:"risks,omitempty"`
+	Commits []string             `json:"commits,omitempty"` // Suggested commit messages, one per commit
+}
+
+// RefactorPlan is the phased plan submitted by the agent
+type RefactorPlan struct {
+	Summary string          `json:"summary"`
+	Phases  []RefactorPhase `json:"phases"`
+	Risks   []string        `json:"risks,omitempty"` // Risks of the refactoring as a whole
+}
+
+// Validate checks that the plan has phases with file changes
+func (p *RefactorPlan) Validate() error {
+	if len(p.Phases) == 0 {
+		return fmt.Errorf("the plan has no phases")
+	}
+	for i, phase := range p.Phases {
+		if strings.TrimSpace(phase.Title) == "" {
+			return fmt.Errorf("phase %d has no title", i+1)
+		}
+		if len(phase.Changes) == 0 {
+			return fmt.Errorf("phase %d (%s) lists no file changes", i+1, phase.Title)
+		}
+		for _, change := range phase.Changes {
+			if strings.TrimSpace(change.File) == "" {
+				return fmt.Errorf("phase %d (%s) has a change without a file", i+1, phase.Title)
+			}
+		}
+	}
+	return nil
+}
+
+// Files returns the files the plan touches, in the order they first appear
+func (p *RefactorPlan) Files() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, phase := range p.Phases {
+		for _, change := range phase.Changes {
+			if !seen[change.File] {
+				seen[change.File] = true
+				files = append(files, change.File)
+			}
+		}
+	}
+	return files
+}
+
+// Markdown renders the plan as a Markdown document
+func (p *RefactorPlan) Markdown(goal string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Refactor plan: %s\n\n", goal)
+	if p.Summary != "" {
+		b.WriteString(p.Summary + "\n\n")
+	}
+	fmt.Fprintf(&b, "%d phase(s), %d file(s)\n", len(p.Phases), len(p.Files()))
+
+	for i, phase := range p.Phases {
+		fmt.Fprintf(&b, "\n## Phase %d: %s\n\n", i+1, phase.Title)
+		if phase.Goal != "" {
+			b.WriteString(phase.Goal + "\n\n")
+		}
+		b.WriteString("### Changes\n\n")
+		for _, change := range phase.Changes {
+			action := change.Action
+			if action == "" {
+				action = "modify"
+			}
+			fmt.Fprintf(&b, "- `%s` (%s): %s\n", change.File, action, change.Change)
+		}
+		if len(phase.Risks) > 0 {
+			b.WriteString("\n### Risks\n\n")
+			for _, risk := range phase.Risks {
+				b.WriteString("- " + risk + "\n")
+			}
+		}
+		if len(phase.Commits) > 0 {
+			b.WriteString("\n### Commits\n\n")
+			for j, commit := range phase.Commits {
+				fmt.Fprintf(&b, "%d. %s\n", j+1, commit)
+			}
+		}
+	}
+
+	if len(p.Risks) > 0 {
+		b.WriteString("\n## Overall risks\n\n")
+		for _, risk := range p.Risks {
+			b.WriteString("- " + risk + "\n")
+		}
+	}
+	return b.String()
+}
+
+// RefactorPlanResponse contains the result of refactor planning
+type RefactorPlanResponse struct {
+	Plan             *RefactorPlan
+	Tasks            []PlanTask // Exploration tasks the agent tracked while planning
+	Partial          bool       // True if the plan was salvaged before it was submitted
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// RefactorPlanAgentOptions contains configuration for RefactorPlanAgent
+type RefactorPlanAgentOptions struct {
+	Language             string
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	MaxLinesPerRead      int
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
+}
+
+// RefactorPlanAgent explores the code read-only and plans a refactoring in
+// phases, tracking its exploration with the same execution plan as DebugAgent
+type RefactorPlanAgent struct {
+	opts RefactorPlanAgentOptions
+}
+
+// NewRefactorPlanAgent creates a new RefactorPlanAgent
+func NewRefactorPlanAgent(opts RefactorPlanAgentOptions) *RefactorPlanAgent {
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.MaxLinesPerRead <= 0 {
+		opts.MaxLinesPerRead = tools.DefaultMaxLinesPerRead
+	}
+	return &RefactorPlanAgent{opts: opts}
+}
+
+// BuildRefactorPlanSystemPrompt builds the system prompt for refactor planning
+func BuildRefactorPlanSystemPrompt(language, goal, scope, context string) string {
+	tmpl, err := template.New("refactor_prompt").Parse(RefactorPlanSystemPrompt)
+	if err != nil {
+		return RefactorPlanSystemPrompt
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"Language": language,
+		"Goal":     goal,
+		"Scope":    scope,
+		"Context":  context,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return RefactorPlanSystemPrompt
+	}
+	return buf.String()
+}
+
+// newRefactorTools creates the read-only tools of the planning agent for
+// workDir, tracking tasks in plan
+func (a *RefactorPlanAgent) newRefactorTools(workDir string, plan *ExecutionPlan) *tools.ToolRegistry {
+	readFile := tools.NewReadFileTool(workDir, a.opts.MaxLinesPerRead)
+	outline := tools.NewFileOutlineTool(workDir)
+	list := tools.NewListFilesTool(workDir, tools.DefaultMaxFiles)
+	listDir := tools.NewListDirectoryTool(workDir)
+	grepFile := tools.NewGrepFileTool(workDir, tools.DefaultMaxFileSize)
+	grepDir := tools.NewGrepDirectoryTool(workDir, tools.DefaultMaxFileSize, tools.DefaultMaxResults, tools.DefaultGrepTimeout)
+	planTool := tools.NewUpdateExecutionPlanTool(plan)
+
+	return tools.NewToolRegistry(
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "read_file",
+				Desc: readFile.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path":     {Type: schema.String, Desc: "Path to the file to read", Required: true},
+					"start_line":    {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
+					"end_line":      {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+					"around_symbol": {Type: schema.String, Desc: "Function, method, type or class to read instead of a line range", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ReadFileParams](readFile.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "file_outline",
+				Desc: outline.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the source file", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.FileOutlineParams](outline.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "list_files",
+				Desc: "Find files matching a glob pattern",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"pattern": {Type: schema.String, Desc: "Glob pattern, e.g. *.go", Required: true},
+					"path":    {Type: schema.String, Desc: "Directory to search in", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ListFilesParams](list.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "list_directory",
+				Desc: listDir.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"path":      {Type: schema.String, Desc: "Directory to list", Required: true},
+					"recursive": {Type: schema.Boolean, Desc: "List subdirectories recursively", Required: false},
+					"max_depth": {Type: schema.Integer, Desc: "Maximum depth when recursive", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ListDirectoryParams](listDir.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "grep_file",
+				Desc: grepFile.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the file to search", Required: true},
+					"pattern":   {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.GrepFileParams](grepFile.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "grep_directory",
+				Desc: grepDir.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"directory":     {Type: schema.String, Desc: "Path to the directory to search", Required: true},
+					"pattern":       {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+					"patterns":      {Type: schema.Array, Desc: "More patterns; a line matching any pattern matches", Required: false},
+					"not_pattern":   {Type: schema.String, Desc: "Leave out lines that also match this pattern", Required: false},
+					"recursive":     {Type: schema.Boolean, Desc: "Search subdirectories recursively", Required: false},
+					"file_pattern":  {Type: schema.String, Desc: "Glob pattern to filter files (e.g., '*.go')", Required: false},
+					"exclude_paths": {Type: schema.Array, Desc: "Globs of files or directories to skip (e.g., ['*_test.go', 'testdata'])", Required: false},
+					"max_results":   {Type: schema.Integer, Desc: "Maximum number of matches to return", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.GrepDirectoryParams](grepDir.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "update_execution_plan",
+				Desc: planTool.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"action":      {Type: schema.String, Desc: "Action to perform: add, update, remove, or show", Required: true},
+					"task_id":     {Type: schema.String, Desc: "Unique identifier for the task (required for update/remove)", Required: false},
+					"description": {Type: schema.String, Desc: "Task description (required for add)", Required: false},
+					"status":      {Type: schema.String, Desc: "Task status: pending, in_progress, completed, or skipped (required for update)", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.UpdateExecutionPlanParams](planTool.Execute),
+		},
+	)
+}
+
+// Plan explores the code and returns the submitted refactor plan
+func (a *RefactorPlanAgent) Plan(ctx context.Context, req RefactorPlanRequest) (*RefactorPlanResponse, error) {
+	printer := a.opts.Printer
+
+	printProgress := func(msg string) {
+		if printer != nil {
+			_ = printer.PrintProgress(msg)
+		}
+		log.Debug(msg)
+	}
+	printInfo := func(msg string) {
+		if printer != nil {
+			_ = printer.PrintInfo(msg)
+		}
+	}
+
+	if strings.TrimSpace(req.Goal) == "" {
+		return nil, fmt.Errorf("refactoring goal is required")
+	}
+	if a.opts.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider is not configured")
+	}
+	language := req.Language
+	if language == "" {
+		language = a.opts.Language
+	}
+	maxIterations := req.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultRefactorPlanIterations
+	}
+
+	providerName := a.opts.LLMProvider.Name()
+	printProgress(fmt.Sprintf("Initializing LLM provider (%s/%s)...", providerName, a.opts.LLMProvider.GetConfig().Model))
+	chatModel, err := a.opts.LLMProvider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat model: %w", err)
+	}
+	if chatModel == nil {
+		return nil, fmt.Errorf("chat model is nil (provider: %s)", providerName)
+	}
+
+	plan := NewTaskPlan()
+	lastPlanSnapshot := plan.Clone().(*ExecutionPlan)
+	toolSet := a.newRefactorTools(req.WorkDir, plan)
+	if err := chatModel.BindTools(refactorToolInfos(toolSet)); err != nil {
+		return nil, fmt.Errorf("failed to bind tools: %w", err)
+	}
+
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+	})
+	defer runner.PrintDiagnostics()
+	toolSet.Wrap("update_execution_plan", func(next tools.Handler) tools.Handler {
+		return func(ctx context.Context, call schema.ToolCall) (string, error) {
+			result, err := next(ctx, call)
+			if err == nil {
+				// Show plan changes compactly instead of the whole plan
+				for _, change := range plan.GetChanges(lastPlanSnapshot) {
+					printInfo(change)
+				}
+				lastPlanSnapshot = plan.Clone().(*ExecutionPlan)
+			}
+			return result, err
+		}
+	})
+	runner.RegisterTools(toolSet)
+
+	systemPrompt := BuildRefactorPlanSystemPrompt(language, req.Goal, strings.Join(req.Scope, ", "), req.Context)
+	messages := []*schema.Message{
+		{Role: schema.System, Content: ExtendSystemPrompt(systemPrompt, a.opts.PromptExtension)},
+		{Role: schema.User, Content: "Plan this refactoring: " + req.Goal},
+	}
+
+	response := &RefactorPlanResponse{}
+	finish := func(result *RefactorPlan, partial bool) *RefactorPlanResponse {
+		response.Plan = result
+		response.Partial = partial
+		response.Tasks = plan.Tasks
+		return response
+	}
+	// salvage returns the last plan the agent tried to submit, or cause
+	salvage := func(cause error) (*RefactorPlanResponse, error) {
+		var result RefactorPlan
+		if !salvageToolArguments(messages, "submit_refactor_plan", &result) || len(result.Phases) == 0 {
+			return nil, cause
+		}
+		printProgress(fmt.Sprintf("Returning partial plan: %v", cause))
+		return finish(&result, true), nil
+	}
+
+	printInfo("Exploring the code...")
+	deadline := NewDeadline(req.MaxDuration)
+	for i := 0; i < maxIterations; i++ {
+		if ctx.Err() != nil {
+			return salvage(ctx.Err())
+		}
+		if deadline.Exceeded() {
+			return salvage(deadline.Err())
+		}
+		if deadline.WrapUp() {
+			printProgress("Time budget nearly used up, asking the agent to submit")
+			messages = append(messages, &schema.Message{Role: schema.User, Content: deadline.WrapUpMessage("submit_refactor_plan")})
+		}
+		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
+		emitProgress(printer, ui.ProgressEvent{Event: ui.EventIteration, Iteration: i + 1, MaxIterations: maxIterations})
+
+		var result *StreamResult
+		messages, result, err = runner.Stream(ctx, messages)
+		usage := runner.Usage()
+		response.PromptTokens, response.CompletionTokens, response.TotalTokens = usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens
+		if err != nil {
+			return salvage(err)
+		}
+		assistantMsg := result.Message
+		if assistantMsg.Content == "" && len(assistantMsg.ToolCalls) == 0 {
+			return salvage(fmt.Errorf("LLM returned an empty response"))
+		}
+
+		if len(assistantMsg.ToolCalls) == 0 {
+			messages = append(messages, &schema.Message{
+				Role:    schema.User,
+				Content: "Call submit_refactor_plan to submit the plan; the plan is only accepted through that tool.",
+			})
+			continue
+		}
+
+		for _, tc := range assistantMsg.ToolCalls {
+			if tc.Function.Name == "submit_refactor_plan" {
+				var result RefactorPlan
+				err := unmarshalToolArgs(tc.Function.Arguments, &result)
+				if err == nil {
+					err = result.Validate()
+				}
+				if err != nil {
+					// Let the model fix the plan
+					messages = append(messages, &schema.Message{Role: schema.Tool, Content: fmt.Sprintf("Error: %v. Fix the plan and submit it again.", err), ToolCallID: tc.ID})
+					continue
+				}
+				if printer != nil {
+					_ = printer.PrintSuccess("Refactor plan completed")
+				}
+				return finish(&result, false), nil
+			}
+
+			reply, err := runner.Execute(ctx, tc)
+			if err != nil {
+				return salvage(err)
+			}
+			messages = append(messages, reply)
+		}
+	}
+
+	return salvage(fmt.Errorf("agent loop exceeded maximum iterations"))
+}
+
+// refactorToolInfos describes the tools of toolSet and the submit tool
+func refactorToolInfos(toolSet *tools.ToolRegistry) []*schema.ToolInfo {
+	stringList := func(desc string) *schema.ParameterInfo {
+		return &schema.ParameterInfo{Type: schema.Array, ElemInfo: &schema.ParameterInfo{Type: schema.String}, Desc: desc}
+	}
+	return append(toolSet.Infos(), &schema.ToolInfo{
+		Name: "submit_refactor_plan",
+		Desc: "Submit the phased refactor plan. Call this once the exploration is done.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"summary": {Type: schema.String, Desc: "The approach in a few sentences", Required: true},
+			"phases": {
+				Type:     schema.Array,
+				Desc:     "Phases in order; each leaves the code building and the tests passing",
+				Required: true,
+				ElemInfo: &schema.ParameterInfo{
+					Type: schema.Object,
+					SubParams: map[string]*schema.ParameterInfo{
+						"title": {Type: schema.String, Desc: "Short phase title", Required: true},
+						"goal":  {Type: schema.String, Desc: "What the phase achieves"},
+						"changes": {
+							Type:     schema.Array,
+							Desc:     "Every file the phase changes",
+							Required: true,
+							ElemInfo: &schema.ParameterInfo{
+								Type: schema.Object,
+								SubParams: map[string]*schema.ParameterInfo{
+									"file":   {Type: schema.String, Desc: "File path", Required: true},
+									"action": {Type: schema.String, Desc: "create, modify, delete or move", Enum: []string{"create", "modify", "delete", "move"}},
+									"change": {Type: schema.String, Desc: "What changes in the file", Required: true},
+								},
+							},
+						},
+						"risks":   stringList("What could break in this phase and how to check it"),
+						"commits": stringList("Suggested commit messages, one per commit"),
+					},
+				},
+			},
+			"risks": stringList("Risks of the refactoring as a whole"),
+		}),
+	})
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRefactorPlan() *RefactorPlan {
+	return &RefactorPlan{
+		Summary: "Introduce Runner next to Executor, migrate callers, then remove Executor.",
+		Phases: []RefactorPhase{
+			{
+				Title: "Introduce Runner",
+				Goal:  "Add the new interface without changing callers",
+				Changes: []RefactorFileChange{
+					{File: "internal/run/runner.go", Action: "create", Change: "Add the Runner interface"},
+					{File: "internal/run/executor.go", Change: "Implement Runner"},
+				},
+				Commits: []string{"Add Runner interface"},
+			},
+			{
+				Title: "Remove Executor",
+				Changes: []RefactorFileChange{
+					{File: "internal/run/executor.go", Action: "delete", Change: "Remove the old type"},
+				},
+				Risks: []string{"External packages may still use Executor"},
+			},
+		},
+		Risks: []string{"No tests cover the retry path"},
+	}
+}
+
+func TestRefactorPlan_Validate(t *testing.T) {
+	assert.NoError(t, sampleRefactorPlan().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*RefactorPlan)
+		errMsg string
+	}{
+		{"no phases", func(p *RefactorPlan) { p.Phases = nil }, "no phases"},
+		{"missing title", func(p *RefactorPlan) { p.Phases[1].Title = " " }, "phase 2 has no title"},
+		{"no changes", func(p *RefactorPlan) { p.Phases[0].Changes = nil }, "lists no file changes"},
+		{"change without file", func(p *RefactorPlan) { p.Phases[1].Changes[0].File = "" }, "change without a file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := sampleRefactorPlan()
+			tt.modify(plan)
+			err := plan.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestRefactorPlan_Markdown(t *testing.T) {
+	plan := sampleRefactorPlan()
+	assert.Equal(t, []string{"internal/run/runner.go", "internal/run/executor.go"}, plan.Files())
+
+	md := plan.Markdown("rename Executor to Runner")
+	assert.Contains(t, md, "# Refactor plan: rename Executor to Runner")
+	assert.Contains(t, md, "2 phase(s), 2 file(s)")
+	assert.Contains(t, md, "## Phase 1: Introduce Runner")
+	assert.Contains(t, md, "- `internal/run/executor.go` (modify): Implement Runner")
+	assert.Contains(t, md, "- `internal/run/executor.go` (delete): Remove the old type")
+	assert.Contains(t, md, "1. Add Runner interface")
+	assert.Contains(t, md, "- External packages may still use Executor")
+	assert.Contains(t, md, "## Overall risks\n\n- No tests cover the retry path")
+}
+
+func TestRefactorTools_ReadOnly(t *testing.T) {
+	a := NewRefactorPlanAgent(RefactorPlanAgentOptions{})
+	toolSet := a.newRefactorTools(t.TempDir(), NewTaskPlan())
+
+	var names []string
+	for _, info := range refactorToolInfos(toolSet) {
+		names = append(names, info.Name)
+	}
+	assert.Contains(t, names, "update_execution_plan")
+	assert.Contains(t, names, "submit_refactor_plan")
+	for _, write := range []string{"write_file", "edit_file", "append_file", "run_command"} {
+		assert.NotContains(t, names, write)
+	}
+}
+
+func TestNewTaskPlan_SummaryWithoutPhase(t *testing.T) {
+	plan := NewTaskPlan()
+	plan.AddTask("1", "Find callers of Executor")
+
+	summary := plan.GetSummary()
+	assert.Contains(t, summary, "Find callers of Executor")
+	assert.NotContains(t, summary, "Phase")
+}
@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// DefaultMaxUntrackedSize is the size from which untracked files are reported by the doctor
+const DefaultMaxUntrackedSize = 10 * 1024 * 1024
+
+// maxListedFiles caps the files named in a single check
+const maxListedFiles = 5
+
+// Doctor check results
+const (
+	DoctorOK      = "ok"
+	DoctorWarning = "warning"
+	DoctorError   = "error"
+)
+
+// DoctorCheck is the result of one repository health check
+type DoctorCheck struct {
+	Name   string
+	Status string // DoctorOK, DoctorWarning or DoctorError
+	Detail string
+	Fixes  []string // Commands that resolve the problem
+	Hint   string   // Further advice that is not a command
+}
+
+// DoctorReport lists the results of all repository health checks
+type DoctorReport struct {
+	Checks []DoctorCheck
+}
+
+// Count returns the number of checks with status
+func (r *DoctorReport) Count(status string) int {
+	n := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// DiagnoseRepository checks the repository containing workDir for problems
+// that make GitBuddy fail or produce poor results. Untracked files of at least
+// maxUntrackedSize bytes are reported (DefaultMaxUntrackedSize when <= 0).
+func DiagnoseRepository(ctx context.Context, workDir string, maxUntrackedSize int64) (*DoctorReport, error) {
+	if _, err := git.IsShallow(ctx, workDir); err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	if maxUntrackedSize <= 0 {
+		maxUntrackedSize = DefaultMaxUntrackedSize
+	}
+
+	report := &DoctorReport{}
+	for _, check := range []func(context.Context, string) (DoctorCheck, error){
+		checkIdentity,
+		checkHead,
+		checkHistory,
+		func(ctx context.Context, workDir string) (DoctorCheck, error) {
+			return checkUntrackedFiles(ctx, workDir, maxUntrackedSize)
+		},
+		checkLFSPointers,
+	} {
+		result, err := check(ctx, workDir)
+		if err != nil {
+			return nil, err
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report, nil
+}
+
+func checkIdentity(ctx context.Context, workDir string) (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Git identity"}
+	name, err := git.ConfigValue(ctx, workDir, "user.name")
+	if err != nil {
+		return check, err
+	}
+	email, err := git.ConfigValue(ctx, workDir, "user.email")
+	if err != nil {
+		return check, err
+	}
+
+	if name != "" && email != "" {
+		check.Status = DoctorOK
+		check.Detail = fmt.Sprintf("%s <%s>", name, email)
+		return check, nil
+	}
+	check.Status = DoctorError
+	check.Detail = "user.name or user.email is not set; commits fail and reports can't find your commits"
+	if name == "" {
+		check.Fixes = append(check.Fixes, `git config --global user.name "Your Name"`)
+	}
+	if email == "" {
+		check.Fixes = append(check.Fixes, `git config --global user.email "you@example.com"`)
+	}
+	return check, nil
+}
+
+func checkHead(ctx context.Context, workDir string) (DoctorCheck, error) {
+	check := DoctorCheck{Name: "HEAD"}
+	branch, err := git.HeadBranch(ctx, workDir)
+	if err != nil {
+		return check, err
+	}
+	if branch != "" {
+		check.Status = DoctorOK
+		check.Detail = "on branch " + branch
+		return check, nil
+	}
+	check.Status = DoctorWarning
+	check.Detail = "HEAD is detached; pr, sync-check and notes need a branch to compare and attach to"
+	check.Fixes = []string{"git switch -c <new-branch>   # keep working here on a new branch", "git switch <branch>          # or go back to an existing branch"}
+	return check, nil
+}
+
+func checkHistory(ctx context.Context, workDir string) (DoctorCheck, error) {
+	check := DoctorCheck{Name: "History"}
+	shallow, err := git.IsShallow(ctx, workDir)
+	if err != nil {
+		return check, err
+	}
+	if !shallow {
+		check.Status = DoctorOK
+		check.Detail = "full clone"
+		return check, nil
+	}
+	check.Status = DoctorWarning
+	check.Detail = "shallow clone; report, pr and the git_log_date tool only see the fetched commits and merge bases may be missing"
+	check.Fixes = []string{"git fetch --unshallow"}
+	check.Hint = "In GitHub Actions, set fetch-depth: 0 on actions/checkout"
+	return check, nil
+}
+
+func checkUntrackedFiles(ctx context.Context, workDir string, maxSize int64) (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Untracked files"}
+	files, err := git.UntrackedFiles(ctx, workDir)
+	if err != nil {
+		return check, err
+	}
+
+	var large []git.UntrackedFile
+	for _, file := range files {
+		if file.Size >= maxSize {
+			large = append(large, file)
+		}
+	}
+	if len(large) == 0 {
+		check.Status = DoctorOK
+		check.Detail = fmt.Sprintf("%d untracked file(s), none of %s or more", len(files), formatFileSize(maxSize))
+		return check, nil
+	}
+
+	sort.Slice(large, func(i, j int) bool { return large[i].Size > large[j].Size })
+	names := make([]string, 0, maxListedFiles)
+	for i, file := range large {
+		if i == maxListedFiles {
+			names = append(names, fmt.Sprintf("and %d more", len(large)-maxListedFiles))
+			break
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", file.Path, formatFileSize(file.Size)))
+		check.Fixes = append(check.Fixes, fmt.Sprintf(`echo '/%s' >> "$(git rev-parse --show-toplevel)/.gitignore"`, file.Path))
+	}
+	check.Status = DoctorWarning
+	check.Detail = fmt.Sprintf("%d large untracked file(s) slow down the file and search tools and are easily staged by accident: %s",
+		len(large), strings.Join(names, ", "))
+	return check, nil
+}
+
+func checkLFSPointers(ctx context.Context, workDir string) (DoctorCheck, error) {
+	check := DoctorCheck{Name: "Git LFS"}
+	diff, err := git.NewExecutor(workDir).DiffCached(ctx)
+	if err != nil {
+		return check, err
+	}
+
+	pointers := git.LFSPointersInDiff(diff)
+	if len(pointers) == 0 {
+		check.Status = DoctorOK
+		check.Detail = "no LFS pointers in the staged changes"
+		return check, nil
+	}
+	if len(pointers) > maxListedFiles {
+		pointers = append(pointers[:maxListedFiles], fmt.Sprintf("and %d more", len(pointers)-maxListedFiles))
+	}
+	check.Status = DoctorWarning
+	check.Detail = "staged LFS files only show up as pointers, so commit and review can't see what changed in: " + strings.Join(pointers, ", ")
+	check.Fixes = []string{`gitbuddy commit -c "Describe the change to the LFS files"`}
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		check.Hint = "Git LFS is not installed; install it and run git lfs install && git lfs pull"
+	}
+	return check, nil
+}
+
+// formatFileSize formats a size in bytes, e.g. "12.5 MB"
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size), "KMGT"
+	i := -1
+	for value >= unit && i < len(suffix)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %cB", value, suffix[i])
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestDiagnoseRepository(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+	runGit(t, dir, "add", "main.go")
+	runGit(t, dir, "commit", "--quiet", "-m", "Initial commit")
+
+	report, err := DiagnoseRepository(ctx, dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(report.Checks), report.Count(DoctorOK))
+
+	// Detached HEAD, a large untracked file and a staged LFS pointer
+	runGit(t, dir, "checkout", "--quiet", "--detach")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dump.bin"), make([]byte, 4096), 0644))
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte(pointer), 0644))
+	runGit(t, dir, "add", "logo.png")
+
+	report, err = DiagnoseRepository(ctx, dir, 1024)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, map[string]string{
+		"Git identity":    DoctorOK,
+		"HEAD":            DoctorWarning,
+		"History":         DoctorOK,
+		"Untracked files": DoctorWarning,
+		"Git LFS":         DoctorWarning,
+	}, statuses)
+	assert.Equal(t, 3, report.Count(DoctorWarning))
+	assert.Contains(t, report.Checks[3].Detail, "dump.bin (4.0 KB)")
+	assert.Equal(t, []string{`echo '/dump.bin' >> "$(git rev-parse --show-toplevel)/.gitignore"`}, report.Checks[3].Fixes)
+	assert.Contains(t, report.Checks[4].Detail, "logo.png")
+
+	_, err = DiagnoseRepository(ctx, t.TempDir(), 0)
+	assert.Error(t, err)
+}
+
+func TestFormatFileSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatFileSize(512))
+	assert.Equal(t, "1.5 KB", formatFileSize(1536))
+	assert.Equal(t, "10.0 MB", formatFileSize(DefaultMaxUntrackedSize))
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/cloudwego/eino/schema"
 
@@ -20,11 +21,12 @@ import (
 
 // ReportRequest contains the input for report generation
 type ReportRequest struct {
-	Since    string // Start date
-	Until    string // End date
-	Author   string // Author name
-	Language string // Output language
-	Context  string // Additional context from user
+	Since       string        // Start date
+	Until       string        // End date
+	Author      string        // Author name
+	Language    string        // Output language
+	Context     string        // Additional context from user
+	MaxDuration time.Duration // Wall-clock budget for the run (0 = unlimited)
 }
 
 // ReportInfo contains structured report information
@@ -130,6 +132,8 @@ func (r *ReportInfo) FormatReport() string {
 type ReportResponse struct {
 	ReportInfo       *ReportInfo
 	Content          string
+	Partial          bool   // True if the report was salvaged after a failure
+	PartialReason    string // Why the report is partial
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
@@ -150,13 +154,15 @@ func (r *ReportResponse) GetContent() string {
 
 // ReportAgentOptions contains configuration for ReportAgent
 type ReportAgentOptions struct {
-	Language    string
-	GitExecutor git.Executor
-	LLMProvider llm.Provider
-	Printer     *ui.StreamPrinter
-	Output      io.Writer
-	Debug       bool
-	RetryConfig llm.RetryConfig
+	Language             string
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Output               io.Writer
+	Debug                bool
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
 }
 
 // ReportAgent generates development reports using LLM
@@ -235,24 +241,6 @@ func (a *ReportAgent) GenerateReport(ctx context.Context, req ReportRequest) (*R
 		log.Debug(msg)
 	}
 
-	printToolCall := func(name string) {
-		if printer != nil {
-			_ = printer.PrintToolCall(name, nil)
-		}
-		log.Debug("Tool call: %s", name)
-	}
-
-	// estimateTokenCount estimates token count from text
-	// This is a simple heuristic: ~4 chars per token for English, ~1.5 chars per token for Chinese
-	// For mixed content, we use a weighted average
-	printToolResult := func(name string, result string) {
-		if printer != nil {
-			bytes := len(result)
-			tokens := estimateTokenCount(result)
-			_ = printer.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
-		}
-	}
-
 	printInfo := func(msg string) {
 		if printer != nil {
 			_ = printer.PrintInfo(msg)
@@ -295,6 +283,7 @@ func (a *ReportAgent) GenerateReport(ctx context.Context, req ReportRequest) (*R
 				"since":  {Type: schema.String, Desc: "Start date in YYYY-MM-DD format", Required: true},
 				"until":  {Type: schema.String, Desc: "End date in YYYY-MM-DD format (optional)", Required: false},
 				"author": {Type: schema.String, Desc: "Filter by author name (optional)", Required: false},
+				"format": {Type: schema.String, Desc: "Output format: text (default) or json with changed files and insert/delete counts", Required: false},
 			}),
 		},
 		{
@@ -325,8 +314,20 @@ func (a *ReportAgent) GenerateReport(ctx context.Context, req ReportRequest) (*R
 		return nil, fmt.Errorf("failed to bind tools: %w", err)
 	}
 
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+	})
+	defer runner.PrintDiagnostics()
+	runner.Register("git_log_date", tools.Bind[tools.GitLogDateParams](gitLogDateTool.Execute))
+	runner.Register("git_status", func(ctx context.Context, _ schema.ToolCall) (string, error) {
+		return gitStatusTool.Execute(ctx, nil)
+	})
+
 	// Build system prompt
-	systemPrompt := BuildReportSystemPrompt(req.Language, req.Context, req.Since, req.Until, req.Author)
+	systemPrompt := ExtendSystemPrompt(BuildReportSystemPrompt(req.Language, req.Context, req.Since, req.Until, req.Author), a.opts.PromptExtension)
 	printInfo(fmt.Sprintf("Generating report: %s to %s", req.Since, req.Until))
 	if req.Author != "" {
 		printInfo(fmt.Sprintf("Author: %s", req.Author))
@@ -344,191 +345,65 @@ func (a *ReportAgent) GenerateReport(ctx context.Context, req ReportRequest) (*R
 		{Role: schema.User, Content: userMsg},
 	}
 
-	var promptTokens, completionTokens, totalTokens int
-	maxIterations := 10
-
-	// Agent loop
-	for i := 0; i < maxIterations; i++ {
-		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
-
-		// Stream LLM response with retry
-		streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
-			return chatModel.Stream(ctx, messages)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("LLM stream failed: %w", err)
-		}
-
-		var fullContent strings.Builder
-		var toolCalls []*schema.ToolCall
-		var toolArgStarted bool
-
-		printInfo("LLM Response:")
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Read stream
-		for {
-			chunk, err := streamReader.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				streamReader.Close()
-				return nil, fmt.Errorf("stream read error: %w", err)
-			}
-
-			if chunk.Content != "" {
-				fullContent.WriteString(chunk.Content)
-				if printer != nil {
-					_ = printer.PrintLLMContent(chunk.Content)
-				}
+	var submitted SubmitReportParams
+	messages, err = runner.Run(ctx, messages, RunOptions{
+		MaxIterations: 10,
+		Deadline:      NewDeadline(req.MaxDuration),
+		AgentType:     "report",
+		NoToolsError:  fmt.Errorf("report agent requires tool usage to fetch commit data and generate proper reports"),
+		SubmitTool:    "submit_report",
+		Submit: func(call schema.ToolCall) error {
+			var params SubmitReportParams
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &params); err != nil {
+				return fmt.Errorf("invalid parameters: %w", err)
 			}
-
-			// Collect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				for _, tc := range chunk.ToolCalls {
-					idx := 0
-					if tc.Index != nil {
-						idx = *tc.Index
-					}
-
-					for len(toolCalls) <= idx {
-						toolCalls = append(toolCalls, &schema.ToolCall{Function: schema.FunctionCall{}})
-					}
-
-					// Collect tool call ID
-					if tc.ID != "" {
-						toolCalls[idx].ID = tc.ID
-					}
-
-					if tc.Function.Name != "" {
-						if toolCalls[idx].Function.Name == "" {
-							printToolCall(tc.Function.Name)
-							if printer != nil {
-								_ = printer.PrintToolArgStart()
-							}
-							toolArgStarted = true
-						}
-						toolCalls[idx].Function.Name = tc.Function.Name
-					}
-					if tc.Function.Arguments != "" {
-						toolCalls[idx].Function.Arguments += tc.Function.Arguments
-						if printer != nil && toolArgStarted {
-							_ = printer.PrintToolArgChunk(tc.Function.Arguments)
-						}
-					}
-				}
-			}
-
-			// Collect token usage
-			if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
-				usage := chunk.ResponseMeta.Usage
-				promptTokens += usage.PromptTokens
-				completionTokens += usage.CompletionTokens
-				totalTokens += usage.TotalTokens
-			}
-		}
-		streamReader.Close()
-
-		if printer != nil {
-			_ = printer.Newline()
-		}
-
-		// Add assistant message to history
-		// Convert []*schema.ToolCall to []schema.ToolCall
-		var toolCallsValue []schema.ToolCall
-		for _, tc := range toolCalls {
-			if tc != nil {
-				toolCallsValue = append(toolCallsValue, *tc)
-			}
-		}
-		assistantMsg := &schema.Message{
-			Role:      schema.Assistant,
-			Content:   fullContent.String(),
-			ToolCalls: toolCallsValue,
-		}
-		messages = append(messages, assistantMsg)
-
-		// Process tool calls - use intelligent fallback if no tools called
-		if len(toolCalls) == 0 {
-			if err := HandleNoToolCallsResponse(fullContent.String(), "report"); err != nil {
-				return nil, err
-			}
-			// If we reach here, the response was accepted without tools
-			// For report agent, we still need structured report data, so we should return error
-			return nil, fmt.Errorf("report agent requires tool usage to fetch commit data and generate proper reports")
+			submitted = params
+			return nil
+		},
+	})
+	usage := runner.Usage()
+	if err == nil {
+		if submitted.Author == "" && req.Author != "" {
+			submitted.Author = req.Author
 		}
+		reportInfo := submitted.ToReportInfo()
+		printSuccess("Development report generated successfully")
+		return &ReportResponse{
+			ReportInfo:       reportInfo,
+			Content:          reportInfo.FormatReport(),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}, nil
+	}
+	if isAborted(err) {
+		return nil, err
+	}
 
-		for _, tc := range toolCalls {
-			if tc.Function.Name == "" {
-				continue
-			}
-
-			// Check if it's the final submit_report call
-			if tc.Function.Name == "submit_report" {
-				var params SubmitReportParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					log.Debug("Failed to parse submit_report arguments: %v", err)
-					continue
-				}
-
-				// Set author if not provided by LLM
-				if params.Author == "" && req.Author != "" {
-					params.Author = req.Author
-				}
-
-				reportInfo := params.ToReportInfo()
-				printSuccess("Development report generated successfully")
-
-				return &ReportResponse{
-					ReportInfo:       reportInfo,
-					Content:          reportInfo.FormatReport(),
-					PromptTokens:     promptTokens,
-					CompletionTokens: completionTokens,
-					TotalTokens:      totalTokens,
-				}, nil
-			}
-
-			// Execute other tools
-			var result string
-			var toolErr error
-
-			switch tc.Function.Name {
-			case "git_log_date":
-				var params tools.GitLogDateParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = gitLogDateTool.Execute(ctx, &params)
-				}
-
-			case "git_status":
-				result, toolErr = gitStatusTool.Execute(ctx, nil)
-
-			default:
-				toolErr = fmt.Errorf("unknown tool: %s", tc.Function.Name)
-			}
-
-			// Build tool result message
-			var toolResult string
-			if toolErr != nil {
-				toolResult = fmt.Sprintf("Error: %v", toolErr)
-				log.Debug("Tool %s error: %v", tc.Function.Name, toolErr)
-			} else {
-				toolResult = result
-				printToolResult(tc.Function.Name, result)
-			}
-
-			// Add tool result to messages
-			messages = append(messages, &schema.Message{
-				Role:       schema.Tool,
-				Content:    toolResult,
-				ToolCallID: tc.ID,
-			})
+	// Salvage a partial report from the message history
+	var params SubmitReportParams
+	var reportInfo *ReportInfo
+	content := ""
+	if salvageToolArguments(messages, "submit_report", &params) {
+		if params.Author == "" && req.Author != "" {
+			params.Author = req.Author
 		}
+		reportInfo = params.ToReportInfo()
+		content = reportInfo.FormatReport()
+	} else {
+		content = lastAssistantContent(messages)
 	}
-
-	return nil, fmt.Errorf("agent loop exceeded maximum iterations")
+	if content == "" {
+		return nil, err
+	}
+	printProgress(fmt.Sprintf("Returning partial report: %v", err))
+	return &ReportResponse{
+		ReportInfo:       reportInfo,
+		Content:          content,
+		Partial:          true,
+		PartialReason:    err.Error(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}, nil
 }
@@ -50,7 +50,8 @@ You have access to the following tools:
 
 1. **git_log_date**: Get commit history within a date range
    - Use this to fetch commits for the report period
-   - Parameters: since (required), until (optional), author (optional)
+   - Parameters: since (required), until (optional), author (optional), format (optional)
+   - Use format "json" to get changed files and insert/delete counts per commit when you need to aggregate activity by area
 
 2. **git_status**: Get current repository status
    - Use if needed to understand current state
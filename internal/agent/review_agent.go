@@ -14,6 +14,8 @@ import (
 
 	"github.com/huimingz/gitbuddy-go/internal/agent/session"
 	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/apidiff"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
@@ -34,19 +36,30 @@ const (
 	CategoryPerformance = "performance"
 	CategoryStyle       = "style"
 	CategorySuggestion  = "suggestion"
+
+	CategoryBreakingChange = "breaking-change" // Reported by the API comparison, not the model
 )
 
 // ReviewRequest contains the input for code review
 type ReviewRequest struct {
-	Language              string           // Output language
-	Context               string           // Additional context from user
-	Files                 []string         // Specific files to review (empty = all staged)
-	Severity              string           // Minimum severity filter (error, warning, info)
-	Focus                 []string         // Focus areas (security, performance, style)
-	WorkDir               string           // Working directory
-	MaxLines              int              // Maximum lines per file read
-	Session               *session.Session // Optional session to resume from
-	PreGeneratedSessionID string           // Optional pre-generated session ID
+	Language              string            // Output language
+	Context               string            // Additional context from user
+	Mode                  string            // What is reviewed, see the ReviewMode constants (empty = staged changes)
+	Target                string            // Revisions of the range and commit modes, directory of the directory mode
+	Files                 []string          // Specific files to review (empty = all reviewed files)
+	Severity              string            // Minimum severity filter (error, warning, info)
+	SeverityRules         []SeverityRule    // Escalation rules applied before the severity filter
+	Focus                 []string          // Focus areas (security, performance, style)
+	WorkDir               string            // Working directory
+	APIBase               string            // Revision the staged API surface is compared with; the range and commit modes compare their own revisions when set (empty = skip the comparison)
+	LicensePolicy         *LicensePolicy    // License header and dependency rules checked locally (nil = skip the check)
+	OwnerConventions      map[string]string // Conventions of the code owners of the reviewed files, keyed by owner
+	MaxLines              int               // Maximum lines per file read
+	MaxDuration           time.Duration     // Wall-clock budget for the run (0 = unlimited)
+	Session               *session.Session  // Optional session to resume from
+	PreGeneratedSessionID string            // Optional pre-generated session ID
+
+	migrations bool // Migration pass, set by ReviewMigrations
 }
 
 // ReviewIssue represents a single issue found during review
@@ -58,6 +71,9 @@ type ReviewIssue struct {
 	Title       string `json:"title"`       // Brief title
 	Description string `json:"description"` // Detailed explanation
 	Suggestion  string `json:"suggestion"`  // How to fix (optional)
+
+	EscalatedFrom string `json:"escalated_from,omitempty"` // Severity before severity rules raised it
+	Fingerprint   string `json:"fingerprint,omitempty"`    // Identifies the issue across runs, see IssueFingerprint
 }
 
 // ReviewResponse contains the result of code review
@@ -65,6 +81,8 @@ type ReviewResponse struct {
 	Issues           []ReviewIssue
 	Summary          string
 	SessionID        string // Session ID for resuming
+	Partial          bool   // True if the review was salvaged after a failure
+	PartialReason    string // Why the review is partial
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
@@ -82,16 +100,20 @@ func (r *ReviewResponse) GetSummary() string {
 
 // ReviewAgentOptions contains configuration for ReviewAgent
 type ReviewAgentOptions struct {
-	Language        string
-	GitExecutor     git.Executor
-	LLMProvider     llm.Provider
-	Printer         *ui.StreamPrinter
-	Output          io.Writer
-	Debug           bool
-	WorkDir         string
-	MaxLinesPerRead int
-	RetryConfig     llm.RetryConfig
-	SessionManager  *session.Manager
+	Language             string
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Output               io.Writer
+	Debug                bool
+	WorkDir              string
+	MaxLinesPerRead      int
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int    // Identical consecutive tool calls tolerated before aborting (0 = default)
+	MaxDiffLines         int    // Staged diff lines above which git_diff_cached returns per-file statistics (0 = default, negative = no limit)
+	PromptExtension      string // Project-specific guidance appended to the system prompt
+	FunctionContextLines int    // Max lines of enclosing-function context appended to the staged diff (0 = disabled)
+	SessionManager       *session.Manager
 }
 
 // ReviewAgent performs code review using LLM
@@ -116,8 +138,13 @@ type SubmitReviewParams struct {
 	Summary string        `json:"summary"`
 }
 
-// BuildReviewSystemPrompt builds the system prompt for review
+// BuildReviewSystemPrompt builds the system prompt for reviewing the staged changes
 func BuildReviewSystemPrompt(language, context, files, focus, minSeverity string) string {
+	return buildReviewSystemPrompt(ReviewRequest{Language: language, Context: context, Severity: minSeverity}, files, focus)
+}
+
+// buildReviewSystemPrompt builds the system prompt for reviewing what req selects
+func buildReviewSystemPrompt(req ReviewRequest, files, focus string) string {
 	tmpl, err := template.New("review_prompt").Parse(ReviewSystemPrompt)
 	if err != nil {
 		return ReviewSystemPrompt
@@ -125,11 +152,13 @@ func BuildReviewSystemPrompt(language, context, files, focus, minSeverity string
 
 	var buf bytes.Buffer
 	data := map[string]string{
-		"Language":    language,
-		"Context":     context,
+		"Language":    req.Language,
+		"Context":     req.Context,
 		"Files":       files,
 		"Focus":       focus,
-		"MinSeverity": minSeverity,
+		"MinSeverity": req.Severity,
+		"Scope":       req.scope(),
+		"DiffTool":    req.diffTool(),
 	}
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return ReviewSystemPrompt
@@ -137,9 +166,12 @@ func BuildReviewSystemPrompt(language, context, files, focus, minSeverity string
 	return buf.String()
 }
 
-// Review performs code review on staged changes
+// Review performs code review on the staged changes, or what req.Mode selects
 func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewResponse, error) {
 	printer := a.opts.Printer
+	if err := req.validateMode(); err != nil {
+		return nil, err
+	}
 
 	// Helper functions
 	printProgress := func(msg string) {
@@ -149,21 +181,6 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 		log.Debug(msg)
 	}
 
-	printToolCall := func(name string) {
-		if printer != nil {
-			_ = printer.PrintToolCall(name, nil)
-		}
-		log.Debug("Tool call: %s", name)
-	}
-
-	printToolResult := func(name string, result string) {
-		if printer != nil {
-			bytes := len(result)
-			tokens := estimateTokenCount(result)
-			_ = printer.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
-		}
-	}
-
 	printInfo := func(msg string) {
 		if printer != nil {
 			_ = printer.PrintInfo(msg)
@@ -194,7 +211,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 	}
 
 	// Create tools
-	gitDiffCachedTool := tools.NewGitDiffCachedTool(a.opts.GitExecutor)
+	diffToolInfo, diffToolHandler := a.reviewDiffTool(req)
 	gitStatusTool := tools.NewGitStatusTool(a.opts.GitExecutor)
 
 	maxLines := req.MaxLines
@@ -202,6 +219,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 		maxLines = a.opts.MaxLinesPerRead
 	}
 	readFileTool := tools.NewReadFileTool(req.WorkDir, maxLines)
+	fileOutlineTool := tools.NewFileOutlineTool(req.WorkDir)
 
 	// Create grep tools
 	grepFileTool := tools.NewGrepFileTool(req.WorkDir, tools.DefaultMaxFileSize)
@@ -209,11 +227,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 
 	// Define tool schemas
 	toolInfos := []*schema.ToolInfo{
-		{
-			Name:        "git_diff_cached",
-			Desc:        gitDiffCachedTool.Description(),
-			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{}),
-		},
+		diffToolInfo,
 		{
 			Name:        "git_status",
 			Desc:        gitStatusTool.Description(),
@@ -223,9 +237,19 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 			Name: "read_file",
 			Desc: readFileTool.Description(),
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
-				"file_path":  {Type: schema.String, Desc: "Path to the file to read", Required: true},
-				"start_line": {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
-				"end_line":   {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+				"file_path":     {Type: schema.String, Desc: "Path to the file to read", Required: true},
+				"start_line":    {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
+				"end_line":      {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+				"around_symbol": {Type: schema.String, Desc: "Function, method, type or class to read, e.g. \"Execute\" or \"ReadFileTool.Execute\"", Required: false},
+				"head":          {Type: schema.Integer, Desc: "Read only the first N lines", Required: false},
+				"tail":          {Type: schema.Integer, Desc: "Read only the last N lines", Required: false},
+			}),
+		},
+		{
+			Name: "file_outline",
+			Desc: fileOutlineTool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"file_path": {Type: schema.String, Desc: "Path to the source file", Required: true},
 			}),
 		},
 		{
@@ -246,8 +270,11 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 				"directory":      {Type: schema.String, Desc: "Path to the directory to search", Required: true},
 				"pattern":        {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+				"patterns":       {Type: schema.Array, Desc: "More patterns; a line matching any pattern matches", Required: false},
+				"not_pattern":    {Type: schema.String, Desc: "Leave out lines that also match this pattern", Required: false},
 				"recursive":      {Type: schema.Boolean, Desc: "Search subdirectories recursively", Required: false},
 				"file_pattern":   {Type: schema.String, Desc: "Glob pattern to filter files (e.g., '*.go')", Required: false},
+				"exclude_paths":  {Type: schema.Array, Desc: "Globs of files or directories to skip (e.g., ['*_test.go', 'testdata'])", Required: false},
 				"ignore_case":    {Type: schema.Boolean, Desc: "Perform case-insensitive search", Required: false},
 				"before_context": {Type: schema.Integer, Desc: "Number of lines to show before each match", Required: false},
 				"after_context":  {Type: schema.Integer, Desc: "Number of lines to show after each match", Required: false},
@@ -282,13 +309,41 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 	}
 
 	// Build system prompt
-	systemPrompt := BuildReviewSystemPrompt(req.Language, req.Context, filesStr, focusStr, req.Severity)
+	systemPrompt := buildReviewSystemPrompt(req, filesStr, focusStr)
+	if req.migrations {
+		systemPrompt = buildMigrationReviewSystemPrompt(req, filesStr)
+	}
+	systemPrompt = ExtendSystemPrompt(systemPrompt, a.opts.PromptExtension)
+	systemPrompt = ExtendWithOwnerConventions(systemPrompt, req.OwnerConventions)
+	if !req.migrations && req.WorkDir != "" {
+		if conventions, err := DetectTestConventions(ctx, req.WorkDir); err != nil {
+			log.Debug("Failed to detect test conventions: %v", err)
+		} else {
+			systemPrompt = ExtendWithTestConventions(systemPrompt, conventions)
+		}
+	}
 	printInfo("Starting code review...")
 
 	// Initial messages
-	userMessage := "Please review the staged code changes and provide your findings."
-	if len(req.Files) > 0 {
-		userMessage = fmt.Sprintf("Please review the staged changes in these files: %s", filesStr)
+	userMessage := req.reviewMessage(ctx)
+	analyzedExecutor := req.analyzedExecutor(ctx, a.opts.GitExecutor)
+	if facts := stagedDiffFacts(ctx, analyzedExecutor, req.Files); len(facts) > 0 {
+		printInfo(fmt.Sprintf("Found %d API and dependency change(s) in the diff", len(facts)))
+		userMessage += "\n\n" + analysis.FormatFacts(facts)
+	}
+	var breaking []apidiff.Change
+	if base, head := req.apiRange(); base != "" {
+		breaking = detectBreakingChanges(ctx, req.WorkDir, base, head, req.Files)
+	}
+	detectedIssues := BreakingChangeIssues(breaking)
+	if len(breaking) > 0 {
+		printInfo(fmt.Sprintf("Found %d breaking API change(s)", len(breaking)))
+		userMessage += "\n\n" + apidiff.FormatChanges(breaking) + "\nThese are already reported as errors; don't report them again, but do report callers in the diff that still use the old API."
+	}
+	if licenseIssues := stagedLicenseIssues(ctx, analyzedExecutor, req.WorkDir, req.LicensePolicy, req.Files); len(licenseIssues) > 0 {
+		printInfo(fmt.Sprintf("Found %d license issue(s)", len(licenseIssues)))
+		detectedIssues = append(detectedIssues, licenseIssues...)
+		userMessage += "\n\n" + formatLicenseIssues(licenseIssues) + "\nThese are already reported; don't report them again."
 	}
 
 	messages := []*schema.Message{
@@ -349,6 +404,56 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 		printProgress(fmt.Sprintf("Created new session %s", sessionID))
 	}
 
+	// Token usage breakdown per tool, restored when resuming
+	if currentSession.Metadata == nil {
+		currentSession.Metadata = make(map[string]string)
+	}
+	tokenBreakdown := LoadTokenBreakdown(currentSession.Metadata, messages)
+	defer printTokenBreakdown(printer, tokenBreakdown)
+
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+		Usage:                currentSession.TokenUsage,
+	})
+	defer runner.PrintDiagnostics()
+	runner.Register(diffToolInfo.Name, diffToolHandler)
+	runner.Register("file_outline", tools.Bind[tools.FileOutlineParams](fileOutlineTool.Execute))
+	runner.Register("git_status", func(ctx context.Context, _ schema.ToolCall) (string, error) {
+		return gitStatusTool.Execute(ctx, nil)
+	})
+	runner.Register("read_file", tools.Bind[tools.ReadFileParams](readFileTool.Execute))
+	runner.Register("grep_file", tools.Bind[tools.GrepFileParams](grepFileTool.Execute))
+	runner.Register("grep_directory", tools.Bind[tools.GrepDirectoryParams](grepDirectoryTool.Execute))
+
+	// salvage returns a partial review from the message history,
+	// or the original error if there is nothing to salvage
+	salvage := func(cause error) (*ReviewResponse, error) {
+		var params SubmitReviewParams
+		if !salvageToolArguments(messages, "submit_review", &params) {
+			params.Summary = lastAssistantContent(messages)
+		}
+		if len(params.Issues) == 0 && params.Summary == "" {
+			return nil, cause
+		}
+		printProgress(fmt.Sprintf("Returning partial review: %v", cause))
+		issues, _ := EscalateSeverity(req.submittedIssues(detectedIssues, params.Issues), req.SeverityRules)
+		return &ReviewResponse{
+			Issues:           filterIssuesBySeverity(issues, req.Severity),
+			Summary:          params.Summary,
+			SessionID:        sessionID,
+			Partial:          true,
+			PartialReason:    cause.Error(),
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      totalTokens,
+		}, nil
+	}
+
+	deadline := NewDeadline(req.MaxDuration)
+
 	// Agent loop
 	for i := 0; i < maxIterations; i++ {
 		// Check if context was cancelled (e.g., due to Ctrl+C)
@@ -367,6 +472,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 				}
 
 				// Save session on cancellation
+				currentSession.SetStatus(session.StatusInterrupted)
 				if err := a.opts.SessionManager.Save(currentSession); err != nil {
 					log.Debug("Failed to save session on cancellation: %v", err)
 				} else {
@@ -385,110 +491,31 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 			// Continue with normal execution
 		}
 
-		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
-
-		// Stream LLM response
-		// Stream LLM response with retry
-		streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
-			return chatModel.Stream(ctx, messages)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("LLM stream failed: %w", err)
+		if deadline.Exceeded() {
+			return salvage(deadline.Err())
 		}
-
-		var fullContent strings.Builder
-		var toolCalls []*schema.ToolCall
-		var toolArgStarted bool
-
-		printInfo("LLM Response:")
-		if printer != nil {
-			_ = printer.Newline()
+		if deadline.WrapUp() {
+			printProgress("Time budget nearly used up, asking the agent to submit")
+			messages = append(messages, &schema.Message{Role: schema.User, Content: deadline.WrapUpMessage("submit_review")})
 		}
 
-		// Read stream
-		for {
-			chunk, err := streamReader.Recv()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				streamReader.Close()
-				return nil, fmt.Errorf("stream read error: %w", err)
-			}
-
-			if chunk.Content != "" {
-				fullContent.WriteString(chunk.Content)
-				if printer != nil {
-					_ = printer.PrintLLMContent(chunk.Content)
-				}
-			}
-
-			// Collect tool calls
-			if len(chunk.ToolCalls) > 0 {
-				for _, tc := range chunk.ToolCalls {
-					idx := 0
-					if tc.Index != nil {
-						idx = *tc.Index
-					}
-
-					for len(toolCalls) <= idx {
-						toolCalls = append(toolCalls, &schema.ToolCall{Function: schema.FunctionCall{}})
-					}
-
-					if tc.ID != "" {
-						toolCalls[idx].ID = tc.ID
-					}
-
-					if tc.Function.Name != "" {
-						if toolCalls[idx].Function.Name == "" {
-							printToolCall(tc.Function.Name)
-							if printer != nil {
-								_ = printer.PrintToolArgStart()
-							}
-							toolArgStarted = true
-						}
-						toolCalls[idx].Function.Name = tc.Function.Name
-					}
-					if tc.Function.Arguments != "" {
-						toolCalls[idx].Function.Arguments += tc.Function.Arguments
-						if printer != nil && toolArgStarted {
-							_ = printer.PrintToolArgChunk(tc.Function.Arguments)
-						}
-					}
-				}
-			}
-
-			// Collect token usage
-			if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
-				usage := chunk.ResponseMeta.Usage
-				promptTokens += usage.PromptTokens
-				completionTokens += usage.CompletionTokens
-				totalTokens += usage.TotalTokens
-			}
-		}
-		streamReader.Close()
+		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
+		emitProgress(printer, ui.ProgressEvent{Event: ui.EventIteration, Iteration: i + 1, MaxIterations: maxIterations})
 
-		if printer != nil {
-			_ = printer.Newline()
+		var result *StreamResult
+		messages, result, err = runner.Stream(ctx, messages)
+		usage := runner.Usage()
+		promptTokens, completionTokens, totalTokens = usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens
+		if err != nil {
+			return salvage(err)
 		}
 
-		// Add assistant message to history
-		var toolCallsValue []schema.ToolCall
-		for _, tc := range toolCalls {
-			if tc != nil {
-				toolCallsValue = append(toolCallsValue, *tc)
-			}
-		}
-		assistantMsg := &schema.Message{
-			Role:      schema.Assistant,
-			Content:   fullContent.String(),
-			ToolCalls: toolCallsValue,
-		}
-		messages = append(messages, assistantMsg)
+		tokenBreakdown.RecordLLMCall("review", result.Sent, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+		tokenBreakdown.StoreIn(currentSession.Metadata)
 
 		// Process tool calls - use intelligent fallback if no tools called
-		if len(toolCalls) == 0 {
-			if err := HandleNoToolCallsResponse(fullContent.String(), "review"); err != nil {
+		if len(result.Message.ToolCalls) == 0 {
+			if err := HandleNoToolCallsResponse(result.Message.Content, "review"); err != nil {
 				return nil, err
 			}
 			// If we reach here, the response was accepted without tools
@@ -497,7 +524,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 			return nil, fmt.Errorf("review agent requires tool usage to examine code and provide thorough analysis")
 		}
 
-		for _, tc := range toolCalls {
+		for _, tc := range result.Message.ToolCalls {
 			if tc.Function.Name == "" {
 				continue
 			}
@@ -507,11 +534,16 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 				var params SubmitReviewParams
 				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
 					log.Debug("Failed to parse submit_review arguments: %v", err)
+					messages = append(messages, toolReply(tc, fmt.Sprintf("Error: invalid parameters: %v", err)))
 					continue
 				}
 
-				// Filter issues by severity if specified
-				filteredIssues := filterIssuesBySeverity(params.Issues, req.Severity)
+				// Apply escalation rules, then filter issues by severity if specified
+				issues, escalated := EscalateSeverity(req.submittedIssues(detectedIssues, params.Issues), req.SeverityRules)
+				if escalated > 0 {
+					printProgress(fmt.Sprintf("Escalated the severity of %d issue(s) by severity rules", escalated))
+				}
+				filteredIssues := filterIssuesBySeverity(issues, req.Severity)
 
 				printSuccess("Code review completed successfully")
 
@@ -527,6 +559,7 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 					}
 
 					// Save final session
+					currentSession.SetStatus(session.StatusCompleted)
 					if err := a.opts.SessionManager.Save(currentSession); err != nil {
 						log.Debug("Failed to save final session: %v", err)
 					} else {
@@ -544,65 +577,31 @@ func (a *ReviewAgent) Review(ctx context.Context, req ReviewRequest) (*ReviewRes
 				}, nil
 			}
 
-			// Execute other tools
-			var result string
-			var toolErr error
-
-			switch tc.Function.Name {
-			case "git_diff_cached":
-				result, toolErr = gitDiffCachedTool.Execute(ctx, nil)
-
-			case "git_status":
-				result, toolErr = gitStatusTool.Execute(ctx, nil)
-
-			case "read_file":
-				var params tools.ReadFileParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = readFileTool.Execute(ctx, &params)
-				}
-
-			case "grep_file":
-				var params tools.GrepFileParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = grepFileTool.Execute(ctx, &params)
-				}
-
-			case "grep_directory":
-				var params tools.GrepDirectoryParams
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
-					toolErr = fmt.Errorf("invalid parameters: %w", err)
-				} else {
-					result, toolErr = grepDirectoryTool.Execute(ctx, &params)
-				}
-
-			default:
-				toolErr = fmt.Errorf("unknown tool: %s", tc.Function.Name)
+			reply, err := runner.Execute(ctx, tc)
+			if err != nil {
+				return salvage(err)
 			}
+			messages = append(messages, reply)
+		}
 
-			// Build tool result message
-			var toolResult string
-			if toolErr != nil {
-				toolResult = fmt.Sprintf("Error: %v", toolErr)
-				log.Debug("Tool %s error: %v", tc.Function.Name, toolErr)
-			} else {
-				toolResult = result
-				printToolResult(tc.Function.Name, result)
+		// Checkpoint the session, so a crash loses at most the current iteration
+		if a.opts.SessionManager != nil && currentSession != nil {
+			currentSession.Messages = messages
+			currentSession.IterationCount = i + 1
+			currentSession.MaxIterations = maxIterations
+			currentSession.TokenUsage = session.TokenUsage{
+				PromptTokens:     promptTokens,
+				CompletionTokens: completionTokens,
+				TotalTokens:      totalTokens,
+			}
+			currentSession.SetStatus(session.StatusRunning)
+			if err := a.opts.SessionManager.Checkpoint(currentSession); err != nil {
+				log.Debug("Failed to checkpoint session: %v", err)
 			}
-
-			// Add tool result to messages
-			messages = append(messages, &schema.Message{
-				Role:       schema.Tool,
-				Content:    toolResult,
-				ToolCallID: tc.ID,
-			})
 		}
 	}
 
-	return nil, fmt.Errorf("agent loop exceeded maximum iterations")
+	return salvage(fmt.Errorf("agent loop exceeded maximum iterations"))
 }
 
 // filterIssuesBySeverity filters issues based on minimum severity level
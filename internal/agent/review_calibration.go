@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// DefaultCalibrationPath is where the review calibration is saved, relative
+// to the repository root. Commit it to share it with the team.
+const DefaultCalibrationPath = ".gitbuddy/review_calibration.md"
+
+// CalibrationMaxAge is the age after which a calibration is due for a refresh
+const CalibrationMaxAge = 30 * 24 * time.Hour
+
+// Limits of the comments sent to the model
+const (
+	maxCalibrationCommentChars = 1000
+	maxCalibrationInputChars   = 60000
+)
+
+// ReviewCalibrationPrompt is the system prompt for distilling review preferences
+const ReviewCalibrationPrompt = `You are helping an AI code reviewer match the preferences of a software team.
+
+## Task
+
+The user message contains review comments human reviewers left on the team's pull requests. Distill what this team actually cares about into guidelines for the AI reviewer:
+1. The kinds of problems the reviewers flag again and again, most frequent first
+2. Conventions they enforce (naming, error handling, testing, documentation, structure)
+3. What they rarely or never comment on, so the AI reviewer doesn't flag it either
+4. The tone and level of detail of their comments
+
+## Rules
+
+- Write 5 to 15 short bullet points in English, each an instruction to the reviewer ("Flag ...", "Don't comment on ...")
+- Only state preferences supported by several comments; ignore one-off remarks
+- Don't name reviewers, pull requests or files, and don't quote code
+- Output only the bullet points, without a heading or introduction`
+
+// ReviewerComment is a comment a human reviewer left on a pull request
+type ReviewerComment struct {
+	Author string
+	Path   string
+	Body   string
+}
+
+// ReviewCalibration is the review guidance distilled from past human reviews
+type ReviewCalibration struct {
+	GeneratedAt time.Time
+	Source      string // Repository the comments came from, e.g. owner/repo
+	Comments    int    // Number of comments distilled
+	Guidelines  string // Markdown bullet points, appended to the review prompt
+}
+
+// calibrationHeaderPattern matches the first line of a saved calibration
+var calibrationHeaderPattern = regexp.MustCompile(`^<!-- gitbuddy review calibration: generated (\S+) from (\d+) review comments of (\S+) -->$`)
+
+// Stale reports whether the calibration is older than CalibrationMaxAge. A
+// calibration of unknown age, written by hand, is never stale.
+func (c *ReviewCalibration) Stale(now time.Time) bool {
+	return !c.GeneratedAt.IsZero() && now.Sub(c.GeneratedAt) > CalibrationMaxAge
+}
+
+// Save writes the calibration to path. The guidelines may be edited by hand;
+// the header line records when and from what they were generated.
+func (c *ReviewCalibration) Save(path string) error {
+	if err := sideeffect.Check("save review calibration"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create calibration directory: %w", err)
+	}
+	content := fmt.Sprintf("<!-- gitbuddy review calibration: generated %s from %d review comments of %s -->\n\n%s\n",
+		c.GeneratedAt.UTC().Format(time.RFC3339), c.Comments, c.Source, strings.TrimSpace(c.Guidelines))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write review calibration: %w", err)
+	}
+	return nil
+}
+
+// LoadReviewCalibration reads a calibration saved by Save. A file without the
+// header line is used as is, with an unknown generation time.
+func LoadReviewCalibration(path string) (*ReviewCalibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := strings.TrimSpace(string(data))
+	calibration := &ReviewCalibration{Guidelines: content}
+	header, rest, _ := strings.Cut(content, "\n")
+	if m := calibrationHeaderPattern.FindStringSubmatch(strings.TrimSpace(header)); m != nil {
+		calibration.GeneratedAt, _ = time.Parse(time.RFC3339, m[1])
+		calibration.Comments, _ = strconv.Atoi(m[2])
+		calibration.Source = m[3]
+		calibration.Guidelines = strings.TrimSpace(rest)
+	}
+	return calibration, nil
+}
+
+// ReviewCalibrationAgentOptions contains configuration for ReviewCalibrationAgent
+type ReviewCalibrationAgentOptions struct {
+	LLMProvider llm.Provider
+	Printer     *ui.StreamPrinter
+	RetryConfig llm.RetryConfig
+}
+
+// ReviewCalibrationAgent distills past review comments into review guidelines
+// using a single LLM call
+type ReviewCalibrationAgent struct {
+	opts ReviewCalibrationAgentOptions
+}
+
+// NewReviewCalibrationAgent creates a new ReviewCalibrationAgent
+func NewReviewCalibrationAgent(opts ReviewCalibrationAgentOptions) *ReviewCalibrationAgent {
+	return &ReviewCalibrationAgent{opts: opts}
+}
+
+// CalibrationResponse contains the distilled guidelines
+type CalibrationResponse struct {
+	Guidelines       string
+	Comments         int // Comments sent to the model
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// Calibrate distills the review preferences shown by comments
+func (a *ReviewCalibrationAgent) Calibrate(ctx context.Context, comments []ReviewerComment) (*CalibrationResponse, error) {
+	if len(comments) == 0 {
+		return nil, errors.New("no review comments to calibrate from")
+	}
+	if a.opts.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider is not configured")
+	}
+	printer := a.opts.Printer
+
+	input, used := formatReviewerComments(comments)
+	providerName := a.opts.LLMProvider.Name()
+	if printer != nil {
+		_ = printer.PrintProgress(fmt.Sprintf("Distilling %d review comments (%s/%s)...", used, providerName, a.opts.LLMProvider.GetConfig().Model))
+	}
+
+	chatModel, err := a.opts.LLMProvider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat model: %w", err)
+	}
+	if chatModel == nil {
+		return nil, fmt.Errorf("chat model is nil (provider: %s)", providerName)
+	}
+
+	messages := []*schema.Message{
+		{Role: schema.System, Content: ReviewCalibrationPrompt},
+		{Role: schema.User, Content: input},
+	}
+	streamReader, err := llm.WithRetryResult(ctx, a.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
+		return chatModel.Stream(ctx, messages)
+	})
+	if err != nil {
+		return nil, llm.ExplainError("LLM stream failed", err)
+	}
+	defer streamReader.Close()
+
+	response := &CalibrationResponse{Comments: used}
+	var guidelines strings.Builder
+	for {
+		chunk, err := streamReader.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, llm.ExplainError("stream read error", err)
+		}
+		if chunk.Content != "" {
+			guidelines.WriteString(chunk.Content)
+			if printer != nil {
+				_ = printer.PrintLLMContent(chunk.Content)
+			}
+		}
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			usage := chunk.ResponseMeta.Usage
+			response.PromptTokens += usage.PromptTokens
+			response.CompletionTokens += usage.CompletionTokens
+			response.TotalTokens += usage.TotalTokens
+		}
+	}
+
+	response.Guidelines = strings.TrimSpace(guidelines.String())
+	if response.Guidelines == "" {
+		return nil, fmt.Errorf("LLM returned no guidelines")
+	}
+	log.Debug("Review calibration distilled from %d comments (%d chars)", used, len(response.Guidelines))
+	return response, nil
+}
+
+// formatReviewerComments lists the comments for the model, each cut to
+// maxCalibrationCommentChars, until maxCalibrationInputChars. It returns the
+// number of comments listed.
+func formatReviewerComments(comments []ReviewerComment) (string, int) {
+	var b strings.Builder
+	used := 0
+	for _, c := range comments {
+		body := truncateString(c.Body, maxCalibrationCommentChars)
+		entry := fmt.Sprintf("### Comment %d (%s, by %s)\n%s\n\n", used+1, c.Path, c.Author, body)
+		if used > 0 && b.Len()+len(entry) > maxCalibrationInputChars {
+			break
+		}
+		b.WriteString(entry)
+		used++
+	}
+	return b.String(), used
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+// guidelinesModel streams fixed guidelines and records the prompt it was sent
+type guidelinesModel struct {
+	input []*schema.Message
+}
+
+func (m *guidelinesModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("- Flag missing error wrapping", nil), nil
+}
+
+func (m *guidelinesModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.input = input
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage("- Flag missing error wrapping\n", nil),
+		schema.AssistantMessage("- Don't comment on import order", nil),
+	}), nil
+}
+
+func (m *guidelinesModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+type guidelinesProvider struct {
+	MockLLMProvider
+	model *guidelinesModel
+}
+
+func (p *guidelinesProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return p.model, nil
+}
+
+func TestReviewCalibrationAgent_Calibrate(t *testing.T) {
+	chatModel := &guidelinesModel{}
+	calibrationAgent := NewReviewCalibrationAgent(ReviewCalibrationAgentOptions{
+		LLMProvider: &guidelinesProvider{MockLLMProvider{cfg: config.ModelConfig{Model: "test"}}, chatModel},
+	})
+
+	_, err := calibrationAgent.Calibrate(context.Background(), nil)
+	assert.Error(t, err)
+
+	response, err := calibrationAgent.Calibrate(context.Background(), []ReviewerComment{
+		{Author: "alice", Path: "internal/cli/root.go", Body: "Wrap this error with %w"},
+		{Author: "bob", Path: "internal/git/executor.go", Body: "Same here, please wrap"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "- Flag missing error wrapping\n- Don't comment on import order", response.Guidelines)
+	assert.Equal(t, 2, response.Comments)
+	require.Len(t, chatModel.input, 2)
+	assert.Contains(t, chatModel.input[1].Content, "### Comment 2 (internal/git/executor.go, by bob)\nSame here, please wrap")
+}
+
+func TestFormatReviewerComments_Limits(t *testing.T) {
+	long := strings.Repeat("x", maxCalibrationCommentChars*2)
+	comments := make([]ReviewerComment, 100)
+	for i := range comments {
+		comments[i] = ReviewerComment{Author: "alice", Path: "a.go", Body: long}
+	}
+
+	input, used := formatReviewerComments(comments)
+	assert.Less(t, used, len(comments))
+	assert.LessOrEqual(t, len(input), maxCalibrationInputChars)
+	assert.NotContains(t, input, strings.Repeat("x", maxCalibrationCommentChars+1))
+}
+
+func TestReviewCalibration_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitbuddy", "review_calibration.md")
+	generated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, (&ReviewCalibration{
+		GeneratedAt: generated,
+		Source:      "acme/api",
+		Comments:    42,
+		Guidelines:  "- Flag missing error wrapping\n",
+	}).Save(path))
+
+	loaded, err := LoadReviewCalibration(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.GeneratedAt.Equal(generated))
+	assert.Equal(t, "acme/api", loaded.Source)
+	assert.Equal(t, 42, loaded.Comments)
+	assert.Equal(t, "- Flag missing error wrapping", loaded.Guidelines)
+
+	assert.False(t, loaded.Stale(generated.Add(29*24*time.Hour)))
+	assert.True(t, loaded.Stale(generated.Add(31*24*time.Hour)))
+
+	// Guidelines written by hand have no age
+	require.NoError(t, os.WriteFile(path, []byte("- Prefer table-driven tests\n"), 0644))
+	loaded, err = LoadReviewCalibration(path)
+	require.NoError(t, err)
+	assert.Equal(t, "- Prefer table-driven tests", loaded.Guidelines)
+	assert.False(t, loaded.Stale(time.Now()))
+}
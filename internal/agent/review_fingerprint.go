@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fingerprintLength is the number of hex characters kept of a fingerprint
+const fingerprintLength = 16
+
+// IssueFingerprint identifies an issue across runs: a hash of its normalized
+// file path, category and the hash of the code it points at. The line number
+// is left out so the fingerprint survives code moving up or down the file.
+func IssueFingerprint(issue ReviewIssue, snippet string) string {
+	snippetHash := sha256.Sum256([]byte(normalizeSnippet(snippet)))
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		normalizeIssuePath(issue.File),
+		strings.ToLower(strings.TrimSpace(issue.Category)),
+		hex.EncodeToString(snippetHash[:]),
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+// FingerprintIssues sets the fingerprint of each issue, reading the line it
+// points at from workDir. Issues without a readable line (no line number, a
+// deleted file, a diff from stdin) are identified by their title instead.
+func FingerprintIssues(workDir string, issues []ReviewIssue) {
+	for i := range issues {
+		snippet := issueSnippet(workDir, issues[i])
+		if snippet == "" {
+			snippet = strings.ToLower(issues[i].Title)
+		}
+		issues[i].Fingerprint = IssueFingerprint(issues[i], snippet)
+	}
+}
+
+// issueSnippet returns the line of the working tree an issue points at, or ""
+func issueSnippet(workDir string, issue ReviewIssue) string {
+	if issue.File == "" || issue.Line <= 0 || workDir == "" {
+		return ""
+	}
+	f, err := os.Open(filepath.Join(workDir, filepath.FromSlash(normalizeIssuePath(issue.File))))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if line == issue.Line {
+			return normalizeSnippet(scanner.Text())
+		}
+	}
+	return ""
+}
+
+// normalizeIssuePath makes paths written differently by the model compare
+// equal, e.g. ./internal/cli/root.go and internal/cli//root.go
+func normalizeIssuePath(file string) string {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		return ""
+	}
+	return path.Clean(filepath.ToSlash(file))
+}
+
+// normalizeSnippet collapses whitespace so reindented code keeps its fingerprint
+func normalizeSnippet(snippet string) string {
+	return strings.Join(strings.Fields(snippet), " ")
+}
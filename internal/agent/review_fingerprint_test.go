@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintIssues(t *testing.T) {
+	workDir := t.TempDir()
+	write := func(content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(workDir, "internal"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, "internal", "db.go"), []byte(content), 0644))
+	}
+
+	write("package db\n\nfunc Query(q string) {\n\trows, _ := db.Query(q)\n}\n")
+	issues := []ReviewIssue{
+		{Category: "bug", File: "internal/db.go", Line: 4, Title: "Ignored error"},
+		{Category: "Bug", File: "./internal/db.go", Line: 4, Title: "Error is dropped"},
+		{Category: "security", File: "internal/db.go", Line: 4, Title: "SQL injection"},
+		{Category: "style", File: "internal/db.go", Title: "Missing doc comment"},
+	}
+	FingerprintIssues(workDir, issues)
+	for _, issue := range issues {
+		assert.Len(t, issue.Fingerprint, fingerprintLength)
+	}
+	assert.Equal(t, issues[0].Fingerprint, issues[1].Fingerprint, "same code, path and category")
+	assert.NotEqual(t, issues[0].Fingerprint, issues[2].Fingerprint, "different category")
+
+	// Lines added above and reindentation don't change the fingerprint
+	write("package db\n\nimport \"log\"\n\nfunc Query(q string) {\n    rows, _ := db.Query(q)\n}\n")
+	moved := []ReviewIssue{
+		{Category: "bug", File: "internal/db.go", Line: 6, Title: "Unchecked error"},
+		{Category: "style", File: "internal/db.go", Title: "Missing doc comment"},
+	}
+	FingerprintIssues(workDir, moved)
+	assert.Equal(t, issues[0].Fingerprint, moved[0].Fingerprint)
+	assert.Equal(t, issues[3].Fingerprint, moved[1].Fingerprint, "issues without a line are identified by their title")
+
+	// A changed line is a different finding
+	write("package db\n\nfunc Query(q string) {\n\trows, err := db.Query(q)\n}\n")
+	changed := []ReviewIssue{{Category: "bug", File: "internal/db.go", Line: 4, Title: "Ignored error"}}
+	FingerprintIssues(workDir, changed)
+	assert.NotEqual(t, issues[0].Fingerprint, changed[0].Fingerprint)
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// DefaultMetricsPath is where per-run review statistics are appended, relative to the repository root
+const DefaultMetricsPath = ".gitbuddy/metrics.jsonl"
+
+// ReviewMetrics are the statistics of a single review run, stored as one JSON line
+type ReviewMetrics struct {
+	Timestamp        time.Time      `json:"timestamp"`
+	SessionID        string         `json:"session_id,omitempty"`
+	Model            string         `json:"model,omitempty"`
+	FilesReviewed    int            `json:"files_reviewed"`
+	Issues           int            `json:"issues"`
+	BySeverity       map[string]int `json:"by_severity,omitempty"`
+	ByCategory       map[string]int `json:"by_category,omitempty"`
+	PromptTokens     int            `json:"prompt_tokens"`
+	CompletionTokens int            `json:"completion_tokens"`
+	TotalTokens      int            `json:"total_tokens"`
+	Partial          bool           `json:"partial,omitempty"`
+	Fingerprints     []string       `json:"fingerprints,omitempty"` // Of the issues, to tell new findings from recurring ones
+}
+
+// NewReviewMetrics collects the statistics of a review response
+func NewReviewMetrics(review *ReviewResponse, filesReviewed int) ReviewMetrics {
+	metrics := ReviewMetrics{
+		Timestamp:     time.Now(),
+		FilesReviewed: filesReviewed,
+		BySeverity:    make(map[string]int),
+		ByCategory:    make(map[string]int),
+	}
+	if review == nil {
+		return metrics
+	}
+
+	metrics.SessionID = review.SessionID
+	metrics.Issues = len(review.Issues)
+	metrics.PromptTokens = review.PromptTokens
+	metrics.CompletionTokens = review.CompletionTokens
+	metrics.TotalTokens = review.TotalTokens
+	metrics.Partial = review.Partial
+	for _, issue := range review.Issues {
+		metrics.BySeverity[strings.ToLower(issue.Severity)]++
+		if issue.Category != "" {
+			metrics.ByCategory[strings.ToLower(issue.Category)]++
+		}
+		if issue.Fingerprint != "" {
+			metrics.Fingerprints = append(metrics.Fingerprints, issue.Fingerprint)
+		}
+	}
+	return metrics
+}
+
+// AppendReviewMetrics appends a run to a JSON Lines file, creating parent directories
+func AppendReviewMetrics(path string, metrics ReviewMetrics) error {
+	if err := sideeffect.Check("record review metrics"); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create metrics directory: %w", err)
+		}
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review metrics: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write review metrics: %w", err)
+	}
+	return nil
+}
+
+// RecordReviewMetrics appends a run to the metrics file of the repository at workDir
+func RecordReviewMetrics(workDir string, metrics ReviewMetrics) error {
+	return AppendReviewMetrics(filepath.Join(workDir, DefaultMetricsPath), metrics)
+}
+
+// LoadReviewMetrics reads the runs written by AppendReviewMetrics in file order.
+// Malformed lines (e.g. from an interrupted write) are skipped.
+func LoadReviewMetrics(path string) ([]ReviewMetrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	var runs []ReviewMetrics
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var metrics ReviewMetrics
+		if err := json.Unmarshal([]byte(line), &metrics); err != nil {
+			continue
+		}
+		runs = append(runs, metrics)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReviewMetrics(t *testing.T) {
+	review := &ReviewResponse{
+		Issues: []ReviewIssue{
+			{Severity: SeverityError, Category: CategorySecurity},
+			{Severity: SeverityWarning, Category: CategoryBug},
+			{Severity: SeverityWarning, Category: "Bug", Fingerprint: "0123456789abcdef"},
+		},
+		SessionID:        "review-1",
+		PromptTokens:     100,
+		CompletionTokens: 20,
+		TotalTokens:      120,
+	}
+
+	metrics := NewReviewMetrics(review, 4)
+	assert.Equal(t, 4, metrics.FilesReviewed)
+	assert.Equal(t, 3, metrics.Issues)
+	assert.Equal(t, map[string]int{SeverityError: 1, SeverityWarning: 2}, metrics.BySeverity)
+	assert.Equal(t, map[string]int{CategorySecurity: 1, CategoryBug: 2}, metrics.ByCategory)
+	assert.Equal(t, 120, metrics.TotalTokens)
+	assert.Equal(t, "review-1", metrics.SessionID)
+	assert.Equal(t, []string{"0123456789abcdef"}, metrics.Fingerprints)
+}
+
+func TestReviewMetrics_AppendAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitbuddy", "metrics.jsonl")
+
+	require.NoError(t, AppendReviewMetrics(path, ReviewMetrics{Issues: 3, FilesReviewed: 2}))
+	require.NoError(t, AppendReviewMetrics(path, ReviewMetrics{Issues: 1, FilesReviewed: 5}))
+
+	// A truncated line from an interrupted run is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"issues\": 7,\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	runs, err := LoadReviewMetrics(path)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, 3, runs[0].Issues)
+	assert.Equal(t, 5, runs[1].FilesReviewed)
+
+	_, err = LoadReviewMetrics(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestRecordReviewMetrics(t *testing.T) {
+	workDir := t.TempDir()
+	require.NoError(t, RecordReviewMetrics(workDir, ReviewMetrics{Issues: 2}))
+
+	runs, err := LoadReviewMetrics(filepath.Join(workDir, DefaultMetricsPath))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 2, runs[0].Issues)
+}
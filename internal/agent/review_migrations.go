@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"text/template"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+)
+
+// CategoryMigration is the category of all issues found by the migration pass
+const CategoryMigration = "migration"
+
+// MigrationFiles returns the files matching any of the migration patterns,
+// which use the same syntax as severity rule paths
+func MigrationFiles(files, patterns []string) []string {
+	var matched []string
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if matchPathPattern(pattern, file) {
+				matched = append(matched, file)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// BuildMigrationReviewSystemPrompt builds the system prompt for the migration
+// pass over the staged changes
+func BuildMigrationReviewSystemPrompt(language, context, files, minSeverity string) string {
+	return buildMigrationReviewSystemPrompt(ReviewRequest{Language: language, Context: context, Severity: minSeverity}, files)
+}
+
+// buildMigrationReviewSystemPrompt builds the system prompt for the migration
+// pass over what req selects
+func buildMigrationReviewSystemPrompt(req ReviewRequest, files string) string {
+	tmpl, err := template.New("migration_review_prompt").Parse(MigrationReviewSystemPrompt)
+	if err != nil {
+		return MigrationReviewSystemPrompt
+	}
+
+	var buf bytes.Buffer
+	data := map[string]string{
+		"Language":    req.Language,
+		"Context":     req.Context,
+		"Files":       files,
+		"MinSeverity": req.Severity,
+		"Scope":       req.scope(),
+		"DiffTool":    req.diffTool(),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return MigrationReviewSystemPrompt
+	}
+	return buf.String()
+}
+
+// ReviewMigrations runs a review pass over the migration files in req.Files
+// with migration-specific prompts. All issues get the migration category
+// before req.SeverityRules are applied, so rules can target the category.
+func (a *ReviewAgent) ReviewMigrations(ctx context.Context, req ReviewRequest) (*ReviewResponse, error) {
+	req.migrations = true
+	req.Focus = nil
+	req.APIBase = ""        // Breaking API changes are reported by the general pass
+	req.LicensePolicy = nil // So are license issues
+	req.Session = nil
+	req.PreGeneratedSessionID = session.GenerateSessionID("review")
+	return a.Review(ctx, req)
+}
+
+// submittedIssues combines the issues found without the model (breaking
+// changes, license checks) with the issues the model submitted, forcing the
+// migration category in a migration pass, and fingerprints them
+func (req ReviewRequest) submittedIssues(detected, submitted []ReviewIssue) []ReviewIssue {
+	issues := append(append([]ReviewIssue{}, detected...), submitted...)
+	if req.migrations {
+		for i := range issues {
+			issues[i].Category = CategoryMigration
+		}
+	}
+	FingerprintIssues(req.WorkDir, issues)
+	return issues
+}
+
+// Merge adds the issues, summary and token usage of another review pass
+func (r *ReviewResponse) Merge(other *ReviewResponse, heading string) {
+	r.Issues = append(r.Issues, other.Issues...)
+	if other.Summary != "" {
+		section := heading + ":\n" + other.Summary
+		if r.Summary == "" {
+			r.Summary = section
+		} else {
+			r.Summary += "\n\n" + section
+		}
+	}
+	if other.Partial && !r.Partial {
+		r.Partial = true
+		r.PartialReason = heading + ": " + other.PartialReason
+	}
+	r.PromptTokens += other.PromptTokens
+	r.CompletionTokens += other.CompletionTokens
+	r.TotalTokens += other.TotalTokens
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationFiles(t *testing.T) {
+	files := []string{
+		"db/migrations/20240101_add_users.up.sql",
+		"internal/migrate/runner.go",
+		"schema/seed.sql",
+		"app/alembic/versions/abc123_add_index.py",
+		"internal/service/user.go",
+		"docs/migrations.md",
+	}
+	patterns := []string{"migrations", "migrate", "*.sql", "alembic/versions"}
+
+	assert.Equal(t, []string{
+		"db/migrations/20240101_add_users.up.sql",
+		"internal/migrate/runner.go",
+		"schema/seed.sql",
+		"app/alembic/versions/abc123_add_index.py",
+	}, MigrationFiles(files, patterns))
+	assert.Empty(t, MigrationFiles(files, nil))
+}
+
+func TestReviewRequest_SubmittedIssues(t *testing.T) {
+	breaking := []ReviewIssue{{Severity: SeverityError, Category: CategoryBreakingChange, Title: "Removed API"}}
+	submitted := []ReviewIssue{{Severity: SeverityWarning, Category: CategoryPerformance, File: "db/migrations/1.sql", Title: "Table lock"}}
+
+	issues := ReviewRequest{}.submittedIssues(breaking, submitted)
+	assert.Equal(t, []string{CategoryBreakingChange, CategoryPerformance}, []string{issues[0].Category, issues[1].Category})
+
+	issues = ReviewRequest{migrations: true}.submittedIssues(nil, submitted)
+	assert.Equal(t, CategoryMigration, issues[0].Category)
+	assert.Equal(t, CategoryPerformance, submitted[0].Category, "the submitted issues are not modified")
+
+	// Migration severity rules match the forced category
+	escalated, count := EscalateSeverity(issues, []SeverityRule{{Categories: []string{CategoryMigration}, Severity: SeverityError}})
+	assert.Equal(t, 1, count)
+	assert.Equal(t, SeverityError, escalated[0].Severity)
+}
+
+func TestReviewResponse_Merge(t *testing.T) {
+	response := &ReviewResponse{
+		Issues:       []ReviewIssue{{Title: "Nil map"}},
+		Summary:      "One bug.",
+		PromptTokens: 100,
+		TotalTokens:  150,
+	}
+	response.Merge(&ReviewResponse{
+		Issues:        []ReviewIssue{{Title: "Table lock", Category: CategoryMigration}},
+		Summary:       "The index blocks writes.",
+		Partial:       true,
+		PartialReason: "iteration limit",
+		PromptTokens:  40,
+		TotalTokens:   60,
+	}, "Migrations")
+
+	assert.Len(t, response.Issues, 2)
+	assert.Equal(t, "One bug.\n\nMigrations:\nThe index blocks writes.", response.Summary)
+	assert.True(t, response.Partial)
+	assert.Equal(t, "Migrations: iteration limit", response.PartialReason)
+	assert.Equal(t, 140, response.PromptTokens)
+	assert.Equal(t, 210, response.TotalTokens)
+}
+
+func TestBuildMigrationReviewSystemPrompt(t *testing.T) {
+	prompt := BuildMigrationReviewSystemPrompt("English", "users has 50M rows", "db/migrations/1.sql", "warning")
+	assert.Contains(t, prompt, "db/migrations/1.sql")
+	assert.Contains(t, prompt, "users has 50M rows")
+	assert.Contains(t, prompt, "Only report issues with severity level: warning or higher.")
+	assert.Contains(t, prompt, `category "migration"`)
+	assert.NotContains(t, prompt, "{{")
+}
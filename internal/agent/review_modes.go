@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// Review modes, selecting what ReviewRequest.Mode reviews
+const (
+	ReviewModeStaged    = "staged"    // The staged changes (the default)
+	ReviewModeRange     = "range"     // The commits of a range such as main..HEAD
+	ReviewModeCommit    = "commit"    // A single commit
+	ReviewModeUnstaged  = "unstaged"  // The unstaged changes of the working tree
+	ReviewModeDirectory = "directory" // The current content of a directory
+)
+
+// maxListedDirectoryFiles is how many files of the reviewed directory are
+// listed in the first message of a directory review
+const maxListedDirectoryFiles = 200
+
+// mode returns the review mode of req
+func (req ReviewRequest) mode() string {
+	if req.Mode == "" {
+		return ReviewModeStaged
+	}
+	return req.Mode
+}
+
+// validateMode checks that the mode of req is known and has a target when it
+// needs one
+func (req ReviewRequest) validateMode() error {
+	switch req.mode() {
+	case ReviewModeStaged, ReviewModeUnstaged:
+		return nil
+	case ReviewModeRange, ReviewModeCommit, ReviewModeDirectory:
+		if strings.TrimSpace(req.Target) == "" {
+			return fmt.Errorf("the %s review mode needs a target", req.mode())
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown review mode: %s", req.Mode)
+	}
+}
+
+// ReviewedDiff returns the diff of the changes req reviews, reading the
+// staged changes with executor. A directory review covers files rather than
+// changes and has no diff.
+func (req ReviewRequest) ReviewedDiff(ctx context.Context, executor git.Executor) (string, error) {
+	if err := req.validateMode(); err != nil {
+		return "", err
+	}
+	switch req.mode() {
+	case ReviewModeRange, ReviewModeCommit:
+		return git.DiffRange(ctx, req.WorkDir, req.Target)
+	case ReviewModeUnstaged:
+		return git.DiffWorktree(ctx, req.WorkDir)
+	case ReviewModeDirectory:
+		return "", nil
+	}
+	diff, err := executor.DiffCached(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	return diff, nil
+}
+
+// DirectoryFiles lists the tracked and untracked, not ignored files below dir,
+// relative to workDir
+func DirectoryFiles(ctx context.Context, workDir, dir string) ([]string, error) {
+	files, err := git.ListFiles(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	dir = path.Clean(filepath.ToSlash(dir))
+	if dir == "." {
+		return files, nil
+	}
+
+	var selected []string
+	for _, file := range files {
+		if strings.HasPrefix(file, dir+"/") {
+			selected = append(selected, file)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no files found in %s", dir)
+	}
+	return selected, nil
+}
+
+// scope describes what req reviews, for the prompts
+func (req ReviewRequest) scope() string {
+	switch req.mode() {
+	case ReviewModeRange:
+		return "the changes of the commits in " + req.Target
+	case ReviewModeCommit:
+		return "the changes of commit " + req.Target
+	case ReviewModeUnstaged:
+		return "the unstaged changes in the working tree"
+	case ReviewModeDirectory:
+		return "the code in the directory " + req.Target
+	}
+	return "the staged changes"
+}
+
+// diffTool returns the name of the tool showing the reviewed changes, or ""
+// in directory mode
+func (req ReviewRequest) diffTool() string {
+	switch req.mode() {
+	case ReviewModeRange, ReviewModeCommit:
+		return "git_diff_range"
+	case ReviewModeUnstaged:
+		return "git_diff_worktree"
+	case ReviewModeDirectory:
+		return ""
+	}
+	return "git_diff_cached"
+}
+
+// apiRange returns the revisions whose API surfaces are compared for breaking
+// changes (see apidiff.Detect), an empty head meaning the staged changes. An
+// empty base skips the comparison: APIBase is not set, or the mode has no
+// revisions to compare.
+func (req ReviewRequest) apiRange() (base, head string) {
+	if req.APIBase == "" {
+		return "", ""
+	}
+	switch req.mode() {
+	case ReviewModeStaged:
+		return req.APIBase, ""
+	case ReviewModeCommit:
+		return req.Target + "^", req.Target
+	case ReviewModeRange:
+		separator := ".."
+		if strings.Contains(req.Target, "...") {
+			separator = "..."
+		}
+		base, head, _ = strings.Cut(req.Target, separator)
+		if base == "" {
+			base = "HEAD"
+		}
+		if head == "" {
+			head = "HEAD"
+		}
+		return base, head
+	}
+	return "", ""
+}
+
+// analyzedExecutor returns an executor serving the reviewed changes as staged
+// changes, for the local checks of the diff (API and dependency facts,
+// licenses), or nil when there are no changes to check
+func (req ReviewRequest) analyzedExecutor(ctx context.Context, executor git.Executor) git.Executor {
+	switch req.mode() {
+	case ReviewModeStaged:
+		return executor
+	case ReviewModeDirectory:
+		return nil
+	}
+	diff, err := req.ReviewedDiff(ctx, executor)
+	if err != nil || diff == "" {
+		return nil
+	}
+	return git.NewDiffExecutor(diff)
+}
+
+// reviewMessage returns the first user message of a review of req, before
+// the findings of the local checks
+func (req ReviewRequest) reviewMessage(ctx context.Context) string {
+	message := fmt.Sprintf("Please review %s and provide your findings.", req.scope())
+	if len(req.Files) > 0 {
+		message = fmt.Sprintf("Please review %s in these files: %s", req.scope(), strings.Join(req.Files, ", "))
+	}
+	if req.mode() != ReviewModeDirectory || len(req.Files) > 0 {
+		return message
+	}
+
+	files, err := DirectoryFiles(ctx, req.WorkDir, req.Target)
+	if err != nil {
+		return message
+	}
+	var b strings.Builder
+	b.WriteString(message)
+	fmt.Fprintf(&b, "\n\nFiles in %s (%d):\n", req.Target, len(files))
+	for i, file := range files {
+		if i == maxListedDirectoryFiles {
+			fmt.Fprintf(&b, "... and %d more; use list_directory to see them\n", len(files)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", file)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// reviewDiffTool returns the description and handler of the tool showing what
+// req reviews: the diff of the reviewed changes, or the listing of the
+// reviewed directory
+func (a *ReviewAgent) reviewDiffTool(req ReviewRequest) (*schema.ToolInfo, ToolHandler) {
+	pathParams := schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+		"path": {Type: schema.String, Desc: "Show only the diff of this file or directory (optional)", Required: false},
+	})
+	maxLines := diffLineLimit(a.opts.LLMProvider, a.opts.MaxDiffLines)
+	// Expand hunks to their enclosing functions so the LLM sees complete logical
+	// units; only the working tree has the versions of the files the diff ends at
+	withFunctionContext := func(handler ToolHandler) ToolHandler {
+		return func(ctx context.Context, call schema.ToolCall) (string, error) {
+			result, err := handler(ctx, call)
+			if err == nil && a.opts.FunctionContextLines > 0 {
+				result = tools.ExpandDiffToFunctions(result, req.WorkDir, a.opts.FunctionContextLines)
+			}
+			return result, err
+		}
+	}
+
+	switch req.mode() {
+	case ReviewModeRange, ReviewModeCommit:
+		tool := tools.NewGitDiffRangeTool(req.WorkDir, req.Target, maxLines)
+		return &schema.ToolInfo{Name: tool.Name(), Desc: tool.Description(), ParamsOneOf: pathParams},
+			tools.Bind[tools.GitDiffRangeParams](tool.Execute)
+	case ReviewModeUnstaged:
+		tool := tools.NewGitDiffWorktreeTool(req.WorkDir, maxLines)
+		return &schema.ToolInfo{Name: tool.Name(), Desc: tool.Description(), ParamsOneOf: pathParams},
+			withFunctionContext(tools.Bind[tools.GitDiffWorktreeParams](tool.Execute))
+	case ReviewModeDirectory:
+		tool := tools.NewListDirectoryTool(req.WorkDir)
+		return &schema.ToolInfo{
+			Name: tool.Name(),
+			Desc: tool.Description(),
+			ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+				"path":        {Type: schema.String, Desc: "Directory path to list", Required: true},
+				"show_hidden": {Type: schema.Boolean, Desc: "Show hidden files", Required: false},
+				"recursive":   {Type: schema.Boolean, Desc: "List subdirectories recursively", Required: false},
+				"max_depth":   {Type: schema.Integer, Desc: "Maximum depth for recursive listing", Required: false},
+			}),
+		}, tools.Bind[tools.ListDirectoryParams](tool.Execute)
+	}
+
+	tool := tools.NewGitDiffCachedTool(a.opts.GitExecutor, maxLines)
+	return &schema.ToolInfo{Name: tool.Name(), Desc: tool.Description(), ParamsOneOf: pathParams},
+		withFunctionContext(tools.Bind[tools.GitDiffCachedParams](tool.Execute))
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewRequest_Modes(t *testing.T) {
+	assert.NoError(t, ReviewRequest{}.validateMode())
+	assert.NoError(t, ReviewRequest{Mode: ReviewModeUnstaged}.validateMode())
+	assert.EqualError(t, ReviewRequest{Mode: ReviewModeRange}.validateMode(), "the range review mode needs a target")
+	assert.EqualError(t, ReviewRequest{Mode: "tree"}.validateMode(), "unknown review mode: tree")
+
+	tests := []struct {
+		req        ReviewRequest
+		diffTool   string
+		base, head string
+	}{
+		{ReviewRequest{APIBase: "HEAD"}, "git_diff_cached", "HEAD", ""},
+		{ReviewRequest{Mode: ReviewModeRange, Target: "main..feature", APIBase: "HEAD"}, "git_diff_range", "main", "feature"},
+		{ReviewRequest{Mode: ReviewModeRange, Target: "main...", APIBase: "HEAD"}, "git_diff_range", "main", "HEAD"},
+		{ReviewRequest{Mode: ReviewModeCommit, Target: "abc123", APIBase: "HEAD"}, "git_diff_range", "abc123^", "abc123"},
+		{ReviewRequest{Mode: ReviewModeCommit, Target: "abc123"}, "git_diff_range", "", ""},
+		{ReviewRequest{Mode: ReviewModeUnstaged, APIBase: "HEAD"}, "git_diff_worktree", "", ""},
+		{ReviewRequest{Mode: ReviewModeDirectory, Target: "internal", APIBase: "HEAD"}, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.req.mode()+" "+tt.req.Target, func(t *testing.T) {
+			assert.Equal(t, tt.diffTool, tt.req.diffTool())
+			base, head := tt.req.apiRange()
+			assert.Equal(t, tt.base, base)
+			assert.Equal(t, tt.head, head)
+		})
+	}
+}
+
+func TestBuildReviewSystemPrompt_Modes(t *testing.T) {
+	staged := BuildReviewSystemPrompt("en", "", "", "", "")
+	assert.Contains(t, staged, "Your task is to analyze the staged changes")
+	assert.Contains(t, staged, "Call git_diff_cached to get the actual code changes")
+
+	ranged := buildReviewSystemPrompt(ReviewRequest{Language: "en", Mode: ReviewModeRange, Target: "main..HEAD"}, "", "")
+	assert.Contains(t, ranged, "Your task is to analyze the changes of the commits in main..HEAD")
+	assert.Contains(t, ranged, "**git_diff_range**")
+	assert.NotContains(t, ranged, "git_diff_cached")
+
+	directory := buildReviewSystemPrompt(ReviewRequest{Language: "en", Mode: ReviewModeDirectory, Target: "internal/auth"}, "", "")
+	assert.Contains(t, directory, "**list_directory**")
+	assert.NotContains(t, directory, "git_diff")
+
+	migrations := buildMigrationReviewSystemPrompt(ReviewRequest{Language: "en", Mode: ReviewModeUnstaged}, "db/1.sql")
+	assert.Contains(t, migrations, "schema migrations in the unstaged changes in the working tree")
+	assert.Contains(t, migrations, "Use git_diff_worktree to see the migrations")
+}
+
+func TestReviewRequest_Directory(t *testing.T) {
+	repo := testutil.NewSampleRepo(t)
+	ctx := context.Background()
+
+	files, err := DirectoryFiles(ctx, repo.Dir, "auth/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth/auth.go", "auth/auth_test.go", "auth/limit.go"}, files)
+
+	_, err = DirectoryFiles(ctx, repo.Dir, "docs")
+	assert.EqualError(t, err, "no files found in docs")
+
+	req := ReviewRequest{Mode: ReviewModeDirectory, Target: "auth", WorkDir: repo.Dir}
+	diff, err := req.ReviewedDiff(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+	assert.Equal(t, "Please review the code in the directory auth and provide your findings.\n\n"+
+		"Files in auth (3):\n- auth/auth.go\n- auth/auth_test.go\n- auth/limit.go", req.reviewMessage(ctx))
+}
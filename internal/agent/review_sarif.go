@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifFingerprintKey names the IssueFingerprint in partialFingerprints
+	sarifFingerprintKey = "gitbuddyIssue/v1"
+)
+
+// sarifRuleDescriptions describe the review categories, which are the rules
+// of the SARIF log
+var sarifRuleDescriptions = map[string]string{
+	"bug":         "Bug",
+	"security":    "Security issue",
+	"performance": "Performance problem",
+	"style":       "Style issue",
+	"suggestion":  "Suggestion",
+}
+
+// sarifLog is the subset of the SARIF 2.1.0 format written for reviews
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string          `json:"id"`
+	ShortDescription sarifMessage    `json:"shortDescription"`
+	Properties       sarifProperties `json:"properties"`
+}
+
+type sarifProperties struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIF returns the review as a SARIF 2.1.0 log, e.g. for GitHub code
+// scanning, with toolVersion as the version of gitbuddy. Issues map to
+// results of the rule of their category, with their severity as the level.
+// Code scanning needs a location, so issues not tied to a file are left out.
+func (r *ReviewResponse) SARIF(toolVersion string) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "gitbuddy",
+			Version:        toolVersion,
+			InformationURI: "https://github.com/huimingz/gitbuddy-go",
+			Rules:          []sarifRule{},
+		}},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: !r.Partial}},
+		Results:     []sarifResult{},
+	}
+	if r.Partial {
+		run.Invocations[0].ToolExecutionNotifications = []sarifNotification{{
+			Level:   "warning",
+			Message: sarifMessage{Text: "Partial review: " + r.PartialReason},
+		}}
+	}
+
+	categories := make(map[string]bool)
+	for _, issue := range r.Issues {
+		if issue.File == "" {
+			continue
+		}
+		category := sarifCategory(issue.Category)
+		categories[category] = true
+
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI:       filepath.ToSlash(strings.TrimPrefix(issue.File, "./")),
+				URIBaseID: "%SRCROOT%",
+			},
+		}}
+		if issue.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line}
+		}
+		result := sarifResult{
+			RuleID:     category,
+			Level:      sarifLevel(issue.Severity),
+			Message:    sarifMessage{Text: sarifMessageText(issue)},
+			Locations:  []sarifLocation{location},
+			Properties: sarifProperties{Tags: sarifTags(category)},
+		}
+		if issue.Fingerprint != "" {
+			result.PartialFingerprints = map[string]string{sarifFingerprintKey: issue.Fingerprint}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	ids := make([]string, 0, len(categories))
+	for category := range categories {
+		ids = append(ids, category)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		description, ok := sarifRuleDescriptions[id]
+		if !ok {
+			description = strings.ToUpper(id[:1]) + id[1:]
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: description},
+			Properties:       sarifProperties{Tags: sarifTags(id)},
+		})
+	}
+
+	return json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+}
+
+// sarifCategory normalizes the category of an issue into a rule ID
+func sarifCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return "suggestion"
+	}
+	return category
+}
+
+// sarifLevel maps a review severity to a SARIF level
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifTags tags results of category; code scanning lists results tagged
+// security as security alerts
+func sarifTags(category string) []string {
+	return []string{"gitbuddy", category}
+}
+
+// sarifMessageText describes an issue in a result message
+func sarifMessageText(issue ReviewIssue) string {
+	parts := []string{issue.Title}
+	if issue.Description != "" {
+		parts = append(parts, issue.Description)
+	}
+	if issue.Suggestion != "" {
+		parts = append(parts, "Suggestion: "+issue.Suggestion)
+	}
+	return strings.Join(parts, "\n\n")
+}
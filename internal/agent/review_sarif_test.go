@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewResponse_SARIF(t *testing.T) {
+	response := &ReviewResponse{
+		Issues: []ReviewIssue{
+			{Severity: SeverityError, Category: "security", File: "./auth/login.go", Line: 42, Title: "SQL injection", Description: "The query concatenates user input.", Suggestion: "Use a prepared statement.", Fingerprint: "0123456789abcdef"},
+			{Severity: SeverityWarning, Category: "performance", File: "cache/cache.go", Title: "Unbounded cache"},
+			{Severity: SeverityInfo, Category: "style", File: "main.go", Line: 3, Title: "Missing doc comment"},
+			{Severity: SeverityInfo, Category: "suggestion", Title: "Add a changelog entry"},
+		},
+	}
+
+	data, err := response.SARIF("1.2.3")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "gitbuddy", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+	assert.True(t, run.Invocations[0].ExecutionSuccessful)
+
+	var rules []string
+	for _, rule := range run.Tool.Driver.Rules {
+		rules = append(rules, rule.ID)
+	}
+	assert.Equal(t, []string{"performance", "security", "style"}, rules)
+
+	require.Len(t, run.Results, 3, "issues without a file have no location for code scanning")
+	injection := run.Results[0]
+	assert.Equal(t, "security", injection.RuleID)
+	assert.Equal(t, "error", injection.Level)
+	assert.Equal(t, "SQL injection\n\nThe query concatenates user input.\n\nSuggestion: Use a prepared statement.", injection.Message.Text)
+	assert.Equal(t, "auth/login.go", injection.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 42, injection.Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, map[string]string{sarifFingerprintKey: "0123456789abcdef"}, injection.PartialFingerprints)
+	assert.Contains(t, injection.Properties.Tags, "security")
+
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region, "issues without a line point at the file")
+	assert.Equal(t, "note", run.Results[2].Level)
+}
+
+func TestReviewResponse_SARIF_Partial(t *testing.T) {
+	data, err := (&ReviewResponse{Partial: true, PartialReason: "time budget exceeded"}).SARIF("")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+	run := log.Runs[0]
+	assert.False(t, run.Invocations[0].ExecutionSuccessful)
+	assert.Equal(t, "Partial review: time budget exceeded", run.Invocations[0].ToolExecutionNotifications[0].Message.Text)
+	assert.NotNil(t, run.Results, "an empty review is an empty result list, not null")
+	assert.Contains(t, string(data), `"results": []`)
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+// severityLevels orders severities from least to most severe
+var severityLevels = map[string]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityError:   2,
+}
+
+// SeverityRule raises the severity of issues matching a path and/or category.
+// Rules only escalate: an issue already at or above Severity is left alone.
+type SeverityRule struct {
+	Paths      []string // Path patterns; plain paths match whole directory/file segments, globs support * and **
+	Categories []string // Issue categories (bug, security, ...); empty matches any category
+	Severity   string   // Severity to escalate to
+}
+
+// Validate checks that the rule has a known severity and at least one condition
+func (r SeverityRule) Validate() error {
+	if _, ok := severityLevels[r.Severity]; !ok {
+		return fmt.Errorf("invalid severity in severity rule: %q (valid: error, warning, info)", r.Severity)
+	}
+	if len(r.Paths) == 0 && len(r.Categories) == 0 {
+		return fmt.Errorf("severity rule for %q needs at least one path or category", r.Severity)
+	}
+	return nil
+}
+
+// SeverityRulesFromConfig converts and validates configured severity
+// escalation rules; key names the rules in errors
+func SeverityRulesFromConfig(configured []config.SeverityRuleConfig, key string) ([]SeverityRule, error) {
+	rules := make([]SeverityRule, 0, len(configured))
+	for i, rc := range configured {
+		rule := SeverityRule{Paths: rc.Paths, Categories: rc.Categories, Severity: rc.Severity}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s[%d]: %w", key, i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Matches reports whether the rule applies to an issue
+func (r SeverityRule) Matches(issue ReviewIssue) bool {
+	if len(r.Categories) > 0 {
+		matched := false
+		for _, category := range r.Categories {
+			if strings.EqualFold(category, issue.Category) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.Paths) > 0 {
+		for _, pattern := range r.Paths {
+			if matchPathPattern(pattern, issue.File) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// EscalateSeverity applies the rules to the issues and returns the updated
+// copy together with the number of escalated issues. It runs after the review
+// is submitted and before severity filtering.
+func EscalateSeverity(issues []ReviewIssue, rules []SeverityRule) ([]ReviewIssue, int) {
+	if len(rules) == 0 || len(issues) == 0 {
+		return issues, 0
+	}
+
+	escalated := 0
+	result := make([]ReviewIssue, len(issues))
+	for i, issue := range issues {
+		original := issue.Severity
+		for _, rule := range rules {
+			if rule.Matches(issue) && severityLevels[rule.Severity] > severityLevels[issue.Severity] {
+				issue.Severity = rule.Severity
+			}
+		}
+		if issue.Severity != original {
+			issue.EscalatedFrom = original
+			escalated++
+		}
+		result[i] = issue
+	}
+	return result, escalated
+}
+
+// matchPathPattern matches a file against a path pattern. Plain patterns like
+// "/payments/" or "internal/auth" match whole path segments anywhere in the
+// path; patterns with * or ? are globs where ** spans directories.
+func matchPathPattern(pattern, file string) bool {
+	file = strings.TrimPrefix(strings.ReplaceAll(file, "\\", "/"), "./")
+	if file == "" || pattern == "" {
+		return false
+	}
+
+	if !strings.ContainsAny(pattern, "*?") {
+		segments := strings.Trim(pattern, "/")
+		if segments == "" {
+			return false
+		}
+		return strings.Contains("/"+file+"/", "/"+segments+"/")
+	}
+
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(file)
+}
+
+// globToRegexp converts a path glob to an anchored regular expression.
+// Patterns without a leading "/" may match at any directory depth.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	if strings.HasPrefix(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A pattern naming a directory also matches the files below it
+	sb.WriteString("(?:/.*)?$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"/payments/", "internal/payments/charge.go", true},
+		{"/payments/", "payments/charge.go", true},
+		{"/payments/", "internal/paymentsv2/charge.go", false},
+		{"internal/auth", "internal/auth/token.go", true},
+		{"internal/auth", "pkg/internal/auth/token.go", true},
+		{"main.go", "cmd/main.go", true},
+		{"*.sql", "db/migrations/001.sql", true},
+		{"/*.sql", "db/migrations/001.sql", false},
+		{"/db/**/*.sql", "db/migrations/001.sql", true},
+		{"internal/**/auth*.go", "internal/api/v1/auth_handler.go", true},
+		{"internal/**/auth*.go", "internal/api/v1/handler.go", false},
+		{"/cmd/*", "cmd/gitbuddy/main.go", true},
+		{"", "main.go", false},
+		{"/", "main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.file, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchPathPattern(tt.pattern, tt.file))
+		})
+	}
+}
+
+func TestSeverityRule_Matches(t *testing.T) {
+	rule := SeverityRule{Paths: []string{"/payments/"}, Categories: []string{"security"}, Severity: SeverityError}
+
+	assert.True(t, rule.Matches(ReviewIssue{File: "internal/payments/charge.go", Category: "Security"}))
+	assert.False(t, rule.Matches(ReviewIssue{File: "internal/payments/charge.go", Category: "style"}))
+	assert.False(t, rule.Matches(ReviewIssue{File: "internal/users/user.go", Category: "security"}))
+
+	categoryOnly := SeverityRule{Categories: []string{"security"}, Severity: SeverityWarning}
+	assert.True(t, categoryOnly.Matches(ReviewIssue{File: "anything.go", Category: "security"}))
+}
+
+func TestSeverityRule_Validate(t *testing.T) {
+	assert.NoError(t, SeverityRule{Paths: []string{"/payments/"}, Severity: SeverityError}.Validate())
+	assert.Error(t, SeverityRule{Paths: []string{"/payments/"}, Severity: "critical"}.Validate())
+	assert.Error(t, SeverityRule{Severity: SeverityError}.Validate())
+}
+
+func TestSeverityRulesFromConfig(t *testing.T) {
+	rules, err := SeverityRulesFromConfig([]config.SeverityRuleConfig{
+		{Paths: []string{"/payments/"}, Categories: []string{"security"}, Severity: SeverityError},
+	}, "review.severity_rules")
+	require.NoError(t, err)
+	assert.Equal(t, []SeverityRule{{Paths: []string{"/payments/"}, Categories: []string{"security"}, Severity: SeverityError}}, rules)
+
+	_, err = SeverityRulesFromConfig([]config.SeverityRuleConfig{
+		{Paths: []string{"/payments/"}, Severity: SeverityError},
+		{Paths: []string{"/payments/"}, Severity: "critical"},
+	}, "review.migrations.severity_rules")
+	assert.ErrorContains(t, err, "invalid review.migrations.severity_rules[1]")
+}
+
+func TestEscalateSeverity(t *testing.T) {
+	issues := []ReviewIssue{
+		{File: "internal/payments/charge.go", Category: "security", Severity: SeverityInfo},
+		{File: "internal/payments/charge.go", Category: "style", Severity: SeverityInfo},
+		{File: "internal/payments/refund.go", Category: "security", Severity: SeverityError},
+		{File: "internal/users/user.go", Category: "security", Severity: SeverityInfo},
+	}
+	rules := []SeverityRule{
+		{Paths: []string{"/payments/"}, Categories: []string{"security"}, Severity: SeverityError},
+		{Categories: []string{"security"}, Severity: SeverityWarning},
+		{Paths: []string{"/payments/"}, Severity: SeverityInfo},
+	}
+
+	result, escalated := EscalateSeverity(issues, rules)
+	require.Len(t, result, 4)
+	assert.Equal(t, 2, escalated)
+
+	assert.Equal(t, SeverityError, result[0].Severity, "the highest matching rule wins")
+	assert.Equal(t, SeverityInfo, result[0].EscalatedFrom)
+	assert.Equal(t, SeverityInfo, result[1].Severity)
+	assert.Empty(t, result[1].EscalatedFrom)
+	assert.Equal(t, SeverityError, result[2].Severity, "rules never lower severity")
+	assert.Empty(t, result[2].EscalatedFrom)
+	assert.Equal(t, SeverityWarning, result[3].Severity)
+
+	assert.Equal(t, SeverityInfo, issues[0].Severity, "input is not modified")
+}
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+const (
+	// maxSampledTestFiles bounds how many test files DetectTestConventions reads
+	maxSampledTestFiles = 200
+	// maxTestFileBytes is how much of each sampled test file is read
+	maxTestFileBytes = 32 * 1024
+)
+
+// Kinds of test traits
+const (
+	testTraitFramework = "framework"
+	testTraitPattern   = "pattern"
+)
+
+// testTrait is a framework, library or pattern recognizable in test files
+// of a language
+type testTrait struct {
+	language string
+	kind     string
+	name     string
+	pattern  *regexp.Regexp
+}
+
+// testTraits are the traits DetectTestConventions looks for
+var testTraits = []testTrait{
+	{"Go", testTraitFramework, "testify (assert/require)", regexp.MustCompile(`"github\.com/stretchr/testify/(?:assert|require)"`)},
+	{"Go", testTraitFramework, "testify suites", regexp.MustCompile(`"github\.com/stretchr/testify/suite"`)},
+	{"Go", testTraitFramework, "testify mocks", regexp.MustCompile(`"github\.com/stretchr/testify/mock"`)},
+	{"Go", testTraitFramework, "gomock", regexp.MustCompile(`"(?:github\.com/golang|go\.uber\.org)/mock/gomock"`)},
+	{"Go", testTraitFramework, "Ginkgo/Gomega", regexp.MustCompile(`"github\.com/onsi/(?:ginkgo|gomega)`)},
+	{"Go", testTraitFramework, "go-cmp", regexp.MustCompile(`"github\.com/google/go-cmp/cmp"`)},
+	{"Go", testTraitFramework, "gocheck", regexp.MustCompile(`"gopkg\.in/check\.v1"`)},
+	{"Go", testTraitPattern, "table-driven tests", regexp.MustCompile(`(?:tests|cases|testCases|tcs)\s*:?=\s*\[\]struct|for\s+_,\s*(?:tt|tc|test)\s*:=\s*range`)},
+	{"Go", testTraitPattern, "subtests with t.Run", regexp.MustCompile(`\bt\.Run\(`)},
+	{"Go", testTraitPattern, "parallel tests (t.Parallel)", regexp.MustCompile(`\bt\.Parallel\(\)`)},
+	{"Go", testTraitPattern, "black-box test packages (package x_test)", regexp.MustCompile(`(?m)^package \w+_test\b`)},
+
+	{"Python", testTraitFramework, "pytest", regexp.MustCompile(`(?m)^\s*(?:import pytest|from pytest\b)|@pytest\.`)},
+	{"Python", testTraitFramework, "unittest", regexp.MustCompile(`unittest\.TestCase|(?m)^\s*(?:import unittest|from unittest import)`)},
+	{"Python", testTraitFramework, "unittest.mock", regexp.MustCompile(`unittest\.mock|from unittest import mock`)},
+	{"Python", testTraitPattern, "pytest fixtures", regexp.MustCompile(`@pytest\.fixture`)},
+	{"Python", testTraitPattern, "parametrized tests", regexp.MustCompile(`@pytest\.mark\.parametrize`)},
+
+	{"JavaScript/TypeScript", testTraitFramework, "Jest", regexp.MustCompile(`\bjest\.(?:fn|mock|spyOn)\(|from ['"]@jest/globals['"]`)},
+	{"JavaScript/TypeScript", testTraitFramework, "Vitest", regexp.MustCompile(`from ['"]vitest['"]|\bvi\.(?:fn|mock|spyOn)\(`)},
+	{"JavaScript/TypeScript", testTraitFramework, "Mocha/Chai", regexp.MustCompile(`from ['"](?:mocha|chai)['"]|require\(['"](?:mocha|chai)['"]\)`)},
+	{"JavaScript/TypeScript", testTraitFramework, "node:test", regexp.MustCompile(`['"]node:test['"]`)},
+	{"JavaScript/TypeScript", testTraitFramework, "Testing Library", regexp.MustCompile(`['"]@testing-library/`)},
+	{"JavaScript/TypeScript", testTraitPattern, "describe/it blocks", regexp.MustCompile(`\bdescribe\(`)},
+	{"JavaScript/TypeScript", testTraitPattern, "table tests (each)", regexp.MustCompile(`\b(?:it|test|describe)\.each\b`)},
+
+	{"Java/Kotlin", testTraitFramework, "JUnit 5", regexp.MustCompile(`org\.junit\.jupiter`)},
+	{"Java/Kotlin", testTraitFramework, "JUnit 4", regexp.MustCompile(`import org\.junit\.(?:Test|Assert|Before|After)\b`)},
+	{"Java/Kotlin", testTraitFramework, "TestNG", regexp.MustCompile(`import org\.testng\.`)},
+	{"Java/Kotlin", testTraitFramework, "AssertJ", regexp.MustCompile(`org\.assertj\.`)},
+	{"Java/Kotlin", testTraitFramework, "Mockito", regexp.MustCompile(`org\.mockito\.`)},
+	{"Java/Kotlin", testTraitPattern, "parameterized tests", regexp.MustCompile(`@ParameterizedTest|@Parameters\b`)},
+
+	{"Ruby", testTraitFramework, "RSpec", regexp.MustCompile(`\bRSpec\.describe\b|require ['"](?:rails_|spec_)helper['"]`)},
+	{"Ruby", testTraitFramework, "Minitest", regexp.MustCompile(`Minitest::Test|require ['"]minitest`)},
+}
+
+// testLanguages maps the extensions of test files to the languages of testTraits
+var testLanguages = map[string]string{
+	".go":   "Go",
+	".py":   "Python",
+	".js":   "JavaScript/TypeScript",
+	".jsx":  "JavaScript/TypeScript",
+	".mjs":  "JavaScript/TypeScript",
+	".cjs":  "JavaScript/TypeScript",
+	".ts":   "JavaScript/TypeScript",
+	".tsx":  "JavaScript/TypeScript",
+	".java": "Java/Kotlin",
+	".kt":   "Java/Kotlin",
+	".rb":   "Ruby",
+}
+
+// TestTrait is a framework or pattern and how many sampled test files use it
+type TestTrait struct {
+	Name  string
+	Files int
+}
+
+// LanguageTestConventions are the test conventions of one language
+type LanguageTestConventions struct {
+	Language   string
+	Files      int         // Sampled test files of the language
+	Frameworks []TestTrait // Most used first; Go tests without any are noted as using only the testing package
+	Patterns   []TestTrait // Most used first
+}
+
+// TestConventions summarizes how a project's existing tests are written
+type TestConventions struct {
+	Languages []LanguageTestConventions // Most test files first
+}
+
+// DetectTestConventions samples the test files of the repository at workDir
+// and detects the frameworks and patterns they use. It returns nil when the
+// repository has no test files of a known language.
+func DetectTestConventions(ctx context.Context, workDir string) (*TestConventions, error) {
+	files, err := git.ListFiles(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	var tests []string
+	for _, file := range files {
+		if testLanguages[path.Ext(file)] != "" && IsTestFile(file) && !strings.Contains(file, "testdata/") {
+			tests = append(tests, file)
+		}
+	}
+	tests = sampleEvenly(tests, maxSampledTestFiles)
+
+	type counts struct {
+		files  int
+		traits map[testTrait]int
+		bare   int // Files without any framework
+	}
+	byLanguage := make(map[string]*counts)
+	for _, file := range tests {
+		content, err := readHead(filepath.Join(workDir, file), maxTestFileBytes)
+		if err != nil {
+			continue
+		}
+		language := testLanguages[path.Ext(file)]
+		c := byLanguage[language]
+		if c == nil {
+			c = &counts{traits: make(map[testTrait]int)}
+			byLanguage[language] = c
+		}
+		c.files++
+		framework := false
+		for _, trait := range testTraits {
+			if trait.language == language && trait.pattern.MatchString(content) {
+				c.traits[trait]++
+				framework = framework || trait.kind == testTraitFramework
+			}
+		}
+		if !framework {
+			c.bare++
+		}
+	}
+	if len(byLanguage) == 0 {
+		return nil, nil
+	}
+
+	conventions := &TestConventions{}
+	for language, c := range byLanguage {
+		lc := LanguageTestConventions{Language: language, Files: c.files}
+		for trait, n := range c.traits {
+			if trait.kind == testTraitFramework {
+				lc.Frameworks = append(lc.Frameworks, TestTrait{Name: trait.name, Files: n})
+			} else {
+				lc.Patterns = append(lc.Patterns, TestTrait{Name: trait.name, Files: n})
+			}
+		}
+		if language == "Go" && c.bare > 0 {
+			lc.Frameworks = append(lc.Frameworks, TestTrait{Name: "only the standard testing package", Files: c.bare})
+		}
+		sortTestTraits(lc.Frameworks)
+		sortTestTraits(lc.Patterns)
+		conventions.Languages = append(conventions.Languages, lc)
+	}
+	sort.Slice(conventions.Languages, func(i, j int) bool {
+		a, b := conventions.Languages[i], conventions.Languages[j]
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return a.Language < b.Language
+	})
+	return conventions, nil
+}
+
+// Format describes the conventions for a prompt, one line per language
+func (c *TestConventions) Format() string {
+	var b strings.Builder
+	for _, lc := range c.Languages {
+		fmt.Fprintf(&b, "- %s (%d test file(s) sampled)", lc.Language, lc.Files)
+		if len(lc.Frameworks) > 0 {
+			fmt.Fprintf(&b, "; frameworks: %s", formatTestTraits(lc.Frameworks))
+		}
+		if len(lc.Patterns) > 0 {
+			fmt.Fprintf(&b, "; patterns: %s", formatTestTraits(lc.Patterns))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sortTestTraits sorts traits by the files using them, then by name
+func sortTestTraits(traits []TestTrait) {
+	sort.Slice(traits, func(i, j int) bool {
+		if traits[i].Files != traits[j].Files {
+			return traits[i].Files > traits[j].Files
+		}
+		return traits[i].Name < traits[j].Name
+	})
+}
+
+// formatTestTraits lists traits with their file counts
+func formatTestTraits(traits []TestTrait) string {
+	parts := make([]string, len(traits))
+	for i, trait := range traits {
+		parts[i] = fmt.Sprintf("%s (%d)", trait.Name, trait.Files)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sampleEvenly returns at most n of items, spread evenly over them so that a
+// large directory doesn't crowd out the others
+func sampleEvenly(items []string, n int) []string {
+	if len(items) <= n {
+		return items
+	}
+	sampled := make([]string, n)
+	for i := range sampled {
+		sampled[i] = items[i*len(items)/n]
+	}
+	return sampled
+}
+
+// readHead reads at most limit bytes of the file at p
+func readHead(p string, limit int64) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
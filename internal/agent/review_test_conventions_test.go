@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTestConventions(t *testing.T) {
+	repo := testutil.NewGitRepo(t)
+	repo.WriteFile("store/store_test.go", `package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct{ key, want string }{{"a", "1"}}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, Get(tt.key))
+		})
+	}
+}
+`)
+	repo.WriteFile("cache/cache_test.go", "package cache_test\n\nimport \"testing\"\n\nfunc TestCache(t *testing.T) {\n\tif New() == nil {\n\t\tt.Fatal(\"nil cache\")\n\t}\n}\n")
+	repo.WriteFile("tests/test_api.py", "import pytest\n\n@pytest.fixture\ndef client():\n    return Client()\n")
+	repo.WriteFile("store/testdata/fixture_test.go", "package fixture\n")
+	repo.WriteFile("store/store.go", "package store\n\nimport \"github.com/stretchr/testify/assert\"\n")
+
+	conventions, err := DetectTestConventions(context.Background(), repo.Dir)
+	require.NoError(t, err)
+	require.Len(t, conventions.Languages, 2)
+
+	golang := conventions.Languages[0]
+	assert.Equal(t, "Go", golang.Language)
+	assert.Equal(t, 2, golang.Files, "testdata and non-test files are not sampled")
+	assert.Equal(t, []TestTrait{{"only the standard testing package", 1}, {"testify (assert/require)", 1}}, golang.Frameworks)
+	assert.Equal(t, []TestTrait{{"black-box test packages (package x_test)", 1}, {"subtests with t.Run", 1}, {"table-driven tests", 1}}, golang.Patterns)
+
+	python := conventions.Languages[1]
+	assert.Equal(t, []TestTrait{{"pytest", 1}}, python.Frameworks)
+	assert.Equal(t, []TestTrait{{"pytest fixtures", 1}}, python.Patterns)
+
+	assert.Equal(t, "- Go (2 test file(s) sampled); frameworks: only the standard testing package (1), testify (assert/require) (1); "+
+		"patterns: black-box test packages (package x_test) (1), subtests with t.Run (1), table-driven tests (1)\n"+
+		"- Python (1 test file(s) sampled); frameworks: pytest (1); patterns: pytest fixtures (1)\n", conventions.Format())
+}
+
+func TestDetectTestConventions_NoTests(t *testing.T) {
+	repo := testutil.NewGitRepo(t)
+	repo.WriteFile("main.go", "package main\n")
+
+	conventions, err := DetectTestConventions(context.Background(), repo.Dir)
+	require.NoError(t, err)
+	assert.Nil(t, conventions)
+	assert.Equal(t, "prompt", ExtendWithTestConventions("prompt", conventions))
+}
+
+func TestExtendWithTestConventions(t *testing.T) {
+	base := BuildReviewSystemPrompt("en", "", "", "", "")
+	prompt := ExtendWithTestConventions(base, &TestConventions{Languages: []LanguageTestConventions{
+		{Language: "Go", Files: 3, Frameworks: []TestTrait{{"testify (assert/require)", 3}}},
+	}})
+	assert.Contains(t, prompt, "## Test Conventions")
+	assert.Contains(t, prompt, "- Go (3 test file(s) sampled); frameworks: testify (assert/require) (3)\n")
+	assert.True(t, strings.HasSuffix(prompt, "report new tests that depart from them.\n"))
+}
+
+func TestSampleEvenly(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e", "f"}
+	assert.Equal(t, items, sampleEvenly(items, 10))
+	assert.Equal(t, []string{"a", "c", "e"}, sampleEvenly(items, 3))
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// Triage decisions for review issues
+const (
+	TriagePending = "pending" // Not yet triaged
+	TriageFix     = "fix"     // Should be fixed
+	TriageIgnore  = "ignore"  // Accepted as-is, not reported by later reviews
+	TriageDefer   = "defer"   // Tracked for later
+)
+
+// DefaultTriagePath is where triage results are written, relative to the repository root
+const DefaultTriagePath = ".gitbuddy/review-triage.json"
+
+// TriagedIssue is a review issue with the user's triage decision
+type TriagedIssue struct {
+	ReviewIssue
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+}
+
+// TriageResult is the machine-readable outcome of an interactive triage
+// session. Later reviews read it to skip the ignored issues.
+type TriageResult struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	SessionID string         `json:"session_id,omitempty"`
+	Summary   string         `json:"summary,omitempty"`
+	Issues    []TriagedIssue `json:"issues"`
+}
+
+// triageResultVersion is bumped when the triage file format changes
+const triageResultVersion = 1
+
+// NewTriageResult creates a triage result with every issue pending
+func NewTriageResult(review *ReviewResponse) *TriageResult {
+	result := &TriageResult{
+		Version:   triageResultVersion,
+		CreatedAt: time.Now(),
+	}
+	if review == nil {
+		return result
+	}
+
+	result.SessionID = review.SessionID
+	result.Summary = review.Summary
+	result.Issues = make([]TriagedIssue, len(review.Issues))
+	for i, issue := range review.Issues {
+		result.Issues[i] = TriagedIssue{ReviewIssue: issue, Decision: TriagePending}
+	}
+	return result
+}
+
+// Counts returns the number of issues per decision
+func (r *TriageResult) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, issue := range r.Issues {
+		counts[issue.Decision]++
+	}
+	return counts
+}
+
+// SkipIgnored removes the issues of review marked ignore in r, matched by
+// fingerprint, and returns how many were removed
+func (r *TriageResult) SkipIgnored(review *ReviewResponse) int {
+	ignored := r.ignoredFingerprints()
+	if len(ignored) == 0 {
+		return 0
+	}
+
+	kept := review.Issues[:0]
+	for _, issue := range review.Issues {
+		if issue.Fingerprint == "" || !ignored[issue.Fingerprint] {
+			kept = append(kept, issue)
+		}
+	}
+	skipped := len(review.Issues) - len(kept)
+	review.Issues = kept
+	return skipped
+}
+
+// KeepIgnored adds the issues marked ignore in previous that r doesn't have,
+// so that saving r doesn't forget them
+func (r *TriageResult) KeepIgnored(previous *TriageResult) {
+	known := make(map[string]bool, len(r.Issues))
+	for _, issue := range r.Issues {
+		known[issue.Fingerprint] = true
+	}
+	for _, issue := range previous.Issues {
+		if issue.Decision == TriageIgnore && issue.Fingerprint != "" && !known[issue.Fingerprint] {
+			r.Issues = append(r.Issues, issue)
+			known[issue.Fingerprint] = true
+		}
+	}
+}
+
+// ignoredFingerprints returns the fingerprints of the issues marked ignore
+func (r *TriageResult) ignoredFingerprints() map[string]bool {
+	ignored := make(map[string]bool)
+	for _, issue := range r.Issues {
+		if issue.Decision == TriageIgnore && issue.Fingerprint != "" {
+			ignored[issue.Fingerprint] = true
+		}
+	}
+	return ignored
+}
+
+// SaveTriageResult writes a triage result as JSON, creating parent directories
+func SaveTriageResult(path string, result *TriageResult) error {
+	if err := sideeffect.Check("save triage result"); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create triage directory: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal triage result: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write triage result: %w", err)
+	}
+	return nil
+}
+
+// LoadTriageResult reads a triage result written by SaveTriageResult
+func LoadTriageResult(path string) (*TriageResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read triage result: %w", err)
+	}
+
+	var result TriageResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse triage result: %w", err)
+	}
+	if result.Version > triageResultVersion {
+		return nil, fmt.Errorf("unsupported triage result version: %d", result.Version)
+	}
+	return &result, nil
+}
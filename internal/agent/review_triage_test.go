@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriageResult(t *testing.T) {
+	review := &ReviewResponse{
+		SessionID: "review-1",
+		Summary:   "two issues",
+		Issues: []ReviewIssue{
+			{Severity: SeverityError, File: "a.go", Line: 3, Title: "nil deref"},
+			{Severity: SeverityInfo, File: "b.go", Line: 9, Title: "naming"},
+		},
+	}
+
+	result := NewTriageResult(review)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, "review-1", result.SessionID)
+	assert.Equal(t, 2, result.Counts()[TriagePending])
+
+	result.Issues[0].Decision = TriageFix
+	counts := result.Counts()
+	assert.Equal(t, 1, counts[TriageFix])
+	assert.Equal(t, 1, counts[TriagePending])
+}
+
+func TestSaveAndLoadTriageResult(t *testing.T) {
+	result := NewTriageResult(&ReviewResponse{
+		Issues: []ReviewIssue{{Severity: SeverityWarning, File: "a.go", Line: 1, Title: "unused"}},
+	})
+	result.Issues[0].Decision = TriageDefer
+
+	path := filepath.Join(t.TempDir(), "nested", "triage.json")
+	require.NoError(t, SaveTriageResult(path, result))
+
+	loaded, err := LoadTriageResult(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Issues, 1)
+	assert.Equal(t, TriageDefer, loaded.Issues[0].Decision)
+	assert.Equal(t, "unused", loaded.Issues[0].Title)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version": 1`)
+	assert.Contains(t, string(data), `"decision": "defer"`)
+	assert.Contains(t, string(data), `"file": "a.go"`)
+}
+
+func TestTriageResult_SkipIgnored(t *testing.T) {
+	previous := &TriageResult{Issues: []TriagedIssue{
+		{ReviewIssue: ReviewIssue{Title: "naming", Fingerprint: "aaa"}, Decision: TriageIgnore},
+		{ReviewIssue: ReviewIssue{Title: "nil deref", Fingerprint: "bbb"}, Decision: TriageFix},
+		{ReviewIssue: ReviewIssue{Title: "old", Fingerprint: "ccc"}, Decision: TriageIgnore},
+	}}
+	review := &ReviewResponse{Issues: []ReviewIssue{
+		{Title: "naming", Fingerprint: "aaa"},
+		{Title: "nil deref", Fingerprint: "bbb"},
+		{Title: "no location"},
+	}}
+
+	assert.Equal(t, 1, previous.SkipIgnored(review))
+	require.Len(t, review.Issues, 2)
+	assert.Equal(t, "nil deref", review.Issues[0].Title)
+	assert.Equal(t, "no location", review.Issues[1].Title)
+
+	// A new triage keeps the ignored issues the review no longer reported
+	result := NewTriageResult(review)
+	result.KeepIgnored(previous)
+	require.Len(t, result.Issues, 4)
+	assert.Equal(t, "naming", result.Issues[2].Title)
+	assert.Equal(t, "old", result.Issues[3].Title)
+	assert.Equal(t, 2, result.Counts()[TriageIgnore])
+}
+
+func TestLoadTriageResult_RejectsNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triage.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "issues": []}`), 0644))
+	_, err := LoadTriageResult(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,471 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// ToolHandler executes a tool call and returns the result for the model
+type ToolHandler = tools.Handler
+
+// AgentRunnerOptions contains configuration for AgentRunner
+type AgentRunnerOptions struct {
+	ChatModel            model.ChatModel
+	RetryConfig          llm.RetryConfig
+	Printer              *ui.StreamPrinter  // Shows the responses and tool calls; nil runs quietly
+	MaxRepeatedToolCalls int                // Identical consecutive tool calls tolerated before aborting (0 = default)
+	MessageModifier      MessageModifier    // Rewrites the history before it is sent, without changing it
+	Artifact             *artifactStream    // Streams the arguments of the submit tool (--raw-stream)
+	Phase                func() string      // Phase reported with the token usage
+	OnContent            func(string)       // Receives the response content as it is streamed
+	UncachedTools        []string           // Tools whose results are never reused for repeated calls, e.g. ones changing files
+	RestartBrokenStreams bool               // Request the response again when its stream breaks before a tool call is complete
+	Usage                session.TokenUsage // Tokens used before the runner, e.g. by the resumed session
+}
+
+// AgentRunner runs the loop shared by the agents: it streams the model's
+// responses with retries, shows them, and executes the tool calls with the
+// registered handlers, disabling failing tools and breaking loops of
+// identical calls. Agents configure it with their tools and submit handling.
+type AgentRunner struct {
+	opts     AgentRunnerOptions
+	handlers map[string]ToolHandler
+	uncached map[string]bool
+	failures *ToolFailureTracker
+	loop     *ToolCallLoopDetector
+	usage    session.TokenUsage
+}
+
+// NewAgentRunner creates a new AgentRunner
+func NewAgentRunner(opts AgentRunnerOptions) *AgentRunner {
+	uncached := make(map[string]bool, len(opts.UncachedTools))
+	for _, name := range opts.UncachedTools {
+		uncached[name] = true
+	}
+	return &AgentRunner{
+		opts:     opts,
+		handlers: make(map[string]ToolHandler),
+		uncached: uncached,
+		failures: NewToolFailureTracker(DefaultToolFailureThreshold),
+		loop:     NewToolCallLoopDetector(opts.MaxRepeatedToolCalls),
+		usage:    opts.Usage,
+	}
+}
+
+// Register sets the handler of the tool name
+func (r *AgentRunner) Register(name string, handler ToolHandler) {
+	r.handlers[name] = handler
+}
+
+// RegisterTools registers the handlers of the tools in registry
+func (r *AgentRunner) RegisterTools(registry *tools.ToolRegistry) {
+	for _, name := range registry.Names() {
+		r.Register(name, registry.Execute)
+	}
+}
+
+// Invalidate drops the cached results of tools, e.g. after the files they
+// read changed
+func (r *AgentRunner) Invalidate(names ...string) {
+	for _, name := range names {
+		r.loop.Invalidate(name)
+	}
+}
+
+// Usage returns the tokens used so far
+func (r *AgentRunner) Usage() session.TokenUsage {
+	return r.usage
+}
+
+// PrintDiagnostics prints the summary of the tool failures, if any
+func (r *AgentRunner) PrintDiagnostics() {
+	printToolDiagnostics(r.opts.Printer, r.failures)
+}
+
+// StreamResult is a streamed response of the model
+type StreamResult struct {
+	Message *schema.Message    // The response
+	Sent    []*schema.Message  // The messages sent for it, after the message modifier
+	Usage   session.TokenUsage // Tokens of the request
+}
+
+// Stream sends the history to the model, retrying failed requests and
+// dropping old tool results when the context window is exceeded, and streams
+// the response to the printer. It returns the history with the response
+// added, unless the response is empty. When reading the stream fails, the
+// partial response is added, so that the caller can salvage it, unless
+// RestartBrokenStreams is set and nothing worth salvaging was streamed yet,
+// in which case the response is requested again.
+func (r *AgentRunner) Stream(ctx context.Context, messages []*schema.Message) ([]*schema.Message, *StreamResult, error) {
+	for restarts := 0; ; restarts++ {
+		sent := r.modify(messages)
+		stream := func() (*schema.StreamReader[*schema.Message], error) {
+			return llm.WithRetryResult(ctx, r.opts.RetryConfig, func() (*schema.StreamReader[*schema.Message], error) {
+				return r.opts.ChatModel.Stream(ctx, sent)
+			})
+		}
+		streamReader, err := stream()
+		if compacted, ok := recoverContextOverflow(err, messages, r.printProgress); ok {
+			messages = compacted
+			sent = r.modify(messages)
+			streamReader, err = stream()
+		}
+		if err != nil {
+			return messages, nil, llm.ExplainError("LLM stream failed", err)
+		}
+
+		r.printInfo("LLM Response:")
+		r.newline()
+		msg, usage, err := r.receive(streamReader)
+		streamReader.Close()
+		r.addUsage(usage)
+		if err != nil {
+			if r.restartable(ctx, err, msg, restarts) {
+				r.newline()
+				r.printProgress(fmt.Sprintf("Stream broke before a tool call was complete (%v), requesting the response again", err))
+				if err := sleepContext(ctx, llm.CalculateBackoff(restarts+1, r.opts.RetryConfig.BackoffBase, r.opts.RetryConfig.BackoffMax)); err != nil {
+					return messages, nil, err
+				}
+				continue
+			}
+			// Keep what was streamed so far so that it can be salvaged
+			messages = append(messages, msg)
+			return messages, nil, llm.ExplainError("stream read error", err)
+		}
+		r.opts.Artifact.EndResponse()
+		r.newline()
+
+		if msg.Content != "" || len(msg.ToolCalls) > 0 {
+			messages = append(messages, msg)
+		}
+		return messages, &StreamResult{Message: msg, Sent: sent, Usage: usage}, nil
+	}
+}
+
+// receive reads a response from the stream, printing it as it arrives. When
+// reading fails, it returns the response streamed so far with the error.
+func (r *AgentRunner) receive(streamReader *schema.StreamReader[*schema.Message]) (*schema.Message, session.TokenUsage, error) {
+	var content strings.Builder
+	var toolCalls []*schema.ToolCall
+	var usage session.TokenUsage
+	toolArgStarted := false
+	for {
+		chunk, err := streamReader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return newAssistantMessage(content.String(), toolCalls), usage, err
+		}
+
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if r.opts.Printer != nil {
+				_ = r.opts.Printer.PrintLLMContent(chunk.Content)
+			}
+			if r.opts.OnContent != nil {
+				r.opts.OnContent(chunk.Content)
+			}
+		}
+
+		for _, tc := range chunk.ToolCalls {
+			idx := 0
+			if tc.Index != nil {
+				idx = *tc.Index
+			}
+			for len(toolCalls) <= idx {
+				toolCalls = append(toolCalls, &schema.ToolCall{})
+			}
+			call := toolCalls[idx]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Type != "" {
+				call.Type = tc.Type
+			}
+			if tc.Function.Name != "" {
+				if call.Function.Name == "" {
+					r.printToolCall(tc.Function.Name)
+					toolArgStarted = true
+				}
+				call.Function.Name = tc.Function.Name
+			}
+			if tc.Function.Arguments != "" {
+				call.Function.Arguments += tc.Function.Arguments
+				r.opts.Artifact.Feed(idx, call.Function.Name, tc.Function.Arguments)
+				if r.opts.Printer != nil && toolArgStarted {
+					_ = r.opts.Printer.PrintToolArgChunk(tc.Function.Arguments)
+				}
+			}
+		}
+
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			usage.PromptTokens += chunk.ResponseMeta.Usage.PromptTokens
+			usage.CompletionTokens += chunk.ResponseMeta.Usage.CompletionTokens
+			usage.TotalTokens += chunk.ResponseMeta.Usage.TotalTokens
+		}
+	}
+	return newAssistantMessage(content.String(), toolCalls), usage, nil
+}
+
+// restartable reports whether the response msg, whose stream broke with err,
+// is requested again: no tool call in it is complete, the error isn't
+// permanent and the retry attempts aren't used up
+func (r *AgentRunner) restartable(ctx context.Context, err error, msg *schema.Message, restarts int) bool {
+	if !r.opts.RestartBrokenStreams || !r.opts.RetryConfig.Enabled || restarts >= r.opts.RetryConfig.MaxAttempts {
+		return false
+	}
+	if ctx.Err() != nil || llm.ClassifyError(err) == llm.ErrorTypeNonRetryable {
+		return false
+	}
+	// Calls before the last one are complete; the last one is when its
+	// arguments are, which the stream may have broken in
+	switch n := len(msg.ToolCalls); {
+	case n == 0:
+		return true
+	case n == 1:
+		return !json.Valid([]byte(msg.ToolCalls[0].Function.Arguments))
+	default:
+		return false
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// Execute runs a tool call with its handler and returns the reply for the
+// model, with the result passed through GuardToolOutput. Calls of disabled
+// tools and repeated calls are answered without running the tool. An error
+// is returned when the model is stuck calling the tool, or when the handler
+// aborts the run.
+func (r *AgentRunner) Execute(ctx context.Context, call schema.ToolCall) (*schema.Message, error) {
+	name := call.Function.Name
+	if r.failures.IsDisabled(name) {
+		log.Debug("Tool %s is disabled, skipping call", name)
+		return toolReply(call, r.failures.DisabledMessage(name)), nil
+	}
+
+	cached, repeated, err := r.loop.Check(name, call.Function.Arguments)
+	if err != nil {
+		r.printProgress(err.Error())
+		return nil, err
+	}
+	if repeated {
+		r.printProgress(fmt.Sprintf("Repeated call to %s detected, reusing previous result", name))
+		return toolReply(call, GuardToolOutput(name, cached)+"\n\n"+RepeatedToolCallNudge(name)), nil
+	}
+
+	var result string
+	if handler, ok := r.handlers[name]; ok {
+		result, err = handler(ctx, call)
+	} else {
+		err = fmt.Errorf("unknown tool: %s", name)
+	}
+	if err != nil {
+		if isAborted(err) {
+			return nil, err
+		}
+		log.Debug("Tool %s error: %v", name, err)
+		reply := fmt.Sprintf("Error: %v", err)
+		if r.failures.RecordFailure(name, err) {
+			reply += "\n\n" + r.failures.DisabledMessage(name)
+			r.printProgress(fmt.Sprintf("Tool %s disabled after %d consecutive failures", name, DefaultToolFailureThreshold))
+		}
+		return toolReply(call, reply), nil
+	}
+
+	r.failures.RecordSuccess(name)
+	if !r.uncached[name] {
+		r.loop.Record(name, call.Function.Arguments, result)
+	}
+	if r.opts.Printer != nil {
+		_ = r.opts.Printer.PrintToolReturned(name, len(result), estimateTokenCount(result))
+	}
+	return toolReply(call, GuardToolOutput(name, result)), nil
+}
+
+// RunOptions configures a run of the agent loop
+type RunOptions struct {
+	MaxIterations int
+	Deadline      *Deadline
+	AgentType     string // Agent named when the model answers without calling tools
+	NoToolsError  error  // Returned when an answer without tool calls is acceptable but unusable
+	SubmitTool    string // Tool the agent submits its result with
+	// Submit takes a call of SubmitTool. An error rejects the submission and
+	// is returned to the model, so that it can fix and resubmit it.
+	Submit func(call schema.ToolCall) error
+}
+
+// Run streams responses and executes their tool calls until the submit tool
+// is called and accepted. It returns the history, which holds everything the
+// model produced so far when the run fails, so that a partial result can be
+// salvaged from it, except for the errors for which isAborted is true.
+func (r *AgentRunner) Run(ctx context.Context, messages []*schema.Message, opts RunOptions) ([]*schema.Message, error) {
+	for i := 0; i < opts.MaxIterations; i++ {
+		if opts.Deadline.Exceeded() {
+			return messages, opts.Deadline.Err()
+		}
+		if opts.Deadline.WrapUp() {
+			r.printProgress("Time budget nearly used up, asking the agent to submit")
+			messages = append(messages, &schema.Message{Role: schema.User, Content: opts.Deadline.WrapUpMessage(opts.SubmitTool)})
+		}
+		r.printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
+		emitProgress(r.opts.Printer, ui.ProgressEvent{Event: ui.EventIteration, Iteration: i + 1, MaxIterations: opts.MaxIterations})
+
+		var result *StreamResult
+		var err error
+		messages, result, err = r.Stream(ctx, messages)
+		if err != nil {
+			return messages, err
+		}
+
+		if len(result.Message.ToolCalls) == 0 {
+			if err := HandleNoToolCallsResponse(result.Message.Content, opts.AgentType); err != nil {
+				return messages, abortRun(err)
+			}
+			return messages, abortRun(opts.NoToolsError)
+		}
+
+		for _, tc := range result.Message.ToolCalls {
+			if tc.Function.Name == "" {
+				continue
+			}
+			if tc.Function.Name == opts.SubmitTool {
+				if err := opts.Submit(tc); err != nil {
+					log.Debug("Rejected %s: %v", tc.Function.Name, err)
+					messages = append(messages, toolReply(tc, fmt.Sprintf("Error: %v", err)))
+					continue
+				}
+				return messages, nil
+			}
+			reply, err := r.Execute(ctx, tc)
+			if err != nil {
+				return messages, err
+			}
+			messages = append(messages, reply)
+		}
+	}
+	return messages, fmt.Errorf("agent loop exceeded maximum iterations")
+}
+
+// modify applies the message modifier to the history and reminds the model
+// that tool output is untrusted
+func (r *AgentRunner) modify(messages []*schema.Message) []*schema.Message {
+	if r.opts.MessageModifier == nil {
+		return withUntrustedToolOutputReminder(messages)
+	}
+	modified := withUntrustedToolOutputReminder(r.opts.MessageModifier(messages))
+	log.Debug("MessageModifier applied, messages count: %d -> %d", len(messages), len(modified))
+	return modified
+}
+
+// addUsage counts the tokens of a request and reports the total
+func (r *AgentRunner) addUsage(usage session.TokenUsage) {
+	r.usage.PromptTokens += usage.PromptTokens
+	r.usage.CompletionTokens += usage.CompletionTokens
+	r.usage.TotalTokens += usage.TotalTokens
+	event := ui.ProgressEvent{
+		Event:            ui.EventTokens,
+		PromptTokens:     r.usage.PromptTokens,
+		CompletionTokens: r.usage.CompletionTokens,
+		TotalTokens:      r.usage.TotalTokens,
+	}
+	if r.opts.Phase != nil {
+		event.Phase = r.opts.Phase()
+	}
+	emitProgress(r.opts.Printer, event)
+}
+
+func (r *AgentRunner) printProgress(msg string) {
+	if r.opts.Printer != nil {
+		_ = r.opts.Printer.PrintProgress(msg)
+	}
+	log.Debug(msg)
+}
+
+func (r *AgentRunner) printInfo(msg string) {
+	if r.opts.Printer != nil {
+		_ = r.opts.Printer.PrintInfo(msg)
+	}
+}
+
+func (r *AgentRunner) printToolCall(name string) {
+	if r.opts.Printer != nil {
+		_ = r.opts.Printer.PrintToolCall(name, nil)
+		_ = r.opts.Printer.PrintToolArgStart()
+	}
+	log.Debug("Tool call: %s", name)
+}
+
+func (r *AgentRunner) newline() {
+	if r.opts.Printer != nil {
+		_ = r.opts.Printer.Newline()
+	}
+}
+
+// toolReply returns the message answering a tool call with content
+func toolReply(call schema.ToolCall, content string) *schema.Message {
+	return &schema.Message{Role: schema.Tool, Content: content, ToolCallID: call.ID}
+}
+
+// abortedError ends a run with an error no partial result is salvaged for,
+// e.g. because there is nothing to do
+type abortedError struct {
+	err error
+}
+
+func (e *abortedError) Error() string { return e.err.Error() }
+func (e *abortedError) Unwrap() error { return e.err }
+
+// abortRun marks err as ending the run without a partial result
+func abortRun(err error) error {
+	return &abortedError{err: err}
+}
+
+// isAborted reports whether err was marked with abortRun
+func isAborted(err error) bool {
+	var aborted *abortedError
+	return errors.As(err, &aborted)
+}
+
+// estimateTokenCount estimates the tokens of text: ~1.5 characters per token
+// for Chinese and ~4 for other text
+func estimateTokenCount(text string) int {
+	if len(text) == 0 {
+		return 0
+	}
+	// Count Chinese characters (CJK unified ideographs)
+	chineseChars := 0
+	for _, r := range text {
+		if r >= 0x4E00 && r <= 0x9FFF {
+			chineseChars++
+		}
+	}
+	otherChars := len([]rune(text)) - chineseChars
+	tokens := (chineseChars * 2 / 3) + (otherChars / 4)
+	if tokens == 0 {
+		tokens = 1 // At least 1 token for non-empty text
+	}
+	return tokens
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoParams struct {
+	Text string `json:"text"`
+}
+
+func echo(ctx context.Context, params *echoParams) (string, error) {
+	if params.Text == "" {
+		return "", errors.New("text is required")
+	}
+	return "echo: " + params.Text, nil
+}
+
+// newTestRunner returns a runner answering with turns, with the echo tool
+func newTestRunner(t *testing.T, opts AgentRunnerOptions, turns ...testutil.Turn) (*AgentRunner, *testutil.ScriptedProvider) {
+	t.Helper()
+	provider := testutil.NewScriptedProvider(turns...)
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	opts.ChatModel = chatModel
+	runner := NewAgentRunner(opts)
+	runner.Register("echo", tools.Bind[echoParams](echo))
+	return runner, provider
+}
+
+func TestAgentRunner_Run(t *testing.T) {
+	runner, provider := newTestRunner(t, AgentRunnerOptions{},
+		testutil.CallTool("echo", echoParams{Text: "hello"}),
+		testutil.CallTool("submit", map[string]string{"answer": ""}),
+		testutil.CallTool("submit", map[string]string{"answer": "42"}),
+	)
+
+	var answer string
+	messages, err := runner.Run(context.Background(), []*schema.Message{schema.UserMessage("question")}, RunOptions{
+		MaxIterations: 5,
+		SubmitTool:    "submit",
+		Submit: func(call schema.ToolCall) error {
+			var params struct {
+				Answer string `json:"answer"`
+			}
+			if err := unmarshalToolArgs(call.Function.Arguments, &params); err != nil {
+				return err
+			}
+			if params.Answer == "" {
+				return errors.New("answer is required")
+			}
+			answer = params.Answer
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "42", answer)
+	assert.Zero(t, provider.Remaining())
+
+	// question, echo call and result, rejected submit and its error, submit
+	require.Len(t, messages, 6)
+	assert.Equal(t, GuardToolOutput("echo", "echo: hello"), messages[2].Content)
+	assert.Equal(t, messages[1].ToolCalls[0].ID, messages[2].ToolCallID)
+	assert.Equal(t, "Error: answer is required", messages[4].Content)
+
+	usage := runner.Usage()
+	assert.Equal(t, 3*testutil.TurnUsage.TotalTokens, usage.TotalTokens)
+	assert.Equal(t, 3*testutil.TurnUsage.PromptTokens, usage.PromptTokens)
+}
+
+func TestAgentRunner_RunWithoutToolCalls(t *testing.T) {
+	runner, _ := newTestRunner(t, AgentRunnerOptions{}, testutil.Reply("Done."))
+	_, err := runner.Run(context.Background(), []*schema.Message{schema.UserMessage("question")}, RunOptions{
+		MaxIterations: 5,
+		AgentType:     "commit",
+		SubmitTool:    "submit",
+	})
+	require.Error(t, err)
+	assert.True(t, isAborted(err), "there is nothing to salvage from an answer without tools")
+}
+
+func TestAgentRunner_RunMaxIterations(t *testing.T) {
+	runner, _ := newTestRunner(t, AgentRunnerOptions{},
+		testutil.CallTool("echo", echoParams{Text: "a"}),
+		testutil.CallTool("echo", echoParams{Text: "b"}),
+	)
+	messages, err := runner.Run(context.Background(), []*schema.Message{schema.UserMessage("question")}, RunOptions{
+		MaxIterations: 2,
+		SubmitTool:    "submit",
+	})
+	require.Error(t, err)
+	assert.False(t, isAborted(err))
+	assert.Contains(t, err.Error(), "exceeded maximum iterations")
+	assert.Len(t, messages, 5, "the history is kept for salvaging")
+}
+
+func TestAgentRunner_Execute(t *testing.T) {
+	runner, _ := newTestRunner(t, AgentRunnerOptions{MaxRepeatedToolCalls: 2})
+	ctx := context.Background()
+	call := func(name, args string) schema.ToolCall {
+		return schema.ToolCall{ID: "call", Function: schema.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	reply, err := runner.Execute(ctx, call("missing", "{}"))
+	require.NoError(t, err)
+	assert.Equal(t, "Error: unknown tool: missing", reply.Content)
+	assert.Equal(t, "call", reply.ToolCallID)
+
+	reply, err = runner.Execute(ctx, call("echo", `{"text": "hi"}`))
+	require.NoError(t, err)
+	assert.Equal(t, GuardToolOutput("echo", "echo: hi"), reply.Content)
+
+	// A repeated call reuses the result, until the model is stuck
+	reply, err = runner.Execute(ctx, call("echo", `{"text":"hi"}`))
+	require.NoError(t, err)
+	assert.Contains(t, reply.Content, "echo: hi\n</tool_output>\n\nNote: you already called echo")
+	_, err = runner.Execute(ctx, call("echo", `{"text":"hi"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck in a loop")
+
+	// A failing tool is disabled
+	for i := 0; i < DefaultToolFailureThreshold; i++ {
+		reply, err = runner.Execute(ctx, call("echo", fmt.Sprintf(`{"n": %d}`, i)))
+		require.NoError(t, err)
+		assert.Contains(t, reply.Content, "Error: text is required")
+	}
+	reply, err = runner.Execute(ctx, call("echo", `{"text": "again"}`))
+	require.NoError(t, err)
+	assert.NotContains(t, reply.Content, "echo: again")
+
+	// Handlers can abort the run
+	runner.Register("stop", func(context.Context, schema.ToolCall) (string, error) {
+		return "", abortRun(errors.New("nothing to do"))
+	})
+	_, err = runner.Execute(ctx, call("stop", ""))
+	require.Error(t, err)
+	assert.True(t, isAborted(err))
+	assert.Equal(t, "nothing to do", err.Error())
+}
+
+func TestAgentRunner_UncachedTools(t *testing.T) {
+	runner, _ := newTestRunner(t, AgentRunnerOptions{UncachedTools: []string{"echo"}})
+	tc := schema.ToolCall{Function: schema.FunctionCall{Name: "echo", Arguments: `{"text":"hi"}`}}
+	_, err := runner.Execute(context.Background(), tc)
+	require.NoError(t, err)
+	reply, err := runner.Execute(context.Background(), tc)
+	require.NoError(t, err)
+	assert.Equal(t, GuardToolOutput("echo", "echo: hi"), reply.Content, "the tool runs again")
+}
+
+func TestAgentRunner_Stream(t *testing.T) {
+	var streamed string
+	var sent []*schema.Message
+	runner, provider := newTestRunner(t, AgentRunnerOptions{
+		RetryConfig: llm.RetryConfig{Enabled: true, MaxAttempts: 2, BackoffBase: 0.001, BackoffMax: 0.001},
+		OnContent:   func(chunk string) { streamed += chunk },
+		MessageModifier: func(messages []*schema.Message) []*schema.Message {
+			sent = append([]*schema.Message{schema.SystemMessage("progress")}, messages...)
+			return sent
+		},
+	},
+		testutil.Fail(context.DeadlineExceeded),
+		testutil.Reply("The cache key ignores the tenant."),
+		testutil.Reply(""),
+	)
+
+	history := []*schema.Message{schema.UserMessage("question")}
+	history, result, err := runner.Stream(context.Background(), history)
+	require.NoError(t, err, "the timeout is retried")
+	assert.Equal(t, "The cache key ignores the tenant.", streamed)
+	require.Len(t, result.Sent, len(sent))
+	assert.Equal(t, "progress\n\n"+UntrustedToolOutputReminder+"\n", result.Sent[0].Content, "the system prompt reminds that tool output is untrusted")
+	assert.Equal(t, sent[1:], result.Sent[1:])
+	assert.Equal(t, testutil.TurnUsage.TotalTokens, result.Usage.TotalTokens)
+	require.Len(t, history, 2, "the modifier doesn't change the history")
+	assert.Equal(t, result.Message, history[1])
+	assert.Len(t, provider.Requests()[1], 2)
+
+	history, result, err = runner.Stream(context.Background(), history)
+	require.NoError(t, err)
+	assert.Empty(t, result.Message.Content)
+	assert.Len(t, history, 2, "empty responses aren't added")
+}
+
+func TestAgentRunner_RestartBrokenStreams(t *testing.T) {
+	retry := llm.RetryConfig{Enabled: true, MaxAttempts: 1, BackoffBase: 0.001, BackoffMax: 0.001}
+	broken := testutil.Break(testutil.CallTool("echo", echoParams{Text: "hello"}), io.ErrUnexpectedEOF)
+	history := []*schema.Message{schema.UserMessage("question")}
+
+	runner, provider := newTestRunner(t, AgentRunnerOptions{RetryConfig: retry, RestartBrokenStreams: true},
+		broken,
+		testutil.CallTool("echo", echoParams{Text: "hello"}),
+	)
+	messages, result, err := runner.Stream(context.Background(), history)
+	require.NoError(t, err, "the response is requested again")
+	require.Len(t, messages, 2)
+	assert.JSONEq(t, `{"text":"hello"}`, result.Message.ToolCalls[0].Function.Arguments)
+	assert.Zero(t, provider.Remaining())
+	assert.Len(t, provider.Requests()[1], 1, "the partial response isn't sent")
+
+	// Once the attempts are used up, the partial response is kept
+	runner, _ = newTestRunner(t, AgentRunnerOptions{RetryConfig: retry, RestartBrokenStreams: true}, broken, broken)
+	messages, _, err = runner.Stream(context.Background(), history)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Len(t, messages, 2)
+	assert.Equal(t, `{"text":`, messages[1].ToolCalls[0].Function.Arguments)
+
+	// Without RestartBrokenStreams, broken streams are left to the caller
+	runner, provider = newTestRunner(t, AgentRunnerOptions{RetryConfig: retry}, broken, broken)
+	_, _, err = runner.Stream(context.Background(), history)
+	require.Error(t, err)
+	assert.Equal(t, 1, provider.Remaining())
+}
@@ -0,0 +1,218 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// checkpointDir is the directory of the checkpoints, under the session directory
+const checkpointDir = "checkpoints"
+
+// checkpointRecord is a line of a checkpoint log. The first record of a log
+// holds the session without its messages; each record then holds the
+// messages changed since the previous one and the agent state.
+type checkpointRecord struct {
+	Session        *Session          `json:"session,omitempty"`
+	From           int               `json:"from"`               // Messages from this index on are replaced by Messages
+	Messages       []*schema.Message `json:"messages,omitempty"` // Messages added or changed since the previous record
+	State          json.RawMessage   `json:"state,omitempty"`
+	TokenUsage     TokenUsage        `json:"token_usage"`
+	IterationCount int               `json:"iteration_count"`
+	MaxIterations  int               `json:"max_iterations"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// checkpointPath returns the checkpoint log of session sessionID
+func (m *Manager) checkpointPath(sessionID string) string {
+	return filepath.Join(m.saveDir, checkpointDir, sessionID+".jsonl")
+}
+
+// Checkpoint appends the progress of a running session to its checkpoint
+// log: the messages added or replaced since the previous checkpoint, and the
+// state. Agents call it after every iteration, so a run that crashes or is
+// killed can be recovered with Recover. Saving the session removes the log.
+// In check mode nothing is written.
+func (m *Manager) Checkpoint(session *Session) error {
+	if sideeffect.Blocked() {
+		return nil
+	}
+	if err := session.Validate(); err != nil {
+		return fmt.Errorf("invalid session: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkpointed == nil {
+		m.checkpointed = make(map[string][]*schema.Message)
+	}
+	written, started := m.checkpointed[session.ID]
+	path := m.checkpointPath(session.ID)
+	// Another process may have recovered the log, so it starts over
+	if _, err := os.Stat(path); started && err != nil {
+		written, started = nil, false
+	}
+
+	record := checkpointRecord{
+		State:          session.State,
+		TokenUsage:     session.TokenUsage,
+		IterationCount: session.IterationCount,
+		MaxIterations:  session.MaxIterations,
+		Metadata:       session.Metadata,
+		UpdatedAt:      time.Now(),
+	}
+	if !started {
+		header := *session
+		header.Messages = nil
+		record.Session = &header
+	}
+	// Messages are replaced rather than edited, e.g. when a stale read is
+	// flagged or the history is compressed, so the first message that isn't
+	// the one written is where the log diverges
+	for record.From < len(written) && record.From < len(session.Messages) && written[record.From] == session.Messages[record.From] {
+		record.From++
+	}
+	record.Messages = session.Messages[record.From:]
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !started {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	m.checkpointed[session.ID] = append([]*schema.Message(nil), session.Messages...)
+	return nil
+}
+
+// removeCheckpoint removes the checkpoint log of session sessionID, which a
+// saved or deleted session supersedes
+func (m *Manager) removeCheckpoint(sessionID string) {
+	m.mu.Lock()
+	delete(m.checkpointed, sessionID)
+	m.mu.Unlock()
+	// A leftover log is recovered as an older session, so the error is ignored
+	_ = os.Remove(m.checkpointPath(sessionID))
+}
+
+// Recover rebuilds the sessions of the checkpoint logs left by runs that
+// didn't end, saves them with their last checkpointed state and returns their
+// IDs. A line cut short by the crash ends its log, and logs without a
+// session are skipped. Sessions saved after their last checkpoint are kept as
+// saved.
+func (m *Manager) Recover() ([]string, error) {
+	if sideeffect.Blocked() {
+		return nil, nil
+	}
+	dir := filepath.Join(m.saveDir, checkpointDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+	}
+
+	var recovered []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		sessionID := strings.TrimSuffix(entry.Name(), ".jsonl")
+		session, err := m.replayCheckpoint(sessionID)
+		if err != nil {
+			continue
+		}
+		if saved, err := m.Load(sessionID); err == nil && !saved.UpdatedAt.Before(session.UpdatedAt) {
+			m.removeCheckpoint(sessionID)
+			continue
+		}
+		if err := m.write(session); err != nil {
+			return recovered, err
+		}
+		m.removeCheckpoint(sessionID)
+		recovered = append(recovered, sessionID)
+	}
+	return recovered, nil
+}
+
+// replayCheckpoint rebuilds session sessionID from its checkpoint log
+func (m *Manager) replayCheckpoint(sessionID string) (*Session, error) {
+	f, err := os.Open(m.checkpointPath(sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	var session *Session
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 50*1024*1024)
+	for scanner.Scan() {
+		var record checkpointRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			break
+		}
+		if record.Session != nil {
+			session = record.Session
+		}
+		if session == nil || record.From > len(session.Messages) {
+			break
+		}
+		session.Messages = append(session.Messages[:record.From], record.Messages...)
+		session.State = record.State
+		session.TokenUsage = record.TokenUsage
+		session.IterationCount = record.IterationCount
+		session.MaxIterations = record.MaxIterations
+		session.Metadata = record.Metadata
+		session.UpdatedAt = record.UpdatedAt
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if session == nil {
+		return nil, fmt.Errorf("checkpoint of session %s is empty", sessionID)
+	}
+	// The run died while the session was running
+	session.SetStatus(StatusRunning)
+	return session, nil
+}
+
+// Last recovers the checkpoints of runs that didn't end and returns the most
+// recently updated session of agentType that didn't complete
+func (m *Manager) Last(agentType string) (*Session, error) {
+	if _, err := m.Recover(); err != nil {
+		return nil, err
+	}
+	sessions, err := m.List(Filter{AgentType: agentType})
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range sessions {
+		if info.Status != StatusCompleted {
+			return m.Load(info.ID)
+		}
+	}
+	return nil, fmt.Errorf("no %s session to resume", agentType)
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestCheckpoint_Recover(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewManager(dir)
+	session := &Session{ID: "debug-2025-12-27-143045-a3f2", AgentType: "debug", CreatedAt: time.Now(), MaxIterations: 30}
+
+	system := &schema.Message{Role: schema.System, Content: "system"}
+	read := &schema.Message{Role: schema.Tool, ToolCallID: "call-1", Content: "package a"}
+	session.Messages = []*schema.Message{system, read}
+	session.IterationCount = 1
+	if err := manager.Checkpoint(session); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+
+	// The read is flagged as stale and a message is added
+	stale := &schema.Message{Role: schema.Tool, ToolCallID: "call-1", Content: "stale: package a"}
+	session.Messages = []*schema.Message{system, stale, {Role: schema.Assistant, Content: "found it"}}
+	session.IterationCount = 2
+	session.TokenUsage = TokenUsage{TotalTokens: 120}
+	if err := session.SetState(map[string]string{"phase": "execution"}); err != nil {
+		t.Fatalf("SetState() error = %v", err)
+	}
+	if err := manager.Checkpoint(session); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+
+	// The process is killed while writing the next checkpoint
+	f, err := os.OpenFile(manager.checkpointPath(session.ID), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"from":3,"messages":[{"role":`)
+	_ = f.Close()
+
+	// A new process recovers the session
+	recovered, err := NewManager(dir).Recover()
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if len(recovered) != 1 || recovered[0] != session.ID {
+		t.Fatalf("Recover() = %v, want [%s]", recovered, session.ID)
+	}
+	loaded, err := manager.Load(session.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Messages) != 3 || loaded.Messages[1].Content != "stale: package a" || loaded.Messages[2].Content != "found it" {
+		t.Errorf("recovered messages = %+v", loaded.Messages)
+	}
+	if loaded.IterationCount != 2 || loaded.TokenUsage.TotalTokens != 120 {
+		t.Errorf("recovered session = %+v", loaded)
+	}
+	var state map[string]string
+	if ok, err := loaded.LoadState(&state); !ok || err != nil || state["phase"] != "execution" {
+		t.Errorf("LoadState() = %v, %v, %v", state, ok, err)
+	}
+	if loaded.Status() != StatusRunning {
+		t.Errorf("Status() = %q, want %q", loaded.Status(), StatusRunning)
+	}
+	if _, err := os.Stat(manager.checkpointPath(session.ID)); !os.IsNotExist(err) {
+		t.Error("Recover() left the checkpoint")
+	}
+}
+
+func TestCheckpoint_RemovedBySave(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	session := &Session{ID: "review-2025-12-27-143045-a3f2", AgentType: "review", CreatedAt: time.Now()}
+	session.Messages = []*schema.Message{{Role: schema.User, Content: "review"}}
+	if err := manager.Checkpoint(session); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	if err := manager.Save(session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(manager.checkpointPath(session.ID)); !os.IsNotExist(err) {
+		t.Error("Save() left the checkpoint")
+	}
+
+	// The next checkpoint starts a new log
+	if err := manager.Checkpoint(session); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	replayed, err := manager.replayCheckpoint(session.ID)
+	if err != nil {
+		t.Fatalf("replayCheckpoint() error = %v", err)
+	}
+	if len(replayed.Messages) != 1 {
+		t.Errorf("replayed %d messages, want 1", len(replayed.Messages))
+	}
+}
+
+func TestManager_Last(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewManager(dir)
+	if _, err := manager.Last("debug"); err == nil {
+		t.Error("Last() error = nil without sessions")
+	}
+
+	completed := &Session{ID: "debug-2025-12-27-143045-a3f2", AgentType: "debug", CreatedAt: time.Now()}
+	completed.SetStatus(StatusCompleted)
+	interrupted := &Session{ID: "debug-2025-12-27-143046-b4e1", AgentType: "debug", CreatedAt: time.Now()}
+	interrupted.SetStatus(StatusInterrupted)
+	for _, s := range []*Session{interrupted, completed} {
+		if err := manager.Save(s); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	last, err := manager.Last("debug")
+	if err != nil || last.ID != interrupted.ID {
+		t.Fatalf("Last() = %v, %v; want %s", last, err, interrupted.ID)
+	}
+
+	// A crashed run is newer than the saved sessions
+	crashed := &Session{ID: "debug-2025-12-27-143047-c5d0", AgentType: "debug", CreatedAt: time.Now()}
+	if err := manager.Checkpoint(crashed); err != nil {
+		t.Fatalf("Checkpoint() error = %v", err)
+	}
+	last, err = NewManager(dir).Last("debug")
+	if err != nil || last.ID != crashed.ID {
+		t.Fatalf("Last() = %v, %v; want %s", last, err, crashed.ID)
+	}
+	if _, err := os.Stat(filepath.Join(dir, crashed.ID+".json")); err != nil {
+		t.Errorf("Last() didn't save the recovered session: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata keys recorded for every session
+const (
+	MetadataModel  = "model"    // provider/model
+	MetadataRepo   = "repo"     // Working directory of the run
+	MetadataBranch = "branch"   // Branch checked out during the run
+	MetadataFlags  = "flags"    // Flags the command was run with
+	MetadataStatus = "status"   // One of the Status constants
+	MetadataCost   = "cost_usd" // Estimated cost, when the model has prices configured
+)
+
+// Session statuses
+const (
+	StatusRunning     = "running"     // Saved during the run; stays so when the process died
+	StatusInterrupted = "interrupted" // Stopped with Ctrl+C, can be resumed
+	StatusPartial     = "partial"     // Ended with a partial result, e.g. out of budget
+	StatusCompleted   = "completed"
+	StatusFailed      = "failed"
+)
+
+// RunInfo describes the run sessions are saved from. The manager records it
+// in the metadata of every session it saves.
+type RunInfo struct {
+	Model       string
+	Repo        string
+	Branch      string
+	Flags       string
+	InputPrice  float64 // USD per million prompt tokens (0 = unknown)
+	OutputPrice float64 // USD per million completion tokens
+}
+
+// SetRunInfo sets the run recorded in the sessions saved by m
+func (m *Manager) SetRunInfo(info RunInfo) {
+	m.runInfo = &info
+}
+
+// apply records the run and the estimated cost in the metadata of s
+func (r *RunInfo) apply(s *Session) {
+	for key, value := range map[string]string{
+		MetadataModel:  r.Model,
+		MetadataRepo:   r.Repo,
+		MetadataBranch: r.Branch,
+		MetadataFlags:  r.Flags,
+	} {
+		if value != "" {
+			s.setMetadata(key, value)
+		}
+	}
+	if r.InputPrice > 0 || r.OutputPrice > 0 {
+		cost := (float64(s.TokenUsage.PromptTokens)*r.InputPrice + float64(s.TokenUsage.CompletionTokens)*r.OutputPrice) / 1e6
+		s.setMetadata(MetadataCost, strconv.FormatFloat(cost, 'f', 4, 64))
+	}
+}
+
+// SetStatus records the status of the session, one of the Status constants
+func (s *Session) SetStatus(status string) {
+	s.setMetadata(MetadataStatus, status)
+}
+
+// Status returns the recorded status of the session, "" for sessions saved
+// before statuses were recorded
+func (s *Session) Status() string {
+	return s.Metadata[MetadataStatus]
+}
+
+func (s *Session) setMetadata(key, value string) {
+	if s.Metadata == nil {
+		s.Metadata = make(map[string]string)
+	}
+	s.Metadata[key] = value
+}
+
+// SetStatus records the status of a saved session, e.g. when the run failed
+// after the agent saved it
+func (m *Manager) SetStatus(sessionID, status string) error {
+	sess, err := m.Load(sessionID)
+	if err != nil {
+		return err
+	}
+	sess.SetStatus(status)
+	return m.Save(sess)
+}
+
+// Filter selects sessions in List. Zero fields match every session.
+type Filter struct {
+	AgentType string
+	Status    string
+	Model     string    // Matches the model name with or without the provider
+	Since     time.Time // Created at or after
+	Until     time.Time // Created before
+}
+
+// Matches reports whether a session matches the filter
+func (f Filter) Matches(info *SessionInfo) bool {
+	switch {
+	case f.AgentType != "" && info.AgentType != f.AgentType:
+		return false
+	case f.Status != "" && info.Status != f.Status:
+		return false
+	case f.Model != "" && info.Model != f.Model && !strings.HasSuffix(info.Model, "/"+f.Model):
+		return false
+	case !f.Since.IsZero() && info.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !info.CreatedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// TestSave_RecordsRunInfo tests that Save records the run and the cost in the metadata
+func TestSave_RecordsRunInfo(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	mgr.SetRunInfo(RunInfo{
+		Model:       "openai/gpt-4o",
+		Repo:        "/src/app",
+		Branch:      "main",
+		Flags:       "--files=main.go",
+		InputPrice:  2.5,
+		OutputPrice: 10,
+	})
+
+	sess := &Session{
+		ID:         "debug-test",
+		AgentType:  "debug",
+		CreatedAt:  time.Now(),
+		Messages:   []*schema.Message{},
+		TokenUsage: TokenUsage{PromptTokens: 100000, CompletionTokens: 20000, TotalTokens: 120000},
+	}
+	sess.SetStatus(StatusCompleted)
+	if err := mgr.Save(sess); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := mgr.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]string{
+		MetadataModel:  "openai/gpt-4o",
+		MetadataRepo:   "/src/app",
+		MetadataBranch: "main",
+		MetadataFlags:  "--files=main.go",
+		MetadataStatus: StatusCompleted,
+		MetadataCost:   "0.4500",
+	}
+	for key, value := range want {
+		if loaded.Metadata[key] != value {
+			t.Errorf("Metadata[%q] = %q, want %q", key, loaded.Metadata[key], value)
+		}
+	}
+}
+
+// TestManager_SetStatus tests updating the status of a saved session
+func TestManager_SetStatus(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	sess := &Session{ID: "review-test", AgentType: "review", CreatedAt: time.Now(), Messages: []*schema.Message{}}
+	sess.SetStatus(StatusRunning)
+	if err := mgr.Save(sess); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := mgr.SetStatus(sess.ID, StatusFailed); err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+	loaded, err := mgr.Load(sess.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Status() != StatusFailed {
+		t.Errorf("Status() = %q, want %q", loaded.Status(), StatusFailed)
+	}
+
+	if err := mgr.SetStatus("missing", StatusFailed); err == nil {
+		t.Error("SetStatus() of a missing session should fail")
+	}
+}
+
+// TestList_Filter tests that List returns only the sessions matching the filter
+func TestList_Filter(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, s := range []struct {
+		id, agent, status, model string
+		created                  time.Time
+	}{
+		{"debug-1", "debug", StatusCompleted, "openai/gpt-4o", day},
+		{"debug-2", "debug", StatusInterrupted, "deepseek/deepseek-chat", day.AddDate(0, 0, 1)},
+		{"review-1", "review", StatusCompleted, "openai/gpt-4o", day.AddDate(0, 0, 2)},
+	} {
+		sess := &Session{
+			ID:        s.id,
+			AgentType: s.agent,
+			CreatedAt: s.created,
+			Messages:  []*schema.Message{},
+			Metadata:  map[string]string{MetadataModel: s.model},
+		}
+		sess.SetStatus(s.status)
+		if err := mgr.Save(sess); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "no filter", filter: Filter{}, want: []string{"debug-1", "debug-2", "review-1"}},
+		{name: "agent", filter: Filter{AgentType: "debug"}, want: []string{"debug-1", "debug-2"}},
+		{name: "status", filter: Filter{Status: StatusCompleted}, want: []string{"debug-1", "review-1"}},
+		{name: "model with provider", filter: Filter{Model: "openai/gpt-4o"}, want: []string{"debug-1", "review-1"}},
+		{name: "model without provider", filter: Filter{Model: "deepseek-chat"}, want: []string{"debug-2"}},
+		{name: "partial model name", filter: Filter{Model: "gpt"}, want: nil},
+		{name: "since", filter: Filter{Since: day.AddDate(0, 0, 1)}, want: []string{"debug-2", "review-1"}},
+		{name: "until", filter: Filter{Until: day.AddDate(0, 0, 1)}, want: []string{"debug-1"}},
+		{name: "combined", filter: Filter{AgentType: "debug", Status: StatusCompleted, Since: day}, want: []string{"debug-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := mgr.List(tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			got := make(map[string]bool)
+			for _, s := range sessions {
+				got[s.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("List() returned %d sessions, want %v", len(got), tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("List() is missing %s", id)
+				}
+			}
+		})
+	}
+}
@@ -9,10 +9,13 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // Session represents a saved agent execution session
@@ -21,14 +24,15 @@ type Session struct {
 	AgentType      string            `json:"agent_type"` // "debug" or "review"
 	CreatedAt      time.Time         `json:"created_at"`
 	UpdatedAt      time.Time         `json:"updated_at"`
-	Request        json.RawMessage   `json:"request"`         // Original request (DebugRequest or ReviewRequest)
-	Messages       []*schema.Message `json:"messages"`        // Message history
-	ExecutionPlan  json.RawMessage   `json:"execution_plan"`  // Debug Agent: ExecutionPlan
-	PhaseHistory   json.RawMessage   `json:"phase_history"`   // Debug Agent: PhaseHistory
-	TokenUsage     TokenUsage        `json:"token_usage"`     // Token usage statistics
-	IterationCount int               `json:"iteration_count"` // Current iteration count
-	MaxIterations  int               `json:"max_iterations"`  // Maximum iterations
-	Metadata       map[string]string `json:"metadata"`        // Additional metadata (model, language, etc.)
+	Request        json.RawMessage   `json:"request"`                  // Original request (DebugRequest or ReviewRequest)
+	Messages       []*schema.Message `json:"messages"`                 // Message history
+	State          json.RawMessage   `json:"state,omitempty"`          // Agent state needed to resume, see SetState
+	ExecutionPlan  json.RawMessage   `json:"execution_plan,omitempty"` // Debug Agent: ExecutionPlan of sessions saved before State
+	PhaseHistory   json.RawMessage   `json:"phase_history,omitempty"`  // Debug Agent: PhaseHistory of sessions saved before State
+	TokenUsage     TokenUsage        `json:"token_usage"`              // Token usage statistics
+	IterationCount int               `json:"iteration_count"`          // Current iteration count
+	MaxIterations  int               `json:"max_iterations"`           // Maximum iterations
+	Metadata       map[string]string `json:"metadata"`                 // Additional metadata (model, language, etc.)
 }
 
 // TokenUsage represents token usage statistics
@@ -48,6 +52,10 @@ type SessionInfo struct {
 	MaxIterations int       `json:"max_iterations"`
 	TotalTokens   int       `json:"total_tokens"`
 	SizeBytes     int64     `json:"size_bytes"`
+	Status        string    `json:"status,omitempty"`
+	Model         string    `json:"model,omitempty"`
+	Branch        string    `json:"branch,omitempty"`
+	CostUSD       float64   `json:"cost_usd,omitempty"`
 }
 
 // Validate validates the session fields
@@ -68,6 +76,30 @@ func (s *Session) Validate() error {
 	return nil
 }
 
+// SetState stores the state the agent needs to resume the session, such as
+// the execution plan of a debug session
+func (s *Session) SetState(state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session state: %w", err)
+	}
+	s.State = data
+	return nil
+}
+
+// LoadState restores the state stored with SetState into state. It returns
+// false when the session has no state, e.g. because it was saved by an
+// earlier version.
+func (s *Session) LoadState(state any) (bool, error) {
+	if len(s.State) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(s.State, state); err != nil {
+		return false, fmt.Errorf("failed to unmarshal session state: %w", err)
+	}
+	return true, nil
+}
+
 // GenerateSessionID generates a unique session ID
 // Format: {agent-type}-{timestamp}-{short-id}
 // Example: debug-2025-12-27-143045-a3f2
@@ -90,6 +122,10 @@ func generateShortID() string {
 // Manager manages session persistence
 type Manager struct {
 	saveDir string
+	runInfo *RunInfo // Recorded in saved sessions, see SetRunInfo
+
+	mu           sync.Mutex
+	checkpointed map[string][]*schema.Message // Messages in the checkpoint log of each session, see Checkpoint
 }
 
 // NewManager creates a new session manager
@@ -99,8 +135,28 @@ func NewManager(saveDir string) *Manager {
 	}
 }
 
-// Save saves a session to disk
+// Save saves a session to disk and removes its checkpoint log. In check mode
+// nothing is saved, so a check run leaves no session behind.
 func (m *Manager) Save(session *Session) error {
+	if sideeffect.Blocked() {
+		return nil
+	}
+
+	// Update timestamp
+	session.UpdatedAt = time.Now()
+	if m.runInfo != nil {
+		m.runInfo.apply(session)
+	}
+
+	if err := m.write(session); err != nil {
+		return err
+	}
+	m.removeCheckpoint(session.ID)
+	return nil
+}
+
+// write writes a session to its file as it is
+func (m *Manager) write(session *Session) error {
 	if err := session.Validate(); err != nil {
 		return fmt.Errorf("invalid session: %w", err)
 	}
@@ -110,9 +166,6 @@ func (m *Manager) Save(session *Session) error {
 		return fmt.Errorf("failed to create session directory: %w", err)
 	}
 
-	// Update timestamp
-	session.UpdatedAt = time.Now()
-
 	// Serialize to JSON
 	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
@@ -158,8 +211,8 @@ func (m *Manager) Load(sessionID string) (*Session, error) {
 	return &session, nil
 }
 
-// List lists all sessions
-func (m *Manager) List() ([]*SessionInfo, error) {
+// List lists the sessions matching filter, most recently updated first
+func (m *Manager) List(filter Filter) ([]*SessionInfo, error) {
 	// Ensure directory exists
 	if err := os.MkdirAll(m.saveDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create session directory: %w", err)
@@ -188,7 +241,7 @@ func (m *Manager) List() ([]*SessionInfo, error) {
 			continue
 		}
 
-		sessions = append(sessions, &SessionInfo{
+		sessionInfo := &SessionInfo{
 			ID:            session.ID,
 			AgentType:     session.AgentType,
 			CreatedAt:     session.CreatedAt,
@@ -197,7 +250,14 @@ func (m *Manager) List() ([]*SessionInfo, error) {
 			MaxIterations: session.MaxIterations,
 			TotalTokens:   session.TokenUsage.TotalTokens,
 			SizeBytes:     info.Size(),
-		})
+			Status:        session.Status(),
+			Model:         session.Metadata[MetadataModel],
+			Branch:        session.Metadata[MetadataBranch],
+		}
+		sessionInfo.CostUSD, _ = strconv.ParseFloat(session.Metadata[MetadataCost], 64)
+		if filter.Matches(sessionInfo) {
+			sessions = append(sessions, sessionInfo)
+		}
 	}
 
 	// Sort by updated time (newest first)
@@ -210,6 +270,9 @@ func (m *Manager) List() ([]*SessionInfo, error) {
 
 // Delete deletes a session
 func (m *Manager) Delete(sessionID string) error {
+	if err := sideeffect.Check("delete session"); err != nil {
+		return err
+	}
 	filePath := filepath.Join(m.saveDir, sessionID+".json")
 
 	if err := os.Remove(filePath); err != nil {
@@ -218,13 +281,14 @@ func (m *Manager) Delete(sessionID string) error {
 		}
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	m.removeCheckpoint(sessionID)
 
 	return nil
 }
 
 // CleanupOld removes old sessions, keeping only the most recent maxSessions
 func (m *Manager) CleanupOld(maxSessions int) error {
-	sessions, err := m.List()
+	sessions, err := m.List(Filter{})
 	if err != nil {
 		return err
 	}
@@ -251,3 +315,105 @@ func (m *Manager) Exists(sessionID string) bool {
 	_, err := os.Stat(filePath)
 	return err == nil
 }
+
+// PrunePolicy limits the sessions kept on disk. Zero values mean no limit.
+type PrunePolicy struct {
+	MaxSessions int           // Number of sessions to keep, most recently updated first
+	MaxAge      time.Duration // Sessions not updated for longer are removed
+	MaxBytes    int64         // Total size of the session files
+	Keep        []string      // IDs of sessions that are never removed, e.g. one being resumed
+	DryRun      bool          // Only report what would be removed
+}
+
+// PruneResult summarizes a prune pass
+type PruneResult struct {
+	Removed    int      // Sessions removed
+	RemovedIDs []string // IDs of the removed sessions, oldest last
+	FreedBytes int64    // Size of the removed session files
+	Kept       int      // Sessions left
+	KeptBytes  int64    // Size of the session files left
+}
+
+// Prune removes the sessions exceeding policy: first those older than
+// MaxAge, then the oldest beyond MaxSessions, then the oldest until the
+// directory fits MaxBytes. Ages and sizes come from the files, so large
+// directories are pruned without loading any session; corrupted files count
+// too. A missing directory has nothing to prune. With DryRun, the result
+// tells what would be removed.
+func (m *Manager) Prune(policy PrunePolicy, now time.Time) (*PruneResult, error) {
+	if !policy.DryRun {
+		if err := sideeffect.Check("prune sessions"); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := os.ReadDir(m.saveDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &PruneResult{}, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	type sessionFile struct {
+		id      string
+		size    int64
+		updated time.Time
+	}
+	var files []sessionFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, sessionFile{id: strings.TrimSuffix(entry.Name(), ".json"), size: info.Size(), updated: info.ModTime()})
+	}
+	// Newest first, so the sessions beyond a limit are at the end
+	sort.Slice(files, func(i, j int) bool { return files[i].updated.After(files[j].updated) })
+
+	keep := make(map[string]bool, len(policy.Keep))
+	for _, id := range policy.Keep {
+		keep[id] = true
+	}
+
+	result := &PruneResult{}
+	remove := func(f sessionFile) bool {
+		if !policy.DryRun {
+			if err := os.Remove(filepath.Join(m.saveDir, f.id+".json")); err != nil {
+				return false
+			}
+		}
+		result.Removed++
+		result.RemovedIDs = append(result.RemovedIDs, f.id)
+		result.FreedBytes += f.size
+		return true
+	}
+
+	var kept []sessionFile
+	for _, f := range files {
+		expired := policy.MaxAge > 0 && now.Sub(f.updated) > policy.MaxAge
+		beyondCount := policy.MaxSessions > 0 && len(kept) >= policy.MaxSessions
+		if !keep[f.id] && (expired || beyondCount) && remove(f) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	var total int64
+	for _, f := range kept {
+		total += f.size
+	}
+	// Drop the oldest until the rest fits the quota
+	for i := len(kept) - 1; i >= 0 && policy.MaxBytes > 0 && total > policy.MaxBytes; i-- {
+		if !keep[kept[i].id] && remove(kept[i]) {
+			total -= kept[i].size
+			kept = append(kept[:i], kept[i+1:]...)
+		}
+	}
+
+	result.Kept = len(kept)
+	result.KeptBytes = total
+	return result, nil
+}
@@ -2,12 +2,14 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // TestSession_MarshalJSON tests Session JSON serialization
@@ -349,7 +351,7 @@ func TestList_EmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	mgr := NewManager(tmpDir)
 
-	sessions, err := mgr.List()
+	sessions, err := mgr.List(Filter{})
 	if err != nil {
 		t.Fatalf("List() error = %v", err)
 	}
@@ -378,7 +380,7 @@ func TestList_MultipleSessions(t *testing.T) {
 		time.Sleep(10 * time.Millisecond) // Ensure different timestamps
 	}
 
-	sessions, err := mgr.List()
+	sessions, err := mgr.List(Filter{})
 	if err != nil {
 		t.Fatalf("List() error = %v", err)
 	}
@@ -417,7 +419,7 @@ func TestList_SkipsCorruptedFiles(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	sessions, err := mgr.List()
+	sessions, err := mgr.List(Filter{})
 	if err != nil {
 		t.Fatalf("List() error = %v", err)
 	}
@@ -490,7 +492,7 @@ func TestCleanupOld_NoCleanupNeeded(t *testing.T) {
 		t.Fatalf("CleanupOld() error = %v", err)
 	}
 
-	sessions, _ := mgr.List()
+	sessions, _ := mgr.List(Filter{})
 	if len(sessions) != 3 {
 		t.Errorf("CleanupOld() deleted sessions when it shouldn't, got %d sessions", len(sessions))
 	}
@@ -521,12 +523,83 @@ func TestCleanupOld_RemovesOldSessions(t *testing.T) {
 		t.Fatalf("CleanupOld() error = %v", err)
 	}
 
-	sessions, _ := mgr.List()
+	sessions, _ := mgr.List(Filter{})
 	if len(sessions) != 2 {
 		t.Errorf("CleanupOld() kept %d sessions, want 2", len(sessions))
 	}
 }
 
+// writeSessionFile writes a session file of size bytes last updated age ago
+func writeSessionFile(t *testing.T, dir, id string, size int, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(dir, id+".json")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	updated := time.Now().Add(-age)
+	if err := os.Chtimes(path, updated, updated); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+}
+
+// TestPrune tests age, count and size based pruning
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PrunePolicy
+		wantIDs []string
+	}{
+		{name: "no limits", policy: PrunePolicy{}, wantIDs: []string{"a", "b", "c", "d"}},
+		{name: "max age", policy: PrunePolicy{MaxAge: 36 * time.Hour}, wantIDs: []string{"a", "b"}},
+		{name: "max sessions", policy: PrunePolicy{MaxSessions: 3}, wantIDs: []string{"a", "b", "c"}},
+		{name: "max bytes", policy: PrunePolicy{MaxBytes: 250}, wantIDs: []string{"a", "b"}},
+		{name: "keep", policy: PrunePolicy{MaxAge: time.Hour, Keep: []string{"d"}}, wantIDs: []string{"a", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeSessionFile(t, dir, "a", 100, time.Minute)
+			writeSessionFile(t, dir, "b", 100, 24*time.Hour)
+			writeSessionFile(t, dir, "c", 100, 48*time.Hour)
+			writeSessionFile(t, dir, "d", 100, 72*time.Hour)
+			mgr := NewManager(dir)
+
+			result, err := mgr.Prune(tt.policy, time.Now())
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			for _, id := range []string{"a", "b", "c", "d"} {
+				want := false
+				for _, kept := range tt.wantIDs {
+					want = want || kept == id
+				}
+				if mgr.Exists(id) != want {
+					t.Errorf("Prune() kept %s = %v, want %v", id, mgr.Exists(id), want)
+				}
+			}
+			if result.Kept != len(tt.wantIDs) || result.Removed != 4-len(tt.wantIDs) {
+				t.Errorf("Prune() = %+v, want %d kept", result, len(tt.wantIDs))
+			}
+			if result.FreedBytes != int64(100*result.Removed) || result.KeptBytes != int64(100*result.Kept) {
+				t.Errorf("Prune() sizes = %+v", result)
+			}
+		})
+	}
+}
+
+// TestPrune_MissingDirectory tests pruning a directory that doesn't exist
+func TestPrune_MissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	result, err := NewManager(dir).Prune(PrunePolicy{MaxSessions: 1}, time.Now())
+	if err != nil || result.Removed != 0 {
+		t.Fatalf("Prune() = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Prune() created the directory")
+	}
+}
+
 // TestExists tests session existence check
 func TestExists(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -686,3 +759,83 @@ func TestSave_ChatSession(t *testing.T) {
 		t.Errorf("Messages count = %v, want 1", len(loaded.Messages))
 	}
 }
+
+// TestManager_CheckMode tests that sessions are neither saved nor pruned in check mode
+func TestManager_CheckMode(t *testing.T) {
+	sideeffect.SetBlocked(true)
+	defer sideeffect.SetBlocked(false)
+
+	dir := filepath.Join(t.TempDir(), "sessions")
+	manager := NewManager(dir)
+	session := &Session{
+		ID:            "review-2025-12-27-143045-a3f2",
+		AgentType:     "review",
+		CreatedAt:     time.Now(),
+		MaxIterations: 10,
+	}
+
+	if err := manager.Save(session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Save() created %s in check mode", dir)
+	}
+	if _, err := manager.Prune(PrunePolicy{MaxSessions: 1}, time.Now()); !errors.Is(err, sideeffect.ErrBlocked) {
+		t.Errorf("Prune() error = %v, want ErrBlocked", err)
+	}
+}
+
+func TestSession_State(t *testing.T) {
+	type state struct {
+		Phase string   `json:"phase"`
+		Tasks []string `json:"tasks"`
+	}
+	session := &Session{ID: "debug-2025-12-27-143045-a3f2", AgentType: "debug", CreatedAt: time.Now()}
+
+	var loaded state
+	if ok, err := session.LoadState(&loaded); ok || err != nil {
+		t.Fatalf("LoadState() = %v, %v; want false, nil without state", ok, err)
+	}
+
+	if err := session.SetState(state{Phase: "execution", Tasks: []string{"check logs"}}); err != nil {
+		t.Fatalf("SetState() error = %v", err)
+	}
+	manager := NewManager(t.TempDir())
+	if err := manager.Save(session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	resumed, err := manager.Load(session.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if ok, err := resumed.LoadState(&loaded); !ok || err != nil {
+		t.Fatalf("LoadState() = %v, %v; want true, nil", ok, err)
+	}
+	if loaded.Phase != "execution" || len(loaded.Tasks) != 1 {
+		t.Errorf("LoadState() restored %+v", loaded)
+	}
+
+	resumed.State = json.RawMessage(`{"phase":1}`)
+	if _, err := resumed.LoadState(&loaded); err == nil {
+		t.Error("LoadState() error = nil for mismatched state")
+	}
+}
+
+// TestPrune_DryRun tests that a dry run reports the sessions without removing them
+func TestPrune_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeSessionFile(t, dir, "a", 100, time.Minute)
+	writeSessionFile(t, dir, "b", 100, 48*time.Hour)
+	mgr := NewManager(dir)
+
+	result, err := mgr.Prune(PrunePolicy{MaxAge: 24 * time.Hour, DryRun: true}, time.Now())
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if result.Removed != 1 || len(result.RemovedIDs) != 1 || result.RemovedIDs[0] != "b" {
+		t.Errorf("Prune() = %+v, want b removed", result)
+	}
+	if !mgr.Exists("b") {
+		t.Errorf("Prune() removed b in a dry run")
+	}
+}
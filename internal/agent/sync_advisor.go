@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// rebaseCommitLimit is the number of local commits above which a conflicting
+// rebase is not recommended, as conflicts would have to be resolved per commit
+const rebaseCommitLimit = 10
+
+// SyncTarget is the divergence of the current branch from another branch
+type SyncTarget struct {
+	Ref    string
+	Ahead  int // Commits on the current branch missing from Ref
+	Behind int // Commits on Ref missing from the current branch
+	// Conflicts lists the files a merge with Ref would conflict in. It is only
+	// computed when both sides have new commits and merge-tree is available.
+	Conflicts      []string
+	ConflictsKnown bool
+}
+
+// Diverged reports whether both sides have commits the other lacks
+func (t *SyncTarget) Diverged() bool {
+	return t.Ahead > 0 && t.Behind > 0
+}
+
+// SyncAdvice is one recommended step with the exact commands to run
+type SyncAdvice struct {
+	Summary  string
+	Commands []string
+}
+
+// SyncReport summarizes how the current branch relates to its upstream and
+// the default branch
+type SyncReport struct {
+	Branch        string
+	Upstream      *SyncTarget // nil when the branch has no upstream
+	DefaultBranch *SyncTarget // nil when the branch is the default branch
+	Warnings      []string
+}
+
+// AnalyzeSync compares branch against its upstream and the default branch
+// (defaultBranch overrides the detected one when not empty)
+func AnalyzeSync(ctx context.Context, workDir, branch, defaultBranch string) (*SyncReport, error) {
+	report := &SyncReport{Branch: branch}
+
+	upstream, err := git.Upstream(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if upstream != "" {
+		if report.Upstream, err = analyzeSyncTarget(ctx, workDir, upstream, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if defaultBranch == "" {
+		if defaultBranch, err = git.DefaultBranch(ctx, workDir); err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+			return report, nil
+		}
+	}
+	if defaultBranch == upstream || defaultBranch == branch || strings.TrimPrefix(defaultBranch, "origin/") == branch {
+		return report, nil
+	}
+	if report.DefaultBranch, err = analyzeSyncTarget(ctx, workDir, defaultBranch, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// analyzeSyncTarget counts the divergence from ref and predicts conflicts
+func analyzeSyncTarget(ctx context.Context, workDir, ref string, report *SyncReport) (*SyncTarget, error) {
+	target := &SyncTarget{Ref: ref}
+
+	var err error
+	if target.Ahead, target.Behind, err = git.RevListCount(ctx, workDir, ref, "HEAD"); err != nil {
+		return nil, err
+	}
+	if !target.Diverged() {
+		return target, nil
+	}
+
+	result, err := git.MergeTree(ctx, workDir, ref, "HEAD")
+	if errors.Is(err, git.ErrMergeTreeUnsupported) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("conflicts with %s not predicted: %v", ref, err))
+		return target, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	target.Conflicts = result.Conflicts
+	target.ConflictsKnown = true
+	return target, nil
+}
+
+// RecommendSync returns the steps to bring the branch in sync, starting with
+// its own upstream
+func RecommendSync(report *SyncReport) []SyncAdvice {
+	var advice []SyncAdvice
+	detached := report.Branch == "" || report.Branch == "HEAD"
+
+	switch up := report.Upstream; {
+	case up == nil && !detached:
+		advice = append(advice, SyncAdvice{
+			Summary:  fmt.Sprintf("%s has no upstream; publish it to share and back up your work.", report.Branch),
+			Commands: []string{"git push -u origin " + report.Branch},
+		})
+	case up == nil:
+	case up.Diverged():
+		summary := fmt.Sprintf("%s and %s have diverged (%d local, %d remote commits); replay your commits on top of the remote ones.", report.Branch, up.Ref, up.Ahead, up.Behind)
+		if len(up.Conflicts) > 0 {
+			summary += " Expect conflicts in: " + strings.Join(up.Conflicts, ", ") + "."
+		}
+		advice = append(advice, SyncAdvice{Summary: summary, Commands: []string{"git pull --rebase"}})
+	case up.Behind > 0:
+		advice = append(advice, SyncAdvice{
+			Summary:  fmt.Sprintf("%s is %d commit(s) behind %s and can be fast-forwarded.", report.Branch, up.Behind, up.Ref),
+			Commands: []string{"git pull --ff-only"},
+		})
+	case up.Ahead > 0:
+		advice = append(advice, SyncAdvice{
+			Summary:  fmt.Sprintf("%s has %d unpushed commit(s).", report.Branch, up.Ahead),
+			Commands: []string{"git push"},
+		})
+	}
+
+	if base := report.DefaultBranch; base != nil && base.Behind > 0 {
+		advice = append(advice, recommendDefaultBranchSync(report, base))
+	}
+
+	if len(advice) == 0 {
+		advice = append(advice, SyncAdvice{Summary: "Branch is up to date; nothing to do."})
+	}
+	return advice
+}
+
+// recommendDefaultBranchSync picks between fast-forward, rebase and merge to
+// pick up the commits of the default branch
+func recommendDefaultBranchSync(report *SyncReport, base *SyncTarget) SyncAdvice {
+	if base.Ahead == 0 {
+		return SyncAdvice{
+			Summary:  fmt.Sprintf("%s is %d commit(s) behind %s and can be fast-forwarded.", report.Branch, base.Behind, base.Ref),
+			Commands: []string{"git merge --ff-only " + base.Ref},
+		}
+	}
+
+	conflicts := ""
+	if len(base.Conflicts) > 0 {
+		conflicts = fmt.Sprintf(" Expect conflicts in %d file(s): %s.", len(base.Conflicts), strings.Join(base.Conflicts, ", "))
+	} else if base.ConflictsKnown {
+		conflicts = " No conflicts are expected."
+	}
+
+	published := report.Upstream != nil
+	switch {
+	case published:
+		return SyncAdvice{
+			Summary: fmt.Sprintf("%s is %d commit(s) behind %s. The branch is published, so merge instead of rewriting shared history.%s",
+				report.Branch, base.Behind, base.Ref, conflicts),
+			Commands: []string{"git merge " + base.Ref, "git push"},
+		}
+	case len(base.Conflicts) > 0 && base.Ahead > rebaseCommitLimit:
+		return SyncAdvice{
+			Summary: fmt.Sprintf("%s is %d commit(s) behind %s. With %d local commits, merge to resolve conflicts once instead of per commit.%s",
+				report.Branch, base.Behind, base.Ref, base.Ahead, conflicts),
+			Commands: []string{"git merge " + base.Ref},
+		}
+	default:
+		return SyncAdvice{
+			Summary: fmt.Sprintf("%s is %d commit(s) behind %s. The branch is not published yet, so rebase for a linear history.%s",
+				report.Branch, base.Behind, base.Ref, conflicts),
+			Commands: []string{"git rebase " + base.Ref},
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendSync(t *testing.T) {
+	t.Run("up to date", func(t *testing.T) {
+		advice := RecommendSync(&SyncReport{
+			Branch:        "feature",
+			Upstream:      &SyncTarget{Ref: "origin/feature"},
+			DefaultBranch: &SyncTarget{Ref: "origin/main", Ahead: 3},
+		})
+		require.Len(t, advice, 1)
+		assert.Empty(t, advice[0].Commands)
+	})
+
+	t.Run("unpublished branch behind default is rebased", func(t *testing.T) {
+		advice := RecommendSync(&SyncReport{
+			Branch:        "feature",
+			DefaultBranch: &SyncTarget{Ref: "origin/main", Ahead: 2, Behind: 5, ConflictsKnown: true},
+		})
+		require.Len(t, advice, 2)
+		assert.Equal(t, []string{"git push -u origin feature"}, advice[0].Commands)
+		assert.Equal(t, []string{"git rebase origin/main"}, advice[1].Commands)
+		assert.Contains(t, advice[1].Summary, "No conflicts are expected")
+	})
+
+	t.Run("published branch is merged", func(t *testing.T) {
+		advice := RecommendSync(&SyncReport{
+			Branch:        "feature",
+			Upstream:      &SyncTarget{Ref: "origin/feature"},
+			DefaultBranch: &SyncTarget{Ref: "origin/main", Ahead: 2, Behind: 5, Conflicts: []string{"go.mod"}, ConflictsKnown: true},
+		})
+		require.Len(t, advice, 1)
+		assert.Equal(t, []string{"git merge origin/main", "git push"}, advice[0].Commands)
+		assert.Contains(t, advice[0].Summary, "go.mod")
+	})
+
+	t.Run("many conflicting local commits are merged", func(t *testing.T) {
+		advice := RecommendSync(&SyncReport{
+			Branch:        "feature",
+			DefaultBranch: &SyncTarget{Ref: "main", Ahead: 20, Behind: 1, Conflicts: []string{"a.go"}, ConflictsKnown: true},
+		})
+		assert.Equal(t, []string{"git merge main"}, advice[len(advice)-1].Commands)
+	})
+
+	t.Run("upstream states", func(t *testing.T) {
+		cases := map[string]struct {
+			target SyncTarget
+			want   []string
+		}{
+			"behind":   {SyncTarget{Ref: "origin/feature", Behind: 2}, []string{"git pull --ff-only"}},
+			"ahead":    {SyncTarget{Ref: "origin/feature", Ahead: 2}, []string{"git push"}},
+			"diverged": {SyncTarget{Ref: "origin/feature", Ahead: 1, Behind: 1}, []string{"git pull --rebase"}},
+		}
+		for name, tc := range cases {
+			target := tc.target
+			advice := RecommendSync(&SyncReport{Branch: "feature", Upstream: &target})
+			require.Len(t, advice, 1, name)
+			assert.Equal(t, tc.want, advice[0].Commands, name)
+		}
+	})
+
+	t.Run("detached HEAD is not published", func(t *testing.T) {
+		advice := RecommendSync(&SyncReport{Branch: "HEAD"})
+		require.Len(t, advice, 1)
+		assert.Empty(t, advice[0].Commands)
+	})
+}
@@ -0,0 +1,645 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/backup"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// DefaultTestGenIterations is the default iteration limit of the test generation agent
+const DefaultTestGenIterations = 25
+
+// previewMaxLines caps the lines shown in the preview of a file change
+const previewMaxLines = 80
+
+// TestGenRequest contains the input for generating tests
+type TestGenRequest struct {
+	Target        string        // File to write tests for, relative to WorkDir; the staged changes when empty
+	Context       string        // Additional context from user
+	Language      string        // Output language
+	WorkDir       string        // Working directory
+	RunTests      bool          // Let the agent run the tests with run_command and fix failures
+	MaxIterations int           // Maximum number of agent iterations
+	SessionID     string        // Session whose snapshot receives files before they are edited
+	MaxDuration   time.Duration // Wall-clock budget for the run (0 = unlimited)
+}
+
+// TestGenResponse contains the result of test generation
+type TestGenResponse struct {
+	Summary          string
+	Files            []string // Test files written or edited
+	Rejected         int      // Changes declined in the preview
+	TestRuns         int      // Commands run with run_command
+	Partial          bool     // True if the agent stopped before submitting
+	SessionID        string   // Session to pass to `gitbuddy rollback` to undo the changes
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// SubmitTestsParams represents the final result reported by the LLM
+type SubmitTestsParams struct {
+	Files   []string `json:"files"`
+	Summary string   `json:"summary"`
+}
+
+// TestGenAgentOptions contains configuration for TestGenAgent
+type TestGenAgentOptions struct {
+	Language             string
+	GitExecutor          git.Executor
+	LLMProvider          llm.Provider
+	Printer              *ui.StreamPrinter
+	Input                io.Reader // Answers to the change previews
+	Output               io.Writer // Where change previews are shown
+	MaxLinesPerRead      int
+	RetryConfig          llm.RetryConfig
+	MaxRepeatedToolCalls int           // Identical consecutive tool calls tolerated before aborting (0 = default)
+	MaxDiffLines         int           // Staged diff lines above which git_diff_cached returns per-file statistics (0 = default, negative = no limit)
+	PromptExtension      string        // Project-specific guidance appended to the system prompt
+	AutoApprove          bool          // Apply changes without asking after the preview
+	TestCommands         []string      // Command prefixes run_command accepts (default: tools.DefaultTestCommands)
+	CommandTimeout       time.Duration // Timeout of a run_command call (default: tools.DefaultCommandTimeout)
+}
+
+// TestGenAgent writes tests for a file or the staged changes. Every change
+// is previewed and confirmed before it is written, and only test files can be
+// changed.
+type TestGenAgent struct {
+	opts TestGenAgentOptions
+}
+
+// NewTestGenAgent creates a new TestGenAgent
+func NewTestGenAgent(opts TestGenAgentOptions) *TestGenAgent {
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.MaxLinesPerRead <= 0 {
+		opts.MaxLinesPerRead = tools.DefaultMaxLinesPerRead
+	}
+	if opts.Input == nil {
+		opts.Input = os.Stdin
+	}
+	// Every change is confirmed on the same input
+	opts.Input = ui.NewLineReader(opts.Input)
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	return &TestGenAgent{opts: opts}
+}
+
+// BuildTestGenSystemPrompt builds the system prompt for test generation
+func BuildTestGenSystemPrompt(language, target, context string, runTests bool) string {
+	tmpl, err := template.New("testgen_prompt").Parse(TestGenSystemPrompt)
+	if err != nil {
+		return TestGenSystemPrompt
+	}
+
+	var buf bytes.Buffer
+	data := map[string]interface{}{
+		"Language": language,
+		"Target":   target,
+		"Context":  context,
+		"RunTests": runTests,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return TestGenSystemPrompt
+	}
+	return buf.String()
+}
+
+// testGenRun holds the state of one GenerateTests call
+type testGenRun struct {
+	agent    *TestGenAgent
+	workDir  string
+	session  string
+	backups  *backup.BackupManager
+	write    *tools.WriteFileTool
+	edit     *tools.EditFileTool
+	appendTo *tools.AppendFileTool
+	registry *tools.ToolRegistry
+
+	files    []string
+	rejected int
+	testRuns int
+}
+
+// newTestGenRun creates the tools of a run in workDir
+func (a *TestGenAgent) newTestGenRun(workDir, sessionID string, runTests bool) *testGenRun {
+	run := &testGenRun{
+		agent:    a,
+		workDir:  workDir,
+		session:  sessionID,
+		backups:  backup.NewBackupManager(workDir),
+		write:    tools.NewWriteFileTool(workDir),
+		edit:     tools.NewEditFileTool(workDir),
+		appendTo: tools.NewAppendFileTool(workDir),
+	}
+	changeFile := func(ctx context.Context, call schema.ToolCall) (string, error) {
+		return run.changeFile(ctx, call.Function.Name, call.Function.Arguments)
+	}
+
+	readFile := tools.NewReadFileTool(workDir, a.opts.MaxLinesPerRead)
+	outline := tools.NewFileOutlineTool(workDir)
+	grepFile := tools.NewGrepFileTool(workDir, tools.DefaultMaxFileSize)
+	grepDir := tools.NewGrepDirectoryTool(workDir, tools.DefaultMaxFileSize, tools.DefaultMaxResults, tools.DefaultGrepTimeout)
+	run.registry = tools.NewToolRegistry(
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "read_file",
+				Desc: readFile.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path":     {Type: schema.String, Desc: "Path to the file to read", Required: true},
+					"start_line":    {Type: schema.Integer, Desc: "Starting line number (1-indexed)", Required: false},
+					"end_line":      {Type: schema.Integer, Desc: "Ending line number (1-indexed, inclusive)", Required: false},
+					"around_symbol": {Type: schema.String, Desc: "Function, method, type or class to read instead of a line range", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ReadFileParams](readFile.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "file_outline",
+				Desc: outline.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the source file", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.FileOutlineParams](outline.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "list_files",
+				Desc: "Find files matching a glob pattern",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"pattern": {Type: schema.String, Desc: "Glob pattern, e.g. *_test.go", Required: true},
+					"path":    {Type: schema.String, Desc: "Directory to search in", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.ListFilesParams](tools.NewListFilesTool(workDir, tools.DefaultMaxFiles).Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "grep_file",
+				Desc: grepFile.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the file to search", Required: true},
+					"pattern":   {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.GrepFileParams](grepFile.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "grep_directory",
+				Desc: grepDir.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"directory":     {Type: schema.String, Desc: "Path to the directory to search", Required: true},
+					"pattern":       {Type: schema.String, Desc: "Regular expression pattern to search for", Required: true},
+					"patterns":      {Type: schema.Array, Desc: "More patterns; a line matching any pattern matches", Required: false},
+					"not_pattern":   {Type: schema.String, Desc: "Leave out lines that also match this pattern", Required: false},
+					"recursive":     {Type: schema.Boolean, Desc: "Search subdirectories recursively", Required: false},
+					"file_pattern":  {Type: schema.String, Desc: "Glob pattern to filter files (e.g., '*_test.go')", Required: false},
+					"exclude_paths": {Type: schema.Array, Desc: "Globs of files or directories to skip (e.g., ['*_test.go', 'testdata'])", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.GrepDirectoryParams](grepDir.Execute),
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "write_file",
+				Desc: "Create or overwrite a test file. The developer previews and confirms the change.",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the test file", Required: true},
+					"content":   {Type: schema.String, Desc: "File content", Required: true},
+				}),
+			},
+			Execute: changeFile,
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "edit_file",
+				Desc: "Replace, insert or delete lines in an existing test file. The developer previews and confirms the change.",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path":  {Type: schema.String, Desc: "Path to the test file", Required: true},
+					"operation":  {Type: schema.String, Desc: "One of: replace, insert, delete", Required: true},
+					"start_line": {Type: schema.Integer, Desc: "First line to edit (1-indexed)", Required: true},
+					"end_line":   {Type: schema.Integer, Desc: "Last line to replace or delete (1-indexed, inclusive)", Required: false},
+					"content":    {Type: schema.String, Desc: "New content for replace and insert", Required: false},
+				}),
+			},
+			Execute: changeFile,
+		},
+		tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "append_file",
+				Desc: "Append content to the end of a test file. The developer previews and confirms the change.",
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"file_path": {Type: schema.String, Desc: "Path to the test file", Required: true},
+					"content":   {Type: schema.String, Desc: "Content to append", Required: true},
+				}),
+			},
+			Execute: changeFile,
+		},
+	)
+
+	if a.opts.GitExecutor != nil {
+		diff := tools.NewGitDiffCachedTool(a.opts.GitExecutor, diffLineLimit(a.opts.LLMProvider, a.opts.MaxDiffLines))
+		run.registry.Register(tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "git_diff_cached",
+				Desc: diff.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"path": {Type: schema.String, Desc: "Show only the diff of this file or directory (optional)", Required: false},
+				}),
+			},
+			Execute: tools.Bind[tools.GitDiffCachedParams](diff.Execute),
+		})
+	}
+	if runTests {
+		command := tools.NewRunCommandTool(workDir, a.opts.TestCommands, a.opts.CommandTimeout)
+		run.registry.Register(tools.Tool{
+			Info: &schema.ToolInfo{
+				Name: "run_command",
+				Desc: command.Description(),
+				ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+					"command": {Type: schema.String, Desc: "Test command to run, e.g. go test ./pkg/ -run TestName", Required: true},
+				}),
+			},
+			Execute: tools.Bind[tools.RunCommandParams](func(ctx context.Context, params *tools.RunCommandParams) (string, error) {
+				if printer := a.opts.Printer; printer != nil {
+					_ = printer.PrintInfo("$ " + params.Command)
+				}
+				run.testRuns++
+				return command.Execute(ctx, params)
+			}),
+		})
+	}
+	return run
+}
+
+// GenerateTests runs the agent until it submits the tests it wrote
+func (a *TestGenAgent) GenerateTests(ctx context.Context, req TestGenRequest) (*TestGenResponse, error) {
+	printer := a.opts.Printer
+
+	printProgress := func(msg string) {
+		if printer != nil {
+			_ = printer.PrintProgress(msg)
+		}
+		log.Debug(msg)
+	}
+
+	if a.opts.LLMProvider == nil {
+		return nil, fmt.Errorf("LLM provider is not configured")
+	}
+	if req.Target == "" && a.opts.GitExecutor == nil {
+		return nil, fmt.Errorf("a target file or a git executor for the staged changes is required")
+	}
+	language := req.Language
+	if language == "" {
+		language = a.opts.Language
+	}
+	maxIterations := req.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultTestGenIterations
+	}
+
+	providerName := a.opts.LLMProvider.Name()
+	printProgress(fmt.Sprintf("Initializing LLM provider (%s/%s)...", providerName, a.opts.LLMProvider.GetConfig().Model))
+	chatModel, err := a.opts.LLMProvider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat model: %w", err)
+	}
+	if chatModel == nil {
+		return nil, fmt.Errorf("chat model is nil (provider: %s)", providerName)
+	}
+
+	run := a.newTestGenRun(req.WorkDir, req.SessionID, req.RunTests)
+	if err := chatModel.BindTools(run.toolInfos()); err != nil {
+		return nil, fmt.Errorf("failed to bind tools: %w", err)
+	}
+
+	runner := NewAgentRunner(AgentRunnerOptions{
+		ChatModel:            chatModel,
+		RetryConfig:          a.opts.RetryConfig,
+		Printer:              printer,
+		MaxRepeatedToolCalls: a.opts.MaxRepeatedToolCalls,
+		UncachedTools:        fileChangeTools,
+	})
+	defer runner.PrintDiagnostics()
+	for _, name := range fileChangeTools {
+		run.registry.Wrap(name, func(next tools.Handler) tools.Handler {
+			return func(ctx context.Context, call schema.ToolCall) (string, error) {
+				result, err := next(ctx, call)
+				if err == nil {
+					// Files changed, so earlier reads and test runs are outdated
+					runner.Invalidate("read_file", "run_command")
+				}
+				return result, err
+			}
+		})
+	}
+	runner.RegisterTools(run.registry)
+
+	userMessage := "Write tests for the staged changes."
+	if req.Target != "" {
+		userMessage = fmt.Sprintf("Write tests for %s.", req.Target)
+	}
+	messages := []*schema.Message{
+		{Role: schema.System, Content: ExtendSystemPrompt(BuildTestGenSystemPrompt(language, req.Target, req.Context, req.RunTests), a.opts.PromptExtension)},
+		{Role: schema.User, Content: userMessage},
+	}
+
+	response := &TestGenResponse{SessionID: req.SessionID}
+	finish := func(summary string, partial bool) *TestGenResponse {
+		response.Summary = summary
+		response.Partial = partial
+		response.Files = run.files
+		response.Rejected = run.rejected
+		response.TestRuns = run.testRuns
+		return response
+	}
+
+	deadline := NewDeadline(req.MaxDuration)
+	for i := 0; i < maxIterations; i++ {
+		if ctx.Err() != nil {
+			return finish(lastAssistantContent(messages), true), ctx.Err()
+		}
+		if deadline.Exceeded() {
+			printProgress(fmt.Sprintf("Stopping before the tests were submitted: %v", deadline.Err()))
+			return finish(lastAssistantContent(messages), true), nil
+		}
+		if deadline.WrapUp() {
+			printProgress("Time budget nearly used up, asking the agent to submit")
+			messages = append(messages, &schema.Message{Role: schema.User, Content: deadline.WrapUpMessage("submit_tests")})
+		}
+		printProgress(fmt.Sprintf("Agent iteration %d...", i+1))
+		emitProgress(printer, ui.ProgressEvent{Event: ui.EventIteration, Iteration: i + 1, MaxIterations: maxIterations})
+
+		var result *StreamResult
+		messages, result, err = runner.Stream(ctx, messages)
+		if err != nil {
+			return nil, err
+		}
+		usage := runner.Usage()
+		response.PromptTokens, response.CompletionTokens, response.TotalTokens = usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens
+		assistantMsg := result.Message
+		if assistantMsg.Content == "" && len(assistantMsg.ToolCalls) == 0 {
+			break
+		}
+
+		// Without tool calls the agent is done, and its reply is the summary
+		if len(assistantMsg.ToolCalls) == 0 {
+			return finish(assistantMsg.Content, false), nil
+		}
+
+		for _, tc := range assistantMsg.ToolCalls {
+			if tc.Function.Name == "submit_tests" {
+				var params SubmitTestsParams
+				if err := unmarshalToolArgs(tc.Function.Arguments, &params); err != nil {
+					log.Debug("Failed to parse submit_tests arguments: %v", err)
+				}
+				if printer != nil {
+					_ = printer.PrintSuccess("Test generation completed")
+				}
+				return finish(params.Summary, false), nil
+			}
+
+			reply, err := runner.Execute(ctx, tc)
+			if err != nil {
+				return finish(lastAssistantContent(messages), true), nil
+			}
+			messages = append(messages, reply)
+		}
+	}
+
+	printProgress("Reached the iteration limit before the tests were submitted")
+	return finish(lastAssistantContent(messages), true), nil
+}
+
+// execute dispatches a tool call, explaining why the tools left out of
+// this run are missing
+func (r *testGenRun) execute(ctx context.Context, tc schema.ToolCall) (string, error) {
+	switch name := tc.Function.Name; {
+	case r.registry.Has(name):
+		return r.registry.Execute(ctx, tc)
+	case name == "git_diff_cached":
+		return "", fmt.Errorf("git_diff_cached is not available")
+	case name == "run_command":
+		return "", fmt.Errorf("run_command is not available; tests can't be run in this session")
+	}
+	return r.registry.Execute(ctx, tc)
+}
+
+// toolInfos describes the tools available to the agent
+func (r *testGenRun) toolInfos() []*schema.ToolInfo {
+	return append(r.registry.Infos(), &schema.ToolInfo{
+		Name: "submit_tests",
+		Desc: "Finish by reporting the test files you changed and what the tests cover.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"files":   {Type: schema.Array, ElemInfo: &schema.ParameterInfo{Type: schema.String}, Desc: "Test files written or edited", Required: true},
+			"summary": {Type: schema.String, Desc: "The cases covered, and whether the tests pass", Required: true},
+		}),
+	})
+}
+
+// changeFile previews a write_file, edit_file or append_file call and applies
+// it once confirmed. Declined changes are reported to the model, not as errors.
+func (r *testGenRun) changeFile(ctx context.Context, name, args string) (string, error) {
+	var write tools.WriteFileParams
+	var edit tools.EditFileParams
+	var appendTo tools.AppendFileParams
+	var filePath string
+	switch name {
+	case "write_file":
+		if err := unmarshalToolArgs(args, &write); err != nil {
+			return "", err
+		}
+		filePath = write.FilePath
+	case "edit_file":
+		if err := unmarshalToolArgs(args, &edit); err != nil {
+			return "", err
+		}
+		filePath = edit.FilePath
+	case "append_file":
+		if err := unmarshalToolArgs(args, &appendTo); err != nil {
+			return "", err
+		}
+		filePath = appendTo.FilePath
+	}
+	if filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	if !IsTestFile(filePath) {
+		return "", fmt.Errorf("%s is not a test file; only test files can be changed", filePath)
+	}
+
+	opts := r.agent.opts
+	fmt.Fprintln(opts.Output)
+	fmt.Fprintln(opts.Output, PreviewFileChange(r.workDir, name, args))
+	if !opts.AutoApprove {
+		ok, err := ui.ConfirmWithDefault("Apply this change?", true, opts.Input, opts.Output)
+		if err != nil || !ok {
+			r.rejected++
+			return fmt.Sprintf("The developer rejected this change to %s. It was not written. Don't retry it unchanged; adjust it or continue without it.", filePath), nil
+		}
+	}
+
+	if r.session != "" {
+		if err := r.backups.SnapshotFile(ctx, r.session, filePath); err != nil {
+			return "", fmt.Errorf("failed to snapshot %s: %w", filePath, err)
+		}
+	}
+
+	var result string
+	var err error
+	switch name {
+	case "write_file":
+		result, err = r.write.Execute(ctx, &write)
+	case "edit_file":
+		result, err = r.edit.Execute(ctx, &edit)
+	case "append_file":
+		result, err = r.appendTo.Execute(ctx, &appendTo)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.recordFile(filePath)
+	return result, nil
+}
+
+// recordFile adds a changed file to the result once
+func (r *testGenRun) recordFile(filePath string) {
+	filePath = filepath.ToSlash(filepath.Clean(filePath))
+	for _, f := range r.files {
+		if f == filePath {
+			return
+		}
+	}
+	r.files = append(r.files, filePath)
+}
+
+// fileChangeTools are the tools writing files
+var fileChangeTools = []string{"write_file", "edit_file", "append_file"}
+
+// IsTestFile reports whether p looks like a test file by the naming
+// conventions of common languages and test frameworks
+func IsTestFile(p string) bool {
+	p = filepath.ToSlash(filepath.Clean(p))
+	base := path.Base(p)
+	ext := path.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		ext == ".py" && (strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test") || name == "conftest"),
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec."),
+		(ext == ".java" || ext == ".kt") && (strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests")),
+		ext == ".rb" && strings.HasSuffix(name, "_spec"):
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if dir == "test" || dir == "tests" || dir == "__tests__" || dir == "testdata" || dir == "spec" {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewFileChange renders a write_file, edit_file or append_file call as
+// the lines it removes (-) and adds (+)
+func PreviewFileChange(workDir, name, args string) string {
+	var b strings.Builder
+	var removed, added []string
+
+	switch name {
+	case "write_file":
+		var params tools.WriteFileParams
+		_ = unmarshalToolArgs(args, &params)
+		existing, err := readLines(workDir, params.FilePath)
+		added = splitContentLines(params.Content)
+		if err != nil {
+			fmt.Fprintf(&b, "Create %s (%d lines)", params.FilePath, len(added))
+		} else {
+			fmt.Fprintf(&b, "Overwrite %s (%d -> %d lines)", params.FilePath, len(existing), len(added))
+			removed = existing
+		}
+
+	case "edit_file":
+		var params tools.EditFileParams
+		_ = unmarshalToolArgs(args, &params)
+		end := params.EndLine
+		if end < params.StartLine {
+			end = params.StartLine
+		}
+		existing, _ := readLines(workDir, params.FilePath)
+		if params.Operation != "insert" && params.StartLine >= 1 && params.StartLine <= len(existing) {
+			removed = existing[params.StartLine-1 : min(end, len(existing))]
+		}
+		if params.Operation != "delete" {
+			added = splitContentLines(params.Content)
+		}
+		switch params.Operation {
+		case "insert":
+			fmt.Fprintf(&b, "Edit %s (insert at line %d)", params.FilePath, params.StartLine)
+		default:
+			fmt.Fprintf(&b, "Edit %s (%s lines %d-%d)", params.FilePath, params.Operation, params.StartLine, end)
+		}
+
+	case "append_file":
+		var params tools.AppendFileParams
+		_ = unmarshalToolArgs(args, &params)
+		added = splitContentLines(params.Content)
+		fmt.Fprintf(&b, "Append to %s (%d lines)", params.FilePath, len(added))
+	}
+
+	shown := 0
+	for _, group := range []struct {
+		prefix string
+		lines  []string
+	}{{"- ", removed}, {"+ ", added}} {
+		for _, line := range group.lines {
+			if shown == previewMaxLines {
+				fmt.Fprintf(&b, "\n  ... %d more lines", len(removed)+len(added)-shown)
+				return b.String()
+			}
+			b.WriteString("\n" + group.prefix + line)
+			shown++
+		}
+	}
+	return b.String()
+}
+
+// readLines reads a file below workDir as lines
+func readLines(workDir, filePath string) ([]string, error) {
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(workDir, filePath)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return splitContentLines(string(data)), nil
+}
+
+// splitContentLines splits content into lines without a trailing empty line
+func splitContentLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/backup"
+)
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"internal/parser/parser_test.go", true},
+		{"internal/parser/parser.go", false},
+		{"tests/test_api.py", true},
+		{"app/api_test.py", true},
+		{"app/conftest.py", true},
+		{"app/api.py", false},
+		{"src/button.test.tsx", true},
+		{"src/button.spec.js", true},
+		{"src/button.tsx", false},
+		{"src/__tests__/button.tsx", true},
+		{"src/test/java/com/acme/ParserTest.java", true},
+		{"src/main/java/com/acme/Parser.java", false},
+		{"spec/models/user_spec.rb", true},
+		{"internal/parser/testdata/input.json", true},
+		{"latest/main.go", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTestFile(tt.path))
+		})
+	}
+}
+
+func TestPreviewFileChange(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a_test.go"), []byte("package a\n\nfunc TestA(t *testing.T) {}\n"), 0644))
+
+	args := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "Create b_test.go (2 lines)\n+ package b\n+ ",
+		PreviewFileChange(dir, "write_file", args(map[string]string{"file_path": "b_test.go", "content": "package b\n\n"})))
+	assert.Equal(t, "Overwrite a_test.go (3 -> 1 lines)\n- package a\n- \n- func TestA(t *testing.T) {}\n+ package a",
+		PreviewFileChange(dir, "write_file", args(map[string]string{"file_path": "a_test.go", "content": "package a\n"})))
+	assert.Equal(t, "Edit a_test.go (replace lines 3-3)\n- func TestA(t *testing.T) {}\n+ func TestB(t *testing.T) {}",
+		PreviewFileChange(dir, "edit_file", args(map[string]interface{}{"file_path": "a_test.go", "operation": "replace", "start_line": 3, "content": "func TestB(t *testing.T) {}"})))
+	assert.Equal(t, "Edit a_test.go (insert at line 2)\n+ import \"testing\"",
+		PreviewFileChange(dir, "edit_file", args(map[string]interface{}{"file_path": "a_test.go", "operation": "insert", "start_line": 2, "content": "import \"testing\""})))
+	assert.Equal(t, "Edit a_test.go (delete lines 1-2)\n- package a\n- ",
+		PreviewFileChange(dir, "edit_file", args(map[string]interface{}{"file_path": "a_test.go", "operation": "delete", "start_line": 1, "end_line": 2})))
+	assert.Equal(t, "Append to a_test.go (1 lines)\n+ // end",
+		PreviewFileChange(dir, "append_file", args(map[string]string{"file_path": "a_test.go", "content": "// end\n"})))
+
+	long := PreviewFileChange(dir, "write_file", args(map[string]string{"file_path": "c_test.go", "content": strings.Repeat("x\n", previewMaxLines+5)}))
+	assert.Equal(t, previewMaxLines+2, strings.Count(long, "\n")+1)
+	assert.True(t, strings.HasSuffix(long, "... 5 more lines"))
+}
+
+func TestTestGenRun_ChangeFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var output bytes.Buffer
+	agent := NewTestGenAgent(TestGenAgentOptions{Input: strings.NewReader("n\ny\n"), Output: &output})
+	run := agent.newTestGenRun(dir, "gen-tests-20240101-120000-abcdef", false)
+
+	call := func(name, args string) schema.ToolCall {
+		return schema.ToolCall{Function: schema.FunctionCall{Name: name, Arguments: args}}
+	}
+
+	// Only test files can be written
+	_, err := run.execute(ctx, call("write_file", `{"file_path":"main.go","content":"package main\n"}`))
+	assert.ErrorContains(t, err, "not a test file")
+
+	// Declined changes are not written
+	result, err := run.execute(ctx, call("write_file", `{"file_path":"main_test.go","content":"package main\n"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "rejected")
+	assert.NoFileExists(t, filepath.Join(dir, "main_test.go"))
+	assert.Contains(t, output.String(), "Create main_test.go (1 lines)\n+ package main")
+	assert.Contains(t, output.String(), "Apply this change? [Y/n]")
+
+	result, err = run.execute(ctx, call("write_file", `{"file_path":"main_test.go","content":"package main\n"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "successfully written")
+	assert.FileExists(t, filepath.Join(dir, "main_test.go"))
+
+	// Without more input the change is declined
+	result, err = run.execute(ctx, call("append_file", `{"file_path":"main_test.go","content":"// more\n"}`))
+	require.NoError(t, err)
+	assert.Contains(t, result, "rejected")
+
+	assert.Equal(t, []string{"main_test.go"}, run.files)
+	assert.Equal(t, 2, run.rejected)
+
+	// The new file is in the session snapshot for `gitbuddy rollback`
+	snapshot, err := backup.NewBackupManager(dir).LoadSnapshot("gen-tests-20240101-120000-abcdef")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Files, 1)
+	assert.Equal(t, "main_test.go", snapshot.Files[0].Path)
+
+	_, err = run.execute(ctx, call("run_command", `{"command":"go test ./..."}`))
+	assert.ErrorContains(t, err, "not available")
+}
+
+func TestTestGenRun_AutoApprove(t *testing.T) {
+	dir := t.TempDir()
+	var output bytes.Buffer
+	agent := NewTestGenAgent(TestGenAgentOptions{Input: strings.NewReader(""), Output: &output, AutoApprove: true})
+	run := agent.newTestGenRun(dir, "", true)
+
+	result, err := run.execute(context.Background(), schema.ToolCall{Function: schema.FunctionCall{Name: "write_file", Arguments: `{"file_path":"tests/test_api.py","content":"def test_ok():\n    assert True\n"}`}})
+	require.NoError(t, err)
+	assert.Contains(t, result, "successfully written")
+	assert.Contains(t, output.String(), "+ def test_ok():")
+	assert.NotContains(t, output.String(), "Apply this change?")
+	assert.Equal(t, []string{"tests/test_api.py"}, run.files)
+
+	var names []string
+	for _, info := range run.toolInfos() {
+		names = append(names, info.Name)
+	}
+	assert.Contains(t, names, "run_command")
+	assert.NotContains(t, names, "git_diff_cached")
+}
+
+func TestBuildTestGenSystemPrompt(t *testing.T) {
+	prompt := BuildTestGenSystemPrompt("en", "internal/parser/parser.go", "Parser handles RFC 3339 dates", true)
+	assert.Contains(t, prompt, "Write tests for `internal/parser/parser.go`")
+	assert.Contains(t, prompt, "run_command")
+	assert.Contains(t, prompt, "Parser handles RFC 3339 dates")
+
+	prompt = BuildTestGenSystemPrompt("en", "", "", false)
+	assert.Contains(t, prompt, "Call git_diff_cached first")
+	assert.Contains(t, prompt, "can't be run in this session")
+	assert.NotContains(t, prompt, "Additional Context")
+}
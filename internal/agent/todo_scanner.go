@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// DefaultTodoStaleAfter is the age from which a TODO comment counts as stale
+const DefaultTodoStaleAfter = 180 * 24 * time.Hour
+
+// TODO comment priorities
+const (
+	TodoHigh   = "high"
+	TodoMedium = "medium"
+	TodoLow    = "low"
+)
+
+// todoMarkerPattern matches a TODO, FIXME, HACK or XXX marker in a comment,
+// with an optional owner such as TODO(alice)
+var todoMarkerPattern = regexp.MustCompile(`(?://|#|/\*|^\s*\*|--|;|<!--).*?\b(TODO|FIXME|HACK|XXX)\b(?:\(([^)]*)\))?:?\s*(.*)$`)
+
+// todoWeights ranks the markers by urgency
+var todoWeights = map[string]int{"FIXME": 3, "HACK": 2, "XXX": 2, "TODO": 1}
+
+// TodoItem is a TODO comment with the commit that introduced it
+type TodoItem struct {
+	File      string        `json:"file"`
+	Line      int           `json:"line"`
+	Kind      string        `json:"kind"`            // TODO, FIXME, HACK or XXX
+	Owner     string        `json:"owner,omitempty"` // Name in TODO(name)
+	Text      string        `json:"text"`
+	Author    string        `json:"author,omitempty"`
+	Email     string        `json:"email,omitempty"`
+	Date      time.Time     `json:"date,omitempty"`
+	Committed bool          `json:"committed"`
+	Age       time.Duration `json:"-"`
+	AgeDays   int           `json:"age_days"`
+	Stale     bool          `json:"stale"`
+	Priority  string        `json:"priority"`
+}
+
+// TodoScanOptions configures ScanTodos
+type TodoScanOptions struct {
+	Paths      []string      // Pathspecs to scan, all tracked files when empty
+	StaleAfter time.Duration // DefaultTodoStaleAfter when <= 0
+	Now        time.Time     // Reference time for ages, time.Now() when zero
+}
+
+// TodoReport lists the TODO comments of a repository, most urgent first
+type TodoReport struct {
+	Items      []TodoItem    `json:"items"`
+	StaleAfter time.Duration `json:"-"`
+	StaleDays  int           `json:"stale_days"`
+}
+
+// Count returns the number of items with priority
+func (r *TodoReport) Count(priority string) int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Priority == priority {
+			n++
+		}
+	}
+	return n
+}
+
+// ScanTodos finds the TODO, FIXME, HACK and XXX comments in the tracked files
+// below workDir and prioritizes them by marker and by the age git blame reports
+func ScanTodos(ctx context.Context, workDir string, opts TodoScanOptions) (*TodoReport, error) {
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = DefaultTodoStaleAfter
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	matches, err := git.Grep(ctx, workDir, "TODO|FIXME|HACK|XXX", opts.Paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TodoReport{StaleAfter: opts.StaleAfter, StaleDays: int(opts.StaleAfter / (24 * time.Hour))}
+	blames := make(map[string]map[int]git.BlameLine)
+	for _, match := range matches {
+		m := todoMarkerPattern.FindStringSubmatch(match.Text)
+		if m == nil {
+			continue
+		}
+		item := TodoItem{
+			File:  match.Path,
+			Line:  match.Line,
+			Kind:  m[1],
+			Owner: strings.TrimSpace(m[2]),
+			Text:  cleanTodoText(m[3]),
+		}
+
+		blame, ok := blames[match.Path]
+		if !ok {
+			if blame, err = git.Blame(ctx, workDir, match.Path); err != nil {
+				log.Debug("Failed to blame %s: %v", match.Path, err)
+			}
+			blames[match.Path] = blame
+		}
+		if line, ok := blame[match.Line]; ok && line.Committed() {
+			item.Committed = true
+			item.Author = line.Author
+			item.Email = line.AuthorMail
+			item.Date = line.AuthorTime
+			item.Age = opts.Now.Sub(line.AuthorTime)
+			item.AgeDays = int(item.Age / (24 * time.Hour))
+		}
+
+		item.Stale = item.Committed && item.Age >= opts.StaleAfter
+		item.Priority = todoPriority(item, opts.StaleAfter)
+		report.Items = append(report.Items, item)
+	}
+
+	rank := map[string]int{TodoHigh: 0, TodoMedium: 1, TodoLow: 2}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if rank[a.Priority] != rank[b.Priority] {
+			return rank[a.Priority] < rank[b.Priority]
+		}
+		if a.Age != b.Age {
+			return a.Age > b.Age
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+// todoPriority ranks stale FIXME and HACK comments highest, followed by other
+// stale comments and urgent markers. Anything twice as old as the stale age is
+// high priority regardless of its marker.
+func todoPriority(item TodoItem, staleAfter time.Duration) string {
+	urgent := todoWeights[item.Kind] >= 2
+	switch {
+	case item.Stale && (urgent || item.Age >= 2*staleAfter):
+		return TodoHigh
+	case item.Stale || urgent:
+		return TodoMedium
+	default:
+		return TodoLow
+	}
+}
+
+// cleanTodoText removes comment terminators from the text after a marker
+func cleanTodoText(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(text, "*/")
+	text = strings.TrimSuffix(text, "-->")
+	return strings.TrimSpace(text)
+}
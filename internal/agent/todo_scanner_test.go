@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanTodos(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	commitAt := func(date time.Time, file, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+		runGit(t, dir, "add", file)
+		cmd := exec.Command("git", "commit", "--quiet", "-m", "Update "+file)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date.Format(time.RFC3339), "GIT_COMMITTER_DATE="+date.Format(time.RFC3339))
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	commitAt(now.AddDate(-1, 0, 0), "old.go", "package main\n\n// FIXME(alice): leaks connections\n// TODO: rename\nvar TODOs = 1\n")
+	commitAt(now.AddDate(0, 0, -10), "new.py", "# HACK: work around upstream bug\n# TODO add tests\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.py"), []byte("# HACK: work around upstream bug\n# TODO add tests\n/* XXX: local */\n"), 0644))
+
+	report, err := ScanTodos(ctx, dir, TodoScanOptions{Now: now})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 5, "identifiers containing TODO are not comments")
+	assert.Equal(t, 180, report.StaleDays)
+
+	fixme := report.Items[0]
+	assert.Equal(t, "old.go", fixme.File)
+	assert.Equal(t, 3, fixme.Line)
+	assert.Equal(t, "FIXME", fixme.Kind)
+	assert.Equal(t, "alice", fixme.Owner)
+	assert.Equal(t, "leaks connections", fixme.Text)
+	assert.Equal(t, "Test User", fixme.Author)
+	assert.True(t, fixme.Stale)
+	assert.Equal(t, TodoHigh, fixme.Priority)
+
+	// A TODO twice as old as the stale age is high priority too
+	assert.Equal(t, "rename", report.Items[1].Text)
+	assert.Equal(t, TodoHigh, report.Items[1].Priority)
+
+	assert.Equal(t, "HACK", report.Items[2].Kind)
+	assert.Equal(t, TodoMedium, report.Items[2].Priority)
+	assert.Equal(t, 10, report.Items[2].AgeDays)
+
+	assert.Equal(t, "XXX", report.Items[3].Kind)
+	assert.Equal(t, "local", report.Items[3].Text)
+	assert.False(t, report.Items[3].Committed)
+	assert.Equal(t, TodoMedium, report.Items[3].Priority)
+
+	assert.Equal(t, "add tests", report.Items[4].Text)
+	assert.Equal(t, TodoLow, report.Items[4].Priority)
+	assert.Equal(t, 2, report.Count(TodoHigh))
+
+	report, err = ScanTodos(ctx, dir, TodoScanOptions{Now: now, Paths: []string{"*.py"}, StaleAfter: 5 * 24 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 3)
+	assert.Equal(t, TodoHigh, report.Items[0].Priority, "stale HACK")
+	assert.Equal(t, TodoHigh, report.Items[1].Priority, "TODO twice as old as the stale age")
+}
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// TokenBreakdownMetadataKey is the session metadata key holding the token breakdown
+const TokenBreakdownMetadataKey = "token_breakdown"
+
+// ToolTokenStats holds the estimated token cost of a tool's results
+type ToolTokenStats struct {
+	Calls        int `json:"calls"`         // Number of results returned
+	ResultTokens int `json:"result_tokens"` // Estimated size of the results
+	PromptTokens int `json:"prompt_tokens"` // Estimated prompt tokens spent re-sending the results
+}
+
+// PhaseTokenStats holds provider-reported token usage for a phase
+type PhaseTokenStats struct {
+	LLMCalls         int `json:"llm_calls"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// TokenBreakdown attributes token usage to tools and phases. Provider usage is
+// only reported per LLM call, so tool shares are estimated from the size of
+// the tool results present in each prompt.
+type TokenBreakdown struct {
+	Tools        map[string]*ToolTokenStats  `json:"tools"`
+	Phases       map[string]*PhaseTokenStats `json:"phases"`
+	PhaseOrder   []string                    `json:"phase_order"`
+	PromptTokens int                         `json:"prompt_tokens"`
+
+	countedResults map[string]bool // tool call IDs already counted in Calls/ResultTokens
+}
+
+// NewTokenBreakdown creates an empty TokenBreakdown
+func NewTokenBreakdown() *TokenBreakdown {
+	return &TokenBreakdown{
+		Tools:          make(map[string]*ToolTokenStats),
+		Phases:         make(map[string]*PhaseTokenStats),
+		countedResults: make(map[string]bool),
+	}
+}
+
+// LoadTokenBreakdown restores a breakdown saved in session metadata, or
+// returns an empty one. Tool results in the restored messages are treated as
+// already counted.
+func LoadTokenBreakdown(metadata map[string]string, messages []*schema.Message) *TokenBreakdown {
+	data := metadata[TokenBreakdownMetadataKey]
+	if data == "" {
+		return NewTokenBreakdown()
+	}
+
+	b := NewTokenBreakdown()
+	if err := json.Unmarshal([]byte(data), b); err != nil {
+		log.Debug("Failed to restore token breakdown: %v", err)
+		return NewTokenBreakdown()
+	}
+	if b.Tools == nil {
+		b.Tools = make(map[string]*ToolTokenStats)
+	}
+	if b.Phases == nil {
+		b.Phases = make(map[string]*PhaseTokenStats)
+	}
+	for _, msg := range messages {
+		if msg.Role == schema.Tool && msg.ToolCallID != "" {
+			b.countedResults[msg.ToolCallID] = true
+		}
+	}
+	return b
+}
+
+// RecordLLMCall records one LLM call: the provider-reported usage is added to
+// the phase, and every tool result in the prompt is charged to its tool
+func (b *TokenBreakdown) RecordLLMCall(phase string, messages []*schema.Message, promptTokens, completionTokens int) {
+	if phase == "" {
+		phase = "default"
+	}
+	stats, ok := b.Phases[phase]
+	if !ok {
+		stats = &PhaseTokenStats{}
+		b.Phases[phase] = stats
+		b.PhaseOrder = append(b.PhaseOrder, phase)
+	}
+	stats.LLMCalls++
+	stats.PromptTokens += promptTokens
+	stats.CompletionTokens += completionTokens
+	b.PromptTokens += promptTokens
+
+	// Map tool call IDs to tool names from the assistant messages
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			toolNames[tc.ID] = tc.Function.Name
+		}
+	}
+
+	for _, msg := range messages {
+		if msg.Role != schema.Tool {
+			continue
+		}
+		name := toolNames[msg.ToolCallID]
+		if name == "" {
+			name = "unknown"
+		}
+		toolStats, ok := b.Tools[name]
+		if !ok {
+			toolStats = &ToolTokenStats{}
+			b.Tools[name] = toolStats
+		}
+
+		tokens := estimateTokenCount(msg.Content)
+		toolStats.PromptTokens += tokens
+		if msg.ToolCallID != "" && !b.countedResults[msg.ToolCallID] {
+			b.countedResults[msg.ToolCallID] = true
+			toolStats.Calls++
+			toolStats.ResultTokens += tokens
+		}
+	}
+}
+
+// StoreIn saves the breakdown as JSON under TokenBreakdownMetadataKey
+func (b *TokenBreakdown) StoreIn(metadata map[string]string) {
+	if metadata == nil {
+		return
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		log.Debug("Failed to marshal token breakdown: %v", err)
+		return
+	}
+	metadata[TokenBreakdownMetadataKey] = string(data)
+}
+
+// Table renders the breakdown as a plain-text table
+func (b *TokenBreakdown) Table() string {
+	if len(b.Phases) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if len(b.Tools) > 0 {
+		names := make([]string, 0, len(b.Tools))
+		for name := range b.Tools {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return b.Tools[names[i]].PromptTokens > b.Tools[names[j]].PromptTokens
+		})
+
+		sb.WriteString("Token usage by tool (estimated):\n")
+		sb.WriteString(fmt.Sprintf("  %-22s %6s %14s %14s %8s\n", "Tool", "Calls", "Result tokens", "Prompt tokens", "Share"))
+		for _, name := range names {
+			stats := b.Tools[name]
+			sb.WriteString(fmt.Sprintf("  %-22s %6d %14d %14d %7.1f%%\n",
+				name, stats.Calls, stats.ResultTokens, stats.PromptTokens, b.share(stats.PromptTokens)))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("Token usage by phase:\n")
+	sb.WriteString(fmt.Sprintf("  %-22s %6s %14s %14s %8s\n", "Phase", "Calls", "Prompt tokens", "Completion", "Share"))
+	for _, phase := range b.PhaseOrder {
+		stats := b.Phases[phase]
+		sb.WriteString(fmt.Sprintf("  %-22s %6d %14d %14d %7.1f%%\n",
+			phase, stats.LLMCalls, stats.PromptTokens, stats.CompletionTokens, b.share(stats.PromptTokens)))
+	}
+	return sb.String()
+}
+
+// share returns tokens as a percentage of all prompt tokens, capped at 100
+func (b *TokenBreakdown) share(tokens int) float64 {
+	if b.PromptTokens <= 0 {
+		return 0
+	}
+	return min(float64(tokens)*100/float64(b.PromptTokens), 100)
+}
+
+// printTokenBreakdown prints the breakdown table after an agent run
+func printTokenBreakdown(printer *ui.StreamPrinter, breakdown *TokenBreakdown) {
+	if printer == nil || breakdown == nil {
+		return
+	}
+	if table := breakdown.Table(); table != "" {
+		_ = printer.PrintInfo("Token usage breakdown\n" + table)
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolCallMessage(id, name string) *schema.Message {
+	return &schema.Message{
+		Role:      schema.Assistant,
+		ToolCalls: []schema.ToolCall{{ID: id, Function: schema.FunctionCall{Name: name}}},
+	}
+}
+
+func TestTokenBreakdown_RecordLLMCall(t *testing.T) {
+	b := NewTokenBreakdown()
+	grepResult := strings.Repeat("a", 400) // ~100 tokens
+	readResult := strings.Repeat("b", 40)  // ~10 tokens
+
+	messages := []*schema.Message{
+		{Role: schema.System, Content: "system"},
+		toolCallMessage("call-1", "grep_directory"),
+		{Role: schema.Tool, ToolCallID: "call-1", Content: grepResult},
+	}
+	b.RecordLLMCall("execution", messages, 200, 20)
+
+	messages = append(messages,
+		toolCallMessage("call-2", "read_file"),
+		&schema.Message{Role: schema.Tool, ToolCallID: "call-2", Content: readResult},
+	)
+	b.RecordLLMCall("reporting", messages, 300, 30)
+
+	grep := b.Tools["grep_directory"]
+	require.NotNil(t, grep)
+	assert.Equal(t, 1, grep.Calls, "re-sent results are only counted once")
+	assert.Equal(t, 100, grep.ResultTokens)
+	assert.Equal(t, 200, grep.PromptTokens, "results are charged on every call that includes them")
+
+	read := b.Tools["read_file"]
+	require.NotNil(t, read)
+	assert.Equal(t, 1, read.Calls)
+	assert.Equal(t, 10, read.PromptTokens)
+
+	assert.Equal(t, []string{"execution", "reporting"}, b.PhaseOrder)
+	assert.Equal(t, PhaseTokenStats{LLMCalls: 1, PromptTokens: 300, CompletionTokens: 30}, *b.Phases["reporting"])
+	assert.Equal(t, 500, b.PromptTokens)
+
+	table := b.Table()
+	assert.Contains(t, table, "grep_directory")
+	assert.Contains(t, table, "40.0%")
+	assert.Less(t, strings.Index(table, "grep_directory"), strings.Index(table, "read_file"), "tools are sorted by cost")
+	assert.Contains(t, table, "Token usage by phase")
+}
+
+func TestTokenBreakdown_StoreAndLoad(t *testing.T) {
+	messages := []*schema.Message{
+		toolCallMessage("call-1", "git_diff_cached"),
+		{Role: schema.Tool, ToolCallID: "call-1", Content: strings.Repeat("x", 80)},
+	}
+
+	b := NewTokenBreakdown()
+	b.RecordLLMCall("review", messages, 100, 10)
+
+	metadata := map[string]string{}
+	b.StoreIn(metadata)
+	require.Contains(t, metadata, TokenBreakdownMetadataKey)
+
+	restored := LoadTokenBreakdown(metadata, messages)
+	restored.RecordLLMCall("review", messages, 120, 10)
+	assert.Equal(t, 1, restored.Tools["git_diff_cached"].Calls, "restored results are not counted again")
+	assert.Equal(t, 2, restored.Phases["review"].LLMCalls)
+
+	assert.Empty(t, LoadTokenBreakdown(nil, nil).Table())
+	assert.Empty(t, LoadTokenBreakdown(map[string]string{TokenBreakdownMetadataKey: "{bad"}, nil).Tools)
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// DefaultToolFailureThreshold is the number of consecutive failures after which a tool is disabled
+const DefaultToolFailureThreshold = 3
+
+// toolFailureStats holds failure statistics for a single tool
+type toolFailureStats struct {
+	consecutive int
+	total       int
+	lastError   string
+	disabled    bool
+}
+
+// ToolFailureTracker tracks tool failures during an agent run and disables
+// tools that keep failing (e.g. git not installed, permission denied)
+type ToolFailureTracker struct {
+	threshold int
+	stats     map[string]*toolFailureStats
+	order     []string
+}
+
+// NewToolFailureTracker creates a new ToolFailureTracker
+func NewToolFailureTracker(threshold int) *ToolFailureTracker {
+	if threshold <= 0 {
+		threshold = DefaultToolFailureThreshold
+	}
+	return &ToolFailureTracker{
+		threshold: threshold,
+		stats:     make(map[string]*toolFailureStats),
+	}
+}
+
+func (t *ToolFailureTracker) get(name string) *toolFailureStats {
+	s, ok := t.stats[name]
+	if !ok {
+		s = &toolFailureStats{}
+		t.stats[name] = s
+		t.order = append(t.order, name)
+	}
+	return s
+}
+
+// IsDisabled reports whether a tool has been disabled
+func (t *ToolFailureTracker) IsDisabled(name string) bool {
+	s, ok := t.stats[name]
+	return ok && s.disabled
+}
+
+// RecordSuccess resets the consecutive failure count of a tool
+func (t *ToolFailureTracker) RecordSuccess(name string) {
+	if s, ok := t.stats[name]; ok {
+		s.consecutive = 0
+	}
+}
+
+// RecordFailure records a tool failure and returns true if the tool was disabled by this failure
+func (t *ToolFailureTracker) RecordFailure(name string, err error) bool {
+	s := t.get(name)
+	s.consecutive++
+	s.total++
+	if err != nil {
+		s.lastError = err.Error()
+	}
+	if !s.disabled && s.consecutive >= t.threshold {
+		s.disabled = true
+		return true
+	}
+	return false
+}
+
+// DisabledMessage returns the message sent to the LLM when it calls a disabled tool
+func (t *ToolFailureTracker) DisabledMessage(name string) string {
+	return fmt.Sprintf("Tool %s has been disabled after %d consecutive failures (last error: %s). Do not call it again; continue with the other available tools or work with the information you already have.",
+		name, t.threshold, t.get(name).lastError)
+}
+
+// HasFailures reports whether any tool failed during the run
+func (t *ToolFailureTracker) HasFailures() bool {
+	for _, s := range t.stats {
+		if s.total > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns a human-readable diagnostics summary of tool failures
+func (t *ToolFailureTracker) Summary() string {
+	if !t.HasFailures() {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Tool diagnostics:")
+	for _, name := range t.order {
+		s := t.stats[name]
+		if s.total == 0 {
+			continue
+		}
+		status := "recovered"
+		if s.disabled {
+			status = "disabled"
+		} else if s.consecutive > 0 {
+			status = "failing"
+		}
+		sb.WriteString(fmt.Sprintf("\n  - %s: %d failure(s), %s", name, s.total, status))
+		if s.lastError != "" {
+			sb.WriteString(fmt.Sprintf(" (last error: %s)", s.lastError))
+		}
+	}
+	return sb.String()
+}
+
+// printToolDiagnostics prints the tool failure summary, if any
+func printToolDiagnostics(printer *ui.StreamPrinter, tracker *ToolFailureTracker) {
+	if printer == nil || tracker == nil || !tracker.HasFailures() {
+		return
+	}
+	_ = printer.PrintError(tracker.Summary())
+}
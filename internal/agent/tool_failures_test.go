@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolFailureTracker_DisablesAfterThreshold(t *testing.T) {
+	tracker := NewToolFailureTracker(2)
+
+	assert.False(t, tracker.RecordFailure("git_status", errors.New("git not found")))
+	assert.False(t, tracker.IsDisabled("git_status"))
+	assert.True(t, tracker.RecordFailure("git_status", errors.New("git not found")))
+	assert.True(t, tracker.IsDisabled("git_status"))
+
+	// Further failures don't report the tool as newly disabled
+	assert.False(t, tracker.RecordFailure("git_status", errors.New("git not found")))
+
+	msg := tracker.DisabledMessage("git_status")
+	assert.Contains(t, msg, "git_status")
+	assert.Contains(t, msg, "git not found")
+}
+
+func TestToolFailureTracker_SuccessResetsConsecutiveFailures(t *testing.T) {
+	tracker := NewToolFailureTracker(2)
+
+	tracker.RecordFailure("read_file", errors.New("permission denied"))
+	tracker.RecordSuccess("read_file")
+	assert.False(t, tracker.RecordFailure("read_file", errors.New("permission denied")))
+	assert.False(t, tracker.IsDisabled("read_file"))
+}
+
+func TestToolFailureTracker_Summary(t *testing.T) {
+	tracker := NewToolFailureTracker(0)
+	assert.False(t, tracker.HasFailures())
+	assert.Empty(t, tracker.Summary())
+
+	tracker.RecordSuccess("git_log")
+	for i := 0; i < DefaultToolFailureThreshold; i++ {
+		tracker.RecordFailure("git_status", errors.New("git not found"))
+	}
+	tracker.RecordFailure("read_file", errors.New("no such file"))
+	tracker.RecordFailure("grep_file", errors.New("bad pattern"))
+	tracker.RecordSuccess("grep_file")
+
+	summary := tracker.Summary()
+	assert.True(t, tracker.HasFailures())
+	assert.Contains(t, summary, "git_status: 3 failure(s), disabled (last error: git not found)")
+	assert.Contains(t, summary, "read_file: 1 failure(s), failing")
+	assert.Contains(t, summary, "grep_file: 1 failure(s), recovered")
+	assert.NotContains(t, summary, "git_log")
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxRepeatedToolCalls is the number of identical consecutive tool calls tolerated before aborting
+const DefaultMaxRepeatedToolCalls = 3
+
+// ToolCallLoopDetector detects the model calling the same tool with identical
+// arguments over and over again
+type ToolCallLoopDetector struct {
+	maxRepeats int
+	lastKey    string
+	repeats    int
+	results    map[string]string
+}
+
+// NewToolCallLoopDetector creates a new ToolCallLoopDetector
+func NewToolCallLoopDetector(maxRepeats int) *ToolCallLoopDetector {
+	if maxRepeats <= 0 {
+		maxRepeats = DefaultMaxRepeatedToolCalls
+	}
+	return &ToolCallLoopDetector{
+		maxRepeats: maxRepeats,
+		results:    make(map[string]string),
+	}
+}
+
+// Check registers a tool call. If it repeats the previous call and a result is
+// cached, the cached result is returned with repeated set to true. An error is
+// returned once the call has been repeated more than the allowed number of times.
+func (d *ToolCallLoopDetector) Check(name, args string) (cached string, repeated bool, err error) {
+	key := toolCallKey(name, args)
+	if key == d.lastKey {
+		d.repeats++
+	} else {
+		d.lastKey = key
+		d.repeats = 1
+	}
+
+	if d.repeats > d.maxRepeats {
+		return "", true, fmt.Errorf("agent is stuck in a loop: %s was called %d times in a row with identical arguments; try rephrasing the request or using a different model", name, d.repeats)
+	}
+	if d.repeats > 1 {
+		if result, ok := d.results[key]; ok {
+			return result, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// Record caches the successful result of a tool call
+func (d *ToolCallLoopDetector) Record(name, args, result string) {
+	d.results[toolCallKey(name, args)] = result
+}
+
+// Invalidate drops the cached results of a tool, e.g. after the files it read changed
+func (d *ToolCallLoopDetector) Invalidate(name string) {
+	prefix := name + "\x00"
+	for key := range d.results {
+		if strings.HasPrefix(key, prefix) {
+			delete(d.results, key)
+		}
+	}
+}
+
+// RepeatedToolCallNudge returns the corrective note appended to a cached tool result
+func RepeatedToolCallNudge(name string) string {
+	return fmt.Sprintf("Note: you already called %s with identical arguments and the result above is unchanged. "+
+		"Do not repeat this call; use the result you have, try a different tool or arguments, or submit your final answer.", name)
+}
+
+// toolCallKey builds a comparison key for a tool call with normalized JSON arguments
+func toolCallKey(name, args string) string {
+	args = strings.TrimSpace(args)
+	var v interface{}
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			args = string(normalized)
+		}
+	}
+	return name + "\x00" + args
+}
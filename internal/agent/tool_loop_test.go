@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallLoopDetector_ReturnsCachedResultForRepeats(t *testing.T) {
+	detector := NewToolCallLoopDetector(3)
+
+	_, repeated, err := detector.Check("read_file", `{"file_path":"main.go"}`)
+	require.NoError(t, err)
+	assert.False(t, repeated)
+	detector.Record("read_file", `{"file_path":"main.go"}`, "package main")
+
+	// Same arguments with different formatting count as a repeat
+	cached, repeated, err := detector.Check("read_file", `{ "file_path": "main.go" }`)
+	require.NoError(t, err)
+	assert.True(t, repeated)
+	assert.Equal(t, "package main", cached)
+
+	_, _, err = detector.Check("read_file", `{"file_path":"main.go"}`)
+	require.NoError(t, err)
+
+	_, repeated, err = detector.Check("read_file", `{"file_path":"main.go"}`)
+	assert.True(t, repeated)
+	assert.ErrorContains(t, err, "read_file was called 4 times in a row")
+}
+
+func TestToolCallLoopDetector_DifferentCallResetsCount(t *testing.T) {
+	detector := NewToolCallLoopDetector(2)
+
+	_, _, err := detector.Check("git_status", "")
+	require.NoError(t, err)
+	_, _, err = detector.Check("git_status", "")
+	require.NoError(t, err)
+	_, repeated, err := detector.Check("git_log", `{"max_count":5}`)
+	require.NoError(t, err)
+	assert.False(t, repeated)
+	_, _, err = detector.Check("git_status", "")
+	require.NoError(t, err)
+}
+
+func TestToolCallLoopDetector_RepeatWithoutCachedResult(t *testing.T) {
+	detector := NewToolCallLoopDetector(0)
+
+	_, _, err := detector.Check("git_show", `{"commit":"HEAD"}`)
+	require.NoError(t, err)
+	// The first call failed, so nothing is cached and the call is executed again
+	_, repeated, err := detector.Check("git_show", `{"commit":"HEAD"}`)
+	require.NoError(t, err)
+	assert.False(t, repeated)
+}
+
+func TestToolCallLoopDetector_Invalidate(t *testing.T) {
+	detector := NewToolCallLoopDetector(0)
+	args := `{"file_path":"main.go"}`
+
+	_, _, err := detector.Check("read_file", args)
+	require.NoError(t, err)
+	detector.Record("read_file", args, "old content")
+	detector.Invalidate("read_file")
+
+	// The file changed, so the repeated call must be executed again
+	_, repeated, err := detector.Check("read_file", args)
+	require.NoError(t, err)
+	assert.False(t, repeated)
+}
+
+func TestRepeatedToolCallNudge(t *testing.T) {
+	assert.Contains(t, RepeatedToolCallNudge("git_status"), "git_status")
+}
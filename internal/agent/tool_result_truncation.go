@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// DefaultToolResultMaxLength is how many characters of a long tool result are kept
+	DefaultToolResultMaxLength = 5000
+	// DefaultToolResultTailRatio is the share of the kept characters taken from the end
+	DefaultToolResultTailRatio = 0.3
+
+	maxKeptLines      = 20  // Matching lines kept from the truncated middle of a result
+	maxKeptLineLength = 300 // Characters kept of each of them
+)
+
+// defaultKeepPattern matches the error and warning lines kept from the
+// truncated middle of tool results
+var defaultKeepPattern = regexp.MustCompile(`(?i)\b(?:errors?|fail(?:s|ed|ure)?|fatal|panic(?:ked)?|warn(?:ing)?s?|exception)\b`)
+
+// ToolResultLimit configures how long tool results are truncated. Zero
+// values fall back to the defaults.
+type ToolResultLimit struct {
+	MaxLength    int      // Characters kept of a longer result
+	TailRatio    float64  // Share of MaxLength taken from the end of the result, the rest from its start
+	KeepPatterns []string // Regular expressions of lines always kept, besides errors and warnings
+}
+
+// ToolResultLimits configures TruncateToolResults
+type ToolResultLimits struct {
+	Default ToolResultLimit
+	Tools   map[string]ToolResultLimit // Overrides by tool name; their KeepPatterns add to the default ones
+}
+
+// toolResultLimit is a ToolResultLimit with the defaults applied and the
+// patterns compiled
+type toolResultLimit struct {
+	maxLength int
+	tailRatio float64
+	keep      []*regexp.Regexp
+}
+
+// compile applies the defaults of base to l and compiles its patterns
+func (l ToolResultLimit) compile(base toolResultLimit) (toolResultLimit, error) {
+	limit := base
+	limit.keep = append([]*regexp.Regexp(nil), base.keep...)
+	if l.MaxLength < 0 {
+		return limit, fmt.Errorf("max length must not be negative")
+	}
+	if l.MaxLength > 0 {
+		limit.maxLength = l.MaxLength
+	}
+	if l.TailRatio < 0 || l.TailRatio >= 1 {
+		return limit, fmt.Errorf("tail ratio must be between 0 and 1")
+	}
+	if l.TailRatio > 0 {
+		limit.tailRatio = l.TailRatio
+	}
+	for _, pattern := range l.KeepPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return limit, fmt.Errorf("invalid keep pattern %q: %w", pattern, err)
+		}
+		limit.keep = append(limit.keep, re)
+	}
+	return limit, nil
+}
+
+// TruncateToolResults creates a modifier that truncates long tool results,
+// keeping their start and end, where commands report what failed, and the
+// error and warning lines in between
+func TruncateToolResults(limits ToolResultLimits) (MessageModifier, error) {
+	base, err := limits.Default.compile(toolResultLimit{
+		maxLength: DefaultToolResultMaxLength,
+		tailRatio: DefaultToolResultTailRatio,
+		keep:      []*regexp.Regexp{defaultKeepPattern},
+	})
+	if err != nil {
+		return nil, err
+	}
+	tools := make(map[string]toolResultLimit, len(limits.Tools))
+	for name, l := range limits.Tools {
+		if tools[name], err = l.compile(base); err != nil {
+			return nil, fmt.Errorf("tool %s: %w", name, err)
+		}
+	}
+	return truncateToolResults(base, tools), nil
+}
+
+// truncateToolResults truncates tool results with the limit of their tool, or
+// base when it has none
+func truncateToolResults(base toolResultLimit, tools map[string]toolResultLimit) MessageModifier {
+	return func(messages []*schema.Message) []*schema.Message {
+		// Tool results only name their call, so find the tools of the calls
+		toolNames := make(map[string]string)
+		for _, msg := range messages {
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+			}
+		}
+
+		result := make([]*schema.Message, len(messages))
+		for i, msg := range messages {
+			result[i] = msg
+			if msg.Role != schema.Tool {
+				continue
+			}
+			limit, ok := tools[toolNames[msg.ToolCallID]]
+			if !ok {
+				limit = base
+			}
+			if len(msg.Content) > limit.maxLength {
+				newMsg := *msg
+				newMsg.Content = truncateToolResult(msg.Content, limit)
+				result[i] = &newMsg
+			}
+		}
+		return result
+	}
+}
+
+// truncateToolResult keeps the start and end of content within the limit,
+// replacing the middle with a marker followed by the lines in it matching
+// the keep patterns. Cuts are moved to line breaks when that loses little.
+func truncateToolResult(content string, limit toolResultLimit) string {
+	tailLength := int(float64(limit.maxLength) * limit.tailRatio)
+	head := cutHead(content, limit.maxLength-tailLength)
+	tail := cutTail(content[len(head):], tailLength)
+	middle := content[len(head) : len(content)-len(tail)]
+
+	var b strings.Builder
+	b.WriteString(head)
+	firstLine := strings.Count(head, "\n") + 1
+	var kept []string
+	matching := 0
+	for i, line := range strings.Split(middle, "\n") {
+		if !matchesAny(line, limit.keep) {
+			continue
+		}
+		matching++
+		if len(kept) < maxKeptLines {
+			kept = append(kept, fmt.Sprintf("  L%d: %s", firstLine+i, shortenLine(line)))
+		}
+	}
+	fmt.Fprintf(&b, "\n\n[... %d characters truncated", len(middle))
+	switch {
+	case matching > len(kept):
+		fmt.Fprintf(&b, "; first %d of %d matching lines kept", len(kept), matching)
+	case matching > 0:
+		fmt.Fprintf(&b, "; %d matching lines kept", matching)
+	}
+	b.WriteString(" ...]")
+	for _, line := range kept {
+		b.WriteString("\n" + line)
+	}
+	if tail != "" {
+		b.WriteString("\n\n" + tail)
+	}
+	return b.String()
+}
+
+// cutHead returns the start of s of at most n bytes, ending before a line
+// break when that keeps at least half of it
+func cutHead(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	head := s[:n]
+	if i := strings.LastIndexByte(head, '\n'); i >= len(head)/2 {
+		head = head[:i]
+	}
+	return head
+}
+
+// cutTail returns the end of s of at most n bytes, starting after a line
+// break when that keeps at least half of it
+func cutTail(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	tail := s[start:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)/2 {
+		tail = tail[i+1:]
+	}
+	return tail
+}
+
+func matchesAny(line string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortenLine trims a kept line to maxKeptLineLength characters
+func shortenLine(line string) string {
+	line = strings.TrimSpace(line)
+	if len(line) <= maxKeptLineLength {
+		return line
+	}
+	n := maxKeptLineLength
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return line[:n] + "…"
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testOutput returns numbered lines, with an error on line errorLine
+func testOutput(lines, errorLine int) string {
+	var b strings.Builder
+	for i := 1; i <= lines; i++ {
+		if i == errorLine {
+			fmt.Fprintf(&b, "main.go:%d: error: undefined: cacheKey\n", i)
+			continue
+		}
+		fmt.Fprintf(&b, "line %03d of the build output\n", i)
+	}
+	return b.String()
+}
+
+func TestTruncateToolResults(t *testing.T) {
+	modifier, err := TruncateToolResults(ToolResultLimits{Default: ToolResultLimit{MaxLength: 300}})
+	require.NoError(t, err)
+
+	output := testOutput(100, 50)
+	messages := []*schema.Message{
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "run_command"}}}},
+		{Role: schema.Tool, Content: output, ToolCallID: "1"},
+	}
+	result := modifier(messages)
+	assert.Equal(t, output, messages[1].Content, "the history isn't changed")
+
+	content := result[1].Content
+	assert.True(t, strings.HasPrefix(content, "line 001 of the build output\n"))
+	assert.True(t, strings.HasSuffix(content, "line 100 of the build output\n"))
+	assert.Contains(t, content, "characters truncated; 1 matching lines kept ...]\n  L50: main.go:50: error: undefined: cacheKey\n\n")
+	for _, part := range strings.Split(content, "\n\n") {
+		assert.False(t, strings.HasPrefix(part, "ine") || strings.HasSuffix(part, "line"), "parts are cut at line breaks")
+	}
+
+	short := []*schema.Message{{Role: schema.Tool, Content: "ok", ToolCallID: "1"}}
+	assert.Equal(t, short, modifier(short))
+}
+
+func TestTruncateToolResults_PerTool(t *testing.T) {
+	modifier, err := TruncateToolResults(ToolResultLimits{
+		Default: ToolResultLimit{MaxLength: 300},
+		Tools: map[string]ToolResultLimit{
+			"git_diff":    {MaxLength: 5000},
+			"run_command": {TailRatio: 0.9, KeepPatterns: []string{`^line 010 `}},
+		},
+	})
+	require.NoError(t, err)
+
+	output := testOutput(100, 0)
+	result := modifier([]*schema.Message{
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{ID: "1", Function: schema.FunctionCall{Name: "git_diff"}},
+			{ID: "2", Function: schema.FunctionCall{Name: "run_command"}},
+			{ID: "3", Function: schema.FunctionCall{Name: "read_file"}},
+		}},
+		{Role: schema.Tool, Content: output, ToolCallID: "1"},
+		{Role: schema.Tool, Content: output, ToolCallID: "2"},
+		{Role: schema.Tool, Content: output, ToolCallID: "3"},
+	})
+
+	assert.Equal(t, output, result[1].Content)
+	assert.Contains(t, result[2].Content, "L10: line 010 of the build output")
+	assert.NotContains(t, result[3].Content, "L10:", "keep patterns are per tool")
+	head := func(content string) string { return strings.SplitN(content, "\n\n", 2)[0] }
+	assert.Less(t, len(head(result[2].Content)), len(head(result[3].Content)), "run_command keeps mostly the end")
+}
+
+func TestTruncateToolResults_Invalid(t *testing.T) {
+	_, err := TruncateToolResults(ToolResultLimits{Default: ToolResultLimit{TailRatio: 1}})
+	assert.Error(t, err)
+	_, err = TruncateToolResults(ToolResultLimits{Tools: map[string]ToolResultLimit{"grep": {KeepPatterns: []string{"("}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grep")
+}
+
+func TestTruncateToolResult_ManyMatches(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("warning: unused variable\n")
+	}
+	limit := toolResultLimit{maxLength: 100, tailRatio: 0.5, keep: []*regexp.Regexp{defaultKeepPattern}}
+	content := truncateToolResult(b.String(), limit)
+	assert.Contains(t, content, fmt.Sprintf("; first %d of", maxKeptLines))
+	assert.Equal(t, maxKeptLines, strings.Count(content, "  L"))
+}
+
+func TestTruncateToolResult_MultibyteRunes(t *testing.T) {
+	limit := toolResultLimit{maxLength: 10, tailRatio: 0.5}
+	content := truncateToolResult(strings.Repeat("缓存", 20), limit)
+	assert.True(t, utf8.ValidString(content))
+}
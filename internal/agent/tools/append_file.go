@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // AppendFileParams contains parameters for appending to a file
@@ -48,6 +50,9 @@ Safety: File operations are restricted to the working directory and subdirectori
 
 // Execute runs the tool and appends content to the specified file
 func (t *AppendFileTool) Execute(ctx context.Context, params *AppendFileParams) (string, error) {
+	if err := sideeffect.Check("append_file"); err != nil {
+		return "", err
+	}
 	if params == nil || params.FilePath == "" {
 		return "", fmt.Errorf("file_path is required")
 	}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/analysis"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// MaxDiffFileSize is the size above which the diff of a single file is
+// replaced by a summary
+const MaxDiffFileSize = 512 * 1024
+
+// stagedSizer is implemented by executors that can look up the size of a staged file
+type stagedSizer interface {
+	StagedFileSize(ctx context.Context, path string) (int64, error)
+}
+
+// diffArtifact is a file whose diff was replaced by a summary
+type diffArtifact struct {
+	path    string
+	kind    string // "Git LFS", "binary", "lockfile" or "large diff"
+	size    int64  // File size, or -1 when unknown
+	summary string
+}
+
+// replaceDiffArtifacts replaces the diff of Git LFS pointers, binary files,
+// dependency lockfiles and files with a diff larger than MaxDiffFileSize by a
+// one-line summary such as "model.bin: binary, 40.0 MB, replaced". Their
+// content is noise to the model and costs tokens. size looks up the size of a file and may be nil.
+func replaceDiffArtifacts(diff string, size func(path string) int64) (string, []diffArtifact) {
+	if !strings.HasPrefix(diff, "diff --git ") && !strings.Contains(diff, "\ndiff --git ") {
+		return diff, nil
+	}
+
+	var out strings.Builder
+	var artifacts []diffArtifact
+	for _, section := range splitDiffSections(diff) {
+		artifact, header, ok := classifyDiffSection(section, size)
+		if !ok {
+			out.WriteString(section)
+			continue
+		}
+		artifacts = append(artifacts, artifact)
+		out.WriteString(header)
+		out.WriteString(artifact.summary + "\n")
+	}
+	return strings.TrimSuffix(out.String(), "\n"), artifacts
+}
+
+// splitDiffSections splits a git diff into per-file sections, each ending
+// with a newline
+func splitDiffSections(diff string) []string {
+	var sections []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") && current.Len() > 0 {
+			sections = append(sections, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		section := current.String()
+		if !strings.HasSuffix(section, "\n") {
+			section += "\n"
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// classifyDiffSection reports whether a file section should be replaced and
+// returns its summary and the header lines to keep
+func classifyDiffSection(section string, size func(path string) int64) (diffArtifact, string, bool) {
+	var header strings.Builder
+	artifact := diffArtifact{size: -1}
+	inHeader, lfs := true, false
+	lfsSize := int64(-1)
+
+	for _, line := range strings.SplitAfter(section, "\n") {
+		text := strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			if parts := strings.SplitN(strings.TrimPrefix(text, "diff --git "), " b/", 2); len(parts) == 2 {
+				artifact.path = parts[1]
+			}
+		case strings.HasPrefix(text, "+++ b/"):
+			artifact.path = strings.TrimPrefix(text, "+++ b/")
+		case text == "GIT binary patch" || (strings.HasPrefix(text, "Binary files ") && strings.HasSuffix(text, " differ")):
+			artifact.kind = "binary"
+		case strings.HasPrefix(text, "@@"):
+			inHeader = false
+		case !inHeader && len(text) > 0 && text[1:] == git.LFSPointerPrefix:
+			lfs = true
+		case !inHeader && (strings.HasPrefix(text, "+size ") || strings.HasPrefix(text, " size ")):
+			if n, err := strconv.ParseInt(strings.TrimSpace(text[len(" size "):]), 10, 64); err == nil {
+				lfsSize = n
+			}
+		}
+		if inHeader && artifact.kind == "" && !strings.HasPrefix(text, "--- ") && !strings.HasPrefix(text, "+++ ") && text != "" {
+			header.WriteString(line)
+		}
+	}
+
+	switch {
+	case lfs:
+		artifact.kind = "Git LFS"
+		artifact.size = lfsSize
+	case artifact.kind == "binary":
+		if size != nil {
+			artifact.size = size(artifact.path)
+		}
+	case analysis.IsLockfile(artifact.path):
+		// Lockfiles are generated; the manifest diff shows the intended change
+		artifact.kind = "lockfile"
+		if files := analysis.ParseDiff(section); len(files) == 1 {
+			if changes := analysis.LockfileChanges(files[0]); len(changes) > 0 {
+				artifact.kind += fmt.Sprintf(", %d package(s) changed", len(changes))
+			}
+		}
+	case len(section) > MaxDiffFileSize:
+		artifact.kind = "large diff"
+		artifact.size = int64(len(section))
+	default:
+		return artifact, "", false
+	}
+
+	artifact.summary = artifact.path + ": " + artifact.kind
+	if artifact.size >= 0 {
+		artifact.summary += ", " + formatSize(artifact.size)
+	}
+	artifact.summary += ", replaced"
+	return artifact, header.String(), true
+}
+
+// summarizeDiff replaces artifacts in diff, looking up binary file sizes with
+// executor when it supports it, and explains the replacements to the model
+func summarizeDiff(ctx context.Context, executor git.Executor, diff string) string {
+	var size func(path string) int64
+	if sizer, ok := executor.(stagedSizer); ok {
+		size = func(path string) int64 {
+			n, err := sizer.StagedFileSize(ctx, path)
+			if err != nil {
+				return -1
+			}
+			return n
+		}
+	}
+
+	diff, artifacts := replaceDiffArtifacts(diff, size)
+	if len(artifacts) == 0 {
+		return diff
+	}
+	return diff + fmt.Sprintf("\n\nNote: the content of %d file(s) was replaced by a summary line because they are binary, "+
+		"Git LFS pointers, lockfiles or too large to show. Describe them from their name, type and size only.", len(artifacts))
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceDiffArtifacts(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
+ package main
++// changed
+diff --git a/assets/logo.png b/assets/logo.png
+new file mode 100644
+index 0000000..b6fc4c6
+--- /dev/null
++++ b/assets/logo.png
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
++size 1536
+diff --git a/model.bin b/model.bin
+new file mode 100644
+index 0000000..3333333
+Binary files /dev/null and b/model.bin differ
+diff --git a/data.csv b/data.csv
+new file mode 100644
+--- /dev/null
++++ b/data.csv
+@@ -0,0 +1 @@
++` + strings.Repeat("x", MaxDiffFileSize)
+
+	sizes := map[string]int64{"model.bin": 40 * 1024 * 1024}
+	out, artifacts := replaceDiffArtifacts(diff, func(path string) int64 {
+		if size, ok := sizes[path]; ok {
+			return size
+		}
+		return -1
+	})
+
+	require.Len(t, artifacts, 3)
+	assert.Equal(t, `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
+ package main
++// changed
+diff --git a/assets/logo.png b/assets/logo.png
+new file mode 100644
+index 0000000..b6fc4c6
+assets/logo.png: Git LFS, 1.5 KB, replaced
+diff --git a/model.bin b/model.bin
+new file mode 100644
+index 0000000..3333333
+model.bin: binary, 40.0 MB, replaced
+diff --git a/data.csv b/data.csv
+new file mode 100644
+data.csv: large diff, 512.1 KB, replaced`, out)
+
+	// Plain diffs and diffs without artifacts are unchanged
+	plain := "--- a.txt\n+++ a.txt\n@@ -1 +1 @@\n-a\n+b"
+	out, artifacts = replaceDiffArtifacts(plain, nil)
+	assert.Equal(t, plain, out)
+	assert.Empty(t, artifacts)
+}
+
+func TestGitDiffCachedTool_SummarizesBinaries(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	data := make([]byte, 3000)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "model.bin"), data, 0644))
+	createAndStageFile(t, repoDir, "model.bin", string(data))
+
+	result, err := NewGitDiffCachedTool(git.NewExecutor(repoDir), 0).Execute(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, result, "+package main")
+	assert.Contains(t, result, "model.bin: binary, 2.9 KB, replaced")
+	assert.Contains(t, result, "the content of 1 file(s) was replaced")
+}
+
+func TestReplaceDiffArtifacts_Lockfiles(t *testing.T) {
+	diff := `diff --git a/package-lock.json b/package-lock.json
+index 1111111..2222222 100644
+--- a/package-lock.json
++++ b/package-lock.json
+@@ -20,4 +20,4 @@
+     "node_modules/axios": {
+-      "version": "1.6.0",
++      "version": "1.7.2",
+       "resolved": "https://registry.npmjs.org/axios/-/axios-1.7.2.tgz",
+diff --git a/go.sum b/go.sum
+index 3333333..4444444 100644
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1 @@
+-github.com/spf13/cobra v1.8.0 h1:abc=
++github.com/spf13/cobra v1.9.1 h1:def=`
+
+	out, artifacts := replaceDiffArtifacts(diff, nil)
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, `diff --git a/package-lock.json b/package-lock.json
+index 1111111..2222222 100644
+package-lock.json: lockfile, 1 package(s) changed, replaced
+diff --git a/go.sum b/go.sum
+index 3333333..4444444 100644
+go.sum: lockfile, replaced`, out)
+}
@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultFunctionContextMaxLines is the default cap on enclosing-function context lines
+const DefaultFunctionContextMaxLines = 400
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// diffFile is a file section of a unified diff
+type diffFile struct {
+	path  string
+	hunks []diffHunk
+}
+
+// diffHunk is a hunk of a unified diff, described by its new-file lines
+type diffHunk struct {
+	header   string
+	newStart int
+	newCount int
+	lines    []string // hunk body lines including their ' ', '+' or '-' prefix
+}
+
+// String renders the hunk in unified diff format
+func (h diffHunk) String() string {
+	return h.header + "\n" + strings.TrimRight(strings.Join(h.lines, "\n"), "\n")
+}
+
+// changedLines returns the new-file line numbers of added lines, plus the
+// line following removed lines so pure deletions still map to a function
+func (h diffHunk) changedLines() []int {
+	var changed []int
+	line := h.newStart
+	for _, l := range h.lines {
+		switch {
+		case strings.HasPrefix(l, "+"):
+			changed = append(changed, line)
+			line++
+		case strings.HasPrefix(l, "-"):
+			changed = append(changed, line)
+		case strings.HasPrefix(l, "\\"):
+			// "\ No newline at end of file"
+		default:
+			line++
+		}
+	}
+	return changed
+}
+
+// matches checks that the hunk's context and added lines agree with the file
+func (h diffHunk) matches(fileLines []string) bool {
+	line := h.newStart
+	for _, l := range h.lines {
+		if strings.HasPrefix(l, "-") || strings.HasPrefix(l, "\\") {
+			continue
+		}
+		if line < 1 || line > len(fileLines) {
+			return false
+		}
+		text := ""
+		if len(l) > 0 {
+			text = l[1:]
+		}
+		if strings.TrimRight(fileLines[line-1], "\r") != text {
+			return false
+		}
+		line++
+	}
+	return true
+}
+
+// parseUnifiedDiff splits a unified diff into files and hunks
+func parseUnifiedDiff(diff string) []diffFile {
+	var files []diffFile
+	var current *diffFile
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, diffFile{})
+			current = &files[len(files)-1]
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "+++ ") && len(current.hunks) == 0:
+			path := strings.TrimPrefix(line, "+++ ")
+			if path != "/dev/null" {
+				current.path = strings.TrimPrefix(path, "b/")
+			}
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeaderPattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			current.hunks = append(current.hunks, diffHunk{header: line, newStart: start, newCount: count})
+		case len(current.hunks) > 0:
+			hunk := &current.hunks[len(current.hunks)-1]
+			if line == "" || strings.ContainsAny(line[:1], " +-\\") {
+				hunk.lines = append(hunk.lines, line)
+			}
+		}
+	}
+	return files
+}
+
+// DiffHunkAt returns the hunk of the diff that touches the given new-file line
+// of path, or the file's first hunk when line is 0. An empty string is
+// returned when the file has no matching hunk.
+func DiffHunkAt(diff, path string, line int) string {
+	for _, file := range parseUnifiedDiff(diff) {
+		if file.path != path || len(file.hunks) == 0 {
+			continue
+		}
+		if line <= 0 {
+			return file.hunks[0].String()
+		}
+		for _, hunk := range file.hunks {
+			if line >= hunk.newStart && line < hunk.newStart+max(hunk.newCount, 1) {
+				return hunk.String()
+			}
+		}
+	}
+	return ""
+}
+
+// ExpandDiffToFunctions appends the full source of every function or method
+// touched by the diff, so reviewers see complete logical units instead of
+// isolated hunks. Files are read from workDir; files whose working-tree
+// content no longer matches the diff are skipped. The appended context is
+// capped at maxLines lines. The original diff is returned unchanged when no
+// enclosing declarations are found.
+func ExpandDiffToFunctions(diff, workDir string, maxLines int) string {
+	if maxLines <= 0 {
+		maxLines = DefaultFunctionContextMaxLines
+	}
+
+	var sections []string
+	remaining := maxLines
+	omitted := 0
+
+	for _, file := range parseUnifiedDiff(diff) {
+		if file.path == "" || len(file.hunks) == 0 {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(workDir, file.path))
+		if err != nil {
+			continue
+		}
+		symbols := ExtractOutline(file.path, content)
+		if len(symbols) == 0 {
+			continue
+		}
+		fileLines := strings.Split(string(content), "\n")
+
+		// Collect the enclosing symbols in diff order, without duplicates
+		seen := make(map[int]bool)
+		var enclosing []OutlineSymbol
+		for _, hunk := range file.hunks {
+			if !hunk.matches(fileLines) {
+				continue
+			}
+			for _, line := range hunk.changedLines() {
+				symbol, ok := EnclosingSymbol(symbols, line)
+				if !ok || seen[symbol.StartLine] {
+					continue
+				}
+				seen[symbol.StartLine] = true
+				enclosing = append(enclosing, symbol)
+			}
+		}
+
+		for _, symbol := range enclosing {
+			end := symbol.EndLine
+			if end > len(fileLines) {
+				end = len(fileLines)
+			}
+			size := end - symbol.StartLine + 1
+			if size > remaining {
+				omitted++
+				continue
+			}
+			remaining -= size
+
+			var sb strings.Builder
+			sb.WriteString(fmt.Sprintf("--- %s: %s %s (lines %d-%d)\n", file.path, symbol.Kind, symbol.Name, symbol.StartLine, end))
+			for i := symbol.StartLine; i <= end; i++ {
+				sb.WriteString(fmt.Sprintf("%6d | %s\n", i, strings.TrimRight(fileLines[i-1], "\r")))
+			}
+			sections = append(sections, sb.String())
+		}
+	}
+
+	if len(sections) == 0 && omitted == 0 {
+		return diff
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(diff, "\n"))
+	sb.WriteString("\n\n=== Enclosing function context ===\n")
+	sb.WriteString("Full source of the functions and methods touched by the diff above (current working tree):\n\n")
+	sb.WriteString(strings.Join(sections, "\n"))
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("\n(%d more enclosing declarations omitted; use read_file to inspect them)\n", omitted))
+	}
+	return sb.String()
+}
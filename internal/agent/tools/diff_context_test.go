@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const expandDiff = `diff --git a/demo.go b/demo.go
+index 1111111..2222222 100644
+--- a/demo.go
++++ b/demo.go
+@@ -8,5 +8,5 @@ func (s *Server) Start() error {
+ 	if s.name == "" {
+ 		return nil
+ 	}
+-	return nil
++	return s.run()
+ }
+`
+
+const expandSource = `package demo
+
+type Server struct {
+	name string
+}
+
+func (s *Server) Start() error {
+	if s.name == "" {
+		return nil
+	}
+	return s.run()
+}
+
+func helper(x int) int {
+	return x * 2
+}
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	files := parseUnifiedDiff(expandDiff)
+	require.Len(t, files, 1)
+	assert.Equal(t, "demo.go", files[0].path)
+	require.Len(t, files[0].hunks, 1)
+	assert.Equal(t, 8, files[0].hunks[0].newStart)
+	assert.Equal(t, []int{11, 11}, files[0].hunks[0].changedLines())
+}
+
+func TestExpandDiffToFunctions(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "demo.go"), []byte(expandSource), 0644))
+
+	t.Run("appends enclosing function", func(t *testing.T) {
+		result := ExpandDiffToFunctions(expandDiff, tmpDir, 0)
+		assert.Contains(t, result, expandDiff[:len(expandDiff)-1])
+		assert.Contains(t, result, "=== Enclosing function context ===")
+		assert.Contains(t, result, "demo.go: method (*Server) Start (lines 7-12)")
+		assert.Contains(t, result, "     7 | func (s *Server) Start() error {")
+		assert.NotContains(t, result, "func helper")
+	})
+
+	t.Run("respects max lines", func(t *testing.T) {
+		result := ExpandDiffToFunctions(expandDiff, tmpDir, 3)
+		assert.NotContains(t, result, "     7 | func")
+		assert.Contains(t, result, "1 more enclosing declarations omitted")
+	})
+
+	t.Run("skips stale working tree", func(t *testing.T) {
+		staleDir := t.TempDir()
+		stale := []byte("package demo\n\nfunc other() {}\n")
+		require.NoError(t, os.WriteFile(filepath.Join(staleDir, "demo.go"), stale, 0644))
+		assert.Equal(t, expandDiff, ExpandDiffToFunctions(expandDiff, staleDir, 0))
+	})
+
+	t.Run("missing file leaves diff unchanged", func(t *testing.T) {
+		assert.Equal(t, expandDiff, ExpandDiffToFunctions(expandDiff, t.TempDir(), 0))
+	})
+}
+
+func TestDiffHunkAt(t *testing.T) {
+	hunk := DiffHunkAt(expandDiff, "demo.go", 11)
+	assert.True(t, strings.HasPrefix(hunk, "@@ -8,5 +8,5 @@"))
+	assert.Contains(t, hunk, "+\treturn s.run()")
+
+	assert.Equal(t, hunk, DiffHunkAt(expandDiff, "demo.go", 0))
+	assert.Empty(t, DiffHunkAt(expandDiff, "demo.go", 40))
+	assert.Empty(t, DiffHunkAt(expandDiff, "other.go", 11))
+}
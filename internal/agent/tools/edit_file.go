@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // EditFileParams contains parameters for editing a file
@@ -55,6 +57,9 @@ Safety: Operations are restricted to the working directory and subdirectories.`
 
 // Execute runs the tool and performs the specified edit operation
 func (t *EditFileTool) Execute(ctx context.Context, params *EditFileParams) (string, error) {
+	if err := sideeffect.Check("edit_file"); err != nil {
+		return "", err
+	}
 	if err := t.validateParams(params); err != nil {
 		return "", err
 	}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// DefaultFeedbackSimilarity is the similarity above which a question counts as
+// already answered. Only the same question is answered without asking: the
+// user confirms answers to similar ones, which may be about another target.
+const DefaultFeedbackSimilarity = 0.7
+
+// FeedbackAnswer is a question the user answered through request_feedback
+type FeedbackAnswer struct {
+	Title   string
+	Prompt  string
+	Options []string
+	Answer  string
+	Source  string // Session the answer was given in; empty for the current session
+}
+
+// FeedbackHistory remembers answered questions so repeated questions can be
+// answered without asking the user again
+type FeedbackHistory struct {
+	answers   []FeedbackAnswer
+	threshold float64
+}
+
+// NewFeedbackHistory creates an empty FeedbackHistory
+func NewFeedbackHistory() *FeedbackHistory {
+	return &FeedbackHistory{threshold: DefaultFeedbackSimilarity}
+}
+
+// Len returns the number of remembered answers
+func (h *FeedbackHistory) Len() int {
+	return len(h.answers)
+}
+
+// Record remembers the answer to a question. Empty answers are not remembered.
+func (h *FeedbackHistory) Record(params *RequestFeedbackParams, answer, source string) {
+	if params == nil || strings.TrimSpace(answer) == "" {
+		return
+	}
+	h.answers = append(h.answers, FeedbackAnswer{
+		Title:   params.Title,
+		Prompt:  params.Prompt,
+		Options: params.Options,
+		Answer:  answer,
+		Source:  source,
+	})
+}
+
+// Find returns the most similar answered question, preferring the most recent
+// answer on ties. For multiple choice questions the earlier answer must be one
+// of the options; its index is returned (-1 for open-ended questions).
+func (h *FeedbackHistory) Find(params *RequestFeedbackParams) (*FeedbackAnswer, int) {
+	if params == nil {
+		return nil, -1
+	}
+
+	question := params.Title + " " + params.Prompt
+	var best *FeedbackAnswer
+	bestIndex, bestScore := -1, 0.0
+	for i := len(h.answers) - 1; i >= 0; i-- {
+		answer := &h.answers[i]
+		score := TextSimilarity(question, answer.Title+" "+answer.Prompt)
+		if score < h.threshold || score <= bestScore {
+			continue
+		}
+
+		index := -1
+		if len(params.Options) > 0 {
+			if index = optionIndex(params.Options, answer.Answer); index < 0 {
+				continue
+			}
+		}
+		best, bestIndex, bestScore = answer, index, score
+	}
+	return best, bestIndex
+}
+
+// LoadMessages remembers the answers recorded in a message history, e.g. of a
+// saved session. It returns the number of answers found.
+func (h *FeedbackHistory) LoadMessages(messages []*schema.Message, source string) int {
+	questions := make(map[string]*RequestFeedbackParams)
+	found := 0
+	for _, msg := range messages {
+		switch msg.Role {
+		case schema.Assistant:
+			for _, tc := range msg.ToolCalls {
+				if tc.Function.Name != "request_feedback" {
+					continue
+				}
+				var params RequestFeedbackParams
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err == nil {
+					questions[tc.ID] = &params
+				}
+			}
+		case schema.Tool:
+			params, ok := questions[msg.ToolCallID]
+			if !ok {
+				continue
+			}
+			var result struct {
+				UserResponse string `json:"user_response"`
+				Reused       bool   `json:"reused"`
+			}
+			if err := json.Unmarshal([]byte(msg.Content), &result); err != nil || result.Reused || result.UserResponse == "" {
+				continue
+			}
+			h.Record(params, result.UserResponse, source)
+			found++
+		}
+	}
+	return found
+}
+
+// TextSimilarity returns the Jaccard similarity of the words of a and b,
+// between 0 (nothing in common) and 1 (same words)
+func TextSimilarity(a, b string) float64 {
+	wordsA, wordsB := similarityTokens(a), similarityTokens(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// similarityTokens splits text into lowercase words. Han text has no spaces,
+// so it is split into character bigrams instead.
+func similarityTokens(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	var word, han []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens[string(word)] = true
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens[string(han)] = true
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens[string(han[i:i+2])] = true
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
+
+// SameQuestion reports whether params asks the question answer answered, with
+// the same words in the same order, ignoring case, spacing and punctuation
+func SameQuestion(params *RequestFeedbackParams, answer *FeedbackAnswer) bool {
+	return normalizeQuestion(params.Title+" "+params.Prompt) == normalizeQuestion(answer.Title+" "+answer.Prompt)
+}
+
+// normalizeQuestion lowercases text and reduces everything but letters and
+// digits to single spaces
+func normalizeQuestion(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// optionIndex returns the index of the option matching answer, or -1
+func optionIndex(options []string, answer string) int {
+	for i, option := range options {
+		if strings.EqualFold(strings.TrimSpace(option), strings.TrimSpace(answer)) {
+			return i
+		}
+	}
+	return -1
+}
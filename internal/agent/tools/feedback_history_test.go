@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestTextSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"Log File Path", "log file path", 1, 1},
+		{"Please provide the log file path", "Please provide the path of the log file", 0.7, 1},
+		{"Log File Path", "Database version", 0, 0},
+		{"日志文件路径", "请提供日志文件路径", 0.6, 1},
+		{"", "anything", 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := TextSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("TextSimilarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestFeedbackHistory_Find(t *testing.T) {
+	history := NewFeedbackHistory()
+	history.Record(&RequestFeedbackParams{Title: "Log File Path", Prompt: "Please provide the log file path"}, "/var/log/app.log", "")
+	history.Record(&RequestFeedbackParams{Title: "Environment", Prompt: "Which environment fails?", Options: []string{"Production", "Staging"}}, "Staging", "debug-1")
+	history.Record(&RequestFeedbackParams{Title: "Skipped", Prompt: "Anything else?"}, "  ", "")
+
+	if history.Len() != 2 {
+		t.Fatalf("expected 2 answers (empty answers are skipped), got %d", history.Len())
+	}
+
+	answer, index := history.Find(&RequestFeedbackParams{Title: "Log file path", Prompt: "Please provide the path of the log file"})
+	if answer == nil || answer.Answer != "/var/log/app.log" || index != -1 {
+		t.Errorf("expected the log path answer, got %+v (index %d)", answer, index)
+	}
+
+	answer, index = history.Find(&RequestFeedbackParams{Title: "Environment", Prompt: "Which environment fails?", Options: []string{"Development", "Staging", "Production"}})
+	if answer == nil || answer.Source != "debug-1" || index != 1 {
+		t.Errorf("expected the staging option at index 1, got %+v (index %d)", answer, index)
+	}
+
+	if answer, _ := history.Find(&RequestFeedbackParams{Title: "Environment", Prompt: "Which environment fails?", Options: []string{"Development", "QA"}}); answer != nil {
+		t.Errorf("answers that are not among the options must not be reused, got %+v", answer)
+	}
+	if answer, _ := history.Find(&RequestFeedbackParams{Title: "Database Version", Prompt: "Which database version do you run?"}); answer != nil {
+		t.Errorf("unrelated questions must not match, got %+v", answer)
+	}
+}
+
+func TestFeedbackHistory_LoadMessages(t *testing.T) {
+	args, _ := json.Marshal(RequestFeedbackParams{Title: "Log File Path", Prompt: "Please provide the log file path"})
+	reusedArgs, _ := json.Marshal(RequestFeedbackParams{Title: "Log Path", Prompt: "Where is the log file?"})
+	messages := []*schema.Message{
+		{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{ID: "call-1", Function: schema.FunctionCall{Name: "request_feedback", Arguments: string(args)}},
+			{ID: "call-2", Function: schema.FunctionCall{Name: "read_file", Arguments: `{"file_path":"main.go"}`}},
+			{ID: "call-3", Function: schema.FunctionCall{Name: "request_feedback", Arguments: string(reusedArgs)}},
+		}},
+		{Role: schema.Tool, ToolCallID: "call-1", Content: `{"user_response": "/var/log/app.log", "title": "Log File Path"}`},
+		{Role: schema.Tool, ToolCallID: "call-2", Content: `{"user_response": "not a question"}`},
+		{Role: schema.Tool, ToolCallID: "call-3", Content: `{"user_response": "/var/log/app.log", "reused": true}`},
+	}
+
+	history := NewFeedbackHistory()
+	if n := history.LoadMessages(messages, "debug-1"); n != 1 {
+		t.Fatalf("expected 1 answer, got %d", n)
+	}
+	answer, _ := history.Find(&RequestFeedbackParams{Title: "Log File Path", Prompt: "Please provide the log file path"})
+	if answer == nil || answer.Source != "debug-1" {
+		t.Errorf("expected the answer from debug-1, got %+v", answer)
+	}
+}
+
+func TestSameQuestion(t *testing.T) {
+	answer := &FeedbackAnswer{Title: "Apply the fix", Prompt: "Apply the fix to internal/foo.go?"}
+	if !SameQuestion(&RequestFeedbackParams{Title: "apply the fix", Prompt: "Apply the fix to  internal/foo.go"}, answer) {
+		t.Error("case, spacing and punctuation must not matter")
+	}
+	if SameQuestion(&RequestFeedbackParams{Title: "Apply the fix", Prompt: "Apply the fix to internal/bar.go?"}, answer) {
+		t.Error("questions about another file are not the same")
+	}
+}
+
+func TestRequestFeedbackTool_ReusesAnswers(t *testing.T) {
+	ctx := context.Background()
+	history := NewFeedbackHistory()
+	output := &bytes.Buffer{}
+	tool := NewRequestFeedbackTool(strings.NewReader("/var/log/app.log\n"), output, history)
+
+	params := &RequestFeedbackParams{Title: "Log File Path", Content: "Need the logs", Prompt: "Please provide the log file path"}
+	if _, err := tool.Execute(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The input is exhausted, so a second answer can only come from the history
+	result, err := tool.Execute(ctx, &RequestFeedbackParams{Title: "Log file path", Content: "Still need the logs", Prompt: "Please provide the log file path."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if response["user_response"] != "/var/log/app.log" || response["reused"] != true {
+		t.Errorf("expected the reused answer, got %v", response)
+	}
+	if !strings.Contains(output.String(), "Reusing your answer") {
+		t.Errorf("expected a reuse note in the output, got %q", output.String())
+	}
+}
+
+func TestRequestFeedbackTool_ConfirmsSimilarAnswers(t *testing.T) {
+	ctx := context.Background()
+	history := NewFeedbackHistory()
+	output := &bytes.Buffer{}
+	// Answer, decline reusing it for another file and answer that, then accept
+	tool := NewRequestFeedbackTool(strings.NewReader("yes\nn\nno\ny\n"), output, history)
+	ask := func(prompt string) map[string]interface{} {
+		t.Helper()
+		result, err := tool.Execute(ctx, &RequestFeedbackParams{Title: "Apply the fix", Content: "The fix is ready", Prompt: prompt})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &response); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	ask("Apply the fix to internal/foo.go?")
+	if response := ask("Apply the fix to internal/bar.go?"); response["user_response"] != "no" || response["reused"] == true {
+		t.Errorf("a declined answer must not be reused, got %v", response)
+	}
+	if !strings.Contains(output.String(), `You answered a similar question, "Apply the fix"`) {
+		t.Errorf("expected the earlier answer to be shown, got %q", output.String())
+	}
+	if response := ask("Apply the fix to internal/baz.go?"); response["user_response"] != "no" || response["reused"] != true {
+		t.Errorf("expected the confirmed answer, got %v", response)
+	}
+}
@@ -0,0 +1,390 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutlineSymbol is a declaration found in a source file
+type OutlineSymbol struct {
+	Name      string
+	Kind      string // func, method, type, class, ...
+	StartLine int    // 1-indexed
+	EndLine   int    // 1-indexed, inclusive
+}
+
+// Contains reports whether the symbol spans the given line
+func (s OutlineSymbol) Contains(line int) bool {
+	return line >= s.StartLine && line <= s.EndLine
+}
+
+// FileOutlineParams contains parameters for the file outline tool
+type FileOutlineParams struct {
+	FilePath string `json:"file_path"`
+}
+
+// FileOutlineTool is a tool for listing the declarations in a source file
+type FileOutlineTool struct {
+	workDir string
+}
+
+// NewFileOutlineTool creates a new FileOutlineTool
+func NewFileOutlineTool(workDir string) *FileOutlineTool {
+	return &FileOutlineTool{workDir: workDir}
+}
+
+// Name returns the tool name
+func (t *FileOutlineTool) Name() string {
+	return "file_outline"
+}
+
+// Description returns the tool description
+func (t *FileOutlineTool) Description() string {
+	return `List the functions, methods, types and classes declared in a source file with their line ranges.
+Use this tool to navigate large files and then read only the relevant ranges with read_file.
+Parameters:
+- file_path (required): Path to the source file
+Supports Go, Python and brace-based languages (JavaScript/TypeScript, Java, C/C++, C#, Rust, Kotlin, Swift, PHP).`
+}
+
+// Execute runs the tool and returns the file outline
+func (t *FileOutlineTool) Execute(ctx context.Context, params *FileOutlineParams) (string, error) {
+	if params == nil || params.FilePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+
+	filePath := params.FilePath
+	if !filepath.IsAbs(filePath) && t.workDir != "" {
+		filePath = filepath.Join(t.workDir, filePath)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", params.FilePath)
+		}
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	symbols := ExtractOutline(params.FilePath, content)
+	if len(symbols) == 0 {
+		return fmt.Sprintf("No declarations found in %s (unsupported language or empty file)", params.FilePath), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Outline of %s (%d declarations):\n", params.FilePath, len(symbols)))
+	for _, s := range symbols {
+		sb.WriteString(fmt.Sprintf("  L%d-%d  %s %s\n", s.StartLine, s.EndLine, s.Kind, s.Name))
+	}
+	return sb.String(), nil
+}
+
+// ExtractOutline returns the declarations in a source file, sorted by start line.
+// The language is detected from the file extension.
+func ExtractOutline(filePath string, content []byte) []OutlineSymbol {
+	var symbols []OutlineSymbol
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".go":
+		var err error
+		symbols, err = goOutline(content)
+		if err != nil {
+			// Fall back to the brace heuristic for files that don't parse
+			symbols = braceOutline(content)
+		}
+	case ".py":
+		symbols = pythonOutline(content)
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".java", ".c", ".h", ".cc", ".cpp", ".hpp", ".cs",
+		".rs", ".kt", ".kts", ".swift", ".php", ".scala", ".dart":
+		symbols = braceOutline(content)
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].StartLine < symbols[j].StartLine
+	})
+	return symbols
+}
+
+// EnclosingSymbol returns the innermost symbol containing the line
+func EnclosingSymbol(symbols []OutlineSymbol, line int) (OutlineSymbol, bool) {
+	var best OutlineSymbol
+	found := false
+	for _, s := range symbols {
+		if !s.Contains(line) {
+			continue
+		}
+		if !found || s.EndLine-s.StartLine < best.EndLine-best.StartLine {
+			best = s
+			found = true
+		}
+	}
+	return best, found
+}
+
+// goOutline extracts declarations from Go source using the Go parser
+func goOutline(content []byte) ([]OutlineSymbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []OutlineSymbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			symbol := OutlineSymbol{
+				Name:      d.Name.Name,
+				Kind:      "func",
+				StartLine: fset.Position(d.Pos()).Line,
+				EndLine:   fset.Position(d.End()).Line,
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbol.Kind = "method"
+				symbol.Name = fmt.Sprintf("(%s) %s", goTypeString(d.Recv.List[0].Type), d.Name.Name)
+			}
+			symbols = append(symbols, symbol)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				symbols = append(symbols, OutlineSymbol{
+					Name:      ts.Name.Name,
+					Kind:      "type",
+					StartLine: fset.Position(ts.Pos()).Line,
+					EndLine:   fset.Position(ts.End()).Line,
+				})
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goTypeString renders a receiver type expression
+func goTypeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + goTypeString(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return goTypeString(t.X)
+	case *ast.IndexListExpr:
+		return goTypeString(t.X)
+	case *ast.SelectorExpr:
+		return goTypeString(t.X) + "." + t.Sel.Name
+	default:
+		return "?"
+	}
+}
+
+var (
+	pythonDeclPattern = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+(\w+)`)
+
+	// braceKeywordPattern matches declarations introduced by a keyword
+	braceKeywordPattern = regexp.MustCompile(`\b(function|fn|func|class|interface|struct|enum|trait|impl|namespace|object)\b\s*\*?\s*([\w:<>]*)`)
+	// braceArrowPattern matches `const name = (...) => {` style functions
+	braceArrowPattern = regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s*)?(?:\([^)]*\)|\w+)\s*(?::\s*[^=]+)?=>`)
+	// braceMethodPattern matches C-style function and method declarations
+	braceMethodPattern = regexp.MustCompile(`^\s*(?:[\w<>\[\],.*&:?]+\s+)*?(\w+)\s*\([^;]*\)\s*(?:const\s*)?(?:throws\s+[\w.,\s]+)?(?::\s*[\w<>\[\],.?| ]+)?\s*\{?\s*$`)
+
+	braceControlKeywords = map[string]bool{
+		"if": true, "for": true, "while": true, "switch": true, "catch": true, "return": true,
+		"else": true, "do": true, "try": true, "new": true, "sizeof": true, "foreach": true, "using": true, "lock": true,
+	}
+)
+
+// pythonOutline extracts def/class declarations using indentation
+func pythonOutline(content []byte) []OutlineSymbol {
+	lines := strings.Split(string(content), "\n")
+	var symbols []OutlineSymbol
+
+	for i, line := range lines {
+		m := pythonDeclPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		indent := len(m[1])
+		end := i + 1
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" {
+				continue
+			}
+			if len(lines[j])-len(strings.TrimLeft(lines[j], " \t")) <= indent && !strings.HasPrefix(trimmed, ")") {
+				break
+			}
+			end = j + 1
+		}
+		kind := "func"
+		if m[2] == "class" {
+			kind = "class"
+		} else if indent > 0 {
+			kind = "method"
+		}
+		symbols = append(symbols, OutlineSymbol{Name: m[3], Kind: kind, StartLine: i + 1, EndLine: end})
+	}
+	return symbols
+}
+
+// braceOutline extracts declarations from brace-delimited languages using
+// declaration patterns and brace matching
+func braceOutline(content []byte) []OutlineSymbol {
+	lines := strings.Split(string(content), "\n")
+	depths := braceDepths(lines)
+	var symbols []OutlineSymbol
+
+	for i := 0; i < len(lines); i++ {
+		name, kind, ok := matchBraceDeclaration(lines[i])
+		if !ok {
+			continue
+		}
+
+		// The body must open on the declaration line or shortly after it
+		openLine := -1
+		for j := i; j < len(lines) && j <= i+3; j++ {
+			if strings.Contains(stripCode(lines[j]), "{") {
+				openLine = j
+				break
+			}
+			if strings.Contains(stripCode(lines[j]), ";") {
+				break
+			}
+		}
+		if openLine < 0 {
+			continue
+		}
+
+		end := findClosingBrace(depths, openLine)
+		if end < 0 {
+			continue
+		}
+		symbols = append(symbols, OutlineSymbol{Name: name, Kind: kind, StartLine: i + 1, EndLine: end + 1})
+	}
+	return symbols
+}
+
+// matchBraceDeclaration checks whether a line starts a declaration
+func matchBraceDeclaration(line string) (name, kind string, ok bool) {
+	code := stripCode(line)
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "}") {
+		return "", "", false
+	}
+
+	if m := braceArrowPattern.FindStringSubmatch(code); m != nil {
+		return m[1], "func", true
+	}
+	if m := braceKeywordPattern.FindStringSubmatch(code); m != nil {
+		kind := m[1]
+		if kind == "fn" || kind == "function" {
+			kind = "func"
+		}
+		name := m[2]
+		if name == "" {
+			name = "(anonymous)"
+			if kind == "func" {
+				// Anonymous functions are part of their enclosing declaration
+				return "", "", false
+			}
+		}
+		return name, kind, true
+	}
+	if m := braceMethodPattern.FindStringSubmatch(code); m != nil && !braceControlKeywords[m[1]] {
+		// Calls such as `foo(bar);` or `x = foo(bar) {` are not declarations
+		if strings.Contains(trimmed, "=") && !strings.Contains(trimmed, "==") {
+			return "", "", false
+		}
+		if !strings.Contains(strings.TrimSpace(strings.SplitN(trimmed, "(", 2)[0]), " ") {
+			// Declarations have at least a return type or modifier before the name
+			return "", "", false
+		}
+		return m[1], "func", true
+	}
+	return "", "", false
+}
+
+// braceDepths returns the brace depth at the end of each line
+func braceDepths(lines []string) []int {
+	depths := make([]int, len(lines))
+	depth := 0
+	inBlockComment := false
+	for i, line := range lines {
+		code := line
+		if inBlockComment {
+			if idx := strings.Index(code, "*/"); idx >= 0 {
+				code = code[idx+2:]
+				inBlockComment = false
+			} else {
+				depths[i] = depth
+				continue
+			}
+		}
+		if idx := strings.Index(code, "/*"); idx >= 0 && !strings.Contains(code[idx:], "*/") {
+			code = code[:idx]
+			inBlockComment = true
+		}
+		code = stripCode(code)
+		depth += strings.Count(code, "{") - strings.Count(code, "}")
+		depths[i] = depth
+	}
+	return depths
+}
+
+// findClosingBrace returns the line closing the block opened on openLine
+func findClosingBrace(depths []int, openLine int) int {
+	before := 0
+	if openLine > 0 {
+		before = depths[openLine-1]
+	}
+	if depths[openLine] <= before {
+		// Block opens and closes on the same line
+		return openLine
+	}
+	for j := openLine + 1; j < len(depths); j++ {
+		if depths[j] <= before {
+			return j
+		}
+	}
+	return -1
+}
+
+// stripCode removes string literals and line comments from a line of code
+func stripCode(line string) string {
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return sb.String()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const outlineGoSource = `package demo
+
+type Server struct {
+	name string
+}
+
+func (s *Server) Start() error {
+	if s.name == "" {
+		return nil
+	}
+	return nil
+}
+
+func helper(x int) int {
+	return x * 2
+}
+`
+
+func TestExtractOutline_Go(t *testing.T) {
+	symbols := ExtractOutline("demo.go", []byte(outlineGoSource))
+	require.Len(t, symbols, 3)
+
+	assert.Equal(t, OutlineSymbol{Name: "Server", Kind: "type", StartLine: 3, EndLine: 5}, symbols[0])
+	assert.Equal(t, OutlineSymbol{Name: "(*Server) Start", Kind: "method", StartLine: 7, EndLine: 12}, symbols[1])
+	assert.Equal(t, OutlineSymbol{Name: "helper", Kind: "func", StartLine: 14, EndLine: 16}, symbols[2])
+}
+
+func TestExtractOutline_Python(t *testing.T) {
+	src := `class Greeter:
+    def hello(self):
+        return "hi"
+
+    def bye(self):
+        return "bye"
+
+
+def main():
+    Greeter().hello()
+`
+	symbols := ExtractOutline("app.py", []byte(src))
+	require.Len(t, symbols, 4)
+
+	assert.Equal(t, OutlineSymbol{Name: "Greeter", Kind: "class", StartLine: 1, EndLine: 6}, symbols[0])
+	assert.Equal(t, OutlineSymbol{Name: "hello", Kind: "method", StartLine: 2, EndLine: 3}, symbols[1])
+	assert.Equal(t, OutlineSymbol{Name: "bye", Kind: "method", StartLine: 5, EndLine: 6}, symbols[2])
+	assert.Equal(t, OutlineSymbol{Name: "main", Kind: "func", StartLine: 9, EndLine: 10}, symbols[3])
+}
+
+func TestExtractOutline_BraceLanguages(t *testing.T) {
+	src := `import x from "y";
+
+export function add(a, b) {
+  const s = "}";
+  return a + b;
+}
+
+class Counter {
+  increment() {
+    this.n++;
+  }
+}
+
+const double = (n) => {
+  return n * 2;
+};
+`
+	symbols := ExtractOutline("math.js", []byte(src))
+	names := make(map[string]OutlineSymbol)
+	for _, s := range symbols {
+		names[s.Name] = s
+	}
+
+	require.Contains(t, names, "add")
+	assert.Equal(t, 3, names["add"].StartLine)
+	assert.Equal(t, 6, names["add"].EndLine)
+
+	require.Contains(t, names, "Counter")
+	assert.Equal(t, 8, names["Counter"].StartLine)
+	assert.Equal(t, 12, names["Counter"].EndLine)
+
+	require.Contains(t, names, "double")
+	assert.Equal(t, 14, names["double"].StartLine)
+	assert.Equal(t, 16, names["double"].EndLine)
+}
+
+func TestExtractOutline_UnsupportedLanguage(t *testing.T) {
+	assert.Empty(t, ExtractOutline("notes.txt", []byte("func foo() {\n}\n")))
+}
+
+func TestEnclosingSymbol(t *testing.T) {
+	symbols := []OutlineSymbol{
+		{Name: "Outer", Kind: "class", StartLine: 1, EndLine: 20},
+		{Name: "inner", Kind: "method", StartLine: 5, EndLine: 10},
+	}
+
+	s, ok := EnclosingSymbol(symbols, 7)
+	require.True(t, ok)
+	assert.Equal(t, "inner", s.Name)
+
+	s, ok = EnclosingSymbol(symbols, 15)
+	require.True(t, ok)
+	assert.Equal(t, "Outer", s.Name)
+
+	_, ok = EnclosingSymbol(symbols, 25)
+	assert.False(t, ok)
+}
+
+func TestFileOutlineTool_Execute(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "demo.go"), []byte(outlineGoSource), 0644))
+
+	tool := NewFileOutlineTool(tmpDir)
+	assert.Equal(t, "file_outline", tool.Name())
+
+	result, err := tool.Execute(context.Background(), &FileOutlineParams{FilePath: "demo.go"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "L7-12  method (*Server) Start")
+	assert.Contains(t, result, "L14-16  func helper")
+
+	_, err = tool.Execute(context.Background(), &FileOutlineParams{FilePath: "missing.go"})
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "file not found"))
+}
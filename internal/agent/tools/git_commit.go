@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/huimingz/gitbuddy-go/internal/git"
@@ -32,7 +33,9 @@ func (t *GitCommitTool) Name() string {
 // Description returns the tool description
 func (t *GitCommitTool) Description() string {
 	return `Execute git commit with the provided message.
-Use this after generating a commit message to actually commit the staged changes.`
+Use this after generating a commit message to actually commit the staged changes.
+The hooks of the repository (e.g. husky or pre-commit) run as part of the commit; when one rejects it,
+the error includes what the hook printed so the problem can be fixed before committing again.`
 }
 
 // Execute runs git commit with the provided message
@@ -42,6 +45,10 @@ func (t *GitCommitTool) Execute(ctx context.Context, params *GitCommitParams) (s
 	}
 
 	err := t.executor.Commit(ctx, params.Message)
+	var hookErr *git.HookError
+	if errors.As(err, &hookErr) {
+		return "", fmt.Errorf("git commit failed: %w\nHook output:\n%s", err, hookErr.Output)
+	}
 	if err != nil {
 		return "", fmt.Errorf("git commit failed: %w", err)
 	}
@@ -2,18 +2,36 @@ package tools
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/huimingz/gitbuddy-go/internal/git"
 )
 
+// DefaultMaxDiffLines is the size of the staged diff above which
+// git_diff_cached returns per-file statistics instead of the diff
+const DefaultMaxDiffLines = 3000
+
+// GitDiffCachedParams represents the parameters for the git_diff_cached tool
+type GitDiffCachedParams struct {
+	// Path limits the diff to a file, or to the files in a directory
+	Path string `json:"path,omitempty" jsonschema:"description=Show only the diff of this file or directory"`
+}
+
 // GitDiffCachedTool is a tool for getting staged diff
 type GitDiffCachedTool struct {
 	executor git.Executor
+	maxLines int
 }
 
-// NewGitDiffCachedTool creates a new GitDiffCachedTool
-func NewGitDiffCachedTool(executor git.Executor) *GitDiffCachedTool {
-	return &GitDiffCachedTool{executor: executor}
+// NewGitDiffCachedTool creates a new GitDiffCachedTool. Staged diffs longer
+// than maxLines are returned as per-file statistics (0 = DefaultMaxDiffLines,
+// negative = no limit).
+func NewGitDiffCachedTool(executor git.Executor, maxLines int) *GitDiffCachedTool {
+	if maxLines == 0 {
+		maxLines = DefaultMaxDiffLines
+	}
+	return &GitDiffCachedTool{executor: executor, maxLines: maxLines}
 }
 
 // Name returns the tool name
@@ -25,7 +43,10 @@ func (t *GitDiffCachedTool) Name() string {
 func (t *GitDiffCachedTool) Description() string {
 	return `Get the diff of staged changes (git diff --cached).
 This shows the changes that have been added to the staging area and are ready to be committed.
-Use this tool to understand what changes will be included in the next commit.`
+Use this tool to understand what changes will be included in the next commit.
+When the diff is too large, only the changed files with their added and deleted line counts are returned; then call it again with path to get the diff of one file or directory at a time.
+Parameters:
+- path: Show only the diff of this file or directory (optional)`
 }
 
 // Execute runs the tool and returns the diff
@@ -36,8 +57,126 @@ func (t *GitDiffCachedTool) Execute(ctx context.Context, params interface{}) (st
 	}
 
 	if diff == "" {
-		return "No staged changes found. Please stage some changes using 'git add' first.", nil
+		return message("no_staged_changes"), nil
+	}
+
+	path := ""
+	if p, ok := params.(*GitDiffCachedParams); ok && p != nil {
+		path = p.Path
+	}
+	return limitDiff(ctx, t.executor, diff, path, t.maxLines, diffSource{tool: t.Name(), changes: "staged changes"})
+}
+
+// diffSource describes where a diff returned by a diff tool comes from
+type diffSource struct {
+	tool    string // Tool to call again with path for a part of the diff
+	changes string // The changes the diff shows, e.g. "staged changes"
+}
+
+// limitDiff returns diff, or the part of it changing path, as a diff tool
+// result: diffs longer than maxLines (negative = no limit) are listed per file
+// and the diff of a path is cut to maxLines. executor, when not nil, sizes
+// the files replaced by summary lines.
+func limitDiff(ctx context.Context, executor git.Executor, diff, path string, maxLines int, source diffSource) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path != "" {
+		return pathDiff(ctx, executor, diff, path, maxLines, source)
+	}
+
+	summary := summarizeDiff(ctx, executor, diff)
+	if maxLines < 0 || countLines(summary) <= maxLines {
+		return summary, nil
+	}
+	return diffOverview(diff, countLines(summary), maxLines, source), nil
+}
+
+// pathDiff returns the part of diff changing path or the files below it,
+// cut to the line limit
+func pathDiff(ctx context.Context, executor git.Executor, diff, path string, maxLines int, source diffSource) (string, error) {
+	var selected strings.Builder
+	var changed []string
+	for _, section := range splitDiffSections(diff) {
+		file := diffSectionPath(section)
+		changed = append(changed, file)
+		if file == path || strings.HasPrefix(file, path+"/") {
+			selected.WriteString(section)
+		}
 	}
+	if selected.Len() == 0 {
+		return "", fmt.Errorf("no %s in %s (changed files: %s)", source.changes, path, strings.Join(changed, ", "))
+	}
+
+	summary := summarizeDiff(ctx, executor, strings.TrimSuffix(selected.String(), "\n"))
+	if maxLines < 0 || countLines(summary) <= maxLines {
+		return summary, nil
+	}
+	lines := strings.Split(summary, "\n")
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n\nNote: the diff of %s has %d lines; only the first %d are shown. "+
+		"Call %s with a narrower path, or use read_file to read the rest of the changed file.", path, len(lines), maxLines, source.tool), nil
+}
+
+// diffOverview lists the files of a diff too large to return with their
+// added and deleted line counts, like git diff --numstat
+func diffOverview(diff string, lines, maxLines int, source diffSource) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The diff of the %s has %d lines, more than the limit of %d, so only the changed files are listed "+
+		"(added, deleted lines and path, like git diff --numstat).\n\n", source.changes, lines, maxLines)
+
+	totalAdded, totalDeleted := 0, 0
+	sections := splitDiffSections(diff)
+	for _, section := range sections {
+		added, deleted, binary := diffSectionStats(section)
+		totalAdded += added
+		totalDeleted += deleted
+		if binary {
+			fmt.Fprintf(&b, "-\t-\t%s\n", diffSectionPath(section))
+			continue
+		}
+		fmt.Fprintf(&b, "%d\t%d\t%s\n", added, deleted, diffSectionPath(section))
+	}
+	fmt.Fprintf(&b, "\n%d file(s) changed, %d insertion(s), %d deletion(s)\n\n", len(sections), totalAdded, totalDeleted)
+	fmt.Fprintf(&b, "Call %s with path set to a file or directory to see its diff, starting with the files that matter most "+
+		"for the task. Skip generated and vendored files; their path and counts are usually enough.", source.tool)
+	return b.String()
+}
+
+// diffSectionPath returns the path of the file a diff section changes
+func diffSectionPath(section string) string {
+	if files := git.DiffFiles(section); len(files) > 0 && files[0].Path != "" {
+		return files[0].Path
+	}
+	header, _, _ := strings.Cut(section, "\n")
+	return strings.TrimPrefix(header, "diff --git ")
+}
+
+// diffSectionStats counts the added and deleted lines of a diff section and
+// reports whether it changes a binary file
+func diffSectionStats(section string) (added, deleted int, binary bool) {
+	inHunk := false
+	for _, line := range strings.Split(section, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			if line == "GIT binary patch" || (strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ")) {
+				binary = true
+			}
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			deleted++
+		}
+	}
+	return added, deleted, binary
+}
+
+// countLines returns the number of lines of s
+func countLines(s string) int {
+	return strings.Count(s, "\n") + 1
+}
 
-	return diff, nil
+// IsNoStagedChanges reports whether a git_diff_cached result says nothing is
+// staged, in any tool language
+func IsNoStagedChanges(result string) bool {
+	return result == message("no_staged_changes")
 }
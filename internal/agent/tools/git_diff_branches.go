@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/huimingz/gitbuddy-go/internal/git"
 )
@@ -13,6 +14,12 @@ type GitDiffBranchesParams struct {
 	Base string `json:"base" jsonschema:"description=Base branch to compare from (e.g., main, develop)"`
 	// Head is the head branch to compare to (defaults to current branch)
 	Head string `json:"head,omitempty" jsonschema:"description=Head branch to compare to (defaults to HEAD)"`
+	// Mode is "three-dot" (default, diff against the merge base) or "two-dot" (diff the branch tips)
+	Mode string `json:"mode,omitempty" jsonschema:"description=three-dot (default) compares head with its merge base; two-dot compares the branch tips"`
+	// StatOnly returns only the diffstat
+	StatOnly bool `json:"stat_only,omitempty" jsonschema:"description=Only return the per-file summary of changes (--stat)"`
+	// Paths limits the diff to the given files or directories
+	Paths []string `json:"paths,omitempty" jsonschema:"description=Limit the diff to these files or directories"`
 }
 
 // GitDiffBranchesTool is a tool for getting diff between two branches
@@ -32,12 +39,15 @@ func (t *GitDiffBranchesTool) Name() string {
 
 // Description returns the tool description
 func (t *GitDiffBranchesTool) Description() string {
-	return `Get the diff between two branches (git diff base..head).
-This shows all code changes between the base branch and head branch.
-Useful for understanding what changes would be included in a pull request.
+	return `Get the diff between two branches (git diff base...head).
+By default head is compared with its merge base, so only the changes made on head are shown,
+not upstream changes that were merged into base afterwards. This is what a pull request contains.
 Parameters:
 - base: The base branch to compare from (required, e.g., "main")
-- head: The head branch to compare to (optional, defaults to HEAD)`
+- head: The head branch to compare to (optional, defaults to HEAD)
+- mode: "three-dot" (default, base...head) or "two-dot" (base..head, compares the branch tips directly)
+- stat_only: Only return the per-file summary of changes; use it first on large branches, then fetch the diff of interesting paths
+- paths: Limit the diff to these files or directories (e.g., ["internal/api", "go.mod"])`
 }
 
 // Execute runs the tool and returns the diff
@@ -56,12 +66,24 @@ func (t *GitDiffBranchesTool) Execute(ctx context.Context, params interface{}) (
 		head = "HEAD"
 	}
 
-	diff, err := t.executor.DiffBranches(ctx, p.Base, head)
+	opts := git.DiffOptions{Stat: p.StatOnly, Paths: p.Paths}
+	switch p.Mode {
+	case "", "three-dot":
+	case "two-dot":
+		opts.TwoDot = true
+	default:
+		return "", fmt.Errorf("invalid mode: %s (valid: three-dot, two-dot)", p.Mode)
+	}
+
+	diff, err := t.executor.DiffBranches(ctx, p.Base, head, opts)
 	if err != nil {
 		return "", err
 	}
 
 	if diff == "" {
+		if len(p.Paths) > 0 {
+			return fmt.Sprintf("No differences found between %s and %s in %s", p.Base, head, strings.Join(p.Paths, ", ")), nil
+		}
 		return fmt.Sprintf("No differences found between %s and %s", p.Base, head), nil
 	}
 
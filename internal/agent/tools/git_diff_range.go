@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// GitDiffRangeParams represents the parameters for the git_diff_range tool
+type GitDiffRangeParams struct {
+	// Path limits the diff to a file, or to the files in a directory
+	Path string `json:"path,omitempty" jsonschema:"description=Show only the diff of this file or directory"`
+}
+
+// GitDiffRangeTool returns the diff of a commit range or a single commit
+type GitDiffRangeTool struct {
+	workDir   string
+	revisions string
+	maxLines  int
+}
+
+// NewGitDiffRangeTool creates a new GitDiffRangeTool for revisions, a range
+// such as main..HEAD or a single commit (see git.DiffRange). Diffs longer
+// than maxLines are returned as per-file statistics (0 = DefaultMaxDiffLines,
+// negative = no limit).
+func NewGitDiffRangeTool(workDir, revisions string, maxLines int) *GitDiffRangeTool {
+	if maxLines == 0 {
+		maxLines = DefaultMaxDiffLines
+	}
+	return &GitDiffRangeTool{workDir: workDir, revisions: revisions, maxLines: maxLines}
+}
+
+// Name returns the tool name
+func (t *GitDiffRangeTool) Name() string {
+	return "git_diff_range"
+}
+
+// Description returns the tool description
+func (t *GitDiffRangeTool) Description() string {
+	return fmt.Sprintf(`Get the diff of %s.
+A range such as main..HEAD shows the changes of its commits; a single commit is compared with its first parent.
+When the diff is too large, only the changed files with their added and deleted line counts are returned; then call it again with path to get the diff of one file or directory at a time.
+Parameters:
+- path: Show only the diff of this file or directory (optional)`, t.changes())
+}
+
+// changes describes the changes of the revisions
+func (t *GitDiffRangeTool) changes() string {
+	return "changes of " + t.revisions
+}
+
+// Execute runs the tool and returns the diff
+func (t *GitDiffRangeTool) Execute(ctx context.Context, params interface{}) (string, error) {
+	diff, err := git.DiffRange(ctx, t.workDir, t.revisions)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return fmt.Sprintf("No changes found in %s.", t.revisions), nil
+	}
+
+	path := ""
+	if p, ok := params.(*GitDiffRangeParams); ok && p != nil {
+		path = p.Path
+	}
+	return limitDiff(ctx, nil, diff, path, t.maxLines, diffSource{tool: t.Name(), changes: t.changes()})
+}
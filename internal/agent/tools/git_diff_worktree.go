@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// GitDiffWorktreeParams represents the parameters for the git_diff_worktree tool
+type GitDiffWorktreeParams struct {
+	// Path limits the diff to a file, or to the files in a directory
+	Path string `json:"path,omitempty" jsonschema:"description=Show only the diff of this file or directory"`
+}
+
+// GitDiffWorktreeTool returns the diff of the unstaged changes
+type GitDiffWorktreeTool struct {
+	workDir  string
+	maxLines int
+}
+
+// NewGitDiffWorktreeTool creates a new GitDiffWorktreeTool. Diffs longer than
+// maxLines are returned as per-file statistics (0 = DefaultMaxDiffLines,
+// negative = no limit).
+func NewGitDiffWorktreeTool(workDir string, maxLines int) *GitDiffWorktreeTool {
+	if maxLines == 0 {
+		maxLines = DefaultMaxDiffLines
+	}
+	return &GitDiffWorktreeTool{workDir: workDir, maxLines: maxLines}
+}
+
+// Name returns the tool name
+func (t *GitDiffWorktreeTool) Name() string {
+	return "git_diff_worktree"
+}
+
+// Description returns the tool description
+func (t *GitDiffWorktreeTool) Description() string {
+	return `Get the diff of the unstaged changes in the working tree (git diff).
+This shows changes to tracked files that have not been staged yet; untracked files are listed by git_status.
+When the diff is too large, only the changed files with their added and deleted line counts are returned; then call it again with path to get the diff of one file or directory at a time.
+Parameters:
+- path: Show only the diff of this file or directory (optional)`
+}
+
+// Execute runs the tool and returns the diff
+func (t *GitDiffWorktreeTool) Execute(ctx context.Context, params interface{}) (string, error) {
+	diff, err := git.DiffWorktree(ctx, t.workDir)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "No unstaged changes found.", nil
+	}
+
+	path := ""
+	if p, ok := params.(*GitDiffWorktreeParams); ok && p != nil {
+		path = p.Path
+	}
+	return limitDiff(ctx, nil, diff, path, t.maxLines, diffSource{tool: t.Name(), changes: "unstaged changes"})
+}
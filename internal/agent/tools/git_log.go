@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/huimingz/gitbuddy-go/internal/git"
 )
@@ -10,6 +12,15 @@ import (
 type GitLogParams struct {
 	// Count is the number of commits to retrieve (default: 5)
 	Count int `json:"count,omitempty" jsonschema:"description=Number of commits to retrieve (default 5)"`
+	// Format is "text" (default) or "json" for structured output with per-file stats
+	Format string `json:"format,omitempty" jsonschema:"description=Output format: text (default) or json with changed files and insert/delete counts"`
+}
+
+// GitLogJSON is the structured output of the git log tools
+type GitLogJSON struct {
+	Commits    []git.LogCommit `json:"commits"`
+	Insertions int             `json:"insertions"`
+	Deletions  int             `json:"deletions"`
 }
 
 // GitLogTool is a tool for getting git log
@@ -32,7 +43,8 @@ func (t *GitLogTool) Description() string {
 	return `Get the recent commit history (git log).
 This shows the recent commits in the repository, useful for understanding the project context and recent changes.
 Parameters:
-- count: Number of commits to retrieve (default: 5)`
+- count: Number of commits to retrieve (default: 5)
+- format: "text" (default) or "json" for structured commits with changed files and insert/delete counts, easier to aggregate than parsing text`
 }
 
 // Execute runs the tool and returns the log
@@ -42,10 +54,20 @@ func (t *GitLogTool) Execute(ctx context.Context, params interface{}) (string, e
 	}
 
 	// Parse params if provided
+	format := ""
 	if p, ok := params.(*GitLogParams); ok && p != nil {
 		if p.Count > 0 {
 			opts.Count = p.Count
 		}
+		format = p.Format
+	}
+
+	switch format {
+	case "", "text":
+	case "json":
+		return structuredLog(ctx, t.executor, opts)
+	default:
+		return "", fmt.Errorf("invalid format: %s (valid: text, json)", format)
 	}
 
 	log, err := t.executor.Log(ctx, opts)
@@ -54,8 +76,34 @@ func (t *GitLogTool) Execute(ctx context.Context, params interface{}) (string, e
 	}
 
 	if log == "" {
-		return "No commits found in this repository.", nil
+		return message("no_commits"), nil
 	}
 
 	return log, nil
 }
+
+// structuredLog runs the log with per-file stats and returns it as GitLogJSON
+func structuredLog(ctx context.Context, executor git.Executor, opts git.LogOptions) (string, error) {
+	opts.Format = git.StructuredLogFormat
+	opts.NumStat = true
+
+	output, err := executor.Log(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	result := GitLogJSON{Commits: git.ParseStructuredLog(output)}
+	if result.Commits == nil {
+		result.Commits = []git.LogCommit{}
+	}
+	for _, commit := range result.Commits {
+		result.Insertions += commit.Insertions
+		result.Deletions += commit.Deletions
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal git log: %w", err)
+	}
+	return string(data), nil
+}
@@ -15,6 +15,8 @@ type GitLogDateParams struct {
 	Until string `json:"until,omitempty" jsonschema:"description=End date in YYYY-MM-DD format (optional, defaults to today)"`
 	// Author is the author name filter (optional)
 	Author string `json:"author,omitempty" jsonschema:"description=Filter by author name (optional)"`
+	// Format is "text" (default) or "json" for structured output with per-file stats
+	Format string `json:"format,omitempty" jsonschema:"description=Output format: text (default) or json with changed files and insert/delete counts"`
 }
 
 // GitLogDateTool is a tool for getting commit log within a date range
@@ -40,7 +42,8 @@ Useful for generating development reports for a specific period.
 Parameters:
 - since: Start date in YYYY-MM-DD format (required)
 - until: End date in YYYY-MM-DD format (optional, defaults to today)
-- author: Filter by author name (optional)`
+- author: Filter by author name (optional)
+- format: "text" (default) or "json" for structured commits with changed files and insert/delete counts (useful to aggregate activity per area)`
 }
 
 // Execute runs the tool and returns the log
@@ -58,7 +61,15 @@ func (t *GitLogDateTool) Execute(ctx context.Context, params interface{}) (strin
 		Since:  p.Since,
 		Until:  p.Until,
 		Author: p.Author,
-		Format: "%h|%s|%ad",
+	}
+
+	switch p.Format {
+	case "", "text":
+		opts.Format = "%h|%s|%ad"
+	case "json":
+		return structuredLog(ctx, t.executor, opts)
+	default:
+		return "", fmt.Errorf("invalid format: %s (valid: text, json)", p.Format)
 	}
 
 	log, err := t.executor.Log(ctx, opts)
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// GitMergeTreeParams represents the parameters for the git_merge_tree tool
+type GitMergeTreeParams struct {
+	// Base is the branch to merge into
+	Base string `json:"base" jsonschema:"description=Branch to merge into (e.g., origin/main)"`
+	// Head is the branch to merge (defaults to HEAD)
+	Head string `json:"head,omitempty" jsonschema:"description=Branch to merge (defaults to HEAD)"`
+}
+
+// mergeTreeSimulator is implemented by executors that can simulate a merge;
+// only git supports it
+type mergeTreeSimulator interface {
+	MergeTree(ctx context.Context, base, head string) (*git.MergeTreeResult, error)
+}
+
+// GitMergeTreeTool predicts merge conflicts without touching the working tree
+type GitMergeTreeTool struct {
+	executor git.Executor
+}
+
+// NewGitMergeTreeTool creates a new GitMergeTreeTool
+func NewGitMergeTreeTool(executor git.Executor) *GitMergeTreeTool {
+	return &GitMergeTreeTool{executor: executor}
+}
+
+// Name returns the tool name
+func (t *GitMergeTreeTool) Name() string {
+	return "git_merge_tree"
+}
+
+// Description returns the tool description
+func (t *GitMergeTreeTool) Description() string {
+	return `Simulate merging two branches (git merge-tree --write-tree) and list the files that would conflict.
+The index and working tree are not modified. Requires git 2.38 or newer.
+Parameters:
+- base: Branch to merge into (required, e.g., "origin/main")
+- head: Branch to merge (optional, defaults to HEAD)`
+}
+
+// Execute runs the tool and returns the predicted conflicts
+func (t *GitMergeTreeTool) Execute(ctx context.Context, params interface{}) (string, error) {
+	p, ok := params.(*GitMergeTreeParams)
+	if !ok || p == nil {
+		return "", fmt.Errorf("invalid parameters: expected GitMergeTreeParams")
+	}
+	if p.Base == "" {
+		return "", fmt.Errorf("base branch is required")
+	}
+	simulator, ok := t.executor.(mergeTreeSimulator)
+	if !ok {
+		return "", fmt.Errorf("git_merge_tree is only supported with git")
+	}
+
+	head := p.Head
+	if head == "" {
+		head = "HEAD"
+	}
+
+	result, err := simulator.MergeTree(ctx, p.Base, head)
+	if err != nil {
+		return "", err
+	}
+	if result.Clean {
+		return fmt.Sprintf("Merging %s into %s would succeed without conflicts.", head, p.Base), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Merging %s into %s would conflict in %d file(s):\n", head, p.Base, len(result.Conflicts)))
+	for _, file := range result.Conflicts {
+		sb.WriteString(fmt.Sprintf("  %s\n", file))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// GitRevListCountParams represents the parameters for the git_rev_list_count tool
+type GitRevListCountParams struct {
+	// Base is the branch to compare against
+	Base string `json:"base" jsonschema:"description=Branch to compare against (e.g., origin/main)"`
+	// Head is the branch to measure (defaults to HEAD)
+	Head string `json:"head,omitempty" jsonschema:"description=Branch to measure (defaults to HEAD)"`
+}
+
+// revListCounter is implemented by executors that can count the commits
+// between two branches; only git supports it
+type revListCounter interface {
+	RevListCount(ctx context.Context, base, head string) (ahead, behind int, err error)
+}
+
+// GitRevListCountTool counts how far two branches have diverged
+type GitRevListCountTool struct {
+	executor git.Executor
+}
+
+// NewGitRevListCountTool creates a new GitRevListCountTool
+func NewGitRevListCountTool(executor git.Executor) *GitRevListCountTool {
+	return &GitRevListCountTool{executor: executor}
+}
+
+// Name returns the tool name
+func (t *GitRevListCountTool) Name() string {
+	return "git_rev_list_count"
+}
+
+// Description returns the tool description
+func (t *GitRevListCountTool) Description() string {
+	return `Count how many commits head is ahead of and behind base (git rev-list --left-right --count base...head).
+Useful for checking whether a branch is up to date with its upstream or the default branch.
+Parameters:
+- base: Branch to compare against (required, e.g., "origin/main")
+- head: Branch to measure (optional, defaults to HEAD)`
+}
+
+// Execute runs the tool and returns the divergence summary
+func (t *GitRevListCountTool) Execute(ctx context.Context, params interface{}) (string, error) {
+	p, ok := params.(*GitRevListCountParams)
+	if !ok || p == nil {
+		return "", fmt.Errorf("invalid parameters: expected GitRevListCountParams")
+	}
+	if p.Base == "" {
+		return "", fmt.Errorf("base branch is required")
+	}
+	counter, ok := t.executor.(revListCounter)
+	if !ok {
+		return "", fmt.Errorf("git_rev_list_count is only supported with git")
+	}
+
+	head := p.Head
+	if head == "" {
+		head = "HEAD"
+	}
+
+	ahead, behind, err := counter.RevListCount(ctx, p.Base, head)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s is %d commit(s) ahead of and %d commit(s) behind %s", head, ahead, behind, p.Base), nil
+}
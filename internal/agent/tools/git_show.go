@@ -48,7 +48,7 @@ func (t *GitShowTool) Execute(ctx context.Context, params *GitShowParams) (strin
 	}
 
 	if output == "" {
-		return "No commit found for reference: " + ref, nil
+		return message("no_commit_for_ref", ref), nil
 	}
 
 	return output, nil
@@ -2,9 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/huimingz/gitbuddy-go/internal/git"
@@ -61,7 +63,7 @@ func TestNewGitDiffCachedTool(t *testing.T) {
 	repoDir := setupTestRepo(t)
 	executor := git.NewExecutor(repoDir)
 
-	tool := NewGitDiffCachedTool(executor)
+	tool := NewGitDiffCachedTool(executor, 0)
 	assert.NotNil(t, tool)
 	assert.Equal(t, "git_diff_cached", tool.Name())
 	assert.NotEmpty(t, tool.Description())
@@ -70,7 +72,7 @@ func TestNewGitDiffCachedTool(t *testing.T) {
 func TestGitDiffCachedTool_Execute(t *testing.T) {
 	repoDir := setupTestRepo(t)
 	executor := git.NewExecutor(repoDir)
-	tool := NewGitDiffCachedTool(executor)
+	tool := NewGitDiffCachedTool(executor, 0)
 	ctx := context.Background()
 
 	t.Run("empty staging area", func(t *testing.T) {
@@ -89,6 +91,141 @@ func TestGitDiffCachedTool_Execute(t *testing.T) {
 	})
 }
 
+func TestGitDiffCachedTool_MaxLines(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,2 +1,3 @@\n" +
+		" package main\n" +
+		"-func old() {}\n" +
+		"+func a() {}\n" +
+		"+func b() {}\n" +
+		"diff --git a/pkg/util/util.go b/pkg/util/util.go\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/pkg/util/util.go\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+package util\n" +
+		"+func Helper() {}\n" +
+		"diff --git a/logo.png b/logo.png\n" +
+		"Binary files a/logo.png and b/logo.png differ"
+	ctx := context.Background()
+
+	t.Run("under the limit returns the diff", func(t *testing.T) {
+		result, err := NewGitDiffCachedTool(git.NewDiffExecutor(diff), 100).Execute(ctx, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result, "+func Helper() {}")
+	})
+
+	t.Run("over the limit lists the files", func(t *testing.T) {
+		tool := NewGitDiffCachedTool(git.NewDiffExecutor(diff), 10)
+		result, err := tool.Execute(ctx, &GitDiffCachedParams{})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "func Helper")
+		assert.Contains(t, result, "2\t1\tmain.go\n")
+		assert.Contains(t, result, "2\t0\tpkg/util/util.go\n")
+		assert.Contains(t, result, "-\t-\tlogo.png\n")
+		assert.Contains(t, result, "3 file(s) changed, 4 insertion(s), 1 deletion(s)")
+		assert.Contains(t, result, "with path")
+	})
+
+	t.Run("path returns the diff of a file or directory", func(t *testing.T) {
+		tool := NewGitDiffCachedTool(git.NewDiffExecutor(diff), 10)
+		result, err := tool.Execute(ctx, &GitDiffCachedParams{Path: "pkg/"})
+		require.NoError(t, err)
+		assert.Contains(t, result, "+func Helper() {}")
+		assert.NotContains(t, result, "func a()")
+
+		result, err = tool.Execute(ctx, &GitDiffCachedParams{Path: "main.go"})
+		require.NoError(t, err)
+		assert.Contains(t, result, "+func b() {}")
+		assert.NotContains(t, result, "Helper")
+	})
+
+	t.Run("path over the limit is cut", func(t *testing.T) {
+		tool := NewGitDiffCachedTool(git.NewDiffExecutor(diff), 5)
+		result, err := tool.Execute(ctx, &GitDiffCachedParams{Path: "main.go"})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "+func b() {}")
+		assert.Contains(t, result, "only the first 5 are shown")
+	})
+
+	t.Run("unknown path lists the staged files", func(t *testing.T) {
+		tool := NewGitDiffCachedTool(git.NewDiffExecutor(diff), 10)
+		_, err := tool.Execute(ctx, &GitDiffCachedParams{Path: "docs"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "main.go, pkg/util/util.go, logo.png")
+	})
+
+	t.Run("negative limit disables the guard", func(t *testing.T) {
+		result, err := NewGitDiffCachedTool(git.NewDiffExecutor(diff), -1).Execute(ctx, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result, "+func Helper() {}")
+	})
+}
+
+func TestGitDiffRangeTool_Execute(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	commitFile(t, repoDir, "feat: add main")
+	require.NoError(t, os.Mkdir(filepath.Join(repoDir, "pkg"), 0755))
+	createAndStageFile(t, repoDir, "pkg/util.go", "package pkg\n")
+	createAndStageFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+	commitFile(t, repoDir, "feat: add util")
+
+	tool := NewGitDiffRangeTool(repoDir, "HEAD~1..HEAD", 0)
+	assert.Equal(t, "git_diff_range", tool.Name())
+	assert.Contains(t, tool.Description(), "changes of HEAD~1..HEAD")
+
+	result, err := tool.Execute(ctx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result, "+func main() {}")
+	assert.Contains(t, result, "+package pkg")
+
+	result, err = NewGitDiffRangeTool(repoDir, "HEAD~1..HEAD", 1).Execute(ctx, &GitDiffRangeParams{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "2\t0\tmain.go\n")
+	assert.Contains(t, result, "Call git_diff_range with path")
+
+	result, err = tool.Execute(ctx, &GitDiffRangeParams{Path: "pkg"})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "main.go")
+
+	result, err = NewGitDiffRangeTool(repoDir, "HEAD~1", 0).Execute(ctx, nil)
+	require.NoError(t, err)
+	assert.Contains(t, result, "+package main", "a commit is compared with its parent")
+
+	result, err = NewGitDiffRangeTool(repoDir, "HEAD..HEAD", 0).Execute(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No changes found in HEAD..HEAD.", result)
+}
+
+func TestGitDiffWorktreeTool_Execute(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	commitFile(t, repoDir, "feat: add main")
+	tool := NewGitDiffWorktreeTool(repoDir, 0)
+	assert.Equal(t, "git_diff_worktree", tool.Name())
+
+	result, err := tool.Execute(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No unstaged changes found.", result)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	createAndStageFile(t, repoDir, "staged.go", "package main\n")
+	result, err = tool.Execute(ctx, &GitDiffWorktreeParams{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "+func main() {}")
+	assert.NotContains(t, result, "staged.go")
+
+	_, err = tool.Execute(ctx, &GitDiffWorktreeParams{Path: "docs"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no unstaged changes in docs")
+}
+
 func TestNewGitStatusTool(t *testing.T) {
 	repoDir := setupTestRepo(t)
 	executor := git.NewExecutor(repoDir)
@@ -157,6 +294,24 @@ func TestGitLogTool_Execute(t *testing.T) {
 		assert.Contains(t, result, "bug fix")
 		assert.NotContains(t, result, "first feature")
 	})
+
+	t.Run("json format", func(t *testing.T) {
+		result, err := tool.Execute(ctx, &GitLogParams{Count: 5, Format: "json"})
+		require.NoError(t, err)
+
+		var log GitLogJSON
+		require.NoError(t, json.Unmarshal([]byte(result), &log))
+		require.Len(t, log.Commits, 2)
+		assert.Equal(t, "fix: bug fix", log.Commits[0].Subject)
+		assert.Equal(t, "Test User", log.Commits[0].Author)
+		assert.Equal(t, []git.LogFileStat{{Path: "second.txt", Insertions: 1}}, log.Commits[0].Files)
+		assert.Equal(t, 2, log.Insertions)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := tool.Execute(ctx, &GitLogParams{Format: "xml"})
+		assert.Error(t, err)
+	})
 }
 
 func TestNewSubmitCommitTool(t *testing.T) {
@@ -362,6 +517,22 @@ func TestGitCommitTool_Execute(t *testing.T) {
 		assert.Error(t, err) // Git will fail if nothing to commit
 	})
 
+	t.Run("rejected by a hook", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("hook scripts need sh")
+		}
+		repoDir := setupTestRepo(t)
+		hook := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+		require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0755))
+		require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\necho 'main.go is not formatted'\nexit 1\n"), 0755))
+		createAndStageFile(t, repoDir, "main.go", "package main\n")
+
+		_, err := NewGitCommitTool(git.NewExecutor(repoDir)).Execute(context.Background(), &GitCommitParams{Message: "feat: add main"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the pre-commit hook rejected the commit")
+		assert.Contains(t, err.Error(), "Hook output:\nmain.go is not formatted")
+	})
+
 	t.Run("multiline commit message", func(t *testing.T) {
 		repoDir := setupTestRepo(t)
 		executor := git.NewExecutor(repoDir)
@@ -484,3 +655,68 @@ func TestGitBranchTool_Execute(t *testing.T) {
 		assert.Contains(t, result, "feature-test")
 	})
 }
+
+func TestGitDiffBranchesTool_Execute(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	executor := git.NewExecutor(repoDir)
+	tool := NewGitDiffBranchesTool(executor)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.txt", "main")
+	commitFile(t, repoDir, "initial commit")
+	base, err := executor.CurrentBranch(ctx)
+	require.NoError(t, err)
+
+	cmd := exec.Command("git", "checkout", "-b", "feature")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+	createAndStageFile(t, repoDir, "feature.go", "package feature\n")
+	createAndStageFile(t, repoDir, "README.md", "readme\n")
+	commitFile(t, repoDir, "feat: add feature")
+
+	t.Run("stat only with paths", func(t *testing.T) {
+		result, err := tool.Execute(ctx, &GitDiffBranchesParams{Base: base, StatOnly: true, Paths: []string{"feature.go"}})
+		require.NoError(t, err)
+		assert.Contains(t, result, "feature.go")
+		assert.NotContains(t, result, "README.md")
+		assert.NotContains(t, result, "package feature")
+	})
+
+	t.Run("no changes in paths", func(t *testing.T) {
+		result, err := tool.Execute(ctx, &GitDiffBranchesParams{Base: base, Paths: []string{"main.txt"}})
+		require.NoError(t, err)
+		assert.Contains(t, result, "No differences found")
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := tool.Execute(ctx, &GitDiffBranchesParams{Base: base, Mode: "four-dot"})
+		assert.Error(t, err)
+	})
+}
+
+func TestGitRevListCountTool_Execute(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.txt", "main")
+	commitFile(t, repoDir, "initial commit")
+	base, err := git.NewExecutor(repoDir).CurrentBranch(ctx)
+	require.NoError(t, err)
+
+	cmd := exec.Command("git", "checkout", "-b", "feature")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+	createAndStageFile(t, repoDir, "feature.txt", "feature")
+	commitFile(t, repoDir, "feat: add feature")
+
+	result, err := NewGitRevListCountTool(git.NewExecutor(repoDir)).Execute(ctx, &GitRevListCountParams{Base: base})
+	require.NoError(t, err)
+	assert.Contains(t, result, "1 commit(s) ahead of and 0 commit(s) behind")
+
+	t.Run("other VCS", func(t *testing.T) {
+		_, err := NewGitRevListCountTool(git.NewJujutsuExecutor(repoDir)).Execute(ctx, &GitRevListCountParams{Base: base})
+		assert.EqualError(t, err, "git_rev_list_count is only supported with git")
+		_, err = NewGitMergeTreeTool(git.NewSaplingExecutor(repoDir)).Execute(ctx, &GitMergeTreeParams{Base: base})
+		assert.EqualError(t, err, "git_merge_tree is only supported with git")
+	})
+}
@@ -38,15 +38,18 @@ var ExcludedDirectories = map[string]bool{
 
 // GrepDirectoryParams contains parameters for grep_directory tool
 type GrepDirectoryParams struct {
-	Directory     string `json:"directory"`
-	Pattern       string `json:"pattern"`
-	Recursive     bool   `json:"recursive,omitempty"`
-	FilePattern   string `json:"file_pattern,omitempty"`
-	IgnoreCase    bool   `json:"ignore_case,omitempty"`
-	BeforeContext int    `json:"before_context,omitempty"`
-	AfterContext  int    `json:"after_context,omitempty"`
-	Context       int    `json:"context,omitempty"`
-	MaxResults    int    `json:"max_results,omitempty"`
+	Directory     string   `json:"directory"`
+	Pattern       string   `json:"pattern"`
+	Patterns      []string `json:"patterns,omitempty"`    // More patterns; a line matching any of them matches
+	NotPattern    string   `json:"not_pattern,omitempty"` // Lines matching it are left out
+	Recursive     bool     `json:"recursive,omitempty"`
+	FilePattern   string   `json:"file_pattern,omitempty"`
+	ExcludePaths  []string `json:"exclude_paths,omitempty"` // Globs of files and directories to skip
+	IgnoreCase    bool     `json:"ignore_case,omitempty"`
+	BeforeContext int      `json:"before_context,omitempty"`
+	AfterContext  int      `json:"after_context,omitempty"`
+	Context       int      `json:"context,omitempty"`
+	MaxResults    int      `json:"max_results,omitempty"`
 }
 
 // GrepDirectoryTool is a tool for searching content within a directory
@@ -89,8 +92,11 @@ This tool is useful for finding where specific code patterns appear across multi
 Parameters:
 - directory (required): Path to the directory to search
 - pattern (required): Regular expression pattern to search for (Go regexp syntax)
+- patterns (optional): More patterns to search for at once; a line matching any pattern matches (e.g., ["LoadConfig", "ParseConfig"])
+- not_pattern (optional): Leave out matching lines that also match this pattern (e.g., "^\s*//" to skip comments)
 - recursive (optional): If true, search subdirectories recursively (default: true)
 - file_pattern (optional): Glob pattern to filter files (e.g., "*.go", "*.{js,ts}"). If not specified, searches all text files
+- exclude_paths (optional): Globs of files or directories to skip. Globs without "/" match names (e.g., "*_test.go", "testdata"), others match paths relative to the directory (e.g., "internal/legacy/**")
 - ignore_case (optional): If true, perform case-insensitive search (default: false)
 - before_context (optional): Number of lines to show before each match (like grep -B)
 - after_context (optional): Number of lines to show after each match (like grep -A)
@@ -100,6 +106,7 @@ Parameters:
 Returns matching lines from all files with file paths, line numbers, and optional context.
 
 Automatically excludes common non-code directories (.git, node_modules, vendor, etc.) and binary files.
+Search for several related names in one call with patterns instead of calling this tool repeatedly.
 
 When to use this tool:
 - Looking for where a function/variable is used across the codebase
@@ -128,7 +135,8 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 	if params == nil || params.Directory == "" {
 		return "", fmt.Errorf("directory is required")
 	}
-	if params.Pattern == "" {
+	patterns := params.searchPatterns()
+	if len(patterns) == 0 {
 		return "", fmt.Errorf("pattern is required")
 	}
 
@@ -151,15 +159,25 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 		return "", fmt.Errorf("path is not a directory: %s. Use grep_file instead", params.Directory)
 	}
 
-	// Compile regex pattern
-	var re *regexp.Regexp
-	if params.IgnoreCase {
-		re, err = regexp.Compile("(?i)" + params.Pattern)
-	} else {
-		re, err = regexp.Compile(params.Pattern)
-	}
+	// Compile regex patterns
+	re, err := compileSearchPattern(patterns, params.IgnoreCase)
 	if err != nil {
-		return "", fmt.Errorf("invalid regular expression pattern: %w", err)
+		return "", err
+	}
+	var notRe *regexp.Regexp
+	if params.NotPattern != "" {
+		notRe, err = compileSearchPattern([]string{params.NotPattern}, params.IgnoreCase)
+		if err != nil {
+			return "", fmt.Errorf("invalid not_pattern: %w", err)
+		}
+	}
+	for _, pattern := range params.ExcludePaths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return "", fmt.Errorf("invalid exclude path %q: %w", pattern, err)
+		}
+	}
+	excluded := func(path string) bool {
+		return excludedPath(dirPath, path, params.ExcludePaths)
 	}
 
 	// Compile file pattern if specified
@@ -201,7 +219,7 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 	var filesScanned int
 	var filesSkipped int
 
-	err = t.walkDirectory(searchCtx, dirPath, recursive, func(path string) error {
+	err = t.walkDirectory(searchCtx, dirPath, recursive, excluded, func(path string) error {
 		// Check context cancellation
 		select {
 		case <-searchCtx.Done():
@@ -241,7 +259,7 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 		filesScanned++
 
 		// Search in file
-		fileMatches, err := t.searchFile(path, re, beforeLines, afterLines)
+		fileMatches, err := t.searchFile(path, re, notRe, beforeLines, afterLines)
 		if err != nil {
 			// Log error but continue with other files
 			return nil
@@ -262,16 +280,14 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 
 	// Build result
 	if len(matches) == 0 {
-		return fmt.Sprintf("No matches found for pattern '%s' in directory: %s\n"+
-			"Files scanned: %d, Files skipped: %d",
-			params.Pattern, params.Directory, filesScanned, filesSkipped), nil
+		return message("no_matches_directory", strings.Join(patterns, " | "), params.Directory, filesScanned, filesSkipped), nil
 	}
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Directory: %s\n", params.Directory))
-	result.WriteString(fmt.Sprintf("Pattern: %s\n", params.Pattern))
-	result.WriteString(fmt.Sprintf("Matches: %d (showing up to %d)\n", len(matches), maxResults))
-	result.WriteString(fmt.Sprintf("Files scanned: %d, Files skipped: %d\n", filesScanned, filesSkipped))
+	result.WriteString(message("directory", params.Directory))
+	result.WriteString(message("pattern", strings.Join(patterns, " | ")))
+	result.WriteString(message("matches_limited", len(matches), maxResults))
+	result.WriteString(message("files_scanned_skipped", filesScanned, filesSkipped))
 	result.WriteString("\n")
 
 	// Group matches by file
@@ -286,9 +302,9 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 
 	// Output matches
 	for file, fmatches := range fileMatches {
-		result.WriteString(fmt.Sprintf("=== File: %s (%d matches) ===\n", file, len(fmatches)))
+		result.WriteString(message("file_matches_header", file, len(fmatches)))
 		for _, match := range fmatches {
-			result.WriteString(fmt.Sprintf("\nMatch at line %d:\n", match.lineNum))
+			result.WriteString("\n" + message("match_at_line", match.lineNum))
 
 			// Output before context
 			for i, line := range match.before {
@@ -313,8 +329,9 @@ func (t *GrepDirectoryTool) Execute(ctx context.Context, params *GrepDirectoryPa
 	return result.String(), nil
 }
 
-// walkDirectory walks through directory and calls fn for each file
-func (t *GrepDirectoryTool) walkDirectory(ctx context.Context, root string, recursive bool, fn func(path string) error) error {
+// walkDirectory walks through directory and calls fn for each file that is
+// not excluded
+func (t *GrepDirectoryTool) walkDirectory(ctx context.Context, root string, recursive bool, excluded func(path string) bool, fn func(path string) error) error {
 	if recursive {
 		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
@@ -330,11 +347,14 @@ func (t *GrepDirectoryTool) walkDirectory(ctx context.Context, root string, recu
 
 			// Skip excluded directories
 			if info.IsDir() {
-				if ExcludedDirectories[info.Name()] {
+				if path != root && (ExcludedDirectories[info.Name()] || excluded(path)) {
 					return filepath.SkipDir
 				}
 				return nil
 			}
+			if excluded(path) {
+				return nil
+			}
 
 			// Process file
 			return fn(path)
@@ -355,11 +375,11 @@ func (t *GrepDirectoryTool) walkDirectory(ctx context.Context, root string, recu
 		default:
 		}
 
-		if entry.IsDir() {
+		path := filepath.Join(root, entry.Name())
+		if entry.IsDir() || excluded(path) {
 			continue
 		}
 
-		path := filepath.Join(root, entry.Name())
 		if err := fn(path); err != nil {
 			return err
 		}
@@ -369,7 +389,7 @@ func (t *GrepDirectoryTool) walkDirectory(ctx context.Context, root string, recu
 }
 
 // searchFile searches for pattern in a file and returns matches
-func (t *GrepDirectoryTool) searchFile(path string, re *regexp.Regexp, beforeLines, afterLines int) ([]matchResult, error) {
+func (t *GrepDirectoryTool) searchFile(path string, re, notRe *regexp.Regexp, beforeLines, afterLines int) ([]matchResult, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -389,7 +409,7 @@ func (t *GrepDirectoryTool) searchFile(path string, re *regexp.Regexp, beforeLin
 	// Find matches
 	var matches []matchResult
 	for i, line := range lines {
-		if re.MatchString(line) {
+		if re.MatchString(line) && (notRe == nil || !notRe.MatchString(line)) {
 			match := matchResult{
 				file:    path,
 				lineNum: i + 1,
@@ -442,6 +462,59 @@ func isBinaryFile(path string) bool {
 	return bytes.Contains(buf[:n], []byte{0})
 }
 
+// searchPatterns returns pattern followed by patterns, without empty ones
+func (p *GrepDirectoryParams) searchPatterns() []string {
+	var patterns []string
+	for _, pattern := range append([]string{p.Pattern}, p.Patterns...) {
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// compileSearchPattern compiles patterns into one expression matching any of them
+func compileSearchPattern(patterns []string, ignoreCase bool) (*regexp.Regexp, error) {
+	parts := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid regular expression pattern %q: %w", pattern, err)
+		}
+		parts[i] = "(?:" + pattern + ")"
+	}
+	combined := strings.Join(parts, "|")
+	if ignoreCase {
+		combined = "(?i)" + combined
+	}
+	return regexp.Compile(combined)
+}
+
+// excludedPath reports whether path, under root, matches an exclude glob.
+// Globs without a slash match the name, others the path relative to root.
+func excludedPath(root, path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if !strings.Contains(pattern, "/") {
+			if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+				return true
+			}
+			continue
+		}
+		if matchGlobPattern(strings.TrimPrefix(pattern, "./"), rel) {
+			return true
+		}
+	}
+	return false
+}
+
 // globToRegex converts a glob pattern to a regular expression
 func globToRegex(pattern string) string {
 	// Escape special regex characters except * and ?
@@ -322,6 +322,62 @@ func TestGrepDirectoryTool_ExcludedDirectories(t *testing.T) {
 	}
 }
 
+func TestGrepDirectoryTool_PatternsAndExclusions(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"config.go":               "func LoadConfig() {}\n// LoadConfig is deprecated\nfunc ParseConfig() {}\nfunc Other() {}\n",
+		"config_test.go":          "func TestLoadConfig() { LoadConfig() }\n",
+		"internal/legacy/old.go":  "func ParseConfig() {}\n",
+		"internal/current/new.go": "func ParseConfig() {}\n",
+		"testdata/sample.go":      "func LoadConfig() {}\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	tool := NewGrepDirectoryTool(tmpDir, DefaultMaxFileSize, DefaultMaxResults, DefaultGrepTimeout)
+	output, err := tool.Execute(context.Background(), &GrepDirectoryParams{
+		Directory:    ".",
+		Pattern:      "LoadConfig",
+		Patterns:     []string{"parseconfig"},
+		NotPattern:   `^\s*//`,
+		IgnoreCase:   true,
+		Recursive:    true,
+		ExcludePaths: []string{"*_test.go", "testdata", "internal/legacy/**"},
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, want := range []string{"func LoadConfig", "func ParseConfig", "new.go", "LoadConfig | parseconfig"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, output: %s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"deprecated", "config_test.go", "sample.go", "old.go"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("Expected output not to contain %q, output: %s", unwanted, output)
+		}
+	}
+
+	// Patterns alone are enough, and invalid ones are reported
+	if _, err := tool.Execute(context.Background(), &GrepDirectoryParams{Directory: ".", Patterns: []string{"Other"}}); err != nil {
+		t.Errorf("Execute() with patterns only error = %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), &GrepDirectoryParams{Directory: ".", Pattern: "ok", Patterns: []string{"("}}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if _, err := tool.Execute(context.Background(), &GrepDirectoryParams{Directory: ".", Pattern: "ok", NotPattern: "["}); err == nil {
+		t.Error("Expected an error for an invalid not_pattern")
+	}
+}
+
 func TestGrepDirectoryTool_BinaryFileSkip(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -152,18 +152,18 @@ func (t *GrepFileTool) Execute(ctx context.Context, params *GrepFileParams) (str
 
 	// Build result
 	if len(matches) == 0 {
-		return fmt.Sprintf("No matches found for pattern '%s' in file: %s", params.Pattern, params.FilePath), nil
+		return message("no_matches_file", params.Pattern, params.FilePath), nil
 	}
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("File: %s\n", params.FilePath))
-	result.WriteString(fmt.Sprintf("Matches: %d\n", len(matches)))
-	result.WriteString(fmt.Sprintf("Total lines: %d\n", len(lines)))
+	result.WriteString(message("file", params.FilePath))
+	result.WriteString(message("matches", len(matches)))
+	result.WriteString(message("total_lines", len(lines)))
 	result.WriteString("\n")
 
 	// Output matches with context
 	for _, matchIdx := range matches {
-		result.WriteString(fmt.Sprintf("Match at line %d:\n", matchIdx+1))
+		result.WriteString(message("match_at_line", matchIdx+1))
 
 		// Calculate context range
 		startLine := matchIdx - beforeLines
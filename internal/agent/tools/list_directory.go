@@ -96,10 +96,10 @@ func (t *ListDirectoryTool) Execute(ctx context.Context, params *ListDirectoryPa
 	}
 
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Directory: %s\n", params.Path))
-	result.WriteString(fmt.Sprintf("Recursive: %v", params.Recursive))
+	result.WriteString(message("directory", params.Path))
+	result.WriteString(message("recursive", params.Recursive))
 	if params.Recursive {
-		result.WriteString(fmt.Sprintf(" (max depth: %d)", maxDepth))
+		result.WriteString(message("max_depth", maxDepth))
 	}
 	result.WriteString("\n\n")
 
@@ -170,7 +170,7 @@ func (t *ListDirectoryTool) listSingle(dirPath string, showHidden bool, result *
 
 	// Output directories first
 	if len(dirs) > 0 {
-		result.WriteString("Directories:\n")
+		result.WriteString(message("directories"))
 		for _, dir := range dirs {
 			result.WriteString(fmt.Sprintf("  %s/\n", dir.Name))
 		}
@@ -179,7 +179,7 @@ func (t *ListDirectoryTool) listSingle(dirPath string, showHidden bool, result *
 
 	// Output files
 	if len(files) > 0 {
-		result.WriteString("Files:\n")
+		result.WriteString(message("files"))
 		for _, file := range files {
 			sizeStr := formatSize(file.Size)
 			result.WriteString(fmt.Sprintf("  %s (%s)\n", file.Name, sizeStr))
@@ -187,7 +187,7 @@ func (t *ListDirectoryTool) listSingle(dirPath string, showHidden bool, result *
 	}
 
 	if len(dirs) == 0 && len(files) == 0 {
-		result.WriteString("(empty directory)\n")
+		result.WriteString(message("empty_directory"))
 	}
 
 	return nil
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -20,6 +22,28 @@ type ListFilesParams struct {
 	Path        string   `json:"path"`
 	ExcludeDirs []string `json:"exclude_dirs,omitempty"`
 	MaxResults  int      `json:"max_results,omitempty"`
+
+	// Metadata filters
+	ModifiedSince string `json:"modified_since,omitempty"` // e.g. "2 days", "3h", "2024-01-15"
+	LargerThan    string `json:"larger_than,omitempty"`    // e.g. "1MB", "500KB"
+	SmallerThan   string `json:"smaller_than,omitempty"`
+	ContentType   string `json:"content_type,omitempty"` // text or binary
+	SortBy        string `json:"sort_by,omitempty"`      // name (default), mtime (newest first) or size (largest first)
+}
+
+// fileMatch is a matched file with its metadata
+type fileMatch struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// listFilesFilter holds the parsed metadata filters
+type listFilesFilter struct {
+	modifiedSince time.Time
+	largerThan    int64
+	smallerThan   int64
+	contentType   string
 }
 
 // ListFilesTool is a tool for finding files matching a glob pattern
@@ -57,8 +81,12 @@ Parameters:
 - path (required): Root directory to start searching from
 - exclude_dirs (optional): List of directory names to exclude from search (e.g., ["node_modules", "vendor"])
 - max_results (optional): Maximum number of files to return (default: 100)
+- modified_since (optional): Only files modified within a period or since a date (e.g., "2 days", "3h", "1 week", "2024-01-15")
+- larger_than / smaller_than (optional): Only files above/below a size (e.g., "1MB", "500KB", "100B")
+- content_type (optional): "text" or "binary"
+- sort_by (optional): "name" (default), "mtime" (newest first) or "size" (largest first)
 
-Returns a list of file paths matching the pattern, relative to the search path.
+Returns the matching file paths relative to the search path, with their size and modification time.
 
 Automatically excludes common non-code directories (.git, node_modules, vendor, etc.) unless explicitly included.
 
@@ -67,6 +95,7 @@ When to use this tool:
 - Locating test files (e.g., *_test.go)
 - Finding files by naming pattern
 - Getting a list of files before reading them
+- Finding recently changed files (modified_since + sort_by: "mtime") or suspiciously large files (larger_than)
 
 When NOT to use this tool:
 - Exploring directory structure → use list_directory instead
@@ -113,6 +142,19 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 		excludeDirs[dir] = true
 	}
 
+	filter, err := parseListFilesFilter(params, time.Now())
+	if err != nil {
+		return "", err
+	}
+	sortBy := strings.ToLower(params.SortBy)
+	switch sortBy {
+	case "", "name", "mtime", "size":
+	default:
+		return "", fmt.Errorf("invalid sort_by: %s (valid: name, mtime, size)", params.SortBy)
+	}
+	// Sorting by metadata needs every match before the limit is applied
+	collectAll := sortBy == "mtime" || sortBy == "size"
+
 	// Set max results
 	maxResults := params.MaxResults
 	if maxResults <= 0 {
@@ -120,7 +162,8 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 	}
 
 	// Find matching files
-	var matches []string
+	var matches []fileMatch
+	var totalMatches int
 	var filesScanned int
 	var dirsSkipped int
 
@@ -137,7 +180,7 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 		}
 
 		// Check if we've reached max results
-		if len(matches) >= maxResults {
+		if !collectAll && len(matches) >= maxResults {
 			return filepath.SkipAll
 		}
 
@@ -174,8 +217,8 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 			matched = matchGlobPattern(params.Pattern, relPath)
 		}
 
-		if matched {
-			matches = append(matches, relPath)
+		if matched && filter.accepts(path, info) {
+			matches = append(matches, fileMatch{path: relPath, size: info.Size(), modTime: info.ModTime()})
 		}
 
 		return nil
@@ -186,28 +229,37 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 	}
 
 	// Sort matches
-	sort.Strings(matches)
+	sortFileMatches(matches, sortBy)
+	totalMatches = len(matches)
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
 
 	// Build result
 	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Pattern: %s\n", params.Pattern))
-	result.WriteString(fmt.Sprintf("Search path: %s\n", params.Path))
-	result.WriteString(fmt.Sprintf("Matches: %d", len(matches)))
-	if len(matches) >= maxResults {
+	result.WriteString(message("pattern", params.Pattern))
+	result.WriteString(message("search_path", params.Path))
+	if desc := filter.describe(); desc != "" {
+		result.WriteString(message("filters", desc))
+	}
+	result.WriteString(message("file_matches", len(matches)))
+	if totalMatches > maxResults {
+		result.WriteString(fmt.Sprintf(" of %d (limited to %d)", totalMatches, maxResults))
+	} else if len(matches) >= maxResults {
 		result.WriteString(fmt.Sprintf(" (limited to %d)", maxResults))
 	}
 	result.WriteString("\n")
-	result.WriteString(fmt.Sprintf("Files scanned: %d, Directories skipped: %d\n", filesScanned, dirsSkipped))
+	result.WriteString(message("files_scanned_dirs", filesScanned, dirsSkipped))
 	result.WriteString("\n")
 
 	if len(matches) == 0 {
-		result.WriteString("No files found matching the pattern.\n")
+		result.WriteString(message("no_files_matching"))
 		return result.String(), nil
 	}
 
-	result.WriteString("Matching files:\n")
+	result.WriteString(message("matching_files"))
 	for _, match := range matches {
-		result.WriteString(fmt.Sprintf("  %s\n", match))
+		result.WriteString(fmt.Sprintf("  %s  (%s, modified %s)\n", match.path, formatSize(match.size), match.modTime.Format("2006-01-02 15:04")))
 	}
 
 	if len(matches) >= maxResults {
@@ -217,6 +269,150 @@ func (t *ListFilesTool) Execute(ctx context.Context, params *ListFilesParams) (s
 	return result.String(), nil
 }
 
+// parseListFilesFilter parses the metadata filters of params relative to now
+func parseListFilesFilter(params *ListFilesParams, now time.Time) (*listFilesFilter, error) {
+	filter := &listFilesFilter{largerThan: -1, smallerThan: -1}
+
+	if params.ModifiedSince != "" {
+		since, err := parseModifiedSince(params.ModifiedSince, now)
+		if err != nil {
+			return nil, err
+		}
+		filter.modifiedSince = since
+	}
+	if params.LargerThan != "" {
+		size, err := parseFileSize(params.LargerThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid larger_than: %w", err)
+		}
+		filter.largerThan = size
+	}
+	if params.SmallerThan != "" {
+		size, err := parseFileSize(params.SmallerThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smaller_than: %w", err)
+		}
+		filter.smallerThan = size
+	}
+
+	switch contentType := strings.ToLower(params.ContentType); contentType {
+	case "", "text", "binary":
+		filter.contentType = contentType
+	default:
+		return nil, fmt.Errorf("invalid content_type: %s (valid: text, binary)", params.ContentType)
+	}
+	return filter, nil
+}
+
+// accepts reports whether a file passes the filters. Content is only sniffed
+// when the cheaper metadata checks pass.
+func (f *listFilesFilter) accepts(path string, info os.FileInfo) bool {
+	if !f.modifiedSince.IsZero() && info.ModTime().Before(f.modifiedSince) {
+		return false
+	}
+	if f.largerThan >= 0 && info.Size() <= f.largerThan {
+		return false
+	}
+	if f.smallerThan >= 0 && info.Size() >= f.smallerThan {
+		return false
+	}
+	if f.contentType != "" && isBinaryFile(path) != (f.contentType == "binary") {
+		return false
+	}
+	return true
+}
+
+// describe summarizes the active filters for the tool output
+func (f *listFilesFilter) describe() string {
+	var parts []string
+	if !f.modifiedSince.IsZero() {
+		parts = append(parts, "modified since "+f.modifiedSince.Format("2006-01-02 15:04"))
+	}
+	if f.largerThan >= 0 {
+		parts = append(parts, "larger than "+formatSize(f.largerThan))
+	}
+	if f.smallerThan >= 0 {
+		parts = append(parts, "smaller than "+formatSize(f.smallerThan))
+	}
+	if f.contentType != "" {
+		parts = append(parts, f.contentType+" files")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortFileMatches orders matches by name, by modification time (newest first)
+// or by size (largest first)
+func sortFileMatches(matches []fileMatch, sortBy string) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		switch sortBy {
+		case "mtime":
+			if !matches[i].modTime.Equal(matches[j].modTime) {
+				return matches[i].modTime.After(matches[j].modTime)
+			}
+		case "size":
+			if matches[i].size != matches[j].size {
+				return matches[i].size > matches[j].size
+			}
+		}
+		return matches[i].path < matches[j].path
+	})
+}
+
+// durationUnits maps the units accepted by modified_since to their length
+var durationUnits = map[string]time.Duration{
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseModifiedSince parses a relative period ("2 days", "3h", "1 week ago")
+// or an absolute date ("2024-01-15", RFC 3339) into a point in time
+func parseModifiedSince(value string, now time.Time) (time.Time, error) {
+	v := strings.TrimSpace(strings.ToLower(value))
+	v = strings.TrimSpace(strings.TrimSuffix(v, "ago"))
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	i := 0
+	for i < len(v) && (v[i] >= '0' && v[i] <= '9' || v[i] == '.') {
+		i++
+	}
+	amount, err := strconv.ParseFloat(v[:i], 64)
+	unit, ok := durationUnits[strings.TrimSpace(v[i:])]
+	if err != nil || !ok || amount < 0 {
+		return time.Time{}, fmt.Errorf("invalid modified_since: %q (use e.g. \"2 days\", \"3h\", \"1 week\" or \"2024-01-15\")", value)
+	}
+	return now.Add(-time.Duration(amount * float64(unit))), nil
+}
+
+// sizeUnits maps the units accepted by larger_than/smaller_than to bytes
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+}
+
+// parseFileSize parses a size like "1MB", "1.5 KB" or "512" (bytes)
+func parseFileSize(value string) (int64, error) {
+	v := strings.TrimSpace(strings.ToLower(value))
+	i := 0
+	for i < len(v) && (v[i] >= '0' && v[i] <= '9' || v[i] == '.') {
+		i++
+	}
+	amount, err := strconv.ParseFloat(v[:i], 64)
+	unit, ok := sizeUnits[strings.TrimSpace(v[i:])]
+	if err != nil || !ok || amount < 0 {
+		return 0, fmt.Errorf("%q is not a size (use e.g. \"1MB\", \"500KB\" or \"100B\")", value)
+	}
+	return int64(amount * float64(unit)), nil
+}
+
 // matchGlobPattern matches a glob pattern with ** support
 func matchGlobPattern(pattern, path string) bool {
 	// Split pattern and path by /
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestListFilesTool_Name(t *testing.T) {
@@ -320,3 +321,115 @@ func TestMatchGlobPattern(t *testing.T) {
 		})
 	}
 }
+
+func TestListFilesTool_Execute_MetadataFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+
+	write := func(name string, content []byte, age time.Duration) {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old.log", []byte("old"), 10*24*time.Hour)
+	write("recent.log", []byte("recent"), time.Hour)
+	write("large.log", []byte(strings.Repeat("x", 4096)), 2*time.Hour)
+	write("blob.bin", []byte{0x00, 0x01, 0x02}, time.Hour)
+
+	tool := NewListFilesTool(tmpDir, 100)
+	ctx := context.Background()
+
+	result, err := tool.Execute(ctx, &ListFilesParams{Pattern: "*", Path: ".", ModifiedSince: "2 days", SortBy: "mtime"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(result, "old.log") {
+		t.Errorf("old.log should be filtered out, got:\n%s", result)
+	}
+	if !strings.Contains(result, "Filters: modified since") {
+		t.Errorf("expected filter summary, got:\n%s", result)
+	}
+	if strings.Index(result, "large.log") < strings.Index(result, "recent.log") {
+		t.Errorf("expected newest files first, got:\n%s", result)
+	}
+
+	result, err = tool.Execute(ctx, &ListFilesParams{Pattern: "*.log", Path: ".", LargerThan: "1KB"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "large.log  (4.0 KB, modified ") || strings.Contains(result, "recent.log") {
+		t.Errorf("expected only large.log with metadata, got:\n%s", result)
+	}
+
+	result, err = tool.Execute(ctx, &ListFilesParams{Pattern: "*", Path: ".", ContentType: "binary"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "blob.bin") || strings.Contains(result, ".log") {
+		t.Errorf("expected only blob.bin, got:\n%s", result)
+	}
+
+	// Sorting by size applies the limit after sorting
+	result, err = tool.Execute(ctx, &ListFilesParams{Pattern: "*", Path: ".", SortBy: "size", MaxResults: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "large.log") || !strings.Contains(result, "Matches: 1 of 4 (limited to 1)") {
+		t.Errorf("expected the largest file, got:\n%s", result)
+	}
+
+	for _, params := range []*ListFilesParams{
+		{Pattern: "*", Path: ".", ModifiedSince: "yesterday-ish"},
+		{Pattern: "*", Path: ".", LargerThan: "1 parsec"},
+		{Pattern: "*", Path: ".", ContentType: "image"},
+		{Pattern: "*", Path: ".", SortBy: "color"},
+	} {
+		if _, err := tool.Execute(ctx, params); err == nil {
+			t.Errorf("expected error for %+v", params)
+		}
+	}
+}
+
+func TestParseModifiedSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2 days", now.Add(-48 * time.Hour)},
+		{"3h", now.Add(-3 * time.Hour)},
+		{"1 week ago", now.Add(-7 * 24 * time.Hour)},
+		{"30 minutes", now.Add(-30 * time.Minute)},
+		{"2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseModifiedSince(tt.value, now)
+		if err != nil {
+			t.Errorf("parseModifiedSince(%q) error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseModifiedSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := map[string]int64{
+		"1MB":    1 << 20,
+		"500KB":  500 << 10,
+		"1.5 kb": 1536,
+		"100B":   100,
+		"512":    512,
+	}
+	for value, want := range tests {
+		got, err := parseFileSize(value)
+		if err != nil || got != want {
+			t.Errorf("parseFileSize(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/huimingz/gitbuddy-go/internal/i18n"
+)
+
+// toolLanguage is the language code of the text tools wrap their results in
+var toolLanguage atomic.Value // string
+
+// SetLanguage localizes the text tools wrap their results in (headers,
+// notes, empty results) to language. The data itself, such as file contents,
+// paths and matches, is never translated. Languages without translations,
+// and "", use English.
+func SetLanguage(language string) {
+	toolLanguage.Store(i18n.Normalize(language))
+}
+
+// englishMessages are the formats of the wrapper text, by key
+var englishMessages = map[string]string{
+	"directory":             "Directory: %s\n",
+	"pattern":               "Pattern: %s\n",
+	"file":                  "File: %s\n",
+	"matches":               "Matches: %d\n",
+	"matches_limited":       "Matches: %d (showing up to %d)\n",
+	"files_scanned_skipped": "Files scanned: %d, Files skipped: %d\n",
+	"no_matches_directory":  "No matches found for pattern '%s' in directory: %s\nFiles scanned: %d, Files skipped: %d",
+	"no_matches_file":       "No matches found for pattern '%s' in file: %s",
+	"file_matches_header":   "=== File: %s (%d matches) ===\n",
+	"match_at_line":         "Match at line %d:\n",
+	"total_lines":           "Total lines: %d\n",
+	"recursive":             "Recursive: %v",
+	"max_depth":             " (max depth: %d)",
+	"directories":           "Directories:\n",
+	"files":                 "Files:\n",
+	"empty_directory":       "(empty directory)\n",
+	"search_path":           "Search path: %s\n",
+	"filters":               "Filters: %s\n",
+	"file_matches":          "Matches: %d",
+	"files_scanned_dirs":    "Files scanned: %d, Directories skipped: %d\n",
+	"no_files_matching":     "No files found matching the pattern.\n",
+	"matching_files":        "Matching files:\n",
+	"lines_range":           "Lines: %d-%d (total lines in file: %d)\n",
+	"output_truncated":      "Note: Output truncated to %d lines (max_lines_per_read limit)\n",
+	"more_content":          "Note: File has more content after line %d\n",
+	"read_sections_tip":     "Tip: Use start_line and end_line parameters to read specific sections\n",
+	"excerpt_head":          "Excerpt: first %d lines",
+	"excerpt_tail":          "Excerpt: last %d lines",
+	"excerpt_symbol":        "Excerpt: %s %s (declared at lines %d-%d)",
+	"encoding_converted":    "Note: Converted from %s to UTF-8\n",
+	"json_formatted":        "Note: Minified JSON was pretty-printed; line numbers refer to the formatted text\n",
+	"binary_content":        "Warning: The file looks binary (%d bytes), so its content is not shown. Read the source it is built from instead.\n",
+	"no_commits":            "No commits found in this repository.",
+	"no_commit_for_ref":     "No commit found for reference: %s",
+	"no_staged_changes":     "No staged changes found. Please stage some changes using 'git add' first.",
+}
+
+// localizedMessages are the translations of englishMessages, by language code
+var localizedMessages = map[string]map[string]string{
+	"zh": {
+		"directory":             "目录：%s\n",
+		"pattern":               "模式：%s\n",
+		"file":                  "文件：%s\n",
+		"matches":               "匹配数：%d\n",
+		"matches_limited":       "匹配数：%d（最多显示 %d 个）\n",
+		"files_scanned_skipped": "已扫描文件：%d，已跳过文件：%d\n",
+		"no_matches_directory":  "在目录 %[2]s 中未找到匹配模式 '%[1]s' 的内容\n已扫描文件：%[3]d，已跳过文件：%[4]d",
+		"no_matches_file":       "在文件 %[2]s 中未找到匹配模式 '%[1]s' 的内容",
+		"file_matches_header":   "=== 文件：%s（%d 处匹配）===\n",
+		"match_at_line":         "第 %d 行匹配：\n",
+		"total_lines":           "总行数：%d\n",
+		"recursive":             "递归：%v",
+		"max_depth":             "（最大深度：%d）",
+		"directories":           "目录：\n",
+		"files":                 "文件：\n",
+		"empty_directory":       "（空目录）\n",
+		"search_path":           "搜索路径：%s\n",
+		"filters":               "过滤条件：%s\n",
+		"file_matches":          "匹配数：%d",
+		"files_scanned_dirs":    "已扫描文件：%d，已跳过目录：%d\n",
+		"no_files_matching":     "未找到匹配该模式的文件。\n",
+		"matching_files":        "匹配的文件：\n",
+		"lines_range":           "行：%d-%d（文件总行数：%d）\n",
+		"output_truncated":      "注意：输出已截断为 %d 行（max_lines_per_read 限制）\n",
+		"more_content":          "注意：第 %d 行之后还有内容\n",
+		"read_sections_tip":     "提示：使用 start_line 和 end_line 参数读取指定部分\n",
+		"excerpt_head":          "摘录：前 %d 行",
+		"excerpt_tail":          "摘录：最后 %d 行",
+		"excerpt_symbol":        "摘录：%s %s（声明于第 %d-%d 行）",
+		"encoding_converted":    "注意：已从 %s 转换为 UTF-8\n",
+		"json_formatted":        "注意：压缩的 JSON 已格式化，行号对应格式化后的文本\n",
+		"binary_content":        "警告：该文件似乎是二进制文件（%d 字节），不显示其内容。请改为读取生成它的源文件。\n",
+		"no_commits":            "此仓库中没有提交。",
+		"no_commit_for_ref":     "未找到引用对应的提交：%s",
+		"no_staged_changes":     "没有已暂存的更改。请先使用 'git add' 暂存更改。",
+	},
+	"ja": {
+		"directory":             "ディレクトリ: %s\n",
+		"pattern":               "パターン: %s\n",
+		"file":                  "ファイル: %s\n",
+		"matches":               "一致数: %d\n",
+		"matches_limited":       "一致数: %d（最大 %d 件を表示）\n",
+		"files_scanned_skipped": "走査したファイル: %d、スキップしたファイル: %d\n",
+		"no_matches_directory":  "ディレクトリ %[2]s にパターン '%[1]s' に一致する内容はありません\n走査したファイル: %[3]d、スキップしたファイル: %[4]d",
+		"no_matches_file":       "ファイル %[2]s にパターン '%[1]s' に一致する内容はありません",
+		"file_matches_header":   "=== ファイル: %s（%d 件一致）===\n",
+		"match_at_line":         "%d 行目で一致:\n",
+		"total_lines":           "総行数: %d\n",
+		"recursive":             "再帰: %v",
+		"max_depth":             "（最大深さ: %d）",
+		"directories":           "ディレクトリ:\n",
+		"files":                 "ファイル:\n",
+		"empty_directory":       "（空のディレクトリ）\n",
+		"search_path":           "検索パス: %s\n",
+		"filters":               "フィルター: %s\n",
+		"file_matches":          "一致数: %d",
+		"files_scanned_dirs":    "走査したファイル: %d、スキップしたディレクトリ: %d\n",
+		"no_files_matching":     "パターンに一致するファイルはありません。\n",
+		"matching_files":        "一致したファイル:\n",
+		"lines_range":           "行: %d-%d（ファイルの総行数: %d）\n",
+		"output_truncated":      "注: 出力は %d 行に切り詰められました（max_lines_per_read の制限）\n",
+		"more_content":          "注: %d 行目以降にも内容があります\n",
+		"read_sections_tip":     "ヒント: start_line と end_line パラメータで特定の部分を読めます\n",
+		"excerpt_head":          "抜粋: 先頭 %d 行",
+		"excerpt_tail":          "抜粋: 末尾 %d 行",
+		"excerpt_symbol":        "抜粋: %s %s（%d-%d 行目で宣言）",
+		"encoding_converted":    "注: %s から UTF-8 に変換しました\n",
+		"json_formatted":        "注: 圧縮された JSON を整形しました。行番号は整形後のテキストのものです\n",
+		"binary_content":        "警告: このファイルはバイナリのようです（%d バイト）。内容は表示しません。生成元のソースを読んでください。\n",
+		"no_commits":            "このリポジトリにはコミットがありません。",
+		"no_commit_for_ref":     "参照に対応するコミットが見つかりません: %s",
+		"no_staged_changes":     "ステージされた変更はありません。先に 'git add' で変更をステージしてください。",
+	},
+}
+
+// message formats the wrapper text key in the tool language
+func message(key string, args ...interface{}) string {
+	format := englishMessages[key]
+	if language, _ := toolLanguage.Load().(string); language != "" {
+		if localized, ok := localizedMessages[language][key]; ok {
+			format = localized
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
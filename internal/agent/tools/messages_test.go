@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatVerb matches the verbs of a format, explicit argument indexes included
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[a-z]`)
+
+func TestLocalizedMessages_Complete(t *testing.T) {
+	for language, messages := range localizedMessages {
+		for key, english := range englishMessages {
+			localized, ok := messages[key]
+			if assert.True(t, ok, "%s is missing %s", language, key) {
+				assert.Len(t, formatVerb.FindAllString(localized, -1), len(formatVerb.FindAllString(english, -1)),
+					"%s %s takes the same arguments", language, key)
+			}
+		}
+		for key := range messages {
+			assert.Contains(t, englishMessages, key, "%s has unknown key %s", language, key)
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	t.Cleanup(func() { SetLanguage("") })
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+	tool := NewGrepFileTool(dir, 0)
+
+	result, err := tool.Execute(context.Background(), &GrepFileParams{FilePath: path, Pattern: "func"})
+	require.NoError(t, err)
+	assert.Equal(t, "No matches found for pattern 'func' in file: "+path, result)
+
+	SetLanguage("zh-CN")
+	result, err = tool.Execute(context.Background(), &GrepFileParams{FilePath: path, Pattern: "func"})
+	require.NoError(t, err)
+	assert.Equal(t, "在文件 "+path+" 中未找到匹配模式 'func' 的内容", result)
+	assert.True(t, IsNoStagedChanges(message("no_staged_changes")))
+	assert.False(t, IsNoStagedChanges("No staged changes found. Please stage some changes using 'git add' first."))
+
+	SetLanguage("ko")
+	assert.Equal(t, "Directory: src\n", message("directory", "src"), "untranslated languages use English")
+}
@@ -1,7 +1,6 @@
 package tools
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
@@ -20,6 +19,12 @@ type ReadFileParams struct {
 	FilePath  string `json:"file_path"`
 	StartLine int    `json:"start_line,omitempty"`
 	EndLine   int    `json:"end_line,omitempty"`
+	// AroundSymbol reads the declaration of a function, method or type
+	AroundSymbol string `json:"around_symbol,omitempty"`
+	// Head reads the first N lines
+	Head int `json:"head,omitempty"`
+	// Tail reads the last N lines
+	Tail int `json:"tail,omitempty"`
 }
 
 // ReadFileTool is a tool for reading file contents
@@ -51,7 +56,12 @@ Parameters:
 - file_path (required): Path to the file to read
 - start_line (optional): Starting line number (1-indexed). If not specified, reads from the beginning.
 - end_line (optional): Ending line number (1-indexed, inclusive). If not specified, reads up to 200 lines by default.
+- around_symbol (optional): Name of a function, method, type or class to read, including its doc comment (e.g. "Execute" or "ReadFileTool.Execute"). Use this instead of guessing line numbers.
+- head (optional): Read only the first N lines.
+- tail (optional): Read only the last N lines.
+Only one of start_line/end_line, around_symbol, head and tail may be used at a time.
 Returns the file contents with line numbers prefixed to each line.
+Files in GBK or UTF-16 are converted to UTF-8, minified JSON is pretty-printed (line numbers then refer to the formatted text), and binary files are reported instead of shown.
 Note: There is a maximum line limit per read. If the requested range exceeds this limit, it will be truncated.`
 }
 
@@ -80,16 +90,31 @@ func (t *ReadFileTool) Execute(ctx context.Context, params *ReadFileParams) (str
 		return "", fmt.Errorf("path is a directory, not a file: %s", params.FilePath)
 	}
 
-	// Open the file
-	file, err := os.Open(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Convert legacy encodings so the model doesn't see mojibake
+	decoded := decodeText(content)
+	if decoded.Binary {
+		return message("file", params.FilePath) + message("binary_content", info.Size()), nil
+	}
+	var notes strings.Builder
+	if decoded.Encoding != "" {
+		notes.WriteString(message("encoding_converted", decoded.Encoding))
+	}
+	content = decoded.Text
+	if pretty, ok := prettyJSON(filePath, content); ok {
+		content = pretty
+		notes.WriteString(message("json_formatted"))
 	}
-	defer file.Close()
 
 	// Determine line range
-	startLine := params.StartLine
-	endLine := params.EndLine
+	startLine, endLine, excerpt, err := resolveExcerpt(params, content)
+	if err != nil {
+		return "", err
+	}
 
 	// If no range specified, read first DefaultLinesNoRange lines
 	noRangeSpecified := startLine <= 0 && endLine <= 0
@@ -114,54 +139,36 @@ func (t *ReadFileTool) Execute(ctx context.Context, params *ReadFileParams) (str
 		truncated = true
 	}
 
-	// Read the file line by line
-	scanner := bufio.NewScanner(file)
+	// Number the lines of the range
+	lines := splitLines(content)
+	totalLines := len(lines)
 	var result strings.Builder
-	currentLine := 0
 	linesRead := 0
-	totalLines := 0
-
-	for scanner.Scan() {
-		currentLine++
-		totalLines = currentLine
-
-		if currentLine < startLine {
-			continue
-		}
-		if currentLine > endLine {
-			// Continue counting total lines
-			continue
-		}
-
+	for i := startLine; i <= endLine && i <= totalLines; i++ {
 		linesRead++
-		result.WriteString(fmt.Sprintf("%6d | %s\n", currentLine, scanner.Text()))
-	}
-
-	// Count remaining lines
-	for scanner.Scan() {
-		totalLines++
-	}
-
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading file: %w", err)
+		result.WriteString(fmt.Sprintf("%6d | %s\n", i, strings.TrimSuffix(lines[i-1], "\r")))
 	}
 
 	// Build response with metadata
 	var response strings.Builder
-	response.WriteString(fmt.Sprintf("File: %s\n", params.FilePath))
-	response.WriteString(fmt.Sprintf("Lines: %d-%d (total lines in file: %d)\n", startLine, startLine+linesRead-1, totalLines))
+	response.WriteString(message("file", params.FilePath))
+	response.WriteString(notes.String())
+	if excerpt != "" {
+		response.WriteString(excerpt + "\n")
+	}
+	response.WriteString(message("lines_range", startLine, startLine+linesRead-1, totalLines))
 
 	if truncated {
-		response.WriteString(fmt.Sprintf("Note: Output truncated to %d lines (max_lines_per_read limit)\n", t.maxLinesPerRead))
+		response.WriteString(message("output_truncated", t.maxLinesPerRead))
 	}
 
 	hasMore := totalLines > endLine
 	if hasMore {
-		response.WriteString(fmt.Sprintf("Note: File has more content after line %d\n", endLine))
+		response.WriteString(message("more_content", endLine))
 	}
 
 	if noRangeSpecified && hasMore {
-		response.WriteString(fmt.Sprintf("Tip: Use start_line and end_line parameters to read specific sections\n"))
+		response.WriteString(message("read_sections_tip"))
 	}
 
 	response.WriteString("---\n")
@@ -169,3 +176,119 @@ func (t *ReadFileTool) Execute(ctx context.Context, params *ReadFileParams) (str
 
 	return response.String(), nil
 }
+
+// resolveExcerpt turns the excerpt modes of params into a line range.
+// For plain start_line/end_line reads the range is returned unchanged.
+func resolveExcerpt(params *ReadFileParams, content []byte) (int, int, string, error) {
+	modes := 0
+	if params.StartLine > 0 || params.EndLine > 0 {
+		modes++
+	}
+	if params.AroundSymbol != "" {
+		modes++
+	}
+	if params.Head > 0 {
+		modes++
+	}
+	if params.Tail > 0 {
+		modes++
+	}
+	if modes > 1 {
+		return 0, 0, "", fmt.Errorf("only one of start_line/end_line, around_symbol, head and tail may be specified")
+	}
+
+	switch {
+	case params.Head > 0:
+		return 1, params.Head, message("excerpt_head", params.Head), nil
+
+	case params.Tail > 0:
+		total := len(splitLines(content))
+		start := max(total-params.Tail+1, 1)
+		return start, total, message("excerpt_tail", params.Tail), nil
+
+	case params.AroundSymbol != "":
+		symbol, err := findSymbol(params.FilePath, content, params.AroundSymbol)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		start := docCommentStart(splitLines(content), symbol.StartLine)
+		return start, symbol.EndLine, message("excerpt_symbol", symbol.Kind, symbol.Name, symbol.StartLine, symbol.EndLine), nil
+	}
+
+	return params.StartLine, params.EndLine, "", nil
+}
+
+// findSymbol looks up a declaration by name. Methods match by their bare name
+// or as "Type.Method".
+func findSymbol(filePath string, content []byte, name string) (OutlineSymbol, error) {
+	symbols := ExtractOutline(filePath, content)
+	if len(symbols) == 0 {
+		return OutlineSymbol{}, fmt.Errorf("cannot locate symbol %q: no declarations found in %s (unsupported language?)", name, filePath)
+	}
+
+	for _, s := range symbols {
+		if symbolMatches(s.Name, name) {
+			return s, nil
+		}
+	}
+
+	names := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	const maxListed = 30
+	if len(names) > maxListed {
+		names = append(names[:maxListed], "...")
+	}
+	return OutlineSymbol{}, fmt.Errorf("symbol %q not found in %s; declared symbols: %s", name, filePath, strings.Join(names, ", "))
+}
+
+// symbolMatches compares an outline name like "(*Tool) Execute" with a query
+// like "Execute", "Tool.Execute" or "(*Tool) Execute"
+func symbolMatches(outlineName, query string) bool {
+	if outlineName == query {
+		return true
+	}
+	// Go methods are outlined as "(Recv) Name"
+	recv, method, ok := strings.Cut(outlineName, ") ")
+	if !ok || !strings.HasPrefix(recv, "(") {
+		return false
+	}
+	if method == query {
+		return true
+	}
+	recv = strings.TrimLeft(recv, "(*")
+	return recv+"."+method == query
+}
+
+// docCommentStart extends a declaration upwards over its leading comment and
+// annotation/decorator lines
+func docCommentStart(lines []string, startLine int) int {
+	start := startLine
+	for start > 1 {
+		trimmed := strings.TrimSpace(lines[start-2])
+		if !isCommentLine(trimmed) {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+func isCommentLine(line string) bool {
+	for _, prefix := range []string{"//", "#", "/*", "*", "--", "@"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits content into lines, ignoring a trailing newline
+func splitLines(content []byte) []string {
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readFileGoSource = `package demo
+
+type Server struct {
+	name string
+}
+
+// Start starts the server.
+// It is a no-op without a name.
+func (s *Server) Start() error {
+	if s.name == "" {
+		return nil
+	}
+	return nil
+}
+
+func helper(x int) int {
+	return x * 2
+}
+`
+
+func newReadFileFixture(t *testing.T) *ReadFileTool {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "demo.go"), []byte(readFileGoSource), 0644))
+	return NewReadFileTool(dir, 0)
+}
+
+func TestReadFileTool_AroundSymbol(t *testing.T) {
+	tool := newReadFileFixture(t)
+
+	for _, query := range []string{"Start", "Server.Start", "(*Server) Start"} {
+		t.Run(query, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", AroundSymbol: query})
+			require.NoError(t, err)
+			assert.Contains(t, result, "Excerpt: method (*Server) Start (declared at lines 9-14)")
+			assert.Contains(t, result, "Lines: 7-14")
+			assert.Contains(t, result, "// Start starts the server.", "doc comment is included")
+			assert.NotContains(t, result, "func helper")
+		})
+	}
+
+	_, err := tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", AroundSymbol: "Stop"})
+	assert.ErrorContains(t, err, `symbol "Stop" not found`)
+	assert.ErrorContains(t, err, "(*Server) Start, helper")
+}
+
+func TestReadFileTool_HeadTail(t *testing.T) {
+	tool := newReadFileFixture(t)
+
+	result, err := tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", Head: 3})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Lines: 1-3 (total lines in file: 18)")
+
+	result, err = tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", Tail: 3})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Lines: 16-18 (total lines in file: 18)")
+	assert.Contains(t, result, "func helper")
+
+	result, err = tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", Tail: 100})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Lines: 1-18")
+}
+
+func TestReadFileTool_ConflictingModes(t *testing.T) {
+	tool := newReadFileFixture(t)
+
+	_, err := tool.Execute(context.Background(), &ReadFileParams{FilePath: "demo.go", Head: 3, StartLine: 5})
+	assert.ErrorContains(t, err, "only one of")
+}
+
+func TestReadFileTool_Encodings(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewReadFileTool(dir, 0)
+
+	// "配置" in GBK
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "legacy.go"), []byte("// \xc5\xe4\xd6\xc3\r\npackage legacy\r\n"), 0644))
+	result, err := tool.Execute(context.Background(), &ReadFileParams{FilePath: "legacy.go"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Note: Converted from GBK to UTF-8")
+	assert.Contains(t, result, "     1 | // 配置\n")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.json"), []byte(`{"items":[`+strings.Repeat(`{"id":1},`, 100)+`{"id":2}]}`), 0644))
+	result, err = tool.Execute(context.Background(), &ReadFileParams{FilePath: "data.json", Head: 3})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Note: Minified JSON was pretty-printed")
+	assert.Contains(t, result, "     3 |     {")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.bin"), []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00, 0x00}, 0644))
+	result, err = tool.Execute(context.Background(), &ReadFileParams{FilePath: "app.bin"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Warning: The file looks binary (9 bytes)")
+	assert.NotContains(t, result, "ELF")
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Handler executes a tool call and returns the result for the model
+type Handler func(ctx context.Context, call schema.ToolCall) (string, error)
+
+// Tool binds the description of a tool for the model to its handler
+type Tool struct {
+	Info    *schema.ToolInfo
+	Execute Handler
+}
+
+// Bind returns a Handler calling execute with the call arguments decoded
+// into parameters of type P. execute takes them as *P or, like the Execute
+// methods of the git tools, as interface{}.
+func Bind[P any, A any](execute func(context.Context, A) (string, error)) Handler {
+	return func(ctx context.Context, call schema.ToolCall) (string, error) {
+		var params P
+		if err := DecodeArgs(call.Function.Arguments, &params); err != nil {
+			return "", err
+		}
+		return execute(ctx, any(&params).(A))
+	}
+}
+
+// DecodeArgs decodes tool call arguments; empty arguments leave params at
+// their zero value
+func DecodeArgs(args string, params interface{}) error {
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(args), params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	return nil
+}
+
+// ToolRegistry holds the tools of an agent, so that they are bound to the
+// model and their calls dispatched from one place
+type ToolRegistry struct {
+	tools map[string]Tool
+	names []string
+}
+
+// NewToolRegistry creates a registry of tools
+func NewToolRegistry(tools ...Tool) *ToolRegistry {
+	r := &ToolRegistry{tools: make(map[string]Tool, len(tools))}
+	for _, tool := range tools {
+		r.Register(tool)
+	}
+	return r
+}
+
+// Register adds a tool, replacing the tool of the same name
+func (r *ToolRegistry) Register(tool Tool) {
+	name := tool.Info.Name
+	if _, ok := r.tools[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.tools[name] = tool
+}
+
+// Wrap replaces the handler of the tool name with wrap applied to it, e.g. to
+// act on its results; it does nothing if the tool isn't registered
+func (r *ToolRegistry) Wrap(name string, wrap func(Handler) Handler) {
+	if tool, ok := r.tools[name]; ok {
+		tool.Execute = wrap(tool.Execute)
+		r.tools[name] = tool
+	}
+}
+
+// Names returns the names of the tools, in the order they were registered
+func (r *ToolRegistry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Infos returns the descriptions of the tools for binding them to a model
+func (r *ToolRegistry) Infos() []*schema.ToolInfo {
+	infos := make([]*schema.ToolInfo, len(r.names))
+	for i, name := range r.names {
+		infos[i] = r.tools[name].Info
+	}
+	return infos
+}
+
+// Has reports whether the tool name is registered
+func (r *ToolRegistry) Has(name string) bool {
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Execute dispatches a tool call to the handler of its tool
+func (r *ToolRegistry) Execute(ctx context.Context, call schema.ToolCall) (string, error) {
+	tool, ok := r.tools[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", call.Function.Name)
+	}
+	return tool.Execute(ctx, call)
+}
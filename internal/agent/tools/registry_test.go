@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type shoutParams struct {
+	Text string `json:"text"`
+}
+
+func shout(ctx context.Context, params *shoutParams) (string, error) {
+	return strings.ToUpper(params.Text), nil
+}
+
+func shoutTool(name string) Tool {
+	return Tool{Info: &schema.ToolInfo{Name: name}, Execute: Bind[shoutParams](shout)}
+}
+
+func toolCall(name, args string) schema.ToolCall {
+	return schema.ToolCall{Function: schema.FunctionCall{Name: name, Arguments: args}}
+}
+
+func TestToolRegistry_Execute(t *testing.T) {
+	registry := NewToolRegistry(shoutTool("shout"))
+	ctx := context.Background()
+
+	result, err := registry.Execute(ctx, toolCall("shout", `{"text":"hello"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", result)
+
+	_, err = registry.Execute(ctx, toolCall("shout", `{"text":`))
+	assert.ErrorContains(t, err, "invalid parameters")
+
+	_, err = registry.Execute(ctx, toolCall("whisper", `{}`))
+	assert.EqualError(t, err, "unknown tool: whisper")
+}
+
+func TestBind_InterfaceParams(t *testing.T) {
+	handler := Bind[shoutParams](func(ctx context.Context, params interface{}) (string, error) {
+		return params.(*shoutParams).Text, nil
+	})
+
+	result, err := handler(context.Background(), toolCall("echo", `{"text":"hi"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "hi", result)
+
+	result, err = handler(context.Background(), toolCall("echo", ""))
+	require.NoError(t, err)
+	assert.Empty(t, result, "empty arguments leave the parameters at their zero value")
+}
+
+func TestToolRegistry_Register(t *testing.T) {
+	registry := NewToolRegistry(shoutTool("b"), shoutTool("a"))
+	replacement := Tool{
+		Info:    &schema.ToolInfo{Name: "b", Desc: "replaced"},
+		Execute: func(ctx context.Context, call schema.ToolCall) (string, error) { return "replaced", nil },
+	}
+	registry.Register(replacement)
+	registry.Register(shoutTool("c"))
+
+	assert.Equal(t, []string{"b", "a", "c"}, registry.Names(), "replacing a tool keeps its place")
+	infos := registry.Infos()
+	require.Len(t, infos, 3)
+	assert.Equal(t, "replaced", infos[0].Desc)
+	assert.True(t, registry.Has("c"))
+	assert.False(t, registry.Has("d"))
+
+	result, err := registry.Execute(context.Background(), toolCall("b", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", result)
+}
+
+func TestToolRegistry_Wrap(t *testing.T) {
+	registry := NewToolRegistry(shoutTool("shout"))
+	var calls []string
+	registry.Wrap("shout", func(next Handler) Handler {
+		return func(ctx context.Context, call schema.ToolCall) (string, error) {
+			result, err := next(ctx, call)
+			calls = append(calls, result)
+			return result + "!", err
+		}
+	})
+	registry.Wrap("missing", func(next Handler) Handler { return next })
+
+	result, err := registry.Execute(context.Background(), toolCall("shout", `{"text":"hey"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "HEY!", result)
+	assert.Equal(t, []string{"HEY"}, calls)
+	assert.False(t, registry.Has("missing"))
+}
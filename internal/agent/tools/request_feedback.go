@@ -22,12 +22,15 @@ type RequestFeedbackParams struct {
 // RequestFeedbackTool is a tool for requesting interactive feedback from the user
 // This tool allows the LLM to pause analysis and ask the user for direction
 type RequestFeedbackTool struct {
-	input  io.Reader
-	output io.Writer
+	input   io.Reader
+	output  io.Writer
+	history *FeedbackHistory
 }
 
-// NewRequestFeedbackTool creates a new RequestFeedbackTool
-func NewRequestFeedbackTool(input io.Reader, output io.Writer) *RequestFeedbackTool {
+// NewRequestFeedbackTool creates a new RequestFeedbackTool. When history is not
+// nil, questions already answered are answered from it, and the user is
+// offered the answers to similar questions.
+func NewRequestFeedbackTool(input io.Reader, output io.Writer, history *FeedbackHistory) *RequestFeedbackTool {
 	if input == nil {
 		input = os.Stdin
 	}
@@ -35,8 +38,9 @@ func NewRequestFeedbackTool(input io.Reader, output io.Writer) *RequestFeedbackT
 		output = os.Stdout
 	}
 	return &RequestFeedbackTool{
-		input:  input,
-		output: output,
+		input:   input,
+		output:  output,
+		history: history,
 	}
 }
 
@@ -68,6 +72,7 @@ Returns:
 - If options are provided: Returns the selected option text
 - If no options: Returns the user's text input
 - If user provides no input: Returns empty string (agent should make own judgment)
+- If the user already answered the same question, or confirms their answer to a similar one: Returns that answer with "reused": true and a note. Ask a more specific question if the earlier answer does not fit.
 
 **When to use this tool (USE LIBERALLY)**:
 ✅ Phase 1 (Problem Definition): Missing critical information about symptoms, timing, or scope
@@ -114,20 +119,64 @@ Returns:
 
 // Execute runs the tool and requests feedback from the user
 func (t *RequestFeedbackTool) Execute(ctx context.Context, params *RequestFeedbackParams) (string, error) {
-	if params == nil {
-		return "", fmt.Errorf("params is required")
+	if err := validateFeedbackParams(params); err != nil {
+		return "", err
 	}
 
-	if params.Title == "" {
-		return "", fmt.Errorf("title is required")
+	if t.history != nil {
+		if previous, index := t.history.Find(params); previous != nil {
+			if SameQuestion(params, previous) {
+				return t.reuseAnswer(params, previous, index, false)
+			}
+			confirmed, err := t.confirmReuse(previous)
+			if err != nil {
+				return "", err
+			}
+			if confirmed {
+				return t.reuseAnswer(params, previous, index, true)
+			}
+		}
 	}
 
-	if params.Content == "" {
-		return "", fmt.Errorf("content is required")
+	userResponse, selectedIndex, err := t.Ask(ctx, params)
+	if err != nil {
+		return "", err
 	}
 
-	if params.Prompt == "" {
-		return "", fmt.Errorf("prompt is required")
+	if t.history != nil {
+		t.history.Record(params, userResponse, "")
+	}
+
+	// Return the response as a structured JSON
+	response := map[string]interface{}{
+		"user_response": userResponse,
+		"title":         params.Title,
+	}
+
+	if selectedIndex >= 0 {
+		response["selected_index"] = selectedIndex
+		response["is_choice"] = true
+	} else {
+		response["is_choice"] = false
+	}
+
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return userResponse, nil // Fallback to plain text
+	}
+
+	return string(responseJSON), nil
+}
+
+// Ask shows a question to the user and returns the answer, without
+// consulting the feedback history. The index of the selected option is -1
+// for open questions.
+func (t *RequestFeedbackTool) Ask(ctx context.Context, params *RequestFeedbackParams) (string, int, error) {
+	if err := validateFeedbackParams(params); err != nil {
+		return "", -1, err
+	}
+	if len(params.Options) == 1 {
+		return "", -1, fmt.Errorf("at least 2 options are required for multiple choice questions")
 	}
 
 	// Print a separator for clarity
@@ -150,11 +199,6 @@ func (t *RequestFeedbackTool) Execute(ctx context.Context, params *RequestFeedba
 
 	// Check if this is a multiple choice question or open-ended
 	if len(params.Options) > 0 {
-		// Multiple choice question
-		if len(params.Options) < 2 {
-			return "", fmt.Errorf("at least 2 options are required for multiple choice questions")
-		}
-
 		// Use the UI SelectOption function
 		idx, err := ui.SelectOption(
 			params.Prompt,
@@ -164,7 +208,7 @@ func (t *RequestFeedbackTool) Execute(ctx context.Context, params *RequestFeedba
 			t.output,
 		)
 		if err != nil {
-			return "", fmt.Errorf("failed to get user feedback: %w", err)
+			return "", -1, fmt.Errorf("failed to get user feedback: %w", err)
 		}
 
 		selectedIndex = idx
@@ -177,26 +221,11 @@ func (t *RequestFeedbackTool) Execute(ctx context.Context, params *RequestFeedba
 		fmt.Fprintf(t.output, "💬 %s\n", params.Prompt)
 		fmt.Fprint(t.output, "> ")
 
-		// Read user input
-		var input strings.Builder
-		buf := make([]byte, 1)
-		for {
-			n, err := t.input.Read(buf)
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				return "", fmt.Errorf("failed to read user input: %w", err)
-			}
-			if n > 0 {
-				if buf[0] == '\n' {
-					break
-				}
-				input.WriteByte(buf[0])
-			}
+		input, err := t.readLine()
+		if err != nil {
+			return "", -1, err
 		}
-
-		userResponse = strings.TrimSpace(input.String())
+		userResponse = input
 
 		// If user provided no input, return empty string (agent should make own judgment)
 		if userResponse == "" {
@@ -207,24 +236,100 @@ func (t *RequestFeedbackTool) Execute(ctx context.Context, params *RequestFeedba
 	}
 
 	fmt.Fprintln(t.output, separator+"\n")
+	return userResponse, selectedIndex, nil
+}
 
-	// Return the response as a structured JSON
-	response := map[string]interface{}{
-		"user_response": userResponse,
-		"title":         params.Title,
+// readLine reads a line of input byte by byte, leaving the following lines
+// for the next questions
+func (t *RequestFeedbackTool) readLine() (string, error) {
+	var input strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := t.input.Read(buf)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("failed to read user input: %w", err)
+		}
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			input.WriteByte(buf[0])
+		}
 	}
+	return strings.TrimSpace(input.String()), nil
+}
 
-	if selectedIndex >= 0 {
-		response["selected_index"] = selectedIndex
-		response["is_choice"] = true
+// confirmReuse shows the answer to a similar question and asks the user
+// whether it answers this one too
+func (t *RequestFeedbackTool) confirmReuse(previous *FeedbackAnswer) (bool, error) {
+	fmt.Fprintf(t.output, "\n♻️  You answered a similar question, %q (%s): %s\n", previous.Title, answerSource(previous), previous.Answer)
+	fmt.Fprint(t.output, "Use this answer again? [y/N]: ")
+	input, err := t.readLine()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(input) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// answerSource describes where an earlier answer was given
+func answerSource(previous *FeedbackAnswer) string {
+	if previous.Source != "" {
+		return "in session " + previous.Source
+	}
+	return "earlier in this session"
+}
+
+// validateFeedbackParams checks the required fields of a question
+func validateFeedbackParams(params *RequestFeedbackParams) error {
+	if params == nil {
+		return fmt.Errorf("params is required")
+	}
+	if params.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if params.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if params.Prompt == "" {
+		return fmt.Errorf("prompt is required")
+	}
+	return nil
+}
+
+// reuseAnswer answers a question from an earlier answer, to the same question
+// or to a similar one the user confirmed it for
+func (t *RequestFeedbackTool) reuseAnswer(params *RequestFeedbackParams, previous *FeedbackAnswer, index int, confirmed bool) (string, error) {
+	source := answerSource(previous)
+	note := fmt.Sprintf("The user already answered %q %s, so the question was not asked again. "+
+		"If that answer does not fit, ask a more specific question.", previous.Title, source)
+	if confirmed {
+		note = fmt.Sprintf("The user confirmed their answer to %q %s for this question.", previous.Title, source)
 	} else {
-		response["is_choice"] = false
+		fmt.Fprintf(t.output, "\n♻️  Reusing your answer to %q (%s): %s\n", previous.Title, source, previous.Answer)
+	}
+
+	response := map[string]interface{}{
+		"user_response": previous.Answer,
+		"title":         params.Title,
+		"is_choice":     index >= 0,
+		"reused":        true,
+		"note":          note,
+	}
+	if index >= 0 {
+		response["selected_index"] = index
 	}
 
 	responseJSON, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		return userResponse, nil // Fallback to plain text
+		return previous.Answer, nil // Fallback to plain text
 	}
-
 	return string(responseJSON), nil
 }
@@ -9,14 +9,14 @@ import (
 )
 
 func TestRequestFeedbackTool_Name(t *testing.T) {
-	tool := NewRequestFeedbackTool(nil, nil)
+	tool := NewRequestFeedbackTool(nil, nil, nil)
 	if tool.Name() != "request_feedback" {
 		t.Errorf("expected name 'request_feedback', got '%s'", tool.Name())
 	}
 }
 
 func TestRequestFeedbackTool_Description(t *testing.T) {
-	tool := NewRequestFeedbackTool(nil, nil)
+	tool := NewRequestFeedbackTool(nil, nil, nil)
 	desc := tool.Description()
 	if desc == "" {
 		t.Error("description should not be empty")
@@ -167,7 +167,7 @@ func TestRequestFeedbackTool_Execute(t *testing.T) {
 			input := strings.NewReader(tt.userInput)
 			output := &bytes.Buffer{}
 
-			tool := NewRequestFeedbackTool(input, output)
+			tool := NewRequestFeedbackTool(input, output, nil)
 			result, err := tool.Execute(ctx, tt.params)
 
 			if tt.wantErr {
@@ -256,7 +256,7 @@ func TestRequestFeedbackTool_Execute_InvalidInput(t *testing.T) {
 	input := strings.NewReader("invalid\n2\n")
 	output := &bytes.Buffer{}
 
-	tool := NewRequestFeedbackTool(input, output)
+	tool := NewRequestFeedbackTool(input, output, nil)
 	params := &RequestFeedbackParams{
 		Title:   "选择",
 		Content: "需要做出选择",
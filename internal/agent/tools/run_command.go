@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+const (
+	// DefaultCommandTimeout is the default timeout for run_command
+	DefaultCommandTimeout = 5 * time.Minute
+	// DefaultMaxCommandOutput is the number of output bytes returned by run_command
+	DefaultMaxCommandOutput = 16 * 1024
+)
+
+// DefaultTestCommands are the command prefixes run_command accepts by default.
+// They run test suites and don't modify the working tree.
+var DefaultTestCommands = []string{
+	"go test", "go vet", "go build",
+	"pytest", "python -m pytest", "python3 -m pytest", "python -m unittest", "python3 -m unittest",
+	"npm test", "npm run test", "npx jest", "npx vitest", "yarn test", "pnpm test",
+	"cargo test",
+}
+
+// RunCommandParams contains parameters for run_command tool
+type RunCommandParams struct {
+	Command string `json:"command"`
+}
+
+// RunCommandTool runs allowed commands such as test suites in the working
+// directory. Commands are split on whitespace and run without a shell, so
+// pipes, redirection and variable expansion are not available.
+type RunCommandTool struct {
+	workDir   string
+	allowed   []string
+	timeout   time.Duration
+	maxOutput int
+}
+
+// NewRunCommandTool creates a new RunCommandTool accepting commands that start
+// with one of the allowed prefixes, DefaultTestCommands when empty
+func NewRunCommandTool(workDir string, allowed []string, timeout time.Duration) *RunCommandTool {
+	if len(allowed) == 0 {
+		allowed = DefaultTestCommands
+	}
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	return &RunCommandTool{
+		workDir:   workDir,
+		allowed:   allowed,
+		timeout:   timeout,
+		maxOutput: DefaultMaxCommandOutput,
+	}
+}
+
+// Name returns the tool name
+func (t *RunCommandTool) Name() string {
+	return "run_command"
+}
+
+// Description returns the tool description
+func (t *RunCommandTool) Description() string {
+	return fmt.Sprintf(`Run a command in the working directory and return its output and exit code.
+Parameters:
+- command (required): The command line, e.g. "go test ./internal/parser/ -run TestParse"
+Only commands starting with one of these are allowed: %s.
+The command runs without a shell: pipes, redirection and environment variables are not supported.
+Long output is truncated to its last %d KB, and commands are stopped after %s.`,
+		strings.Join(t.allowed, ", "), t.maxOutput/1024, t.timeout)
+}
+
+// CommandResult is the outcome of a command run by run_command
+type CommandResult struct {
+	Command  string // The command line as run
+	Output   string // Combined stdout and stderr, truncated to the last maxOutput bytes
+	ExitCode int
+}
+
+// String formats the result for the model
+func (r CommandResult) String() string {
+	return fmt.Sprintf("$ %s\n%s\nExit code: %d", r.Command, strings.TrimRight(r.Output, "\n"), r.ExitCode)
+}
+
+// Execute runs the command. A non-zero exit code is reported in the result,
+// not as an error, so the caller can read the failures.
+func (t *RunCommandTool) Execute(ctx context.Context, params *RunCommandParams) (string, error) {
+	result, err := t.Run(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// Run runs the command like Execute and returns its outcome
+func (t *RunCommandTool) Run(ctx context.Context, params *RunCommandParams) (CommandResult, error) {
+	if err := sideeffect.Check("run_command"); err != nil {
+		return CommandResult{}, err
+	}
+	if params == nil || strings.TrimSpace(params.Command) == "" {
+		return CommandResult{}, fmt.Errorf("command is required")
+	}
+	args := strings.Fields(params.Command)
+	if !t.isAllowed(args) {
+		return CommandResult{}, fmt.Errorf("command not allowed: %s (allowed: %s)", params.Command, strings.Join(t.allowed, ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = t.workDir
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return CommandResult{}, fmt.Errorf("command timed out after %s", t.timeout)
+	}
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return CommandResult{}, fmt.Errorf("failed to run command: %w", err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	output := string(out)
+	if len(output) > t.maxOutput {
+		output = fmt.Sprintf("... (%d bytes truncated)\n%s", len(output)-t.maxOutput, output[len(output)-t.maxOutput:])
+	}
+	return CommandResult{Command: strings.Join(args, " "), Output: output, ExitCode: exitCode}, nil
+}
+
+// isAllowed reports whether args start with the words of an allowed prefix
+func (t *RunCommandTool) isAllowed(args []string) bool {
+	for _, prefix := range t.allowed {
+		words := strings.Fields(prefix)
+		if len(words) == 0 || len(args) < len(words) {
+			continue
+		}
+		matched := true
+		for i, word := range words {
+			if args[i] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCommandTool_Execute(t *testing.T) {
+	ctx := context.Background()
+	tool := NewRunCommandTool(t.TempDir(), []string{"git --version", "git unknown-command", "sleep"}, 200*time.Millisecond)
+	assert.Equal(t, "run_command", tool.Name())
+	assert.Contains(t, tool.Description(), "git --version, git unknown-command, sleep")
+
+	result, err := tool.Execute(ctx, &RunCommandParams{Command: "git  --version"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "$ git --version\ngit version")
+	assert.Contains(t, result, "Exit code: 0")
+
+	// Failures are reported in the result so the output can be read
+	result, err = tool.Execute(ctx, &RunCommandParams{Command: "git unknown-command"})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "Exit code: 0")
+
+	_, err = tool.Execute(ctx, &RunCommandParams{Command: "git status"})
+	assert.ErrorContains(t, err, "command not allowed")
+	_, err = tool.Execute(ctx, &RunCommandParams{Command: "git"})
+	assert.ErrorContains(t, err, "command not allowed")
+	_, err = tool.Execute(ctx, &RunCommandParams{})
+	assert.ErrorContains(t, err, "command is required")
+
+	_, err = tool.Execute(ctx, &RunCommandParams{Command: "sleep 5"})
+	assert.ErrorContains(t, err, "timed out")
+}
+
+func TestRunCommandTool_DefaultCommands(t *testing.T) {
+	tool := NewRunCommandTool(t.TempDir(), nil, 0)
+	assert.True(t, tool.isAllowed([]string{"go", "test", "./..."}))
+	assert.True(t, tool.isAllowed([]string{"python3", "-m", "pytest", "tests/"}))
+	assert.False(t, tool.isAllowed([]string{"go", "run", "main.go"}))
+	assert.False(t, tool.isAllowed([]string{"rm", "-rf", "/"}))
+}
+
+func TestRunCommandTool_TruncatesOutput(t *testing.T) {
+	tool := NewRunCommandTool(t.TempDir(), []string{"git --version"}, 0)
+	tool.maxOutput = 5
+	result, err := tool.Execute(context.Background(), &RunCommandParams{Command: "git --version"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "bytes truncated)")
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -44,8 +45,15 @@ type SubmitCommitParams struct {
 	// Footer is for breaking changes or issue references (optional)
 	// Example: "BREAKING CHANGE: description" or "Closes #123"
 	Footer string `json:"footer,omitempty" jsonschema:"description=Footer for breaking changes or issue references. Example: BREAKING CHANGE: xxx or Closes #123"`
+
+	// NotBreaking explains why likely breaking changes found in the diff
+	// don't break callers (optional, not part of the message)
+	NotBreaking string `json:"not_breaking,omitempty" jsonschema:"description=When likely breaking changes were detected but the commit has no BREAKING CHANGE footer: why the changes don't break callers"`
 }
 
+// breakingChangeFooter matches a Conventional Commits breaking change footer
+var breakingChangeFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: \S`)
+
 // Validate validates the commit parameters
 func (p *SubmitCommitParams) Validate() error {
 	if p.Type == "" {
@@ -60,6 +68,20 @@ func (p *SubmitCommitParams) Validate() error {
 	return nil
 }
 
+// IsBreaking reports whether the footer declares a breaking change
+func (p *SubmitCommitParams) IsBreaking() bool {
+	return breakingChangeFooter.MatchString(p.Footer)
+}
+
+// ValidateBreakingChange requires a BREAKING CHANGE footer, or a reason in
+// NotBreaking, when likely breaking changes were detected in the diff
+func (p *SubmitCommitParams) ValidateBreakingChange(detected []string) error {
+	if len(detected) == 0 || p.IsBreaking() || strings.TrimSpace(p.NotBreaking) != "" {
+		return nil
+	}
+	return fmt.Errorf("likely breaking changes were detected (%s): add a \"BREAKING CHANGE: <description>\" footer, or explain in not_breaking why callers are not affected", strings.Join(detected, "; "))
+}
+
 // FormatMessage formats the commit message according to Conventional Commits
 func (p *SubmitCommitParams) FormatMessage() string {
 	var parts []string
@@ -117,7 +139,8 @@ Parameters:
 - scope (optional): The scope of the commit (e.g., auth, api, ui)
 - description (required): Short description of the change, use imperative mood, do not end with period
 - body (optional): Detailed description explaining what and why
-- footer (optional): For breaking changes or issue references`
+- footer (optional): For breaking changes or issue references
+- not_breaking (optional): Why likely breaking changes detected in the diff don't break callers`
 }
 
 // Execute runs the tool with the given parameters
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitCommitParams_ValidateBreakingChange(t *testing.T) {
+	detected := []string{"removed exported API: func Parse(s string) error"}
+
+	tests := []struct {
+		name     string
+		params   SubmitCommitParams
+		detected []string
+		wantErr  bool
+	}{
+		{name: "nothing detected", params: SubmitCommitParams{Type: "feat", Description: "add parser"}},
+		{name: "footer", params: SubmitCommitParams{Type: "feat", Description: "drop Parse", Footer: "BREAKING CHANGE: Parse was removed"}, detected: detected},
+		{name: "hyphenated footer after a reference", params: SubmitCommitParams{Type: "feat", Description: "drop Parse", Footer: "Closes #12\nBREAKING-CHANGE: Parse was removed"}, detected: detected},
+		{name: "justified", params: SubmitCommitParams{Type: "refactor", Description: "move Parse", NotBreaking: "Parse is only used within this module"}, detected: detected},
+		{name: "missing footer", params: SubmitCommitParams{Type: "refactor", Description: "move Parse"}, detected: detected, wantErr: true},
+		{name: "footer in lowercase", params: SubmitCommitParams{Type: "refactor", Description: "move Parse", Footer: "breaking change: Parse was removed"}, detected: detected, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.ValidateBreakingChange(tt.detected)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "func Parse(s string) error")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // SubmitReportParams contains parameters for submitting a debug report
@@ -28,6 +31,7 @@ type DebugReport struct {
 // SubmitReportTool is a tool for submitting and saving debug reports
 type SubmitReportTool struct {
 	issuesDir string
+	metadata  func() reports.Metadata
 }
 
 // NewSubmitReportTool creates a new SubmitReportTool
@@ -40,6 +44,12 @@ func NewSubmitReportTool(issuesDir string) *SubmitReportTool {
 	}
 }
 
+// SetMetadata sets the function describing the investigation in the front
+// matter of saved reports. Title and date are always filled in by the tool.
+func (t *SubmitReportTool) SetMetadata(metadata func() reports.Metadata) {
+	t.metadata = metadata
+}
+
 // Name returns the tool name
 func (t *SubmitReportTool) Name() string {
 	return "submit_report"
@@ -92,27 +102,41 @@ Returns a confirmation message with the saved file path.`
 
 // Execute runs the tool and saves the report
 func (t *SubmitReportTool) Execute(ctx context.Context, params *SubmitReportParams) (string, error) {
+	report, err := t.Save(params)
+	if err != nil {
+		return "", err
+	}
+
+	// Return success message
+	return t.formatSuccessMessage(report), nil
+}
+
+// Save writes the report, with its front matter, to the issues directory
+func (t *SubmitReportTool) Save(params *SubmitReportParams) (*DebugReport, error) {
+	if err := sideeffect.Check("submit_report"); err != nil {
+		return nil, err
+	}
 	if params == nil {
-		return "", fmt.Errorf("params is required")
+		return nil, fmt.Errorf("params is required")
 	}
 
 	if params.Title == "" {
-		return "", fmt.Errorf("title is required")
+		return nil, fmt.Errorf("title is required")
 	}
 
 	if params.Content == "" {
-		return "", fmt.Errorf("content is required")
+		return nil, fmt.Errorf("content is required")
 	}
 
 	// Create issues directory if it doesn't exist
 	if err := os.MkdirAll(t.issuesDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create issues directory: %w", err)
+		return nil, fmt.Errorf("failed to create issues directory: %w", err)
 	}
 
 	// Get next issue ID
 	issueID, err := t.getNextIssueID()
 	if err != nil {
-		return "", fmt.Errorf("failed to get next issue ID: %w", err)
+		return nil, fmt.Errorf("failed to get next issue ID: %w", err)
 	}
 
 	// Generate filename
@@ -121,21 +145,29 @@ func (t *SubmitReportTool) Execute(ctx context.Context, params *SubmitReportPara
 	filename := fmt.Sprintf("issue-%03d-%s-%s.md", issueID, slug, date)
 	filePath := filepath.Join(t.issuesDir, filename)
 
+	var meta reports.Metadata
+	if t.metadata != nil {
+		meta = t.metadata()
+	}
+	meta.Title = params.Title
+	meta.Date = date
+	content, err := reports.Format(meta, params.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	// Write report to file
-	if err := os.WriteFile(filePath, []byte(params.Content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write report file: %w", err)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write report file: %w", err)
 	}
 
-	report := &DebugReport{
+	return &DebugReport{
 		Title:    params.Title,
 		Content:  params.Content,
 		IssueID:  issueID,
 		Date:     date,
 		FilePath: filePath,
-	}
-
-	// Return success message
-	return t.formatSuccessMessage(report), nil
+	}, nil
 }
 
 // getNextIssueID scans the issues directory and returns the next available issue ID
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
 )
 
 func TestSubmitReportTool_Name(t *testing.T) {
@@ -126,9 +128,13 @@ func TestSubmitReportTool_Execute(t *testing.T) {
 						t.Fatalf("failed to read report file: %v", err)
 					}
 
-					if string(content) != tt.params.Content {
+					meta, body := reports.Parse(content)
+					if body != tt.params.Content {
 						t.Error("file content does not match expected content")
 					}
+					if meta.Title != tt.params.Title {
+						t.Errorf("expected front matter title %q, got %q", tt.params.Title, meta.Title)
+					}
 					break
 				}
 			}
@@ -186,6 +192,33 @@ func TestSubmitReportTool_Execute_IssueIDIncrement(t *testing.T) {
 	}
 }
 
+func TestSubmitReportTool_Save_Metadata(t *testing.T) {
+	tool := NewSubmitReportTool(t.TempDir())
+	tool.SetMetadata(func() reports.Metadata {
+		return reports.Metadata{Title: "ignored", Session: "debug-1", Files: []string{"auth.go"}, Phases: []string{"problem_definition"}}
+	})
+
+	report, err := tool.Save(&SubmitReportParams{Title: "Login Fails", Content: "# Login Fails"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(report.FilePath)
+	if err != nil {
+		t.Fatalf("failed to read report file: %v", err)
+	}
+
+	meta, body := reports.Parse(content)
+	if meta.Title != "Login Fails" || meta.Date != report.Date {
+		t.Errorf("title and date should come from the report, got %q and %q", meta.Title, meta.Date)
+	}
+	if meta.Session != "debug-1" || len(meta.Files) != 1 || len(meta.Phases) != 1 {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+	if body != "# Login Fails" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
 func TestSubmitReportTool_TitleToSlug(t *testing.T) {
 	tool := NewSubmitReportTool("./issues")
 
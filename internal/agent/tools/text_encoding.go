@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	// encodingSampleSize is how much of a file is inspected to detect its encoding
+	encodingSampleSize = 8000
+	// minifiedLineLength is the line length from which JSON counts as minified
+	minifiedLineLength = 500
+)
+
+// decodedText is file content converted to UTF-8
+type decodedText struct {
+	Text     []byte
+	Encoding string // Encoding the text was converted from, "" for UTF-8
+	Binary   bool   // The content doesn't look like text at all
+}
+
+// decodeText detects the encoding of content and converts it to UTF-8.
+// UTF-16 is recognized by its byte order mark or its zero bytes, other
+// invalid UTF-8 is read as GBK when it decodes cleanly and as Windows-1252
+// otherwise.
+func decodeText(content []byte) decodedText {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return decodedText{Text: content[3:]}
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}), bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeWith(content, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "UTF-16")
+	}
+
+	sample := content[:min(len(content), encodingSampleSize)]
+	if endianness, ok := utf16Endianness(sample); ok {
+		return decodeWith(content, unicode.UTF16(endianness, unicode.IgnoreBOM), "UTF-16")
+	}
+	if bytes.IndexByte(sample, 0) >= 0 || controlRatio(sample) > 0.1 {
+		return decodedText{Binary: true}
+	}
+	if utf8.Valid(content) {
+		return decodedText{Text: content}
+	}
+
+	if decoded := decodeWith(content, simplifiedchinese.GB18030, "GBK"); !bytes.ContainsRune(decoded.Text, utf8.RuneError) {
+		return decoded
+	}
+	return decodeWith(content, charmap.Windows1252, "Windows-1252")
+}
+
+// decodeWith converts content from enc, treating content that fails to
+// convert as binary
+func decodeWith(content []byte, enc encoding.Encoding, name string) decodedText {
+	text, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return decodedText{Binary: true}
+	}
+	return decodedText{Text: text, Encoding: name}
+}
+
+// utf16Endianness recognizes UTF-16 without a byte order mark by the zero
+// high bytes of its ASCII characters, which are at odd offsets in little
+// endian and at even offsets in big endian
+func utf16Endianness(sample []byte) (unicode.Endianness, bool) {
+	pairs := len(sample) / 2
+	if pairs < 2 {
+		return unicode.LittleEndian, false
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*20 < pairs:
+		return unicode.LittleEndian, true
+	case evenZeros*10 >= pairs*4 && oddZeros*20 < pairs:
+		return unicode.BigEndian, true
+	}
+	return unicode.LittleEndian, false
+}
+
+// controlRatio returns the share of control characters other than whitespace
+// and escape sequences
+func controlRatio(sample []byte) float64 {
+	if len(sample) == 0 {
+		return 0
+	}
+	controls := 0
+	for _, b := range sample {
+		if b < 0x20 && !strings.ContainsRune("\t\n\r\f\v\x1b", rune(b)) {
+			controls++
+		}
+	}
+	return float64(controls) / float64(len(sample))
+}
+
+// prettyJSON indents minified JSON: JSON files, or content that parses as
+// JSON, with a line longer than minifiedLineLength
+func prettyJSON(path string, text []byte) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(text)
+	isJSONFile := strings.EqualFold(filepath.Ext(path), ".json")
+	if !isJSONFile && !bytes.HasPrefix(trimmed, []byte("{")) && !bytes.HasPrefix(trimmed, []byte("[")) {
+		return nil, false
+	}
+
+	minified := false
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		if len(line) > minifiedLineLength {
+			minified = true
+			break
+		}
+	}
+	if !minified || !json.Valid(trimmed) {
+		return nil, false
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, trimmed, "", "  "); err != nil {
+		return nil, false
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), true
+}
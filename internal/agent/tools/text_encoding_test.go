@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDecodeText(t *testing.T) {
+	source := "// 读取配置文件\nfunc Load() {}\n"
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(source))
+	require.NoError(t, err)
+	utf16LE, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte(source))
+	require.NoError(t, err)
+	utf16BE, err := unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(source))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		encoding string
+	}{
+		{"utf-8", []byte(source), ""},
+		{"utf-8 with BOM", append([]byte{0xEF, 0xBB, 0xBF}, source...), ""},
+		{"gbk", gbk, "GBK"},
+		{"utf-16 without BOM", utf16LE, "UTF-16"},
+		{"utf-16 with BOM", utf16BE, "UTF-16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded := decodeText(tt.content)
+			assert.False(t, decoded.Binary)
+			assert.Equal(t, tt.encoding, decoded.Encoding)
+			assert.Equal(t, source, string(decoded.Text))
+		})
+	}
+
+	latin1 := decodeText([]byte("caf\xe9 cr\xe8me\n"))
+	assert.Equal(t, "Windows-1252", latin1.Encoding)
+	assert.Equal(t, "café crème\n", string(latin1.Text))
+
+	assert.True(t, decodeText([]byte("\x7fELF\x02\x01\x01\x00\x00\x00")).Binary)
+	assert.True(t, decodeText([]byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x01, 0x02, 0x03, 0x04}).Binary)
+}
+
+func TestPrettyJSON(t *testing.T) {
+	minified := `{"name":"demo","items":[` + strings.Repeat(`{"id":1,"tags":["a","b"]},`, 30) + `{"id":2}]}`
+
+	pretty, ok := prettyJSON("data.json", []byte(minified))
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(string(pretty), "{\n  \"name\": \"demo\",\n"))
+
+	_, ok = prettyJSON("response.txt", []byte(minified))
+	assert.True(t, ok, "content that parses as JSON")
+
+	_, ok = prettyJSON("data.json", []byte("{\n  \"name\": \"demo\"\n}\n"))
+	assert.False(t, ok, "already formatted")
+	_, ok = prettyJSON("data.json", []byte(minified[:len(minified)-1]))
+	assert.False(t, ok, "invalid JSON")
+	_, ok = prettyJSON("main.go", []byte(strings.Repeat("x", minifiedLineLength+1)))
+	assert.False(t, ok)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent/backup"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 )
 
 // WriteFileParams contains parameters for writing a file
@@ -48,6 +49,9 @@ Safety: File operations are restricted to the working directory and subdirectori
 
 // Execute runs the tool and writes content to the specified file
 func (t *WriteFileTool) Execute(ctx context.Context, params *WriteFileParams) (string, error) {
+	if err := sideeffect.Check("write_file"); err != nil {
+		return "", err
+	}
 	if params == nil || params.FilePath == "" {
 		return "", fmt.Errorf("file_path is required")
 	}
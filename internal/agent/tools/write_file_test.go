@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -379,4 +380,14 @@ func TestWriteFileTool_RestrictedFiles(t *testing.T) {
 			assert.Contains(t, err.Error(), tt.errMsg)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestWriteFileTool_CheckMode(t *testing.T) {
+	sideeffect.SetBlocked(true)
+	defer sideeffect.SetBlocked(false)
+
+	tmpDir := t.TempDir()
+	_, err := NewWriteFileTool(tmpDir).Execute(context.Background(), &WriteFileParams{FilePath: "test.txt", Content: "x"})
+	assert.ErrorIs(t, err, sideeffect.ErrBlocked)
+	assert.NoFileExists(t, filepath.Join(tmpDir, "test.txt"))
+}
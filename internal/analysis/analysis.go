@@ -0,0 +1,268 @@
+// Package analysis extracts structured facts from diffs with lightweight,
+// language-specific heuristics, such as new exported APIs, changed function
+// signatures and added dependencies. The facts are given to the model next to
+// the diff so it describes what actually changed instead of guessing.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fact kinds
+const (
+	KindNewAPI            = "new exported API"
+	KindRemovedAPI        = "removed exported API"
+	KindChangedSignature  = "changed signature"
+	KindAddedDependency   = "added dependency"
+	KindUpdatedDependency = "updated dependency"
+	KindRemovedDependency = "removed dependency"
+)
+
+// maxFacts caps the facts included in a prompt
+const maxFacts = 40
+
+// Fact is a structured observation about a diff
+type Fact struct {
+	Language string // Analyzer that found the fact, e.g. "Go"
+	File     string
+	Kind     string // One of the Kind constants
+	Detail   string // The declaration or dependency, e.g. "func Parse(s string) error"
+	Breaking bool   // The change likely breaks callers of an exported API
+}
+
+// String renders the fact as a single line
+func (f Fact) String() string {
+	return fmt.Sprintf("[%s] %s: %s: %s", f.Language, f.File, f.Kind, f.Detail)
+}
+
+// FileDiff holds the changes of one file
+type FileDiff struct {
+	Path    string
+	Added   []string // Added lines without their + prefix
+	Removed []string // Removed lines without their - prefix
+	Lines   []string // Hunk headers and lines with their ' ', '+' or '-' prefix
+}
+
+// Analyzer extracts facts from the files of one language
+type Analyzer interface {
+	// Language names the language, e.g. "Go"
+	Language() string
+	// Match reports whether the analyzer handles the file at path
+	Match(path string) bool
+	// Analyze returns the facts found in the changes of one file
+	Analyze(file FileDiff) []Fact
+}
+
+var (
+	mu        sync.RWMutex
+	analyzers = []Analyzer{goAnalyzer{}, pythonAnalyzer{}, jsAnalyzer{}}
+)
+
+// Register adds an analyzer. Files are handled by every analyzer that matches them.
+func Register(a Analyzer) {
+	mu.Lock()
+	defer mu.Unlock()
+	analyzers = append(analyzers, a)
+}
+
+// Analyze returns the facts the registered analyzers find in a unified diff
+func Analyze(diff string) []Fact {
+	mu.RLock()
+	registered := append([]Analyzer(nil), analyzers...)
+	mu.RUnlock()
+
+	var facts []Fact
+	for _, file := range ParseDiff(diff) {
+		for _, a := range registered {
+			if a.Match(file.Path) {
+				facts = append(facts, a.Analyze(file)...)
+			}
+		}
+	}
+	return facts
+}
+
+// FormatFacts renders facts as a prompt block, or an empty string without facts
+func FormatFacts(facts []Fact) string {
+	if len(facts) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Facts from static analysis of the diff\n")
+	b.WriteString("These were extracted from the changes by language-specific heuristics. Use them to describe the change accurately, and don't claim API or dependency changes that are not listed here or visible in the diff.\n")
+	for i, fact := range facts {
+		if i == maxFacts {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(facts)-maxFacts)
+			break
+		}
+		b.WriteString("- " + fact.String() + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// BreakingFacts returns the facts that likely break callers of an exported API
+func BreakingFacts(facts []Fact) []Fact {
+	var breaking []Fact
+	for _, fact := range facts {
+		if fact.Breaking {
+			breaking = append(breaking, fact)
+		}
+	}
+	return breaking
+}
+
+// ParseDiff splits a unified diff into the changed lines of each file. Both
+// `git diff` output and plain `diff -u` output are supported.
+func ParseDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var current *FileDiff
+	var oldPath string
+	gitStyle := strings.HasPrefix(diff, "diff --git ") || strings.Contains(diff, "\ndiff --git ")
+	inHunk := false
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileDiff{})
+			current = &files[len(files)-1]
+			inHunk = false
+			if parts := strings.SplitN(strings.TrimPrefix(line, "diff --git "), " b/", 2); len(parts) == 2 {
+				current.Path = parts[1]
+			}
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+			if current != nil {
+				current.Lines = append(current.Lines, line)
+			}
+		case inHunk && gitStyle || inHunk && !strings.HasPrefix(line, "--- "):
+			if current == nil {
+				continue
+			}
+			current.Lines = append(current.Lines, line)
+			if strings.HasPrefix(line, "+") {
+				current.Added = append(current.Added, line[1:])
+			} else if strings.HasPrefix(line, "-") {
+				current.Removed = append(current.Removed, line[1:])
+			}
+		case strings.HasPrefix(line, "--- "):
+			oldPath = headerPath(strings.TrimPrefix(line, "--- "))
+			inHunk = false
+		case strings.HasPrefix(line, "+++ "):
+			if !gitStyle {
+				files = append(files, FileDiff{})
+				current = &files[len(files)-1]
+				inHunk = false
+			}
+			if current == nil {
+				continue
+			}
+			if path := headerPath(strings.TrimPrefix(line, "+++ ")); path != "/dev/null" {
+				current.Path = path
+			} else {
+				current.Path = oldPath
+			}
+		}
+	}
+	return files
+}
+
+// headerPath extracts the path from a ---/+++ header line
+func headerPath(header string) string {
+	if i := strings.IndexByte(header, '\t'); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+	if strings.HasPrefix(header, "a/") || strings.HasPrefix(header, "b/") {
+		return header[2:]
+	}
+	return header
+}
+
+// declaration is a named declaration found in the changed lines
+type declaration struct {
+	name      string
+	signature string // Normalized declaration line
+	exported  bool
+}
+
+// compareDeclarations reports new and removed exported declarations and
+// declarations whose signature changed between removed and added lines
+func compareDeclarations(language, path string, removed, added []declaration) []Fact {
+	before := make(map[string]declaration)
+	for _, d := range removed {
+		before[d.name] = d
+	}
+	after := make(map[string]declaration)
+	for _, d := range added {
+		after[d.name] = d
+	}
+
+	var facts []Fact
+	for _, d := range added {
+		old, existed := before[d.name]
+		switch {
+		case !existed && d.exported:
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindNewAPI, Detail: d.signature})
+		case existed && old.signature != d.signature:
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindChangedSignature, Detail: old.signature + " -> " + d.signature, Breaking: old.exported})
+		}
+	}
+	for _, d := range removed {
+		if _, kept := after[d.name]; !kept && d.exported {
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindRemovedAPI, Detail: d.signature, Breaking: true})
+		}
+	}
+	return facts
+}
+
+// compareDependencies reports added, updated and removed dependencies given
+// the name -> version maps of the removed and added lines
+func compareDependencies(language, path string, removed, added map[string]string) []Fact {
+	var facts []Fact
+	for _, name := range sortedKeys(added) {
+		version := added[name]
+		old, existed := removed[name]
+		switch {
+		case !existed:
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindAddedDependency, Detail: strings.TrimSpace(name + " " + version)})
+		case old != version:
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindUpdatedDependency, Detail: fmt.Sprintf("%s %s -> %s", name, old, version)})
+		}
+	}
+	for _, name := range sortedKeys(removed) {
+		if _, kept := added[name]; !kept {
+			facts = append(facts, Fact{Language: language, File: path, Kind: KindRemovedDependency, Detail: name})
+		}
+	}
+	return facts
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valueSignature normalizes a variable, constant or type alias declaration
+// without its value, so changed values are not reported as signature changes
+func valueSignature(line string) string {
+	if i := strings.Index(line, "="); i >= 0 {
+		line = line[:i]
+	}
+	return normalizeSignature(line)
+}
+
+// normalizeSignature collapses whitespace and drops a trailing opening brace or colon
+func normalizeSignature(line string) string {
+	line = strings.Join(strings.Fields(line), " ")
+	line = strings.TrimSuffix(line, "{")
+	line = strings.TrimSuffix(line, ":")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze_Go(t *testing.T) {
+	diff := `diff --git a/internal/git/shallow.go b/internal/git/shallow.go
+--- a/internal/git/shallow.go
++++ b/internal/git/shallow.go
+@@ -1,8 +1,12 @@
+-func ShallowCutoff(ctx context.Context, workDir, since string) (time.Time, error) {
++func ShallowCutoff(ctx context.Context, workDir, rev, since string) (time.Time, error) {
++func Unshallow(ctx context.Context, workDir string) error {
++func (e *DefaultExecutor) StagedFileSize(ctx context.Context, path string) (int64, error) {
++func helper() {}
+-const MaxDepth = 10
++const MaxDepth = 20
+-type Options struct {
++var ErrShallow = errors.New("shallow")
+diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,5 +3,6 @@ go 1.24
+ require (
+-	github.com/spf13/cobra v1.8.0
++	github.com/spf13/cobra v1.9.1
++	golang.org/x/sync v0.7.0 // indirect
+-	github.com/pkg/errors v0.9.1
+ )
+`
+	var details []string
+	for _, fact := range Analyze(diff) {
+		assert.Equal(t, "Go", fact.Language)
+		details = append(details, fact.Kind+": "+fact.Detail)
+	}
+	assert.Equal(t, []string{
+		"changed signature: func ShallowCutoff(ctx context.Context, workDir, since string) (time.Time, error) -> func ShallowCutoff(ctx context.Context, workDir, rev, since string) (time.Time, error)",
+		"new exported API: func Unshallow(ctx context.Context, workDir string) error",
+		"new exported API: func (e *DefaultExecutor) StagedFileSize(ctx context.Context, path string) (int64, error)",
+		"new exported API: var ErrShallow",
+		"removed exported API: type Options struct",
+		"updated dependency: github.com/spf13/cobra v1.8.0 -> v1.9.1",
+		"added dependency: golang.org/x/sync v0.7.0",
+		"removed dependency: github.com/pkg/errors",
+	}, details)
+
+	var breaking []string
+	for _, fact := range BreakingFacts(Analyze(diff)) {
+		breaking = append(breaking, fact.Kind+": "+fact.Detail)
+	}
+	assert.Equal(t, []string{
+		"changed signature: func ShallowCutoff(ctx context.Context, workDir, since string) (time.Time, error) -> func ShallowCutoff(ctx context.Context, workDir, rev, since string) (time.Time, error)",
+		"removed exported API: type Options struct",
+	}, breaking)
+}
+
+func TestAnalyze_Python(t *testing.T) {
+	diff := `--- a/app/service.py
++++ b/app/service.py
+@@ -1,4 +1,6 @@
+-def fetch(url):
++def fetch(url, timeout=10):
++class Client:
++    def close(self):
++def _internal():
+--- a/requirements.txt
++++ b/requirements.txt
+@@ -1,2 +1,3 @@
+-requests==2.31.0
++requests==2.32.3
++httpx>=0.27 ; python_version >= "3.8"
+--- a/pyproject.toml
++++ b/pyproject.toml
+@@ -1,3 +1,4 @@
+-version = "0.1.0"
++version = "0.2.0"
++    "rich>=13",
++pydantic = "^2.7"
+`
+	var details []string
+	for _, fact := range Analyze(diff) {
+		details = append(details, fact.File+": "+fact.Kind+": "+fact.Detail)
+	}
+	assert.Equal(t, []string{
+		"app/service.py: changed signature: def fetch(url) -> def fetch(url, timeout=10)",
+		"app/service.py: new exported API: class Client",
+		"requirements.txt: added dependency: httpx >=0.27",
+		"requirements.txt: updated dependency: requests ==2.31.0 -> ==2.32.3",
+		"pyproject.toml: added dependency: pydantic ^2.7",
+		"pyproject.toml: added dependency: rich >=13",
+	}, details)
+}
+
+func TestAnalyze_JavaScript(t *testing.T) {
+	diff := `diff --git a/src/api.ts b/src/api.ts
+--- a/src/api.ts
++++ b/src/api.ts
+@@ -1,5 +1,7 @@
+-export async function getUser(id: string): Promise<User> {
++export async function getUser(id: string, opts?: Options): Promise<User> {
++export const fetchAll = async (ids: string[]) => {
++export interface Options {
++const local = (x) => x * 2;
+-export const VERSION = "1.0";
++export const VERSION = "1.1";
+diff --git a/src/api.test.ts b/src/api.test.ts
+--- a/src/api.test.ts
++++ b/src/api.test.ts
+@@ -1 +1,2 @@
++export function helper() {}
+diff --git a/package.json b/package.json
+--- a/package.json
++++ b/package.json
+@@ -2,6 +2,7 @@
+-  "version": "1.0.0",
++  "version": "1.1.0",
++    "build": "tsc -p .",
++    "@tanstack/react-query": "^5.40.0",
+-    "axios": "^1.6.0"
++    "axios": "^1.7.2"
+`
+	var details []string
+	for _, fact := range Analyze(diff) {
+		details = append(details, fact.Kind+": "+fact.Detail)
+	}
+	assert.Equal(t, []string{
+		"changed signature: export async function getUser(id: string): Promise<User> -> export async function getUser(id: string, opts?: Options): Promise<User>",
+		"new exported API: export const fetchAll = async (ids: string[]) =>",
+		"new exported API: export interface Options",
+		"added dependency: @tanstack/react-query ^5.40.0",
+		"updated dependency: axios ^1.6.0 -> ^1.7.2",
+	}, details)
+}
+
+type markdownAnalyzer struct{}
+
+func (markdownAnalyzer) Language() string       { return "Markdown" }
+func (markdownAnalyzer) Match(path string) bool { return strings.HasSuffix(path, ".md") }
+func (markdownAnalyzer) Analyze(file FileDiff) []Fact {
+	return []Fact{{Language: "Markdown", File: file.Path, Kind: "heading", Detail: strings.TrimSpace(file.Added[0])}}
+}
+
+func TestRegisterAndFormatFacts(t *testing.T) {
+	assert.Empty(t, FormatFacts(nil))
+
+	Register(markdownAnalyzer{})
+	facts := Analyze("--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-# Old\n+# New\n")
+	require.Len(t, facts, 1)
+
+	block := FormatFacts(facts)
+	assert.True(t, strings.HasPrefix(block, "## Facts from static analysis of the diff\n"))
+	assert.True(t, strings.HasSuffix(block, "\n- [Markdown] README.md: heading: # New"))
+
+	many := make([]Fact, maxFacts+3)
+	assert.Contains(t, FormatFacts(many), "- ... and 3 more")
+}
@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ChangelogSchemaVersion is the version of the Changelog format. It is
+// raised when fields are renamed, removed or change meaning; new fields keep it.
+const ChangelogSchemaVersion = 1
+
+// Changelog summarizes a diff for release tooling
+type Changelog struct {
+	SchemaVersion     int      `json:"schema_version" yaml:"schema_version"`
+	Modules           []string `json:"modules" yaml:"modules"`                       // Directories with changed files, "." for the root
+	Migrations        []string `json:"migrations" yaml:"migrations"`                 // Changed migration files
+	FeatureFlags      []string `json:"feature_flags" yaml:"feature_flags"`           // Flags referenced by added lines but not by removed ones
+	AddedDependencies []string `json:"added_dependencies" yaml:"added_dependencies"` // Dependencies added by manifests, with their version
+	Breaking          bool     `json:"breaking" yaml:"breaking"`                     // Exported APIs likely changed incompatibly
+}
+
+// featureFlagPatterns match the flag key in calls of common feature flag
+// SDKs, e.g. IsEnabled("new-checkout"), client.variation('beta', user, false)
+// or GetBooleanValue(ctx, "dark_mode", false)
+var featureFlagPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:is_?enabled|is_?feature_?enabled|feature_?enabled|feature_?flag|flag_?enabled|is_?on|(?:bool|string|int|json)?_?variation|get_?(?:boolean|string|integer|number|object)_?value)\s*\(\s*(?:[\w.]+\s*,\s*)?["'` + "`" + `]([\w.:/-]+)["'` + "`" + `]`),
+}
+
+// BuildChangelog summarizes diff for release tooling. isMigration reports
+// whether a changed file is a schema migration (nil = none are).
+func BuildChangelog(diff string, isMigration func(path string) bool) Changelog {
+	changelog := Changelog{
+		SchemaVersion:     ChangelogSchemaVersion,
+		Modules:           []string{},
+		Migrations:        []string{},
+		FeatureFlags:      []string{},
+		AddedDependencies: []string{},
+	}
+
+	modules := make(map[string]bool)
+	added := make(map[string]bool)
+	removed := make(map[string]bool)
+	for _, file := range ParseDiff(diff) {
+		if file.Path == "" {
+			continue
+		}
+		modules[path.Dir(file.Path)] = true
+		if isMigration != nil && isMigration(file.Path) {
+			changelog.Migrations = append(changelog.Migrations, file.Path)
+		}
+		for _, flag := range featureFlags(file.Added) {
+			added[flag] = true
+		}
+		for _, flag := range featureFlags(file.Removed) {
+			removed[flag] = true
+		}
+	}
+	for module := range modules {
+		changelog.Modules = append(changelog.Modules, module)
+	}
+	sort.Strings(changelog.Modules)
+	for flag := range added {
+		if !removed[flag] {
+			changelog.FeatureFlags = append(changelog.FeatureFlags, flag)
+		}
+	}
+	sort.Strings(changelog.FeatureFlags)
+
+	facts := Analyze(diff)
+	for _, fact := range facts {
+		if fact.Kind == KindAddedDependency {
+			changelog.AddedDependencies = append(changelog.AddedDependencies, fact.Detail)
+		}
+	}
+	changelog.Breaking = len(BreakingFacts(facts)) > 0
+	return changelog
+}
+
+// featureFlags returns the flag keys used in lines
+func featureFlags(lines []string) []string {
+	var flags []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		for _, pattern := range featureFlagPatterns {
+			for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+				flags = append(flags, match[1])
+			}
+		}
+	}
+	return flags
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildChangelog(t *testing.T) {
+	diff := `diff --git a/internal/checkout/checkout.go b/internal/checkout/checkout.go
+--- a/internal/checkout/checkout.go
++++ b/internal/checkout/checkout.go
+@@ -1,6 +1,8 @@
+-func Total(items []Item) int {
++func Total(items []Item, currency string) int {
+-	if flags.IsEnabled("legacy-tax") {
++	if flags.IsEnabled("legacy-tax") && client.BoolVariation("new-checkout", user, false) {
++	// flags.IsEnabled("commented-out")
++	enabled, _ := of.GetBooleanValue(ctx, "express_pay", false, evalCtx)
+diff --git a/web/src/cart.js b/web/src/cart.js
+--- a/web/src/cart.js
++++ b/web/src/cart.js
+@@ -1,2 +1,3 @@
++if (ldClient.variation('cart.v2', false)) {}
+diff --git a/db/migrations/0003_add_currency.sql b/db/migrations/0003_add_currency.sql
+new file mode 100644
+--- /dev/null
++++ b/db/migrations/0003_add_currency.sql
+@@ -0,0 +1 @@
++ALTER TABLE orders ADD COLUMN currency TEXT;
+diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3,2 +3,3 @@
+ require (
++	golang.org/x/text v0.14.0
+ )
+`
+	changelog := BuildChangelog(diff, func(path string) bool {
+		return strings.HasPrefix(path, "db/migrations/")
+	})
+
+	assert.Equal(t, ChangelogSchemaVersion, changelog.SchemaVersion)
+	assert.Equal(t, []string{".", "db/migrations", "internal/checkout", "web/src"}, changelog.Modules)
+	assert.Equal(t, []string{"db/migrations/0003_add_currency.sql"}, changelog.Migrations)
+	assert.Equal(t, []string{"cart.v2", "express_pay", "new-checkout"}, changelog.FeatureFlags, "flags that were already used and comments are left out")
+	assert.Equal(t, []string{"golang.org/x/text v0.14.0"}, changelog.AddedDependencies)
+	assert.True(t, changelog.Breaking)
+}
+
+func TestBuildChangelog_Empty(t *testing.T) {
+	changelog := BuildChangelog("", nil)
+	assert.Equal(t, Changelog{
+		SchemaVersion:     ChangelogSchemaVersion,
+		Modules:           []string{},
+		Migrations:        []string{},
+		FeatureFlags:      []string{},
+		AddedDependencies: []string{},
+	}, changelog)
+}
@@ -0,0 +1,254 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	npmLockPackagePattern = regexp.MustCompile(`^\s*"(?:[^"]*/)?node_modules/((?:@[^/"]+/)?[^/"]+)"\s*:\s*\{\s*$`)
+	npmLockVersionPattern = regexp.MustCompile(`^\s*"version"\s*:\s*"([^"]+)"`)
+	versionNumberPattern  = regexp.MustCompile(`\d+(?:\.\d+)?`)
+)
+
+// lockfiles are generated files pinning the full dependency tree
+var lockfiles = map[string]bool{
+	"go.sum": true, "go.work.sum": true,
+	"package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"poetry.lock": true, "Pipfile.lock": true, "uv.lock": true,
+}
+
+// manifests declare the direct dependencies of a project
+var manifests = map[string]bool{
+	"go.mod": true, "go.work": true, "package.json": true, "pyproject.toml": true, "Pipfile": true,
+}
+
+// manifestSyntax are lines of a manifest that only hold its structure
+var manifestSyntax = map[string]bool{
+	"": true, "{": true, "}": true, "},": true, "[": true, "]": true, "],": true,
+	"(": true, ")": true, "require (": true, "\"dependencies\": {": true, "\"devDependencies\": {": true,
+	"dependencies = [": true,
+}
+
+// Dependency change statuses
+const (
+	DependencyAdded   = "added"
+	DependencyRemoved = "removed"
+	DependencyUpdated = "updated"
+)
+
+// DependencyChange is a dependency that was added, removed or changed version
+type DependencyChange struct {
+	File   string // Manifest or lockfile the change was found in
+	Name   string
+	Status string // DependencyAdded, DependencyRemoved or DependencyUpdated
+	From   string // Previous version or constraint, empty when added or unpinned
+	To     string // New version or constraint, empty when removed or unpinned
+}
+
+// Major reports whether an update crosses a major version, which for 0.x
+// versions is the minor version
+func (c DependencyChange) Major() bool {
+	if c.Status != DependencyUpdated {
+		return false
+	}
+	from, to := majorVersion(c.From), majorVersion(c.To)
+	return from != "" && to != "" && from != to
+}
+
+// majorVersion returns the major version of v, or major.minor for 0.x
+func majorVersion(v string) string {
+	number := versionNumberPattern.FindString(v)
+	if number == "" {
+		return ""
+	}
+	major, minor, _ := strings.Cut(number, ".")
+	if major == "0" {
+		return major + "." + minor
+	}
+	return major
+}
+
+// DependencyUpdate summarizes the dependency changes of a diff
+type DependencyUpdate struct {
+	Direct     []DependencyChange // Changes to manifests such as go.mod and package.json
+	Transitive []DependencyChange // Changes only found in lockfiles
+	// OnlyDependencies is true when the diff changes nothing but dependency
+	// declarations and lockfiles, so the changes above describe all of it
+	OnlyDependencies bool
+}
+
+// Majors returns the changes crossing a major version
+func (u *DependencyUpdate) Majors() []DependencyChange {
+	var majors []DependencyChange
+	for _, change := range append(append([]DependencyChange(nil), u.Direct...), u.Transitive...) {
+		if change.Major() {
+			majors = append(majors, change)
+		}
+	}
+	return majors
+}
+
+// IsLockfile reports whether the file at p is a dependency lockfile
+func IsLockfile(p string) bool {
+	return lockfiles[path.Base(p)]
+}
+
+// IsDependencyFile reports whether the file at p is a dependency manifest or lockfile
+func IsDependencyFile(p string) bool {
+	return IsLockfile(p) || manifests[path.Base(p)] || isPythonRequirements(p)
+}
+
+// SummarizeDependencies parses the dependency manifests and lockfiles of a
+// unified diff. It returns nil when the diff changes no dependency.
+func SummarizeDependencies(diff string) *DependencyUpdate {
+	update := &DependencyUpdate{OnlyDependencies: true}
+	direct := make(map[string]bool)
+	var transitive []DependencyChange
+
+	for _, file := range ParseDiff(diff) {
+		if !IsDependencyFile(file.Path) {
+			update.OnlyDependencies = false
+			continue
+		}
+		if IsLockfile(file.Path) {
+			transitive = append(transitive, LockfileChanges(file)...)
+			continue
+		}
+		if !onlyDependencyLines(file) {
+			update.OnlyDependencies = false
+		}
+		for _, change := range manifestChanges(file) {
+			direct[change.Name] = true
+			update.Direct = append(update.Direct, change)
+		}
+	}
+	for _, change := range transitive {
+		if !direct[change.Name] {
+			update.Transitive = append(update.Transitive, change)
+		}
+	}
+
+	if len(update.Direct) == 0 && len(update.Transitive) == 0 {
+		return nil
+	}
+	return update
+}
+
+// manifestChanges returns the dependency changes of a manifest
+func manifestChanges(file FileDiff) []DependencyChange {
+	var removed, added map[string]string
+	switch base := path.Base(file.Path); {
+	case base == "go.mod":
+		removed, added = goRequirements(file.Removed), goRequirements(file.Added)
+	case base == "package.json":
+		removed, added = jsDependencies(file.Removed), jsDependencies(file.Added)
+	case base == "pyproject.toml":
+		removed, added = pyDependencies(file.Removed, pyProjectDepPattern, pyPoetryDepPattern), pyDependencies(file.Added, pyProjectDepPattern, pyPoetryDepPattern)
+	case isPythonRequirements(file.Path):
+		removed, added = pyDependencies(file.Removed, pyRequirementPattern), pyDependencies(file.Added, pyRequirementPattern)
+	default:
+		return nil
+	}
+	return diffVersions(file.Path, removed, added)
+}
+
+// onlyDependencyLines reports whether every changed line of a manifest
+// declares a dependency or is structure such as braces and comments
+func onlyDependencyLines(file FileDiff) bool {
+	base := path.Base(file.Path)
+	for _, line := range append(append([]string(nil), file.Added...), file.Removed...) {
+		trimmed := strings.TrimSpace(line)
+		if manifestSyntax[trimmed] || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		var deps map[string]string
+		switch {
+		case base == "go.mod":
+			deps = goRequirements([]string{line})
+		case base == "package.json":
+			deps = jsDependencies([]string{line})
+		case base == "pyproject.toml":
+			deps = pyDependencies([]string{line}, pyProjectDepPattern, pyPoetryDepPattern)
+		case isPythonRequirements(file.Path):
+			deps = pyDependencies([]string{line}, pyRequirementPattern)
+		}
+		if len(deps) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// LockfileChanges returns the package versions changed in a lockfile diff.
+// Only package-lock.json (lockfile version 2 and 3) is parsed; other
+// lockfiles return nil.
+func LockfileChanges(file FileDiff) []DependencyChange {
+	base := path.Base(file.Path)
+	if base != "package-lock.json" && base != "npm-shrinkwrap.json" {
+		return nil
+	}
+
+	removed := make(map[string]string)
+	added := make(map[string]string)
+	current := ""
+	for _, line := range file.Lines {
+		if strings.HasPrefix(line, "@@") {
+			current = ""
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		prefix, text := line[0], line[1:]
+		if m := npmLockPackagePattern.FindStringSubmatch(text); m != nil {
+			current = m[1]
+			continue
+		}
+		m := npmLockVersionPattern.FindStringSubmatch(text)
+		if m == nil || current == "" {
+			continue
+		}
+		// Nested copies of a package keep the first version seen
+		switch prefix {
+		case '-':
+			if _, ok := removed[current]; !ok {
+				removed[current] = m[1]
+			}
+		case '+':
+			if _, ok := added[current]; !ok {
+				added[current] = m[1]
+			}
+		}
+	}
+	return diffVersions(file.Path, removed, added)
+}
+
+// diffVersions turns the name -> version maps of removed and added lines
+// into changes sorted by name
+func diffVersions(file string, removed, added map[string]string) []DependencyChange {
+	var changes []DependencyChange
+	for name, to := range added {
+		from, existed := removed[name]
+		switch {
+		case !existed:
+			changes = append(changes, DependencyChange{File: file, Name: name, Status: DependencyAdded, To: cleanVersion(to)})
+		case from != to:
+			changes = append(changes, DependencyChange{File: file, Name: name, Status: DependencyUpdated, From: cleanVersion(from), To: cleanVersion(to)})
+		}
+	}
+	for name, from := range removed {
+		if _, kept := added[name]; !kept {
+			changes = append(changes, DependencyChange{File: file, Name: name, Status: DependencyRemoved, From: cleanVersion(from)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// cleanVersion drops exact-pin operators, e.g. "==2.31.0" -> "2.31.0"
+func cleanVersion(v string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v), "=="), "=")
+}
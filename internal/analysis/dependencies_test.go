@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const npmUpdateDiff = `diff --git a/package.json b/package.json
+--- a/package.json
++++ b/package.json
+@@ -10,6 +10,6 @@
+   "dependencies": {
+-    "axios": "^0.27.2",
++    "axios": "^1.7.2",
+     "lodash": "^4.17.21"
+   }
+diff --git a/package-lock.json b/package-lock.json
+--- a/package-lock.json
++++ b/package-lock.json
+@@ -20,8 +20,8 @@
+     "node_modules/axios": {
+-      "version": "0.27.2",
++      "version": "1.7.2",
+       "resolved": "https://registry.npmjs.org/axios/-/axios-1.7.2.tgz",
+@@ -60,6 +60,11 @@
++    "node_modules/proxy-from-env": {
++      "version": "1.1.0",
++      "license": "MIT"
++    },
+     "node_modules/@babel/core": {
+-      "version": "7.24.0",
++      "version": "7.24.5",
+`
+
+func TestSummarizeDependencies(t *testing.T) {
+	update := SummarizeDependencies(npmUpdateDiff)
+	require.NotNil(t, update)
+	assert.True(t, update.OnlyDependencies)
+	assert.Equal(t, []DependencyChange{
+		{File: "package.json", Name: "axios", Status: DependencyUpdated, From: "^0.27.2", To: "^1.7.2"},
+	}, update.Direct)
+	assert.Equal(t, []DependencyChange{
+		{File: "package-lock.json", Name: "@babel/core", Status: DependencyUpdated, From: "7.24.0", To: "7.24.5"},
+		{File: "package-lock.json", Name: "proxy-from-env", Status: DependencyAdded, To: "1.1.0"},
+	}, update.Transitive)
+	require.Len(t, update.Majors(), 1)
+	assert.Equal(t, "axios", update.Majors()[0].Name)
+
+	// Go: go.sum is a lockfile, but the go directive is not a dependency
+	goDiff := `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -1,5 +1,5 @@
+-go 1.22
++go 1.24
+ require (
+-	github.com/spf13/cobra v1.8.0
++	github.com/spf13/cobra v1.9.1
+ )
+diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1,2 +1,2 @@
+-github.com/spf13/cobra v1.8.0 h1:abc=
++github.com/spf13/cobra v1.9.1 h1:def=
+`
+	update = SummarizeDependencies(goDiff)
+	require.NotNil(t, update)
+	assert.False(t, update.OnlyDependencies)
+	assert.Equal(t, "v1.9.1", update.Direct[0].To)
+	assert.Empty(t, update.Transitive)
+
+	// Code changes make it more than a dependency update
+	update = SummarizeDependencies(npmUpdateDiff + `diff --git a/src/api.js b/src/api.js
+--- a/src/api.js
++++ b/src/api.js
+@@ -1 +1 @@
+-import axios from "axios"
++import axios from "axios";
+`)
+	require.NotNil(t, update)
+	assert.False(t, update.OnlyDependencies)
+
+	assert.Nil(t, SummarizeDependencies("diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n"))
+}
+
+func TestDependencyChange_Major(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		major    bool
+	}{
+		{"v1.8.0", "v1.9.1", false},
+		{"4.17.0", "5.0.0", true},
+		{"^0.27.2", "^0.28.0", true},
+		{"0.27.2", "0.27.5", false},
+		{">=2.0", "==3.1", true},
+		{"latest", "1.0.0", false},
+	} {
+		change := DependencyChange{Status: DependencyUpdated, From: tc.from, To: tc.to}
+		assert.Equal(t, tc.major, change.Major(), "%s -> %s", tc.from, tc.to)
+	}
+	assert.False(t, DependencyChange{Status: DependencyAdded, To: "2.0.0"}.Major())
+}
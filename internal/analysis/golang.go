@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	goFuncPattern    = regexp.MustCompile(`^func\s+(?:\(\s*\w*\s*\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)\s*)?(\w+)`)
+	goTypePattern    = regexp.MustCompile(`^type\s+(\w+)`)
+	goValuePattern   = regexp.MustCompile(`^(?:var|const)\s+(\w+)`)
+	goRequirePattern = regexp.MustCompile(`^\s*(?:require\s+)?([\w.\-~]+\.[\w.\-~/]+)\s+(v[\w.\-+]+)(?:\s*//\s*indirect)?\s*$`)
+)
+
+// goAnalyzer finds exported declarations in .go files and module
+// requirements in go.mod
+type goAnalyzer struct{}
+
+func (goAnalyzer) Language() string { return "Go" }
+
+func (goAnalyzer) Match(p string) bool {
+	return strings.HasSuffix(p, ".go") || path.Base(p) == "go.mod"
+}
+
+func (a goAnalyzer) Analyze(file FileDiff) []Fact {
+	if path.Base(file.Path) == "go.mod" {
+		return compareDependencies(a.Language(), file.Path, goRequirements(file.Removed), goRequirements(file.Added))
+	}
+	if strings.HasSuffix(file.Path, "_test.go") {
+		return nil
+	}
+	return compareDeclarations(a.Language(), file.Path, goDeclarations(file.Removed), goDeclarations(file.Added))
+}
+
+// goDeclarations finds top-level funcs, methods, types, vars and consts.
+// Methods are named Type.Method and exported when both names are.
+func goDeclarations(lines []string) []declaration {
+	var decls []declaration
+	for _, line := range lines {
+		if m := goFuncPattern.FindStringSubmatch(line); m != nil {
+			name, exported := m[2], isExported(m[2])
+			if m[1] != "" {
+				name = m[1] + "." + m[2]
+				exported = exported && isExported(m[1])
+			}
+			decls = append(decls, declaration{name: name, signature: normalizeSignature(line), exported: exported})
+			continue
+		}
+		if m := goTypePattern.FindStringSubmatch(line); m != nil {
+			decls = append(decls, declaration{name: m[1], signature: normalizeSignature(line), exported: isExported(m[1])})
+		} else if m := goValuePattern.FindStringSubmatch(line); m != nil {
+			decls = append(decls, declaration{name: m[1], signature: valueSignature(line), exported: isExported(m[1])})
+		}
+	}
+	return decls
+}
+
+// goRequirements maps module paths to versions in go.mod lines
+func goRequirements(lines []string) map[string]string {
+	modules := make(map[string]string)
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "module ") || strings.HasPrefix(strings.TrimSpace(line), "go ") {
+			continue
+		}
+		if m := goRequirePattern.FindStringSubmatch(line); m != nil {
+			modules[m[1]] = m[2]
+		}
+	}
+	return modules
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	jsExportPattern     = regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|var|interface|type|enum)\s+(\w+)`)
+	jsFunctionPattern   = regexp.MustCompile(`^(?:export\s+(?:default\s+)?)?(?:async\s+)?function\*?\s+(\w+)\s*[(<]`)
+	jsArrowPattern      = regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>`)
+	jsDependencyPattern = regexp.MustCompile(`^\s*"(@?[\w.\-]+(?:/[\w.\-]+)?)"\s*:\s*"([^"]+)"\s*,?\s*$`)
+	jsVersionPattern    = regexp.MustCompile(`^(?:[\^~<>=*]|\d|latest$|next$|workspace:|npm:|file:|link:|git|https?:)`)
+)
+
+// jsNotDependencies are package.json keys with version-like values
+var jsNotDependencies = map[string]bool{"version": true, "node": true, "npm": true, "pnpm": true, "yarn": true}
+
+// jsAnalyzer finds exports in JavaScript and TypeScript files and
+// dependencies in package.json
+type jsAnalyzer struct{}
+
+func (jsAnalyzer) Language() string { return "JavaScript" }
+
+func (jsAnalyzer) Match(p string) bool {
+	switch path.Ext(p) {
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts":
+		return true
+	}
+	return path.Base(p) == "package.json"
+}
+
+func (a jsAnalyzer) Analyze(file FileDiff) []Fact {
+	if path.Base(file.Path) == "package.json" {
+		return compareDependencies(a.Language(), file.Path, jsDependencies(file.Removed), jsDependencies(file.Added))
+	}
+	if isJSTestFile(file.Path) {
+		return nil
+	}
+	return compareDeclarations(a.Language(), file.Path, jsDeclarations(file.Removed), jsDeclarations(file.Added))
+}
+
+func isJSTestFile(p string) bool {
+	base := path.Base(p)
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+}
+
+// jsDeclarations finds top-level exports and functions. Only exports are
+// public; other functions are tracked for signature changes.
+func jsDeclarations(lines []string) []declaration {
+	var decls []declaration
+	for _, line := range lines {
+		exported := jsExportPattern.FindStringSubmatch(line)
+		switch {
+		case jsFunctionPattern.MatchString(line):
+			name := jsFunctionPattern.FindStringSubmatch(line)[1]
+			decls = append(decls, declaration{name: name, signature: normalizeSignature(line), exported: exported != nil})
+		case jsArrowPattern.MatchString(line):
+			name := jsArrowPattern.FindStringSubmatch(line)[1]
+			signature := normalizeSignature(line[:strings.Index(line, "=>")+2])
+			decls = append(decls, declaration{name: name, signature: signature, exported: exported != nil})
+		case exported != nil:
+			decls = append(decls, declaration{name: exported[1], signature: valueSignature(line), exported: true})
+		}
+	}
+	return decls
+}
+
+// jsDependencies maps package names to version ranges in package.json lines
+func jsDependencies(lines []string) map[string]string {
+	deps := make(map[string]string)
+	for _, line := range lines {
+		m := jsDependencyPattern.FindStringSubmatch(line)
+		if m == nil || jsNotDependencies[m[1]] || !jsVersionPattern.MatchString(m[2]) {
+			continue
+		}
+		deps[m[1]] = m[2]
+	}
+	return deps
+}
@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	pyDefPattern         = regexp.MustCompile(`^(\s*)(?:async\s+)?def\s+(\w+)\s*\(`)
+	pyClassPattern       = regexp.MustCompile(`^class\s+(\w+)`)
+	pyRequirementPattern = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._\-]*(?:\[[^\]]*\])?)\s*((?:[<>=!~]=?|===)[^;#]*)?(?:[;#].*)?$`)
+	pyProjectDepPattern  = regexp.MustCompile(`^\s*["']([A-Za-z0-9][A-Za-z0-9._\-]*(?:\[[^\]]*\])?)\s*([<>=!~][^"';]*)?(?:;[^"']*)?["'],?\s*$`)
+	pyPoetryDepPattern   = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._\-]*)\s*=\s*["']([\^~<>=!*\d][^"']*)["']\s*$`)
+)
+
+// pyNotDependencies are pyproject.toml keys that look like dependencies
+var pyNotDependencies = map[string]bool{"version": true, "python": true, "requires-python": true, "target-version": true, "minversion": true}
+
+// pythonAnalyzer finds public functions and classes in .py files and
+// dependencies in requirements files and pyproject.toml
+type pythonAnalyzer struct{}
+
+func (pythonAnalyzer) Language() string { return "Python" }
+
+func (pythonAnalyzer) Match(p string) bool {
+	return strings.HasSuffix(p, ".py") || isPythonRequirements(p) || path.Base(p) == "pyproject.toml"
+}
+
+func (a pythonAnalyzer) Analyze(file FileDiff) []Fact {
+	switch {
+	case isPythonRequirements(file.Path):
+		return compareDependencies(a.Language(), file.Path, pyDependencies(file.Removed, pyRequirementPattern), pyDependencies(file.Added, pyRequirementPattern))
+	case path.Base(file.Path) == "pyproject.toml":
+		return compareDependencies(a.Language(), file.Path,
+			pyDependencies(file.Removed, pyProjectDepPattern, pyPoetryDepPattern), pyDependencies(file.Added, pyProjectDepPattern, pyPoetryDepPattern))
+	case strings.HasPrefix(path.Base(file.Path), "test_"):
+		return nil
+	}
+	return compareDeclarations(a.Language(), file.Path, pyDeclarations(file.Removed), pyDeclarations(file.Added))
+}
+
+func isPythonRequirements(p string) bool {
+	base := path.Base(p)
+	return strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+}
+
+// pyDeclarations finds functions and classes. Only top-level names without a
+// leading underscore are public; indented defs are tracked for signature
+// changes only, since the class they belong to is not visible in the diff.
+func pyDeclarations(lines []string) []declaration {
+	var decls []declaration
+	for _, line := range lines {
+		if m := pyDefPattern.FindStringSubmatch(line); m != nil {
+			topLevel := m[1] == ""
+			name := m[2]
+			if !topLevel {
+				name = "." + name
+			}
+			decls = append(decls, declaration{name: name, signature: normalizeSignature(line), exported: topLevel && !strings.HasPrefix(m[2], "_")})
+			continue
+		}
+		if m := pyClassPattern.FindStringSubmatch(line); m != nil {
+			decls = append(decls, declaration{name: m[1], signature: normalizeSignature(line), exported: !strings.HasPrefix(m[1], "_")})
+		}
+	}
+	return decls
+}
+
+// pyDependencies maps package names to version specifiers using the first
+// matching pattern of each line
+func pyDependencies(lines []string, patterns ...*regexp.Regexp) map[string]string {
+	deps := make(map[string]string)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		for _, pattern := range patterns {
+			if m := pattern.FindStringSubmatch(line); m != nil {
+				if name := strings.ToLower(m[1]); !pyNotDependencies[name] {
+					deps[name] = strings.TrimSpace(m[2])
+				}
+				break
+			}
+		}
+	}
+	return deps
+}
@@ -0,0 +1,144 @@
+// Package apidiff finds breaking changes by comparing the API surface of two
+// versions of the code: the exported declarations of Go packages and the
+// operations and schemas of OpenAPI/Swagger specs.
+package apidiff
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// Change kinds
+const (
+	KindRemoved     = "removed"
+	KindChanged     = "changed"
+	KindMethodAdded = "method added to interface"
+	KindNowRequired = "now required"
+)
+
+// maxChanges caps the changes included in a prompt
+const maxChanges = 30
+
+// Change is a breaking change to the API surface
+type Change struct {
+	File   string // File declaring the API (before the change when it was removed)
+	Symbol string // e.g. "parser.Parse", "parser.Config.Timeout" or "GET /users/{id}"
+	Kind   string // One of the Kind constants
+	Before string // Declaration before the change
+	After  string // Declaration after the change, empty when removed
+}
+
+// String renders the change as a single line
+func (c Change) String() string {
+	switch {
+	case c.Before != "" && c.After != "":
+		return fmt.Sprintf("%s: %s %s: %s -> %s", c.File, c.Symbol, c.Kind, c.Before, c.After)
+	case c.Before != "":
+		return fmt.Sprintf("%s: %s %s: %s", c.File, c.Symbol, c.Kind, c.Before)
+	case c.After != "":
+		return fmt.Sprintf("%s: %s %s: %s", c.File, c.Symbol, c.Kind, c.After)
+	default:
+		return fmt.Sprintf("%s: %s %s", c.File, c.Symbol, c.Kind)
+	}
+}
+
+// FilePair holds both versions of a changed file; a nil version means the
+// file does not exist on that side
+type FilePair struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Compare returns the breaking changes between the two versions of files.
+// Go files are compared per package, so declarations moved between files of
+// a package are not reported.
+func Compare(files []FilePair) []Change {
+	packages := make(map[string][]FilePair)
+	var changes []Change
+	for _, file := range files {
+		switch {
+		case isGoSource(file.Path):
+			dir := path.Dir(file.Path)
+			packages[dir] = append(packages[dir], file)
+		case isSpecCandidate(file.Path):
+			changes = append(changes, compareOpenAPI(file)...)
+		}
+	}
+
+	dirs := make([]string, 0, len(packages))
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		changes = append(changes, compareGoPackage(packages[dir])...)
+	}
+	return changes
+}
+
+// Detect compares the API surface of the files changed between base and
+// head. An empty head compares base with the staged changes; otherwise head
+// is compared with its merge base with base.
+func Detect(ctx context.Context, workDir, base, head string) ([]Change, error) {
+	paths, err := git.ChangedFiles(ctx, workDir, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	beforeRev := base
+	if head != "" {
+		if beforeRev, err = git.MergeBase(ctx, workDir, base, head); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []FilePair
+	for _, p := range paths {
+		if !isGoSource(p) && !isSpecCandidate(p) {
+			continue
+		}
+		pair := FilePair{Path: p}
+		if pair.Before, err = readVersion(ctx, workDir, beforeRev, p); err != nil {
+			return nil, err
+		}
+		if pair.After, err = readVersion(ctx, workDir, head, p); err != nil {
+			return nil, err
+		}
+		files = append(files, pair)
+	}
+	return Compare(files), nil
+}
+
+// readVersion returns the file at rev, or nil when it doesn't exist there
+func readVersion(ctx context.Context, workDir, rev, p string) ([]byte, error) {
+	content, ok, err := git.FileAt(ctx, workDir, rev, p)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return content, nil
+}
+
+// FormatChanges renders changes as a prompt block, or an empty string without changes
+func FormatChanges(changes []Change) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Breaking API changes\n")
+	b.WriteString("These were found by comparing the exported Go declarations and OpenAPI specs before and after the changes.\n")
+	for i, change := range changes {
+		if i == maxChanges {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(changes)-maxChanges)
+			break
+		}
+		b.WriteString("- " + change.String() + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
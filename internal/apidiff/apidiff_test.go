@@ -0,0 +1,175 @@
+package apidiff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare_Go(t *testing.T) {
+	before := `package parser
+
+type Config struct {
+	Timeout int
+	Strict  bool
+	debug   bool
+}
+
+type Source interface {
+	Read() string
+}
+
+type Legacy struct {
+	Name string
+}
+
+const Version = "1"
+
+func Parse(s string, cfg Config) (n int, err error) { return 0, nil }
+func Helper() {}
+func (c *Config) Validate() error { return nil }
+func internalOnly() {}
+`
+	after := `package parser
+
+type Config struct {
+	Timeout time.Duration
+	debug   bool
+}
+
+type Source interface {
+	Read() string
+	Close() error
+}
+
+const Version = "2"
+
+func Parse(input string, config Config) (int, error) { return 0, nil }
+func (c *Config) Validate(strict bool) error { return nil }
+func NewThing() {}
+`
+	moved := `package parser
+
+func Helper() {}
+`
+
+	changes := Compare([]FilePair{
+		{Path: "pkg/parser/parser.go", Before: []byte(before), After: []byte(after)},
+		{Path: "pkg/parser/helper.go", After: []byte(moved)},
+	})
+
+	assert.Equal(t, []Change{
+		{File: "pkg/parser/parser.go", Symbol: "parser.Config.Strict", Kind: KindRemoved, Before: "field bool"},
+		{File: "pkg/parser/parser.go", Symbol: "parser.Config.Timeout", Kind: KindChanged, Before: "field int", After: "field time.Duration"},
+		{File: "pkg/parser/parser.go", Symbol: "parser.Config.Validate", Kind: KindChanged, Before: "func() error", After: "func(bool) error"},
+		{File: "pkg/parser/parser.go", Symbol: "parser.Legacy", Kind: KindRemoved, Before: "type struct"},
+		{File: "pkg/parser/parser.go", Symbol: "parser.Source.Close", Kind: KindMethodAdded, After: "func() error"},
+	}, changes, "renamed parameters, changed constant values, moved and new functions are compatible")
+}
+
+func TestCompare_GoSkippedFiles(t *testing.T) {
+	removed := []byte("package api\n\nfunc Old() {}\n")
+	changes := Compare([]FilePair{
+		{Path: "internal/api/api.go", Before: removed},
+		{Path: "api/api_test.go", Before: removed},
+		{Path: "cmd/tool/main.go", Before: []byte("package main\n\nfunc Run() {}\n")},
+		{Path: "api/broken.go", Before: removed, After: []byte("package api\n\nfunc {")},
+	})
+	assert.Empty(t, changes)
+}
+
+func TestCompare_OpenAPI(t *testing.T) {
+	before := `openapi: 3.0.0
+paths:
+  /users:
+    get:
+      parameters:
+        - name: limit
+          in: query
+    post: {}
+  /users/{id}:
+    delete: {}
+components:
+  schemas:
+    User:
+      properties:
+        id: {type: integer}
+        email: {type: string}
+        tags: {type: array, items: {type: string}}
+    Legacy:
+      type: object
+`
+	after := `openapi: 3.0.0
+paths:
+  /users:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+        - name: org
+          in: header
+    post: {}
+components:
+  schemas:
+    User:
+      properties:
+        id: {type: string}
+        tags: {type: array, items: {type: integer}}
+        name: {type: string}
+`
+	changes := Compare([]FilePair{{Path: "api/openapi.yaml", Before: []byte(before), After: []byte(after)}})
+	assert.Equal(t, []Change{
+		{File: "api/openapi.yaml", Symbol: "DELETE /users/{id}", Kind: KindRemoved},
+		{File: "api/openapi.yaml", Symbol: "GET /users parameter limit (query)", Kind: KindNowRequired},
+		{File: "api/openapi.yaml", Symbol: "schema Legacy", Kind: KindRemoved},
+		{File: "api/openapi.yaml", Symbol: "schema User.email", Kind: KindRemoved, Before: "string"},
+		{File: "api/openapi.yaml", Symbol: "schema User.id", Kind: KindChanged, Before: "integer", After: "string"},
+		{File: "api/openapi.yaml", Symbol: "schema User.tags", Kind: KindChanged, Before: "array of string", After: "array of integer"},
+	}, changes)
+
+	// Other YAML and JSON files are ignored
+	assert.Empty(t, Compare([]FilePair{{Path: "config.json", Before: []byte(`{"name": "x"}`)}}))
+}
+
+func TestChange_String(t *testing.T) {
+	assert.Equal(t, "a.go: p.F changed: func() -> func(int)", Change{File: "a.go", Symbol: "p.F", Kind: KindChanged, Before: "func()", After: "func(int)"}.String())
+	assert.Equal(t, "a.go: p.F removed: func()", Change{File: "a.go", Symbol: "p.F", Kind: KindRemoved, Before: "func()"}.String())
+	assert.Equal(t, "spec.yaml: DELETE /x removed", Change{File: "spec.yaml", Symbol: "DELETE /x", Kind: KindRemoved}.String())
+	assert.Empty(t, FormatChanges(nil))
+}
+
+func TestDetect_Staged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test User")
+	write("api/api.go", "package api\n\nfunc Get(id int) string { return \"\" }\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+
+	write("api/api.go", "package api\n\nfunc Get(id string) string { return \"\" }\n")
+	run("add", ".")
+	changes, err := Detect(context.Background(), dir, "HEAD", "")
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{File: "api/api.go", Symbol: "api.Get", Kind: KindChanged, Before: "func(int) string", After: "func(string) string"}}, changes)
+}
@@ -0,0 +1,268 @@
+package apidiff
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// goSymbol is an exported declaration of a package
+type goSymbol struct {
+	file            string
+	decl            string // Normalized declaration, e.g. "func(string, int) error"
+	interfaceMethod bool   // Method (or embedded interface) of an interface type
+}
+
+// goSurface maps symbol names (Name, Type.Field or Type.Method) to their declarations
+type goSurface map[string]goSymbol
+
+// isGoSource reports whether p is a Go file that can be imported by other
+// modules: test files, and packages below an internal directory, are skipped
+func isGoSource(p string) bool {
+	if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+		return false
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "internal" || segment == "testdata" {
+			return false
+		}
+	}
+	return true
+}
+
+// compareGoPackage compares the exported declarations of the changed files of a package
+func compareGoPackage(files []FilePair) []Change {
+	before, after := goSurface{}, goSurface{}
+	var pkg string
+	for _, file := range files {
+		oldName, oldOK := addGoFile(before, file.Path, file.Before)
+		newName, newOK := addGoFile(after, file.Path, file.After)
+		if !oldOK || !newOK {
+			// A file that doesn't parse would look like removed declarations
+			return nil
+		}
+		if newName != "" {
+			pkg = newName
+		} else if oldName != "" {
+			pkg = oldName
+		}
+	}
+	if pkg == "" {
+		return nil
+	}
+
+	var changes []Change
+	for _, name := range sortedKeys(before) {
+		old := before[name]
+		current, kept := after[name]
+		if owner, _, member := strings.Cut(name, "."); member && !kept {
+			_, ownerExisted := before[owner]
+			if _, ownerKept := after[owner]; ownerExisted && !ownerKept {
+				// Reported once with the removed type
+				continue
+			}
+		}
+		switch {
+		case !kept:
+			changes = append(changes, Change{File: old.file, Symbol: pkg + "." + name, Kind: KindRemoved, Before: old.decl})
+		case current.decl != old.decl:
+			changes = append(changes, Change{File: current.file, Symbol: pkg + "." + name, Kind: KindChanged, Before: old.decl, After: current.decl})
+		}
+	}
+	for _, name := range sortedKeys(after) {
+		current := after[name]
+		if _, existed := before[name]; existed || !current.interfaceMethod {
+			continue
+		}
+		// Only a new method of an existing interface breaks its implementations
+		owner, _, _ := strings.Cut(name, ".")
+		if _, existed := before[owner]; existed {
+			changes = append(changes, Change{File: current.file, Symbol: pkg + "." + name, Kind: KindMethodAdded, After: current.decl})
+		}
+	}
+	return changes
+}
+
+// addGoFile adds the exported declarations of a file to surface and returns
+// the package name, which is empty for missing and main files. ok is false
+// when the file doesn't parse.
+func addGoFile(surface goSurface, path string, src []byte) (pkg string, ok bool) {
+	if src == nil {
+		return "", true
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return "", false
+	}
+	if file.Name.Name == "main" {
+		return "", true
+	}
+
+	add := func(name, decl string, interfaceMethod bool) {
+		surface[name] = goSymbol{file: path, decl: decl, interfaceMethod: interfaceMethod}
+	}
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				add(d.Name.Name, "func"+goFuncSignature(fset, d.Type), false)
+			} else if recv := receiverName(d.Recv); ast.IsExported(recv) {
+				add(recv+"."+d.Name.Name, "func"+goFuncSignature(fset, d.Type), false)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						addGoType(fset, spec, add)
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if !name.IsExported() {
+							continue
+						}
+						decl := d.Tok.String()
+						if spec.Type != nil {
+							decl += " " + goExpr(fset, spec.Type)
+						}
+						add(name.Name, decl, false)
+					}
+				}
+			}
+		}
+	}
+	return file.Name.Name, true
+}
+
+// addGoType adds a type with its exported struct fields or interface methods
+func addGoType(fset *token.FileSet, spec *ast.TypeSpec, add func(name, decl string, interfaceMethod bool)) {
+	name := spec.Name.Name
+	prefix := "type"
+	if spec.TypeParams != nil {
+		prefix += goFieldTypes(fset, spec.TypeParams, true)
+	}
+	if spec.Assign.IsValid() {
+		prefix += " ="
+	}
+
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		add(name, prefix+" struct", false)
+		for _, field := range t.Fields.List {
+			fieldType := goExpr(fset, field.Type)
+			if len(field.Names) == 0 {
+				// Embedded fields are named after their type
+				if embedded := embeddedName(field.Type); ast.IsExported(embedded) {
+					add(name+"."+embedded, "embedded "+fieldType, false)
+				}
+				continue
+			}
+			for _, fieldName := range field.Names {
+				if fieldName.IsExported() {
+					add(name+"."+fieldName.Name, "field "+fieldType, false)
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		add(name, prefix+" interface", false)
+		for _, method := range t.Methods.List {
+			if len(method.Names) == 0 {
+				add(name+"."+goExpr(fset, method.Type), "embedded "+goExpr(fset, method.Type), true)
+				continue
+			}
+			if ft, ok := method.Type.(*ast.FuncType); ok {
+				for _, methodName := range method.Names {
+					if methodName.IsExported() {
+						add(name+"."+methodName.Name, "func"+goFuncSignature(fset, ft), true)
+					}
+				}
+			}
+		}
+	default:
+		add(name, prefix+" "+goExpr(fset, spec.Type), false)
+	}
+}
+
+// goFuncSignature renders the parameter and result types without names,
+// because renaming a parameter does not break callers
+func goFuncSignature(fset *token.FileSet, ft *ast.FuncType) string {
+	var b strings.Builder
+	if ft.TypeParams != nil {
+		b.WriteString(goFieldTypes(fset, ft.TypeParams, true))
+	}
+	b.WriteString(goFieldTypes(fset, ft.Params, false))
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return b.String()
+	}
+	results := goFieldTypes(fset, ft.Results, false)
+	if len(ft.Results.List) == 1 && len(ft.Results.List[0].Names) <= 1 {
+		results = strings.TrimSuffix(strings.TrimPrefix(results, "("), ")")
+	}
+	return b.String() + " " + results
+}
+
+// goFieldTypes renders a field list as "(T1, T2)", repeating a type for every
+// name it declares. Type parameters keep their names, which constraints refer to.
+func goFieldTypes(fset *token.FileSet, fields *ast.FieldList, typeParams bool) string {
+	var parts []string
+	for _, field := range fields.List {
+		fieldType := goExpr(fset, field.Type)
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			if typeParams {
+				parts = append(parts, field.Names[i].Name+" "+fieldType)
+			} else {
+				parts = append(parts, fieldType)
+			}
+		}
+	}
+	if typeParams {
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// goExpr renders an expression in gofmt style on one line
+func goExpr(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	return embeddedName(recv.List[0].Type)
+}
+
+// embeddedName returns the type name of an embedded field or receiver,
+// without pointers, package qualifiers and type arguments
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package apidiff
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// httpMethods are the operation keys of an OpenAPI path item
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPISpec is the part of an OpenAPI 3 or Swagger 2 spec that clients depend on
+type openAPISpec struct {
+	operations map[string]map[string]bool // "GET /users" -> parameter key -> required
+	schemas    map[string]map[string]string
+}
+
+// isSpecCandidate reports whether p may be an OpenAPI spec; the content decides
+func isSpecCandidate(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// compareOpenAPI reports removed operations and schemas, new required
+// parameters and changed property types between two versions of a spec
+func compareOpenAPI(file FilePair) []Change {
+	before, ok := parseOpenAPI(file.Before)
+	if !ok {
+		return nil
+	}
+	after, ok := parseOpenAPI(file.After)
+	if !ok {
+		after = &openAPISpec{}
+	}
+
+	var changes []Change
+	for _, op := range sortedKeys(before.operations) {
+		params, kept := after.operations[op]
+		if !kept {
+			changes = append(changes, Change{File: file.Path, Symbol: op, Kind: KindRemoved})
+			continue
+		}
+		for _, param := range sortedKeys(params) {
+			if params[param] && !before.operations[op][param] {
+				changes = append(changes, Change{File: file.Path, Symbol: op + " parameter " + param, Kind: KindNowRequired})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(before.schemas) {
+		properties, kept := after.schemas[name]
+		if !kept {
+			changes = append(changes, Change{File: file.Path, Symbol: "schema " + name, Kind: KindRemoved})
+			continue
+		}
+		for _, property := range sortedKeys(before.schemas[name]) {
+			oldType := before.schemas[name][property]
+			newType, kept := properties[property]
+			symbol := "schema " + name + "." + property
+			switch {
+			case !kept:
+				changes = append(changes, Change{File: file.Path, Symbol: symbol, Kind: KindRemoved, Before: oldType})
+			case oldType != "" && newType != "" && oldType != newType:
+				changes = append(changes, Change{File: file.Path, Symbol: symbol, Kind: KindChanged, Before: oldType, After: newType})
+			}
+		}
+	}
+	return changes
+}
+
+// parseOpenAPI parses a YAML or JSON spec, reporting false for other files
+func parseOpenAPI(content []byte) (*openAPISpec, bool) {
+	if !bytes.Contains(content, []byte("openapi")) && !bytes.Contains(content, []byte("swagger")) {
+		return nil, false
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, false
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, false
+	}
+
+	spec := &openAPISpec{
+		operations: make(map[string]map[string]bool),
+		schemas:    make(map[string]map[string]string),
+	}
+	paths, _ := doc["paths"].(map[string]any)
+	for route, item := range paths {
+		pathItem, _ := item.(map[string]any)
+		shared := openAPIParameters(pathItem["parameters"])
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			params := make(map[string]bool)
+			for key, required := range shared {
+				params[key] = required
+			}
+			for key, required := range openAPIParameters(operation["parameters"]) {
+				params[key] = required
+			}
+			spec.operations[strings.ToUpper(method)+" "+route] = params
+		}
+	}
+
+	// Swagger 2 keeps schemas in definitions, OpenAPI 3 in components.schemas
+	schemas, _ := doc["definitions"].(map[string]any)
+	if components, ok := doc["components"].(map[string]any); ok {
+		schemas, _ = components["schemas"].(map[string]any)
+	}
+	for name, schema := range schemas {
+		properties := make(map[string]string)
+		if s, ok := schema.(map[string]any); ok {
+			props, _ := s["properties"].(map[string]any)
+			for property, value := range props {
+				properties[property] = openAPIType(value)
+			}
+		}
+		spec.schemas[name] = properties
+	}
+	return spec, true
+}
+
+// openAPIParameters maps "name (in)" to whether the parameter is required.
+// Referenced parameters are skipped.
+func openAPIParameters(value any) map[string]bool {
+	params := make(map[string]bool)
+	list, _ := value.([]any)
+	for _, item := range list {
+		param, _ := item.(map[string]any)
+		name, _ := param["name"].(string)
+		if name == "" {
+			continue
+		}
+		required, _ := param["required"].(bool)
+		params[fmt.Sprintf("%s (%v)", name, param["in"])] = required
+	}
+	return params
+}
+
+// openAPIType describes the type of a property schema, e.g. "string",
+// "array of integer" or a referenced schema
+func openAPIType(value any) string {
+	schema, _ := value.(map[string]any)
+	if ref, ok := schema["$ref"].(string); ok {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	typ, _ := schema["type"].(string)
+	if typ == "array" {
+		if items := openAPIType(schema["items"]); items != "" {
+			return "array of " + items
+		}
+	}
+	return typ
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
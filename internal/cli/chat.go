@@ -3,6 +3,7 @@ package cli
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -23,6 +24,7 @@ var (
 	chatModel         string
 	chatMaxIterations int
 	chatResume        string
+	chatIsolated      bool
 )
 
 var chatCmd = &cobra.Command{
@@ -48,9 +50,14 @@ Examples:
   gitbuddy chat "What's in main.go?"        # Single query
   gitbuddy chat --resume <session-id>       # Resume a session
   gitbuddy chat --language zh               # Use Chinese
+  gitbuddy chat --isolated                  # Edit a temporary copy, get a patch
+
+With --isolated, the assistant works in a temporary git worktree holding the
+staged state, so its edits can't touch your working tree. The changes are
+saved as a patch under .gitbuddy/patches when the chat ends.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return handleChat(cmd.Context(), args)
+		return handleChat(cmd, args)
 	},
 }
 
@@ -59,10 +66,13 @@ func init() {
 	chatCmd.Flags().StringVar(&chatModel, "model", "", "LLM model to use (optional)")
 	chatCmd.Flags().IntVar(&chatMaxIterations, "max-iterations", 10, "Maximum agent iterations")
 	chatCmd.Flags().StringVar(&chatResume, "resume", "", "Resume a previous session by ID")
+	chatCmd.Flags().BoolVar(&chatIsolated, "isolated", false, "Work in a temporary worktree of the staged state and save changes as a patch")
 	rootCmd.AddCommand(chatCmd)
 }
 
-func handleChat(ctx context.Context, args []string) error {
+func handleChat(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
 	// Load configuration
 	cfg, err := config.Load("")
 	if err != nil {
@@ -70,9 +80,28 @@ func handleChat(ctx context.Context, args []string) error {
 	}
 
 	// Determine working directory
-	workDir, err := os.Getwd()
+	workDir, err := workspaceDir(false)
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return err
+	}
+
+	// Map the repository before isolation, so the cache in the real work dir is reused
+	repoMap := repositoryMap(ctx, cfg, workDir)
+	repoDir := workDir
+
+	// Point all tools at a temporary worktree in isolated mode
+	var worktree *git.IsolatedWorktree
+	if chatIsolated {
+		worktree, err = startIsolated(ctx, cfg, workDir)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if worktree != nil {
+				_ = worktree.Remove(context.WithoutCancel(ctx))
+			}
+		}()
+		workDir = worktree.Dir()
 	}
 
 	// Create UI printer
@@ -97,7 +126,10 @@ func handleChat(ctx context.Context, args []string) error {
 	}
 
 	// Create Git executor
-	gitExec := git.NewExecutor(workDir)
+	gitExec, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
 
 	// Get the model configuration
 	var modelCfg config.ModelConfig
@@ -121,11 +153,15 @@ func handleChat(ctx context.Context, args []string) error {
 	}
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(modelCfg)
+	provider, err := newProvider(modelCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	// Get retry configuration
 	var retryConfig llm.RetryConfig
@@ -140,6 +176,9 @@ func handleChat(ctx context.Context, args []string) error {
 		retryConfig = llm.DefaultRetryConfig()
 	}
 
+	applyToolLanguage(cfg, chatLanguage)
+	sessionManager.SetRunInfo(sessionRunInfo(ctx, cmd, &modelCfg, repoDir, gitExec))
+
 	// Create ChatAgent
 	chatAgent := agent.NewChatAgent(agent.ChatAgentOptions{
 		Language:        chatLanguage,
@@ -151,22 +190,37 @@ func handleChat(ctx context.Context, args []string) error {
 		WorkDir:         workDir,
 		MaxLinesPerRead: 1000,
 		RetryConfig:     retryConfig,
+		PromptExtension: cfg.GetPromptExtension("chat"),
+		RepositoryMap:   repoMap,
 		SessionManager:  sessionManager,
 	})
 
 	// Print welcome message
 	fmt.Println(agent.GetChatWelcomeMessage(chatLanguage))
 	fmt.Println()
+	if worktree != nil {
+		fmt.Printf("🔒 Isolated mode: working in %s\n\n", workDir)
+	}
 
 	// Determine if we're in interactive or single-query mode
 	if len(args) > 0 {
 		// Single query mode
 		query := strings.Join(args, " ")
-		return handleSingleQuery(ctx, chatAgent, query, sessionID, sess)
+		err = handleSingleQuery(ctx, chatAgent, query, sessionID, sess)
+	} else {
+		// Interactive mode
+		err = handleInteractiveChat(ctx, chatAgent, sessionID, sess)
 	}
 
-	// Interactive mode
-	return handleInteractiveChat(ctx, chatAgent, sessionID, sess)
+	if worktree != nil {
+		path, finishErr := finishIsolated(ctx, worktree, sessionID)
+		worktree = nil
+		if finishErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to save isolated changes: %w", finishErr))
+		}
+		fmt.Println(isolatedPatchHint(path))
+	}
+	return err
 }
 
 func handleSingleQuery(ctx context.Context, chatAgent *agent.ChatAgent, query string, sessionID string, sess *session.Session) error {
@@ -200,35 +254,66 @@ func handleSingleQuery(ctx context.Context, chatAgent *agent.ChatAgent, query st
 	fmt.Println()
 	fmt.Println("🤖 Assistant:")
 
-	_, err := chatAgent.Chat(ctx, req)
+	resp, err := chatAgent.Chat(ctx, req)
 	if err != nil {
 		return fmt.Errorf("chat failed: %w", err)
 	}
 
 	fmt.Println()
 	fmt.Println()
+	printRollbackHint(resp)
 
 	return nil
 }
 
+// printRollbackHint tells the user how to undo the files a chat session edited
+func printRollbackHint(resp *agent.ChatResponse) {
+	// Isolated edits never reach the working tree and are saved as a patch instead
+	if resp == nil || len(resp.ModifiedFiles) == 0 || chatIsolated {
+		return
+	}
+	fmt.Printf("💾 %d file(s) modified in this session. Undo all edits with: gitbuddy rollback %s\n\n", len(resp.ModifiedFiles), resp.SessionID)
+}
+
 func handleInteractiveChat(ctx context.Context, chatAgent *agent.ChatAgent, sessionID string, sess *session.Session) error {
 	// Setup signal handler for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 
+	// The prompt is read in the background, so that an interrupt there returns
+	// right away and the isolated worktree is saved and removed. A line is only
+	// read when asked for, leaving stdin to the tools while the agent runs.
 	scanner := bufio.NewScanner(os.Stdin)
+	next := make(chan struct{})
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for range next {
+			if !scanner.Scan() {
+				return
+			}
+			lines <- scanner.Text()
+		}
+	}()
 	fmt.Print("> ")
 
-	for scanner.Scan() {
+	for {
+		next <- struct{}{}
+		var line string
+		var ok bool
 		select {
 		case <-sigChan:
 			fmt.Println()
 			fmt.Println(agent.GetChatExitMessage(chatLanguage))
 			return nil
-		default:
+		case line, ok = <-lines:
+		}
+		if !ok {
+			break
 		}
 
-		input := strings.TrimSpace(scanner.Text())
+		input := strings.TrimSpace(line)
 
 		// Handle special commands
 		if input == "" {
@@ -271,13 +356,14 @@ func handleInteractiveChat(ctx context.Context, chatAgent *agent.ChatAgent, sess
 		go func() {
 			fmt.Println()
 			fmt.Println("🤖 Assistant:")
-			_, err := chatAgent.Chat(queryCtx, req)
+			resp, err := chatAgent.Chat(queryCtx, req)
 			if err != nil {
 				done <- err
 				return
 			}
 			fmt.Println()
 			fmt.Println()
+			printRollbackHint(resp)
 			done <- nil
 		}()
 
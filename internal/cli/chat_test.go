@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,6 +36,10 @@ func TestChatCmd_Flags(t *testing.T) {
 	// Check for resume flag
 	resumeFlag := flags.Lookup("resume")
 	assert.NotNil(t, resumeFlag, "resume flag should exist")
+
+	// Check for isolated flag
+	isolatedFlag := flags.Lookup("isolated")
+	assert.NotNil(t, isolatedFlag, "isolated flag should exist")
 }
 
 // TestChatCmd_DefaultFlags tests default flag values
@@ -65,3 +71,14 @@ func TestHandleSingleQuery_EmptyQuery(t *testing.T) {
 	// This would be tested in integration tests with mocked dependencies
 	assert.True(t, true, "empty query validation test")
 }
+
+// TestChat_IsolatedCleanupOnError tests that the worktree of --isolated is
+// removed when chat fails before it starts
+func TestChat_IsolatedCleanupOnError(t *testing.T) {
+	repo := testutil.NewSampleRepo(t)
+	result := runGitBuddy(t, repo, testutil.NewScriptedProvider(), "chat", "--isolated", "--model", "missing", "hello")
+	require.ErrorContains(t, result.err, "model not found")
+
+	worktrees := repo.Git("worktree", "list", "--porcelain")
+	assert.Equal(t, 1, strings.Count(worktrees, "worktree "), worktrees)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/spf13/cobra"
+)
+
+// checkFailedExitCode is the exit code of a --check run whose checks failed,
+// distinct from errors running the check (1) and model failures (3-6)
+const checkFailedExitCode = 2
+
+// checkFlagUsage is the help of the --check flag of commit, review and pr
+const checkFlagUsage = "Run the analysis and validation only, without side effects (no commit, file writes or pushes); exit with 2 when a check fails"
+
+// checkFailedError is returned by a --check run that completed but found a
+// problem, such as error-level review issues or a partial result
+type checkFailedError struct {
+	err error
+}
+
+func (e *checkFailedError) Error() string {
+	return "check failed: " + e.err.Error()
+}
+
+func (e *checkFailedError) Unwrap() error {
+	return e.err
+}
+
+// checkFailed marks err as the outcome of a failed check
+func checkFailed(err error) error {
+	return &checkFailedError{err: err}
+}
+
+// blockSideEffects turns on check mode for a command run with --check, before
+// anything runs. Side effects are refused where they happen (git commands,
+// file writing tools, saved state), so every command and tool complies.
+func blockSideEffects(cmd *cobra.Command) {
+	flag := cmd.Flags().Lookup("check")
+	if flag == nil || flag.Value.Type() != "bool" || flag.Value.String() != "true" {
+		return
+	}
+	sideeffect.SetBlocked(true)
+	// A failed check is a result, not a misuse of the command
+	cmd.SilenceUsage = true
+}
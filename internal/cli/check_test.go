@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+func TestExitCode_CheckFailed(t *testing.T) {
+	assert.Equal(t, 2, ExitCode(checkFailed(errors.New("1 error-level issue(s) found"))))
+	assert.Equal(t, 2, ExitCode(fmt.Errorf("review: %w", checkFailed(errors.New("partial")))))
+	assert.Equal(t, 1, ExitCode(errors.New("failed to load config")))
+}
+
+func TestBlockSideEffects(t *testing.T) {
+	defer sideeffect.SetBlocked(false)
+
+	var check bool
+	cmd := &cobra.Command{Use: "review"}
+	cmd.Flags().BoolVar(&check, "check", false, checkFlagUsage)
+
+	blockSideEffects(cmd)
+	assert.False(t, sideeffect.Blocked())
+
+	require.NoError(t, cmd.Flags().Set("check", "true"))
+	blockSideEffects(cmd)
+	assert.True(t, sideeffect.Blocked())
+	assert.True(t, cmd.SilenceUsage)
+}
+
+func TestCheckReview(t *testing.T) {
+	assert.NoError(t, checkReview(&agent.ReviewResponse{Issues: []agent.ReviewIssue{{Severity: agent.SeverityWarning}}}))
+
+	err := checkReview(&agent.ReviewResponse{Issues: []agent.ReviewIssue{{Severity: agent.SeverityError}, {Severity: agent.SeverityError}}})
+	assert.EqualError(t, err, "check failed: 2 error-level issue(s) found")
+
+	err = checkReview(&agent.ReviewResponse{Partial: true, PartialReason: "time budget exhausted"})
+	assert.EqualError(t, err, "check failed: partial result (time budget exhausted)")
+}
+
+func TestCheckCommit(t *testing.T) {
+	valid := &agent.CommitResponse{CommitInfo: &agent.CommitInfo{Type: "feat", Description: "add login"}}
+	guard := &protectedBranchGuard{cfg: &config.ProtectedBranchesConfig{Patterns: []string{"main"}, Policy: config.ProtectedBranchConfirm}}
+
+	assert.NoError(t, checkCommit(valid, guard, "feature/login", ""))
+	assert.NoError(t, checkCommit(valid, nil, "main", ""))
+
+	err := checkCommit(valid, guard, "main", "")
+	assert.Equal(t, 2, ExitCode(err))
+	assert.Contains(t, err.Error(), "git checkout -b feat/add-login")
+
+	invalid := &agent.CommitResponse{CommitInfo: &agent.CommitInfo{Type: "feature", Description: "add login"}}
+	assert.EqualError(t, checkCommit(invalid, guard, "feature/login", ""), "check failed: invalid commit message: invalid commit type: feature")
+
+	partial := &agent.CommitResponse{CommitInfo: valid.CommitInfo, Partial: true, PartialReason: "stream interrupted"}
+	assert.Equal(t, 2, ExitCode(checkCommit(partial, guard, "feature/login", "")))
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/huimingz/gitbuddy-go/internal/codeowners"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// changeOwners returns the code owners of the changed files and the
+// conventions documented for them in code_owners.conventions. An unreadable
+// CODEOWNERS file only loses the owners, so the error is printed and the
+// command continues.
+func changeOwners(cfg *config.Config, workDir string, files []string, printer *ui.StreamPrinter) ([]codeowners.Ownership, map[string]string) {
+	ownersCfg := cfg.GetCodeOwnersConfig()
+	if ownersCfg.Disabled || len(files) == 0 {
+		return nil, nil
+	}
+	file, err := codeowners.Load(workDir, ownersCfg.Path)
+	if err != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to load code owners: %v", err))
+		return nil, nil
+	}
+	if file == nil {
+		return nil, nil
+	}
+	owners := file.Affected(files)
+	return owners, codeowners.Conventions(ownersCfg.Conventions, owners)
+}
+
+// showCodeOwners prints the code owners of the changed files
+func showCodeOwners(owners []codeowners.Ownership, output io.Writer) error {
+	display := make([]ui.CodeOwner, 0, len(owners))
+	for _, owner := range owners {
+		display = append(display, ui.CodeOwner{Owner: owner.Owner, Files: owner.Files})
+	}
+	return ui.ShowCodeOwners(display, output)
+}
@@ -2,12 +2,18 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
 	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
@@ -16,9 +22,16 @@ import (
 )
 
 var (
-	commitContext  string
-	commitLanguage string
-	commitAutoYes  bool
+	commitContext   string
+	commitLanguage  string
+	commitAutoYes   bool
+	commitPrintOnly bool
+	commitStdinDiff bool
+	commitNotes     bool
+	commitOffline   bool
+	commitPush      bool
+	commitAllowProt bool
+	commitCheck     bool
 )
 
 var commitCmd = &cobra.Command{
@@ -35,14 +48,47 @@ Examples:
   gitbuddy commit
   gitbuddy commit -c "Bug fix for user authentication"
   gitbuddy commit --language zh
-  gitbuddy commit -m deepseek`,
+  gitbuddy commit -m deepseek
+  gitbuddy commit --print-only
+  gitbuddy commit --offline-fallback
+  gitbuddy commit --push
+  gitbuddy commit --check
+  git diff HEAD~1 | gitbuddy commit --stdin-diff
+
+With --offline-fallback, a model that can't be reached (network failure or a
+5xx response, after retrying) doesn't fail the command: a plain message is
+built from the staged files instead, with the type inferred from the kind of
+files (test, docs, ci, build), the scope from their common top-level
+directory, and the changed files in the body. Review it before committing.
+
+With --push, the branch is pushed once the commit is created. A branch
+without an upstream is pushed to origin (or the only remote) and tracks it
+from then on, and the link to open a pull request for it is printed.
+
+Branches matching protected_branches.patterns (e.g. main, release/*) are
+guarded: committing to one, or pushing to one with --push, asks first and
+offers to create a branch named after the message instead. With the block
+policy, committing to them is refused. Without a terminal, commit fails
+unless --allow-protected is given and the policy is confirm.
+
+With --check, the message is generated and validated, and the protected
+branch rules are applied as without a terminal, but nothing is committed,
+pushed or written. The command exits with 2 when the message is invalid or
+partial or the branch is protected, so it can run in hooks and CI.`,
 	RunE: runCommit,
 }
 
 func init() {
 	commitCmd.Flags().StringVarP(&commitContext, "context", "c", "", "Additional context to help AI generate better message")
 	commitCmd.Flags().StringVarP(&commitLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
-	commitCmd.Flags().BoolVarP(&commitAutoYes, "yes", "y", false, "Auto-confirm the commit without prompting")
+	commitCmd.Flags().BoolVarP(&commitAutoYes, "yes", "y", false, "Auto-confirm the commit without prompting, unless the message is partial")
+	commitCmd.Flags().BoolVar(&commitPrintOnly, "print-only", false, "Print the generated commit info as JSON without committing")
+	commitCmd.Flags().BoolVar(&commitStdinDiff, "stdin-diff", false, "Read a unified diff from stdin instead of the staged changes (implies --print-only)")
+	commitCmd.Flags().BoolVar(&commitNotes, "notes", false, "Record the model and token usage as a git note on the new commit (default: notes.enabled)")
+	commitCmd.Flags().BoolVar(&commitOffline, "offline-fallback", false, "Build a message from the diff stats when the model is unreachable")
+	commitCmd.Flags().BoolVar(&commitPush, "push", false, "Push the branch after committing, setting up its upstream when missing")
+	commitCmd.Flags().BoolVar(&commitAllowProt, "allow-protected", false, "Commit and push to a protected branch without asking (unless its policy is block)")
+	commitCmd.Flags().BoolVar(&commitCheck, "check", false, checkFlagUsage)
 	rootCmd.AddCommand(commitCmd)
 }
 
@@ -73,18 +119,66 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	log.Debug("Using model: %s (provider: %s)", model, modelConfig.Provider)
 
 	// Get language (CLI flag > config > default)
-	language := cfg.GetLanguage(commitLanguage)
+	language := cfg.GetArtifactLanguage(config.ArtifactCommit, commitLanguage)
+	applyToolLanguage(cfg, language)
 
 	log.Debug("Using language: %s", language)
 
-	// Get current working directory
-	cwd, err := os.Getwd()
+	// Work in the repository root, wherever in it gitbuddy was started
+	cwd, err := workspaceDir(true)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
+	}
+
+	// A diff on stdin replaces the local repository, so there is nothing to commit to
+	printOnly := commitPrintOnly || commitStdinDiff
+	if commitPush && printOnly {
+		return fmt.Errorf("--push cannot be used with --print-only or --stdin-diff")
+	}
+	if commitCheck && printOnly {
+		return fmt.Errorf("--check cannot be used with --print-only or --stdin-diff")
 	}
 
 	// Create git executor
-	gitExec := git.NewExecutor(cwd)
+	gitExec, err := newVCSExecutor(cfg, cwd)
+	if err != nil {
+		return err
+	}
+	_, isGit := gitExec.(*git.DefaultExecutor)
+	if commitPush && !isGit {
+		return fmt.Errorf("--push is only supported with git")
+	}
+
+	// Protected branches are checked before calling the model when nobody
+	// can be asked, and once the message is known otherwise
+	var guard *protectedBranchGuard
+	var branch, pushTarget string
+	if isGit && !printOnly {
+		branch, pushTarget, err = commitTargets(ctx, cwd, commitPush)
+		if err != nil {
+			return err
+		}
+		guard = &protectedBranchGuard{
+			cfg:         cfg.GetProtectedBranchesConfig(),
+			allow:       commitAllowProt,
+			interactive: !commitCheck && isTerminal(os.Stdin) && isTerminal(os.Stdout),
+			input:       os.Stdin,
+			output:      os.Stdout,
+		}
+		if _, err := guard.check(branch, pushTarget, ""); err != nil {
+			if commitCheck {
+				return checkFailed(err)
+			}
+			return err
+		}
+	}
+	if commitStdinDiff {
+		stdinDiff, err := git.ReadDiff(os.Stdin)
+		if err != nil {
+			return err
+		}
+		gitExec = git.NewDiffExecutor(stdinDiff)
+	}
 
 	// Check if there are staged changes
 	diff, err := gitExec.DiffCached(ctx)
@@ -93,6 +187,9 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	}
 
 	if diff == "" {
+		if printOnly {
+			return fmt.Errorf("no staged changes found")
+		}
 		fmt.Println("No staged changes found.")
 		fmt.Println("\nTo stage changes, use:")
 		fmt.Println("  git add <file>")
@@ -101,11 +198,15 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(*modelConfig)
+	provider, err := newProvider(*modelConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("LLM provider created successfully")
 
@@ -121,17 +222,26 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Setup stream printer
-	printer := ui.NewStreamPrinter(os.Stdout, ui.WithVerbose(debugMode))
+	// In print-only mode stdout is reserved for the JSON result, so progress goes to stderr
+	var progressOut io.Writer = os.Stdout
+	if printOnly {
+		progressOut = os.Stderr
+	}
+	printer := newStreamPrinter(progressOut)
 
 	// Create commit agent with printer for progress output
 	agentOpts := agent.CommitAgentOptions{
-		Language:    language,
-		GitExecutor: gitExec,
-		LLMProvider: provider,
-		Printer:     printer,
-		Output:      os.Stdout,
-		Debug:       debugMode,
-		RetryConfig: retryConfig,
+		Language:             language,
+		GitExecutor:          gitExec,
+		LLMProvider:          provider,
+		Printer:              printer,
+		Output:               progressOut,
+		Debug:                debugMode,
+		RetryConfig:          retryConfig,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         cfg.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      cfg.GetPromptExtension("commit"),
+		OfflineFallback:      commitOffline,
 	}
 
 	commitAgent, err := agent.NewCommitAgent(agentOpts)
@@ -144,8 +254,9 @@ func runCommit(cmd *cobra.Command, args []string) error {
 
 	// Generate commit message
 	req := agent.CommitRequest{
-		Language: language,
-		Context:  commitContext,
+		Language:    language,
+		Context:     commitContext,
+		MaxDuration: maxDuration,
 	}
 
 	response, err := commitAgent.GenerateCommitMessage(ctx, req)
@@ -162,6 +273,17 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no commit message generated")
 	}
 
+	if response.Partial {
+		_ = printer.PrintError(fmt.Sprintf("Partial result (%s)", response.PartialReason))
+	}
+	if response.Offline {
+		_ = printer.PrintInfo("The message was generated offline from the staged files; review it before committing")
+	}
+
+	if printOnly {
+		return printCommitJSON(os.Stdout, response)
+	}
+
 	// Print the generated commit message
 	err = ui.ShowCommitMessage(commitMessage, os.Stdout)
 	if err != nil {
@@ -179,9 +301,21 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	}
 	_ = printer.PrintStats(stats)
 
-	// Ask for confirmation (default is Yes)
-	if !commitAutoYes {
-		confirmed, err := ui.ConfirmWithDefault("\nDo you want to commit with this message?", true, os.Stdin, os.Stdout)
+	if commitCheck {
+		if err := checkCommit(response, guard, branch, pushTarget); err != nil {
+			return err
+		}
+		fmt.Println("\n✅ Check passed, nothing was committed.")
+		return nil
+	}
+
+	// Ask for confirmation (default is Yes); a partial message salvaged from a
+	// failed run is never committed without asking, and defaults to No
+	if !commitAutoYes || response.Partial {
+		if commitAutoYes && !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to commit a partial message without confirmation (%s)", response.PartialReason)
+		}
+		confirmed, err := ui.ConfirmWithDefault("\nDo you want to commit with this message?", !response.Partial, os.Stdin, os.Stdout)
 		if err != nil {
 			return err
 		}
@@ -191,12 +325,200 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Execute commit
-	err = gitExec.Commit(ctx, commitMessage)
-	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+	if guard != nil {
+		newBranch, err := guard.check(branch, pushTarget, suggestBranchName(response.CommitInfo))
+		if errors.Is(err, errCommitCancelled) {
+			fmt.Println("Commit cancelled.")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if newBranch != "" {
+			if err := git.CreateBranch(ctx, cwd, newBranch); err != nil {
+				return err
+			}
+			fmt.Printf("Switched to a new branch %s\n", newBranch)
+		}
+	}
+
+	// Execute commit; hooks of the repository may reject it
+	hooks := &commitHookHandler{
+		workDir:     cwd,
+		fixCommands: cfg.GetCommitConfig().HookFixCommands,
+		interactive: !commitAutoYes && isTerminal(os.Stdin) && isTerminal(os.Stdout),
+		input:       os.Stdin,
+		output:      os.Stdout,
+	}
+	if err := hooks.commit(ctx, gitExec, commitMessage); err != nil {
+		if errors.Is(err, errCommitCancelled) {
+			fmt.Println("Commit cancelled.")
+			return nil
+		}
+		return err
 	}
 
 	fmt.Println("\n✅ Commit created successfully!")
+
+	if notesEnabled(cmd, cfg, commitNotes) {
+		recordNote(ctx, cwd, "HEAD", printer, git.NoteEntry{
+			Kind:             "commit",
+			CreatedAt:        endTime,
+			Model:            servedModel(provider),
+			PromptTokens:     response.PromptTokens,
+			CompletionTokens: response.CompletionTokens,
+			TotalTokens:      response.TotalTokens,
+			Summary:          commitNoteSummary(response),
+		})
+	}
+
+	if commitPush {
+		return pushBranch(ctx, cwd, os.Stdout)
+	}
+	return nil
+}
+
+// checkCommit validates a generated commit for --check: the message must be
+// complete and valid, and committing it to branch allowed
+func checkCommit(response *agent.CommitResponse, guard *protectedBranchGuard, branch, pushTarget string) error {
+	if response.Partial {
+		return checkFailed(fmt.Errorf("partial result (%s)", response.PartialReason))
+	}
+	if err := response.CommitInfo.Validate(); err != nil {
+		return checkFailed(fmt.Errorf("invalid commit message: %w", err))
+	}
+	if guard != nil {
+		if _, err := guard.check(branch, pushTarget, suggestBranchName(response.CommitInfo)); err != nil {
+			return checkFailed(err)
+		}
+	}
+	return nil
+}
+
+// pushBranch pushes the current branch, creating its upstream on origin (or
+// the only remote) when missing, and prints where to open a pull request
+func pushBranch(ctx context.Context, workDir string, out io.Writer) error {
+	branch, err := git.HeadBranch(ctx, workDir)
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		return fmt.Errorf("commit created, but HEAD is detached: check out a branch to push")
+	}
+	upstream, err := git.Upstream(ctx, workDir)
+	if err != nil {
+		return err
+	}
+
+	// target stays empty to push to the existing upstream
+	var remote, target string
+	if upstream == "" {
+		if remote, err = pushRemote(ctx, workDir); err != nil {
+			return fmt.Errorf("commit created, but %w", err)
+		}
+		target = remote
+		fmt.Fprintf(out, "\nPushing %s to %s and setting its upstream...\n", branch, remote)
+	} else {
+		remote, _, _ = strings.Cut(upstream, "/")
+		fmt.Fprintf(out, "\nPushing %s to %s...\n", branch, upstream)
+	}
+
+	output, err := git.Push(ctx, workDir, target, branch, upstream == "")
+	if err != nil {
+		return fmt.Errorf("commit created, but the push failed: %w", err)
+	}
+	fmt.Fprintln(out, "✅ Pushed successfully!")
+
+	if url := pullRequestURL(ctx, workDir, remote, branch, output); url != "" {
+		fmt.Fprintf(out, "Open a pull request: %s\n", url)
+	}
+	return nil
+}
+
+// pushRemote returns the remote to create the upstream on: origin, or the
+// only remote
+func pushRemote(ctx context.Context, workDir string) (string, error) {
+	remotes, err := git.Remotes(ctx, workDir)
+	if err != nil {
+		return "", err
+	}
+	for _, remote := range remotes {
+		if remote == "origin" {
+			return remote, nil
+		}
+	}
+	switch len(remotes) {
+	case 0:
+		return "", fmt.Errorf("there is no remote to push to")
+	case 1:
+		return remotes[0], nil
+	default:
+		return "", fmt.Errorf("there is no origin remote to push to (remotes: %s); push with git push -u <remote> <branch>", strings.Join(remotes, ", "))
+	}
+}
+
+// pullRequestURL returns the link to open a pull request for branch: the one
+// the remote printed during the push, or one built from the remote URL. There
+// is none for the default branch.
+func pullRequestURL(ctx context.Context, workDir, remote, branch, pushOutput string) string {
+	if url := forge.PushMessageURL(pushOutput); url != "" {
+		return url
+	}
+	if defaultBranch, err := git.DefaultBranch(ctx, workDir); err == nil && strings.TrimPrefix(defaultBranch, remote+"/") == branch {
+		return ""
+	}
+	remoteURL, err := git.ConfigValue(ctx, workDir, "remote."+remote+".url")
+	if err != nil || remoteURL == "" {
+		return ""
+	}
+	repo, err := forge.ParseRemoteURL(remoteURL)
+	if err != nil {
+		log.Debug("No pull request link: %v", err)
+		return ""
+	}
+	return repo.NewPullRequestURL(branch)
+}
+
+// commitPrintOutput is the JSON document emitted by `commit --print-only`
+type commitPrintOutput struct {
+	*agent.CommitInfo
+	Title      string             `json:"title"`
+	Message    string             `json:"message"`
+	TokenUsage session.TokenUsage `json:"token_usage"`
+	Partial    bool               `json:"partial,omitempty"`
+	Offline    bool               `json:"offline,omitempty"`
+}
+
+// newCommitPrintOutput builds the print-only output from a commit response
+func newCommitPrintOutput(response *agent.CommitResponse) commitPrintOutput {
+	return commitPrintOutput{
+		CommitInfo: response.CommitInfo,
+		Title:      response.CommitInfo.Title(),
+		Message:    response.CommitInfo.Message(),
+		Partial:    response.Partial,
+		Offline:    response.Offline,
+		TokenUsage: session.TokenUsage{
+			PromptTokens:     response.PromptTokens,
+			CompletionTokens: response.CompletionTokens,
+			TotalTokens:      response.TotalTokens,
+		},
+	}
+}
+
+// commitNoteSummary describes how the commit message was generated
+func commitNoteSummary(response *agent.CommitResponse) string {
+	if response.Offline {
+		return "Commit message generated by gitbuddy offline from the diff stats (model unreachable)"
+	}
+	return "Commit message generated by gitbuddy"
+}
+
+// printCommitJSON writes the generated commit info as indented JSON
+func printCommitJSON(w io.Writer, response *agent.CommitResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newCommitPrintOutput(response)); err != nil {
+		return fmt.Errorf("failed to encode commit info: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// maxHookOutputLines is how many of the last lines printed by a failed hook
+// are shown
+const maxHookOutputLines = 40
+
+// hookAction is a way to continue after a hook rejected the commit
+type hookAction struct {
+	label   string
+	command string // Fix command to run first, "" for none
+	restage bool   // Stage the staged files changed since, e.g. by a formatter
+	cancel  bool
+}
+
+// commitHookHandler commits and, when a hook of the repository rejects the
+// commit, shows what the hook printed and offers to fix the problem and
+// commit again
+type commitHookHandler struct {
+	workDir     string
+	fixCommands []string // commit.hook_fix_commands
+	interactive bool
+	input       io.Reader
+	output      io.Writer
+}
+
+// commit commits message with executor until it succeeds, fails for
+// another reason than a hook, or the user gives up
+func (h *commitHookHandler) commit(ctx context.Context, executor git.Executor, message string) error {
+	for {
+		err := executor.Commit(ctx, message)
+		var hookErr *git.HookError
+		if !errors.As(err, &hookErr) {
+			if err != nil {
+				return fmt.Errorf("failed to commit: %w", err)
+			}
+			return nil
+		}
+
+		printHookFailure(h.output, hookErr)
+		if !h.interactive {
+			return fmt.Errorf("failed to commit: %w; fix what it reports and commit again", hookErr)
+		}
+		action, changed, err := h.choose(ctx)
+		if err != nil {
+			return err
+		}
+		if action.cancel {
+			return errCommitCancelled
+		}
+		if err := h.apply(ctx, action, changed); err != nil {
+			return err
+		}
+		fmt.Fprintln(h.output, "Retrying the commit...")
+	}
+}
+
+// choose asks how to continue. changed are the staged files the hook
+// changed, which are offered to be staged again.
+func (h *commitHookHandler) choose(ctx context.Context) (hookAction, []string, error) {
+	staged, err := git.StagedFiles(ctx, h.workDir)
+	if err != nil {
+		return hookAction{}, nil, err
+	}
+	changed, err := git.UnstagedFiles(ctx, h.workDir, staged)
+	if err != nil {
+		return hookAction{}, nil, err
+	}
+
+	var actions []hookAction
+	if len(changed) > 0 {
+		actions = append(actions, hookAction{
+			label:   fmt.Sprintf("Stage the %d file(s) the hook changed (%s) and retry", len(changed), strings.Join(changed, ", ")),
+			restage: true,
+		})
+	}
+	for _, command := range h.fixCommands {
+		actions = append(actions, hookAction{label: fmt.Sprintf("Run %s, stage its changes and retry", command), command: command, restage: true})
+	}
+	actions = append(actions,
+		hookAction{label: "Retry (after fixing it in another terminal)"},
+		hookAction{label: "Cancel the commit", cancel: true},
+	)
+
+	labels := make([]string, len(actions))
+	for i, action := range actions {
+		labels[i] = action.label
+	}
+	choice, err := ui.SelectOption("How do you want to continue?", labels, 0, h.input, h.output)
+	if err != nil {
+		return hookAction{}, nil, fmt.Errorf("failed to read the choice: %w", err)
+	}
+	return actions[choice], changed, nil
+}
+
+// apply runs the fix command of action, if any, and stages the staged files
+// changed since they were staged. Files that weren't staged are left alone.
+func (h *commitHookHandler) apply(ctx context.Context, action hookAction, changed []string) error {
+	if action.command != "" {
+		fmt.Fprintf(h.output, "$ %s\n", action.command)
+		result, err := tools.NewRunCommandTool(h.workDir, []string{action.command}, 0).Run(ctx, &tools.RunCommandParams{Command: action.command})
+		if err != nil {
+			return fmt.Errorf("failed to run %s: %w", action.command, err)
+		}
+		if output := strings.TrimRight(result.Output, "\n"); output != "" {
+			fmt.Fprintln(h.output, output)
+		}
+		if result.ExitCode != 0 {
+			fmt.Fprintf(h.output, "⚠️  %s exited with code %d\n", action.command, result.ExitCode)
+		}
+
+		staged, err := git.StagedFiles(ctx, h.workDir)
+		if err != nil {
+			return err
+		}
+		if changed, err = git.UnstagedFiles(ctx, h.workDir, staged); err != nil {
+			return err
+		}
+	}
+	if !action.restage || len(changed) == 0 {
+		return nil
+	}
+	if err := git.StageFiles(ctx, h.workDir, changed); err != nil {
+		return err
+	}
+	fmt.Fprintf(h.output, "Staged %s\n", strings.Join(changed, ", "))
+	return nil
+}
+
+// printHookFailure shows which hook rejected the commit and the end of what
+// it printed
+func printHookFailure(out io.Writer, hookErr *git.HookError) {
+	fmt.Fprintf(out, "\n❌ The %s hook rejected the commit (exit code %d)", hookErr.Hook, hookErr.ExitCode)
+	if hookErr.Output == "" {
+		fmt.Fprintln(out, " without printing why.")
+		return
+	}
+	fmt.Fprintln(out, ":")
+	lines := strings.Split(hookErr.Output, "\n")
+	if len(lines) > maxHookOutputLines {
+		fmt.Fprintf(out, "    ... (%d lines omitted)\n", len(lines)-maxHookOutputLines)
+		lines = lines[len(lines)-maxHookOutputLines:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(out, "    "+line)
+	}
+}
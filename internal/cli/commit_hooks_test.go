@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatHook is a pre-commit hook that, like formatter hooks, rewrites
+// main.go when its staged content isn't formatted and rejects the commit
+const formatHook = `#!/bin/sh
+if [ "$(git show :main.go)" != "package main" ]; then
+	printf 'package main\n' > main.go
+	echo "main.go: reformatted, stage it again"
+	exit 1
+fi
+`
+
+func hookRepo(t *testing.T) *testutil.GitRepo {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need sh")
+	}
+	repo := testutil.NewGitRepo(t)
+	repo.WriteFile(".git/hooks/pre-commit", formatHook)
+	require.NoError(t, os.Chmod(filepath.Join(repo.Dir, ".git/hooks/pre-commit"), 0755))
+	repo.Stage("main.go", "package  main\n")
+	return repo
+}
+
+func TestCommitHookHandler_RestageChangedFiles(t *testing.T) {
+	repo := hookRepo(t)
+	var output bytes.Buffer
+	handler := &commitHookHandler{
+		workDir:     repo.Dir,
+		interactive: true,
+		input:       strings.NewReader("1\n"),
+		output:      &output,
+	}
+
+	err := handler.commit(context.Background(), git.NewExecutor(repo.Dir), "feat: add main")
+	require.NoError(t, err, output.String())
+	assert.Contains(t, output.String(), "❌ The pre-commit hook rejected the commit (exit code 1):\n    main.go: reformatted, stage it again")
+	assert.Contains(t, output.String(), "Stage the 1 file(s) the hook changed (main.go) and retry")
+	assert.Equal(t, "feat: add main", repo.LastCommitMessage())
+}
+
+func TestCommitHookHandler_FixCommand(t *testing.T) {
+	repo := hookRepo(t)
+	// Keep the hook from fixing the file itself
+	repo.WriteFile(".git/hooks/pre-commit", strings.Replace(formatHook, "\tprintf 'package main\\n' > main.go\n", "", 1))
+	repo.WriteFile("formatted.go.txt", "package main\n")
+	var output bytes.Buffer
+	handler := &commitHookHandler{
+		workDir:     repo.Dir,
+		fixCommands: []string{"cp formatted.go.txt main.go"},
+		interactive: true,
+		input:       strings.NewReader("1\n"),
+		output:      &output,
+	}
+
+	err := handler.commit(context.Background(), git.NewExecutor(repo.Dir), "feat: add main")
+	require.NoError(t, err, output.String())
+	assert.Contains(t, output.String(), "Run cp formatted.go.txt main.go, stage its changes and retry")
+	assert.Contains(t, output.String(), "Staged main.go")
+	assert.Equal(t, "feat: add main", repo.LastCommitMessage())
+	assert.NotContains(t, repo.Git("show", "--name-only", "--format="), "formatted.go.txt", "files that weren't staged stay unstaged")
+}
+
+func TestCommitHookHandler_NonInteractive(t *testing.T) {
+	repo := hookRepo(t)
+	var output bytes.Buffer
+	handler := &commitHookHandler{workDir: repo.Dir, output: &output}
+
+	err := handler.commit(context.Background(), git.NewExecutor(repo.Dir), "feat: add main")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the pre-commit hook rejected the commit")
+	assert.Contains(t, output.String(), "main.go: reformatted")
+}
+
+func TestCommitHookHandler_Cancel(t *testing.T) {
+	repo := hookRepo(t)
+	handler := &commitHookHandler{
+		workDir:     repo.Dir,
+		interactive: true,
+		input:       strings.NewReader("3\n"),
+		output:      &bytes.Buffer{},
+	}
+
+	err := handler.commit(context.Background(), git.NewExecutor(repo.Dir), "feat: add main")
+	assert.ErrorIs(t, err, errCommitCancelled)
+}
+
+func TestPrintHookFailure_LongOutput(t *testing.T) {
+	var lines []string
+	for i := 1; i <= maxHookOutputLines+10; i++ {
+		lines = append(lines, "lint warning")
+	}
+	var output bytes.Buffer
+	printHookFailure(&output, &git.HookError{Hook: "pre-commit", ExitCode: 1, Output: strings.Join(lines, "\n")})
+	assert.Contains(t, output.String(), "    ... (10 lines omitted)\n")
+	assert.Equal(t, maxHookOutputLines, strings.Count(output.String(), "lint warning"))
+}
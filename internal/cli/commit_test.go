@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+)
+
+func TestCommitCmd_PrintOnlyFlag(t *testing.T) {
+	flag := commitCmd.Flags().Lookup("print-only")
+	require.NotNil(t, flag, "print-only flag should exist")
+	assert.Equal(t, "false", flag.DefValue)
+}
+
+func TestPrintCommitJSON(t *testing.T) {
+	response := &agent.CommitResponse{
+		CommitInfo: &agent.CommitInfo{
+			Type:        "feat",
+			Scope:       "cli",
+			Description: "add print-only mode",
+			Body:        "Useful for editor integrations",
+			Footer:      "Closes #42",
+		},
+		PromptTokens:     100,
+		CompletionTokens: 20,
+		TotalTokens:      120,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printCommitJSON(&buf, response))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+	assert.Equal(t, "feat", decoded["type"])
+	assert.Equal(t, "cli", decoded["scope"])
+	assert.Equal(t, "add print-only mode", decoded["description"])
+	assert.Equal(t, "Useful for editor integrations", decoded["body"])
+	assert.Equal(t, "Closes #42", decoded["footer"])
+	assert.Equal(t, "feat(cli): add print-only mode", decoded["title"])
+	assert.Equal(t, response.CommitInfo.Message(), decoded["message"])
+
+	usage, ok := decoded["token_usage"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(100), usage["prompt_tokens"])
+	assert.Equal(t, float64(20), usage["completion_tokens"])
+	assert.Equal(t, float64(120), usage["total_tokens"])
+}
+
+func TestPushBranch(t *testing.T) {
+	ctx := context.Background()
+	remote := t.TempDir()
+	runGitAt(t, remote, nil, "init", "--quiet", "--bare")
+	repo := t.TempDir()
+	runGitAt(t, repo, nil, "init", "--quiet", "--initial-branch=main")
+	runGitAt(t, repo, nil, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial")
+	runGitAt(t, repo, nil, "checkout", "--quiet", "-b", "feature")
+
+	var out bytes.Buffer
+	assert.ErrorContains(t, pushBranch(ctx, repo, &out), "no remote to push to")
+
+	runGitAt(t, repo, nil, "remote", "add", "upstream", remote)
+	runGitAt(t, repo, nil, "remote", "add", "fork", remote)
+	assert.ErrorContains(t, pushBranch(ctx, repo, &out), "no origin remote")
+
+	runGitAt(t, repo, nil, "remote", "add", "origin", remote)
+	out.Reset()
+	require.NoError(t, pushBranch(ctx, repo, &out))
+	assert.Contains(t, out.String(), "Pushing feature to origin and setting its upstream")
+	assert.NotContains(t, out.String(), "pull request", "a local remote has no forge")
+
+	out.Reset()
+	require.NoError(t, pushBranch(ctx, repo, &out))
+	assert.Contains(t, out.String(), "Pushing feature to origin/feature...")
+}
+
+func TestPullRequestURL(t *testing.T) {
+	ctx := context.Background()
+	repo := t.TempDir()
+	runGitAt(t, repo, nil, "init", "--quiet", "--initial-branch=main")
+	runGitAt(t, repo, nil, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial")
+	runGitAt(t, repo, nil, "remote", "add", "origin", "git@github.com:acme/api.git")
+
+	assert.Equal(t, "https://github.com/acme/api/pull/new/x", pullRequestURL(ctx, repo, "origin", "x", "remote:   https://github.com/acme/api/pull/new/x\n"))
+	assert.Equal(t, "https://github.com/acme/api/compare/feature?expand=1", pullRequestURL(ctx, repo, "origin", "feature", ""))
+	assert.Empty(t, pullRequestURL(ctx, repo, "origin", "main", ""), "no pull request from the default branch")
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/daemon"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonPollInterval       time.Duration
+	daemonMinRefreshInterval time.Duration
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep the repository map and staged diff warm in the background",
+	Long: `Run a background process that watches the current directory and keeps the
+state the commands start from warm: the repository map, which debug and chat
+put in their prompts, and the diff of the staged changes, which commit and
+review start from. Commands run in the same directory ask the daemon over a
+socket in $XDG_RUNTIME_DIR/gitbuddy (~/.gitbuddy/run without it); without a
+daemon they compute the state themselves, as before.
+
+The repository is checked for changes every --interval. Refreshing the
+repository map stats every file, so refreshes are rate-limited to one per
+--min-refresh; edits made in between show up in the next refresh. The
+staged diff is always current: it is recomputed whenever the index changed.
+
+The daemon runs in the foreground until interrupted or stopped; start it in
+a separate terminal, with "&" or from a service manager.
+
+Available subcommands:
+  status - Show the status of the daemon of the current directory
+  stop   - Stop the daemon of the current directory
+
+Examples:
+  gitbuddy daemon &
+  gitbuddy daemon --interval 10s --min-refresh 2m
+  gitbuddy daemon status
+  gitbuddy daemon stop`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the daemon of the current directory",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon of the current directory",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&daemonPollInterval, "interval", daemon.DefaultPollInterval, "How often to check the repository for changes")
+	daemonCmd.Flags().DurationVar(&daemonMinRefreshInterval, "min-refresh", daemon.DefaultMinRefreshInterval, "Minimum time between two repository map refreshes")
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	if _, err := git.IndexState(cmd.Context(), workDir); err != nil {
+		return fmt.Errorf("the daemon only supports git repositories: %w", err)
+	}
+
+	listener, err := daemon.Listen(workDir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := daemon.NewServer(daemon.Options{
+		WorkDir:            workDir,
+		PollInterval:       daemonPollInterval,
+		MinRefreshInterval: daemonMinRefreshInterval,
+	})
+	fmt.Printf("Daemon serving %s on %s\n", workDir, daemon.SocketPath(workDir))
+	if err := server.Serve(ctx, listener); err != nil {
+		return err
+	}
+	fmt.Println("Daemon stopped")
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	client, err := connectDaemon()
+	if err != nil {
+		return err
+	}
+	status, err := client.Status(cmd.Context())
+	if err != nil {
+		return err
+	}
+	printDaemonStatus(os.Stdout, status, time.Now())
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	client, err := connectDaemon()
+	if err != nil {
+		return err
+	}
+	if err := client.Stop(cmd.Context()); err != nil {
+		return err
+	}
+	fmt.Println("Daemon stopped")
+	return nil
+}
+
+// connectDaemon returns a client of the daemon of the current directory
+func connectDaemon() (*daemon.Client, error) {
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return nil, err
+	}
+	client, err := daemon.Connect(workDir)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		return nil, fmt.Errorf("no daemon is running for %s (start one with: gitbuddy daemon)", workDir)
+	}
+	return client, err
+}
+
+func printDaemonStatus(w io.Writer, status *daemon.Status, now time.Time) {
+	fmt.Fprintf(w, "Directory:      %s\n", status.WorkDir)
+	fmt.Fprintf(w, "PID:            %d\n", status.PID)
+	fmt.Fprintf(w, "Uptime:         %s\n", now.Sub(status.StartedAt).Round(time.Second))
+	fmt.Fprintf(w, "Intervals:      poll every %s, refresh at most every %s\n", status.PollInterval, status.MinRefreshInterval)
+	if status.MapRefreshes == 0 {
+		fmt.Fprintln(w, "Repository map: building")
+	} else {
+		fmt.Fprintf(w, "Repository map: %d files, refreshed %s ago (%d refreshes)\n",
+			status.MapFiles, now.Sub(status.MapRefreshedAt).Round(time.Second), status.MapRefreshes)
+	}
+	fmt.Fprintf(w, "Staged diff:    %d requests, %d served from cache\n", status.DiffRequests, status.DiffCacheHits)
+}
+
+// daemonRepoMap returns the repository map of workDir rendered by its daemon,
+// or false when no daemon serves it or its map isn't built yet
+func daemonRepoMap(ctx context.Context, workDir string, maxChars int) (string, bool) {
+	client, err := daemon.Connect(workDir)
+	if err != nil {
+		warnDaemonRefused(err)
+		return "", false
+	}
+	rendered, err := client.RepoMap(ctx, maxChars)
+	if err != nil {
+		log.Debug("Repository map not served by the daemon: %v", err)
+		return "", false
+	}
+	log.Debug("Repository map served by the daemon")
+	return rendered, true
+}
+
+// useDaemonDiff makes executor read the staged diff from the daemon of
+// workDir when one runs
+func useDaemonDiff(executor git.Executor, workDir string) {
+	gitExecutor, ok := executor.(*git.DefaultExecutor)
+	if !ok {
+		return
+	}
+	client, err := daemon.Connect(workDir)
+	if err != nil {
+		warnDaemonRefused(err)
+		return
+	}
+	gitExecutor.SetStagedDiffSource(func(ctx context.Context) (string, error) {
+		diff, err := client.StagedDiff(ctx)
+		if err != nil {
+			log.Debug("Staged diff not served by the daemon: %v", err)
+		}
+		return diff, err
+	})
+}
+
+// warnDaemonRefused warns when the socket of the daemon was refused, rather
+// than missing
+func warnDaemonRefused(err error) {
+	if !errors.Is(err, daemon.ErrNotRunning) {
+		log.Warn("Not using the daemon: %v", err)
+	}
+}
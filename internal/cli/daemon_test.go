@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/daemon"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVCSExecutor_DaemonDiff(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	repo := testutil.NewSampleRepo(t)
+	repo.Stage("auth/lockout.go", "package auth\n")
+
+	listener, err := daemon.Listen(repo.Dir)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- daemon.NewServer(daemon.Options{WorkDir: repo.Dir, PollInterval: time.Hour}).Serve(ctx, listener)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// commit and review read the staged diff through this executor
+	executor, err := newVCSExecutor(&config.Config{}, repo.Dir)
+	require.NoError(t, err)
+	diff, err := executor.DiffCached(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "auth/lockout.go")
+
+	client, err := daemon.Connect(repo.Dir)
+	require.NoError(t, err)
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.DiffRequests)
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
 	"github.com/huimingz/gitbuddy-go/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -26,7 +28,17 @@ var (
 	debugIssuesDir     string
 	debugMaxIterations int
 	debugResume        string
+	debugResumeLast    bool
+	debugNotes         bool
 	debugPostInteractive bool // Post-execution interactive mode
+	debugIsolated      bool
+	debugApprovePlan   bool
+	debugIssuesFile    string
+	debugParallel      int
+	debugNoRelated     bool
+	debugRawStream     bool
+	debugRunTests      bool
+	debugFileIssues    bool
 )
 
 var debugCmd = &cobra.Command{
@@ -45,19 +57,41 @@ The AI agent has access to:
 - Search tools (grep_file, grep_directory)
 - Git tools (git_status, git_diff_cached, git_log, git_show)
 - Interactive feedback (with --interactive flag)
+- Test execution in the verification phase (with --run-tests or debug.test_commands)
 
 Examples:
   gitbuddy debug "Login fails with 500 error"
   gitbuddy debug "Memory leak in background worker" -c "Happens after 24h"
   gitbuddy debug "Test TestUserAuth is failing" --files "auth_test.go,auth.go"
   gitbuddy debug "API returns wrong data" --interactive
-  gitbuddy debug "Performance issue" -l zh --interactive`,
+  gitbuddy debug "Performance issue" -l zh --interactive
+  gitbuddy debug "Flaky test" --isolated     # Investigate the staged state only
+  gitbuddy debug --issues issues.yaml --parallel 2
+
+With --isolated, the agent works in a temporary git worktree holding the staged
+state instead of your working tree. Any changes are saved as a patch under
+.gitbuddy/patches.
+
+The solutions and prevention measures of a saved report are recorded as
+follow-up tasks in .gitbuddy/tasks.yaml; with --file-issues, each new task is
+also filed as an issue on the repository's GitHub.
+
+With --issues, every issue listed in the YAML file is debugged in turn (or
+--parallel at a time), and an index report linking the individual reports is
+saved next to them. Entries are either a description or a mapping with issue,
+context and files keys.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		// If resuming, no args needed
+		// If resuming or reading issues from a file, no args needed
 		resumeFlag := cmd.Flag("resume").Value.String()
 		if resumeFlag != "" {
 			return cobra.NoArgs(cmd, args)
 		}
+		if resumeLast := cmd.Flag("resume-last"); resumeLast != nil && resumeLast.Value.String() == "true" {
+			return cobra.NoArgs(cmd, args)
+		}
+		if issuesFlag := cmd.Flag("issues"); issuesFlag != nil && issuesFlag.Value.String() != "" {
+			return cobra.NoArgs(cmd, args)
+		}
 		// Allow 0 or 1 args (0 for interactive input, 1 for traditional)
 		return cobra.RangeArgs(0, 1)(cmd, args)
 	},
@@ -69,10 +103,20 @@ func init() {
 	debugCmd.Flags().StringVarP(&debugLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
 	debugCmd.Flags().StringVar(&debugFiles, "files", "", "Comma-separated list of files to focus on")
 	debugCmd.Flags().BoolVarP(&debugInteractive, "interactive", "i", false, "Enable interactive mode (agent can ask for your input)")
+	debugCmd.Flags().BoolVar(&debugApprovePlan, "approve-plan", false, "Ask for approval of the investigation plan before it is executed (requires --interactive, default: debug.approve_plan)")
 	debugCmd.Flags().StringVar(&debugIssuesDir, "issues-dir", "./issues", "Directory to save debug reports")
 	debugCmd.Flags().IntVar(&debugMaxIterations, "max-iterations", 0, "Maximum number of agent iterations (0 = use config default)")
 	debugCmd.Flags().StringVar(&debugResume, "resume", "", "Resume from a previous session (session ID)")
+	debugCmd.Flags().BoolVar(&debugResumeLast, "resume-last", false, "Resume the most recent debug session that didn't complete, recovering one whose process crashed")
+	debugCmd.Flags().BoolVar(&debugNotes, "notes", false, "Record a reference to the debug report and token usage as a git note on HEAD (default: notes.enabled)")
 	debugCmd.Flags().BoolVar(&debugPostInteractive, "post-interactive", false, "Enable post-execution interactive mode for follow-up questions and report modifications")
+	debugCmd.Flags().BoolVar(&debugNoRelated, "no-related", false, "Don't look for earlier reports on similar issues")
+	debugCmd.Flags().StringVar(&debugIssuesFile, "issues", "", "Debug every issue listed in a YAML file and write an index report linking the reports")
+	debugCmd.Flags().IntVar(&debugParallel, "parallel", 1, "Number of issues from --issues debugged at the same time")
+	debugCmd.Flags().BoolVar(&debugIsolated, "isolated", false, "Work in a temporary worktree of the staged state and save changes as a patch")
+	debugCmd.Flags().BoolVar(&debugRawStream, "raw-stream", false, "Stream only the report to stdout, undecorated, as it is written; progress goes to stderr")
+	debugCmd.Flags().BoolVar(&debugRunTests, "run-tests", false, "Let the agent run targeted tests in the verification phase and add the results to the report (default: on when debug.test_commands is set)")
+	debugCmd.Flags().BoolVar(&debugFileIssues, "file-issues", false, "File a GitHub issue for each follow-up task of the report (default: debug.file_issues)")
 
 	rootCmd.AddCommand(debugCmd)
 }
@@ -81,8 +125,28 @@ func runDebug(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 	startTime := time.Now()
 
+	if debugApprovePlan && !debugInteractive {
+		return fmt.Errorf("--approve-plan requires --interactive")
+	}
+	if debugRawStream && (debugInteractive || debugPostInteractive || debugIssuesFile != "") {
+		return fmt.Errorf("--raw-stream cannot be combined with --interactive, --post-interactive or --issues")
+	}
+	if debugIssuesFile != "" {
+		return runDebugBatch(cmd)
+	}
+
+	// With --raw-stream, stdout only receives the report
+	display := io.Writer(os.Stdout)
+	if debugRawStream {
+		display = os.Stderr
+	}
+
+	if debugResume != "" && debugResumeLast {
+		return fmt.Errorf("--resume cannot be combined with --resume-last")
+	}
+
 	var issue string
-	if debugResume != "" {
+	if debugResume != "" || debugResumeLast {
 		// When resuming, issue will be loaded from session
 		issue = "Resuming from session"
 	} else if len(args) == 0 {
@@ -99,7 +163,7 @@ func runDebug(cmd *cobra.Command, args []string) error {
 		}
 
 		var err error
-		issue, err = prompt.Show(os.Stdin, os.Stdout)
+		issue, err = prompt.Show(os.Stdin, display)
 		if err != nil {
 			if err == ui.ErrEmptyInput {
 				return fmt.Errorf("issue description cannot be empty")
@@ -124,6 +188,7 @@ func runDebug(cmd *cobra.Command, args []string) error {
 	}
 
 	log.DebugConfig("Configuration", cfg)
+	notifier := ui.NewNotifier(cfg.UINotifyAfter())
 
 	// Get model configuration
 	modelConfig, err := cfg.GetModel(modelName)
@@ -134,7 +199,8 @@ func runDebug(cmd *cobra.Command, args []string) error {
 	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
 
 	// Get language
-	language := cfg.GetLanguage(debugLanguage)
+	language := cfg.GetArtifactLanguage(config.ArtifactDebug, debugLanguage)
+	applyToolLanguage(cfg, language)
 	log.Debug("Using language: %s", language)
 
 	// Get debug config
@@ -156,22 +222,50 @@ func runDebug(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(*modelConfig)
+	provider, err := newProvider(*modelConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("LLM provider created successfully")
 
-	// Get current working directory
-	workDir, err := os.Getwd()
+	// Work in the repository root, wherever in it gitbuddy was started
+	workDir, err := workspaceDir(false)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
+	}
+
+	// Point all tools at a temporary worktree in isolated mode
+	agentDir := workDir
+	var worktree *git.IsolatedWorktree
+	var releaseWorktree func() bool
+	if debugIsolated {
+		worktree, err = startIsolated(ctx, cfg, workDir)
+		if err != nil {
+			return err
+		}
+		// The interrupt handler exits without running deferred calls, so it
+		// removes the worktree itself
+		isolated := worktree
+		releaseWorktree = onInterrupt(func() { _ = isolated.Remove(context.WithoutCancel(ctx)) })
+		defer func() {
+			if worktree != nil && releaseWorktree() {
+				_ = worktree.Remove(context.WithoutCancel(ctx))
+			}
+		}()
+		agentDir = worktree.Dir()
 	}
 
 	// Create git executor
-	gitExecutor := git.NewExecutor(workDir)
+	gitExecutor, err := newVCSExecutor(cfg, agentDir)
+	if err != nil {
+		return err
+	}
 
 	// Parse files list
 	var files []string
@@ -180,10 +274,15 @@ func runDebug(cmd *cobra.Command, args []string) error {
 		for i := range files {
 			files[i] = strings.TrimSpace(files[i])
 		}
+		// Given relative to where gitbuddy was started
+		files = workspaceFiles(workDir, files)
 	}
 
 	// Create stream printer for output
-	printer := ui.NewStreamPrinter(os.Stdout, ui.WithVerbose(debugMode))
+	printer := newStreamPrinter(display)
+	if debugRawStream {
+		printer = newStreamPrinter(display, ui.WithRawStream(os.Stdout))
+	}
 
 	// Get retry and session config
 	retryConfigPtr := cfg.GetRetryConfig()
@@ -199,21 +298,29 @@ func runDebug(cmd *cobra.Command, args []string) error {
 
 	// Create session manager
 	sessionMgr := session.NewManager(sessionConfig.SaveDir)
+	sessionMgr.SetRunInfo(sessionRunInfo(ctx, cmd, modelConfig, workDir, gitExecutor))
 
 	// Create debug agent
 	debugAgent := agent.NewDebugAgent(agent.DebugAgentOptions{
-		Language:        language,
-		GitExecutor:     gitExecutor,
-		LLMProvider:     provider,
-		Printer:         printer,
-		Output:          os.Stdout,
-		Input:           os.Stdin,
-		Debug:           debugMode,
-		WorkDir:         workDir,
-		IssuesDir:       issuesDir,
-		MaxLinesPerRead: debugCfg.MaxLinesPerRead,
-		RetryConfig:     retryConfig,
-		SessionManager:  sessionMgr,
+		Language:             language,
+		GitExecutor:          gitExecutor,
+		LLMProvider:          provider,
+		Printer:              printer,
+		Output:               display,
+		Input:                os.Stdin,
+		Debug:                debugMode,
+		WorkDir:              agentDir,
+		IssuesDir:            issuesDir,
+		MaxLinesPerRead:      debugCfg.MaxLinesPerRead,
+		RetryConfig:          retryConfig,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         cfg.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      cfg.GetPromptExtension("debug"),
+		RepositoryMap:        repositoryMap(ctx, cfg, workDir),
+		SessionManager:       sessionMgr,
+		TestCommands:         debugCfg.TestCommands,
+		Notifier:             notifier,
+		DisableAnswerReuse:   debugCfg.DisableAnswerReuse,
 	})
 
 	// Setup context with cancellation for Ctrl+C handling
@@ -235,19 +342,30 @@ func runDebug(cmd *cobra.Command, args []string) error {
 
 	// Check if resuming from a previous session
 	var sess *session.Session
-	if debugResume != "" {
-		_ = printer.PrintInfo(fmt.Sprintf("Resuming session: %s", debugResume))
-
-		loadedSession, err := sessionMgr.Load(debugResume)
+	var relatedReports []*reports.Report
+	if debugResume != "" || debugResumeLast {
+		var loadedSession *session.Session
+		var err error
+		if debugResumeLast {
+			loadedSession, err = sessionMgr.Last("debug")
+		} else {
+			loadedSession, err = sessionMgr.Load(debugResume)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to load session: %w", err)
 		}
+		_ = printer.PrintInfo(fmt.Sprintf("Resuming session: %s", loadedSession.ID))
 
 		sess = loadedSession
 		currentSessionID = sess.ID
 
 		_ = printer.PrintSuccess(fmt.Sprintf("Session loaded (iterations: %d/%d)", sess.IterationCount, sess.MaxIterations))
 	} else {
+		// Offer what earlier sessions found out about similar issues
+		if !debugNoRelated {
+			relatedReports = offerRelatedReports(issuesDir, issue, os.Stdin, display)
+		}
+
 		// Generate session ID early so interrupt handler can access it
 		currentSessionID = session.GenerateSessionID("debug")
 		_ = printer.PrintThinking("Starting debugging session...")
@@ -260,15 +378,21 @@ func runDebug(cmd *cobra.Command, args []string) error {
 		Language:               language,
 		Context:                debugContext,
 		Files:                  files,
-		WorkDir:                workDir,
+		WorkDir:                agentDir,
 		IssuesDir:              issuesDir,
 		MaxLines:               debugCfg.MaxLinesPerRead,
 		MaxIterations:          maxIterations,
+		MaxTokens:              debugCfg.MaxTokens,
+		MaxDuration:            maxDuration,
 		Interactive:            debugInteractive,
+		ApprovePlan:            debugApprovePlan || debugCfg.ApprovePlan,
+		RunTests:               runDebugTests(cmd, debugCfg),
+		RelatedReports:         relatedReports,
 		EnableCompression:      debugCfg.EnableCompression,
 		CompressionThreshold:   debugCfg.CompressionThreshold,
 		CompressionKeepRecent:  debugCfg.CompressionKeepRecent,
 		ShowCompressionSummary: debugCfg.ShowCompressionSummary,
+		ToolResultLimits:       toolResultLimits(debugCfg.ToolResults),
 		Session:                sess,
 		PreGeneratedSessionID:  currentSessionID, // Pass the pre-generated session ID
 	}
@@ -292,22 +416,56 @@ func runDebug(cmd *cobra.Command, args []string) error {
 			// So we just wait here indefinitely (the handler will call os.Exit)
 			select {} // Block forever - interrupt handler will exit the program
 		}
+		markSessionFailed(sessionMgr, currentSessionID)
+		notifier.Notify("gitbuddy debug failed", err.Error())
 		return fmt.Errorf("failed to debug issue: %w", err)
 	}
 
 	// Print the debug report
-	fmt.Println("\n" + strings.Repeat("=", 80))
-	fmt.Println("📋 Debug Report")
-	fmt.Println(strings.Repeat("=", 80))
-	fmt.Println()
-	fmt.Println(response.Report)
-	fmt.Println()
+	if debugRawStream {
+		if response.Partial {
+			_ = printer.PrintError("The analysis budget was exhausted; this is a partial report")
+			if response.SessionID != "" {
+				_ = printer.PrintInfo(fmt.Sprintf("Resume with: gitbuddy debug --resume %s", response.SessionID))
+			}
+		}
+		if !response.Streamed {
+			fmt.Println(response.Report)
+		}
+	} else {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("📋 Debug Report")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println()
+		if response.Partial {
+			_ = printer.PrintError("The analysis budget was exhausted; this is a partial report")
+			if response.SessionID != "" {
+				_ = printer.PrintInfo(fmt.Sprintf("Resume with: gitbuddy debug --resume %s", response.SessionID))
+			}
+			fmt.Println()
+		}
+		fmt.Println(response.Report)
+		fmt.Println()
+	}
 
 	if response.FilePath != "" {
-		fmt.Printf("✓ Report saved to: %s\n", response.FilePath)
-		fmt.Println()
+		fmt.Fprintf(display, "✓ Report saved to: %s\n", response.FilePath)
+		recordReportTasks(ctx, workDir, response.FilePath, response.Report, debugTaskOptions(cmd, cfg), printer)
+		fmt.Fprintln(display)
 	}
 
+	if worktree != nil && releaseWorktree() {
+		path, err := finishIsolated(ctx, worktree, currentSessionID)
+		worktree = nil
+		if err != nil {
+			_ = printer.PrintError(fmt.Sprintf("Failed to save isolated changes: %v", err))
+		} else {
+			_ = printer.PrintInfo(isolatedPatchHint(path))
+		}
+	}
+
+	notifier.Notify("gitbuddy debug finished", debugFinishedMessage(response))
+
 	// Print stats
 	endTime := time.Now()
 	stats := &ui.ExecutionStats{
@@ -319,6 +477,23 @@ func runDebug(cmd *cobra.Command, args []string) error {
 	}
 	_ = printer.PrintStats(stats)
 
+	if notesEnabled(cmd, cfg, debugNotes) {
+		reference := response.FilePath
+		if reference == "" {
+			reference = "session " + response.SessionID
+		}
+		recordNote(ctx, workDir, "HEAD", printer, git.NoteEntry{
+			Kind:             "debug",
+			CreatedAt:        endTime,
+			Model:            servedModel(provider),
+			PromptTokens:     response.PromptTokens,
+			CompletionTokens: response.CompletionTokens,
+			TotalTokens:      response.TotalTokens,
+			Summary:          issue,
+			Reference:        reference,
+		})
+	}
+
 	// Check if post-execution interactive mode is enabled
 	postInteractiveEnabled := debugPostInteractive || debugCfg.InteractiveMode
 	if postInteractiveEnabled {
@@ -343,3 +518,39 @@ func runDebug(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// debugFinishedMessage describes a finished debug run in a notification
+func debugFinishedMessage(response *agent.DebugResponse) string {
+	message := "The debug report is ready"
+	if response.Partial {
+		message = "The analysis budget was exhausted; a partial report is ready"
+	}
+	if response.FilePath != "" {
+		message += ": " + response.FilePath
+	}
+	return message
+}
+
+// toolResultLimits converts the tool_results configuration
+func toolResultLimits(cfg *config.ToolResultsConfig) agent.ToolResultLimits {
+	if cfg == nil {
+		return agent.ToolResultLimits{}
+	}
+	limits := agent.ToolResultLimits{Default: agent.ToolResultLimit(cfg.ToolResultLimitConfig)}
+	if len(cfg.Tools) > 0 {
+		limits.Tools = make(map[string]agent.ToolResultLimit, len(cfg.Tools))
+		for name, tool := range cfg.Tools {
+			limits.Tools[name] = agent.ToolResultLimit(tool)
+		}
+	}
+	return limits
+}
+
+// runDebugTests returns --run-tests if given, otherwise whether test commands
+// are configured in debug.test_commands
+func runDebugTests(cmd *cobra.Command, debugCfg *config.DebugConfig) bool {
+	if cmd.Flags().Changed("run-tests") {
+		return debugRunTests
+	}
+	return len(debugCfg.TestCommands) > 0
+}
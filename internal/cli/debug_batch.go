@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// batchIssue is one issue of a --issues file
+type batchIssue struct {
+	Issue   string   `yaml:"issue"`
+	Context string   `yaml:"context"`
+	Files   []string `yaml:"files"`
+}
+
+// UnmarshalYAML accepts a plain string as shorthand for an issue without
+// context or files
+func (b *batchIssue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Issue = node.Value
+		return nil
+	}
+	type plain batchIssue
+	return node.Decode((*plain)(b))
+}
+
+// batchResult is the outcome of debugging one issue of a batch
+type batchResult struct {
+	Issue     batchIssue
+	SessionID string
+	Response  *agent.DebugResponse
+	Err       error
+	Duration  time.Duration
+}
+
+// loadBatchIssues reads a --issues file: either a list of issues or a
+// mapping with an issues key
+func loadBatchIssues(path string) ([]batchIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issues file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse issues file: %w", err)
+	}
+	var issues []batchIssue
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind == yaml.MappingNode {
+			var file struct {
+				Issues []batchIssue `yaml:"issues"`
+			}
+			err = root.Decode(&file)
+			issues = file.Issues
+		} else {
+			err = root.Decode(&issues)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse issues file: %w", err)
+		}
+	}
+
+	for i := range issues {
+		issues[i].Issue = strings.TrimSpace(issues[i].Issue)
+		if issues[i].Issue == "" {
+			return nil, fmt.Errorf("issue %d in %s has no description", i+1, path)
+		}
+	}
+	if len(issues) == 0 {
+		return nil, fmt.Errorf("no issues found in %s", path)
+	}
+	return issues, nil
+}
+
+// runDebugBatch debugs every issue of the --issues file, sharing the LLM
+// provider, git executor and repository map across runs, then writes an
+// index report linking the individual reports
+func runDebugBatch(cmd *cobra.Command) error {
+	startTime := time.Now()
+	switch {
+	case debugResume != "" || debugResumeLast:
+		return fmt.Errorf("--resume and --resume-last cannot be combined with --issues")
+	case debugIsolated:
+		return fmt.Errorf("--isolated cannot be combined with --issues")
+	case debugParallel < 1:
+		return fmt.Errorf("--parallel must be at least 1")
+	case debugParallel > 1 && debugInteractive:
+		return fmt.Errorf("--interactive requires --parallel 1, since only one run can ask at a time")
+	}
+
+	issues, err := loadBatchIssues(debugIssuesFile)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	modelConfig, err := cfg.GetModel(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to get model config: %w", err)
+	}
+	language := cfg.GetArtifactLanguage(config.ArtifactDebug, debugLanguage)
+	applyToolLanguage(cfg, language)
+	debugCfg := cfg.GetDebugConfig()
+
+	issuesDir := debugIssuesDir
+	if issuesDir == "./issues" && debugCfg.IssuesDir != "" {
+		issuesDir = debugCfg.IssuesDir
+	}
+	maxIterations := debugMaxIterations
+	if maxIterations <= 0 {
+		maxIterations = debugCfg.MaxIterations
+	}
+
+	provider, err := newProvider(*modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	gitExecutor, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C stops the batch; the sessions of interrupted runs stay resumable
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sessionMgr := session.NewManager(cfg.GetSessionConfig().SaveDir)
+	sessionMgr.SetRunInfo(sessionRunInfo(ctx, cmd, modelConfig, workDir, gitExecutor))
+
+	printer := newStreamPrinter(os.Stdout)
+	retryCfg := cfg.GetRetryConfig()
+	opts := agent.DebugAgentOptions{
+		Language:    language,
+		GitExecutor: gitExecutor,
+		LLMProvider: provider,
+		Input:       os.Stdin,
+		Debug:       debugMode,
+		WorkDir:     workDir,
+		IssuesDir:   issuesDir,
+		RetryConfig: llm.RetryConfig{
+			Enabled:     retryCfg.Enabled,
+			MaxAttempts: retryCfg.MaxAttempts,
+			BackoffBase: retryCfg.BackoffBase,
+			BackoffMax:  retryCfg.BackoffMax,
+		},
+		MaxLinesPerRead:      debugCfg.MaxLinesPerRead,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         cfg.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      cfg.GetPromptExtension("debug"),
+		RepositoryMap:        repositoryMap(ctx, cfg, workDir),
+		SessionManager:       sessionMgr,
+		TestCommands:         debugCfg.TestCommands,
+	}
+
+	_ = printer.PrintInfo(fmt.Sprintf("Debugging %d issue(s) from %s (parallel: %d)", len(issues), debugIssuesFile, debugParallel))
+
+	results := make([]batchResult, len(issues))
+	output := ui.NewSharedOutput(os.Stdout)
+	grouped := !isTerminal(os.Stdout)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, debugParallel)
+	for i, issue := range issues {
+		if ctx.Err() != nil {
+			results[i] = batchResult{Issue: issue}
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, issue batchIssue) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			label := fmt.Sprintf("[%d/%d]", i+1, len(issues))
+			var runOutput io.Writer = output
+			if debugParallel > 1 {
+				// Live output gets a prefix per line; logs get one block per issue
+				var region io.WriteCloser = output.Prefixed(label + " ")
+				if grouped {
+					region = output.Panel(label + " " + issue.Issue)
+				}
+				defer region.Close()
+				runOutput = region
+			}
+			runOpts := opts
+			runOpts.Output = runOutput
+			runOpts.Printer = printer.Fork(runOutput)
+			_ = runOpts.Printer.PrintInfo(fmt.Sprintf("%s Debugging: %s", label, issue.Issue))
+
+			results[i] = debugBatchIssue(ctx, agent.NewDebugAgent(runOpts), issue, agent.DebugRequest{
+				Language:               language,
+				WorkDir:                workDir,
+				IssuesDir:              issuesDir,
+				MaxLines:               debugCfg.MaxLinesPerRead,
+				MaxIterations:          maxIterations,
+				MaxTokens:              debugCfg.MaxTokens,
+				MaxDuration:            maxDuration,
+				Interactive:            debugInteractive,
+				ApprovePlan:            debugApprovePlan || debugCfg.ApprovePlan,
+				RunTests:               runDebugTests(cmd, debugCfg),
+				EnableCompression:      debugCfg.EnableCompression,
+				CompressionThreshold:   debugCfg.CompressionThreshold,
+				CompressionKeepRecent:  debugCfg.CompressionKeepRecent,
+				ShowCompressionSummary: debugCfg.ShowCompressionSummary,
+			})
+			if results[i].Err != nil && ctx.Err() == nil {
+				markSessionFailed(sessionMgr, results[i].SessionID)
+			}
+		}(i, issue)
+	}
+	wg.Wait()
+
+	indexPath, err := writeBatchIndex(issuesDir, debugIssuesFile, results, time.Now())
+	if err != nil {
+		return err
+	}
+	taskOpts := debugTaskOptions(cmd, cfg)
+	for _, result := range results {
+		if result.Response != nil && result.Response.FilePath != "" {
+			recordReportTasks(ctx, workDir, result.Response.FilePath, result.Response.Report, taskOpts, printer)
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("📋 Batch Debug Summary")
+	fmt.Println(strings.Repeat("=", 80))
+	var failed int
+	stats := &ui.ExecutionStats{StartTime: startTime, EndTime: time.Now()}
+	for i, result := range results {
+		fmt.Printf("%d. %s %s\n", i+1, batchStatus(result), result.Issue.Issue)
+		if result.Err != nil {
+			failed++
+		}
+		if result.Response != nil {
+			stats.PromptTokens += result.Response.PromptTokens
+			stats.CompletionTokens += result.Response.CompletionTokens
+			stats.TotalTokens += result.Response.TotalTokens
+		}
+	}
+	fmt.Printf("\n✓ Index saved to: %s\n\n", indexPath)
+	_ = printer.PrintStats(stats)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d issue(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+// debugBatchIssue runs the debug agent on one issue of a batch
+func debugBatchIssue(ctx context.Context, debugAgent *agent.DebugAgent, issue batchIssue, req agent.DebugRequest) batchResult {
+	start := time.Now()
+	req.Issue = issue.Issue
+	req.Context = debugContext
+	if issue.Context != "" {
+		req.Context = strings.TrimSpace(issue.Context + "\n" + debugContext)
+	}
+	req.Files = issue.Files
+	req.PreGeneratedSessionID = session.GenerateSessionID("debug")
+
+	response, err := debugAgent.Debug(ctx, req)
+	result := batchResult{Issue: issue, SessionID: req.PreGeneratedSessionID, Response: response, Err: err, Duration: time.Since(start)}
+	if response != nil && response.SessionID != "" {
+		result.SessionID = response.SessionID
+	}
+	if err != nil {
+		log.Debug("Debugging %q failed: %v", issue.Issue, err)
+	}
+	return result
+}
+
+// batchStatus summarizes the outcome of a batch run
+func batchStatus(result batchResult) string {
+	switch {
+	case result.Err != nil:
+		return "❌ Failed"
+	case result.Response == nil:
+		return "⏭️ Not run"
+	case result.Response.Partial:
+		return "⚠️ Partial"
+	}
+	return "✅ Complete"
+}
+
+// writeBatchIndex saves the index report of a batch to issuesDir and
+// returns its path
+func writeBatchIndex(issuesDir, issuesFile string, results []batchResult, now time.Time) (string, error) {
+	if err := os.MkdirAll(issuesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create issues directory: %w", err)
+	}
+	path := filepath.Join(issuesDir, fmt.Sprintf("batch-%s.md", now.Format("2006-01-02-150405")))
+	if err := os.WriteFile(path, []byte(renderBatchIndex(issuesDir, issuesFile, results, now)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write batch index: %w", err)
+	}
+	return path, nil
+}
+
+// renderBatchIndex formats the index report of a batch, linking each report
+// relative to issuesDir
+func renderBatchIndex(issuesDir, issuesFile string, results []batchResult, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("# Batch Debug Report\n\n")
+	fmt.Fprintf(&b, "- Issues file: `%s`\n", issuesFile)
+	fmt.Fprintf(&b, "- Generated: %s\n", now.Format("2006-01-02 15:04:05"))
+
+	counts := make(map[string]int)
+	var tokens int
+	for _, result := range results {
+		counts[batchStatus(result)]++
+		if result.Response != nil {
+			tokens += result.Response.TotalTokens
+		}
+	}
+	var summary []string
+	for _, status := range []string{"✅ Complete", "⚠️ Partial", "❌ Failed", "⏭️ Not run"} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%s: %d", status, counts[status]))
+		}
+	}
+	fmt.Fprintf(&b, "- Results: %s\n", strings.Join(summary, ", "))
+	fmt.Fprintf(&b, "- Total tokens: %d\n\n", tokens)
+
+	b.WriteString("| # | Issue | Status | Report | Tokens | Duration |\n")
+	b.WriteString("|---|-------|--------|--------|--------|----------|\n")
+	for i, result := range results {
+		report := "-"
+		tokens := "-"
+		if result.Response != nil {
+			tokens = fmt.Sprintf("%d", result.Response.TotalTokens)
+			if result.Response.FilePath != "" {
+				link := result.Response.FilePath
+				if rel, err := filepath.Rel(issuesDir, link); err == nil {
+					link = rel
+				}
+				report = fmt.Sprintf("[%s](%s)", filepath.Base(link), filepath.ToSlash(link))
+			}
+		}
+		duration := "-"
+		if result.Duration > 0 {
+			duration = result.Duration.Round(time.Second).String()
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %s |\n", i+1, markdownCell(result.Issue.Issue), batchStatus(result), report, tokens, duration)
+	}
+
+	var notes []string
+	for i, result := range results {
+		switch {
+		case result.Err != nil && result.SessionID != "":
+			notes = append(notes, fmt.Sprintf("- #%d failed: %s. Resume with `gitbuddy debug --resume %s`", i+1, markdownCell(result.Err.Error()), result.SessionID))
+		case result.Err != nil:
+			notes = append(notes, fmt.Sprintf("- #%d failed: %s", i+1, markdownCell(result.Err.Error())))
+		case result.Response != nil && result.Response.Partial:
+			notes = append(notes, fmt.Sprintf("- #%d ran out of budget. Resume with `gitbuddy debug --resume %s`", i+1, result.SessionID))
+		}
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n## Follow-up\n\n")
+		b.WriteString(strings.Join(notes, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// markdownCell flattens text for a markdown table cell
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
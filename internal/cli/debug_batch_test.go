@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBatchIssues(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	issues, err := loadBatchIssues(write("mapping.yaml", `issues:
+  - Login fails with 500
+  - issue: Cache misses after deploy
+    context: Started with v2.3
+    files: [cache/cache.go]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []batchIssue{
+		{Issue: "Login fails with 500"},
+		{Issue: "Cache misses after deploy", Context: "Started with v2.3", Files: []string{"cache/cache.go"}},
+	}, issues)
+
+	issues, err = loadBatchIssues(write("list.yaml", "- Slow checkout\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []batchIssue{{Issue: "Slow checkout"}}, issues)
+
+	_, err = loadBatchIssues(write("empty.yaml", "issues: []\n"))
+	assert.ErrorContains(t, err, "no issues")
+	_, err = loadBatchIssues(write("blank.yaml", "- context: only context\n"))
+	assert.ErrorContains(t, err, "issue 1")
+	_, err = loadBatchIssues(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestRenderBatchIndex(t *testing.T) {
+	issuesDir := filepath.Join("work", "issues")
+	results := []batchResult{
+		{
+			Issue:    batchIssue{Issue: "Login | fails"},
+			Response: &agent.DebugResponse{FilePath: filepath.Join(issuesDir, "2026-10-16-login.md"), TotalTokens: 1200},
+			Duration: 90 * time.Second,
+		},
+		{
+			Issue:     batchIssue{Issue: "Slow checkout"},
+			SessionID: "debug-1",
+			Response:  &agent.DebugResponse{Partial: true, TotalTokens: 800},
+		},
+		{Issue: batchIssue{Issue: "Cache misses"}, SessionID: "debug-2", Err: errors.New("rate limited")},
+		{Issue: batchIssue{Issue: "Never started"}},
+	}
+
+	index := renderBatchIndex(issuesDir, "issues.yaml", results, time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC))
+	assert.Contains(t, index, "- Results: ✅ Complete: 1, ⚠️ Partial: 1, ❌ Failed: 1, ⏭️ Not run: 1\n")
+	assert.Contains(t, index, "- Total tokens: 2000\n")
+	assert.Contains(t, index, "| 1 | Login \\| fails | ✅ Complete | [2026-10-16-login.md](2026-10-16-login.md) | 1200 | 1m30s |\n")
+	assert.Contains(t, index, "| 4 | Never started | ⏭️ Not run | - | - | - |\n")
+	assert.Contains(t, index, "- #2 ran out of budget. Resume with `gitbuddy debug --resume debug-1`")
+	assert.Contains(t, index, "- #3 failed: rate limited. Resume with `gitbuddy debug --resume debug-2`")
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/interactive"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/spf13/cobra"
+)
+
+// revisionDateFormat is the date format of revision history entries
+const revisionDateFormat = "2006-01-02 15:04"
+
+var debugEditIssuesDir string
+
+var debugEditCmd = &cobra.Command{
+	Use:   "edit <report>",
+	Short: "Revise a saved debug report interactively",
+	Long: `Open an interactive session on a saved debug report, as with --post-interactive,
+and write the revised report back to its file.
+
+The report is given as a path or as its ID from 'gitbuddy issues list'. Use
+"modify <request>" in the session to change the report and "exit" to save it.
+Every modification is listed with its date in a Revision History section at the
+end of the report; reports without modifications are left untouched.
+
+Examples:
+  gitbuddy debug edit 3
+  gitbuddy debug edit issues/issue-003-login-fails-2026-10-16.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDebugEdit,
+}
+
+func init() {
+	debugEditCmd.Flags().StringVar(&debugEditIssuesDir, "issues-dir", "", "Directory of saved reports, for reports given by ID (default: debug.issues_dir)")
+
+	debugCmd.AddCommand(debugEditCmd)
+}
+
+func runDebugEdit(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	issuesDir := debugEditIssuesDir
+	if issuesDir == "" {
+		issuesDir = cfg.GetDebugConfig().IssuesDir
+	}
+	path, err := resolveReport(args[0], issuesDir)
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	_, body := reports.Parse(original)
+	content, _ := reports.SplitRevisions(body)
+
+	modelConfig, err := cfg.GetModel(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to get model config: %w", err)
+	}
+	provider, err := newProvider(*modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := workspaceDir(false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Editing %s\n", path)
+	interactiveSession := interactive.NewInteractiveSession(workDir)
+	interactiveSession.SetReportContent(content)
+	interactiveSession.SetLLMProvider(provider)
+	if err := interactiveSession.Start(ctx, os.Stdin, os.Stdout); err != nil {
+		return fmt.Errorf("interactive session failed: %w", err)
+	}
+
+	modifications := interactiveSession.GetModifications()
+	if len(modifications) == 0 {
+		fmt.Println("No modifications; the report is unchanged.")
+		return nil
+	}
+	revised, err := revisedReport(original, interactiveSession.GetReportContent(), modifications)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(revised), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("✓ Report saved to %s (%d modification(s))\n", path, len(modifications))
+	return nil
+}
+
+// resolveReport returns the path of the report ref names, either a path or
+// the ID of a report in issuesDir ("3", "#003")
+func resolveReport(ref, issuesDir string) (string, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return ref, nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return "", fmt.Errorf("report not found: %s", ref)
+	}
+	saved, err := reports.Load(issuesDir)
+	if err != nil {
+		return "", err
+	}
+	for _, report := range saved {
+		if report.ID == id {
+			return report.Path, nil
+		}
+	}
+	return "", fmt.Errorf("report #%03d not found in %s (see gitbuddy issues list)", id, issuesDir)
+}
+
+// revisedReport returns the original report file with its content replaced
+// and the modifications added to its revision history. The front matter is kept.
+func revisedReport(original []byte, content string, modifications []interactive.Modification) (string, error) {
+	meta, body := reports.Parse(original)
+	_, revisions := reports.SplitRevisions(body)
+	for _, m := range modifications {
+		revisions = append(revisions, reports.Revision{Date: m.Timestamp.Format(revisionDateFormat), Change: m.Request})
+	}
+	// The session's report excludes the history, so a copy in the reply is stale
+	content, _ = reports.SplitRevisions(strings.TrimSpace(content) + "\n")
+	revised := reports.AppendRevisions(content, revisions)
+
+	if meta.Title == "" && meta.Date == "" {
+		// Saved before reports had front matter
+		return revised, nil
+	}
+	return reports.Format(meta, revised)
+}
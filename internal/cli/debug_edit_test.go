@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/interactive"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "issue-003-login-fails-2026-10-16.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Login fails\n"), 0644))
+
+	for _, ref := range []string{path, "3", "#003"} {
+		got, err := resolveReport(ref, dir)
+		require.NoError(t, err, ref)
+		assert.Equal(t, path, got)
+	}
+
+	_, err := resolveReport("7", dir)
+	assert.ErrorContains(t, err, "report #007 not found")
+	_, err = resolveReport("missing.md", dir)
+	assert.ErrorContains(t, err, "report not found: missing.md")
+}
+
+func TestRevisedReport(t *testing.T) {
+	original, err := reports.Format(reports.Metadata{Title: "Login fails", Date: "2026-10-15"},
+		reports.AppendRevisions("# Login fails\n\nRoot cause: unknown\n", []reports.Revision{{Date: "2026-10-15 10:00", Change: "Shorten"}}))
+	require.NoError(t, err)
+
+	revised, err := revisedReport([]byte(original), "# Login fails\n\nRoot cause: expired key", []interactive.Modification{
+		{Request: "name the root cause", Timestamp: time.Date(2026, 10, 16, 14, 5, 0, 0, time.Local)},
+	})
+	require.NoError(t, err)
+
+	meta, body := reports.Parse([]byte(revised))
+	assert.Equal(t, "Login fails", meta.Title)
+	content, revisions := reports.SplitRevisions(body)
+	assert.Equal(t, "# Login fails\n\nRoot cause: expired key\n", content)
+	assert.Equal(t, []reports.Revision{
+		{Date: "2026-10-15 10:00", Change: "Shorten"},
+		{Date: "2026-10-16 14:05", Change: "name the root cause"},
+	}, revisions)
+
+	// Reports without front matter stay without it
+	revised, err = revisedReport([]byte("# Old report\n"), "# Old report\n\nMore\n", []interactive.Modification{{Request: "add more"}})
+	require.NoError(t, err)
+	assert.NotContains(t, revised, "---\ntitle")
+	assert.Contains(t, revised, "| add more |")
+}
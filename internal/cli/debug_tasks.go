@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// maxIssueTitle bounds the title of the issues filed for tasks, in runes
+const maxIssueTitle = 120
+
+// reportTaskOptions tells how the follow-up tasks of debug reports are
+// recorded
+type reportTaskOptions struct {
+	FileIssues bool                // File an issue for each new task
+	Labels     []string            // Labels of the filed issues
+	Forge      *config.ForgeConfig // Access to the GitHub API
+}
+
+// debugTaskOptions returns --file-issues if given, otherwise debug.file_issues
+func debugTaskOptions(cmd *cobra.Command, cfg *config.Config) reportTaskOptions {
+	debugCfg := cfg.GetDebugConfig()
+	opts := reportTaskOptions{FileIssues: debugCfg.FileIssues, Labels: debugCfg.IssueLabels, Forge: cfg.GetForgeConfig()}
+	if cmd.Flags().Changed("file-issues") {
+		opts.FileIssues = debugFileIssues
+	}
+	return opts
+}
+
+// recordReportTasks adds the action items of the report saved at reportPath
+// to the tasks file of the repository and, with opts.FileIssues, files an
+// issue on the repository's GitHub for each new one. Problems are printed,
+// since the report itself was saved.
+func recordReportTasks(ctx context.Context, workDir, reportPath, content string, opts reportTaskOptions, printer *ui.StreamPrinter) {
+	tasks := reports.ExtractTasks(content)
+	if len(tasks) == 0 {
+		return
+	}
+	tasksPath := filepath.Join(workDir, reports.DefaultTasksPath)
+	list, err := reports.LoadTasks(tasksPath)
+	if err != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to record follow-up tasks: %v", err))
+		return
+	}
+	if rel, err := filepath.Rel(workDir, reportPath); err == nil && filepath.IsAbs(reportPath) {
+		reportPath = rel
+	}
+	added := list.Add(reportPath, tasks, time.Now())
+	if len(added) == 0 {
+		return
+	}
+
+	var fileErr error
+	filed := 0
+	if opts.FileIssues {
+		filed, fileErr = fileTaskIssues(ctx, workDir, added, opts)
+	}
+	if err := list.Save(tasksPath); err != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to record follow-up tasks: %v", err))
+		return
+	}
+
+	message := fmt.Sprintf("Recorded %d follow-up task(s) in %s", len(added), reports.DefaultTasksPath)
+	if filed > 0 {
+		message += fmt.Sprintf(", filed %d issue(s)", filed)
+	}
+	_ = printer.PrintInfo(message)
+	if fileErr != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to file issues for follow-up tasks: %v", fileErr))
+	}
+}
+
+// fileTaskIssues files an issue for each task on the GitHub repository of the
+// origin remote, records the issue URLs in the tasks and returns how many were
+// filed before an error
+func fileTaskIssues(ctx context.Context, workDir string, tasks []*reports.Task, opts reportTaskOptions) (int, error) {
+	token := forgeToken(opts.Forge, forge.KindGitHub)
+	if token == "" {
+		return 0, errors.New("set GITHUB_TOKEN, GH_TOKEN or forge.token to a token allowed to create issues")
+	}
+	repo, err := remoteRepository(ctx, workDir, "origin")
+	if err != nil {
+		return 0, err
+	}
+
+	github := forge.NewGitHub(forgeAPIURL(opts.Forge, repo), token)
+	for i, task := range tasks {
+		issue, err := github.CreateIssue(ctx, repo, taskIssueTitle(task.Title), taskIssueBody(task), opts.Labels)
+		if err != nil {
+			return i, err
+		}
+		task.Issue = issue.URL
+	}
+	return len(tasks), nil
+}
+
+// taskIssueTitle shortens a task title to maxIssueTitle runes
+func taskIssueTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) <= maxIssueTitle {
+		return title
+	}
+	return string(runes[:maxIssueTitle-1]) + "…"
+}
+
+// taskIssueBody describes a task and the report it comes from
+func taskIssueBody(task *reports.Task) string {
+	return fmt.Sprintf("%s\n\nFollow-up %s task from the debug report `%s` (task %s in `%s`).\n",
+		task.Title, task.Kind, task.Report, task.ID, reports.DefaultTasksPath)
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReportTasks(t *testing.T) {
+	workDir := t.TempDir()
+	report := "# Cache misses\n\n## Solutions\n- Include the tenant in the cache key\n\n## Prevention\n- Test caching per tenant\n"
+	reportPath := filepath.Join(workDir, "issues", "issue-001-cache.md")
+
+	var out bytes.Buffer
+	printer := ui.NewStreamPrinter(&out)
+	recordReportTasks(context.Background(), workDir, reportPath, report, reportTaskOptions{}, printer)
+	assert.Contains(t, out.String(), "Recorded 2 follow-up task(s) in .gitbuddy/tasks.yaml")
+
+	list, err := reports.LoadTasks(filepath.Join(workDir, reports.DefaultTasksPath))
+	require.NoError(t, err)
+	require.Len(t, list.Tasks, 2)
+	assert.Equal(t, "Include the tenant in the cache key", list.Tasks[0].Title)
+	assert.Equal(t, "issues/issue-001-cache.md", list.Tasks[0].Report, "reports are referenced relative to the repository")
+	assert.Equal(t, reports.TaskPrevention, list.Tasks[1].Kind)
+
+	// The same report again records nothing
+	out.Reset()
+	recordReportTasks(context.Background(), workDir, reportPath, report, reportTaskOptions{}, printer)
+	assert.Empty(t, out.String())
+}
+
+func TestRecordReportTasks_FileIssuesWithoutToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	workDir := t.TempDir()
+
+	var out bytes.Buffer
+	recordReportTasks(context.Background(), workDir, "issues/issue-002.md", "## Fix\n- Retry the upload\n",
+		reportTaskOptions{FileIssues: true}, ui.NewStreamPrinter(&out))
+	assert.Contains(t, out.String(), "Recorded 1 follow-up task(s)")
+	assert.Contains(t, out.String(), "GITHUB_TOKEN")
+
+	list, err := reports.LoadTasks(filepath.Join(workDir, reports.DefaultTasksPath))
+	require.NoError(t, err)
+	require.Len(t, list.Tasks, 1)
+	assert.Empty(t, list.Tasks[0].Issue)
+}
+
+func TestTaskIssueTitle(t *testing.T) {
+	assert.Equal(t, "Retry the upload", taskIssueTitle("Retry the upload"))
+	title := taskIssueTitle(strings.Repeat("a", 200))
+	assert.Len(t, []rune(title), maxIssueTitle)
+	assert.True(t, strings.HasSuffix(title, "…"))
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var doctorMaxFileSize int
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the repository for problems affecting GitBuddy",
+	Long: `Check the repository for problems that make GitBuddy fail or produce poor
+results, and print how to fix them.
+
+Checks:
+- Git identity: user.name and user.email are set
+- HEAD: a branch is checked out (not a detached HEAD)
+- History: the repository is not a shallow clone (common in CI)
+- Untracked files: no huge untracked files slowing down the file tools
+- Git LFS: no LFS pointers in the staged changes
+
+The command exits with an error when a check fails, so it can gate CI jobs.
+
+Examples:
+  gitbuddy doctor
+  gitbuddy doctor --max-file-size 50`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().IntVar(&doctorMaxFileSize, "max-file-size", agent.DefaultMaxUntrackedSize/(1024*1024), "Report untracked files of at least this size in MB")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	workDir, err := workspaceDir(false)
+	if err != nil {
+		return err
+	}
+
+	report, err := agent.DiagnoseRepository(ctx, workDir, int64(doctorMaxFileSize)*1024*1024)
+	if err != nil {
+		return err
+	}
+
+	icons := map[string]string{
+		agent.DoctorOK:      "✅",
+		agent.DoctorWarning: "⚠️ ",
+		agent.DoctorError:   "❌",
+	}
+	if ui.Accessible() {
+		icons = map[string]string{
+			agent.DoctorOK:      "OK",
+			agent.DoctorWarning: "WARNING",
+			agent.DoctorError:   "ERROR",
+		}
+	}
+	for _, check := range report.Checks {
+		fmt.Printf("%s %s: %s\n", icons[check.Status], check.Name, check.Detail)
+		for _, fix := range check.Fixes {
+			fmt.Printf("     $ %s\n", fix)
+		}
+		if check.Hint != "" {
+			fmt.Printf("     %s\n", check.Hint)
+		}
+	}
+
+	errors, warnings := report.Count(agent.DoctorError), report.Count(agent.DoctorWarning)
+	fmt.Println()
+	if errors > 0 {
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", errors, warnings)
+	}
+	if warnings > 0 {
+		fmt.Printf("No blocking problems, %d warning(s)\n", warnings)
+		return nil
+	}
+	fmt.Println("No problems found")
+	return nil
+}
@@ -0,0 +1,422 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+)
+
+// e2eConfig is the config of end-to-end runs; its model is answered by the
+// scripted provider of the test
+const e2eConfig = `default_model: scripted
+models:
+  scripted:
+    provider: ollama
+    model: scripted-model
+language: en
+debug:
+  issues_dir: %s
+`
+
+// e2eResult is the outcome of an end-to-end run of gitbuddy
+type e2eResult struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// runGitBuddy runs gitbuddy with args in repo as the binary would, with
+// provider answering the model requests. The flags, the working directory
+// and the global state the run changes are restored afterwards.
+func runGitBuddy(t *testing.T, repo *testutil.GitRepo, provider *testutil.ScriptedProvider, args ...string) e2eResult {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	configPath := filepath.Join(home, ".gitbuddy.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(fmt.Sprintf(e2eConfig, filepath.Join(home, "issues"))), 0600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr
+	colorOutput, colorError := color.Output, color.Error
+	create := newProvider
+	newProvider = func(cfg config.ModelConfig) (llm.Provider, error) { return provider, nil }
+	defer func() {
+		newProvider = create
+		os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+		color.Output, color.Error = colorOutput, colorError
+		sideeffect.SetBlocked(false)
+		resetAllFlags(rootCmd)
+		require.NoError(t, os.Chdir(cwd))
+	}()
+
+	// Without a terminal on stdin nothing waits for an answer
+	os.Stdin, err = os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer os.Stdin.Close()
+	outFile := captureFile(t, "stdout")
+	errFile := captureFile(t, "stderr")
+	os.Stdout, os.Stderr = outFile, errFile
+	color.Output, color.Error = outFile, errFile
+	rootCmd.SetOut(outFile)
+	rootCmd.SetErr(errFile)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	rootCmd.SetArgs(append([]string{"--config", configPath, "--workdir", repo.Dir}, args...))
+	result := e2eResult{err: Execute()}
+	result.stdout = readCapture(t, outFile)
+	result.stderr = readCapture(t, errFile)
+	return result
+}
+
+// captureFile creates a file standing in for stdout or stderr
+func captureFile(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func readCapture(t *testing.T, f *os.File) string {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return string(data)
+}
+
+// resetAllFlags sets the flags of cmd and its subcommands back to their
+// defaults, so that a run doesn't see the flags of the previous one
+func resetAllFlags(cmd *cobra.Command) {
+	resetFlags(cmd.Flags())
+	resetFlags(cmd.PersistentFlags())
+	for _, sub := range cmd.Commands() {
+		resetAllFlags(sub)
+	}
+}
+
+func TestEndToEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		setup func(t *testing.T, repo *testutil.GitRepo)
+		turns []testutil.Turn
+		check func(t *testing.T, repo *testutil.GitRepo, result e2eResult)
+	}{
+		{
+			name: "commit",
+			args: []string{"commit", "--yes"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n\n// MaxAttempts is the number of failed logins before an account is locked\nconst MaxAttempts = 3\n")
+			},
+			turns: []testutil.Turn{
+				testutil.CallTool("git_diff_cached", map[string]any{}),
+				testutil.CallTool("submit_commit", map[string]any{
+					"type":        "fix",
+					"scope":       "auth",
+					"description": "lock accounts after three failed logins",
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Equal(t, "fix(auth): lock accounts after three failed logins", repo.LastCommitMessage())
+			},
+		},
+		{
+			name: "commit print-only",
+			args: []string{"commit", "--print-only"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("docs/limits.md", "# Limits\n")
+			},
+			turns: []testutil.Turn{
+				testutil.CallTool("submit_commit", map[string]any{"type": "docs", "description": "document login limits"}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, `"title": "docs: document login limits"`)
+				assert.Equal(t, "feat(auth): limit login attempts", repo.LastCommitMessage(), "nothing is committed")
+			},
+		},
+		{
+			name: "commit check",
+			args: []string{"commit", "--check"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n\n// MaxAttempts is the number of failed logins before an account is locked\nconst MaxAttempts = 10\n")
+			},
+			turns: []testutil.Turn{
+				testutil.CallTool("submit_commit", map[string]any{"type": "fix", "scope": "auth", "description": "allow ten failed logins"}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Equal(t, "feat(auth): limit login attempts", repo.LastCommitMessage(), "nothing is committed")
+				assert.Equal(t, "M  auth/limit.go", repo.Git("status", "--short"))
+			},
+		},
+		{
+			name: "commit partial message",
+			args: []string{"commit", "--yes"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n\n// MaxAttempts is the number of failed logins before an account is locked\nconst MaxAttempts = 7\n")
+			},
+			turns: []testutil.Turn{
+				{
+					Content:   "Here is the message:\n\n**fix(auth): allow seven failed logins**",
+					ToolCalls: []schema.ToolCall{testutil.Call("git_diff_cached", map[string]any{})},
+				},
+				testutil.Fail(fmt.Errorf("error, status code: 500, message: internal error")),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.Error(t, result.err, "nobody confirms the partial message")
+				assert.Contains(t, result.stdout, "Do you want to commit with this message? [y/N]")
+				assert.Equal(t, "feat(auth): limit login attempts", repo.LastCommitMessage(), "nothing is committed")
+			},
+		},
+		{
+			name: "commit without staged changes",
+			args: []string{"commit", "--yes"},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err)
+				assert.Contains(t, result.stdout, "No staged changes found.")
+			},
+		},
+		{
+			name: "model failure",
+			args: []string{"commit", "--yes"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n")
+			},
+			turns: []testutil.Turn{
+				testutil.Fail(fmt.Errorf("error, status code: 401, message: invalid api key")),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.Error(t, result.err)
+				assert.Equal(t, llm.ExitAuth, ExitCode(result.err))
+				assert.Contains(t, result.stderr, "Hint:")
+			},
+		},
+		{
+			name: "review",
+			args: []string{"review"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n\n// MaxAttempts is the number of failed logins before an account is locked\nconst MaxAttempts = 0\n")
+			},
+			turns: []testutil.Turn{
+				testutil.CallTool("git_diff_cached", map[string]any{}),
+				testutil.CallTool("submit_review", map[string]any{
+					"summary": "Accounts are locked right away.",
+					"issues": []map[string]any{{
+						"severity":    "warning",
+						"category":    "bug",
+						"file":        "auth/limit.go",
+						"line":        4,
+						"title":       "Zero attempts lock every account",
+						"description": "No login can fail before the account is locked.",
+					}},
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, "Zero attempts lock every account")
+			},
+		},
+		{
+			name: "review skips issues ignored in triage",
+			args: []string{"review"},
+			setup: func(t *testing.T, repo *testutil.GitRepo) {
+				repo.Stage("auth/limit.go", "package auth\n\n// MaxAttempts is the number of failed logins before an account is locked\nconst MaxAttempts = 0\n")
+				issues := []agent.ReviewIssue{{Category: "bug", File: "auth/limit.go", Line: 4, Title: "Zero attempts lock every account"}}
+				agent.FingerprintIssues(repo.Dir, issues)
+				triaged := &agent.TriageResult{Version: 1, Issues: []agent.TriagedIssue{{ReviewIssue: issues[0], Decision: agent.TriageIgnore}}}
+				require.NoError(t, agent.SaveTriageResult(filepath.Join(repo.Dir, agent.DefaultTriagePath), triaged))
+			},
+			turns: []testutil.Turn{
+				testutil.CallTool("git_diff_cached", map[string]any{}),
+				testutil.CallTool("submit_review", map[string]any{
+					"summary": "Accounts are locked right away.",
+					"issues": []map[string]any{{
+						"severity":    "warning",
+						"category":    "bug",
+						"file":        "auth/limit.go",
+						"line":        4,
+						"title":       "Zero attempts lock every account",
+						"description": "No login can fail before the account is locked.",
+					}, {
+						"severity":    "info",
+						"category":    "style",
+						"file":        "auth/limit.go",
+						"line":        3,
+						"title":       "Say what zero means",
+						"description": "The comment doesn't say whether zero disables the limit.",
+					}},
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, "Found 1 issue(s)")
+				assert.NotContains(t, result.stdout, "[WARNING] Zero attempts lock every account")
+				assert.Contains(t, result.stdout, "[INFO] Say what zero means")
+				assert.Contains(t, result.stdout+result.stderr, "Skipped 1 issue(s) ignored in .gitbuddy/review-triage.json")
+			},
+		},
+		{
+			name: "review range",
+			args: []string{"review", "--range", "main..HEAD"},
+			turns: []testutil.Turn{
+				testutil.CallTool("git_diff_range", map[string]any{"path": "auth"}),
+				testutil.CallTool("submit_review", map[string]any{
+					"summary": "The limit looks right.",
+					"issues":  []map[string]any{},
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, "The limit looks right.")
+			},
+		},
+		{
+			name: "pr",
+			args: []string{"pr", "--base", "main"},
+			turns: []testutil.Turn{
+				testutil.CallTool("git_log_range", map[string]any{"base": "main", "head": "HEAD"}),
+				testutil.CallTool("submit_pr", map[string]any{
+					"title":       "Limit login attempts",
+					"description": "## Summary\n\nAdds the number of failed logins before an account is locked.",
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, "Limit login attempts")
+				assert.Contains(t, result.stdout, "Adds the number of failed logins")
+			},
+		},
+		{
+			name: "report",
+			args: []string{"report", "--since", "2024-01-01", "--until", "2024-01-31"},
+			turns: []testutil.Turn{
+				testutil.CallTool("submit_report", map[string]any{
+					"title":    "January report",
+					"period":   "2024-01-01 to 2024-01-31",
+					"summary":  "Authentication landed.",
+					"features": []string{"Login with a password", "Login attempt limit"},
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, "January report")
+				assert.Contains(t, result.stdout, "Login attempt limit")
+			},
+		},
+		{
+			name: "debug",
+			args: []string{"debug", "--no-related", "login succeeds with a wrong password"},
+			turns: []testutil.Turn{
+				testutil.CallTool("read_file", map[string]any{"file_path": "auth/auth.go"}),
+				testutil.CallTool("transition_phase", map[string]any{"new_phase": "reporting", "reason": "root cause found"}),
+				testutil.CallTool("submit_report", map[string]any{
+					"title":   "Login accepts unknown users",
+					"content": "# Login accepts unknown users\n\n## Root Cause\n\nAn unknown user has an empty password.",
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				_, path, found := strings.Cut(result.stdout, "Report saved to: ")
+				require.True(t, found)
+				report, err := os.ReadFile(strings.TrimSpace(strings.SplitN(path, "\n", 2)[0]))
+				require.NoError(t, err)
+				assert.Contains(t, string(report), "An unknown user has an empty password.")
+			},
+		},
+		{
+			name: "plan-refactor",
+			args: []string{"plan-refactor", "--json", "move the login limit into a policy type"},
+			turns: []testutil.Turn{
+				testutil.CallTool("file_outline", map[string]any{"file_path": "auth/limit.go"}),
+				testutil.CallTool("submit_refactor_plan", map[string]any{
+					"summary": "Introduce a Policy type holding the limit.",
+					"phases": []map[string]any{{
+						"title":   "Add Policy",
+						"changes": []map[string]any{{"file": "auth/policy.go", "action": "create", "change": "Policy with MaxAttempts"}},
+					}},
+				}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.Contains(t, result.stdout, `"auth/policy.go"`)
+			},
+		},
+		{
+			name: "gen-tests",
+			args: []string{"gen-tests", "--yes", "auth/limit.go"},
+			turns: []testutil.Turn{
+				testutil.CallTool("write_file", map[string]any{
+					"file_path": "auth/limit_test.go",
+					"content":   "package auth\n\nimport \"testing\"\n\nfunc TestMaxAttempts(t *testing.T) {\n\tif MaxAttempts < 1 {\n\t\tt.Fatal(\"no attempts\")\n\t}\n}\n",
+				}),
+				testutil.CallTool("submit_tests", map[string]any{"files": []string{"auth/limit_test.go"}, "summary": "Checks the limit allows a login."}),
+			},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err, result.stderr)
+				assert.FileExists(t, filepath.Join(repo.Dir, "auth", "limit_test.go"))
+			},
+		},
+		{
+			name: "sync-check with another VCS",
+			args: []string{"--vcs", "jj", "sync-check", "--no-fetch"},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.EqualError(t, result.err, "sync-check requires git, but the repository uses jj")
+			},
+		},
+		{
+			name: "models list",
+			args: []string{"models", "list"},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err)
+				assert.Contains(t, result.stdout, "scripted (default)")
+				assert.Contains(t, result.stdout, "Model:    scripted-model")
+			},
+		},
+		{
+			name: "version",
+			args: []string{"version"},
+			check: func(t *testing.T, repo *testutil.GitRepo, result e2eResult) {
+				require.NoError(t, result.err)
+				assert.Contains(t, result.stdout, "dev")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testutil.NewSampleRepo(t)
+			if tt.setup != nil {
+				tt.setup(t, repo)
+			}
+			provider := testutil.NewScriptedProvider(tt.turns...)
+			result := runGitBuddy(t, repo, provider, tt.args...)
+			defer func() {
+				if t.Failed() {
+					t.Logf("stdout:\n%s\nstderr:\n%s", result.stdout, result.stderr)
+				}
+			}()
+			tt.check(t, repo, result)
+			assert.NotContains(t, result.stdout, "Tool diagnostics", "the scripted tool calls succeed")
+			assert.Zero(t, provider.Remaining(), "all scripted turns are played back")
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// forgeTokenEnv lists the environment variables of the token of each kind of
+// forge, in order of precedence
+var forgeTokenEnv = map[string][]string{
+	forge.KindGitHub: {"GITHUB_TOKEN", "GH_TOKEN"},
+	forge.KindGitLab: {"GITLAB_TOKEN"},
+}
+
+// forgeToken returns the token of forge.token, or the one of the environment
+// for the forge of kind
+func forgeToken(forgeCfg *config.ForgeConfig, kind string) string {
+	if forgeCfg != nil && forgeCfg.Token != "" {
+		return forgeCfg.Token
+	}
+	for _, name := range forgeTokenEnv[kind] {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// forgeAPIURL returns the API of forge.api_url, or the one of repo's host
+func forgeAPIURL(forgeCfg *config.ForgeConfig, repo forge.Repository) string {
+	if forgeCfg != nil && forgeCfg.APIURL != "" {
+		return forgeCfg.APIURL
+	}
+	return repo.APIURL()
+}
+
+// remoteRepository returns the forge repository of the URL of remote
+func remoteRepository(ctx context.Context, workDir, remote string) (forge.Repository, error) {
+	remoteURL, err := git.ConfigValue(ctx, workDir, "remote."+remote+".url")
+	if err != nil {
+		return forge.Repository{}, err
+	}
+	if remoteURL == "" {
+		return forge.Repository{}, fmt.Errorf("remote %q is not configured", remote)
+	}
+	return forge.ParseRemoteURL(remoteURL)
+}
+
+// openForge returns a client of the forge hosting the origin remote and its
+// repository. The kind of forge is kind when set, forge.type otherwise, or
+// derived from the remote URL.
+func openForge(ctx context.Context, forgeCfg *config.ForgeConfig, workDir, kind string) (forge.Forge, forge.Repository, error) {
+	if kind == "" && forgeCfg != nil {
+		kind = forgeCfg.Type
+	}
+	if kind != "" && !forge.ValidKind(kind) {
+		return nil, forge.Repository{}, fmt.Errorf("invalid forge: %s (valid: github, gitlab)", kind)
+	}
+	repo, err := remoteRepository(ctx, workDir, "origin")
+	if err != nil {
+		return nil, forge.Repository{}, err
+	}
+	if kind == "" {
+		kind = forge.DetectKind(repo)
+	}
+
+	token := forgeToken(forgeCfg, kind)
+	if token == "" {
+		return nil, forge.Repository{}, fmt.Errorf("set %s or forge.token to a token of %s", strings.Join(forgeTokenEnv[kind], ", "), repo.Host)
+	}
+	var apiURL string
+	if forgeCfg != nil {
+		apiURL = forgeCfg.APIURL
+	}
+	f, err := forge.New(kind, repo, apiURL, token)
+	if err != nil {
+		return nil, forge.Repository{}, err
+	}
+	return f, repo, nil
+}
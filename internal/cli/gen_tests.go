@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	genTestsContext       string
+	genTestsLanguage      string
+	genTestsRun           bool
+	genTestsYes           bool
+	genTestsMaxIterations int
+)
+
+var genTestsCmd = &cobra.Command{
+	Use:   "gen-tests [file]",
+	Short: "Generate tests for a file or the staged changes",
+	Long: `Generate tests for a file, or for the staged changes when no file is given.
+
+The agent reads the code and its existing tests, finds untested logic and
+writes tests in the style of the project: table-driven tests for Go, and the
+project's framework (pytest, Jest, Vitest, ...) for other languages.
+
+Every change is previewed and applied only when you confirm it, and only test
+files can be written. Undo all changes with "gitbuddy rollback <session-id>".
+
+With --run, the agent runs the new tests (go test, pytest, npm test, ...) and
+fixes failing tests. Failures caused by bugs in the code are reported, not
+hidden by changing the tests.
+
+Examples:
+  gitbuddy gen-tests internal/parser/parser.go
+  gitbuddy gen-tests --run
+  gitbuddy gen-tests src/cart.ts -c "prices are in cents" --run
+  gitbuddy gen-tests internal/parser/parser.go --run --yes`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGenTests,
+}
+
+func init() {
+	genTestsCmd.Flags().StringVarP(&genTestsContext, "context", "c", "", "Additional context to help AI understand the code")
+	genTestsCmd.Flags().StringVarP(&genTestsLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
+	genTestsCmd.Flags().BoolVar(&genTestsRun, "run", false, "Run the new tests and fix failing tests")
+	genTestsCmd.Flags().BoolVarP(&genTestsYes, "yes", "y", false, "Apply changes without asking after the preview")
+	genTestsCmd.Flags().IntVar(&genTestsMaxIterations, "max-iterations", agent.DefaultTestGenIterations, "Maximum agent iterations")
+	rootCmd.AddCommand(genTestsCmd)
+}
+
+func runGenTests(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	modelConfig, err := cfg.GetModel(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to get model config: %w", err)
+	}
+	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
+
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+
+	gitExecutor, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
+
+	var target string
+	if len(args) == 1 {
+		target = filepath.ToSlash(filepath.Clean(args[0]))
+		if info, err := os.Stat(target); err != nil || info.IsDir() {
+			return fmt.Errorf("file not found: %s", args[0])
+		}
+	} else {
+		diff, err := gitExecutor.DiffCached(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get staged changes: %w", err)
+		}
+		if diff == "" {
+			fmt.Println("No staged changes found. Pass a file or stage the code to test:")
+			fmt.Println("  gitbuddy gen-tests <file>")
+			fmt.Println("  git add <file>")
+			return nil
+		}
+	}
+
+	provider, err := newProvider(*modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
+
+	applyToolLanguage(cfg, cfg.GetArtifactLanguage(config.ArtifactTests, genTestsLanguage))
+	retryConfigPtr := cfg.GetRetryConfig()
+	printer := newStreamPrinter(os.Stdout)
+	testGenAgent := agent.NewTestGenAgent(agent.TestGenAgentOptions{
+		Language:    cfg.GetArtifactLanguage(config.ArtifactTests, genTestsLanguage),
+		GitExecutor: gitExecutor,
+		LLMProvider: provider,
+		Printer:     printer,
+		Input:       os.Stdin,
+		Output:      os.Stdout,
+		RetryConfig: llm.RetryConfig{
+			Enabled:     retryConfigPtr.Enabled,
+			MaxAttempts: retryConfigPtr.MaxAttempts,
+			BackoffBase: retryConfigPtr.BackoffBase,
+			BackoffMax:  retryConfigPtr.BackoffMax,
+		},
+		MaxLinesPerRead:      cfg.GetReviewConfig().MaxLinesPerRead,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         cfg.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      cfg.GetPromptExtension("gen-tests"),
+		AutoApprove:          genTestsYes,
+	})
+
+	resp, err := testGenAgent.GenerateTests(ctx, agent.TestGenRequest{
+		Target:        target,
+		Context:       genTestsContext,
+		Language:      cfg.GetArtifactLanguage(config.ArtifactTests, genTestsLanguage),
+		WorkDir:       workDir,
+		RunTests:      genTestsRun,
+		MaxIterations: genTestsMaxIterations,
+		SessionID:     session.GenerateSessionID("gen-tests"),
+		MaxDuration:   maxDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate tests: %w", err)
+	}
+
+	printTestGenResult(resp)
+	return nil
+}
+
+// printTestGenResult prints the summary and the files the agent changed
+func printTestGenResult(resp *agent.TestGenResponse) {
+	fmt.Println()
+	if resp.Partial {
+		fmt.Println("Warning: the agent stopped before it finished; the tests may be incomplete.")
+	}
+	if resp.Summary != "" {
+		fmt.Println(resp.Summary)
+		fmt.Println()
+	}
+	if len(resp.Files) == 0 {
+		fmt.Println("No test files were changed.")
+	} else {
+		fmt.Printf("%d test file(s) changed:\n", len(resp.Files))
+		for _, file := range resp.Files {
+			fmt.Printf("  %s\n", file)
+		}
+		fmt.Printf("Undo all changes with: gitbuddy rollback %s\n", resp.SessionID)
+	}
+	if resp.Rejected > 0 {
+		fmt.Printf("%d change(s) declined in the preview\n", resp.Rejected)
+	}
+	fmt.Printf("Tokens: %d (prompt %d, completion %d)\n", resp.TotalTokens, resp.PromptTokens, resp.CompletionTokens)
+}
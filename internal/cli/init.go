@@ -105,6 +105,7 @@ for various LLM providers. Edit the file to add your API keys and customize sett
 		fmt.Println("  1. Edit the config file and add your API keys")
 		fmt.Println("  2. Set environment variables for sensitive keys (recommended)")
 		fmt.Println("  3. Run 'gitbuddy commit' to generate a commit message")
+		fmt.Println("  4. Run 'gitbuddy recipes' for CI, hook and alias snippets matching your config")
 
 		return nil
 	},
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+)
+
+// isolatedPatchDir is where the changes made in isolated mode are saved,
+// relative to the repository root
+const isolatedPatchDir = ".gitbuddy/patches"
+
+// startIsolated creates the temporary worktree used by --isolated. Agents get
+// its directory as their working directory so the user's files stay untouched.
+func startIsolated(ctx context.Context, cfg *config.Config, workDir string) (*git.IsolatedWorktree, error) {
+	if err := requireGit(cfg.GetVCS(vcsName), workDir, "--isolated"); err != nil {
+		return nil, err
+	}
+
+	worktree, err := git.NewIsolatedWorktree(ctx, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create isolated worktree: %w", err)
+	}
+	return worktree, nil
+}
+
+// finishIsolated saves the changes made in the worktree as <name>.patch and
+// removes the worktree. It returns the patch path, or "" when nothing changed.
+func finishIsolated(ctx context.Context, worktree *git.IsolatedWorktree, name string) (string, error) {
+	// Clean up even when the agent was cancelled
+	ctx = context.WithoutCancel(ctx)
+	defer worktree.Remove(ctx)
+
+	// File backups of the write tools are written into the worktree as well
+	patch, err := worktree.Patch(ctx, ".gitbuddy-backups")
+	if err != nil {
+		return "", err
+	}
+	if patch == "" {
+		return "", nil
+	}
+
+	dir := filepath.Join(worktree.RepoRoot(), isolatedPatchDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create patch directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".patch")
+	if err := os.WriteFile(path, []byte(patch), 0644); err != nil {
+		return "", fmt.Errorf("failed to write patch: %w", err)
+	}
+	return path, nil
+}
+
+// isolatedPatchHint tells the user how to review and apply a saved patch
+func isolatedPatchHint(path string) string {
+	if path == "" {
+		return "Isolated run finished without changes"
+	}
+	return fmt.Sprintf("Changes saved to %s (review with: git apply --stat %s, apply with: git apply %s)", path, path, path)
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartIsolated_RequiresGit(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".jj"), 0755))
+
+	_, err := startIsolated(context.Background(), &config.Config{}, dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires git")
+}
+
+func TestFinishIsolated(t *testing.T) {
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "--allow-empty", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	ctx := context.Background()
+	cfg := &config.Config{VCS: "git"}
+
+	// Without changes no patch is written
+	worktree, err := startIsolated(ctx, cfg, repoDir)
+	require.NoError(t, err)
+	path, err := finishIsolated(ctx, worktree, "chat-1")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+	assert.Equal(t, "Isolated run finished without changes", isolatedPatchHint(path))
+
+	worktree, err = startIsolated(ctx, cfg, repoDir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.Dir(), "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(worktree.Dir(), ".gitbuddy-backups"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.Dir(), ".gitbuddy-backups", "main.go"), []byte("old\n"), 0644))
+
+	path, err = finishIsolated(ctx, worktree, "chat-2")
+	require.NoError(t, err)
+	assert.Equal(t, "chat-2.patch", filepath.Base(path))
+	assert.NoDirExists(t, worktree.Dir(), "the worktree is removed")
+	assert.NoFileExists(t, filepath.Join(repoDir, "main.go"), "the working tree is untouched")
+
+	patch, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch), "+++ b/main.go")
+	assert.NotContains(t, string(patch), ".gitbuddy-backups")
+}
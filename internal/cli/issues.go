@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	issuesDirFlag string
+	issuesLimit   int
+)
+
+var issuesCmd = &cobra.Command{
+	Use:   "issues",
+	Short: "Find saved debug reports",
+	Long: `Find the debug reports saved by 'gitbuddy debug' in the issues directory.
+
+Available subcommands:
+  list   - List saved reports, newest first
+  search - Full-text search saved reports`,
+}
+
+var issuesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved debug reports",
+	Long: `List saved debug reports, newest first.
+
+Examples:
+  gitbuddy issues list
+  gitbuddy issues list --limit 10`,
+	Args: cobra.NoArgs,
+	RunE: runIssuesList,
+}
+
+var issuesSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search saved debug reports",
+	Long: `Search saved debug reports for every word of the query, in their titles,
+issue descriptions, investigated files and content. Reports matching in the
+title or files rank first.
+
+Examples:
+  gitbuddy issues search "token cache"
+  gitbuddy issues search auth.go`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIssuesSearch,
+}
+
+func init() {
+	issuesCmd.PersistentFlags().StringVar(&issuesDirFlag, "issues-dir", "", "Directory of saved reports (default: debug.issues_dir)")
+	issuesCmd.PersistentFlags().IntVar(&issuesLimit, "limit", 0, "Maximum number of reports to show (0 = all)")
+
+	issuesCmd.AddCommand(issuesListCmd)
+	issuesCmd.AddCommand(issuesSearchCmd)
+	rootCmd.AddCommand(issuesCmd)
+}
+
+// loadReports loads the reports of the configured issues directory
+func loadReports() ([]*reports.Report, string, error) {
+	dir := issuesDirFlag
+	if dir == "" {
+		cfg, err := config.Load(configFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load config: %w", err)
+		}
+		dir = cfg.GetDebugConfig().IssuesDir
+	}
+	saved, err := reports.Load(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return saved, dir, nil
+}
+
+func runIssuesList(cmd *cobra.Command, args []string) error {
+	saved, dir, err := loadReports()
+	if err != nil {
+		return err
+	}
+	if len(saved) == 0 {
+		fmt.Printf("No saved reports found in %s.\n", dir)
+		return nil
+	}
+
+	shown := saved
+	if issuesLimit > 0 && len(shown) > issuesLimit {
+		shown = shown[:issuesLimit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tTITLE\tFILE")
+	fmt.Fprintln(w, "--\t----\t-----\t----")
+	for _, report := range shown {
+		fmt.Fprintf(w, "#%03d\t%s\t%s\t%s\n", report.ID, report.Date, report.Title, report.Path)
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %d report(s)\n", len(saved))
+	return nil
+}
+
+func runIssuesSearch(cmd *cobra.Command, args []string) error {
+	saved, dir, err := loadReports()
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(args, " ")
+	matches := reports.Search(saved, query)
+	if len(matches) == 0 {
+		fmt.Printf("No reports in %s match %q.\n", dir, query)
+		return nil
+	}
+
+	shown := matches
+	if issuesLimit > 0 && len(shown) > issuesLimit {
+		shown = shown[:issuesLimit]
+	}
+	for _, match := range shown {
+		report := match.Report
+		fmt.Printf("#%03d  %s  %s\n", report.ID, report.Date, report.Title)
+		fmt.Printf("      %s\n", report.Path)
+		if len(report.Files) > 0 {
+			fmt.Printf("      Files: %s\n", strings.Join(report.Files, ", "))
+		}
+		for _, snippet := range match.Snippets {
+			fmt.Printf("      > %s\n", snippet)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d matching report(s)\n", len(matches))
+	return nil
+}
+
+// maxRelatedReports is the number of earlier reports offered to a new debug
+// session
+const maxRelatedReports = 3
+
+// offerRelatedReports looks for earlier reports on issues similar to issue
+// and asks whether to start the session from their summaries
+func offerRelatedReports(issuesDir, issue string, input io.Reader, output io.Writer) []*reports.Report {
+	saved, err := reports.Load(issuesDir)
+	if err != nil {
+		log.Debug("Failed to load earlier reports: %v", err)
+		return nil
+	}
+	matches := reports.Related(saved, issue, maxRelatedReports)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(output, "\n📚 Earlier reports on similar issues:")
+	related := make([]*reports.Report, 0, len(matches))
+	for _, match := range matches {
+		fmt.Fprintf(output, "  #%03d  %s  %s\n", match.Report.ID, match.Report.Date, match.Report.Title)
+		related = append(related, match.Report)
+	}
+	include, err := ui.ConfirmWithDefault("Include their summaries in the session context?", true, input, output)
+	if err != nil || !include {
+		return nil
+	}
+	return related
+}
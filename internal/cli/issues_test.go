@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/reports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfferRelatedReports(t *testing.T) {
+	dir := t.TempDir()
+	content, err := reports.Format(reports.Metadata{Title: "Login fails after token refresh", Date: "2026-10-01"}, "# Login fails\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "issue-001-login-fails-2026-10-01.md"), []byte(content), 0644))
+
+	var output bytes.Buffer
+	related := offerRelatedReports(dir, "Login fails on token refresh", strings.NewReader("\n"), &output)
+	require.Len(t, related, 1, "included by default")
+	assert.Contains(t, output.String(), "#001  2026-10-01  Login fails after token refresh")
+
+	assert.Empty(t, offerRelatedReports(dir, "Login fails on token refresh", strings.NewReader("n\n"), &output))
+	assert.Empty(t, offerRelatedReports(dir, "Checkout page slow", strings.NewReader(""), &output))
+}
@@ -2,10 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -24,50 +29,194 @@ var modelsListCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		printModels(cmd.OutOrStdout(), cfg)
+		return nil
+	},
+}
 
-		if len(cfg.Models) == 0 {
-			fmt.Println("No models configured.")
-			fmt.Println("\nRun 'gitbuddy init' to create a configuration file.")
-			return nil
+var modelsUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Set the default model",
+	Long: `Set default_model in the nearest configuration file: the one given with
+--config, otherwise .gitbuddy.yaml in the current directory or in the home
+directory. Only that line of the file is changed.
+
+Without a name, the model is chosen from the configured ones.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.FindConfigFile(configFile)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.LoadFromFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		bold := color.New(color.Bold)
-		green := color.New(color.FgGreen)
-		cyan := color.New(color.FgCyan)
+		out := cmd.OutOrStdout()
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			if !isTerminal(os.Stdin) {
+				return fmt.Errorf("model name required: gitbuddy models use <name>")
+			}
+			if name, err = chooseModel(cfg, os.Stdin, out); err != nil {
+				return err
+			}
+		}
+		return useModel(out, path, cfg, name)
+	},
+}
 
-		bold.Println("Configured Models:")
-		fmt.Println()
+// printModels lists the configured models, the default one first
+func printModels(out io.Writer, cfg *config.Config) {
+	if len(cfg.Models) == 0 {
+		fmt.Fprintln(out, "No models configured.")
+		fmt.Fprintln(out, "\nRun 'gitbuddy init' to create a configuration file.")
+		return
+	}
 
-		for name, model := range cfg.Models {
-			// Check if this is the default model
-			isDefault := name == cfg.DefaultModel
-			defaultMark := ""
-			if isDefault {
-				defaultMark = " (default)"
-			}
+	bold := color.New(color.Bold)
+	green := color.New(color.FgGreen)
+	cyan := color.New(color.FgCyan)
 
-			if isDefault {
-				green.Printf("  ✓ %s%s\n", name, defaultMark)
-			} else {
-				fmt.Printf("    %s\n", name)
-			}
+	bold.Fprintln(out, "Configured Models:")
+	fmt.Fprintln(out)
 
-			cyan.Printf("      Provider: %s\n", model.Provider)
-			cyan.Printf("      Model:    %s\n", model.Model)
-			if model.BaseURL != "" {
-				cyan.Printf("      Base URL: %s\n", model.BaseURL)
-			}
-			fmt.Println()
+	for _, name := range modelNames(cfg) {
+		model := cfg.Models[name]
+		if name == cfg.DefaultModel {
+			green.Fprintf(out, "  ✓ %s (default)\n", name)
+		} else {
+			fmt.Fprintf(out, "    %s\n", name)
 		}
 
-		return nil
-	},
+		capabilities := llm.ModelCapabilities(model)
+		cyan.Fprintf(out, "      Provider: %s\n", model.Provider)
+		cyan.Fprintf(out, "      Model:    %s\n", model.Model)
+		if model.BaseURL != "" {
+			cyan.Fprintf(out, "      Base URL: %s\n", model.BaseURL)
+		}
+		cyan.Fprintf(out, "      Context:  %s\n", formatContextWindow(capabilities.MaxContext))
+		cyan.Fprintf(out, "      Cost:     %s\n", costTier(model))
+		cyan.Fprintf(out, "      Supports: %s\n", formatCapabilities(capabilities))
+		fmt.Fprintln(out)
+	}
+}
+
+// modelNames returns the names of the configured models, the default one
+// first and the others sorted
+func modelNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Models))
+	for name := range cfg.Models {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == cfg.DefaultModel) != (names[j] == cfg.DefaultModel) {
+			return names[i] == cfg.DefaultModel
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// chooseModel asks which configured model to use, defaulting to the current one
+func chooseModel(cfg *config.Config, input io.Reader, out io.Writer) (string, error) {
+	names := modelNames(cfg)
+	if len(names) == 0 {
+		return "", fmt.Errorf("no models configured")
+	}
+	options := make([]string, len(names))
+	for i, name := range names {
+		model := cfg.Models[name]
+		options[i] = fmt.Sprintf("%s (%s/%s)", name, model.Provider, model.Model)
+	}
+	// The default model is listed first
+	choice, err := ui.SelectOption("Select the default model:", options, 0, input, out)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the choice: %w", err)
+	}
+	return names[choice], nil
+}
+
+// useModel makes name the default model in the configuration file at path
+func useModel(out io.Writer, path string, cfg *config.Config, name string) error {
+	if _, ok := cfg.Models[name]; !ok {
+		return fmt.Errorf("model '%s' not found in %s (configured: %s)", name, path, strings.Join(modelNames(cfg), ", "))
+	}
+	if name == cfg.DefaultModel {
+		fmt.Fprintf(out, "%s is already the default model\n", name)
+	} else {
+		if err := config.SetDefaultModel(path, name); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "✅ Default model set to %s in %s\n", name, path)
+	}
+	if env := os.Getenv("GITBUDDY_MODEL"); env != "" && env != name {
+		fmt.Fprintf(out, "Note: GITBUDDY_MODEL=%s overrides default_model\n", env)
+	}
+	return nil
+}
+
+// formatContextWindow formats a context window in tokens
+func formatContextWindow(tokens int) string {
+	switch {
+	case tokens <= 0:
+		return "unknown"
+	case tokens >= 1000000 && tokens%1000000 == 0:
+		return fmt.Sprintf("%dM tokens", tokens/1000000)
+	case tokens >= 1000:
+		return fmt.Sprintf("%dK tokens", tokens/1000)
+	default:
+		return fmt.Sprintf("%d tokens", tokens)
+	}
+}
+
+// costTier rates the prices of a model, weighing prompt tokens three times
+// as much as completion tokens since agents send much more than they get
+func costTier(model config.ModelConfig) string {
+	if model.InputPrice <= 0 && model.OutputPrice <= 0 {
+		if model.Provider == "ollama" {
+			return "free (local)"
+		}
+		return "unknown (set input_price and output_price)"
+	}
+	prices := fmt.Sprintf("$%.2f in, $%.2f out per 1M tokens", model.InputPrice, model.OutputPrice)
+	switch blended := (3*model.InputPrice + model.OutputPrice) / 4; {
+	case blended < 0.5:
+		return "$ low (" + prices + ")"
+	case blended < 3:
+		return "$$ medium (" + prices + ")"
+	default:
+		return "$$$ high (" + prices + ")"
+	}
+}
+
+// formatCapabilities lists the capabilities of a model on one line
+func formatCapabilities(c llm.Capabilities) string {
+	var supported []string
+	for _, capability := range []struct {
+		name      string
+		supported bool
+	}{
+		{"tools", c.Tools},
+		{"vision", c.Vision},
+		{"json mode", c.JSONMode},
+		{"streaming usage", c.StreamUsage},
+	} {
+		if capability.supported {
+			supported = append(supported, capability.name)
+		}
+	}
+	if len(supported) == 0 {
+		supported = append(supported, "chat only")
+	}
+	return strings.Join(supported, ", ")
 }
 
 func init() {
 	modelsCmd.AddCommand(modelsListCmd)
+	modelsCmd.AddCommand(modelsUseCmd)
 	rootCmd.AddCommand(modelsCmd)
-
-	// Suppress unused variable warning
-	_ = os.Stdout
 }
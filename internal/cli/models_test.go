@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testModelsConfig() *config.Config {
+	return &config.Config{
+		DefaultModel: "deepseek",
+		Models: map[string]config.ModelConfig{
+			"openai":   {Provider: "openai", Model: "gpt-4o", InputPrice: 2.5, OutputPrice: 10},
+			"deepseek": {Provider: "deepseek", Model: "deepseek-chat", InputPrice: 0.27, OutputPrice: 1.1},
+			"local":    {Provider: "ollama", Model: "llama3"},
+		},
+	}
+}
+
+func TestPrintModels(t *testing.T) {
+	var out bytes.Buffer
+	printModels(&out, testModelsConfig())
+	text := out.String()
+	assert.Contains(t, text, "✓ deepseek (default)")
+	assert.Contains(t, text, "Context:  64K tokens")
+	assert.Contains(t, text, "Cost:     $ low ($0.27 in, $1.10 out per 1M tokens)")
+	assert.Contains(t, text, "Cost:     free (local)")
+	assert.Less(t, strings.Index(text, "deepseek"), strings.Index(text, "local"), "the default model is listed first")
+	assert.Less(t, strings.Index(text, "local"), strings.Index(text, "openai"))
+}
+
+func TestCostTier(t *testing.T) {
+	assert.Equal(t, "unknown (set input_price and output_price)", costTier(config.ModelConfig{Provider: "openai"}))
+	assert.True(t, strings.HasPrefix(costTier(config.ModelConfig{InputPrice: 2.5, OutputPrice: 10}), "$$$ high"))
+	assert.True(t, strings.HasPrefix(costTier(config.ModelConfig{InputPrice: 1, OutputPrice: 2}), "$$ medium"))
+}
+
+func TestUseModel(t *testing.T) {
+	t.Setenv("GITBUDDY_MODEL", "")
+	path := filepath.Join(t.TempDir(), ".gitbuddy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("default_model: deepseek\n"), 0600))
+	cfg := testModelsConfig()
+
+	var out bytes.Buffer
+	require.NoError(t, useModel(&out, path, cfg, "openai"))
+	assert.Contains(t, out.String(), "Default model set to openai")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "default_model: openai\n", string(data))
+
+	out.Reset()
+	require.NoError(t, useModel(&out, path, cfg, "deepseek"))
+	assert.Contains(t, out.String(), "already the default model")
+
+	err = useModel(&out, path, cfg, "claude")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configured: deepseek, local, openai")
+}
+
+func TestChooseModel(t *testing.T) {
+	var out bytes.Buffer
+	name, err := chooseModel(testModelsConfig(), strings.NewReader("3\n"), &out)
+	require.NoError(t, err)
+	assert.Equal(t, "openai", name)
+	assert.Contains(t, out.String(), "1) deepseek (deepseek/deepseek-chat) [default]")
+
+	name, err = chooseModel(testModelsConfig(), strings.NewReader("\n"), &out)
+	require.NoError(t, err)
+	assert.Equal(t, "deepseek", name)
+}
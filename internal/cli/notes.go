@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Show AI metadata recorded as git notes",
+	Long: `Show the AI metadata GitBuddy records as git notes under ` + git.NotesRef + `.
+
+Enable recording with "notes.enabled: true" in the config file, or per run with
+--notes on commit, review and debug. Notes are versioned next to the code
+without touching commit messages; share them with:
+  git push origin ` + git.NotesRef + `
+
+Available subcommands:
+  show - Show the note attached to a commit`,
+}
+
+var notesShowCmd = &cobra.Command{
+	Use:   "show [commit]",
+	Short: "Show the note attached to a commit",
+	Long: `Show the GitBuddy note attached to a commit (default: HEAD).
+
+Examples:
+  gitbuddy notes show
+  gitbuddy notes show 1a2b3c4`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNotesShow,
+}
+
+func init() {
+	notesCmd.AddCommand(notesShowCmd)
+	rootCmd.AddCommand(notesCmd)
+}
+
+func runNotesShow(cmd *cobra.Command, args []string) error {
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+
+	commit := "HEAD"
+	if len(args) > 0 {
+		commit = args[0]
+	}
+
+	note, err := git.ShowNote(context.Background(), workDir, commit)
+	if errors.Is(err, git.ErrNoNote) {
+		fmt.Printf("No gitbuddy note for %s\n", commit)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(note)
+	return nil
+}
+
+// notesEnabled resolves the --notes flag of cmd against the config
+func notesEnabled(cmd *cobra.Command, cfg *config.Config, flag bool) bool {
+	if sideeffect.Blocked() {
+		return false
+	}
+	if cmd.Flags().Changed("notes") {
+		return flag
+	}
+	return cfg.NotesEnabled()
+}
+
+// recordNote attaches an entry to commit. Failures are reported but don't fail the command.
+func recordNote(ctx context.Context, workDir, commit string, printer *ui.StreamPrinter, entry git.NoteEntry) {
+	if err := git.AddNote(ctx, workDir, commit, entry); err != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to record git note: %v", err))
+		return
+	}
+	show := "gitbuddy notes show"
+	if commit != "HEAD" {
+		show += " " + commit
+	}
+	_ = printer.PrintInfo(fmt.Sprintf("Recorded %s metadata as a git note on %s (view with: %s)", entry.Kind, commit, show))
+}
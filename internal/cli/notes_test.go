@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewNoteCommit(t *testing.T) {
+	assert.Equal(t, "abc123", reviewNoteCommit(agent.ReviewModeCommit, "abc123"))
+	assert.Equal(t, "feature", reviewNoteCommit(agent.ReviewModeRange, "main..feature"))
+	assert.Equal(t, "feature", reviewNoteCommit(agent.ReviewModeRange, "main...feature"))
+	assert.Equal(t, "HEAD", reviewNoteCommit(agent.ReviewModeRange, "main.."))
+	assert.Equal(t, "", reviewNoteCommit(agent.ReviewModeStaged, ""))
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	planRefactorScope         []string
+	planRefactorContext       string
+	planRefactorLanguage      string
+	planRefactorOutput        string
+	planRefactorJSON          bool
+	planRefactorMaxIterations int
+)
+
+var planRefactorCmd = &cobra.Command{
+	Use:   "plan-refactor <goal>",
+	Short: "Plan a refactoring in phases without changing code",
+	Long: `Plan a refactoring without changing any code.
+
+The agent explores the code read-only, tracking what it has checked in an
+execution plan, and produces a phased plan. Each phase leaves the code building
+and the tests passing, and lists the files to change, its risks and the
+suggested commit boundaries.
+
+Examples:
+  gitbuddy plan-refactor "split the config package into loading and validation"
+  gitbuddy plan-refactor "replace the logger with slog" --scope internal/log
+  gitbuddy plan-refactor "extract the HTTP client" --output plan.md
+  gitbuddy plan-refactor "rename Executor to Runner" --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanRefactor,
+}
+
+func init() {
+	planRefactorCmd.Flags().StringSliceVar(&planRefactorScope, "scope", nil, "Paths to focus on (comma-separated or repeated)")
+	planRefactorCmd.Flags().StringVarP(&planRefactorContext, "context", "c", "", "Additional context to help AI understand the refactoring")
+	planRefactorCmd.Flags().StringVarP(&planRefactorLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
+	planRefactorCmd.Flags().StringVarP(&planRefactorOutput, "output", "o", "", "Write the plan as Markdown to this file")
+	planRefactorCmd.Flags().BoolVar(&planRefactorJSON, "json", false, "Print the plan as JSON")
+	planRefactorCmd.Flags().IntVar(&planRefactorMaxIterations, "max-iterations", agent.DefaultRefactorPlanIterations, "Maximum agent iterations")
+	rootCmd.AddCommand(planRefactorCmd)
+}
+
+func runPlanRefactor(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	goal := strings.TrimSpace(args[0])
+	if goal == "" {
+		return fmt.Errorf("refactoring goal is required")
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	modelConfig, err := cfg.GetModel(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to get model config: %w", err)
+	}
+	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
+
+	workDir, err := workspaceDir(false)
+	if err != nil {
+		return err
+	}
+
+	scope := make([]string, 0, len(planRefactorScope))
+	for _, path := range planRefactorScope {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("scope path not found: %s", path)
+		}
+		scope = append(scope, filepath.ToSlash(filepath.Clean(path)))
+	}
+
+	provider, err := newProvider(*modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout clean for --json
+	printerOut := os.Stdout
+	if planRefactorJSON {
+		printerOut = os.Stderr
+	}
+
+	applyToolLanguage(cfg, cfg.GetArtifactLanguage(config.ArtifactRefactorPlan, planRefactorLanguage))
+	retryConfigPtr := cfg.GetRetryConfig()
+	planAgent := agent.NewRefactorPlanAgent(agent.RefactorPlanAgentOptions{
+		Language:    cfg.GetArtifactLanguage(config.ArtifactRefactorPlan, planRefactorLanguage),
+		LLMProvider: provider,
+		Printer:     newStreamPrinter(printerOut),
+		RetryConfig: llm.RetryConfig{
+			Enabled:     retryConfigPtr.Enabled,
+			MaxAttempts: retryConfigPtr.MaxAttempts,
+			BackoffBase: retryConfigPtr.BackoffBase,
+			BackoffMax:  retryConfigPtr.BackoffMax,
+		},
+		MaxLinesPerRead:      cfg.GetReviewConfig().MaxLinesPerRead,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		PromptExtension:      cfg.GetPromptExtension("plan-refactor"),
+	})
+
+	resp, err := planAgent.Plan(ctx, agent.RefactorPlanRequest{
+		Goal:          goal,
+		Scope:         scope,
+		Context:       planRefactorContext,
+		Language:      cfg.GetArtifactLanguage(config.ArtifactRefactorPlan, planRefactorLanguage),
+		WorkDir:       workDir,
+		MaxIterations: planRefactorMaxIterations,
+		MaxDuration:   maxDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to plan refactoring: %w", err)
+	}
+
+	markdown := resp.Plan.Markdown(goal)
+	if planRefactorOutput != "" {
+		if err := os.WriteFile(planRefactorOutput, []byte(markdown), 0644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+	}
+
+	if planRefactorJSON {
+		data, err := json.MarshalIndent(resp.Plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println()
+	if resp.Partial {
+		fmt.Println("Warning: the agent stopped before it submitted the plan; the plan may be incomplete.")
+		fmt.Println()
+	}
+	fmt.Print(markdown)
+	fmt.Println()
+	if planRefactorOutput != "" {
+		fmt.Printf("Plan written to %s\n", planRefactorOutput)
+	}
+	fmt.Printf("Tokens: %d (prompt %d, completion %d)\n", resp.TotalTokens, resp.PromptTokens, resp.CompletionTokens)
+	return nil
+}
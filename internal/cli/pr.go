@@ -3,11 +3,14 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent"
 	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
@@ -19,19 +22,54 @@ var (
 	prBaseBranch string
 	prContext    string
 	prLanguage   string
+	prRedact     string
+	prChangelog  string
+	prFetch      bool
+	prRawStream  bool
+	prCheck      bool
+	prCreate     bool
+	prForge      string
+	prDraft      bool
+	prReviewers  []string
+	prAllowProt  bool
 )
 
 var prCmd = &cobra.Command{
 	Use:   "pr",
 	Short: "Generate PR description",
-	Long:  `Generate a pull request title and description based on the diff between current branch and target branch.`,
-	RunE:  runPR,
+	Long: `Generate a pull request title and description based on the diff between current branch and target branch.
+
+With --check, the description is generated without side effects: a shallow
+clone is not deepened and nothing is written. The command exits with 2 when
+the result is partial, so it can run in CI.
+
+With --create, the description opens a pull request (a merge request on
+GitLab) from the current branch into the base branch on the forge of the
+origin remote. The branch is pushed first when it has no upstream or has
+commits its upstream lacks; an upstream on another remote than origin is
+refused. Pushing to a protected branch asks for confirmation (without a
+terminal, it needs --allow-protected) or stops with the block policy.
+--draft opens it as a draft, and --reviewer requests reviews from users.`,
+	RunE: runPR,
 }
 
 func init() {
 	prCmd.Flags().StringVarP(&prBaseBranch, "base", "b", "", "Target branch to compare against (required)")
 	prCmd.Flags().StringVarP(&prContext, "context", "c", "", "Additional context to help AI generate better description")
 	prCmd.Flags().StringVarP(&prLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
+	prCmd.Flags().BoolVar(&prFetch, "fetch-history", false, "Fetch the full history of a shallow clone without asking")
+	prCmd.Flags().StringVar(&prRedact, "redact", "", "Redaction profile applied to the output (default: redaction.default_profile, \"none\" to disable)")
+	prCmd.Flags().StringVar(&prChangelog, "changelog", "", "Append a machine-readable changelog block to the description: yaml, json or none (default: pr.changelog)")
+
+	prCmd.Flags().BoolVar(&prRawStream, "raw-stream", false, "Print only the title and description to stdout, undecorated, streamed as they are written; progress goes to stderr")
+
+	prCmd.Flags().BoolVar(&prCreate, "create", false, "Open a pull request (merge request on GitLab) with the description on the forge of the origin remote")
+	prCmd.Flags().BoolVar(&prDraft, "draft", false, "Open the pull request of --create as a draft")
+	prCmd.Flags().StringSliceVar(&prReviewers, "reviewer", nil, "Request a review of the pull request of --create from this user (repeatable, or comma-separated; org/team on GitHub)")
+	prCmd.Flags().BoolVar(&prAllowProt, "allow-protected", false, "Push the branch of --create to a protected branch without asking (unless its policy is block)")
+	prCmd.Flags().StringVar(&prForge, "forge", "", "Forge of the origin remote for --create: github or gitlab (default: forge.type, or derived from the remote URL)")
+
+	prCmd.Flags().BoolVar(&prCheck, "check", false, checkFlagUsage)
 
 	_ = prCmd.MarkFlagRequired("base")
 
@@ -59,7 +97,8 @@ func runPR(cmd *cobra.Command, args []string) error {
 	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
 
 	// Get language
-	language := cfg.GetLanguage(prLanguage)
+	language := cfg.GetArtifactLanguage(config.ArtifactPR, prLanguage)
+	applyToolLanguage(cfg, language)
 	log.Debug("Using language: %s", language)
 
 	// Get PR template
@@ -72,17 +111,27 @@ func runPR(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(*modelConfig)
+	provider, err := newProvider(*modelConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("LLM provider created successfully")
 
 	// Create git executor
-	workDir, _ := os.Getwd()
-	gitExecutor := git.NewExecutor(workDir)
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	gitExecutor, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
 
 	// Get current branch
 	currentBranch, err := gitExecutor.CurrentBranch(ctx)
@@ -94,6 +143,51 @@ func runPR(cmd *cobra.Command, args []string) error {
 	if prBaseBranch == currentBranch {
 		return fmt.Errorf("base branch cannot be the same as current branch (%s)", currentBranch)
 	}
+	if prCheck && prFetch {
+		return fmt.Errorf("--fetch-history cannot be used with --check")
+	}
+	if prCheck && prCreate {
+		return fmt.Errorf("--create cannot be used with --check")
+	}
+	if !prCreate && (prDraft || len(prReviewers) > 0) {
+		return fmt.Errorf("--draft and --reviewer need --create")
+	}
+	var prForgeClient forge.Forge
+	var prRepo forge.Repository
+	var prHead string
+	if prCreate {
+		if _, ok := gitExecutor.(*git.DefaultExecutor); !ok {
+			return fmt.Errorf("--create is only supported with git")
+		}
+		if prForgeClient, prRepo, err = openForge(ctx, cfg.GetForgeConfig(), workDir, prForge); err != nil {
+			return err
+		}
+		// The push is checked before calling the model, like commit --push
+		if prHead, err = pullRequestHead(ctx, workDir, currentBranch); err != nil {
+			return err
+		}
+		guard := &protectedBranchGuard{
+			cfg:         cfg.GetProtectedBranchesConfig(),
+			allow:       prAllowProt,
+			interactive: isTerminal(os.Stdin) && isTerminal(os.Stdout),
+			input:       os.Stdin,
+			output:      os.Stdout,
+		}
+		if err := guard.checkPush(currentBranch, prHead); err != nil {
+			return err
+		}
+	}
+
+	changelogFormat := cfg.GetPRConfig().Changelog
+	if prChangelog != "" {
+		changelogFormat = prChangelog
+	}
+	if changelogFormat == "none" {
+		changelogFormat = ""
+	}
+	if err := agent.ValidateChangelogFormat(changelogFormat); err != nil {
+		return err
+	}
 
 	// Get retry config
 	retryConfigPtr := cfg.GetRetryConfig()
@@ -106,18 +200,45 @@ func runPR(cmd *cobra.Command, args []string) error {
 		BackoffMax:  retryConfigPtr.BackoffMax,
 	}
 
-	// Create stream printer for output
-	printer := ui.NewStreamPrinter(os.Stdout, ui.WithVerbose(debugMode))
+	// Create output redactor
+	redactor, err := newOutputRedactor(cfg, prRedact)
+	if err != nil {
+		return err
+	}
+
+	// Create stream printer for output. With --raw-stream, stdout only
+	// receives the title and description, streamed unless they must be
+	// redacted first.
+	display := io.Writer(os.Stdout)
+	printer := newStreamPrinter(display)
+	if prRawStream {
+		display = os.Stderr
+		printer = newStreamPrinter(display)
+		if redactor == nil {
+			printer = newStreamPrinter(display, ui.WithRawStream(os.Stdout))
+		}
+	}
+
+	// A shallow clone may not contain the commit the branches diverged from
+	if _, ok := gitExecutor.(*git.DefaultExecutor); ok {
+		check := newHistoryCheck(workDir, prFetch, printer)
+		check.output = display
+		if err := check.prHistory(ctx, prBaseBranch, currentBranch); err != nil {
+			return err
+		}
+	}
 
 	// Create PR agent
 	prAgent := agent.NewPRAgent(agent.PRAgentOptions{
-		Language:    language,
-		Template:    prTemplate,
-		GitExecutor: gitExecutor,
-		LLMProvider: provider,
-		Printer:     printer,
-		Debug:       debugMode,
-		RetryConfig: retryConfig,
+		Language:             language,
+		Template:             prTemplate,
+		GitExecutor:          gitExecutor,
+		LLMProvider:          provider,
+		Printer:              printer,
+		Debug:                debugMode,
+		RetryConfig:          retryConfig,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		PromptExtension:      cfg.GetPromptExtension("pr"),
 	})
 
 	// Print initial indicator
@@ -125,10 +246,14 @@ func runPR(cmd *cobra.Command, args []string) error {
 
 	// Generate PR description
 	req := agent.PRRequest{
-		BaseBranch: prBaseBranch,
-		HeadBranch: currentBranch,
-		Language:   language,
-		Context:    prContext,
+		BaseBranch:     prBaseBranch,
+		HeadBranch:     currentBranch,
+		Language:       language,
+		Context:        prContext,
+		WorkDir:        workDir,
+		MaxDuration:    maxDuration,
+		Changelog:      changelogFormat,
+		MigrationPaths: cfg.GetReviewConfig().Migrations.Paths,
 	}
 
 	response, err := prAgent.GeneratePRDescription(ctx, req)
@@ -136,12 +261,53 @@ func runPR(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate PR description: %w", err)
 	}
 
+	if response.Partial {
+		_ = printer.PrintError(fmt.Sprintf("Partial result (%s)", response.PartialReason))
+	}
+
+	redactor.PR(response)
+	redactor.PrintSummary(printer)
+
 	// Print the generated PR description
-	err = ui.ShowPRDescription(response, os.Stdout)
-	if err != nil {
+	if prRawStream {
+		if !response.Streamed {
+			fmt.Println(response.Title + "\n\n" + response.Description)
+		}
+	} else if err := ui.ShowPRDescription(response, os.Stdout); err != nil {
 		return err
 	}
 
+	if prCreate {
+		if response.Partial {
+			return fmt.Errorf("not opening a pull request with a partial description (%s)", response.PartialReason)
+		}
+		if err := pushForPullRequest(ctx, workDir, currentBranch, prHead, printer); err != nil {
+			return err
+		}
+		created, err := prForgeClient.CreatePullRequest(ctx, prRepo, forge.NewPullRequest{
+			Title: response.Title,
+			Body:  response.Description,
+			Head:  prHead,
+			Base:  prBaseBranch,
+			Draft: prDraft,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to open the pull request on %s: %w", prForgeClient.Name(), err)
+		}
+		_ = printer.PrintSuccess(fmt.Sprintf("Opened pull request #%d: %s", created.Number, created.URL))
+		requestPullRequestReviews(ctx, prForgeClient, prRepo, created.Number, prReviewers, printer)
+	}
+
+	// Owners of the changed files are the natural reviewers
+	if changed, err := git.ChangedFiles(ctx, workDir, prBaseBranch, currentBranch); err != nil {
+		log.Debug("Failed to list changed files for code owners: %v", err)
+	} else {
+		owners, _ := changeOwners(cfg, workDir, changed, printer)
+		if err := showCodeOwners(owners, display); err != nil {
+			return err
+		}
+	}
+
 	// Print stats
 	endTime := time.Now()
 	stats := &ui.ExecutionStats{
@@ -153,5 +319,82 @@ func runPR(cmd *cobra.Command, args []string) error {
 	}
 	_ = printer.PrintStats(stats)
 
+	if prCheck && response.Partial {
+		return checkFailed(fmt.Errorf("partial result (%s)", response.PartialReason))
+	}
 	return nil
 }
+
+// pullRequestHead returns the branch on origin a pull request from branch
+// is opened from: its upstream's, or the same name when it has none. Pull
+// requests are opened on the forge of origin, so an upstream on another
+// remote is refused.
+func pullRequestHead(ctx context.Context, workDir, branch string) (string, error) {
+	upstream, err := git.Upstream(ctx, workDir)
+	if err != nil {
+		return "", err
+	}
+	if upstream == "" {
+		return branch, nil
+	}
+	remote, head, _ := strings.Cut(upstream, "/")
+	if remote != "origin" || head == "" {
+		return "", fmt.Errorf("the upstream of %s is %s, but pull requests are opened on origin; set it with git branch --set-upstream-to origin/%s", branch, upstream, branch)
+	}
+	return head, nil
+}
+
+// pushForPullRequest pushes branch before a pull request is opened from it:
+// to origin, setting the upstream, when it has none, or to head on origin,
+// its upstream, when that lacks some of its commits
+func pushForPullRequest(ctx context.Context, workDir, branch, head string, printer *ui.StreamPrinter) error {
+	upstream, err := git.Upstream(ctx, workDir)
+	if err != nil {
+		return err
+	}
+	if upstream != "" {
+		ahead, _, err := git.RevListCount(ctx, workDir, upstream, "HEAD")
+		if err != nil {
+			return err
+		}
+		if ahead == 0 {
+			return nil
+		}
+		_ = printer.PrintProgress(fmt.Sprintf("Pushing %d commit(s) of %s to %s...", ahead, branch, upstream))
+		// Explicit, so push.default and a differently named upstream don't matter
+		_, err = git.Push(ctx, workDir, "origin", "HEAD:"+head, false)
+		return err
+	}
+	_ = printer.PrintProgress(fmt.Sprintf("Pushing %s to origin and setting its upstream...", branch))
+	_, err = git.Push(ctx, workDir, "origin", branch, true)
+	return err
+}
+
+// requestPullRequestReviews requests reviews of pull request number from
+// reviewers, given with or without @. The pull request is open already, so a
+// failure is printed rather than returned.
+func requestPullRequestReviews(ctx context.Context, f forge.Forge, repo forge.Repository, number int, reviewers []string, printer *ui.StreamPrinter) {
+	if len(reviewers) == 0 {
+		return
+	}
+	requester, ok := f.(forge.ReviewRequester)
+	if !ok {
+		_ = printer.PrintError(fmt.Sprintf("%s doesn't support requesting reviews", f.Name()))
+		return
+	}
+	owners := make([]string, len(reviewers))
+	for i, reviewer := range reviewers {
+		owners[i] = "@" + strings.TrimPrefix(strings.TrimSpace(reviewer), "@")
+	}
+	requested, err := requester.RequestReviewers(ctx, repo, number, owners)
+	switch {
+	case err != nil:
+		_ = printer.PrintError(fmt.Sprintf("Failed to request reviews: %v", err))
+	case len(requested) == 0:
+		_ = printer.PrintError("No reviews were requested: the reviewers are the author or can't review on " + f.Name())
+	case len(requested) < len(owners):
+		_ = printer.PrintError(fmt.Sprintf("Requested reviews from %s only; the others are the author or can't review on %s", strings.Join(requested, ", "), f.Name()))
+	default:
+		_ = printer.PrintInfo(fmt.Sprintf("Requested reviews from %s", strings.Join(requested, ", ")))
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushForPullRequest(t *testing.T) {
+	ctx := context.Background()
+	remote := testutil.NewGitRepo(t)
+	remote.Git("config", "receive.denyCurrentBranch", "ignore")
+	repo := testutil.NewSampleRepo(t)
+	repo.Git("remote", "add", "origin", remote.Dir)
+
+	var out bytes.Buffer
+	printer := ui.NewStreamPrinter(&out)
+	require.NoError(t, pushForPullRequest(ctx, repo.Dir, "feature/login", "feature/login", printer))
+	assert.Contains(t, out.String(), "Pushing feature/login to origin and setting its upstream")
+	assert.Equal(t, "origin/feature/login", repo.Git("rev-parse", "--abbrev-ref", "@{upstream}"))
+	assert.Equal(t, repo.Git("rev-parse", "HEAD"), remote.Git("rev-parse", "feature/login"))
+
+	// Nothing to push
+	out.Reset()
+	require.NoError(t, pushForPullRequest(ctx, repo.Dir, "feature/login", "feature/login", printer))
+	assert.Empty(t, out.String())
+
+	repo.Stage("auth/lockout.go", "package auth\n")
+	repo.Commit("feat(auth): lock out after too many attempts")
+	require.NoError(t, pushForPullRequest(ctx, repo.Dir, "feature/login", "feature/login", printer))
+	assert.Contains(t, out.String(), "Pushing 1 commit(s) of feature/login to origin/feature/login")
+	assert.Equal(t, repo.Git("rev-parse", "HEAD"), remote.Git("rev-parse", "feature/login"))
+
+	// An upstream with another name is pushed to explicitly, whatever push.default says
+	repo.Git("config", "push.default", "simple")
+	repo.Git("push", "--quiet", "origin", "HEAD:login")
+	repo.Git("branch", "--set-upstream-to", "origin/login")
+	repo.Stage("auth/reset.go", "package auth\n")
+	repo.Commit("feat(auth): reset the attempts after a login")
+	head, err := pullRequestHead(ctx, repo.Dir, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "login", head)
+	require.NoError(t, pushForPullRequest(ctx, repo.Dir, "main", head, printer))
+	assert.Contains(t, out.String(), "Pushing 1 commit(s) of main to origin/login")
+	assert.Equal(t, repo.Git("rev-parse", "HEAD"), remote.Git("rev-parse", "login"))
+}
+
+func TestPullRequestHead(t *testing.T) {
+	ctx := context.Background()
+	remote := testutil.NewGitRepo(t)
+	repo := testutil.NewSampleRepo(t)
+
+	head, err := pullRequestHead(ctx, repo.Dir, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "main", head)
+
+	// The pull request is opened on origin, so another remote is refused
+	repo.Git("remote", "add", "fork", remote.Dir)
+	repo.Git("push", "--quiet", "--set-upstream", "fork", "HEAD:feature")
+	_, err = pullRequestHead(ctx, repo.Dir, "main")
+	assert.ErrorContains(t, err, "the upstream of main is fork/feature, but pull requests are opened on origin")
+}
+
+func TestRequestPullRequestReviews(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/api/pulls/8":
+			fmt.Fprint(w, `{"number":8,"user":{"login":"alice"}}`)
+		case "/repos/acme/api/pulls/8/requested_reviewers":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := testutil.NewGitRepo(t)
+	repo.Git("remote", "add", "origin", "git@github.com:acme/api.git")
+	f, forgeRepo, err := openForge(context.Background(), &config.ForgeConfig{Token: "secret", APIURL: server.URL}, repo.Dir, "")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	requestPullRequestReviews(context.Background(), f, forgeRepo, 8, []string{"bob", "@acme/backend", "alice"}, ui.NewStreamPrinter(&out))
+	assert.Equal(t, []any{"bob"}, got["reviewers"])
+	assert.Equal(t, []any{"backend"}, got["team_reviewers"])
+	assert.Contains(t, out.String(), "Requested reviews from @bob, @acme/backend only; the others are the author or can't review on GitHub")
+}
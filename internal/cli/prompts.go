@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/huimingz/gitbuddy-go/internal/promptpack"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promptsInstallRef  string
+	promptsInstallName string
+	promptsUpdateRef   string
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Manage prompt packs shared through git",
+	Long: `Install prompt packs (commit styles, review rulesets, personas) from git
+repositories into ~/.gitbuddy/prompts, so teams can share prompt configurations
+without copy-pasting YAML.
+
+A pack is a repository with a ` + promptpack.ManifestName + ` manifest:
+  name: acme-style
+  description: ACME commit and review conventions
+  prompts:
+    commit: prompts/commit.md
+    review: prompts/review.md
+
+Without a manifest, <key>.md files in the repository root or in prompts/ are
+used. Keys: ` + strings.Join(promptpack.PromptKeys, ", ") + `.
+
+Enable installed packs in the config file; their prompts are added before
+prompt_extensions:
+  prompt_packs:
+    - acme-style
+
+Available subcommands:
+  install - Install a pack from a git URL
+  list    - List installed packs
+  update  - Update a pack or change its pinned version
+  remove  - Remove a pack`,
+}
+
+var promptsInstallCmd = &cobra.Command{
+	Use:   "install <git-url>",
+	Short: "Install a prompt pack from a git URL",
+	Long: `Clone a prompt pack and pin it to a tag, branch or commit (default: the
+remote's default branch).
+
+Examples:
+  gitbuddy prompts install https://github.com/acme/gitbuddy-prompts.git
+  gitbuddy prompts install https://github.com/acme/gitbuddy-prompts.git --ref v1.2.0
+  gitbuddy prompts install git@github.com:acme/prompts.git --name acme`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPromptsInstall,
+}
+
+var promptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed prompt packs",
+	Args:  cobra.NoArgs,
+	RunE:  runPromptsList,
+}
+
+var promptsUpdateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "Update an installed prompt pack",
+	Long: `Fetch an installed prompt pack and check out its pinned ref again, or the
+ref given with --ref (which becomes the new pin).
+
+Examples:
+  gitbuddy prompts update acme-style
+  gitbuddy prompts update acme-style --ref v2.0.0`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPromptsUpdate,
+}
+
+var promptsRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an installed prompt pack",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runPromptsRemove,
+}
+
+func init() {
+	promptsInstallCmd.Flags().StringVar(&promptsInstallRef, "ref", "", "Tag, branch or commit to pin (default: remote default branch)")
+	promptsInstallCmd.Flags().StringVar(&promptsInstallName, "name", "", "Name to install the pack as (default: manifest or repository name)")
+	promptsUpdateCmd.Flags().StringVar(&promptsUpdateRef, "ref", "", "Tag, branch or commit to pin instead of the current one")
+
+	promptsCmd.AddCommand(promptsInstallCmd)
+	promptsCmd.AddCommand(promptsListCmd)
+	promptsCmd.AddCommand(promptsUpdateCmd)
+	promptsCmd.AddCommand(promptsRemoveCmd)
+	rootCmd.AddCommand(promptsCmd)
+}
+
+// newPromptPackManager returns the manager for ~/.gitbuddy/prompts
+func newPromptPackManager() (*promptpack.Manager, error) {
+	dir, err := promptpack.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return promptpack.NewManager(dir), nil
+}
+
+func runPromptsInstall(cmd *cobra.Command, args []string) error {
+	mgr, err := newPromptPackManager()
+	if err != nil {
+		return err
+	}
+
+	installed, err := mgr.Install(context.Background(), args[0], promptsInstallRef, promptsInstallName)
+	if err != nil {
+		return err
+	}
+	pack, err := mgr.Load(installed.Name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Installed prompt pack %s at %s\n", installed.Name, shortCommit(installed.Commit))
+	fmt.Printf("   Prompts: %s\n", strings.Join(packPromptKeys(pack), ", "))
+	fmt.Printf("\nEnable it in your config file:\n  prompt_packs:\n    - %s\n", installed.Name)
+	return nil
+}
+
+func runPromptsList(cmd *cobra.Command, args []string) error {
+	mgr, err := newPromptPackManager()
+	if err != nil {
+		return err
+	}
+
+	packs, err := mgr.List()
+	if err != nil {
+		return err
+	}
+	if len(packs) == 0 {
+		fmt.Println("No prompt packs installed. Install one with `gitbuddy prompts install <git-url>`.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREF\tCOMMIT\tPROMPTS\tUPDATED\tURL")
+	fmt.Fprintln(w, "----\t---\t------\t-------\t-------\t---")
+	for _, p := range packs {
+		ref := p.Ref
+		if ref == "" {
+			ref = "(default)"
+		}
+		prompts := "(invalid)"
+		if pack, err := mgr.Load(p.Name); err == nil {
+			prompts = strings.Join(packPromptKeys(pack), ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Name, ref, shortCommit(p.Commit), prompts, p.UpdatedAt.Format("2006-01-02 15:04"), p.URL)
+	}
+	w.Flush()
+	return nil
+}
+
+func runPromptsUpdate(cmd *cobra.Command, args []string) error {
+	mgr, err := newPromptPackManager()
+	if err != nil {
+		return err
+	}
+
+	previous, err := mgr.Get(args[0])
+	if err != nil {
+		return err
+	}
+	updated, err := mgr.Update(context.Background(), args[0], promptsUpdateRef)
+	if err != nil {
+		return err
+	}
+
+	if updated.Commit == previous.Commit {
+		fmt.Printf("Prompt pack %s is already up to date (%s)\n", updated.Name, shortCommit(updated.Commit))
+		return nil
+	}
+	fmt.Printf("✅ Updated prompt pack %s: %s → %s\n", updated.Name, shortCommit(previous.Commit), shortCommit(updated.Commit))
+	return nil
+}
+
+func runPromptsRemove(cmd *cobra.Command, args []string) error {
+	mgr, err := newPromptPackManager()
+	if err != nil {
+		return err
+	}
+	if err := mgr.Remove(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Removed prompt pack %s. Remove it from prompt_packs in your config file as well.\n", args[0])
+	return nil
+}
+
+// packPromptKeys returns the prompt keys a pack provides, sorted
+func packPromptKeys(pack *promptpack.Pack) []string {
+	keys := make([]string, 0, len(pack.Prompts))
+	for key := range pack.Prompts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// errCommitCancelled is returned when the user declines to commit to a
+// protected branch
+var errCommitCancelled = errors.New("commit cancelled")
+
+// errPushCancelled is returned when the user declines to push to a protected
+// branch
+var errPushCancelled = errors.New("push cancelled")
+
+// branchSlugPattern matches the characters replaced in a suggested branch name
+var branchSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// protectedBranchGuard keeps commit and pr --create from writing to
+// protected branches
+type protectedBranchGuard struct {
+	cfg         *config.ProtectedBranchesConfig
+	allow       bool // --allow-protected
+	interactive bool
+	input       io.Reader
+	output      io.Writer
+}
+
+// check decides whether a commit to branch, pushed to pushBranch with
+// --push, may go ahead. It returns the branch to create and commit to
+// instead, if the user chose to. suggestion is the offered branch, or "" when
+// it is not known yet; then only the non-interactive checks run.
+func (g *protectedBranchGuard) check(branch, pushBranch, suggestion string) (string, error) {
+	target := g.target(branch, pushBranch)
+	if target == "" {
+		return "", nil
+	}
+
+	hint := "create a branch first: git checkout -b <branch>"
+	if suggestion != "" {
+		hint = "create a branch first: git checkout -b " + suggestion
+	}
+	if !g.interactive {
+		if g.cfg.Policy == config.ProtectedBranchBlock {
+			return "", fmt.Errorf("%s is a protected branch and commits to it are blocked; %s", target, hint)
+		}
+		return "", fmt.Errorf("%s is a protected branch; pass --allow-protected to commit anyway, or %s", target, hint)
+	}
+	if suggestion == "" {
+		return "", nil
+	}
+
+	fmt.Fprintf(g.output, "\n⚠️  %s is a protected branch.\n", target)
+	create, err := ui.ConfirmWithDefault(fmt.Sprintf("Create branch %s and commit there instead?", suggestion), true, g.input, g.output)
+	if err != nil {
+		return "", err
+	}
+	if create {
+		return suggestion, nil
+	}
+	if g.cfg.Policy == config.ProtectedBranchBlock {
+		return "", fmt.Errorf("commits to %s are blocked by protected_branches; %s", target, hint)
+	}
+	confirmed, err := ui.ConfirmWithDefault(fmt.Sprintf("Commit to %s anyway?", target), false, g.input, g.output)
+	if err != nil {
+		return "", err
+	}
+	if !confirmed {
+		return "", errCommitCancelled
+	}
+	return "", nil
+}
+
+// checkPush decides whether branch may be pushed to pushBranch, as pr
+// --create does before opening a pull request. The commits exist already, so
+// no other branch is offered.
+func (g *protectedBranchGuard) checkPush(branch, pushBranch string) error {
+	target := g.target(branch, pushBranch)
+	if target == "" {
+		return nil
+	}
+	if g.cfg.Policy == config.ProtectedBranchBlock {
+		return fmt.Errorf("%s is a protected branch and pushes to it are blocked; push from another branch", target)
+	}
+	if !g.interactive {
+		return fmt.Errorf("%s is a protected branch; pass --allow-protected to push anyway", target)
+	}
+
+	fmt.Fprintf(g.output, "\n⚠️  %s is a protected branch.\n", target)
+	confirmed, err := ui.ConfirmWithDefault(fmt.Sprintf("Push to %s anyway?", target), false, g.input, g.output)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errPushCancelled
+	}
+	return nil
+}
+
+// target returns the protected ones of branch and pushBranch, joined for
+// messages, or "" when neither needs a check
+func (g *protectedBranchGuard) target(branch, pushBranch string) string {
+	var protected []string
+	for _, b := range []string{branch, pushBranch} {
+		if b != "" && g.cfg.Protects(b) && (len(protected) == 0 || protected[0] != b) {
+			protected = append(protected, b)
+		}
+	}
+	if len(protected) == 0 || (g.allow && g.cfg.Policy != config.ProtectedBranchBlock) {
+		return ""
+	}
+	return strings.Join(protected, " and ")
+}
+
+// commitTargets returns the branch a commit goes to and, with push, the
+// branch it is pushed to: the upstream's, or the same name when there is none
+func commitTargets(ctx context.Context, workDir string, push bool) (branch, pushBranch string, err error) {
+	branch, err = git.HeadBranch(ctx, workDir)
+	if err != nil || !push || branch == "" {
+		return branch, "", err
+	}
+	upstream, err := git.Upstream(ctx, workDir)
+	if err != nil {
+		return "", "", err
+	}
+	if upstream == "" {
+		return branch, branch, nil
+	}
+	_, pushBranch, _ = strings.Cut(upstream, "/")
+	return branch, pushBranch, nil
+}
+
+// suggestBranchName derives a branch name from a commit message, e.g.
+// feat/auth-add-login for "feat(auth): add login"
+func suggestBranchName(info *agent.CommitInfo) string {
+	slug := strings.ToLower(strings.TrimSpace(info.Scope + " " + info.Description))
+	slug = strings.Trim(branchSlugPattern.ReplaceAllString(slug, "-"), "-")
+	if len(slug) > 40 {
+		slug = slug[:40]
+		if lastHyphen := strings.LastIndex(slug, "-"); lastHyphen > 0 {
+			slug = slug[:lastHyphen]
+		}
+	}
+	if slug == "" {
+		slug = "change"
+	}
+	prefix := info.Type
+	if prefix == "" {
+		prefix = "change"
+	}
+	return prefix + "/" + slug
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+func TestProtectedBranchGuard_Check(t *testing.T) {
+	confirm := &config.ProtectedBranchesConfig{Patterns: []string{"main", "release/*"}, Policy: config.ProtectedBranchConfirm}
+	block := &config.ProtectedBranchesConfig{Patterns: []string{"main", "release/*"}, Policy: config.ProtectedBranchBlock}
+
+	tests := []struct {
+		name       string
+		cfg        *config.ProtectedBranchesConfig
+		allow      bool
+		answers    string
+		branch     string
+		pushBranch string
+		want       string
+		wantErr    string
+	}{
+		{name: "unprotected", cfg: block, branch: "feature/x", pushBranch: "feature/x"},
+		{name: "non-interactive confirm", cfg: confirm, branch: "main", wantErr: "--allow-protected"},
+		{name: "non-interactive allowed", cfg: confirm, allow: true, branch: "main"},
+		{name: "allow does not override block", cfg: block, allow: true, branch: "main", wantErr: "blocked"},
+		{name: "push to protected upstream", cfg: confirm, branch: "wip", pushBranch: "release/1.0", wantErr: "release/1.0 is a protected branch"},
+		{name: "create branch", cfg: confirm, answers: "\n", branch: "main", want: "feat/login"},
+		{name: "confirmed", cfg: confirm, answers: "n\ny\n", branch: "main"},
+		{name: "cancelled", cfg: confirm, answers: "n\n\n", branch: "main", wantErr: errCommitCancelled.Error()},
+		{name: "blocked after declining", cfg: block, answers: "n\n", branch: "main", wantErr: "blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			guard := &protectedBranchGuard{
+				cfg:         tt.cfg,
+				allow:       tt.allow,
+				interactive: tt.answers != "",
+				input:       iotest.OneByteReader(strings.NewReader(tt.answers)),
+				output:      &out,
+			}
+			newBranch, err := guard.check(tt.branch, tt.pushBranch, "feat/login")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, newBranch)
+		})
+	}
+}
+
+func TestProtectedBranchGuard_CheckBeforeGeneration(t *testing.T) {
+	guard := &protectedBranchGuard{
+		cfg:         &config.ProtectedBranchesConfig{Patterns: []string{"main"}, Policy: config.ProtectedBranchConfirm},
+		interactive: true,
+	}
+	// Interactive users are asked once the message is known
+	newBranch, err := guard.check("main", "", "")
+	require.NoError(t, err)
+	assert.Empty(t, newBranch)
+}
+
+func TestProtectedBranchGuard_CheckPush(t *testing.T) {
+	confirm := &config.ProtectedBranchesConfig{Patterns: []string{"main", "release/*"}, Policy: config.ProtectedBranchConfirm}
+	block := &config.ProtectedBranchesConfig{Patterns: []string{"main", "release/*"}, Policy: config.ProtectedBranchBlock}
+
+	tests := []struct {
+		name       string
+		cfg        *config.ProtectedBranchesConfig
+		allow      bool
+		answers    string
+		branch     string
+		pushBranch string
+		wantErr    string
+	}{
+		{name: "unprotected", cfg: block, branch: "feature/x", pushBranch: "feature/x"},
+		{name: "blocked", cfg: block, branch: "main", pushBranch: "main", wantErr: "main is a protected branch and pushes to it are blocked"},
+		{name: "blocked upstream", cfg: block, branch: "wip", pushBranch: "release/1.0", wantErr: "release/1.0 is a protected branch and pushes to it are blocked"},
+		{name: "allow does not override block", cfg: block, allow: true, branch: "main", pushBranch: "main", wantErr: "blocked"},
+		{name: "blocked even when asked", cfg: block, answers: "y\n", branch: "main", pushBranch: "main", wantErr: "blocked"},
+		{name: "non-interactive confirm", cfg: confirm, branch: "main", pushBranch: "main", wantErr: "pass --allow-protected to push anyway"},
+		{name: "non-interactive allowed", cfg: confirm, allow: true, branch: "main", pushBranch: "main"},
+		{name: "confirmed", cfg: confirm, answers: "y\n", branch: "main", pushBranch: "main"},
+		{name: "cancelled", cfg: confirm, answers: "\n", branch: "main", pushBranch: "main", wantErr: errPushCancelled.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			guard := &protectedBranchGuard{
+				cfg:         tt.cfg,
+				allow:       tt.allow,
+				interactive: tt.answers != "",
+				input:       iotest.OneByteReader(strings.NewReader(tt.answers)),
+				output:      &out,
+			}
+			err := guard.checkPush(tt.branch, tt.pushBranch)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCommitTargets(t *testing.T) {
+	dir := t.TempDir()
+	runGitAt(t, dir, nil, "init", "--quiet", "--initial-branch=main")
+	runGitAt(t, dir, nil, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial")
+
+	branch, pushTarget, err := commitTargets(context.Background(), dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+	assert.Empty(t, pushTarget)
+
+	branch, pushTarget, err = commitTargets(context.Background(), dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+	assert.Equal(t, "main", pushTarget)
+}
+
+func TestSuggestBranchName(t *testing.T) {
+	assert.Equal(t, "feat/auth-add-login-with-oauth", suggestBranchName(&agent.CommitInfo{Type: "feat", Scope: "auth", Description: "Add login with OAuth"}))
+	assert.Equal(t, "fix/handle-empty-diff", suggestBranchName(&agent.CommitInfo{Type: "fix", Description: "handle empty diff!"}))
+	assert.Equal(t, "change/change", suggestBranchName(&agent.CommitInfo{}))
+
+	long := suggestBranchName(&agent.CommitInfo{Type: "refactor", Description: strings.Repeat("split the executor ", 5)})
+	assert.LessOrEqual(t, len(long), len("refactor/")+40)
+	assert.False(t, strings.HasSuffix(long, "-"))
+}
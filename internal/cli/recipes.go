@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var recipesCmd = &cobra.Command{
+	Use:   "recipes [name]",
+	Short: "Print ready-to-copy workflows for your configuration",
+	Long: `Print ready-to-copy workflows (CI job, git hooks, aliases, ...) generated from
+your current configuration, so model names, profiles and packs match what you
+actually use. Nothing is sent anywhere; the recipes are assembled locally.
+
+Without a name, all recipes are printed.
+
+Examples:
+  gitbuddy recipes
+  gitbuddy recipes ci
+  gitbuddy recipes aliases > aliases.sh`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRecipes,
+}
+
+func init() {
+	rootCmd.AddCommand(recipesCmd)
+}
+
+// recipe is a workflow snippet generated from the configuration
+type recipe struct {
+	Name        string
+	Title       string
+	Description string
+	Body        string
+}
+
+func runRecipes(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	recipes := buildRecipes(cfg, modelName)
+	if len(args) == 0 {
+		printRecipes(os.Stdout, recipes)
+		return nil
+	}
+
+	names := make([]string, len(recipes))
+	for i, r := range recipes {
+		if r.Name == args[0] {
+			// A single recipe prints only its body so it can be redirected to a file
+			fmt.Print(r.Body)
+			return nil
+		}
+		names[i] = r.Name
+	}
+	return fmt.Errorf("unknown recipe: %s (available: %s)", args[0], strings.Join(names, ", "))
+}
+
+func printRecipes(w io.Writer, recipes []recipe) {
+	for i, r := range recipes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "━━━ %s (gitbuddy recipes %s)\n", r.Title, r.Name)
+		fmt.Fprintf(w, "%s\n\n", r.Description)
+		fmt.Fprint(w, r.Body)
+	}
+}
+
+// buildRecipes assembles the recipes for cfg. model selects the model used in
+// the snippets (default: the configured default model).
+func buildRecipes(cfg *config.Config, model string) []recipe {
+	if model == "" {
+		model = cfg.DefaultModel
+	}
+
+	recipes := []recipe{
+		ciRecipe(cfg, model),
+		hooksRecipe(cfg, model),
+		aliasesRecipe(cfg),
+	}
+	if cfg.Redaction != nil && len(cfg.Redaction.Profiles) > 0 {
+		recipes = append(recipes, redactionRecipe(cfg))
+	}
+	if cfg.NotesEnabled() {
+		recipes = append(recipes, notesRecipe())
+	}
+	if len(cfg.PromptPacks) > 0 {
+		recipes = append(recipes, promptPacksRecipe(cfg))
+	}
+	return recipes
+}
+
+// ciRecipe reviews pull requests in GitHub Actions with a config written at runtime
+func ciRecipe(cfg *config.Config, model string) recipe {
+	description := "GitHub Actions job reviewing the diff of every pull request. Store the API key as a repository secret."
+
+	// Local models are not reachable from CI runners, so prefer a hosted one
+	name, mc, ok := recipeModel(cfg, model)
+	if ok && mc.Provider == "ollama" {
+		for _, candidate := range sortedModelNames(cfg) {
+			if cfg.Models[candidate].Provider != "ollama" {
+				name, mc = candidate, cfg.Models[candidate]
+				break
+			}
+		}
+		if mc.Provider == "ollama" {
+			description += " Note: " + name + " runs on Ollama; make its base_url reachable from the runner."
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`# .github/workflows/gitbuddy-review.yml
+name: AI review
+on: pull_request
+jobs:
+  review:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - run: go install github.com/huimingz/gitbuddy-go/cmd/gitbuddy@latest
+      - name: Write config
+        run: |
+          cat > "$RUNNER_TEMP/gitbuddy.yaml" <<'EOF'
+`)
+	if ok {
+		fmt.Fprintf(&sb, "          default_model: %s\n", name)
+		fmt.Fprintf(&sb, "          models:\n            %s:\n", name)
+		fmt.Fprintf(&sb, "              provider: %s\n", mc.Provider)
+		fmt.Fprintf(&sb, "              model: %s\n", mc.Model)
+		if mc.Provider != "ollama" {
+			fmt.Fprintf(&sb, "              api_key: ${%s}\n", apiKeyEnv(mc))
+		}
+		if mc.BaseURL != "" {
+			fmt.Fprintf(&sb, "              base_url: %s\n", mc.BaseURL)
+		}
+	} else {
+		sb.WriteString("          # Add your model configuration (see gitbuddy init)\n")
+	}
+	if cfg.Language != "" {
+		fmt.Fprintf(&sb, "          language: %s\n", cfg.Language)
+	}
+	sb.WriteString("          EOF\n")
+	sb.WriteString("      - name: Review\n")
+	if ok && mc.Provider != "ollama" {
+		env := apiKeyEnv(mc)
+		fmt.Fprintf(&sb, "        env:\n          %s: ${{ secrets.%s }}\n", env, env)
+	}
+	review := `git diff "origin/${{ github.base_ref }}...HEAD" | gitbuddy review --stdin --config "$RUNNER_TEMP/gitbuddy.yaml"`
+	if profile := defaultRedactionProfile(cfg); profile != "" {
+		review += " --redact " + profile
+	}
+	fmt.Fprintf(&sb, "        run: %s\n", review)
+
+	return recipe{Name: "ci", Title: "CI review", Description: description, Body: sb.String()}
+}
+
+// hooksRecipe drafts commit messages in a prepare-commit-msg hook
+func hooksRecipe(cfg *config.Config, model string) recipe {
+	modelFlag := ""
+	if name, _, ok := recipeModel(cfg, model); ok && name != cfg.DefaultModel {
+		modelFlag = " --model " + name
+	}
+
+	body := `#!/bin/sh
+# Draft the message for plain "git commit" (not for -m, merges or amends); requires jq
+[ -z "$2" ] || exit 0
+message=$(gitbuddy commit --print-only` + modelFlag + ` 2>/dev/null | jq -r .message) || exit 0
+[ -n "$message" ] || exit 0
+{ printf '%s\n\n' "$message"; cat "$1"; } > "$1.gitbuddy" && mv "$1.gitbuddy" "$1"
+`
+	return recipe{
+		Name:  "hooks",
+		Title: "Commit message hook",
+		Description: "prepare-commit-msg hook that pre-fills the editor with a generated message; failures fall back to an empty message.\n" +
+			"Install with: gitbuddy recipes hooks > .git/hooks/prepare-commit-msg && chmod +x .git/hooks/prepare-commit-msg",
+		Body: body,
+	}
+}
+
+// aliasesRecipe adds git aliases for the common commands and one per configured model
+func aliasesRecipe(cfg *config.Config) recipe {
+	var sb strings.Builder
+	sb.WriteString(`git config --global alias.ac '!gitbuddy commit'
+git config --global alias.ar '!gitbuddy review'
+git config --global alias.apr '!gitbuddy pr --base'
+git config --global alias.sync-check '!gitbuddy sync-check'
+`)
+	if len(cfg.Models) > 1 {
+		sb.WriteString("\n# Per-model commit aliases\n")
+		for _, name := range sortedModelNames(cfg) {
+			fmt.Fprintf(&sb, "git config --global alias.ac-%s '!gitbuddy commit --model %s'\n", name, name)
+		}
+	}
+	return recipe{
+		Name:        "aliases",
+		Title:       "Git aliases",
+		Description: "Short git aliases, e.g. git ac, git ar and git apr main.",
+		Body:        sb.String(),
+	}
+}
+
+// redactionRecipe shows the redacted commands for each profile
+func redactionRecipe(cfg *config.Config) recipe {
+	profiles := make([]string, 0, len(cfg.Redaction.Profiles))
+	for name := range cfg.Redaction.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+
+	var sb strings.Builder
+	for _, profile := range profiles {
+		fmt.Fprintf(&sb, "# Profile %s\n", profile)
+		fmt.Fprintf(&sb, "gitbuddy pr --base main --redact %s\n", profile)
+		fmt.Fprintf(&sb, "gitbuddy report --since $(date -d '7 days ago' +%%F) --redact %s\n", profile)
+	}
+	if profile := defaultRedactionProfile(cfg); profile != "" {
+		fmt.Fprintf(&sb, "\n# %s is applied by default; disable it for internal use\ngitbuddy review --redact none\n", profile)
+	}
+	return recipe{
+		Name:        "redaction",
+		Title:       "Redacted output",
+		Description: "Share PR descriptions and reports outside the company with the configured redaction profiles.",
+		Body:        sb.String(),
+	}
+}
+
+func notesRecipe() recipe {
+	return recipe{
+		Name:        "notes",
+		Title:       "Sharing git notes",
+		Description: "Notes are enabled; push and fetch them alongside the branches.",
+		Body: `git push origin refs/notes/gitbuddy
+git config --add remote.origin.fetch '+refs/notes/gitbuddy:refs/notes/gitbuddy'
+git config notes.displayRef refs/notes/gitbuddy   # Show notes in git log
+`,
+	}
+}
+
+func promptPacksRecipe(cfg *config.Config) recipe {
+	var sb strings.Builder
+	for _, name := range cfg.PromptPacks {
+		fmt.Fprintf(&sb, "gitbuddy prompts update %s\n", name)
+	}
+	return recipe{
+		Name:        "prompt-packs",
+		Title:       "Prompt pack updates",
+		Description: "Refresh the enabled prompt packs, e.g. from a weekly cron job.",
+		Body:        sb.String(),
+	}
+}
+
+// recipeModel returns the named model, falling back to the first configured one
+func recipeModel(cfg *config.Config, name string) (string, config.ModelConfig, bool) {
+	if mc, ok := cfg.Models[name]; ok {
+		return name, mc, true
+	}
+	if names := sortedModelNames(cfg); len(names) > 0 {
+		return names[0], cfg.Models[names[0]], true
+	}
+	return "", config.ModelConfig{}, false
+}
+
+func sortedModelNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Models))
+	for name := range cfg.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apiKeyEnv returns the environment variable the model's API key is read from.
+// Literal keys are never printed; the provider's conventional variable is used instead.
+func apiKeyEnv(mc config.ModelConfig) string {
+	key := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(mc.APIKey, "$"), "{"), "}")
+	if strings.HasPrefix(mc.APIKey, "$") && key != "" {
+		return key
+	}
+	switch mc.Provider {
+	case "gemini":
+		return "GOOGLE_API_KEY"
+	case "grok":
+		return "XAI_API_KEY"
+	default:
+		return strings.ToUpper(mc.Provider) + "_API_KEY"
+	}
+}
+
+func defaultRedactionProfile(cfg *config.Config) string {
+	if cfg.Redaction == nil {
+		return ""
+	}
+	return cfg.Redaction.DefaultProfile
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recipeNames(recipes []recipe) []string {
+	names := make([]string, len(recipes))
+	for i, r := range recipes {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func findRecipe(t *testing.T, recipes []recipe, name string) recipe {
+	t.Helper()
+	for _, r := range recipes {
+		if r.Name == name {
+			return r
+		}
+	}
+	require.Failf(t, "recipe not found", "%s in %v", name, recipeNames(recipes))
+	return recipe{}
+}
+
+func TestBuildRecipes_FromConfig(t *testing.T) {
+	cfg := &config.Config{
+		DefaultModel: "local",
+		Language:     "zh",
+		Models: map[string]config.ModelConfig{
+			"local":    {Provider: "ollama", Model: "llama3.2"},
+			"deepseek": {Provider: "deepseek", Model: "deepseek-chat", APIKey: "${DS_KEY}"},
+			"gpt":      {Provider: "openai", Model: "gpt-4o", APIKey: "sk-secret"},
+		},
+		Redaction: &config.RedactionConfig{
+			DefaultProfile: "external",
+			Profiles:       map[string]*config.RedactionProfile{"external": {}},
+		},
+		Notes:       &config.NotesConfig{Enabled: true},
+		PromptPacks: []string{"acme-style"},
+	}
+
+	recipes := buildRecipes(cfg, "")
+	assert.Equal(t, []string{"ci", "hooks", "aliases", "redaction", "notes", "prompt-packs"}, recipeNames(recipes))
+
+	ci := findRecipe(t, recipes, "ci").Body
+	assert.Contains(t, ci, "default_model: deepseek", "CI prefers a hosted model over ollama")
+	assert.Contains(t, ci, "api_key: ${DS_KEY}")
+	assert.Contains(t, ci, "DS_KEY: ${{ secrets.DS_KEY }}")
+	assert.Contains(t, ci, "language: zh")
+	assert.Contains(t, ci, "--redact external")
+
+	aliases := findRecipe(t, recipes, "aliases").Body
+	assert.Contains(t, aliases, "alias.ac-gpt '!gitbuddy commit --model gpt'")
+	assert.Contains(t, findRecipe(t, recipes, "prompt-packs").Body, "gitbuddy prompts update acme-style")
+
+	for _, r := range recipes {
+		assert.NotContains(t, r.Body, "sk-secret", "literal API keys are never printed")
+	}
+}
+
+func TestBuildRecipes_ModelOverride(t *testing.T) {
+	cfg := &config.Config{
+		DefaultModel: "deepseek",
+		Models: map[string]config.ModelConfig{
+			"deepseek": {Provider: "deepseek", Model: "deepseek-chat"},
+			"gpt":      {Provider: "openai", Model: "gpt-4o", APIKey: "sk-secret"},
+		},
+	}
+
+	recipes := buildRecipes(cfg, "gpt")
+	assert.Equal(t, []string{"ci", "hooks", "aliases"}, recipeNames(recipes))
+	assert.Contains(t, findRecipe(t, recipes, "ci").Body, "OPENAI_API_KEY: ${{ secrets.OPENAI_API_KEY }}")
+	assert.Contains(t, findRecipe(t, recipes, "hooks").Body, "gitbuddy commit --print-only --model gpt")
+	assert.NotContains(t, findRecipe(t, buildRecipes(cfg, ""), "hooks").Body, "--model")
+}
+
+func TestApiKeyEnv(t *testing.T) {
+	assert.Equal(t, "MY_KEY", apiKeyEnv(config.ModelConfig{Provider: "openai", APIKey: "$MY_KEY"}))
+	assert.Equal(t, "MY_KEY", apiKeyEnv(config.ModelConfig{Provider: "openai", APIKey: "${MY_KEY}"}))
+	assert.Equal(t, "GOOGLE_API_KEY", apiKeyEnv(config.ModelConfig{Provider: "gemini", APIKey: "literal"}))
+	assert.Equal(t, "XAI_API_KEY", apiKeyEnv(config.ModelConfig{Provider: "grok"}))
+}
+
+func TestPrintRecipes(t *testing.T) {
+	var buf bytes.Buffer
+	printRecipes(&buf, buildRecipes(&config.Config{}, ""))
+
+	out := buf.String()
+	assert.Contains(t, out, "━━━ CI review (gitbuddy recipes ci)")
+	assert.Contains(t, out, "# Add your model configuration")
+	assert.Contains(t, out, "#!/bin/sh")
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/redact"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// outputRedactor applies a redaction profile to generated output and counts replacements
+type outputRedactor struct {
+	profile  string
+	redactor *redact.Redactor
+	count    int
+}
+
+// newOutputRedactor creates the redactor for a profile name (empty = config default).
+// It returns nil when redaction is disabled.
+func newOutputRedactor(cfg *config.Config, profileName string) (*outputRedactor, error) {
+	profile, err := cfg.GetRedactionProfile(profileName)
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	redactor, err := redact.NewRedactor(redact.Rules{
+		Hostnames:   profile.Hostnames,
+		Usernames:   profile.Usernames,
+		Identifiers: profile.Identifiers,
+		Patterns:    profile.Patterns,
+		Replacement: profile.Replacement,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if profileName == "" {
+		profileName = cfg.Redaction.DefaultProfile
+	}
+	return &outputRedactor{profile: profileName, redactor: redactor}, nil
+}
+
+// apply redacts a string in place
+func (r *outputRedactor) apply(s *string) {
+	redacted, n := r.redactor.Redact(*s)
+	*s = redacted
+	r.count += n
+}
+
+// applyAll redacts each string of a slice in place
+func (r *outputRedactor) applyAll(items []string) {
+	for i := range items {
+		r.apply(&items[i])
+	}
+}
+
+// PR redacts a generated PR description
+func (r *outputRedactor) PR(response *agent.PRResponse) {
+	if r == nil || response == nil {
+		return
+	}
+	r.apply(&response.Title)
+	r.apply(&response.Description)
+	if response.PRInfo != nil {
+		r.apply(&response.PRInfo.Title)
+		r.apply(&response.PRInfo.Description)
+	}
+}
+
+// Report redacts a generated development report
+func (r *outputRedactor) Report(response *agent.ReportResponse) {
+	if r == nil || response == nil {
+		return
+	}
+	r.apply(&response.Content)
+	if info := response.ReportInfo; info != nil {
+		r.apply(&info.Title)
+		r.apply(&info.Period)
+		r.apply(&info.Author)
+		r.apply(&info.Summary)
+		r.applyAll(info.Features)
+		r.applyAll(info.Fixes)
+		r.applyAll(info.Refactoring)
+		r.applyAll(info.Other)
+		r.apply(&info.Highlights)
+		r.apply(&info.NextSteps)
+	}
+}
+
+// Review redacts the findings of a code review
+func (r *outputRedactor) Review(response *agent.ReviewResponse) {
+	if r == nil || response == nil {
+		return
+	}
+	r.apply(&response.Summary)
+	for i := range response.Issues {
+		r.apply(&response.Issues[i].Title)
+		r.apply(&response.Issues[i].Description)
+		r.apply(&response.Issues[i].Suggestion)
+	}
+}
+
+// PrintSummary reports how many occurrences were redacted
+func (r *outputRedactor) PrintSummary(printer *ui.StreamPrinter) {
+	if r == nil || r.count == 0 {
+		return
+	}
+	_ = printer.PrintInfo(fmt.Sprintf("Redacted %d occurrence(s) using profile %q", r.count, r.profile))
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/repomap"
+)
+
+// sharedRepoMaps holds the rendered repository maps by working directory
+// while a workflow runs, so that only its first step refreshes the map. It is
+// nil outside workflows.
+var sharedRepoMaps map[string]string
+
+// repositoryMap refreshes the repository map cached in workDir and renders
+// it for a system prompt, or takes it from the daemon of workDir. The map
+// only saves exploration, so failures are logged and yield no map.
+func repositoryMap(ctx context.Context, cfg *config.Config, workDir string) string {
+	mapCfg := cfg.GetRepoMapConfig()
+	if mapCfg.Disabled {
+		return ""
+	}
+	if rendered, ok := sharedRepoMaps[workDir]; ok {
+		return rendered
+	}
+	if rendered, ok := daemonRepoMap(ctx, workDir, mapCfg.MaxChars); ok {
+		if sharedRepoMaps != nil {
+			sharedRepoMaps[workDir] = rendered
+		}
+		return rendered
+	}
+	m, err := repomap.Refresh(ctx, workDir)
+	if err != nil {
+		log.Debug("Failed to refresh the repository map: %v", err)
+		if m == nil {
+			return ""
+		}
+	}
+	rendered := m.Render(mapCfg.MaxChars)
+	if sharedRepoMaps != nil {
+		sharedRepoMaps[workDir] = rendered
+	}
+	return rendered
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent"
@@ -21,6 +22,8 @@ var (
 	reportAuthor   string
 	reportContext  string
 	reportLanguage string
+	reportRedact   string
+	reportFetch    bool
 )
 
 var reportCmd = &cobra.Command{
@@ -36,6 +39,8 @@ func init() {
 	reportCmd.Flags().StringVarP(&reportAuthor, "author", "a", "", "Author name (optional, defaults to current git user)")
 	reportCmd.Flags().StringVarP(&reportContext, "context", "c", "", "Additional context to help AI generate better report")
 	reportCmd.Flags().StringVarP(&reportLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
+	reportCmd.Flags().BoolVar(&reportFetch, "fetch-history", false, "Fetch missing history of a shallow clone without asking")
+	reportCmd.Flags().StringVar(&reportRedact, "redact", "", "Redaction profile applied to the output (default: redaction.default_profile, \"none\" to disable)")
 
 	_ = reportCmd.MarkFlagRequired("since")
 
@@ -63,21 +68,32 @@ func runReport(cmd *cobra.Command, args []string) error {
 	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
 
 	// Get language
-	language := cfg.GetLanguage(reportLanguage)
+	language := cfg.GetArtifactLanguage(config.ArtifactReport, reportLanguage)
+	applyToolLanguage(cfg, language)
 	log.Debug("Using language: %s", language)
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(*modelConfig)
+	provider, err := newProvider(*modelConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("LLM provider created successfully")
 
 	// Create git executor
-	workDir, _ := os.Getwd()
-	gitExecutor := git.NewExecutor(workDir)
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	gitExecutor, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
 
 	// Get author - default to current git user
 	author := reportAuthor
@@ -106,17 +122,35 @@ func runReport(cmd *cobra.Command, args []string) error {
 		BackoffMax:  retryConfigPtr.BackoffMax,
 	}
 
+	// Create output redactor
+	redactor, err := newOutputRedactor(cfg, reportRedact)
+	if err != nil {
+		return err
+	}
+
 	// Create stream printer for output
-	printer := ui.NewStreamPrinter(os.Stdout, ui.WithVerbose(debugMode))
+	printer := newStreamPrinter(os.Stdout)
+
+	// A shallow clone may not reach back to the start of the period
+	since, reportCtx := reportSince, reportContext
+	if _, ok := gitExecutor.(*git.DefaultExecutor); ok {
+		var note string
+		since, note = newHistoryCheck(workDir, reportFetch, printer).reportPeriod(ctx, since)
+		if note != "" {
+			reportCtx = strings.TrimSpace(reportCtx + "\n\n" + note)
+		}
+	}
 
 	// Create Report agent
 	reportAgent := agent.NewReportAgent(agent.ReportAgentOptions{
-		Language:    language,
-		GitExecutor: gitExecutor,
-		LLMProvider: provider,
-		Printer:     printer,
-		Debug:       debugMode,
-		RetryConfig: retryConfig,
+		Language:             language,
+		GitExecutor:          gitExecutor,
+		LLMProvider:          provider,
+		Printer:              printer,
+		Debug:                debugMode,
+		RetryConfig:          retryConfig,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		PromptExtension:      cfg.GetPromptExtension("report"),
 	})
 
 	// Print initial indicator
@@ -124,11 +158,12 @@ func runReport(cmd *cobra.Command, args []string) error {
 
 	// Generate report
 	req := agent.ReportRequest{
-		Since:    reportSince,
-		Until:    until,
-		Author:   author,
-		Language: language,
-		Context:  reportContext,
+		Since:       since,
+		Until:       until,
+		Author:      author,
+		Language:    language,
+		Context:     reportCtx,
+		MaxDuration: maxDuration,
 	}
 
 	response, err := reportAgent.GenerateReport(ctx, req)
@@ -136,6 +171,13 @@ func runReport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate report: %w", err)
 	}
 
+	if response.Partial {
+		_ = printer.PrintError(fmt.Sprintf("Partial result (%s)", response.PartialReason))
+	}
+
+	redactor.Report(response)
+	redactor.PrintSummary(printer)
+
 	// Print the generated report
 	err = ui.ShowReport(response, os.Stdout)
 	if err != nil {
@@ -2,37 +2,64 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent"
 	"github.com/huimingz/gitbuddy-go/internal/agent/session"
 	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
 	"github.com/huimingz/gitbuddy-go/internal/git"
 	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
 	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/huimingz/gitbuddy-go/internal/ui/triage"
 	"github.com/spf13/cobra"
 )
 
 var (
-	reviewContext  string
-	reviewLanguage string
-	reviewFiles    string
-	reviewSeverity string
-	reviewFocus    string
-	reviewResume   string
+	reviewContext    string
+	reviewLanguage   string
+	reviewFiles      string
+	reviewSeverity   string
+	reviewFocus      string
+	reviewResume     string
+	reviewResumeLast bool
+	reviewTriage     bool
+	reviewTriageTo   string
+	reviewShowIgn    bool
+	reviewStdin      bool
+	reviewRedact     string
+	reviewNotes      bool
+	reviewCheck      bool
+	reviewFormat     string
+	reviewRange      string
+	reviewCommit     string
+	reviewUnstaged   bool
+	reviewDir        string
+	reviewPostTo     int
+	reviewPR         int
+	reviewForge      string
+)
+
+// Output formats of review
+const (
+	reviewFormatText  = "text"
+	reviewFormatSARIF = "sarif"
 )
 
 var reviewCmd = &cobra.Command{
 	Use:   "review",
-	Short: "Review staged code changes",
-	Long: `Review staged code changes using AI to identify potential issues.
+	Short: "Review code changes",
+	Long: `Review code changes using AI to identify potential issues.
 
 This command will:
-1. Analyze your staged changes (git diff --cached)
+1. Analyze your staged changes (git diff --cached), or what --range,
+   --commit, --unstaged or --dir select
 2. Identify bugs, security issues, performance problems, and style issues
 3. Provide suggestions for improvement
 
@@ -42,7 +69,40 @@ Examples:
   gitbuddy review --files "auth.go,crypto.go"
   gitbuddy review --severity error
   gitbuddy review --focus security,performance
-  gitbuddy review -l zh --focus security`,
+  gitbuddy review -l zh --focus security
+  gitbuddy review --triage
+  gitbuddy review --range main..HEAD
+  gitbuddy review --commit HEAD~1
+  gitbuddy review --unstaged
+  gitbuddy review --dir internal/auth
+  git diff main... | gitbuddy review --stdin
+  gitbuddy review --check
+  gitbuddy review --format sarif > review.sarif
+  gitbuddy review --range origin/main... --post-to-pr 42
+  gitbuddy review --pr 42 --post-to-pr 42
+
+Each run appends its statistics to ` + agent.DefaultMetricsPath + `
+(unless review.disable_metrics is set); see trends with "gitbuddy review stats".
+
+With --check, the review runs without side effects: no statistics, notes,
+sessions or files are written and the agent can't edit anything. The
+command exits with 2 when an error-level issue is found or the result is
+partial, so it can run in hooks and CI.
+
+With --format sarif, the issues are printed as a SARIF 2.1.0 log for GitHub
+code scanning and progress goes to stderr. Issues not tied to a file are
+left out, since code scanning needs a location.
+
+With --pr, the diff of the pull request (merge request on GitLab) is fetched
+from the forge of the origin remote and reviewed instead of the staged
+changes.
+
+With --post-to-pr, the issues are posted to the pull request on the forge of
+the origin remote, each issue commented on its line. Issues on lines outside
+the pull request's diff are listed in the summary, and on GitHub reviews are
+requested from the code owners of the changed files. The forge is --forge,
+forge.type or derived from the remote URL; the token is read from
+forge.token, GITHUB_TOKEN or GH_TOKEN on GitHub and GITLAB_TOKEN on GitLab.`,
 	RunE: runReview,
 }
 
@@ -51,8 +111,24 @@ func init() {
 	reviewCmd.Flags().StringVarP(&reviewLanguage, "language", "l", "", "Output language (en, zh, ja, etc.)")
 	reviewCmd.Flags().StringVar(&reviewFiles, "files", "", "Comma-separated list of files to review (default: all staged files)")
 	reviewCmd.Flags().StringVar(&reviewSeverity, "severity", "", "Minimum severity level to report (error, warning, info)")
-	reviewCmd.Flags().StringVar(&reviewFocus, "focus", "", "Comma-separated focus areas (security, performance, style)")
+	reviewCmd.Flags().StringVar(&reviewFocus, "focus", "", "Comma-separated focus areas (security, performance, style, bugs); asked for in a terminal when omitted")
 	reviewCmd.Flags().StringVar(&reviewResume, "resume", "", "Resume from a previous session (session ID)")
+	reviewCmd.Flags().BoolVar(&reviewResumeLast, "resume-last", false, "Resume the most recent review session that didn't complete, recovering one whose process crashed")
+	reviewCmd.Flags().BoolVar(&reviewTriage, "triage", false, "Interactively triage issues (fix/ignore/defer) after the review")
+	reviewCmd.Flags().StringVar(&reviewTriageTo, "triage-output", agent.DefaultTriagePath, "File to write the triage result to; issues ignored in it are skipped by later reviews")
+	reviewCmd.Flags().BoolVar(&reviewShowIgn, "show-ignored", false, "Report the issues ignored in the triage result as well")
+	reviewCmd.Flags().BoolVar(&reviewStdin, "stdin", false, "Read a unified diff from stdin instead of the staged changes")
+	reviewCmd.Flags().StringVar(&reviewRange, "range", "", "Review the commits of a range instead of the staged changes (e.g. main..HEAD)")
+	reviewCmd.Flags().StringVar(&reviewCommit, "commit", "", "Review a single commit instead of the staged changes")
+	reviewCmd.Flags().BoolVar(&reviewUnstaged, "unstaged", false, "Review the unstaged changes of the working tree instead of the staged changes")
+	reviewCmd.Flags().StringVar(&reviewDir, "dir", "", "Review the current code of a directory instead of changes")
+	reviewCmd.Flags().BoolVar(&reviewNotes, "notes", false, "Record the review summary and token usage as a git note on the reviewed commit, or the last one of --range (default: notes.enabled)")
+	reviewCmd.Flags().BoolVar(&reviewCheck, "check", false, checkFlagUsage)
+	reviewCmd.Flags().StringVar(&reviewFormat, "format", reviewFormatText, "Output format: text or sarif (for GitHub code scanning)")
+	reviewCmd.Flags().IntVar(&reviewPR, "pr", 0, "Review the diff of this pull request (merge request on GitLab) of the origin remote instead of the staged changes")
+	reviewCmd.Flags().IntVar(&reviewPostTo, "post-to-pr", 0, "Post the issues as inline comments on this pull request (merge request on GitLab) of the origin remote")
+	reviewCmd.Flags().StringVar(&reviewForge, "forge", "", "Forge of the origin remote for --pr and --post-to-pr: github or gitlab (default: forge.type, or derived from the remote URL)")
+	reviewCmd.Flags().StringVar(&reviewRedact, "redact", "", "Redaction profile applied to the output (default: redaction.default_profile, \"none\" to disable)")
 
 	rootCmd.AddCommand(reviewCmd)
 }
@@ -68,6 +144,7 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 
 	log.DebugConfig("Configuration", cfg)
+	notifier := ui.NewNotifier(cfg.UINotifyAfter())
 
 	// Get model configuration
 	modelConfig, err := cfg.GetModel(modelName)
@@ -78,7 +155,8 @@ func runReview(cmd *cobra.Command, args []string) error {
 	log.Debug("Using model: %s (provider: %s)", modelName, modelConfig.Provider)
 
 	// Get language
-	language := cfg.GetLanguage(reviewLanguage)
+	language := cfg.GetArtifactLanguage(config.ArtifactReview, reviewLanguage)
+	applyToolLanguage(cfg, language)
 	log.Debug("Using language: %s", language)
 
 	// Get review config
@@ -86,34 +164,111 @@ func runReview(cmd *cobra.Command, args []string) error {
 	log.Debug("Max lines per read: %d", reviewCfg.MaxLinesPerRead)
 
 	// Create LLM provider
-	factory := llm.NewProviderFactory()
-	provider, err := factory.Create(*modelConfig)
+	provider, err := newProvider(*modelConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create LLM provider: %w", err)
 	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
 
 	log.Debug("LLM provider created successfully")
 
-	// Get current working directory
-	workDir, err := os.Getwd()
+	// Work in the repository root, wherever in it gitbuddy was started
+	workDir, err := workspaceDir(true)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return err
+	}
+
+	mode, target, err := reviewScope(workDir)
+	if err != nil {
+		return err
+	}
+	if reviewStdin && reviewTriage {
+		return fmt.Errorf("--triage cannot be used with --stdin (stdin is not a terminal)")
+	}
+	if reviewCheck && reviewTriage {
+		return fmt.Errorf("--triage cannot be used with --check")
+	}
+	if reviewFormat != reviewFormatText && reviewFormat != reviewFormatSARIF {
+		return fmt.Errorf("invalid format: %s (must be text or sarif)", reviewFormat)
+	}
+	if reviewFormat == reviewFormatSARIF && reviewTriage {
+		return fmt.Errorf("--triage cannot be used with --format sarif")
+	}
+	if reviewResume != "" && reviewResumeLast {
+		return fmt.Errorf("--resume cannot be combined with --resume-last")
+	}
+	if reviewPostTo < 0 || reviewPR < 0 {
+		return fmt.Errorf("invalid pull request number: %d", min(reviewPostTo, reviewPR))
+	}
+	if reviewPostTo > 0 && reviewCheck {
+		return fmt.Errorf("--post-to-pr cannot be used with --check")
+	}
+	var prForge forge.Forge
+	var prRepo forge.Repository
+	if reviewPostTo > 0 || reviewPR > 0 {
+		if prForge, prRepo, err = openForge(ctx, cfg.GetForgeConfig(), workDir, reviewForge); err != nil {
+			return err
+		}
+	}
+	if reviewTriage && ui.Accessible() {
+		return fmt.Errorf("--triage uses a full-screen UI that is not available in accessible mode")
 	}
 
 	// Create git executor
-	gitExecutor := git.NewExecutor(workDir)
+	gitExecutor, err := newVCSExecutor(cfg, workDir)
+	if err != nil {
+		return err
+	}
+	if reviewStdin {
+		stdinDiff, err := git.ReadDiff(os.Stdin)
+		if err != nil {
+			return err
+		}
+		gitExecutor = git.NewDiffExecutor(stdinDiff)
+	}
+	if reviewPR > 0 {
+		prDiff, err := prForge.PullRequestDiff(ctx, prRepo, reviewPR)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the diff of pull request #%d: %w", reviewPR, err)
+		}
+		gitExecutor = git.NewDiffExecutor(prDiff)
+	}
+	// A diff from stdin or a pull request is not the staged changes
+	externalDiff := reviewStdin || reviewPR > 0
 
-	// Check if there are staged changes
-	diff, err := gitExecutor.DiffCached(ctx)
+	// Check if there are changes to review
+	scope := agent.ReviewRequest{Mode: mode, Target: target, WorkDir: workDir}
+	diff, err := scope.ReviewedDiff(ctx, gitExecutor)
 	if err != nil {
-		return fmt.Errorf("failed to get staged changes: %w", err)
+		return err
+	}
+	var directoryFiles []string
+	if mode == agent.ReviewModeDirectory {
+		if directoryFiles, err = agent.DirectoryFiles(ctx, workDir, target); err != nil {
+			return err
+		}
 	}
 
-	if diff == "" {
-		fmt.Println("No staged changes found.")
-		fmt.Println("\nTo stage changes, use:")
-		fmt.Println("  git add <file>")
-		fmt.Println("  git add -A")
+	if diff == "" && mode != agent.ReviewModeDirectory {
+		message := noReviewChangesMessage(mode, target)
+		if reviewPR > 0 {
+			message = fmt.Sprintf("No changes found in pull request #%d.", reviewPR)
+		}
+		if reviewFormat == reviewFormatSARIF {
+			// An empty log keeps an upload step of CI working
+			fmt.Fprintln(os.Stderr, message)
+			return printReviewSARIF(&agent.ReviewResponse{})
+		}
+		fmt.Println(message)
+		if mode == agent.ReviewModeStaged && !externalDiff {
+			fmt.Println("\nTo stage changes, use:")
+			fmt.Println("  git add <file>")
+			fmt.Println("  git add -A")
+		}
 		return nil
 	}
 
@@ -124,6 +279,8 @@ func runReview(cmd *cobra.Command, args []string) error {
 		for i := range files {
 			files[i] = strings.TrimSpace(files[i])
 		}
+		// Given relative to where gitbuddy was started
+		files = workspaceFiles(workDir, files)
 	}
 
 	// Parse focus areas
@@ -133,6 +290,8 @@ func runReview(cmd *cobra.Command, args []string) error {
 		for i := range focus {
 			focus[i] = strings.TrimSpace(focus[i])
 		}
+	} else if reviewResume == "" && !reviewResumeLast && !reviewStdin && !reviewCheck && !progressJSON && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		focus = chooseReviewFocus(workDir, os.Stdin, os.Stdout)
 	}
 
 	// Validate severity
@@ -161,21 +320,45 @@ func runReview(cmd *cobra.Command, args []string) error {
 
 	// Create session manager
 	sessionMgr := session.NewManager(sessionConfig.SaveDir)
+	sessionMgr.SetRunInfo(sessionRunInfo(ctx, cmd, modelConfig, workDir, gitExecutor))
 
-	// Create stream printer for output
-	printer := ui.NewStreamPrinter(os.Stdout, ui.WithVerbose(debugMode))
+	severityRules, err := agent.SeverityRulesFromConfig(reviewCfg.SeverityRules, "review.severity_rules")
+	if err != nil {
+		return err
+	}
+	migrationRules, err := agent.SeverityRulesFromConfig(reviewCfg.Migrations.SeverityRules, "review.migrations.severity_rules")
+	if err != nil {
+		return err
+	}
+
+	// Create output redactor
+	redactor, err := newOutputRedactor(cfg, reviewRedact)
+	if err != nil {
+		return err
+	}
+
+	// Create stream printer for output, keeping stdout for the SARIF log
+	printerOut := os.Stdout
+	if reviewFormat == reviewFormatSARIF {
+		printerOut = os.Stderr
+	}
+	printer := newStreamPrinter(printerOut)
 
 	// Create review agent
 	reviewAgent := agent.NewReviewAgent(agent.ReviewAgentOptions{
-		Language:        language,
-		GitExecutor:     gitExecutor,
-		LLMProvider:     provider,
-		Printer:         printer,
-		Debug:           debugMode,
-		WorkDir:         workDir,
-		MaxLinesPerRead: reviewCfg.MaxLinesPerRead,
-		RetryConfig:     retryConfig,
-		SessionManager:  sessionMgr,
+		Language:             language,
+		GitExecutor:          gitExecutor,
+		LLMProvider:          provider,
+		Printer:              printer,
+		Debug:                debugMode,
+		WorkDir:              workDir,
+		MaxLinesPerRead:      reviewCfg.MaxLinesPerRead,
+		RetryConfig:          retryConfig,
+		MaxRepeatedToolCalls: cfg.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         cfg.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      reviewPromptExtension(cfg, workDir, printer),
+		FunctionContextLines: reviewCfg.FunctionContextMaxLines,
+		SessionManager:       sessionMgr,
 	})
 
 	// Setup context with cancellation for Ctrl+C handling
@@ -197,13 +380,18 @@ func runReview(cmd *cobra.Command, args []string) error {
 
 	// Check if resuming from a previous session
 	var sess *session.Session
-	if reviewResume != "" {
-		_ = printer.PrintInfo(fmt.Sprintf("Resuming session: %s", reviewResume))
-
-		loadedSession, err := sessionMgr.Load(reviewResume)
+	if reviewResume != "" || reviewResumeLast {
+		var loadedSession *session.Session
+		var err error
+		if reviewResumeLast {
+			loadedSession, err = sessionMgr.Last("review")
+		} else {
+			loadedSession, err = sessionMgr.Load(reviewResume)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to load session: %w", err)
 		}
+		_ = printer.PrintInfo(fmt.Sprintf("Resuming session: %s", loadedSession.ID))
 
 		sess = loadedSession
 		currentSessionID = sess.ID
@@ -216,8 +404,19 @@ func runReview(cmd *cobra.Command, args []string) error {
 		_ = printer.PrintInfo(fmt.Sprintf("Session ID: %s", currentSessionID))
 	}
 
+	reviewedFiles := files
+	if len(reviewedFiles) == 0 {
+		reviewedFiles = directoryFiles
+		for _, file := range git.DiffFiles(diff) {
+			reviewedFiles = append(reviewedFiles, file.Path)
+		}
+	}
+	owners, ownerConventions := changeOwners(cfg, workDir, reviewedFiles, printer)
+
 	// Perform review
 	req := agent.ReviewRequest{
+		Mode:                  mode,
+		Target:                target,
 		Language:              language,
 		Context:               reviewContext,
 		Files:                 files,
@@ -225,8 +424,16 @@ func runReview(cmd *cobra.Command, args []string) error {
 		Focus:                 focus,
 		WorkDir:               workDir,
 		MaxLines:              reviewCfg.MaxLinesPerRead,
+		MaxDuration:           maxDuration,
 		Session:               sess,
 		PreGeneratedSessionID: currentSessionID, // Pass the pre-generated session ID
+		SeverityRules:         severityRules,
+		LicensePolicy:         reviewLicensePolicy(reviewCfg.License),
+		OwnerConventions:      ownerConventions,
+	}
+	// An external diff is not the staged changes, so there is nothing to compare the API with
+	if !externalDiff {
+		req.APIBase = "HEAD"
 	}
 
 	response, err := reviewAgent.Review(ctx, req)
@@ -248,13 +455,68 @@ func runReview(cmd *cobra.Command, args []string) error {
 			// So we just wait here indefinitely (the handler will call os.Exit)
 			select {} // Block forever - interrupt handler will exit the program
 		}
+		markSessionFailed(sessionMgr, currentSessionID)
+		notifier.Notify("gitbuddy review failed", err.Error())
 		return fmt.Errorf("failed to perform code review: %w", err)
 	}
 
+	// Review schema migrations again with migration-specific prompts
+	if migrationFiles := agent.MigrationFiles(reviewedFiles, reviewCfg.Migrations.Paths); len(migrationFiles) > 0 && !reviewCfg.Migrations.Disabled {
+		_ = printer.PrintThinking(fmt.Sprintf("Reviewing %d migration file(s)...", len(migrationFiles)))
+		migrationReq := req
+		migrationReq.Files = migrationFiles
+		migrationReq.SeverityRules = append(append([]agent.SeverityRule{}, severityRules...), migrationRules...)
+		migrationResponse, err := reviewAgent.ReviewMigrations(ctx, migrationReq)
+		if err != nil {
+			// The general review is still useful
+			_ = printer.PrintError(fmt.Sprintf("Migration review failed: %v", err))
+		} else {
+			response.Merge(migrationResponse, "Migrations")
+		}
+	}
+
+	// Issues ignored in an earlier triage are not reported again
+	previousTriage := loadReviewTriage(workDir, printer)
+	if previousTriage != nil && !reviewShowIgn {
+		if skipped := previousTriage.SkipIgnored(response); skipped > 0 {
+			_ = printer.PrintInfo(fmt.Sprintf("Skipped %d issue(s) ignored in %s (see --show-ignored)", skipped, reviewTriageTo))
+		}
+	}
+
+	if response.Partial {
+		_ = printer.PrintError(fmt.Sprintf("Partial result (%s)", response.PartialReason))
+	}
+
+	redactor.Review(response)
+	redactor.PrintSummary(printer)
+
 	// Print the review results
-	err = ui.ShowReviewResult(response, os.Stdout)
-	if err != nil {
-		return err
+	if reviewFormat == reviewFormatSARIF {
+		if err := printReviewSARIF(response); err != nil {
+			return err
+		}
+	} else {
+		if err := ui.ShowReviewResult(response, os.Stdout); err != nil {
+			return err
+		}
+		if err := showCodeOwners(owners, os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	if reviewPostTo > 0 {
+		if err := postReview(ctx, prForge, prRepo, reviewPostTo, response, owners, printer); err != nil {
+			return fmt.Errorf("failed to post the review to pull request #%d: %w", reviewPostTo, err)
+		}
+	}
+
+	if reviewTriage && len(response.Issues) > 0 {
+		notifier.Notify("gitbuddy review needs your feedback", fmt.Sprintf("%d issue(s) to triage", len(response.Issues)))
+		if err := runReviewTriage(ctx, response, previousTriage, diff, workDir, printer); err != nil {
+			return err
+		}
+	} else {
+		notifier.Notify("gitbuddy review finished", fmt.Sprintf("%d issue(s) found", len(response.Issues)))
 	}
 
 	// Print stats
@@ -268,5 +530,187 @@ func runReview(cmd *cobra.Command, args []string) error {
 	}
 	_ = printer.PrintStats(stats)
 
+	// Record per-run statistics for `gitbuddy review stats`
+	metrics := agent.NewReviewMetrics(response, len(reviewedFiles))
+	metrics.Timestamp = endTime
+	metrics.Model = servedModel(provider)
+	if !reviewCheck && !reviewCfg.DisableMetrics {
+		if err := agent.RecordReviewMetrics(workDir, metrics); err != nil {
+			_ = printer.PrintError(fmt.Sprintf("Failed to record review metrics: %v", err))
+		}
+	}
+
+	// Only reviewed commits get a note: staged changes aren't committed yet,
+	// and an external diff has no commit at all
+	noteCommit := reviewNoteCommit(mode, target)
+	if noteCommit == "" && cmd.Flags().Changed("notes") && notesEnabled(cmd, cfg, reviewNotes) {
+		_ = printer.PrintInfo("No git note recorded: only reviews of --commit or --range are attached to a commit")
+	}
+	if noteCommit != "" && notesEnabled(cmd, cfg, reviewNotes) {
+		recordNote(ctx, workDir, noteCommit, printer, git.NoteEntry{
+			Kind:             "review",
+			CreatedAt:        endTime,
+			Model:            servedModel(provider),
+			PromptTokens:     response.PromptTokens,
+			CompletionTokens: response.CompletionTokens,
+			TotalTokens:      response.TotalTokens,
+			Summary:          fmt.Sprintf("%d issue(s) found\n\n%s", len(response.Issues), response.Summary),
+			Reference:        response.SessionID,
+		})
+	}
+
+	if reviewCheck {
+		return checkReview(response)
+	}
 	return nil
 }
+
+// reviewScope returns the review mode and its target selected by --range,
+// --commit, --unstaged and --dir, which exclude each other, --stdin and --pr
+func reviewScope(workDir string) (mode, target string, err error) {
+	mode = agent.ReviewModeStaged
+	var selected []string
+	if reviewStdin {
+		selected = append(selected, "--stdin")
+	}
+	if reviewPR > 0 {
+		selected = append(selected, "--pr")
+	}
+	if reviewRange != "" {
+		if !strings.Contains(reviewRange, "..") {
+			return "", "", fmt.Errorf("invalid range: %s (use two revisions like main..HEAD, or --commit for a single commit)", reviewRange)
+		}
+		mode, target = agent.ReviewModeRange, reviewRange
+		selected = append(selected, "--range")
+	}
+	if reviewCommit != "" {
+		mode, target = agent.ReviewModeCommit, reviewCommit
+		selected = append(selected, "--commit")
+	}
+	if reviewUnstaged {
+		mode = agent.ReviewModeUnstaged
+		selected = append(selected, "--unstaged")
+	}
+	if reviewDir != "" {
+		// Given relative to where gitbuddy was started
+		mode, target = agent.ReviewModeDirectory, workspaceFiles(workDir, []string{reviewDir})[0]
+		selected = append(selected, "--dir")
+	}
+	if len(selected) > 1 {
+		return "", "", fmt.Errorf("only one of %s can be used", strings.Join(selected, ", "))
+	}
+	return mode, target, nil
+}
+
+// reviewNoteCommit returns the commit the note of a review is attached to:
+// the reviewed commit, or the end of the reviewed range. Other reviews are of
+// changes that have no commit yet, so it returns "".
+func reviewNoteCommit(mode, target string) string {
+	switch mode {
+	case agent.ReviewModeCommit:
+		return target
+	case agent.ReviewModeRange:
+		_, head, _ := strings.Cut(strings.Replace(target, "...", "..", 1), "..")
+		if head == "" {
+			return "HEAD"
+		}
+		return head
+	}
+	return ""
+}
+
+// noReviewChangesMessage tells that the review mode found no changes
+func noReviewChangesMessage(mode, target string) string {
+	switch mode {
+	case agent.ReviewModeRange, agent.ReviewModeCommit:
+		return fmt.Sprintf("No changes found in %s.", target)
+	case agent.ReviewModeUnstaged:
+		return "No unstaged changes found."
+	}
+	return "No staged changes found."
+}
+
+// printReviewSARIF prints the review as a SARIF log
+func printReviewSARIF(response *agent.ReviewResponse) error {
+	data, err := response.SARIF(version)
+	if err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// checkReview fails a --check review with a partial result or issues of
+// error severity
+func checkReview(response *agent.ReviewResponse) error {
+	if response.Partial {
+		return checkFailed(fmt.Errorf("partial result (%s)", response.PartialReason))
+	}
+	found := 0
+	for _, issue := range response.Issues {
+		if issue.Severity == agent.SeverityError {
+			found++
+		}
+	}
+	if found > 0 {
+		return checkFailed(fmt.Errorf("%d error-level issue(s) found", found))
+	}
+	return nil
+}
+
+// reviewLicensePolicy converts the configured license checks
+func reviewLicensePolicy(cfg *config.LicenseConfig) *agent.LicensePolicy {
+	if cfg == nil {
+		return nil
+	}
+	return &agent.LicensePolicy{Header: cfg.Header, HeaderPaths: cfg.HeaderPaths, Disallowed: cfg.Disallowed}
+}
+
+// runReviewTriage shows the triage UI for the review issues and saves the
+// result, keeping the issues ignored in previous, if any
+func runReviewTriage(ctx context.Context, response *agent.ReviewResponse, previous *agent.TriageResult, diff, workDir string, printer *ui.StreamPrinter) error {
+	result, err := triage.Run(ctx, agent.NewTriageResult(response), triage.Options{
+		Diff:    diff,
+		WorkDir: workDir,
+	})
+	if err != nil {
+		if errors.Is(err, triage.ErrAborted) {
+			_ = printer.PrintInfo("Triage aborted, no result saved")
+			return nil
+		}
+		return err
+	}
+
+	if previous != nil {
+		result.KeepIgnored(previous)
+	}
+	if err := agent.SaveTriageResult(reviewTriagePath(workDir), result); err != nil {
+		return err
+	}
+
+	counts := result.Counts()
+	_ = printer.PrintSuccess(fmt.Sprintf("Triage saved to %s (%d fix, %d ignore, %d defer, %d pending)",
+		reviewTriageTo, counts[agent.TriageFix], counts[agent.TriageIgnore], counts[agent.TriageDefer], counts[agent.TriagePending]))
+	return nil
+}
+
+// loadReviewTriage reads the triage result of an earlier review, or returns
+// nil when there is none or it can't be read
+func loadReviewTriage(workDir string, printer *ui.StreamPrinter) *agent.TriageResult {
+	result, err := agent.LoadTriageResult(reviewTriagePath(workDir))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			_ = printer.PrintError(fmt.Sprintf("Ignoring the triage result: %v", err))
+		}
+		return nil
+	}
+	return result
+}
+
+// reviewTriagePath returns the path of --triage-output
+func reviewTriagePath(workDir string) string {
+	if filepath.IsAbs(reviewTriageTo) {
+		return reviewTriageTo
+	}
+	return filepath.Join(workDir, reviewTriageTo)
+}
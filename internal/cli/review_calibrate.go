@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewCalibrateSince   string
+	reviewCalibrateLimit   int
+	reviewCalibrateRemote  string
+	reviewCalibrateAPIURL  string
+	reviewCalibrateOutput  string
+	reviewCalibrateIfStale bool
+	reviewCalibrateDryRun  bool
+)
+
+var reviewCalibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Learn the team's review preferences from past pull request reviews",
+	Long: `Fetch the review comments people left on the repository's pull requests and
+distill what the team actually flags into guidelines for the AI review.
+
+The guidelines are saved to ` + agent.DefaultCalibrationPath + ` and appended to the
+review prompt from then on, after prompt_extensions.review. Commit the file to
+share it with the team, and edit it by hand if a guideline is off.
+
+Comments are read from GitHub (or GitHub Enterprise, found from the remote
+URL) with the token in GITHUB_TOKEN or GH_TOKEN. Bot comments are skipped.
+
+Calibrations older than 30 days are reported by review as due for a refresh;
+--if-stale only refreshes those, for a monthly cron job or CI schedule.
+
+Examples:
+  gitbuddy review calibrate
+  gitbuddy review calibrate --since 90d --limit 300
+  gitbuddy review calibrate --if-stale
+  gitbuddy review calibrate --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runReviewCalibrate,
+}
+
+func init() {
+	reviewCalibrateCmd.Flags().StringVar(&reviewCalibrateSince, "since", "180d", "Only comments created since this date or age (e.g. 2024-01-01, 90d)")
+	reviewCalibrateCmd.Flags().IntVar(&reviewCalibrateLimit, "limit", 500, "Maximum number of comments to read, newest first")
+	reviewCalibrateCmd.Flags().StringVar(&reviewCalibrateRemote, "remote", "origin", "Git remote of the repository on GitHub")
+	reviewCalibrateCmd.Flags().StringVar(&reviewCalibrateAPIURL, "api-url", "", "GitHub API URL (default: derived from the remote URL)")
+	reviewCalibrateCmd.Flags().StringVarP(&reviewCalibrateOutput, "output", "o", agent.DefaultCalibrationPath, "File to save the calibration to")
+	reviewCalibrateCmd.Flags().BoolVar(&reviewCalibrateIfStale, "if-stale", false, "Only refresh a calibration older than 30 days")
+	reviewCalibrateCmd.Flags().BoolVar(&reviewCalibrateDryRun, "dry-run", false, "Print the guidelines without saving them")
+	reviewCmd.AddCommand(reviewCalibrateCmd)
+}
+
+func runReviewCalibrate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	if reviewCalibrateIfStale {
+		if existing, err := agent.LoadReviewCalibration(reviewCalibrateOutput); err == nil && !existing.Stale(now) {
+			fmt.Printf("Review calibration in %s is up to date.\n", reviewCalibrateOutput)
+			return nil
+		}
+	}
+	since, err := parseSessionTime(reviewCalibrateSince, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	modelConfig, err := cfg.GetModel(modelName)
+	if err != nil {
+		return fmt.Errorf("failed to get model config: %w", err)
+	}
+
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	repo, err := remoteRepository(ctx, workDir, reviewCalibrateRemote)
+	if err != nil {
+		return err
+	}
+	forgeCfg := cfg.GetForgeConfig()
+	apiURL := reviewCalibrateAPIURL
+	if apiURL == "" {
+		apiURL = forgeAPIURL(forgeCfg, repo)
+	}
+
+	printer := newStreamPrinter(os.Stdout)
+	_ = printer.PrintProgress(fmt.Sprintf("Fetching review comments of %s since %s...", repo, since.Format("2006-01-02")))
+	comments, err := forge.NewGitHub(apiURL, forgeToken(forgeCfg, forge.KindGitHub)).ReviewComments(ctx, repo, since, reviewCalibrateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	if len(comments) == 0 {
+		return errors.New("no review comments found; widen --since or check the token's access to the repository")
+	}
+
+	provider, err := newProvider(*modelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	provider = wrapProvider(cfg, provider)
+	provider, err = withTranscript(cmd, provider)
+	if err != nil {
+		return err
+	}
+
+	reviewerComments := make([]agent.ReviewerComment, len(comments))
+	for i, c := range comments {
+		reviewerComments[i] = agent.ReviewerComment{Author: c.Author, Path: c.Path, Body: c.Body}
+	}
+	retryConfigPtr := cfg.GetRetryConfig()
+	calibrationAgent := agent.NewReviewCalibrationAgent(agent.ReviewCalibrationAgentOptions{
+		LLMProvider: provider,
+		Printer:     printer,
+		RetryConfig: llm.RetryConfig{
+			Enabled:     retryConfigPtr.Enabled,
+			MaxAttempts: retryConfigPtr.MaxAttempts,
+			BackoffBase: retryConfigPtr.BackoffBase,
+			BackoffMax:  retryConfigPtr.BackoffMax,
+		},
+	})
+	response, err := calibrationAgent.Calibrate(ctx, reviewerComments)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+
+	if reviewCalibrateDryRun {
+		return nil
+	}
+	calibration := &agent.ReviewCalibration{
+		GeneratedAt: now,
+		Source:      repo.String(),
+		Comments:    response.Comments,
+		Guidelines:  response.Guidelines,
+	}
+	if err := calibration.Save(reviewCalibrateOutput); err != nil {
+		return err
+	}
+	_ = printer.PrintSuccess(fmt.Sprintf("Review calibration from %d comments saved to %s", response.Comments, reviewCalibrateOutput))
+	return nil
+}
+
+// reviewPromptExtension returns the review prompt extension of the config
+// followed by the guidelines of the repository's review calibration, and
+// tells the user when the calibration is due for a refresh
+func reviewPromptExtension(cfg *config.Config, workDir string, printer *ui.StreamPrinter) string {
+	extension := cfg.GetPromptExtension("review")
+	calibration, err := agent.LoadReviewCalibration(filepath.Join(workDir, agent.DefaultCalibrationPath))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("Failed to read review calibration: %v", err)
+		}
+		return extension
+	}
+	if calibration.Stale(time.Now()) && printer != nil {
+		days := int(time.Since(calibration.GeneratedAt).Hours() / 24)
+		_ = printer.PrintInfo(fmt.Sprintf("Review calibration is %d days old; refresh it with: gitbuddy review calibrate", days))
+	}
+	return strings.TrimSpace(strings.Join([]string{extension, calibration.Guidelines}, "\n\n"))
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// repoStatePath holds choices remembered per repository, relative to the repository root
+const repoStatePath = ".gitbuddy/state.json"
+
+// reviewFocusAreas are offered when review runs in a terminal without --focus
+var reviewFocusAreas = []string{"security", "performance", "style", "bugs"}
+
+// defaultReviewFocus is marked the first time the focus is asked for in a repository
+var defaultReviewFocus = []string{"security", "bugs"}
+
+// repoState is the content of the repository state file
+type repoState struct {
+	ReviewFocus []string `json:"review_focus,omitempty"`
+}
+
+// loadRepoState reads the repository state; a missing or unreadable file is an empty state
+func loadRepoState(workDir string) *repoState {
+	state := &repoState{}
+	data, err := os.ReadFile(filepath.Join(workDir, repoStatePath))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("Failed to read repository state: %v", err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		log.Debug("Failed to parse repository state: %v", err)
+	}
+	return state
+}
+
+// save writes the repository state, creating its directory
+func (s *repoState) save(workDir string) error {
+	if err := sideeffect.Check("save repository state"); err != nil {
+		return err
+	}
+	path := filepath.Join(workDir, repoStatePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode repository state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write repository state: %w", err)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// chooseReviewFocus asks which areas to review, marking the areas chosen last
+// time in the repository, and remembers the answer. Choosing every area, or
+// failing to ask, returns no focus, which reviews everything.
+func chooseReviewFocus(workDir string, input io.Reader, output io.Writer) []string {
+	state := loadRepoState(workDir)
+	marked := state.ReviewFocus
+	if len(marked) == 0 {
+		marked = defaultReviewFocus
+	}
+	defaults := make([]bool, len(reviewFocusAreas))
+	for i, area := range reviewFocusAreas {
+		for _, m := range marked {
+			if m == area {
+				defaults[i] = true
+			}
+		}
+	}
+
+	selected, err := ui.SelectMultiple("\nWhat should the review focus on?", reviewFocusAreas, defaults, input, output)
+	if err != nil {
+		log.Debug("Failed to ask for the review focus: %v", err)
+		return nil
+	}
+
+	var focus []string
+	for i, ok := range selected {
+		if ok {
+			focus = append(focus, reviewFocusAreas[i])
+		}
+	}
+	if len(focus) == 0 {
+		return nil
+	}
+
+	state.ReviewFocus = focus
+	if err := state.save(workDir); err != nil {
+		log.Debug("Failed to remember the review focus: %v", err)
+	}
+	if len(focus) == len(reviewFocusAreas) {
+		return nil
+	}
+	return focus
+}
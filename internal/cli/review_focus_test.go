@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseReviewFocus_RemembersChoice(t *testing.T) {
+	dir := t.TempDir()
+
+	var output bytes.Buffer
+	assert.Equal(t, []string{"security", "bugs"}, chooseReviewFocus(dir, strings.NewReader("\n"), &output))
+	assert.Contains(t, output.String(), "[x] 1) security")
+
+	assert.Equal(t, []string{"performance"}, chooseReviewFocus(dir, strings.NewReader("2\n"), &output))
+	assert.Equal(t, []string{"performance"}, loadRepoState(dir).ReviewFocus)
+
+	output.Reset()
+	assert.Equal(t, []string{"performance"}, chooseReviewFocus(dir, strings.NewReader("\n"), &output))
+	assert.Contains(t, output.String(), "[x] 2) performance")
+	assert.Contains(t, output.String(), "[ ] 1) security")
+}
+
+func TestChooseReviewFocus_AllReviewsEverything(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, chooseReviewFocus(dir, strings.NewReader("all\n"), &bytes.Buffer{}))
+	assert.Equal(t, reviewFocusAreas, loadRepoState(dir).ReviewFocus)
+	assert.Nil(t, chooseReviewFocus(dir, strings.NewReader(""), &bytes.Buffer{}), "closed input")
+}
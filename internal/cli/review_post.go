@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/codeowners"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// postReview posts the issues of response as a review of pull request number
+// of repo on f, and requests reviews from the code owners of the changed
+// files when f supports it. A failed request only loses the reviewers, so the
+// error is printed.
+func postReview(ctx context.Context, f forge.Forge, repo forge.Repository, number int, response *agent.ReviewResponse, owners []codeowners.Ownership, printer *ui.StreamPrinter) error {
+	body, comments := reviewComments(response)
+	review, inline, err := f.PostReview(ctx, repo, number, body, comments)
+	if err != nil {
+		return err
+	}
+	_ = printer.PrintSuccess(fmt.Sprintf("Posted the review to pull request #%d with %d inline comment(s): %s", number, inline, review.URL))
+
+	requester, ok := f.(forge.ReviewRequester)
+	if !ok || len(owners) == 0 {
+		return nil
+	}
+	names := make([]string, len(owners))
+	for i, owner := range owners {
+		names[i] = owner.Owner
+	}
+	requested, err := requester.RequestReviewers(ctx, repo, number, names)
+	if err != nil {
+		_ = printer.PrintError(fmt.Sprintf("Failed to request reviews from the code owners: %v", err))
+	} else if len(requested) > 0 {
+		_ = printer.PrintInfo(fmt.Sprintf("Requested reviews from %s", strings.Join(requested, ", ")))
+	}
+	return nil
+}
+
+// reviewComments returns the summary and the inline comments of a review of
+// response on a pull request: issues with a file are commented on their line,
+// the others are listed in the summary
+func reviewComments(response *agent.ReviewResponse) (string, []forge.InlineComment) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**gitbuddy review**: %d issue(s) found", len(response.Issues))
+	if response.Partial {
+		fmt.Fprintf(&b, " (partial result: %s)", response.PartialReason)
+	}
+	if summary := strings.TrimSpace(response.Summary); summary != "" {
+		b.WriteString("\n\n" + summary)
+	}
+
+	var comments []forge.InlineComment
+	var general []string
+	for _, issue := range response.Issues {
+		if issue.File == "" {
+			general = append(general, reviewCommentBody(issue))
+			continue
+		}
+		comments = append(comments, forge.InlineComment{
+			Path: filepath.ToSlash(strings.TrimPrefix(issue.File, "./")),
+			Line: issue.Line,
+			Body: reviewCommentBody(issue),
+		})
+	}
+	if len(general) > 0 {
+		b.WriteString("\n\n" + strings.Join(general, "\n\n---\n\n"))
+	}
+	return b.String(), comments
+}
+
+// reviewCommentBody formats issue as a Markdown comment
+func reviewCommentBody(issue agent.ReviewIssue) string {
+	body := fmt.Sprintf("**[%s] %s** (%s)", issue.Severity, issue.Title, issue.Category)
+	if issue.Description != "" {
+		body += "\n\n" + issue.Description
+	}
+	if issue.Suggestion != "" {
+		body += "\n\n**Suggestion:** " + issue.Suggestion
+	}
+	return body
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/codeowners"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/forge"
+	"github.com/huimingz/gitbuddy-go/internal/testutil"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewComments(t *testing.T) {
+	response := &agent.ReviewResponse{
+		Summary: "The login flow needs work.",
+		Issues: []agent.ReviewIssue{
+			{Severity: agent.SeverityError, Category: "security", File: "./auth/login.go", Line: 42, Title: "SQL injection", Description: "The query concatenates user input.", Suggestion: "Use a prepared statement."},
+			{Severity: agent.SeverityInfo, Category: "suggestion", Title: "Add a changelog entry"},
+		},
+	}
+
+	body, comments := reviewComments(response)
+	assert.Equal(t, "**gitbuddy review**: 2 issue(s) found\n\nThe login flow needs work.\n\n**[info] Add a changelog entry** (suggestion)", body)
+	assert.Equal(t, []forge.InlineComment{{
+		Path: "auth/login.go",
+		Line: 42,
+		Body: "**[error] SQL injection** (security)\n\nThe query concatenates user input.\n\n**Suggestion:** Use a prepared statement.",
+	}}, comments)
+
+	body, comments = reviewComments(&agent.ReviewResponse{Partial: true, PartialReason: "time budget exceeded"})
+	assert.Equal(t, "**gitbuddy review**: 0 issue(s) found (partial result: time budget exceeded)", body)
+	assert.Empty(t, comments)
+}
+
+func TestPostReview(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/acme/api/pulls/7/files":
+			fmt.Fprint(w, `[{"filename":"auth/limit.go","patch":"@@ -0,0 +1,3 @@\n+package auth\n+\n+const maxAttempts = 5"}]`)
+		case "/repos/acme/api/pulls/7/reviews":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			fmt.Fprint(w, `{"id":1,"html_url":"https://github.com/acme/api/pull/7#pullrequestreview-1"}`)
+		case "/repos/acme/api/pulls/7":
+			fmt.Fprint(w, `{"number":7,"user":{"login":"alice"}}`)
+		case "/repos/acme/api/pulls/7/requested_reviewers":
+			http.Error(w, `{"message":"Reviews may only be requested from collaborators"}`, http.StatusUnprocessableEntity)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := testutil.NewSampleRepo(t)
+	repo.Git("remote", "add", "origin", "git@github.com:acme/api.git")
+	response := &agent.ReviewResponse{Issues: []agent.ReviewIssue{
+		{Severity: agent.SeverityWarning, Category: "style", File: "auth/limit.go", Line: 3, Title: "Undocumented constant"},
+	}}
+	owners := []codeowners.Ownership{{Owner: "@bob", Files: []string{"auth/limit.go"}}}
+
+	f, forgeRepo, err := openForge(context.Background(), &config.ForgeConfig{Token: "secret", APIURL: server.URL}, repo.Dir, "")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = postReview(context.Background(), f, forgeRepo, 7, response, owners, ui.NewStreamPrinter(&out))
+	require.NoError(t, err, "a failed review request only loses the reviewers")
+	assert.Len(t, got["comments"], 1)
+	assert.Contains(t, out.String(), "Posted the review to pull request #7 with 1 inline comment(s): https://github.com/acme/api/pull/7#pullrequestreview-1")
+	assert.Contains(t, out.String(), "Failed to request reviews from the code owners")
+}
+
+func TestOpenForge(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITLAB_TOKEN", "gl-secret")
+
+	repo := testutil.NewGitRepo(t)
+	_, _, err := openForge(context.Background(), nil, repo.Dir, "")
+	assert.EqualError(t, err, `remote "origin" is not configured`)
+
+	repo.Git("remote", "add", "origin", "git@gitlab.example.com:acme/platform/api.git")
+	f, forgeRepo, err := openForge(context.Background(), nil, repo.Dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "GitLab", f.Name())
+	assert.Equal(t, "acme/platform/api", forgeRepo.String())
+
+	_, _, err = openForge(context.Background(), &config.ForgeConfig{Type: forge.KindGitHub}, repo.Dir, "")
+	assert.EqualError(t, err, "set GITHUB_TOKEN, GH_TOKEN or forge.token to a token of gitlab.example.com")
+
+	f, _, err = openForge(context.Background(), &config.ForgeConfig{Type: forge.KindGitHub, Token: "secret"}, repo.Dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "GitHub", f.Name())
+
+	_, _, err = openForge(context.Background(), nil, repo.Dir, "bitbucket")
+	assert.EqualError(t, err, "invalid forge: bitbucket (valid: github, gitlab)")
+}
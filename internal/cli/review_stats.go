@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewStatsLast int
+	reviewStatsFile string
+)
+
+var reviewStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show review trends over time",
+	Long: `Show how review results evolve across runs.
+
+Every review, also through "gitbuddy rpc", appends its statistics (issue
+counts by severity and category, files reviewed and tokens) to
+` + agent.DefaultMetricsPath + ` unless review.disable_metrics is set. This
+command charts them in the terminal to show whether code health is improving.
+
+Examples:
+  gitbuddy review stats
+  gitbuddy review stats --last 50`,
+	Args: cobra.NoArgs,
+	RunE: runReviewStats,
+}
+
+func init() {
+	reviewStatsCmd.Flags().IntVarP(&reviewStatsLast, "last", "n", 20, "Number of most recent runs to show (0 = all)")
+	reviewStatsCmd.Flags().StringVar(&reviewStatsFile, "file", agent.DefaultMetricsPath, "Metrics file to read")
+	reviewCmd.AddCommand(reviewStatsCmd)
+}
+
+func runReviewStats(cmd *cobra.Command, args []string) error {
+	runs, err := agent.LoadReviewMetrics(reviewStatsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No review metrics found in %s. Run `gitbuddy review` to start recording.\n", reviewStatsFile)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if reviewStatsLast > 0 && len(runs) > reviewStatsLast {
+		runs = runs[len(runs)-reviewStatsLast:]
+	}
+	printReviewTrend(os.Stdout, runs)
+	return nil
+}
+
+const trendBarWidth = 30
+
+// printReviewTrend renders one bar per run followed by sparklines and totals
+func printReviewTrend(w io.Writer, runs []agent.ReviewMetrics) {
+	if len(runs) == 0 {
+		fmt.Fprintln(w, "No review runs recorded yet.")
+		return
+	}
+
+	first, last := runs[0].Timestamp, runs[len(runs)-1].Timestamp
+	fmt.Fprintf(w, "📈 Review trends (%d runs, %s → %s)\n\n", len(runs), first.Format("2006-01-02"), last.Format("2006-01-02"))
+
+	maxIssues := 0
+	for _, run := range runs {
+		if run.Issues > maxIssues {
+			maxIssues = run.Issues
+		}
+	}
+
+	fmt.Fprintf(w, "%-16s  %-*s  %5s %5s %5s %5s %8s\n", "DATE", trendBarWidth+5, "ISSUES", "ERR", "WARN", "INFO", "FILES", "TOKENS")
+	for _, run := range runs {
+		bar := ""
+		if maxIssues > 0 {
+			bar = strings.Repeat("█", run.Issues*trendBarWidth/maxIssues)
+		}
+		if bar == "" && run.Issues > 0 {
+			bar = "▏"
+		}
+		label := fmt.Sprintf("%s %d", bar, run.Issues)
+		if run.Partial {
+			label += "*"
+		}
+		fmt.Fprintf(w, "%-16s  %s%s  %5d %5d %5d %5d %8d\n",
+			run.Timestamp.Format("2006-01-02 15:04"),
+			label, strings.Repeat(" ", max(0, trendBarWidth+5-len([]rune(label)))),
+			run.BySeverity[agent.SeverityError], run.BySeverity[agent.SeverityWarning], run.BySeverity[agent.SeverityInfo],
+			run.FilesReviewed, run.TotalTokens)
+	}
+
+	issuesPerFile := make([]float64, len(runs))
+	errorsPerRun := make([]float64, len(runs))
+	for i, run := range runs {
+		issuesPerFile[i] = issuesPerFileOf(run)
+		errorsPerRun[i] = float64(run.BySeverity[agent.SeverityError])
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Issues per file: %s  %s\n", sparkline(issuesPerFile), describeTrend(issuesPerFile))
+	fmt.Fprintf(w, "Errors:          %s  %s\n", sparkline(errorsPerRun), describeTrend(errorsPerRun))
+
+	categories := make(map[string]int)
+	for _, run := range runs {
+		for category, count := range run.ByCategory {
+			categories[category] += count
+		}
+	}
+	if len(categories) > 0 {
+		names := make([]string, 0, len(categories))
+		for name := range categories {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if categories[names[i]] != categories[names[j]] {
+				return categories[names[i]] > categories[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s %d", name, categories[name])
+		}
+		fmt.Fprintf(w, "By category:     %s\n", strings.Join(parts, ", "))
+	}
+	if recurring, total := recurringIssues(runs); total > 0 {
+		fmt.Fprintf(w, "Recurring:       %d of %d issues in the latest run were found before\n", recurring, total)
+	}
+
+	for _, run := range runs {
+		if run.Partial {
+			fmt.Fprintln(w, "\n* partial review (salvaged after a failure)")
+			break
+		}
+	}
+}
+
+// recurringIssues counts the issues of the latest run whose fingerprint was
+// seen in an earlier run; total is 0 when the latest run has no fingerprints
+func recurringIssues(runs []agent.ReviewMetrics) (recurring, total int) {
+	if len(runs) < 2 {
+		return 0, 0
+	}
+	seen := make(map[string]bool)
+	for _, run := range runs[:len(runs)-1] {
+		for _, fingerprint := range run.Fingerprints {
+			seen[fingerprint] = true
+		}
+	}
+	for _, fingerprint := range runs[len(runs)-1].Fingerprints {
+		if seen[fingerprint] {
+			recurring++
+		}
+	}
+	return recurring, len(runs[len(runs)-1].Fingerprints)
+}
+
+// issuesPerFileOf normalizes the issue count by the size of the review
+func issuesPerFileOf(run agent.ReviewMetrics) float64 {
+	if run.FilesReviewed <= 0 {
+		return float64(run.Issues)
+	}
+	return float64(run.Issues) / float64(run.FilesReviewed)
+}
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as a single line of block characters
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkTicks)-1))
+		}
+		sb.WriteRune(sparkTicks[idx])
+	}
+	return sb.String()
+}
+
+// describeTrend compares the average of the older and newer half of the runs.
+// Lower values are better, so a decrease of more than 10% is an improvement.
+func describeTrend(values []float64) string {
+	if len(values) < 2 {
+		return "(not enough runs for a trend)"
+	}
+
+	half := len(values) / 2
+	older := average(values[:half])
+	newer := average(values[len(values)-half:])
+
+	switch {
+	case newer < older*0.9:
+		return fmt.Sprintf("improving (%.1f → %.1f)", older, newer)
+	case newer > older*1.1:
+		return fmt.Sprintf("worsening (%.1f → %.1f)", older, newer)
+	default:
+		return fmt.Sprintf("stable (%.1f → %.1f)", older, newer)
+	}
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
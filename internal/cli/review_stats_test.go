@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 0.5, 1}))
+	assert.Equal(t, "▁▁", sparkline([]float64{3, 3}))
+	assert.Equal(t, "", sparkline(nil))
+}
+
+func TestDescribeTrend(t *testing.T) {
+	assert.Contains(t, describeTrend([]float64{4, 4, 1, 1}), "improving")
+	assert.Contains(t, describeTrend([]float64{1, 1, 4, 4}), "worsening")
+	assert.Contains(t, describeTrend([]float64{2, 3, 2}), "stable")
+	assert.Contains(t, describeTrend([]float64{2}), "not enough runs")
+}
+
+func TestPrintReviewTrend(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	runs := []agent.ReviewMetrics{
+		{Timestamp: start, FilesReviewed: 2, Issues: 6, BySeverity: map[string]int{"error": 2, "warning": 4}, ByCategory: map[string]int{"bug": 4, "security": 2}},
+		{Timestamp: start.AddDate(0, 0, 7), FilesReviewed: 3, Issues: 1, BySeverity: map[string]int{"info": 1}, ByCategory: map[string]int{"style": 1}, Partial: true},
+	}
+
+	var buf bytes.Buffer
+	printReviewTrend(&buf, runs)
+	out := buf.String()
+
+	assert.Contains(t, out, "2 runs, 2024-01-01 → 2024-01-08")
+	assert.Contains(t, out, "2024-01-01 09:00")
+	assert.Contains(t, out, "improving (3.0 → 0.3)")
+	assert.Contains(t, out, "By category:     bug 4, security 2, style 1")
+	assert.Contains(t, out, "* partial review")
+	assert.NotContains(t, out, "Recurring:")
+
+	runs[0].Fingerprints = []string{"a", "b", "c"}
+	runs[1].Fingerprints = []string{"b", "d"}
+	buf.Reset()
+	printReviewTrend(&buf, runs)
+	assert.Contains(t, buf.String(), "Recurring:       1 of 2 issues in the latest run were found before")
+}
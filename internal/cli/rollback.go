@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/backup"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackList   bool
+	rollbackDryRun bool
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [session-id]",
+	Short: "Undo all file edits made by an agent session",
+	Long: `Restore every file an agent session modified to its state before the session
+started editing, and remove files the session created.
+
+Before a session first modifies a file, GitBuddy snapshots it under
+.gitbuddy-backups/snapshots/<session-id>. Rollback restores the whole
+snapshot at once: if any file cannot be restored, nothing is changed.
+
+Examples:
+  gitbuddy rollback --list
+  gitbuddy rollback chat-20240101-120000-abc123 --dry-run
+  gitbuddy rollback chat-20240101-120000-abc123`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRollback,
+}
+
+func init() {
+	rollbackCmd.Flags().BoolVar(&rollbackList, "list", false, "List sessions with snapshots")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "Show the files that would be restored without changing them")
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	workDir, err := workspaceDir(false)
+	if err != nil {
+		return err
+	}
+	mgr := backup.NewBackupManager(workDir)
+
+	if rollbackList {
+		return listSnapshots(mgr)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("session ID is required (use --list to see available snapshots)")
+	}
+	sessionID := args[0]
+
+	if rollbackDryRun {
+		snapshot, err := mgr.LoadSnapshot(sessionID)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no snapshot found for session %s", sessionID)
+		} else if err != nil {
+			return fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		fmt.Printf("Rolling back %s would:\n", sessionID)
+		printSnapshotFiles(snapshot)
+		return nil
+	}
+
+	snapshot, err := mgr.Rollback(context.Background(), sessionID)
+	if err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	fmt.Printf("✓ Rolled back session %s:\n", sessionID)
+	printSnapshotFiles(snapshot)
+	return nil
+}
+
+func listSnapshots(mgr *backup.BackupManager) error {
+	snapshots, err := mgr.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No session snapshots found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION ID\tCREATED\tFILES\tROLLED BACK")
+	fmt.Fprintln(w, "----------\t-------\t-----\t-----------")
+	for _, s := range snapshots {
+		rolledBack := "-"
+		if s.RolledBackAt != nil {
+			rolledBack = s.RolledBackAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", s.SessionID, s.CreatedAt.Format("2006-01-02 15:04"), len(s.Files), rolledBack)
+	}
+	w.Flush()
+	return nil
+}
+
+func printSnapshotFiles(snapshot *backup.Snapshot) {
+	for _, entry := range snapshot.Files {
+		if entry.Existed {
+			fmt.Printf("  restore %s\n", entry.Path)
+		} else {
+			fmt.Printf("  remove  %s\n", entry.Path)
+		}
+	}
+}
@@ -1,15 +1,31 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/i18n"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
 	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
-	debugMode  bool
-	configFile string
-	modelName  string
+	debugMode    bool
+	configFile   string
+	modelName    string
+	vcsName      string
+	progressJSON bool
+	accessibleUI bool
+	maxDuration  time.Duration
 
 	// Version info
 	version   = "dev"
@@ -27,18 +43,54 @@ var rootCmd = &cobra.Command{
   - Generating development reports
 
 Use "gitbuddy [command] --help" for more information about a command.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Set debug mode before any command runs
 		if debugMode {
 			log.SetDebugMode(true)
 			log.Debug("Debug mode enabled")
 		}
+		// Before anything reads the current directory, including the config
+		if err := changeWorkDir(); err != nil {
+			return err
+		}
+		ui.SetAccessible(accessibleMode(cmd))
+		ui.SetWidth(outputWidth())
+		blockSideEffects(cmd)
+		pruneSessions(cmd, os.Stderr)
+		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	closeTranscript(err)
+	closeUsage()
+	printErrorHint(os.Stderr, err)
+	return err
+}
+
+// ExitCode returns the process exit code for an error returned by Execute:
+// 2 for a failed --check, a distinct code per model failure type (see
+// llm.UserError), 1 otherwise
+func ExitCode(err error) int {
+	var checkErr *checkFailedError
+	if errors.As(err, &checkErr) {
+		return checkFailedExitCode
+	}
+	var userErr llm.UserError
+	if errors.As(err, &userErr) {
+		return userErr.ExitCode()
+	}
+	return 1
+}
+
+// printErrorHint tells the user how to fix a model failure
+func printErrorHint(w io.Writer, err error) {
+	var userErr llm.UserError
+	if errors.As(err, &userErr) {
+		fmt.Fprintf(w, "Hint: %s\n", userErr.Hint())
+	}
 }
 
 // SetVersionInfo sets version information from build flags
@@ -57,5 +109,128 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable debug mode for verbose output")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file path (default: ~/.gitbuddy.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&workDirFlag, "workdir", "C", "", "Run as if gitbuddy was started in this directory")
 	rootCmd.PersistentFlags().StringVarP(&modelName, "model", "m", "", "LLM model to use (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&vcsName, "vcs", "", "Version control system: auto, git, jj or sapling (overrides config)")
+	rootCmd.PersistentFlags().BoolVar(&progressJSON, "progress-json", false, "Emit progress as one JSON object per line on stderr instead of terminal output")
+	rootCmd.PersistentFlags().BoolVar(&accessibleUI, "accessible", false, "Plain prefixed output without colors, emoji or redraws, for screen readers and logs (default: ui.accessible)")
+	rootCmd.PersistentFlags().DurationVar(&maxDuration, "max-duration", 0, "Wall-clock budget for the run, e.g. 10m; when it runs out the agent finishes with a partial result (0 = unlimited)")
+	rootCmd.PersistentFlags().StringVar(&transcriptPath, "transcript", "", "Save all model requests and responses of the run as JSON lines to this file or directory")
+	rootCmd.PersistentFlags().BoolVar(&saveTranscript, "save-transcript", false, "Like --transcript, saving to "+defaultTranscriptDir+"/<command>-<time>.jsonl")
+	rootCmd.PersistentFlags().BoolVar(&ignoreQuota, "ignore-quota", false, "Keep sending model requests when a monthly quota of the quota config is used up")
+}
+
+// accessibleMode returns --accessible if given, otherwise ui.accessible from
+// the config file. It is decided before any command prints output.
+func accessibleMode(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("accessible") {
+		return accessibleUI
+	}
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		// Commands report a missing or invalid config themselves
+		return false
+	}
+	return cfg.AccessibleUI()
+}
+
+// outputWidth returns ui.width from the config file, 0 (the terminal width)
+// without one
+func outputWidth() int {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return 0
+	}
+	return cfg.UIWidth()
+}
+
+// newStreamPrinter creates the progress printer of an agent command. With
+// --progress-json, progress is emitted as NDJSON on stderr and out only
+// receives the final result.
+func newStreamPrinter(out io.Writer, opts ...ui.StreamPrinterOption) *ui.StreamPrinter {
+	opts = append([]ui.StreamPrinterOption{ui.WithVerbose(debugMode)}, opts...)
+	if progressJSON {
+		opts = append(opts, ui.WithProgressJSON(os.Stderr))
+	}
+	return ui.NewStreamPrinter(out, opts...)
+}
+
+// applyToolLanguage localizes tool result text and git status for a command
+// writing in language, as agent.tool_language configures
+func applyToolLanguage(cfg *config.Config, language string) {
+	toolLanguage := cfg.GetAgentConfig().ToolLanguageFor(language)
+	tools.SetLanguage(toolLanguage)
+	git.SetLocale(i18n.Locale(toolLanguage))
+}
+
+// newProvider creates the provider of a configured model. End-to-end tests
+// replace it to play back scripted model responses.
+var newProvider = func(cfg config.ModelConfig) (llm.Provider, error) {
+	return llm.NewProviderFactory().Create(cfg)
+}
+
+// wrapProvider wraps provider to cancel model responses that stall for longer
+// than retry.stream_idle_timeout, so they are retried instead of hanging the
+// run, to name the provider's request ID in stream errors, to send failed
+// requests to the fallbacks configured for the model, and to enforce the
+// usage quotas
+func wrapProvider(cfg *config.Config, provider llm.Provider) llm.Provider {
+	retryCfg := cfg.GetRetryConfig()
+	timeout := time.Duration(retryCfg.StreamIdleTimeout) * time.Second
+	wrap := func(p llm.Provider) llm.Provider {
+		return llm.WithRequestIDs(llm.WithStreamIdleTimeout(trackUsage(cfg, p), timeout))
+	}
+
+	fallbacks, err := llm.NewProviderFactory().CreateFallbacks(cfg, provider.GetConfig())
+	if err != nil {
+		log.Warn("Model fallbacks disabled: %v", err)
+		fallbacks = nil
+	}
+	for i := range fallbacks {
+		fallbacks[i] = wrap(fallbacks[i])
+	}
+	retryConfig := llm.RetryConfig{
+		Enabled:     retryCfg.Enabled,
+		MaxAttempts: retryCfg.MaxAttempts,
+		BackoffBase: retryCfg.BackoffBase,
+		BackoffMax:  retryCfg.BackoffMax,
+	}
+	return llm.WithFallbacks(wrap(provider), fallbacks, retryConfig, warnFallback)
+}
+
+// warnFallback tells the user that requests moved to a fallback model
+func warnFallback(from, to llm.Provider, err error) {
+	log.Warn("%s/%s failed, falling back to %s/%s: %v",
+		from.Name(), from.GetConfig().Model, to.Name(), to.GetConfig().Model, err)
+}
+
+// servedModel names the model that answered the requests of provider, which
+// is a fallback model when the configured one failed
+func servedModel(provider llm.Provider) string {
+	mc := provider.GetConfig()
+	return mc.Provider + "/" + mc.Model
+}
+
+// requireGit returns an error unless the repository uses git; vcs is the
+// configured version control system and feature names what needs git
+func requireGit(vcs, workDir, feature string) error {
+	if vcs == "" || vcs == git.VCSAuto {
+		vcs = git.DetectVCS(workDir)
+	}
+	if vcs != git.VCSGit {
+		return fmt.Errorf("%s requires git, but the repository uses %s", feature, vcs)
+	}
+	return nil
+}
+
+// newVCSExecutor creates the executor for the configured version control system
+func newVCSExecutor(cfg *config.Config, workDir string) (git.Executor, error) {
+	vcs := cfg.GetVCS(vcsName)
+	executor, err := git.NewVCSExecutor(workDir, vcs)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Using VCS: %s", vcs)
+	useDaemonDiff(executor, workDir)
+	return executor, nil
 }
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/rpc"
+	"github.com/spf13/cobra"
+)
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Run a JSON-RPC server over stdio for editor integrations",
+	Long: `Run a long-lived JSON-RPC 2.0 server over stdin/stdout.
+
+Messages use LSP-style framing (a Content-Length header followed by a blank line).
+This is a stable machine interface intended for editor extensions.
+
+Methods:
+  initialize       Returns server name, version and supported methods
+  generateCommit   Generates a commit message for staged changes
+  review           Reviews staged changes
+  explainRange     Explains a range of lines in a file
+  shutdown         Prepares the server for exit
+  exit             Stops the server (notification)
+
+While a request runs, agent output is streamed as "$/progress" notifications.
+Requests can be cancelled with "$/cancelRequest".`,
+	Args: cobra.NoArgs,
+	RunE: runRPC,
+}
+
+func init() {
+	rootCmd.AddCommand(rpcCmd)
+}
+
+func runRPC(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	log.DebugConfig("Configuration", cfg)
+
+	// Work in the repository root, wherever in it gitbuddy was started
+	workDir, err := workspaceDir(false)
+	if err != nil {
+		return err
+	}
+
+	v, _, _ := GetVersionInfo()
+	service := rpc.NewService(rpc.ServiceOptions{
+		Config:    cfg,
+		WorkDir:   workDir,
+		ModelName: modelName,
+		Version:   v,
+	})
+
+	server := rpc.NewServer(os.Stdin, os.Stdout)
+	service.Register(server)
+
+	log.Debug("RPC server listening on stdio")
+	return server.Serve(context.Background())
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var runDryRun bool
+
+var runCmd = &cobra.Command{
+	Use:   "run [workflow]",
+	Short: "Run a workflow defined in the config",
+	Long: `Run a named sequence of gitbuddy commands from the workflows section of the
+config, e.g.
+
+  workflows:
+    ship:
+      - commit
+      - review --severity error
+      - pr --base main
+
+The steps run one after another in this process and the workflow stops at the
+first step that fails. Global flags given to run (--model, --config, ...)
+apply to every step unless a step sets them itself, and the repository map is
+indexed once for the whole workflow.
+
+Without a workflow name, the configured workflows are listed.
+
+Examples:
+  gitbuddy run
+  gitbuddy run ship
+  gitbuddy run ship --dry-run
+  gitbuddy run ship -m gpt4`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "Print the steps without running them")
+
+	rootCmd.AddCommand(runCmd)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(args) == 0 {
+		printWorkflows(os.Stdout, cfg)
+		return nil
+	}
+
+	name := args[0]
+	steps, err := cfg.GetWorkflow(name)
+	if err != nil {
+		return err
+	}
+	if runDryRun {
+		for i, step := range steps {
+			fmt.Printf("%d. gitbuddy %s\n", i+1, strings.Join(step, " "))
+		}
+		return nil
+	}
+
+	// Steps share the repository map and the global flags given to run
+	sharedRepoMaps = make(map[string]string)
+	defer func() { sharedRepoMaps = nil }()
+	globals := saveFlags(rootCmd.PersistentFlags())
+
+	for i, step := range steps {
+		fmt.Printf("\n━━━ %s [%d/%d]: gitbuddy %s\n", name, i+1, len(steps), strings.Join(step, " "))
+		restoreFlags(rootCmd.PersistentFlags(), globals)
+		err := runWorkflowStep(step)
+		closeTranscript(err)
+		if err != nil {
+			return fmt.Errorf("workflow %s stopped at step %d (%s): %w", name, i+1, step[0], err)
+		}
+	}
+	restoreFlags(rootCmd.PersistentFlags(), globals)
+	return nil
+}
+
+// printWorkflows lists the configured workflows with their steps
+func printWorkflows(w io.Writer, cfg *config.Config) {
+	names := cfg.WorkflowNames()
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No workflows configured. Add a workflows section to the config, e.g.")
+		fmt.Fprintln(w, "\nworkflows:\n  ship:\n    - commit\n    - review --severity error\n    - pr --base main")
+		return
+	}
+	for _, name := range names {
+		fmt.Fprintf(w, "%s:\n", name)
+		for _, step := range cfg.Workflows[name] {
+			fmt.Fprintf(w, "  - %s\n", step)
+		}
+	}
+}
+
+// runWorkflowStep runs one gitbuddy command line in this process, with the
+// command's own flags reset to their defaults first
+func runWorkflowStep(args []string) error {
+	cmd, rest, err := rootCmd.Find(args)
+	if err != nil {
+		return err
+	}
+	if cmd == rootCmd {
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	if cmd.Parent() == rootCmd && cmd.Name() == "run" {
+		return fmt.Errorf("workflows cannot run other workflows")
+	}
+	if cmd.RunE == nil && cmd.Run == nil {
+		return fmt.Errorf("%s needs a subcommand", cmd.CommandPath())
+	}
+
+	resetFlags(cmd.LocalNonPersistentFlags())
+	if err := cmd.ParseFlags(rest); err != nil {
+		return err
+	}
+	positional := cmd.Flags().Args()
+	if err := cmd.ValidateArgs(positional); err != nil {
+		return err
+	}
+
+	if rootCmd.PersistentPreRunE != nil {
+		if err := rootCmd.PersistentPreRunE(cmd, positional); err != nil {
+			return err
+		}
+	}
+	if cmd.RunE != nil {
+		return cmd.RunE(cmd, positional)
+	}
+	cmd.Run(cmd, positional)
+	return nil
+}
+
+// flagState is the value of a flag and whether it was given
+type flagState struct {
+	value   string
+	changed bool
+}
+
+// saveFlags records the values of flags
+func saveFlags(flags *pflag.FlagSet) map[string]flagState {
+	saved := make(map[string]flagState)
+	flags.VisitAll(func(f *pflag.Flag) {
+		saved[f.Name] = flagState{value: f.Value.String(), changed: f.Changed}
+	})
+	return saved
+}
+
+// restoreFlags sets flags back to the values recorded by saveFlags
+func restoreFlags(flags *pflag.FlagSet, saved map[string]flagState) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if state, ok := saved[f.Name]; ok && (f.Changed || state.changed) {
+			setFlag(f, state.value)
+			f.Changed = state.changed
+		}
+	})
+}
+
+// resetFlags sets the given flags back to their defaults, so that a step
+// doesn't inherit the flags of an earlier step running the same command
+func resetFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			setFlag(f, f.DefValue)
+			f.Changed = false
+		}
+	})
+}
+
+// setFlag sets f to value; slice values replace the current elements
+// instead of appending to them
+func setFlag(f *pflag.Flag, value string) {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		var elems []string
+		if value = strings.Trim(value, "[]"); value != "" {
+			elems = strings.Split(value, ",")
+		}
+		_ = slice.Replace(elems)
+		return
+	}
+	_ = f.Value.Set(value)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWorkflowStep_ResetsFlagsBetweenSteps(t *testing.T) {
+	var focus string
+	var scope []string
+	var gotArgs []string
+	stepCmd := &cobra.Command{
+		Use:  "workflow-test-step",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gotArgs = args
+			return nil
+		},
+	}
+	stepCmd.Flags().StringVar(&focus, "focus", "all", "")
+	stepCmd.Flags().StringSliceVar(&scope, "scope", nil, "")
+	rootCmd.AddCommand(stepCmd)
+	defer rootCmd.RemoveCommand(stepCmd)
+
+	globals := saveFlags(rootCmd.PersistentFlags())
+	defer restoreFlags(rootCmd.PersistentFlags(), globals)
+
+	require.NoError(t, runWorkflowStep([]string{"workflow-test-step", "--focus", "security", "--scope", "a,b", "-m", "other", "target"}))
+	assert.Equal(t, "security", focus)
+	assert.Equal(t, []string{"a", "b"}, scope)
+	assert.Equal(t, []string{"target"}, gotArgs)
+	assert.Equal(t, "other", modelName)
+
+	restoreFlags(rootCmd.PersistentFlags(), globals)
+	require.NoError(t, runWorkflowStep([]string{"workflow-test-step", "--scope", "c"}))
+	assert.Equal(t, "all", focus)
+	assert.Equal(t, []string{"c"}, scope)
+	assert.Empty(t, gotArgs)
+	assert.Empty(t, modelName, "global flags set by a step don't carry over")
+
+	assert.Error(t, runWorkflowStep([]string{"workflow-test-step", "a", "b"}))
+	assert.ErrorContains(t, runWorkflowStep([]string{"run", "ship"}), "cannot run other workflows")
+	assert.ErrorContains(t, runWorkflowStep([]string{"no-such-command"}), "unknown command")
+}
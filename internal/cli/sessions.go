@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/huimingz/gitbuddy-go/internal/agent/session"
 	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var sessionsCmd = &cobra.Command{
@@ -20,19 +27,49 @@ Available subcommands:
   list   - List all saved sessions
   show   - Show details of a specific session
   delete - Delete a session
-  clean  - Clean up old sessions`,
+  prune  - Remove old sessions (alias: clean)
+  usage  - Summarize token usage and cost of the sessions`,
 }
 
+var (
+	sessionsAgent  string
+	sessionsStatus string
+	sessionsModel  string
+	sessionsSince  string
+	sessionsUntil  string
+)
+
 var sessionsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all saved sessions",
 	Long: `List all saved sessions with their basic information.
 
+Sessions can be filtered by agent, status (running, interrupted, partial,
+completed or failed), model and creation date. --since and --until take a
+date (2006-01-02), a timestamp (RFC 3339) or an age such as 7d.
+
 Examples:
-  gitbuddy sessions list`,
+  gitbuddy sessions list
+  gitbuddy sessions list --agent debug --status interrupted
+  gitbuddy sessions list --model gpt-4o --since 7d`,
 	RunE: runSessionsList,
 }
 
+var sessionsUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarize token usage and cost of the sessions",
+	Long: `Summarize the sessions, tokens and estimated cost per agent and model. Costs
+are only known for models with input_price and output_price configured.
+
+Takes the same filters as sessions list.
+
+Examples:
+  gitbuddy sessions usage
+  gitbuddy sessions usage --since 30d
+  gitbuddy sessions usage --agent review --since 2024-01-01 --until 2024-02-01`,
+	RunE: runSessionsUsage,
+}
+
 var sessionsShowCmd = &cobra.Command{
 	Use:   "show <session-id>",
 	Short: "Show details of a specific session",
@@ -57,25 +94,45 @@ Examples:
 
 var (
 	sessionsCleanMaxSessions int
+	sessionsPruneOlderThan   string
+	sessionsPruneDryRun      bool
 )
 
 var sessionsCleanCmd = &cobra.Command{
-	Use:   "clean",
-	Short: "Clean up old sessions",
-	Long: `Clean up old sessions, keeping only the most recent ones.
+	Use:     "prune",
+	Aliases: []string{"clean"},
+	Short:   "Remove old sessions",
+	Long: `Remove old sessions: those not updated within session.max_age, then the
+oldest beyond max_sessions, then the oldest until the session directory fits
+session.max_size. The same pass runs automatically before every command.
+
+--older-than and --max override session.max_age and max_sessions. --dry-run
+lists the sessions that would be removed.
 
 Examples:
-  gitbuddy sessions clean --max 10`,
+  gitbuddy sessions prune
+  gitbuddy sessions prune --max 10
+  gitbuddy sessions prune --older-than 7d --dry-run`,
 	RunE: runSessionsClean,
 }
 
 func init() {
 	sessionsCleanCmd.Flags().IntVar(&sessionsCleanMaxSessions, "max", 0, "Maximum number of sessions to keep (0 = use config default)")
+	sessionsCleanCmd.Flags().StringVar(&sessionsPruneOlderThan, "older-than", "", "Remove sessions not updated for this long, e.g. 7d (default: session.max_age)")
+	sessionsCleanCmd.Flags().BoolVar(&sessionsPruneDryRun, "dry-run", false, "List the sessions that would be removed without removing them")
+	for _, cmd := range []*cobra.Command{sessionsListCmd, sessionsUsageCmd} {
+		cmd.Flags().StringVar(&sessionsAgent, "agent", "", "Only sessions of this agent (debug, review, chat)")
+		cmd.Flags().StringVar(&sessionsStatus, "status", "", "Only sessions with this status")
+		cmd.Flags().StringVar(&sessionsModel, "model", "", "Only sessions of this model")
+		cmd.Flags().StringVar(&sessionsSince, "since", "", "Only sessions created since this date or age (e.g. 2024-01-01, 7d)")
+		cmd.Flags().StringVar(&sessionsUntil, "until", "", "Only sessions created before this date or age")
+	}
 
 	sessionsCmd.AddCommand(sessionsListCmd)
 	sessionsCmd.AddCommand(sessionsShowCmd)
 	sessionsCmd.AddCommand(sessionsDeleteCmd)
 	sessionsCmd.AddCommand(sessionsCleanCmd)
+	sessionsCmd.AddCommand(sessionsUsageCmd)
 	rootCmd.AddCommand(sessionsCmd)
 }
 
@@ -86,10 +143,15 @@ func runSessionsList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	filter, err := sessionFilter(time.Now())
+	if err != nil {
+		return err
+	}
+
 	sessionConfig := cfg.GetSessionConfig()
 	mgr := session.NewManager(sessionConfig.SaveDir)
 
-	sessions, err := mgr.List()
+	sessions, err := mgr.List(filter)
 	if err != nil {
 		return fmt.Errorf("failed to list sessions: %w", err)
 	}
@@ -99,28 +161,196 @@ func runSessionsList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Print sessions in a table
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "SESSION ID\tAGENT\tCREATED\tUPDATED\tITERATIONS")
-	fmt.Fprintln(w, "----------\t-----\t-------\t-------\t----------")
+	printSessionList(os.Stdout, sessions, time.Now())
+
+	fmt.Printf("\nTotal: %d session(s)\n", len(sessions))
+	fmt.Printf("Session directory: %s\n", sessionConfig.SaveDir)
+
+	return nil
+}
+
+// printSessionList prints sessions in a table, with their age since the last
+// update at now
+func printSessionList(out io.Writer, sessions []*session.SessionInfo, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION ID\tAGENT\tSTATUS\tMODEL\tCREATED\tAGE\tITERATIONS\tTOKENS")
+	fmt.Fprintln(w, "----------\t-----\t------\t-----\t-------\t---\t----------\t------")
 
 	for _, s := range sessions {
 		createdTime := s.CreatedAt.Format("2006-01-02 15:04")
-		updatedTime := s.UpdatedAt.Format("2006-01-02 15:04")
 		iterations := fmt.Sprintf("%d/%d", s.Iterations, s.MaxIterations)
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			s.ID, s.AgentType, createdTime, updatedTime, iterations)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			s.ID, s.AgentType, orDash(s.Status), orDash(s.Model), createdTime, formatAge(now.Sub(s.UpdatedAt)), iterations, s.TotalTokens)
 	}
 
 	w.Flush()
+}
 
-	fmt.Printf("\nTotal: %d session(s)\n", len(sessions))
-	fmt.Printf("Session directory: %s\n", sessionConfig.SaveDir)
+// formatAge formats the time since a session was updated in its largest unit,
+// e.g. "3h" or "2d"
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	}
+}
+
+func runSessionsUsage(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	filter, err := sessionFilter(time.Now())
+	if err != nil {
+		return err
+	}
 
+	sessions, err := session.NewManager(cfg.GetSessionConfig().SaveDir).List(filter)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No saved sessions found.")
+		return nil
+	}
+
+	printSessionUsage(os.Stdout, sessions)
 	return nil
 }
 
+// sessionUsage is the usage of the sessions of one agent and model
+type sessionUsage struct {
+	agent    string
+	model    string
+	sessions int
+	tokens   int
+	cost     float64
+}
+
+// printSessionUsage prints the sessions, tokens and cost per agent and
+// model, followed by the totals
+func printSessionUsage(out io.Writer, sessions []*session.SessionInfo) {
+	byKey := make(map[[2]string]*sessionUsage)
+	var total sessionUsage
+	for _, s := range sessions {
+		key := [2]string{s.AgentType, s.Model}
+		usage, ok := byKey[key]
+		if !ok {
+			usage = &sessionUsage{agent: s.AgentType, model: s.Model}
+			byKey[key] = usage
+		}
+		for _, u := range []*sessionUsage{usage, &total} {
+			u.sessions++
+			u.tokens += s.TotalTokens
+			u.cost += s.CostUSD
+		}
+	}
+
+	rows := make([]*sessionUsage, 0, len(byKey))
+	for _, usage := range byKey {
+		rows = append(rows, usage)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].agent != rows[j].agent {
+			return rows[i].agent < rows[j].agent
+		}
+		return rows[i].model < rows[j].model
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tMODEL\tSESSIONS\tTOKENS\tCOST (USD)")
+	fmt.Fprintln(w, "-----\t-----\t--------\t------\t----------")
+	for _, u := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", u.agent, orDash(u.model), u.sessions, u.tokens, formatCost(u.cost))
+	}
+	fmt.Fprintf(w, "TOTAL\t\t%d\t%d\t%s\n", total.sessions, total.tokens, formatCost(total.cost))
+	w.Flush()
+}
+
+// formatCost formats an estimated cost, "-" when unknown
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", cost)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// sessionFilter returns the filter given with the list and usage flags
+func sessionFilter(now time.Time) (session.Filter, error) {
+	filter := session.Filter{AgentType: sessionsAgent, Status: sessionsStatus, Model: sessionsModel}
+	var err error
+	if filter.Since, err = parseSessionTime(sessionsSince, now); err != nil {
+		return filter, fmt.Errorf("invalid --since: %w", err)
+	}
+	if filter.Until, err = parseSessionTime(sessionsUntil, now); err != nil {
+		return filter, fmt.Errorf("invalid --until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseSessionTime parses a date (2006-01-02, local time), an RFC 3339
+// timestamp or an age before now such as "7d". "" is the zero time.
+func parseSessionTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	age, err := config.ParseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02), a timestamp or an age (e.g. 7d)", value)
+	}
+	return now.Add(-age), nil
+}
+
+// sessionRunInfo describes the run of cmd for the metadata of its sessions
+func sessionRunInfo(ctx context.Context, cmd *cobra.Command, modelConfig *config.ModelConfig, workDir string, gitExecutor git.Executor) session.RunInfo {
+	info := session.RunInfo{
+		Model:       modelConfig.Provider + "/" + modelConfig.Model,
+		Repo:        workDir,
+		InputPrice:  modelConfig.InputPrice,
+		OutputPrice: modelConfig.OutputPrice,
+	}
+	if branch, err := gitExecutor.CurrentBranch(ctx); err == nil {
+		info.Branch = strings.TrimSpace(branch)
+	}
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	info.Flags = strings.Join(flags, " ")
+	return info
+}
+
+// markSessionFailed records that the run of a saved session failed
+func markSessionFailed(mgr *session.Manager, sessionID string) {
+	if sessionID == "" || !mgr.Exists(sessionID) {
+		return
+	}
+	if err := mgr.SetStatus(sessionID, session.StatusFailed); err != nil {
+		log.Debug("Failed to record the session status: %v", err)
+	}
+}
+
 func runSessionsShow(cmd *cobra.Command, args []string) error {
 	sessionID := args[0]
 
@@ -203,20 +433,102 @@ func runSessionsClean(cmd *cobra.Command, args []string) error {
 	}
 
 	sessionConfig := cfg.GetSessionConfig()
-	mgr := session.NewManager(sessionConfig.SaveDir)
-
+	policy, err := sessionPrunePolicy(sessionConfig)
+	if err != nil {
+		return err
+	}
 	// Determine max sessions to keep
-	maxSessions := sessionsCleanMaxSessions
-	if maxSessions <= 0 {
-		maxSessions = sessionConfig.MaxSessions
+	if sessionsCleanMaxSessions > 0 {
+		policy.MaxSessions = sessionsCleanMaxSessions
 	}
+	if sessionsPruneOlderThan != "" {
+		if policy.MaxAge, err = config.ParseAge(sessionsPruneOlderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+	policy.DryRun = sessionsPruneDryRun
 
-	// Clean up old sessions
-	if err := mgr.CleanupOld(maxSessions); err != nil {
+	result, err := session.NewManager(sessionConfig.SaveDir).Prune(policy, time.Now())
+	if err != nil {
 		return fmt.Errorf("failed to clean up sessions: %w", err)
 	}
 
-	fmt.Printf("✓ Cleaned up old sessions (kept %d most recent)\n", maxSessions)
+	if policy.DryRun {
+		for _, id := range result.RemovedIDs {
+			fmt.Println(id)
+		}
+		fmt.Printf("Would remove %d session(s), freeing %s; %d session(s) left (%s)\n",
+			result.Removed, formatBytes(result.FreedBytes), result.Kept, formatBytes(result.KeptBytes))
+		return nil
+	}
+	fmt.Printf("✓ Removed %d session(s), freeing %s; %d session(s) left (%s)\n",
+		result.Removed, formatBytes(result.FreedBytes), result.Kept, formatBytes(result.KeptBytes))
 
 	return nil
 }
+
+// sessionPrunePolicy returns the limits of the session config
+func sessionPrunePolicy(sessionConfig *config.SessionConfig) (session.PrunePolicy, error) {
+	maxAge, err := sessionConfig.MaxAgeDuration()
+	if err != nil {
+		return session.PrunePolicy{}, fmt.Errorf("invalid session.max_age: %w", err)
+	}
+	maxBytes, err := sessionConfig.MaxSizeBytes()
+	if err != nil {
+		return session.PrunePolicy{}, fmt.Errorf("invalid session.max_size: %w", err)
+	}
+	return session.PrunePolicy{MaxSessions: sessionConfig.MaxSessions, MaxAge: maxAge, MaxBytes: maxBytes}, nil
+}
+
+// sessionsPruned is set once the startup prune pass ran, so that workflow
+// steps don't repeat it
+var sessionsPruned bool
+
+// pruneSessions removes the saved sessions exceeding session.max_age,
+// max_sessions and max_size before a command runs, and prints a summary line
+// when it removed any. The session a command resumes is kept. Problems are
+// only logged, since the command itself reports config errors.
+func pruneSessions(cmd *cobra.Command, w io.Writer) {
+	if sessionsPruned {
+		return
+	}
+	sessionsPruned = true
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return
+	}
+	sessionConfig := cfg.GetSessionConfig()
+	policy, err := sessionPrunePolicy(sessionConfig)
+	if err != nil {
+		log.Debug("Skipping session pruning: %v", err)
+		return
+	}
+	if resume := cmd.Flags().Lookup("resume"); resume != nil && resume.Value.String() != "" {
+		policy.Keep = []string{resume.Value.String()}
+	}
+
+	result, err := session.NewManager(sessionConfig.SaveDir).Prune(policy, time.Now())
+	if err != nil {
+		log.Debug("Session pruning failed: %v", err)
+		return
+	}
+	if result.Removed > 0 {
+		fmt.Fprintf(w, "Pruned %d saved session(s) past session.max_age, max_sessions or max_size, freeing %s\n",
+			result.Removed, formatBytes(result.FreedBytes))
+	}
+}
+
+// formatBytes formats a size in bytes for humans, e.g. "12.5 MB"
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSessionTime(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseSessionTime("", now)
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	got, err = parseSessionTime("2025-01-02", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local), got)
+
+	got, err = parseSessionTime("2025-01-02T15:04:05Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC), got)
+
+	got, err = parseSessionTime("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), got)
+
+	_, err = parseSessionTime("last week", now)
+	assert.Error(t, err)
+}
+
+func TestPrintSessionUsage(t *testing.T) {
+	sessions := []*session.SessionInfo{
+		{AgentType: "debug", Model: "openai/gpt-4o", TotalTokens: 1000, CostUSD: 0.25},
+		{AgentType: "review", Model: "openai/gpt-4o", TotalTokens: 500},
+		{AgentType: "debug", Model: "openai/gpt-4o", TotalTokens: 2000, CostUSD: 0.5},
+		{AgentType: "chat", TotalTokens: 300},
+	}
+
+	var out bytes.Buffer
+	printSessionUsage(&out, sessions)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 6)
+	assert.Equal(t, []string{"chat", "-", "1", "300", "-"}, tableFields(lines[2]))
+	assert.Equal(t, []string{"debug", "openai/gpt-4o", "2", "3000", "0.7500"}, tableFields(lines[3]))
+	assert.Equal(t, []string{"review", "openai/gpt-4o", "1", "500", "-"}, tableFields(lines[4]))
+	assert.Equal(t, []string{"TOTAL", "4", "3800", "0.7500"}, tableFields(lines[5]))
+}
+
+func TestPrintSessionList(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	sessions := []*session.SessionInfo{
+		{ID: "debug-1", AgentType: "debug", Status: "interrupted", Model: "openai/gpt-4o", CreatedAt: now.Add(-50 * time.Hour),
+			UpdatedAt: now.Add(-49 * time.Hour), Iterations: 12, MaxIterations: 30, TotalTokens: 4200},
+		{ID: "review-1", AgentType: "review", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-20 * time.Minute), Iterations: 3, MaxIterations: 20},
+	}
+
+	var out bytes.Buffer
+	printSessionList(&out, sessions, now)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"debug-1", "debug", "interrupted", "openai/gpt-4o", "2025-03-08", "10:00", "2d", "12/30", "4200"}, tableFields(lines[2]))
+	assert.Equal(t, []string{"review-1", "review", "-", "-", "2025-03-10", "11:00", "20m", "3/20", "0"}, tableFields(lines[3]))
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "now", formatAge(30*time.Second))
+	assert.Equal(t, "59m", formatAge(59*time.Minute))
+	assert.Equal(t, "5h", formatAge(5*time.Hour+40*time.Minute))
+	assert.Equal(t, "14d", formatAge(14*24*time.Hour+time.Hour))
+}
+
+func tableFields(line []byte) []string {
+	var out []string
+	for _, f := range bytes.Fields(line) {
+		out = append(out, string(f))
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// historyCheck makes sure a shallow clone has the history a command needs
+// before the model starts looking at it. Missing history is fetched after
+// confirmation, or without asking when autoFetch is set.
+type historyCheck struct {
+	workDir   string
+	autoFetch bool
+	input     io.Reader
+	output    io.Writer
+	printer   *ui.StreamPrinter
+}
+
+func newHistoryCheck(workDir string, autoFetch bool, printer *ui.StreamPrinter) *historyCheck {
+	return &historyCheck{workDir: workDir, autoFetch: autoFetch, input: os.Stdin, output: os.Stdout, printer: printer}
+}
+
+// confirmFetch asks whether to fetch; a closed or non-interactive stdin
+// declines, and so does check mode
+func (h *historyCheck) confirmFetch(question string) bool {
+	if sideeffect.Blocked() {
+		return false
+	}
+	if h.autoFetch {
+		return true
+	}
+	ok, err := ui.ConfirmWithDefault(question, true, h.input, h.output)
+	return err == nil && ok
+}
+
+// reportPeriod returns the start of the report period the local history
+// covers. When commits after since are missing and not fetched, the period
+// starts at the shallow boundary and note tells the model why.
+func (h *historyCheck) reportPeriod(ctx context.Context, since string) (start, note string) {
+	cutoff, err := git.ShallowCutoff(ctx, h.workDir, "HEAD", since)
+	if err != nil {
+		log.Debug("Failed to check for a shallow clone: %v", err)
+		return since, ""
+	}
+	if cutoff.IsZero() {
+		return since, ""
+	}
+
+	day := cutoff.Format("2006-01-02")
+	_ = h.printer.PrintInfo(fmt.Sprintf("This is a shallow clone: commits before %s are missing from the report period", day))
+	if h.confirmFetch(fmt.Sprintf("Fetch the history since %s?", since)) {
+		_ = h.printer.PrintProgress("Fetching history...")
+		err := git.FetchSince(ctx, h.workDir, since)
+		if err == nil {
+			return since, ""
+		}
+		_ = h.printer.PrintError(err.Error())
+	}
+
+	_ = h.printer.PrintInfo(fmt.Sprintf("The report covers %s onwards only; run git fetch --shallow-since=%s for the full period", day, since))
+	return day, shallowReportNote(since, day)
+}
+
+// shallowReportNote tells the model the report period was cut short, so it
+// reports the gap instead of inventing earlier work
+func shallowReportNote(since, day string) string {
+	return fmt.Sprintf("The repository is a shallow clone without commits before %s, so the period starts at %s instead of %s. "+
+		"State this limitation in the report and do not describe work before %s.", day, day, since, day)
+}
+
+// prHistory makes sure base and head share a commit in a shallow clone,
+// otherwise the diff and log between them can't be computed
+func (h *historyCheck) prHistory(ctx context.Context, base, head string) error {
+	shallow, err := git.IsShallow(ctx, h.workDir)
+	if err != nil {
+		log.Debug("Failed to check for a shallow clone: %v", err)
+		return nil
+	}
+	if !shallow || git.HasMergeBase(ctx, h.workDir, base, head) {
+		return nil
+	}
+
+	_ = h.printer.PrintInfo(fmt.Sprintf("This is a shallow clone and %s and %s have no common commit in the fetched history", base, head))
+	if !h.confirmFetch("Fetch the full history?") {
+		return fmt.Errorf("%s and %s have no common history in this shallow clone; run git fetch --unshallow (in CI, set fetch-depth: 0) and try again", base, head)
+	}
+	_ = h.printer.PrintProgress("Fetching history...")
+	if err := git.Unshallow(ctx, h.workDir); err != nil {
+		return err
+	}
+	if !git.HasMergeBase(ctx, h.workDir, base, head) {
+		return fmt.Errorf("%s and %s have no common history", base, head)
+	}
+	return nil
+}
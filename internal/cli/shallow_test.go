@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGitAt(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// shallowClone creates a repository with commits in January, February and
+// March 2024 on main and a feature branch started in January, and returns a
+// depth 1 clone of it
+func shallowClone(t *testing.T) string {
+	t.Helper()
+	origin := t.TempDir()
+	runGitAt(t, origin, nil, "init", "--quiet", "--initial-branch=main")
+	commit := func(date, msg string) {
+		runGitAt(t, origin, []string{"GIT_COMMITTER_DATE=" + date, "GIT_AUTHOR_DATE=" + date},
+			"-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", msg)
+	}
+	commit("2024-01-10T10:00:00Z", "January")
+	runGitAt(t, origin, nil, "branch", "feature")
+	commit("2024-02-10T10:00:00Z", "February")
+	commit("2024-03-10T10:00:00Z", "March")
+	runGitAt(t, origin, nil, "checkout", "--quiet", "feature")
+	commit("2024-03-15T10:00:00Z", "Feature")
+	runGitAt(t, origin, nil, "checkout", "--quiet", "main")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGitAt(t, origin, nil, "clone", "--quiet", "--depth", "1", "--no-single-branch", "file://"+origin, clone)
+	return clone
+}
+
+func TestHistoryCheck_ReportPeriod(t *testing.T) {
+	ctx := context.Background()
+	clone := shallowClone(t)
+	printer := ui.NewStreamPrinter(io.Discard)
+
+	// Declining keeps the history and cuts the period
+	check := &historyCheck{workDir: clone, input: strings.NewReader("n\n"), output: io.Discard, printer: printer}
+	start, note := check.reportPeriod(ctx, "2024-01-01")
+	assert.Equal(t, "2024-03-10", start)
+	assert.Contains(t, note, "without commits before 2024-03-10")
+
+	// Within the fetched history nothing is missing
+	start, note = check.reportPeriod(ctx, "2024-03-11")
+	assert.Equal(t, "2024-03-11", start)
+	assert.Empty(t, note)
+
+	check.autoFetch = true
+	start, note = check.reportPeriod(ctx, "2024-02-01")
+	assert.Equal(t, "2024-02-01", start)
+	assert.Empty(t, note)
+}
+
+func TestHistoryCheck_PRHistory(t *testing.T) {
+	ctx := context.Background()
+	clone := shallowClone(t)
+	printer := ui.NewStreamPrinter(io.Discard)
+
+	// Without input the fetch is declined
+	check := &historyCheck{workDir: clone, input: strings.NewReader(""), output: io.Discard, printer: printer}
+	err := check.prHistory(ctx, "origin/main", "origin/feature")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git fetch --unshallow")
+
+	check.input = strings.NewReader("\n")
+	require.NoError(t, check.prHistory(ctx, "origin/main", "origin/feature"))
+	require.NoError(t, check.prHistory(ctx, "origin/main", "origin/feature"))
+}
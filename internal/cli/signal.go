@@ -2,9 +2,11 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -13,6 +15,64 @@ import (
 	"github.com/huimingz/gitbuddy-go/internal/ui"
 )
 
+// interruptCleanups run before an interrupted command exits: os.Exit skips
+// deferred calls
+var (
+	interruptMu       sync.Mutex
+	interruptCleanups []*interruptCleanup
+)
+
+// interruptCleanup is a cleanup registered with onInterrupt
+type interruptCleanup struct {
+	run  func()
+	done bool
+}
+
+// onInterrupt registers cleanup to run when the interrupt handler exits. The
+// returned release unregisters it and reports whether it still has to be
+// done, i.e. it hasn't run already.
+func onInterrupt(cleanup func()) (release func() bool) {
+	c := &interruptCleanup{run: cleanup}
+	interruptMu.Lock()
+	interruptCleanups = append(interruptCleanups, c)
+	interruptMu.Unlock()
+
+	return func() bool {
+		interruptMu.Lock()
+		defer interruptMu.Unlock()
+		if c.done {
+			return false
+		}
+		c.done = true
+		for i, registered := range interruptCleanups {
+			if registered == c {
+				interruptCleanups = append(interruptCleanups[:i], interruptCleanups[i+1:]...)
+				break
+			}
+		}
+		return true
+	}
+}
+
+// runInterruptCleanups runs the registered cleanups, newest first, and
+// unregisters them
+func runInterruptCleanups() {
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	for i := len(interruptCleanups) - 1; i >= 0; i-- {
+		c := interruptCleanups[i]
+		c.done = true
+		c.run()
+	}
+	interruptCleanups = nil
+}
+
+// exitInterrupted runs the interrupt cleanups and exits with the code of SIGINT
+func exitInterrupted() {
+	runInterruptCleanups()
+	os.Exit(130)
+}
+
 // SessionInterruptHandler handles graceful shutdown with session saving on interrupt signals
 type SessionInterruptHandler struct {
 	sessionMgr     *session.Manager
@@ -56,7 +116,10 @@ func (h *SessionInterruptHandler) Start() {
 
 // handleSignals handles interrupt signals
 func (h *SessionInterruptHandler) handleSignals() {
-	<-h.sigChan
+	// The channel is closed by Stop when the command finished
+	if _, ok := <-h.sigChan; !ok {
+		return
+	}
 	h.interrupted = true
 
 	fmt.Println("\n\n⚠️  Received interrupt signal.")
@@ -78,11 +141,11 @@ func (h *SessionInterruptHandler) handleSignals() {
 		case <-h.sigChan:
 			// Second Ctrl+C received during confirmation - force exit immediately
 			fmt.Println("\n\n🛑  Force exit requested.")
-			os.Exit(130)
+			exitInterrupted()
 		case <-time.After(30 * time.Second):
 			// Timeout after 30 seconds
 			fmt.Println("\n⏰  Confirmation timeout. Exiting.")
-			os.Exit(130)
+			exitInterrupted()
 		}
 	}()
 
@@ -120,7 +183,9 @@ func (h *SessionInterruptHandler) handleSignals() {
 		}
 	}
 
-	os.Exit(130) // Standard exit code for SIGINT
+	closeTranscript(errors.New("interrupted by user"))
+	closeUsage()
+	exitInterrupted()
 }
 
 // IsInterrupted returns whether the handler has been interrupted
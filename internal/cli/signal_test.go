@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterruptCleanups(t *testing.T) {
+	var ran []string
+	releaseFirst := onInterrupt(func() { ran = append(ran, "first") })
+	releaseSecond := onInterrupt(func() { ran = append(ran, "second") })
+	releaseDone := onInterrupt(func() { ran = append(ran, "released") })
+
+	// A released cleanup is left to its owner
+	assert.True(t, releaseDone())
+	assert.False(t, releaseDone())
+
+	runInterruptCleanups()
+	assert.Equal(t, []string{"second", "first"}, ran)
+
+	// The owner doesn't clean up again what the interrupt handler did
+	assert.False(t, releaseFirst())
+	assert.False(t, releaseSecond())
+
+	runInterruptCleanups()
+	assert.Len(t, ran, 2)
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue replaces secrets in the support bundle
+const redactedValue = "REDACTED"
+
+// supportBundleEnv are the environment variables reported in the support
+// bundle; proxies are only reported as set, since their URLs can hold credentials
+var supportBundleEnv = []struct {
+	name   string
+	secret bool
+}{
+	{name: "GITBUDDY_MODEL"},
+	{name: "GITBUDDY_LANG"},
+	{name: "LANG"},
+	{name: "TERM"},
+	{name: "HTTPS_PROXY", secret: true},
+	{name: "HTTP_PROXY", secret: true},
+	{name: "NO_PROXY"},
+}
+
+var (
+	supportBundleOutput       string
+	supportBundleFrom         string
+	supportBundleNoTranscript bool
+)
+
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "Package diagnostics into a zip file for bug reports",
+	Long: `Package what is needed to investigate a problem into a zip file to attach to a
+bug report:
+
+- environment.txt: GitBuddy, Go, OS and git versions, relevant environment
+  variables, and the provider request IDs found in the transcript
+- config.yaml: the configuration, with API keys, the forge and usage backend
+  tokens and redaction profile entries replaced by REDACTED (references to
+  environment variables are kept)
+- transcript.jsonl: the newest transcript in ` + defaultTranscriptDir + `, or the
+  one given with --from (record one with --save-transcript)
+
+The transcript contains the prompts and source code sent to the model. Review
+it before sharing the bundle, or leave it out with --no-transcript.
+
+Examples:
+  gitbuddy review --save-transcript
+  gitbuddy support-bundle
+  gitbuddy support-bundle --from run.jsonl -o bug-123.zip
+  gitbuddy support-bundle --no-transcript`,
+	Args: cobra.NoArgs,
+	RunE: runSupportBundle,
+}
+
+func init() {
+	supportBundleCmd.Flags().StringVarP(&supportBundleOutput, "output", "o", "", "Zip file to write (default: gitbuddy-support-<timestamp>.zip)")
+	supportBundleCmd.Flags().StringVar(&supportBundleFrom, "from", "", "Transcript to include (default: the newest in "+defaultTranscriptDir+")")
+	supportBundleCmd.Flags().BoolVar(&supportBundleNoTranscript, "no-transcript", false, "Leave the transcript out of the bundle")
+
+	rootCmd.AddCommand(supportBundleCmd)
+}
+
+func runSupportBundle(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	output := supportBundleOutput
+	if output == "" {
+		output = fmt.Sprintf("gitbuddy-support-%s.zip", now.Format("20060102-150405"))
+	}
+
+	transcript := ""
+	if !supportBundleNoTranscript {
+		transcript = supportBundleFrom
+		if transcript == "" {
+			transcript = latestTranscript(defaultTranscriptDir)
+		} else if _, err := os.Stat(transcript); err != nil {
+			return fmt.Errorf("failed to read transcript: %w", err)
+		}
+	}
+
+	// A broken config is a common reason for a bug report, so it doesn't stop the bundle
+	cfg, cfgErr := config.Load(configFile)
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create support bundle: %w", err)
+	}
+	if err := writeSupportBundle(file, cfg, cfgErr, transcript, now); err != nil {
+		file.Close()
+		os.Remove(output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+
+	fmt.Printf("Support bundle written to %s\n", output)
+	if transcript != "" {
+		fmt.Printf("It includes the transcript %s, which contains the prompts and source code sent to the model. Review it before sharing.\n", transcript)
+	} else if !supportBundleNoTranscript {
+		fmt.Println("No transcript found; run the failing command again with --save-transcript to include one.")
+	}
+	return nil
+}
+
+// bundleFile is a file of the support bundle
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// writeSupportBundle writes the support bundle zip to w. cfgErr is the error
+// loading the config failed with, transcript the path of the transcript to
+// include ("" for none).
+func writeSupportBundle(w io.Writer, cfg *config.Config, cfgErr error, transcript string, now time.Time) error {
+	archive := zip.NewWriter(w)
+
+	var transcriptData []byte
+	if transcript != "" {
+		data, err := os.ReadFile(transcript)
+		if err != nil {
+			return fmt.Errorf("failed to read transcript: %w", err)
+		}
+		transcriptData = data
+	}
+
+	files := []bundleFile{
+		{name: "environment.txt", data: supportEnvironment(now, transcriptRequestIDs(transcriptData))},
+		{name: "config.yaml", data: redactedConfig(cfg, cfgErr)},
+	}
+	if transcriptData != nil {
+		files = append(files, bundleFile{name: "transcript.jsonl", data: transcriptData})
+	}
+
+	for _, f := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to support bundle: %w", f.name, err)
+		}
+		if _, err := entry.Write(f.data); err != nil {
+			return fmt.Errorf("failed to add %s to support bundle: %w", f.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+	return nil
+}
+
+// supportEnvironment describes the versions and environment of GitBuddy
+func supportEnvironment(now time.Time, requestIDs []string) []byte {
+	var b bytes.Buffer
+	v, commit, buildTime := GetVersionInfo()
+	fmt.Fprintf(&b, "Created:    %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "GitBuddy:   %s (commit %s, built %s)\n", v, commit, buildTime)
+	fmt.Fprintf(&b, "Go:         %s\n", runtime.Version())
+	fmt.Fprintf(&b, "OS/Arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if out, err := exec.Command("git", "--version").Output(); err == nil {
+		fmt.Fprintf(&b, "Git:        %s\n", strings.TrimSpace(string(out)))
+	} else {
+		fmt.Fprintf(&b, "Git:        not found (%v)\n", err)
+	}
+
+	b.WriteString("\nEnvironment:\n")
+	for _, env := range supportBundleEnv {
+		value, ok := os.LookupEnv(env.name)
+		switch {
+		case !ok:
+			value = "(unset)"
+		case env.secret:
+			value = "(set)"
+		}
+		fmt.Fprintf(&b, "  %s=%s\n", env.name, value)
+	}
+
+	b.WriteString("\nProvider request IDs (oldest first):\n")
+	if len(requestIDs) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, id := range requestIDs {
+		fmt.Fprintf(&b, "  %s\n", id)
+	}
+	return b.Bytes()
+}
+
+// transcriptRequestIDs returns the provider request IDs recorded in a transcript
+func transcriptRequestIDs(transcript []byte) []string {
+	var ids []string
+	scanner := bufio.NewScanner(bytes.NewReader(transcript))
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var entry llm.TranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.RequestID == "" {
+			continue
+		}
+		ids = append(ids, fmt.Sprintf("call %d: %s", entry.Call, entry.RequestID))
+	}
+	return ids
+}
+
+// redactedConfig renders cfg as YAML without API keys and redaction profile
+// entries, or the error loading it failed with
+func redactedConfig(cfg *config.Config, cfgErr error) []byte {
+	if cfgErr != nil {
+		return []byte(fmt.Sprintf("# Failed to load the config: %v\n", cfgErr))
+	}
+
+	redacted := *cfg
+	redacted.Models = make(map[string]config.ModelConfig, len(cfg.Models))
+	for name, model := range cfg.Models {
+		model.APIKey = redactSecret(model.APIKey)
+		redacted.Models[name] = model
+	}
+	if cfg.Forge != nil {
+		forge := *cfg.Forge
+		forge.Token = redactSecret(forge.Token)
+		redacted.Forge = &forge
+	}
+	if cfg.Quota != nil {
+		quota := *cfg.Quota
+		quota.BackendToken = redactSecret(quota.BackendToken)
+		redacted.Quota = &quota
+	}
+	if cfg.Redaction != nil {
+		redaction := *cfg.Redaction
+		redaction.Profiles = make(map[string]*config.RedactionProfile, len(cfg.Redaction.Profiles))
+		for name, profile := range cfg.Redaction.Profiles {
+			if profile == nil {
+				continue
+			}
+			redaction.Profiles[name] = &config.RedactionProfile{
+				Hostnames:   redactAll(profile.Hostnames),
+				Usernames:   redactAll(profile.Usernames),
+				Identifiers: redactAll(profile.Identifiers),
+				Patterns:    redactAll(profile.Patterns),
+				Replacement: profile.Replacement,
+			}
+		}
+		redacted.Redaction = &redaction
+	}
+
+	data, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return []byte(fmt.Sprintf("# Failed to encode the config: %v\n", err))
+	}
+	return data
+}
+
+// redactSecret replaces a secret unless it is a reference such as
+// ${OPENAI_API_KEY}, which doesn't reveal it
+func redactSecret(value string) string {
+	if value != "" && !strings.HasPrefix(value, "$") {
+		return redactedValue
+	}
+	return value
+}
+
+// redactAll replaces every value, keeping how many there are
+func redactAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	redacted := make([]string, len(values))
+	for i := range redacted {
+		redacted[i] = redactedValue
+	}
+	return redacted
+}
+
+// latestTranscript returns the most recently modified transcript in dir, or
+// "" when there is none
+func latestTranscript(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest = filepath.Join(dir, entry.Name())
+			latestTime = info.ModTime()
+		}
+	}
+	return latest
+}
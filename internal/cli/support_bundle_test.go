@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestWriteSupportBundle(t *testing.T) {
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "review-20261016-120000.jsonl")
+	require.NoError(t, os.WriteFile(transcript, []byte(
+		`{"type":"run","run":{"command":"gitbuddy review"}}`+"\n"+
+			`{"type":"response","call":1,"request_id":"chatcmpl-abc"}`+"\n"), 0600))
+
+	cfg := &config.Config{
+		DefaultModel: "gpt",
+		Models: map[string]config.ModelConfig{
+			"gpt":  {Provider: "openai", APIKey: "sk-secret", Model: "gpt-4o"},
+			"env":  {Provider: "openai", APIKey: "${OPENAI_API_KEY}", Model: "gpt-4o"},
+			"free": {Provider: "ollama", Model: "qwen"},
+		},
+		Forge: &config.ForgeConfig{Type: "github", Token: "ghp_secret"},
+		Quota: &config.QuotaConfig{MonthlyTokens: 1000000, BackendURL: "https://usage.example.com", BackendToken: "usage-secret"},
+		Redaction: &config.RedactionConfig{Profiles: map[string]*config.RedactionProfile{
+			"external": {Hostnames: []string{"jenkins.corp.example.com"}, Identifiers: []string{"Acme Bank"}},
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeSupportBundle(&out, cfg, nil, transcript, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)))
+	files := readBundle(t, out.Bytes())
+
+	assert.Contains(t, files["environment.txt"], "GitBuddy:")
+	assert.Contains(t, files["environment.txt"], "call 1: chatcmpl-abc")
+	assert.NotContains(t, files["config.yaml"], "sk-secret")
+	assert.NotContains(t, files["config.yaml"], "jenkins.corp.example.com")
+	assert.NotContains(t, files["config.yaml"], "Acme Bank")
+	assert.NotContains(t, files["config.yaml"], "ghp_secret")
+	assert.NotContains(t, files["config.yaml"], "usage-secret")
+	assert.Contains(t, files["config.yaml"], "https://usage.example.com")
+	assert.Contains(t, files["config.yaml"], "${OPENAI_API_KEY}")
+	assert.Contains(t, files["config.yaml"], "gpt-4o")
+	assert.Contains(t, files["transcript.jsonl"], "gitbuddy review")
+	assert.Equal(t, "sk-secret", cfg.Models["gpt"].APIKey, "the loaded config is not modified")
+	assert.Equal(t, "ghp_secret", cfg.Forge.Token)
+	assert.Equal(t, "usage-secret", cfg.Quota.BackendToken)
+
+	out.Reset()
+	require.NoError(t, writeSupportBundle(&out, nil, errors.New("no models configured"), "", time.Now()))
+	files = readBundle(t, out.Bytes())
+	assert.Len(t, files, 2)
+	assert.Contains(t, files["config.yaml"], "no models configured")
+}
+
+func TestLatestTranscript(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, latestTranscript(filepath.Join(dir, "missing")))
+
+	older := filepath.Join(dir, "commit-1.jsonl")
+	newer := filepath.Join(dir, "review-2.jsonl")
+	require.NoError(t, os.WriteFile(older, nil, 0600))
+	require.NoError(t, os.WriteFile(newer, nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(older, past, past))
+
+	assert.Equal(t, newer, latestTranscript(dir))
+}
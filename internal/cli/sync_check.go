@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncCheckBase    string
+	syncCheckNoFetch bool
+)
+
+var syncCheckCmd = &cobra.Command{
+	Use:   "sync-check",
+	Short: "Check how the current branch diverged and how to sync it",
+	Long: `Compare the current branch against its upstream and the default branch.
+
+This command will:
+1. Fetch the remotes (skip with --no-fetch)
+2. Count the commits ahead of and behind the upstream and the default branch
+3. Predict conflicting files with git merge-tree (git 2.38+), without touching the working tree
+4. Recommend fast-forward, rebase or merge with the exact commands to run
+
+Examples:
+  gitbuddy sync-check
+  gitbuddy sync-check --base origin/develop
+  gitbuddy sync-check --no-fetch`,
+	Args: cobra.NoArgs,
+	RunE: runSyncCheck,
+}
+
+func init() {
+	syncCheckCmd.Flags().StringVar(&syncCheckBase, "base", "", "Branch to compare against instead of the default branch (e.g., origin/develop)")
+	syncCheckCmd.Flags().BoolVar(&syncCheckNoFetch, "no-fetch", false, "Compare against the remote-tracking branches without fetching first")
+	rootCmd.AddCommand(syncCheckCmd)
+}
+
+func runSyncCheck(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+
+	// No model is used, so the configuration is only read for the VCS
+	vcs := vcsName
+	if cfg, err := config.Load(configFile); err == nil {
+		vcs = cfg.GetVCS(vcsName)
+	}
+	if err := requireGit(vcs, workDir, "sync-check"); err != nil {
+		return err
+	}
+
+	branch, err := git.NewExecutor(workDir).CurrentBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	if !syncCheckNoFetch {
+		fmt.Println("Fetching remotes...")
+		if err := git.Fetch(ctx, workDir); err != nil {
+			fmt.Printf("⚠️  %v\n   Comparing against the last fetched state.\n", err)
+		}
+	}
+
+	report, err := agent.AnalyzeSync(ctx, workDir, branch, syncCheckBase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n🌿 Branch: %s\n", report.Branch)
+	if report.Upstream != nil {
+		printSyncTarget("Upstream", report.Upstream)
+	} else {
+		fmt.Println("   Upstream: none")
+	}
+	if report.DefaultBranch != nil {
+		printSyncTarget("Base", report.DefaultBranch)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+
+	fmt.Println("\n💡 Recommendation:")
+	for i, advice := range agent.RecommendSync(report) {
+		fmt.Printf("  %d. %s\n", i+1, advice.Summary)
+		for _, command := range advice.Commands {
+			fmt.Printf("       $ %s\n", command)
+		}
+	}
+	return nil
+}
+
+func printSyncTarget(label string, target *agent.SyncTarget) {
+	fmt.Printf("   %s: %s (%d ahead, %d behind)\n", label, target.Ref, target.Ahead, target.Behind)
+	if len(target.Conflicts) > 0 {
+		fmt.Printf("      Conflicts predicted in %d file(s):\n", len(target.Conflicts))
+		for _, file := range target.Conflicts {
+			fmt.Printf("        %s\n", file)
+		}
+	} else if target.ConflictsKnown {
+		fmt.Println("      No conflicts predicted")
+	}
+}
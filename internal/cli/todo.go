@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	todoStaleDays int
+	todoAuthor    string
+	todoLimit     int
+	todoJSON      bool
+)
+
+var todoCmd = &cobra.Command{
+	Use:   "todo [pathspec...]",
+	Short: "Report TODO, FIXME and HACK comments by age and priority",
+	Long: `Scan the tracked files for TODO, FIXME, HACK and XXX comments and print a
+cleanup report, most urgent first.
+
+Each comment is matched with git blame to find who added it and when.
+Comments older than --stale-days are stale. Priorities:
+- high: stale FIXME, HACK and XXX comments, and any comment twice as old as --stale-days
+- medium: other stale comments, and recent FIXME, HACK and XXX comments
+- low: recent TODO comments and uncommitted changes
+
+Examples:
+  gitbuddy todo
+  gitbuddy todo internal/ --stale-days 90
+  gitbuddy todo --author alice --limit 20
+  gitbuddy todo --json > todos.json`,
+	RunE: runTodo,
+}
+
+func init() {
+	todoCmd.Flags().IntVar(&todoStaleDays, "stale-days", int(agent.DefaultTodoStaleAfter/(24*time.Hour)), "Consider comments older than this many days stale")
+	todoCmd.Flags().StringVar(&todoAuthor, "author", "", "Only report comments added by authors whose name or email contains this text")
+	todoCmd.Flags().IntVar(&todoLimit, "limit", 0, "Report at most this many comments (0 for all)")
+	todoCmd.Flags().BoolVar(&todoJSON, "json", false, "Print the report as JSON")
+	rootCmd.AddCommand(todoCmd)
+}
+
+func runTodo(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	workDir, err := workspaceDir(true)
+	if err != nil {
+		return err
+	}
+	if todoStaleDays <= 0 {
+		return fmt.Errorf("--stale-days must be positive")
+	}
+
+	report, err := agent.ScanTodos(ctx, workDir, agent.TodoScanOptions{
+		Paths:      args,
+		StaleAfter: time.Duration(todoStaleDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+	filterTodos(report, todoAuthor, todoLimit)
+
+	if todoJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode todo report: %w", err)
+		}
+		return nil
+	}
+	printTodoReport(os.Stdout, report)
+	return nil
+}
+
+// filterTodos keeps the items added by author and at most limit items
+func filterTodos(report *agent.TodoReport, author string, limit int) {
+	if author != "" {
+		author = strings.ToLower(author)
+		var kept []agent.TodoItem
+		for _, item := range report.Items {
+			if strings.Contains(strings.ToLower(item.Author), author) || strings.Contains(strings.ToLower(item.Email), author) {
+				kept = append(kept, item)
+			}
+		}
+		report.Items = kept
+	}
+	if limit > 0 && len(report.Items) > limit {
+		report.Items = report.Items[:limit]
+	}
+}
+
+// printTodoReport prints the items grouped by priority followed by a summary
+func printTodoReport(w io.Writer, report *agent.TodoReport) {
+	if len(report.Items) == 0 {
+		fmt.Fprintln(w, "No TODO comments found")
+		return
+	}
+
+	headings := map[string]string{
+		agent.TodoHigh:   "🔴 High priority",
+		agent.TodoMedium: "🟡 Medium priority",
+		agent.TodoLow:    "🟢 Low priority",
+	}
+	if ui.Accessible() {
+		headings = map[string]string{
+			agent.TodoHigh:   "HIGH PRIORITY",
+			agent.TodoMedium: "MEDIUM PRIORITY",
+			agent.TodoLow:    "LOW PRIORITY",
+		}
+	}
+
+	for _, priority := range []string{agent.TodoHigh, agent.TodoMedium, agent.TodoLow} {
+		count := report.Count(priority)
+		if count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d)\n", headings[priority], count)
+		for _, item := range report.Items {
+			if item.Priority != priority {
+				continue
+			}
+			fmt.Fprintf(w, "  %-5s %s:%d (%s) %s\n", item.Kind, item.File, item.Line, todoOrigin(item), item.Text)
+		}
+		fmt.Fprintln(w)
+	}
+
+	kinds := make(map[string]int)
+	authors := make(map[string]int)
+	stale := 0
+	for _, item := range report.Items {
+		kinds[item.Kind]++
+		if item.Committed {
+			authors[item.Author]++
+		} else {
+			authors["(uncommitted)"]++
+		}
+		if item.Stale {
+			stale++
+		}
+	}
+	fmt.Fprintf(w, "%d comment(s), %d older than %d days\n", len(report.Items), stale, report.StaleDays)
+	fmt.Fprintf(w, "By kind: %s\n", formatTodoCounts(kinds))
+	fmt.Fprintf(w, "By author: %s\n", formatTodoCounts(authors))
+}
+
+// todoOrigin describes who added a comment and how long ago
+func todoOrigin(item agent.TodoItem) string {
+	if !item.Committed {
+		return "uncommitted"
+	}
+	return fmt.Sprintf("%s, %s", item.Author, formatTodoAge(item.AgeDays))
+}
+
+// formatTodoAge renders an age in days, e.g. "3d", "5mo" or "2y 1mo"
+func formatTodoAge(days int) string {
+	switch {
+	case days < 1:
+		return "today"
+	case days < 30:
+		return fmt.Sprintf("%dd", days)
+	case days < 365:
+		return fmt.Sprintf("%dmo", days/30)
+	}
+	years, months := days/365, days%365/30
+	if months == 0 {
+		return fmt.Sprintf("%dy", years)
+	}
+	return fmt.Sprintf("%dy %dmo", years, months)
+}
+
+// formatTodoCounts renders counts as "a 3, b 1", highest first
+func formatTodoCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatTodoAge(t *testing.T) {
+	assert.Equal(t, "today", formatTodoAge(0))
+	assert.Equal(t, "12d", formatTodoAge(12))
+	assert.Equal(t, "5mo", formatTodoAge(150))
+	assert.Equal(t, "1y", formatTodoAge(370))
+	assert.Equal(t, "2y 3mo", formatTodoAge(820))
+}
+
+func TestPrintTodoReport(t *testing.T) {
+	report := &agent.TodoReport{
+		StaleDays: 180,
+		Items: []agent.TodoItem{
+			{File: "a.go", Line: 3, Kind: "FIXME", Text: "leak", Author: "Alice", Email: "alice@example.com", Committed: true, AgeDays: 400, Stale: true, Priority: agent.TodoHigh},
+			{File: "b.go", Line: 7, Kind: "TODO", Text: "rename", Author: "Bob", Committed: true, AgeDays: 3, Priority: agent.TodoLow},
+			{File: "c.go", Line: 1, Kind: "TODO", Text: "wip", Priority: agent.TodoLow},
+		},
+	}
+	filterTodos(report, "ALICE@", 0)
+	assert.Len(t, report.Items, 1)
+
+	report.Items = append(report.Items, agent.TodoItem{File: "c.go", Line: 1, Kind: "TODO", Text: "wip", Priority: agent.TodoLow})
+	var buf bytes.Buffer
+	printTodoReport(&buf, report)
+	out := buf.String()
+	assert.Contains(t, out, "a.go:3 (Alice, 1y 1mo) leak")
+	assert.Contains(t, out, "c.go:1 (uncommitted) wip")
+	assert.NotContains(t, out, "Medium")
+	assert.Contains(t, out, "2 comment(s), 1 older than 180 days")
+	assert.Contains(t, out, "By kind: FIXME 1, TODO 1")
+	assert.Contains(t, out, "By author: (uncommitted) 1, Alice 1")
+
+	filterTodos(report, "", 1)
+	assert.Len(t, report.Items, 1)
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/spf13/cobra"
+)
+
+// defaultTranscriptDir is where --save-transcript saves transcripts
+const defaultTranscriptDir = ".gitbuddy/transcripts"
+
+var (
+	transcriptPath string
+	saveTranscript bool
+
+	// activeTranscript is the transcript of the running command, closed by Execute
+	activeTranscript *llm.Transcript
+)
+
+// withTranscript wraps provider to record all model calls when --transcript or
+// --save-transcript is given. Without them provider is returned unchanged.
+func withTranscript(cmd *cobra.Command, provider llm.Provider) (llm.Provider, error) {
+	path := transcriptPath
+	if saveTranscript {
+		if path != "" {
+			return nil, fmt.Errorf("--transcript and --save-transcript cannot be used together")
+		}
+		path = defaultTranscriptDir
+	}
+	if path == "" {
+		return provider, nil
+	}
+
+	path = resolveTranscriptPath(path, cmd.Name(), time.Now())
+	workDir, _ := workspaceDir(false)
+	mc := provider.GetConfig()
+	transcript, err := llm.NewTranscript(path, llm.RunInfo{
+		Command:  cmd.CommandPath(),
+		Args:     os.Args[1:],
+		Version:  version,
+		Provider: mc.Provider,
+		Model:    mc.Model,
+		WorkDir:  workDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	activeTranscript = transcript
+	return llm.WithTranscript(provider, transcript), nil
+}
+
+// resolveTranscriptPath returns path, or a timestamped file in it when path is
+// a directory (an existing one, or one ending with a separator)
+func resolveTranscriptPath(path, command string, now time.Time) string {
+	info, err := os.Stat(path)
+	isDir := (err == nil && info.IsDir()) || strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator))
+	if path == defaultTranscriptDir || isDir {
+		return filepath.Join(path, fmt.Sprintf("%s-%s.jsonl", command, now.Format("20060102-150405")))
+	}
+	return path
+}
+
+// closeTranscript records the outcome of the command and tells the user where
+// the transcript is
+func closeTranscript(runErr error) {
+	if activeTranscript == nil {
+		return
+	}
+	if err := activeTranscript.Close(runErr); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "Transcript saved to %s\n", activeTranscript.Path())
+	activeTranscript = nil
+}
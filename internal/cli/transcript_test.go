@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTranscriptPath(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	dir := t.TempDir()
+
+	assert.Equal(t, filepath.Join(defaultTranscriptDir, "review-20240102-030405.jsonl"),
+		resolveTranscriptPath(defaultTranscriptDir, "review", now))
+	assert.Equal(t, filepath.Join(dir, "debug-20240102-030405.jsonl"),
+		resolveTranscriptPath(dir, "debug", now), "existing directories get a timestamped file")
+	assert.Equal(t, filepath.Join("logs", "commit-20240102-030405.jsonl"),
+		resolveTranscriptPath("logs/", "commit", now))
+	assert.Equal(t, "run.jsonl", resolveTranscriptPath("run.jsonl", "commit", now))
+}
+
+func TestTranscriptFlags(t *testing.T) {
+	defer func() { transcriptPath, saveTranscript = "", false }()
+
+	// The path is the value of the flag, not an argument of the command
+	flags := pflag.NewFlagSet("notes", pflag.ContinueOnError)
+	flags.AddFlagSet(rootCmd.PersistentFlags())
+	require.NoError(t, flags.Parse([]string{"--transcript", "run.jsonl", "HEAD~1"}))
+	assert.Equal(t, "run.jsonl", transcriptPath)
+	assert.Equal(t, []string{"HEAD~1"}, flags.Args())
+
+	require.NoError(t, flags.Parse([]string{"--save-transcript", "HEAD~1"}))
+	_, err := withTranscript(rootCmd, nil)
+	assert.EqualError(t, err, "--transcript and --save-transcript cannot be used together")
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/usage"
+)
+
+// usageTimeout bounds reading and recording usage in the ledgers
+const usageTimeout = 10 * time.Second
+
+var (
+	ignoreQuota bool
+
+	// activeUsage tracks the model usage of the running command, recorded by Execute
+	activeUsage *usageTracking
+)
+
+// usageTracking is the quota tracker of a run and where its usage is recorded
+type usageTracking struct {
+	tracker *usage.Tracker
+	ledgers []usage.Ledger
+	user    string
+	project string
+}
+
+// trackUsage wraps provider to refuse requests once a quota configured in
+// quota is used up, and to record its usage. Without a quota section
+// provider is returned unchanged.
+func trackUsage(cfg *config.Config, provider llm.Provider) llm.Provider {
+	if cfg.Quota == nil {
+		return provider
+	}
+	if activeUsage == nil {
+		tracking, err := newUsageTracking(cfg, time.Now())
+		if err != nil {
+			log.Warn("Usage quotas disabled: %v", err)
+			return provider
+		}
+		activeUsage = tracking
+	}
+	mc := provider.GetConfig()
+	return usage.WithTracker(provider, cfg.ModelName(&mc), activeUsage.tracker)
+}
+
+// newUsageTracking reads the usage of the month from the ledgers and creates
+// the tracker of the run. The shared backend, when configured, is the source
+// of the usage; the local ledger is used when it can't be reached.
+func newUsageTracking(cfg *config.Config, now time.Time) (*usageTracking, error) {
+	quotaCfg := cfg.GetQuotaConfig()
+	if err := quotaCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid quota configuration: %w", err)
+	}
+	ledgerPath, err := expandHome(quotaCfg.Ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageTimeout)
+	defer cancel()
+	tracking := &usageTracking{ledgers: []usage.Ledger{usage.NewFileLedger(ledgerPath)}}
+	tracking.user, tracking.project = usageIdentity(ctx)
+	if quotaCfg.BackendURL != "" {
+		tracking.ledgers = append([]usage.Ledger{usage.NewRemoteLedger(quotaCfg.BackendURL, quotaCfg.BackendToken)}, tracking.ledgers...)
+	}
+
+	var history []usage.Entry
+	for i, ledger := range tracking.ledgers {
+		history, err = ledger.Entries(ctx, usage.MonthStart(now))
+		if err == nil {
+			break
+		}
+		if i == len(tracking.ledgers)-1 {
+			return nil, err
+		}
+		log.Warn("Failed to read usage from the backend, counting local usage only: %v", err)
+	}
+
+	quota := usage.Quota{
+		Total:  usage.Amount{Tokens: quotaCfg.MonthlyTokens, Cost: quotaCfg.MonthlyCost},
+		Models: make(map[string]usage.Amount, len(quotaCfg.Models)),
+	}
+	for name, m := range quotaCfg.Models {
+		quota.Models[name] = usage.Amount{Tokens: m.MonthlyTokens, Cost: m.MonthlyCost}
+	}
+	tracking.tracker = usage.NewTracker(quota, scopeEntries(history, quotaCfg.Scope, tracking.user, tracking.project), !ignoreQuota, nil)
+	return tracking, nil
+}
+
+// scopeEntries returns the entries that count towards the quota in scope
+func scopeEntries(entries []usage.Entry, scope, user, project string) []usage.Entry {
+	if scope == config.QuotaScopeAll {
+		return entries
+	}
+	var scoped []usage.Entry
+	for _, entry := range entries {
+		if (scope == config.QuotaScopeProject && entry.Project == project) || (scope != config.QuotaScopeProject && entry.User == user) {
+			scoped = append(scoped, entry)
+		}
+	}
+	return scoped
+}
+
+// usageIdentity returns who is using the models and in which project: git
+// user.email, or the OS user, and the name of the repository
+func usageIdentity(ctx context.Context) (string, string) {
+	dir, err := workspaceDir(false)
+	if err != nil {
+		dir = "."
+	}
+	name, _ := git.ConfigValue(ctx, dir, "user.email")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	return name, filepath.Base(dir)
+}
+
+// closeUsage records the usage of the run in the ledgers
+func closeUsage() {
+	if activeUsage == nil {
+		return
+	}
+	entries := activeUsage.tracker.Pending(time.Now())
+	for i := range entries {
+		entries[i].User = activeUsage.user
+		entries[i].Project = activeUsage.project
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageTimeout)
+	defer cancel()
+	for _, ledger := range activeUsage.ledgers {
+		if err := ledger.Append(ctx, entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record usage: %v\n", err)
+		}
+	}
+}
+
+// expandHome replaces a leading ~/ of path with the home directory
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, path[2:]), nil
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/usage"
+)
+
+func TestScopeEntries(t *testing.T) {
+	entries := []usage.Entry{
+		{User: "ann@example.com", Project: "api"},
+		{User: "bob@example.com", Project: "api"},
+		{User: "ann@example.com", Project: "web"},
+	}
+	assert.Len(t, scopeEntries(entries, config.QuotaScopeAll, "ann@example.com", "api"), 3)
+	assert.Equal(t, []usage.Entry{entries[0], entries[2]}, scopeEntries(entries, config.QuotaScopeUser, "ann@example.com", "api"))
+	assert.Equal(t, []usage.Entry{entries[0], entries[1]}, scopeEntries(entries, config.QuotaScopeProject, "ann@example.com", "api"))
+}
+
+func TestUsageTracking(t *testing.T) {
+	ledgerPath := filepath.Join(t.TempDir(), "usage.jsonl")
+	cfg := &config.Config{Quota: &config.QuotaConfig{MonthlyTokens: 1000, Scope: config.QuotaScopeAll, Ledger: ledgerPath}}
+	now := time.Now()
+	require.NoError(t, usage.NewFileLedger(ledgerPath).Append(context.Background(), []usage.Entry{
+		{Time: usage.MonthStart(now).Add(-time.Hour), Model: "deepseek", PromptTokens: 5000}, // Last month
+		{Time: now, Model: "deepseek", PromptTokens: 900},
+	}))
+
+	tracking, err := newUsageTracking(cfg, now)
+	require.NoError(t, err)
+	assert.NoError(t, tracking.tracker.Check("deepseek"))
+	tracking.tracker.Record("deepseek", 100, 0, 0)
+	assert.Error(t, tracking.tracker.Check("deepseek"))
+
+	activeUsage = tracking
+	defer func() { activeUsage = nil }()
+	closeUsage()
+	entries, err := usage.NewFileLedger(ledgerPath).Entries(context.Background(), usage.MonthStart(now))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(100), entries[1].PromptTokens)
+	assert.Equal(t, tracking.user, entries[1].User)
+	assert.NotEmpty(t, entries[1].Project)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/huimingz/gitbuddy-go/internal/workspace"
+)
+
+// workDirFlag is the global --workdir/-C flag
+var workDirFlag string
+
+// changeWorkDir makes --workdir the current directory, like git -C, so that
+// relative paths of every command and tool start from it
+func changeWorkDir() error {
+	if workDirFlag == "" {
+		return nil
+	}
+	dir, err := workspace.Resolve(workDirFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --workdir: %w", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("failed to change to %s: %w", dir, err)
+	}
+	// Later calls, e.g. for the steps of a workflow, stay in dir
+	workDirFlag = dir
+	return nil
+}
+
+// workspaceDir returns the directory a command works in: the root of the
+// repository containing the current directory, or with requireRepo false
+// the current directory itself when it is not in a repository
+func workspaceDir(requireRepo bool) (string, error) {
+	dir, err := workspace.Resolve("")
+	if err != nil {
+		return "", err
+	}
+	root, err := workspace.Root(dir)
+	if errors.Is(err, workspace.ErrNotRepository) {
+		if requireRepo {
+			return "", fmt.Errorf("%s is %w; run gitbuddy in a repository or point --workdir (-C) at one", dir, err)
+		}
+		return dir, nil
+	}
+	return root, err
+}
+
+// workspaceFiles converts file arguments given relative to the current
+// directory to paths relative to the workspace root
+func workspaceFiles(root string, files []string) []string {
+	dir, err := workspace.Resolve("")
+	if err != nil {
+		return files
+	}
+	return workspace.RootRelative(root, dir, files)
+}
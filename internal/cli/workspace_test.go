@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceDir(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	nested := filepath.Join(dir, "internal", "cli")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	t.Chdir(nested)
+	got, err := workspaceDir(false)
+	require.NoError(t, err)
+	assert.Equal(t, nested, got, "outside a repository")
+	_, err = workspaceDir(true)
+	assert.ErrorContains(t, err, "--workdir")
+
+	runGitAt(t, dir, nil, "init", "--quiet")
+	got, err = workspaceDir(true)
+	require.NoError(t, err)
+	assert.Equal(t, dir, got)
+	assert.Equal(t, []string{"internal/cli/root.go"}, workspaceFiles(got, []string{"root.go"}))
+}
+
+func TestChangeWorkDir(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	t.Chdir(dir)
+	defer func() { workDirFlag = "" }()
+
+	workDirFlag = "sub"
+	require.NoError(t, changeWorkDir())
+	// Applying it again, as the steps of a workflow do, stays in the same place
+	require.NoError(t, changeWorkDir())
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "sub"), wd)
+
+	workDirFlag = filepath.Join(dir, "missing")
+	assert.ErrorContains(t, changeWorkDir(), "invalid --workdir")
+}
@@ -0,0 +1,249 @@
+// Package codeowners parses CODEOWNERS files in the GitHub and GitLab formats
+// and finds the owners of changed files.
+package codeowners
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultPaths are the locations GitHub and GitLab look for a CODEOWNERS
+// file, in the order they are searched
+var DefaultPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// sectionPattern matches GitLab section headers such as "[Docs]",
+// "^[Optional][2] @docs-team" or "[Backend] @backend"
+var sectionPattern = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?\s*(.*)$`)
+
+// Rule assigns owners to the files matching a pattern
+type Rule struct {
+	Pattern string
+	Owners  []string // Empty when the pattern explicitly has no owner
+	Section string   // GitLab section, empty for GitHub files
+	Line    int
+
+	re *regexp.Regexp
+}
+
+// File is a parsed CODEOWNERS file
+type File struct {
+	Path  string // Path relative to the repository root, empty when parsed from a string
+	Rules []Rule
+}
+
+// Ownership lists the changed files an owner owns
+type Ownership struct {
+	Owner string   `json:"owner"`
+	Files []string `json:"files"`
+}
+
+// Load reads the CODEOWNERS file of the repository at workDir from path, or
+// from the first of DefaultPaths that exists when path is empty. It returns
+// nil without an error when the repository has no CODEOWNERS file.
+func Load(workDir, path string) (*File, error) {
+	candidates := DefaultPaths
+	if path != "" {
+		candidates = []string{path}
+	}
+	for _, candidate := range candidates {
+		full := candidate
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(workDir, filepath.FromSlash(candidate))
+		}
+		data, err := os.ReadFile(full)
+		if errors.Is(err, os.ErrNotExist) && path == "" {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CODEOWNERS file: %w", err)
+		}
+		file := Parse(string(data))
+		file.Path = candidate
+		return file, nil
+	}
+	return nil, nil
+}
+
+// Parse parses the content of a CODEOWNERS file
+func Parse(content string) *File {
+	file := &File{}
+	section := ""
+	var sectionOwners []string
+	for i, line := range strings.Split(content, "\n") {
+		line = stripComment(strings.TrimSpace(line))
+		if line == "" {
+			continue
+		}
+		if m := sectionPattern.FindStringSubmatch(line); m != nil {
+			section = strings.TrimSpace(m[1])
+			sectionOwners = strings.Fields(m[2])
+			continue
+		}
+
+		fields := strings.Fields(line)
+		rule := Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			Section: section,
+			Line:    i + 1,
+		}
+		// In GitLab sections, entries without owners get the section's default owners
+		if len(rule.Owners) == 0 && section != "" {
+			rule.Owners = sectionOwners
+		}
+		rule.re = compilePattern(rule.Pattern)
+		file.Rules = append(file.Rules, rule)
+	}
+	return file
+}
+
+// Owners returns the owners of a file. The last matching rule wins; GitLab
+// sections are independent, so each section's last matching rule contributes.
+func (f *File) Owners(path string) []string {
+	if f == nil {
+		return nil
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+
+	matched := make(map[string]*Rule)
+	var sections []string
+	for i := range f.Rules {
+		rule := &f.Rules[i]
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if _, ok := matched[rule.Section]; !ok {
+			sections = append(sections, rule.Section)
+		}
+		matched[rule.Section] = rule
+	}
+
+	var owners []string
+	seen := make(map[string]bool)
+	for _, section := range sections {
+		for _, owner := range matched[section].Owners {
+			if key := strings.ToLower(owner); !seen[key] {
+				seen[key] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// Affected groups files by owner, sorted by owner. Files without owners are
+// left out.
+func (f *File) Affected(files []string) []Ownership {
+	byOwner := make(map[string]*Ownership)
+	for _, file := range files {
+		for _, owner := range f.Owners(file) {
+			key := strings.ToLower(owner)
+			if byOwner[key] == nil {
+				byOwner[key] = &Ownership{Owner: owner}
+			}
+			byOwner[key].Files = append(byOwner[key].Files, file)
+		}
+	}
+
+	affected := make([]Ownership, 0, len(byOwner))
+	for _, ownership := range byOwner {
+		affected = append(affected, *ownership)
+	}
+	sort.Slice(affected, func(i, j int) bool {
+		return strings.ToLower(affected[i].Owner) < strings.ToLower(affected[j].Owner)
+	})
+	return affected
+}
+
+// Conventions returns the documented conventions of the affected owners,
+// keyed by owner. Owners are compared case-insensitively, as on GitHub and
+// GitLab (and because config keys are lower-cased when loaded).
+func Conventions(conventions map[string]string, affected []Ownership) map[string]string {
+	if len(conventions) == 0 {
+		return nil
+	}
+	byKey := make(map[string]string, len(conventions))
+	for owner, text := range conventions {
+		if text = strings.TrimSpace(text); text != "" {
+			byKey[strings.ToLower(owner)] = text
+		}
+	}
+
+	result := make(map[string]string)
+	for _, ownership := range affected {
+		if text, ok := byKey[strings.ToLower(ownership.Owner)]; ok {
+			result[ownership.Owner] = text
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// stripComment removes a trailing comment; "\#" escapes a literal #
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+// compilePattern converts a CODEOWNERS pattern to a regular expression using
+// gitignore rules: patterns with a leading or inner "/" are anchored to the
+// repository root, others match at any depth, and a pattern matching a
+// directory also matches everything below it. As on GitHub, "dir/*" only
+// matches the files directly in dir.
+func compilePattern(pattern string) *regexp.Regexp {
+	pattern = strings.ReplaceAll(pattern, `\#`, "#")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for rest := trimmed; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "**/"):
+			b.WriteString("(?:.*/)?")
+			rest = rest[3:]
+		case strings.HasPrefix(rest, "**"):
+			b.WriteString(".*")
+			rest = rest[2:]
+		case rest[0] == '*':
+			b.WriteString("[^/]*")
+			rest = rest[1:]
+		case rest[0] == '?':
+			b.WriteString("[^/]")
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, "*?")
+			if end < 0 {
+				end = len(rest)
+			}
+			b.WriteString(regexp.QuoteMeta(rest[:end]))
+			rest = rest[end:]
+		}
+	}
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case strings.HasSuffix(trimmed, "/*") && !strings.HasSuffix(trimmed, "/**"):
+		// Direct children only
+	default:
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,102 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubFile = `# Default owners
+*                   @acme/core
+
+*.js                @acme/frontend   # Inline comment
+/build/logs/        @doctocat
+docs/*              docs@example.com
+apps/               @octocat
+/scripts/**/deploy* @acme/ops
+**/payments         @acme/payments @Alice
+/apps/github
+`
+
+func TestFile_Owners(t *testing.T) {
+	file := Parse(githubFile)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@acme/core"}},
+		{"web/src/app.js", []string{"@acme/frontend"}},
+		{"build/logs/today.log", []string{"@doctocat"}},
+		{"src/build/logs/today.log", []string{"@acme/core"}},
+		{"docs/getting-started.md", []string{"docs@example.com"}},
+		{"docs/build-app/troubleshooting.md", []string{"@acme/core"}},
+		{"apps/api/main.go", []string{"@octocat"}},
+		{"nested/apps/api/main.go", []string{"@octocat"}}, // A trailing slash alone does not anchor
+		{"scripts/ci/deploy.sh", []string{"@acme/ops"}},
+		{"scripts/deploy-prod.sh", []string{"@acme/ops"}},
+		{"internal/payments/charge.go", []string{"@acme/payments", "@Alice"}},
+		{"apps/github/workflow.yml", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, file.Owners(tt.path))
+		})
+	}
+
+	var none *File
+	assert.Nil(t, none.Owners("main.go"))
+}
+
+func TestFile_Owners_GitLabSections(t *testing.T) {
+	file := Parse(`[Backend] @backend
+internal/
+internal/auth/ @security
+
+^[Docs][2] @docs
+*.md
+`)
+	assert.Equal(t, []string{"@backend"}, file.Owners("internal/service.go"))
+	assert.Equal(t, []string{"@security"}, file.Owners("internal/auth/token.go"))
+	assert.Equal(t, []string{"@backend", "@docs"}, file.Owners("internal/README.md"), "sections are independent")
+	assert.Equal(t, "Docs", file.Rules[2].Section)
+	assert.Equal(t, 6, file.Rules[2].Line)
+}
+
+func TestFile_Affected(t *testing.T) {
+	file := Parse(githubFile)
+	affected := file.Affected([]string{"internal/payments/charge.go", "web/app.js", "internal/payments/refund.go", "apps/github/x.yml"})
+	assert.Equal(t, []Ownership{
+		{Owner: "@acme/frontend", Files: []string{"web/app.js"}},
+		{Owner: "@acme/payments", Files: []string{"internal/payments/charge.go", "internal/payments/refund.go"}},
+		{Owner: "@Alice", Files: []string{"internal/payments/charge.go", "internal/payments/refund.go"}},
+	}, affected)
+
+	conventions := Conventions(map[string]string{
+		"@acme/payments": "Amounts are int64 cents.",
+		"@alice":         "  ",
+		"@acme/ops":      "Deploys go through the release train.",
+	}, affected)
+	assert.Equal(t, map[string]string{"@acme/payments": "Amounts are int64 cents."}, conventions)
+	assert.Nil(t, Conventions(nil, affected))
+}
+
+func TestLoad(t *testing.T) {
+	workDir := t.TempDir()
+	file, err := Load(workDir, "")
+	require.NoError(t, err)
+	assert.Nil(t, file)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "docs", "CODEOWNERS"), []byte("* @docs\n"), 0644))
+	file, err = Load(workDir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "docs/CODEOWNERS", file.Path)
+	assert.Equal(t, []string{"@docs"}, file.Owners("README.md"))
+
+	_, err = Load(workDir, "OWNERS")
+	assert.Error(t, err, "a configured file must exist")
+}
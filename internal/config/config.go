@@ -1,11 +1,18 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/promptpack"
 	"github.com/spf13/viper"
 )
 
@@ -32,12 +39,277 @@ type Config struct {
 	DefaultModel string                 `yaml:"default_model" mapstructure:"default_model"`
 	Models       map[string]ModelConfig `yaml:"models" mapstructure:"models"`
 	Language     string                 `yaml:"language" mapstructure:"language"`
+	VCS          string                 `yaml:"vcs" mapstructure:"vcs"` // auto, git, jj or sapling
 	PRTemplate   *PRTemplateConfig      `yaml:"pr_template" mapstructure:"pr_template"`
 	Review       *ReviewConfig          `yaml:"review" mapstructure:"review"`
 	Debug        *DebugConfig           `yaml:"debug" mapstructure:"debug"`
 	Chat         *ChatConfig            `yaml:"chat" mapstructure:"chat"`
 	Retry        *RetryConfig           `yaml:"retry" mapstructure:"retry"`
 	Session      *SessionConfig         `yaml:"session" mapstructure:"session"`
+	Agent        *AgentConfig           `yaml:"agent" mapstructure:"agent"`
+	Redaction    *RedactionConfig       `yaml:"redaction" mapstructure:"redaction"`
+	Notes        *NotesConfig           `yaml:"notes" mapstructure:"notes"`
+	UI           *UIConfig              `yaml:"ui" mapstructure:"ui"`
+	CodeOwners   *CodeOwnersConfig      `yaml:"code_owners" mapstructure:"code_owners"`
+	RepoMap      *RepoMapConfig         `yaml:"repo_map" mapstructure:"repo_map"`
+	Quota        *QuotaConfig           `yaml:"quota" mapstructure:"quota"`
+	Commit       *CommitConfig          `yaml:"commit" mapstructure:"commit"`
+	PR           *PRConfig              `yaml:"pr" mapstructure:"pr"`
+	Forge        *ForgeConfig           `yaml:"forge" mapstructure:"forge"`
+
+	// ProtectedBranches guards branches commit must not write to directly
+	ProtectedBranches *ProtectedBranchesConfig `yaml:"protected_branches" mapstructure:"protected_branches"`
+
+	// Languages overrides Language per artifact or per group of artifacts
+	// ("code" or "explanation"), e.g. code: en, explanation: zh, see
+	// GetArtifactLanguage
+	Languages map[string]string `yaml:"languages" mapstructure:"languages"`
+
+	// PromptExtensions appends project-specific guidance to agent system prompts,
+	// keyed by agent (commit, review, pr, report, debug, chat, explain) or "all"
+	PromptExtensions map[string]string `yaml:"prompt_extensions" mapstructure:"prompt_extensions"`
+
+	// PromptPacks enables prompt packs installed with "gitbuddy prompts install";
+	// their prompts come before prompt_extensions
+	PromptPacks []string `yaml:"prompt_packs" mapstructure:"prompt_packs"`
+
+	// Workflows are named command sequences run with "gitbuddy run <name>",
+	// e.g. ship: ["commit", "review --severity error", "pr --base main"]
+	Workflows map[string][]string `yaml:"workflows" mapstructure:"workflows"`
+
+	packPrompts map[string][]string // Prompts of the enabled packs by key, see LoadPromptPacks
+}
+
+// AgentConfig represents settings shared by all agents
+type AgentConfig struct {
+	MaxRepeatedToolCalls int `yaml:"max_repeated_tool_calls" mapstructure:"max_repeated_tool_calls"` // Identical consecutive tool calls before aborting
+	// MaxDiffLines is the size of the staged diff above which git_diff_cached
+	// returns per-file statistics and the model asks for one file at a time
+	// (negative = no limit)
+	MaxDiffLines int `yaml:"max_diff_lines" mapstructure:"max_diff_lines"`
+	// ToolLanguage localizes tool result text and git status: "" keeps
+	// English tool text and the user's locale for git, "auto" follows the
+	// output language, anything else is a language code
+	ToolLanguage string `yaml:"tool_language" mapstructure:"tool_language"`
+}
+
+// ToolLanguageFor returns the language tool output is localized to for a
+// command writing in outputLanguage, "" for no localization
+func (a *AgentConfig) ToolLanguageFor(outputLanguage string) string {
+	if a.ToolLanguage == "auto" {
+		return outputLanguage
+	}
+	return a.ToolLanguage
+}
+
+// CommitConfig represents settings of the commit command
+type CommitConfig struct {
+	// HookFixCommands are offered when a hook of the repository rejects the
+	// commit, e.g. "gofmt -w ." or "npm run lint -- --fix". They run without
+	// a shell, and the staged files they change are staged again.
+	HookFixCommands []string `yaml:"hook_fix_commands" mapstructure:"hook_fix_commands"`
+}
+
+// PRConfig represents settings of the pr command
+type PRConfig struct {
+	// Changelog appends a machine-readable block listing the changed modules,
+	// migrations and new feature flags to the description for release
+	// tooling: "yaml", "json" or "" for none (overridden by --changelog)
+	Changelog string `yaml:"changelog" mapstructure:"changelog"`
+}
+
+// NotesConfig represents settings for recording AI metadata as git notes
+type NotesConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"` // Record notes for commit, review and debug runs (overridden by --notes)
+}
+
+// UIConfig represents terminal output settings
+type UIConfig struct {
+	// Accessible prints plain prefixed lines without colors, emoji or redraws
+	// for screen readers and log files (overridden by --accessible)
+	Accessible bool `yaml:"accessible" mapstructure:"accessible"`
+	// Width is the column long output lines are wrapped at: 0 detects the
+	// terminal width, -1 disables wrapping
+	Width int `yaml:"width" mapstructure:"width"`
+	// NotifyAfter sends a desktop notification when a debug or review run
+	// that took longer than this finishes or asks for feedback, e.g. "120s"
+	// (empty or "0" = never)
+	NotifyAfter string `yaml:"notify_after" mapstructure:"notify_after"`
+}
+
+// NotifyAfterDuration returns NotifyAfter as a duration, 0 for never
+func (u *UIConfig) NotifyAfterDuration() (time.Duration, error) {
+	if u.NotifyAfter == "" || u.NotifyAfter == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(u.NotifyAfter)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration (e.g. 90s or 2m)", u.NotifyAfter)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+// ForgeConfig represents access to the API of the forge hosting the
+// repository, used to open pull requests, post reviews, learn from review
+// comments and file issues
+type ForgeConfig struct {
+	Type   string `yaml:"type" mapstructure:"type"`       // github or gitlab (default: derived from the remote URL)
+	Token  string `yaml:"token" mapstructure:"token"`     // API token, e.g. ${GITBUDDY_GITHUB_TOKEN} (default: GITHUB_TOKEN or GH_TOKEN, GITLAB_TOKEN on GitLab)
+	APIURL string `yaml:"api_url" mapstructure:"api_url"` // API URL (default: derived from the remote URL)
+}
+
+// CodeOwnersConfig represents settings for CODEOWNERS awareness in review and pr
+type CodeOwnersConfig struct {
+	Disabled bool   `yaml:"disabled" mapstructure:"disabled"`
+	Path     string `yaml:"path" mapstructure:"path"` // CODEOWNERS file relative to the repository root (default: where GitHub and GitLab look)
+	// Conventions documents each owner's conventions, keyed by owner (e.g.
+	// "@acme/payments"); they are added to the review prompt when the owner's
+	// area is touched
+	Conventions map[string]string `yaml:"conventions" mapstructure:"conventions"`
+}
+
+// RepoMapConfig represents settings for the repository map cached in
+// .gitbuddy/repomap.json and added to the debug and chat system prompts
+type RepoMapConfig struct {
+	Disabled bool `yaml:"disabled" mapstructure:"disabled"`
+	MaxChars int  `yaml:"max_chars" mapstructure:"max_chars"` // Size of the map in the prompt; larger maps are trimmed
+}
+
+// DefaultRepoMapConfig returns the default repository map configuration
+func DefaultRepoMapConfig() *RepoMapConfig {
+	return &RepoMapConfig{
+		MaxChars: 6000, // About 1500 tokens
+	}
+}
+
+// Quota scopes: whose usage counts towards the limits
+const (
+	QuotaScopeUser    = "user"    // Usage of the current user in all projects
+	QuotaScopeProject = "project" // Usage of everyone in the current project
+	QuotaScopeAll     = "all"     // All usage in the ledger
+)
+
+// DefaultUsageLedger is the file model usage is recorded in
+const DefaultUsageLedger = "~/.gitbuddy/usage.jsonl"
+
+// QuotaConfig limits the tokens and cost spent on models per calendar month.
+// A warning is shown at 80% of a limit; at 100% model requests are refused
+// unless --ignore-quota is given.
+type QuotaConfig struct {
+	MonthlyTokens int64   `yaml:"monthly_tokens" mapstructure:"monthly_tokens"` // Tokens of all models (0 = unlimited)
+	MonthlyCost   float64 `yaml:"monthly_cost" mapstructure:"monthly_cost"`     // USD of all models, from their input_price and output_price (0 = unlimited)
+	// Models limits single models, keyed by their name in models
+	Models map[string]ModelQuota `yaml:"models" mapstructure:"models"`
+	Scope  string                `yaml:"scope" mapstructure:"scope"`   // user (default), project or all
+	Ledger string                `yaml:"ledger" mapstructure:"ledger"` // File usage is recorded in (default: ~/.gitbuddy/usage.jsonl)
+	// BackendURL is a shared usage service, so that the limits count the
+	// usage of a whole team: GET ?since=<RFC 3339 time> returns the entries of
+	// the month as a JSON array, POST records a JSON array of entries
+	BackendURL   string `yaml:"backend_url" mapstructure:"backend_url"`
+	BackendToken string `yaml:"backend_token" mapstructure:"backend_token"` // Bearer token of the backend, e.g. ${GITBUDDY_USAGE_TOKEN}
+}
+
+// ModelQuota limits the monthly usage of one model
+type ModelQuota struct {
+	MonthlyTokens int64   `yaml:"monthly_tokens" mapstructure:"monthly_tokens"`
+	MonthlyCost   float64 `yaml:"monthly_cost" mapstructure:"monthly_cost"`
+}
+
+// Enabled reports whether any limit is set
+func (q *QuotaConfig) Enabled() bool {
+	if q.MonthlyTokens > 0 || q.MonthlyCost > 0 {
+		return true
+	}
+	for _, m := range q.Models {
+		if m.MonthlyTokens > 0 || m.MonthlyCost > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates the quota configuration
+func (q *QuotaConfig) Validate() error {
+	if q.MonthlyTokens < 0 || q.MonthlyCost < 0 {
+		return fmt.Errorf("monthly_tokens and monthly_cost must not be negative")
+	}
+	for name, m := range q.Models {
+		if m.MonthlyTokens < 0 || m.MonthlyCost < 0 {
+			return fmt.Errorf("monthly_tokens and monthly_cost of model '%s' must not be negative", name)
+		}
+	}
+	switch q.Scope {
+	case "", QuotaScopeUser, QuotaScopeProject, QuotaScopeAll:
+	default:
+		return fmt.Errorf("invalid scope %q: must be user, project or all", q.Scope)
+	}
+	return nil
+}
+
+// Protected branch policies
+const (
+	ProtectedBranchConfirm = "confirm" // Ask before committing or pushing to the branch
+	ProtectedBranchBlock   = "block"   // Refuse to commit or push to the branch
+)
+
+// ProtectedBranchesConfig represents the branches that commit and commit
+// --push must not write to without confirmation
+type ProtectedBranchesConfig struct {
+	// Patterns are glob patterns of branch names, e.g. main or release/*;
+	// none protects no branch
+	Patterns []string `yaml:"patterns" mapstructure:"patterns"`
+	Policy   string   `yaml:"policy" mapstructure:"policy"` // confirm (default) or block
+}
+
+// Validate validates the protected branch configuration
+func (p *ProtectedBranchesConfig) Validate() error {
+	switch p.Policy {
+	case "", ProtectedBranchConfirm, ProtectedBranchBlock:
+	default:
+		return fmt.Errorf("invalid policy %q (valid: confirm, block)", p.Policy)
+	}
+	for _, pattern := range p.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Protects reports whether branch matches one of the patterns
+func (p *ProtectedBranchesConfig) Protects(branch string) bool {
+	for _, pattern := range p.Patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactionConfig represents output redaction settings for generated text
+type RedactionConfig struct {
+	DefaultProfile string                       `yaml:"default_profile" mapstructure:"default_profile"` // Applied when --redact is not given
+	Profiles       map[string]*RedactionProfile `yaml:"profiles" mapstructure:"profiles"`
+}
+
+// RedactionProfile lists identifiers removed from generated reviews, PR descriptions and reports
+type RedactionProfile struct {
+	Hostnames   []string `yaml:"hostnames" mapstructure:"hostnames"` // "*.corp.example.com" matches any subdomain
+	Usernames   []string `yaml:"usernames" mapstructure:"usernames"`
+	Identifiers []string `yaml:"identifiers" mapstructure:"identifiers"` // Customer names, project codes, ...
+	Patterns    []string `yaml:"patterns" mapstructure:"patterns"`       // Regular expressions
+	Replacement string   `yaml:"replacement" mapstructure:"replacement"` // Overrides the per-category placeholder
+}
+
+// DefaultAgentConfig returns the default agent configuration
+func DefaultAgentConfig() *AgentConfig {
+	return &AgentConfig{
+		MaxRepeatedToolCalls: 3,
+		MaxDiffLines:         3000,
+	}
 }
 
 // ReviewConfig represents the review command configuration
@@ -46,15 +318,59 @@ type ReviewConfig struct {
 	GrepMaxFileSize int `yaml:"grep_max_file_size" mapstructure:"grep_max_file_size"` // in MB
 	GrepTimeout     int `yaml:"grep_timeout" mapstructure:"grep_timeout"`             // in seconds
 	GrepMaxResults  int `yaml:"grep_max_results" mapstructure:"grep_max_results"`
+	// FunctionContextMaxLines caps the enclosing-function source appended to the
+	// staged diff before review (negative = disabled)
+	FunctionContextMaxLines int `yaml:"function_context_max_lines" mapstructure:"function_context_max_lines"`
+	// SeverityRules escalate issue severity by path and/or category after the review is submitted
+	SeverityRules []SeverityRuleConfig `yaml:"severity_rules" mapstructure:"severity_rules"`
+	// Migrations configures the extra review pass for schema migration files
+	Migrations *MigrationReviewConfig `yaml:"migrations" mapstructure:"migrations"`
+	// License configures the license header and dependency license checks (nil = disabled)
+	License *LicenseConfig `yaml:"license" mapstructure:"license"`
+	// DisableMetrics stops reviews from appending their statistics to the metrics file
+	DisableMetrics bool `yaml:"disable_metrics" mapstructure:"disable_metrics"`
+}
+
+// MigrationReviewConfig configures the migration review pass, which runs when
+// staged files match Paths
+type MigrationReviewConfig struct {
+	Disabled      bool                 `yaml:"disabled" mapstructure:"disabled"`
+	Paths         []string             `yaml:"paths" mapstructure:"paths"`                   // Patterns as in severity rules, e.g. "migrations", "*.sql"
+	SeverityRules []SeverityRuleConfig `yaml:"severity_rules" mapstructure:"severity_rules"` // Escalation rules for the migration findings
+}
+
+// DefaultMigrationPaths match the migration files of common frameworks
+var DefaultMigrationPaths = []string{"migrations", "migrate", "*.sql", "alembic/versions", "db/changelog"}
+
+// LicenseConfig configures the license checks run during review without the LLM
+type LicenseConfig struct {
+	Header      string   `yaml:"header" mapstructure:"header"`             // Required header of new files; {year} matches any year
+	HeaderPaths []string `yaml:"header_paths" mapstructure:"header_paths"` // Files that need the header, as in severity rules
+	Disallowed  []string `yaml:"disallowed" mapstructure:"disallowed"`     // SPDX identifiers, e.g. "GPL-3.0" also covers "GPL-3.0-only"
+}
+
+// DefaultLicenseHeaderPaths match common source files
+var DefaultLicenseHeaderPaths = []string{
+	"*.go", "*.py", "*.js", "*.jsx", "*.ts", "*.tsx", "*.java", "*.kt", "*.rs", "*.c", "*.h",
+	"*.cc", "*.cpp", "*.hpp", "*.rb", "*.php", "*.swift", "*.cs", "*.scala", "*.sh",
+}
+
+// SeverityRuleConfig escalates review issues matching any of Paths and any of Categories
+type SeverityRuleConfig struct {
+	Paths      []string `yaml:"paths" mapstructure:"paths"`           // e.g. "/payments/", "internal/**/auth*.go"
+	Categories []string `yaml:"categories" mapstructure:"categories"` // e.g. security, bug
+	Severity   string   `yaml:"severity" mapstructure:"severity"`     // error, warning or info
 }
 
 // DefaultReviewConfig returns the default review configuration
 func DefaultReviewConfig() *ReviewConfig {
 	return &ReviewConfig{
-		MaxLinesPerRead: 1000,
-		GrepMaxFileSize: 10,  // 10 MB
-		GrepTimeout:     10,  // 10 seconds
-		GrepMaxResults:  100, // 100 results
+		MaxLinesPerRead:         1000,
+		GrepMaxFileSize:         10,  // 10 MB
+		GrepTimeout:             10,  // 10 seconds
+		GrepMaxResults:          100, // 100 results
+		FunctionContextMaxLines: 400, // 400 lines of enclosing-function context
+		Migrations:              &MigrationReviewConfig{Paths: DefaultMigrationPaths},
 	}
 }
 
@@ -63,6 +379,7 @@ type DebugConfig struct {
 	MaxLinesPerRead        int    `yaml:"max_lines_per_read" mapstructure:"max_lines_per_read"`
 	IssuesDir              string `yaml:"issues_dir" mapstructure:"issues_dir"`
 	MaxIterations          int    `yaml:"max_iterations" mapstructure:"max_iterations"`
+	MaxTokens              int    `yaml:"max_tokens" mapstructure:"max_tokens"` // Token budget per session (0 = unlimited)
 	EnableCompression      bool   `yaml:"enable_compression" mapstructure:"enable_compression"`
 	CompressionThreshold   int    `yaml:"compression_threshold" mapstructure:"compression_threshold"`       // Number of messages before compression
 	CompressionKeepRecent  int    `yaml:"compression_keep_recent" mapstructure:"compression_keep_recent"`   // Number of recent messages to keep
@@ -71,6 +388,37 @@ type DebugConfig struct {
 	GrepTimeout            int    `yaml:"grep_timeout" mapstructure:"grep_timeout"`                         // in seconds
 	GrepMaxResults         int    `yaml:"grep_max_results" mapstructure:"grep_max_results"`
 	InteractiveMode        bool   `yaml:"interactive_mode" mapstructure:"interactive_mode"` // Enable post-execution interactive mode
+	ApprovePlan            bool   `yaml:"approve_plan" mapstructure:"approve_plan"`         // Approve the investigation plan before execution (interactive only)
+	// DisableAnswerReuse always asks request_feedback questions, instead of
+	// reusing the answers to questions asked before
+	DisableAnswerReuse bool `yaml:"disable_answer_reuse" mapstructure:"disable_answer_reuse"`
+	// TestCommands are the command prefixes the agent may run in the
+	// verification phase to test the root cause, e.g. ["go test"]; setting
+	// them enables test execution (override per run with --run-tests)
+	TestCommands []string `yaml:"test_commands" mapstructure:"test_commands"`
+	// FileIssues files an issue on the repository's GitHub for each follow-up
+	// task extracted from a report (override per run with --file-issues)
+	FileIssues  bool     `yaml:"file_issues" mapstructure:"file_issues"`
+	IssueLabels []string `yaml:"issue_labels" mapstructure:"issue_labels"` // Labels of the filed issues
+	// ToolResults configures how long tool results are truncated before
+	// they are sent to the model
+	ToolResults *ToolResultsConfig `yaml:"tool_results" mapstructure:"tool_results"`
+}
+
+// ToolResultsConfig configures the truncation of long tool results, which
+// keeps their start and end and the error and warning lines in between
+type ToolResultsConfig struct {
+	ToolResultLimitConfig `yaml:",inline" mapstructure:",squash"`
+	// Tools overrides the limits per tool, e.g. to keep more of git_diff
+	Tools map[string]ToolResultLimitConfig `yaml:"tools" mapstructure:"tools"`
+}
+
+// ToolResultLimitConfig configures how long results are truncated; zero
+// values use the defaults
+type ToolResultLimitConfig struct {
+	MaxLength    int      `yaml:"max_length" mapstructure:"max_length"`       // Characters kept of a longer result (default 5000)
+	TailRatio    float64  `yaml:"tail_ratio" mapstructure:"tail_ratio"`       // Share of them taken from the end (default 0.3)
+	KeepPatterns []string `yaml:"keep_patterns" mapstructure:"keep_patterns"` // Regular expressions of lines always kept, besides errors and warnings
 }
 
 // DefaultDebugConfig returns the default debug configuration
@@ -124,15 +472,19 @@ type RetryConfig struct {
 	MaxAttempts int     `yaml:"max_attempts" mapstructure:"max_attempts"`
 	BackoffBase float64 `yaml:"backoff_base" mapstructure:"backoff_base"` // in seconds
 	BackoffMax  float64 `yaml:"backoff_max" mapstructure:"backoff_max"`   // in seconds
+	// StreamIdleTimeout cancels and retries a model response when no chunk
+	// arrives for this many seconds (0 = default, negative = disabled)
+	StreamIdleTimeout int `yaml:"stream_idle_timeout" mapstructure:"stream_idle_timeout"`
 }
 
 // DefaultRetryConfig returns the default retry configuration
 func DefaultRetryConfig() *RetryConfig {
 	return &RetryConfig{
-		Enabled:     true,
-		MaxAttempts: 3,
-		BackoffBase: 1.0,
-		BackoffMax:  8.0,
+		Enabled:           true,
+		MaxAttempts:       3,
+		BackoffBase:       1.0,
+		BackoffMax:        8.0,
+		StreamIdleTimeout: 120,
 	}
 }
 
@@ -155,6 +507,8 @@ type SessionConfig struct {
 	SaveDir     string `yaml:"save_dir" mapstructure:"save_dir"`
 	AutoSave    bool   `yaml:"auto_save" mapstructure:"auto_save"`
 	MaxSessions int    `yaml:"max_sessions" mapstructure:"max_sessions"`
+	MaxAge      string `yaml:"max_age" mapstructure:"max_age"`   // Sessions not updated for this long are pruned, e.g. "30d", "12h" ("0" = never)
+	MaxSize     string `yaml:"max_size" mapstructure:"max_size"` // Quota of the session directory, e.g. "500MB"; the oldest sessions are pruned first ("0" = unlimited)
 }
 
 // DefaultSessionConfig returns the default session configuration
@@ -163,9 +517,21 @@ func DefaultSessionConfig() *SessionConfig {
 		SaveDir:     "./.gitbuddy/sessions",
 		AutoSave:    true,
 		MaxSessions: 10,
+		MaxAge:      "30d",
+		MaxSize:     "500MB",
 	}
 }
 
+// MaxAgeDuration returns MaxAge as a duration, 0 for no limit
+func (s *SessionConfig) MaxAgeDuration() (time.Duration, error) {
+	return ParseAge(s.MaxAge)
+}
+
+// MaxSizeBytes returns MaxSize in bytes, 0 for no limit
+func (s *SessionConfig) MaxSizeBytes() (int64, error) {
+	return ParseSize(s.MaxSize)
+}
+
 // Validate validates the session configuration
 func (s *SessionConfig) Validate() error {
 	if s.SaveDir == "" {
@@ -174,15 +540,100 @@ func (s *SessionConfig) Validate() error {
 	if s.MaxSessions < 0 {
 		return fmt.Errorf("max_sessions must be non-negative")
 	}
+	if _, err := s.MaxAgeDuration(); err != nil {
+		return fmt.Errorf("invalid max_age: %w", err)
+	}
+	if _, err := s.MaxSizeBytes(); err != nil {
+		return fmt.Errorf("invalid max_size: %w", err)
+	}
 	return nil
 }
 
+// ageUnits maps the units accepted by ParseAge to their length
+var ageUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseAge parses an age such as "30d", "2w", "12h" or "90m". "" and "0"
+// mean no limit.
+func ParseAge(value string) (time.Duration, error) {
+	amount, unit, err := splitQuantity(value)
+	if err != nil || amount == 0 {
+		return 0, err
+	}
+	length, ok := ageUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("%q has no unit (use m, h, d or w, e.g. 30d)", value)
+	}
+	return time.Duration(amount * float64(length)), nil
+}
+
+// sizeUnits maps the units accepted by ParseSize to bytes
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"kb": 1 << 10, "k": 1 << 10,
+	"mb": 1 << 20, "m": 1 << 20,
+	"gb": 1 << 30, "g": 1 << 30,
+}
+
+// ParseSize parses a size such as "500MB", "2GB" or "4096" (bytes). "" and
+// "0" mean no limit.
+func ParseSize(value string) (int64, error) {
+	amount, unit, err := splitQuantity(value)
+	if err != nil || amount == 0 {
+		return 0, err
+	}
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("%q has an unknown unit (use KB, MB or GB, e.g. 500MB)", value)
+	}
+	return int64(amount * float64(multiplier)), nil
+}
+
+// splitQuantity splits a value such as "30d" or "1.5 GB" into its
+// non-negative amount and lowercased unit; "" is 0
+func splitQuantity(value string) (float64, string, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" {
+		return 0, "", nil
+	}
+	i := 0
+	for i < len(v) && (v[i] >= '0' && v[i] <= '9' || v[i] == '.') {
+		i++
+	}
+	amount, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil || amount < 0 {
+		return 0, "", fmt.Errorf("%q is not a number with a unit", value)
+	}
+	return amount, strings.TrimSpace(v[i:]), nil
+}
+
 // ModelConfig represents a single model configuration
 type ModelConfig struct {
 	Provider string `yaml:"provider" mapstructure:"provider"`
 	APIKey   string `yaml:"api_key" mapstructure:"api_key"`
 	Model    string `yaml:"model" mapstructure:"model"`
 	BaseURL  string `yaml:"base_url" mapstructure:"base_url"`
+	// Prices in USD per million tokens, used to estimate the cost of sessions (optional)
+	InputPrice  float64 `yaml:"input_price,omitempty" mapstructure:"input_price"`
+	OutputPrice float64 `yaml:"output_price,omitempty" mapstructure:"output_price"`
+	// Models tried in order when a request to this one fails (optional)
+	Fallbacks []string `yaml:"fallbacks,omitempty" mapstructure:"fallbacks"`
+	// Overrides of the known capabilities of the model, for models gitbuddy doesn't know yet (optional)
+	Capabilities *CapabilitiesConfig `yaml:"capabilities,omitempty" mapstructure:"capabilities"`
+}
+
+// CapabilitiesConfig overrides the capabilities gitbuddy assumes for a model.
+// Unset fields keep the known value.
+type CapabilitiesConfig struct {
+	Tools       *bool `yaml:"tools,omitempty" mapstructure:"tools"`               // Reliable tool calling
+	Vision      *bool `yaml:"vision,omitempty" mapstructure:"vision"`             // Image input
+	JSONMode    *bool `yaml:"json_mode,omitempty" mapstructure:"json_mode"`       // Structured JSON output
+	StreamUsage *bool `yaml:"stream_usage,omitempty" mapstructure:"stream_usage"` // Token usage reported in streamed responses
+	MaxContext  int   `yaml:"max_context,omitempty" mapstructure:"max_context"`   // Context window in tokens
 }
 
 // Validate validates the model configuration
@@ -200,6 +651,9 @@ func (m *ModelConfig) Validate() error {
 	if m.Provider != "ollama" && m.APIKey == "" {
 		return fmt.Errorf("api_key is required for provider %s", m.Provider)
 	}
+	if m.Capabilities != nil && m.Capabilities.MaxContext < 0 {
+		return fmt.Errorf("capabilities.max_context must not be negative")
+	}
 	return nil
 }
 
@@ -221,6 +675,14 @@ func (c *Config) Validate() error {
 		if err := model.Validate(); err != nil {
 			return fmt.Errorf("invalid model '%s': %w", name, err)
 		}
+		for _, fallback := range model.Fallbacks {
+			if fallback == name {
+				return fmt.Errorf("invalid model '%s': it can't be its own fallback", name)
+			}
+			if _, ok := c.Models[fallback]; !ok {
+				return fmt.Errorf("invalid model '%s': fallback model '%s' not found in models configuration", name, fallback)
+			}
+		}
 	}
 
 	// Validate retry config if present
@@ -230,6 +692,18 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate quota config if present
+	if c.Quota != nil {
+		if err := c.Quota.Validate(); err != nil {
+			return fmt.Errorf("invalid quota configuration: %w", err)
+		}
+		for name := range c.Quota.Models {
+			if _, ok := c.Models[name]; !ok {
+				return fmt.Errorf("invalid quota configuration: model '%s' not found in models configuration", name)
+			}
+		}
+	}
+
 	// Validate session config if present
 	if c.Session != nil {
 		if err := c.Session.Validate(); err != nil {
@@ -237,6 +711,35 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.UI != nil && c.UI.Width < -1 {
+		return fmt.Errorf("invalid ui configuration: width must be -1 (no wrapping), 0 (terminal width) or a number of columns")
+	}
+	if c.UI != nil {
+		if _, err := c.UI.NotifyAfterDuration(); err != nil {
+			return fmt.Errorf("invalid ui configuration: notify_after: %w", err)
+		}
+	}
+
+	if c.Forge != nil && c.Forge.Type != "" && c.Forge.Type != "github" && c.Forge.Type != "gitlab" {
+		return fmt.Errorf("invalid forge configuration: type must be github, gitlab or empty")
+	}
+
+	if c.PR != nil && c.PR.Changelog != "" && c.PR.Changelog != "yaml" && c.PR.Changelog != "json" {
+		return fmt.Errorf("invalid pr configuration: changelog must be yaml, json or empty")
+	}
+
+	if c.ProtectedBranches != nil {
+		if err := c.ProtectedBranches.Validate(); err != nil {
+			return fmt.Errorf("invalid protected_branches configuration: %w", err)
+		}
+	}
+
+	for key := range c.Languages {
+		if _, ok := languageGroups[key]; !ok && key != LanguageGroupCode && key != LanguageGroupExplanation {
+			return fmt.Errorf("invalid languages configuration: unknown artifact '%s'", key)
+		}
+	}
+
 	return nil
 }
 
@@ -291,6 +794,66 @@ func (c *Config) GetLanguage(langParam string) string {
 	return "en"
 }
 
+// Artifacts whose language can be set in the languages config
+const (
+	ArtifactCommit       = "commit"
+	ArtifactPR           = "pr"
+	ArtifactTests        = "tests"
+	ArtifactReview       = "review"
+	ArtifactDebug        = "debug"
+	ArtifactReport       = "report"
+	ArtifactRefactorPlan = "refactor_plan"
+	ArtifactChat         = "chat"
+	ArtifactExplain      = "explain"
+)
+
+// Groups of artifacts whose language can be set together
+const (
+	LanguageGroupCode        = "code"        // Read with the code: commit messages, PR descriptions, tests
+	LanguageGroupExplanation = "explanation" // Read by the team: reviews, reports, answers
+)
+
+// languageGroups maps each artifact to its group
+var languageGroups = map[string]string{
+	ArtifactCommit:       LanguageGroupCode,
+	ArtifactPR:           LanguageGroupCode,
+	ArtifactTests:        LanguageGroupCode,
+	ArtifactReview:       LanguageGroupExplanation,
+	ArtifactDebug:        LanguageGroupExplanation,
+	ArtifactReport:       LanguageGroupExplanation,
+	ArtifactRefactorPlan: LanguageGroupExplanation,
+	ArtifactChat:         LanguageGroupExplanation,
+	ArtifactExplain:      LanguageGroupExplanation,
+}
+
+// GetArtifactLanguage returns the language to write artifact in, one of the
+// Artifact constants
+// Priority: parameter > languages.<artifact> > languages.<group> > GetLanguage
+func (c *Config) GetArtifactLanguage(artifact, langParam string) string {
+	if langParam != "" {
+		return langParam
+	}
+	if lang := c.Languages[artifact]; lang != "" {
+		return lang
+	}
+	if lang := c.Languages[languageGroups[artifact]]; lang != "" {
+		return lang
+	}
+	return c.GetLanguage("")
+}
+
+// GetVCS returns the version control system to use
+// Priority: parameter > config file > default (auto)
+func (c *Config) GetVCS(vcsParam string) string {
+	if vcsParam != "" {
+		return vcsParam
+	}
+	if c.VCS != "" {
+		return c.VCS
+	}
+	return "auto"
+}
+
 // GetReviewConfig returns the review configuration with defaults applied
 func (c *Config) GetReviewConfig() *ReviewConfig {
 	if c.Review == nil {
@@ -310,9 +873,95 @@ func (c *Config) GetReviewConfig() *ReviewConfig {
 	if c.Review.GrepMaxResults <= 0 {
 		c.Review.GrepMaxResults = defaults.GrepMaxResults
 	}
+	if c.Review.FunctionContextMaxLines == 0 {
+		c.Review.FunctionContextMaxLines = defaults.FunctionContextMaxLines
+	}
+	if c.Review.Migrations == nil {
+		c.Review.Migrations = defaults.Migrations
+	} else if len(c.Review.Migrations.Paths) == 0 {
+		c.Review.Migrations.Paths = defaults.Migrations.Paths
+	}
+	if c.Review.License != nil && len(c.Review.License.HeaderPaths) == 0 {
+		c.Review.License.HeaderPaths = DefaultLicenseHeaderPaths
+	}
 	return c.Review
 }
 
+// GetCodeOwnersConfig returns the CODEOWNERS configuration
+func (c *Config) GetCodeOwnersConfig() *CodeOwnersConfig {
+	if c.CodeOwners == nil {
+		return &CodeOwnersConfig{}
+	}
+	return c.CodeOwners
+}
+
+// GetForgeConfig returns the forge configuration with the token's environment
+// variable expanded
+func (c *Config) GetForgeConfig() *ForgeConfig {
+	if c.Forge == nil {
+		return &ForgeConfig{}
+	}
+	forge := *c.Forge
+	forge.Token = expandEnv(forge.Token)
+	return &forge
+}
+
+// GetProtectedBranchesConfig returns the protected branch configuration with
+// defaults applied
+func (c *Config) GetProtectedBranchesConfig() *ProtectedBranchesConfig {
+	if c.ProtectedBranches == nil {
+		return &ProtectedBranchesConfig{Policy: ProtectedBranchConfirm}
+	}
+	if c.ProtectedBranches.Policy == "" {
+		c.ProtectedBranches.Policy = ProtectedBranchConfirm
+	}
+	return c.ProtectedBranches
+}
+
+// GetQuotaConfig returns the quota configuration with defaults applied
+func (c *Config) GetQuotaConfig() *QuotaConfig {
+	if c.Quota == nil {
+		return &QuotaConfig{Scope: QuotaScopeUser, Ledger: DefaultUsageLedger}
+	}
+	if c.Quota.Scope == "" {
+		c.Quota.Scope = QuotaScopeUser
+	}
+	if c.Quota.Ledger == "" {
+		c.Quota.Ledger = DefaultUsageLedger
+	}
+	quota := *c.Quota
+	quota.BackendToken = expandEnv(quota.BackendToken)
+	return &quota
+}
+
+// ModelName returns the name model is configured under in models, or
+// provider/model when it is not configured, e.g. given with --model
+func (c *Config) ModelName(model *ModelConfig) string {
+	names := make([]string, 0, len(c.Models))
+	for name := range c.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := c.Models[name]
+		if m.Provider == model.Provider && m.Model == model.Model && m.BaseURL == model.BaseURL {
+			return name
+		}
+	}
+	return model.Provider + "/" + model.Model
+}
+
+// GetRepoMapConfig returns the repository map configuration with defaults applied
+func (c *Config) GetRepoMapConfig() *RepoMapConfig {
+	if c.RepoMap == nil {
+		return DefaultRepoMapConfig()
+	}
+	if c.RepoMap.MaxChars <= 0 {
+		c.RepoMap.MaxChars = DefaultRepoMapConfig().MaxChars
+	}
+	return c.RepoMap
+}
+
 // GetDebugConfig returns the debug configuration with defaults applied
 func (c *Config) GetDebugConfig() *DebugConfig {
 	if c.Debug == nil {
@@ -347,6 +996,122 @@ func (c *Config) GetDebugConfig() *DebugConfig {
 	return c.Debug
 }
 
+// GetAgentConfig returns the agent configuration with defaults applied
+func (c *Config) GetAgentConfig() *AgentConfig {
+	if c.Agent == nil {
+		return DefaultAgentConfig()
+	}
+	// Apply defaults for unset values
+	defaults := DefaultAgentConfig()
+	if c.Agent.MaxRepeatedToolCalls <= 0 {
+		c.Agent.MaxRepeatedToolCalls = defaults.MaxRepeatedToolCalls
+	}
+	if c.Agent.MaxDiffLines == 0 {
+		c.Agent.MaxDiffLines = defaults.MaxDiffLines
+	}
+	return c.Agent
+}
+
+// GetPromptExtension returns the system prompt extension for an agent,
+// combining the shared "all" entry with the agent-specific entry
+func (c *Config) GetPromptExtension(agentType string) string {
+	var parts []string
+	for _, key := range []string{"all", agentType} {
+		parts = append(parts, c.packPrompts[key]...)
+	}
+	for _, key := range []string{"all", agentType} {
+		if ext := strings.TrimSpace(c.PromptExtensions[key]); ext != "" {
+			parts = append(parts, ext)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// LoadPromptPacks loads the prompts of the enabled prompt packs from mgr.
+// Packs that fail to load are skipped, and their errors returned.
+func (c *Config) LoadPromptPacks(mgr *promptpack.Manager) error {
+	c.packPrompts = nil
+	var errs []error
+	for _, name := range c.PromptPacks {
+		pack, err := mgr.Load(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to load prompt pack %s: %w", name, err))
+			continue
+		}
+		if c.packPrompts == nil {
+			c.packPrompts = make(map[string][]string)
+		}
+		for _, key := range promptpack.PromptKeys {
+			if text := pack.Prompts[key]; text != "" {
+				c.packPrompts[key] = append(c.packPrompts[key], text)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetRedactionProfile returns the named redaction profile, or the default
+// profile when name is empty. It returns nil when redaction is disabled
+// (no profile configured, or name is "none").
+func (c *Config) GetRedactionProfile(name string) (*RedactionProfile, error) {
+	if name == "" && c.Redaction != nil {
+		name = c.Redaction.DefaultProfile
+	}
+	if name == "" || name == "none" {
+		return nil, nil
+	}
+	if c.Redaction == nil || c.Redaction.Profiles[name] == nil {
+		return nil, fmt.Errorf("redaction profile not found: %s", name)
+	}
+	return c.Redaction.Profiles[name], nil
+}
+
+// NotesEnabled reports whether AI metadata is recorded as git notes
+func (c *Config) NotesEnabled() bool {
+	return c.Notes != nil && c.Notes.Enabled
+}
+
+// GetCommitConfig returns the commit configuration, empty when not set
+func (c *Config) GetCommitConfig() *CommitConfig {
+	if c.Commit == nil {
+		return &CommitConfig{}
+	}
+	return c.Commit
+}
+
+// GetPRConfig returns the pr command configuration with defaults
+func (c *Config) GetPRConfig() *PRConfig {
+	if c.PR == nil {
+		return &PRConfig{}
+	}
+	return c.PR
+}
+
+// AccessibleUI reports whether accessible output is enabled in the config
+func (c *Config) AccessibleUI() bool {
+	return c.UI != nil && c.UI.Accessible
+}
+
+// UIWidth returns the column output is wrapped at, 0 to detect the terminal
+// width
+func (c *Config) UIWidth() int {
+	if c.UI == nil {
+		return 0
+	}
+	return c.UI.Width
+}
+
+// UINotifyAfter returns how long a run takes before desktop notifications
+// announce that it finished or waits for feedback, 0 for never
+func (c *Config) UINotifyAfter() time.Duration {
+	if c.UI == nil {
+		return 0
+	}
+	// Validated when the config was loaded
+	d, _ := c.UI.NotifyAfterDuration()
+	return d
+}
+
 // GetRetryConfig returns the retry configuration with defaults applied
 func (c *Config) GetRetryConfig() *RetryConfig {
 	if c.Retry == nil {
@@ -363,6 +1128,9 @@ func (c *Config) GetRetryConfig() *RetryConfig {
 	if c.Retry.BackoffMax < 0 {
 		c.Retry.BackoffMax = defaults.BackoffMax
 	}
+	if c.Retry.StreamIdleTimeout == 0 {
+		c.Retry.StreamIdleTimeout = defaults.StreamIdleTimeout
+	}
 	return c.Retry
 }
 
@@ -379,6 +1147,12 @@ func (c *Config) GetSessionConfig() *SessionConfig {
 	if c.Session.MaxSessions < 0 {
 		c.Session.MaxSessions = defaults.MaxSessions
 	}
+	if c.Session.MaxAge == "" {
+		c.Session.MaxAge = defaults.MaxAge
+	}
+	if c.Session.MaxSize == "" {
+		c.Session.MaxSize = defaults.MaxSize
+	}
 	return c.Session
 }
 
@@ -458,6 +1232,24 @@ func LoadFromFile(path string) (*Config, error) {
 // 2. Current directory .gitbuddy.yaml
 // 3. Home directory ~/.gitbuddy.yaml
 func Load(customPath string) (*Config, error) {
+	cfg, err := loadConfigFile(customPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.PromptPacks) > 0 {
+		// A broken pack only loses its prompts, so commands keep working
+		dir, err := promptpack.DefaultDir()
+		if err != nil {
+			log.Warn("Skipping prompt packs: %v", err)
+		} else if err := cfg.LoadPromptPacks(promptpack.NewManager(dir)); err != nil {
+			log.Warn("Skipping prompt packs that failed to load: %v", err)
+		}
+	}
+	return cfg, nil
+}
+
+// loadConfigFile finds and loads the configuration file (see Load)
+func loadConfigFile(customPath string) (*Config, error) {
 	// If custom path is provided, use it exclusively
 	if customPath != "" {
 		return LoadFromFile(customPath)
@@ -481,3 +1273,22 @@ func Load(customPath string) (*Config, error) {
 
 	return nil, fmt.Errorf("no configuration file found. Run 'gitbuddy init' to create one")
 }
+
+// FindConfigFile returns the path of the nearest configuration file: the
+// custom path if provided, otherwise .gitbuddy.yaml in the current directory
+// or in the home directory
+func FindConfigFile(customPath string) (string, error) {
+	if customPath != "" {
+		return customPath, nil
+	}
+	candidates := []string{".gitbuddy.yaml"}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, ".gitbuddy.yaml"))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no configuration file found. Run 'gitbuddy init' to create one")
+}
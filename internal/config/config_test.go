@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/huimingz/gitbuddy-go/internal/promptpack"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -80,6 +82,16 @@ func TestModelConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "api_key is required",
 		},
+		{
+			name: "negative max context",
+			config: ModelConfig{
+				Provider:     "ollama",
+				Model:        "my-finetune",
+				Capabilities: &CapabilitiesConfig{MaxContext: -1},
+			},
+			wantErr: true,
+			errMsg:  "max_context",
+		},
 	}
 
 	for _, tt := range tests {
@@ -225,6 +237,23 @@ func TestConfig_GetLanguage(t *testing.T) {
 	})
 }
 
+func TestConfig_GetArtifactLanguage(t *testing.T) {
+	cfg := &Config{
+		Language:  "de",
+		Languages: map[string]string{"code": "en", "explanation": "zh", "report": "ja"},
+	}
+	assert.Equal(t, "en", cfg.GetArtifactLanguage(ArtifactCommit, ""))
+	assert.Equal(t, "en", cfg.GetArtifactLanguage(ArtifactPR, ""))
+	assert.Equal(t, "zh", cfg.GetArtifactLanguage(ArtifactReview, ""))
+	assert.Equal(t, "zh", cfg.GetArtifactLanguage(ArtifactDebug, ""))
+	assert.Equal(t, "ja", cfg.GetArtifactLanguage(ArtifactReport, ""), "an artifact overrides its group")
+	assert.Equal(t, "fr", cfg.GetArtifactLanguage(ArtifactReview, "fr"), "the parameter overrides the config")
+
+	t.Setenv("GITBUDDY_LANG", "ko")
+	assert.Equal(t, "zh", cfg.GetArtifactLanguage(ArtifactChat, ""), "the env variable only replaces language")
+	assert.Equal(t, "ko", (&Config{Language: "de"}).GetArtifactLanguage(ArtifactCommit, ""))
+}
+
 func TestLoadFromFile(t *testing.T) {
 	// Create a temporary config file
 	tmpDir := t.TempDir()
@@ -241,6 +270,9 @@ models:
     provider: openai
     api_key: sk-openai
     model: gpt-4o
+    capabilities:
+      tools: false
+      max_context: 32000
 language: zh
 `
 	err := os.WriteFile(configPath, []byte(configContent), 0644)
@@ -257,6 +289,14 @@ language: zh
 	assert.True(t, ok)
 	assert.Equal(t, "deepseek", deepseek.Provider)
 	assert.Equal(t, "deepseek-chat", deepseek.Model)
+	assert.Nil(t, deepseek.Capabilities)
+
+	gpt4 := cfg.Models["gpt4"]
+	require.NotNil(t, gpt4.Capabilities)
+	require.NotNil(t, gpt4.Capabilities.Tools)
+	assert.False(t, *gpt4.Capabilities.Tools)
+	assert.Nil(t, gpt4.Capabilities.Vision)
+	assert.Equal(t, 32000, gpt4.Capabilities.MaxContext)
 }
 
 func TestLoadFromFile_NotFound(t *testing.T) {
@@ -321,6 +361,177 @@ func TestConfig_Validate(t *testing.T) {
 		err := cfg.Validate()
 		assert.Error(t, err)
 	})
+
+	t.Run("fallback models", func(t *testing.T) {
+		cfg := &Config{
+			DefaultModel: "deepseek",
+			Models: map[string]ModelConfig{
+				"deepseek": {
+					Provider:  "deepseek",
+					APIKey:    "sk-test",
+					Model:     "deepseek-chat",
+					Fallbacks: []string{"openai"},
+				},
+				"openai": {
+					Provider: "openai",
+					APIKey:   "sk-openai",
+					Model:    "gpt-4o",
+				},
+			},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Models["openai"] = ModelConfig{Provider: "openai", APIKey: "sk-openai", Model: "gpt-4o", Fallbacks: []string{"claude"}}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "fallback model 'claude' not found")
+
+		cfg.Models["openai"] = ModelConfig{Provider: "openai", APIKey: "sk-openai", Model: "gpt-4o", Fallbacks: []string{"openai"}}
+		err = cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "its own fallback")
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		cfg := &Config{
+			Models: map[string]ModelConfig{
+				"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"},
+			},
+			Quota: &QuotaConfig{
+				MonthlyCost: 50,
+				Models:      map[string]ModelQuota{"deepseek": {MonthlyTokens: 1000000}},
+			},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Quota.Models["openai"] = ModelQuota{MonthlyTokens: 1000}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "model 'openai' not found")
+
+		delete(cfg.Quota.Models, "openai")
+		cfg.Quota.MonthlyTokens = -1
+		assert.Error(t, cfg.Validate())
+
+		cfg.Quota.MonthlyTokens = 0
+		cfg.Quota.Scope = "team"
+		err = cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid scope")
+	})
+
+	t.Run("languages", func(t *testing.T) {
+		cfg := &Config{
+			Models: map[string]ModelConfig{
+				"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"},
+			},
+			Languages: map[string]string{"code": "en", "explanation": "zh", "review": "ja"},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Languages["commits"] = "en"
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown artifact 'commits'")
+	})
+
+	t.Run("ui width", func(t *testing.T) {
+		cfg := &Config{
+			Models: map[string]ModelConfig{
+				"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"},
+			},
+			UI: &UIConfig{Width: -1},
+		}
+		assert.NoError(t, cfg.Validate())
+		assert.Equal(t, -1, cfg.UIWidth())
+
+		cfg.UI.Width = -2
+		assert.ErrorContains(t, cfg.Validate(), "width")
+	})
+
+	t.Run("ui notify_after", func(t *testing.T) {
+		cfg := &Config{
+			Models: map[string]ModelConfig{
+				"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"},
+			},
+		}
+		assert.Zero(t, cfg.UINotifyAfter())
+
+		cfg.UI = &UIConfig{NotifyAfter: "120s"}
+		assert.NoError(t, cfg.Validate())
+		assert.Equal(t, 2*time.Minute, cfg.UINotifyAfter())
+
+		cfg.UI.NotifyAfter = "0"
+		assert.NoError(t, cfg.Validate())
+		assert.Zero(t, cfg.UINotifyAfter())
+
+		cfg.UI.NotifyAfter = "120"
+		assert.ErrorContains(t, cfg.Validate(), "notify_after")
+		cfg.UI.NotifyAfter = "-1m"
+		assert.ErrorContains(t, cfg.Validate(), "notify_after")
+	})
+
+	t.Run("pr changelog", func(t *testing.T) {
+		cfg := &Config{
+			Models: map[string]ModelConfig{
+				"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"},
+			},
+		}
+		assert.Empty(t, cfg.GetPRConfig().Changelog)
+
+		cfg.PR = &PRConfig{Changelog: "json"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.PR.Changelog = "toml"
+		assert.ErrorContains(t, cfg.Validate(), "changelog must be yaml, json or empty")
+	})
+}
+
+func TestConfig_GetQuotaConfig(t *testing.T) {
+	cfg := &Config{}
+	quota := cfg.GetQuotaConfig()
+	assert.False(t, quota.Enabled())
+	assert.Equal(t, QuotaScopeUser, quota.Scope)
+	assert.Equal(t, DefaultUsageLedger, quota.Ledger)
+
+	t.Setenv("GITBUDDY_TEST_USAGE_TOKEN", "secret")
+	cfg.Quota = &QuotaConfig{
+		Models:       map[string]ModelQuota{"deepseek": {MonthlyCost: 10}},
+		BackendToken: "${GITBUDDY_TEST_USAGE_TOKEN}",
+	}
+	quota = cfg.GetQuotaConfig()
+	assert.True(t, quota.Enabled())
+	assert.Equal(t, "secret", quota.BackendToken)
+	assert.Equal(t, "${GITBUDDY_TEST_USAGE_TOKEN}", cfg.Quota.BackendToken)
+}
+
+func TestConfig_GetForgeConfig(t *testing.T) {
+	cfg := &Config{}
+	assert.Empty(t, cfg.GetForgeConfig().Token)
+
+	t.Setenv("GITBUDDY_TEST_GITHUB_TOKEN", "secret")
+	cfg.Forge = &ForgeConfig{Token: "${GITBUDDY_TEST_GITHUB_TOKEN}", APIURL: "https://ghe.example.com/api/v3"}
+	forge := cfg.GetForgeConfig()
+	assert.Equal(t, "secret", forge.Token)
+	assert.Equal(t, "https://ghe.example.com/api/v3", forge.APIURL)
+	assert.Equal(t, "${GITBUDDY_TEST_GITHUB_TOKEN}", cfg.Forge.Token)
+
+	cfg.Models = map[string]ModelConfig{"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat"}}
+	cfg.Forge.Type = "gitlab"
+	assert.NoError(t, cfg.Validate())
+	cfg.Forge.Type = "gitea"
+	assert.ErrorContains(t, cfg.Validate(), "type must be github, gitlab or empty")
+}
+
+func TestConfig_ModelName(t *testing.T) {
+	cfg := &Config{
+		Models: map[string]ModelConfig{
+			"fast":  {Provider: "openai", Model: "gpt-4o-mini"},
+			"smart": {Provider: "openai", Model: "gpt-4o"},
+		},
+	}
+	assert.Equal(t, "smart", cfg.ModelName(&ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: "sk-expanded"}))
+	assert.Equal(t, "ollama/llama3", cfg.ModelName(&ModelConfig{Provider: "ollama", Model: "llama3"}))
 }
 
 func TestSupportedProviders(t *testing.T) {
@@ -508,19 +719,28 @@ func TestConfig_GetRetryConfig(t *testing.T) {
 			name: "returns configured values",
 			config: &Config{
 				Retry: &RetryConfig{
-					Enabled:     false,
-					MaxAttempts: 5,
-					BackoffBase: 2.0,
-					BackoffMax:  16.0,
+					Enabled:           false,
+					MaxAttempts:       5,
+					BackoffBase:       2.0,
+					BackoffMax:        16.0,
+					StreamIdleTimeout: -1,
 				},
 			},
 			want: &RetryConfig{
-				Enabled:     false,
-				MaxAttempts: 5,
-				BackoffBase: 2.0,
-				BackoffMax:  16.0,
+				Enabled:           false,
+				MaxAttempts:       5,
+				BackoffBase:       2.0,
+				BackoffMax:        16.0,
+				StreamIdleTimeout: -1,
 			},
 		},
+		{
+			name: "applies default stream idle timeout",
+			config: &Config{
+				Retry: &RetryConfig{Enabled: true, MaxAttempts: 3, BackoffBase: 1.0, BackoffMax: 8.0},
+			},
+			want: DefaultRetryConfig(),
+		},
 	}
 
 	for _, tt := range tests {
@@ -551,13 +771,24 @@ func TestConfig_GetSessionConfig(t *testing.T) {
 					SaveDir:     "/tmp/sessions",
 					AutoSave:    false,
 					MaxSessions: 20,
+					MaxAge:      "0",
+					MaxSize:     "2GB",
 				},
 			},
 			want: &SessionConfig{
 				SaveDir:     "/tmp/sessions",
 				AutoSave:    false,
 				MaxSessions: 20,
+				MaxAge:      "0",
+				MaxSize:     "2GB",
+			},
+		},
+		{
+			name: "applies default limits",
+			config: &Config{
+				Session: &SessionConfig{SaveDir: "/tmp/sessions", MaxSessions: 20},
 			},
+			want: &SessionConfig{SaveDir: "/tmp/sessions", MaxSessions: 20, MaxAge: "30d", MaxSize: "500MB"},
 		},
 	}
 
@@ -569,6 +800,175 @@ func TestConfig_GetSessionConfig(t *testing.T) {
 	}
 }
 
+func TestParseAgeAndSize(t *testing.T) {
+	age, err := ParseAge("30d")
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, age)
+	age, err = ParseAge(" 1.5h ")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, age)
+	age, err = ParseAge("0")
+	require.NoError(t, err)
+	assert.Zero(t, age)
+	_, err = ParseAge("30")
+	assert.Error(t, err)
+	_, err = ParseAge("soon")
+	assert.Error(t, err)
+
+	size, err := ParseSize("500MB")
+	require.NoError(t, err)
+	assert.Equal(t, int64(500<<20), size)
+	size, err = ParseSize("2 gb")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2<<30), size)
+	size, err = ParseSize("4096")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096), size)
+	_, err = ParseSize("5TB")
+	assert.Error(t, err)
+
+	assert.Error(t, (&SessionConfig{SaveDir: "s", MaxAge: "a month"}).Validate())
+	assert.NoError(t, DefaultSessionConfig().Validate())
+}
+
+func TestConfig_GetAgentConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   *AgentConfig
+	}{
+		{
+			name:   "returns default when nil",
+			config: &Config{},
+			want:   DefaultAgentConfig(),
+		},
+		{
+			name: "applies default for unset values",
+			config: &Config{
+				Agent: &AgentConfig{},
+			},
+			want: &AgentConfig{MaxRepeatedToolCalls: 3, MaxDiffLines: 3000},
+		},
+		{
+			name: "returns configured values",
+			config: &Config{
+				Agent: &AgentConfig{MaxRepeatedToolCalls: 5, MaxDiffLines: 500},
+			},
+			want: &AgentConfig{MaxRepeatedToolCalls: 5, MaxDiffLines: 500},
+		},
+		{
+			name: "keeps a disabled diff limit",
+			config: &Config{
+				Agent: &AgentConfig{MaxDiffLines: -1},
+			},
+			want: &AgentConfig{MaxRepeatedToolCalls: 3, MaxDiffLines: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.GetAgentConfig()
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAgentConfig_ToolLanguageFor(t *testing.T) {
+	assert.Equal(t, "", (&AgentConfig{}).ToolLanguageFor("zh"))
+	assert.Equal(t, "zh", (&AgentConfig{ToolLanguage: "auto"}).ToolLanguageFor("zh"))
+	assert.Equal(t, "ja", (&AgentConfig{ToolLanguage: "ja"}).ToolLanguageFor("zh"))
+}
+
+func TestConfig_GetReviewConfig_Migrations(t *testing.T) {
+	assert.Equal(t, DefaultMigrationPaths, (&Config{}).GetReviewConfig().Migrations.Paths)
+
+	cfg := &Config{Review: &ReviewConfig{Migrations: &MigrationReviewConfig{Disabled: true}}}
+	migrations := cfg.GetReviewConfig().Migrations
+	assert.True(t, migrations.Disabled)
+	assert.Equal(t, DefaultMigrationPaths, migrations.Paths, "empty paths use the defaults")
+
+	cfg = &Config{Review: &ReviewConfig{Migrations: &MigrationReviewConfig{Paths: []string{"schema/*.up.sql"}}}}
+	assert.Equal(t, []string{"schema/*.up.sql"}, cfg.GetReviewConfig().Migrations.Paths)
+}
+
+func TestConfig_GetReviewConfig_License(t *testing.T) {
+	assert.Nil(t, (&Config{}).GetReviewConfig().License, "the license check is opt-in")
+
+	cfg := &Config{Review: &ReviewConfig{License: &LicenseConfig{Disallowed: []string{"GPL-3.0"}}}}
+	assert.Equal(t, DefaultLicenseHeaderPaths, cfg.GetReviewConfig().License.HeaderPaths)
+
+	cfg = &Config{Review: &ReviewConfig{License: &LicenseConfig{HeaderPaths: []string{"src/**/*.ts"}}}}
+	assert.Equal(t, []string{"src/**/*.ts"}, cfg.GetReviewConfig().License.HeaderPaths)
+}
+
+func TestConfig_GetRepoMapConfig(t *testing.T) {
+	assert.Equal(t, DefaultRepoMapConfig(), (&Config{}).GetRepoMapConfig())
+
+	cfg := &Config{RepoMap: &RepoMapConfig{Disabled: true}}
+	assert.True(t, cfg.GetRepoMapConfig().Disabled)
+	assert.Equal(t, 6000, cfg.GetRepoMapConfig().MaxChars)
+}
+
+func TestConfig_GetPromptExtension(t *testing.T) {
+	cfg := &Config{
+		PromptExtensions: map[string]string{
+			"all":    "Use British spelling.\n",
+			"review": "Never suggest panic(), we use errors.Wrap.",
+		},
+	}
+
+	assert.Equal(t, "Use British spelling.\n\nNever suggest panic(), we use errors.Wrap.", cfg.GetPromptExtension("review"))
+	assert.Equal(t, "Use British spelling.", cfg.GetPromptExtension("commit"))
+	assert.Empty(t, (&Config{}).GetPromptExtension("review"))
+}
+
+func TestConfig_GetRedactionProfile(t *testing.T) {
+	external := &RedactionProfile{Hostnames: []string{"*.corp.example.com"}}
+	cfg := &Config{
+		Redaction: &RedactionConfig{
+			DefaultProfile: "external",
+			Profiles:       map[string]*RedactionProfile{"external": external},
+		},
+	}
+
+	profile, err := cfg.GetRedactionProfile("")
+	require.NoError(t, err)
+	assert.Same(t, external, profile)
+
+	profile, err = cfg.GetRedactionProfile("none")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+
+	_, err = cfg.GetRedactionProfile("missing")
+	assert.ErrorContains(t, err, "redaction profile not found")
+
+	profile, err = (&Config{}).GetRedactionProfile("")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestConfig_NotesEnabled(t *testing.T) {
+	assert.False(t, (&Config{}).NotesEnabled())
+	assert.False(t, (&Config{Notes: &NotesConfig{}}).NotesEnabled())
+	assert.True(t, (&Config{Notes: &NotesConfig{Enabled: true}}).NotesEnabled())
+}
+
+func TestProtectedBranchesConfig(t *testing.T) {
+	cfg := (&Config{}).GetProtectedBranchesConfig()
+	assert.Equal(t, ProtectedBranchConfirm, cfg.Policy)
+	assert.False(t, cfg.Protects("main"))
+
+	cfg = &ProtectedBranchesConfig{Patterns: []string{"main", "release/*"}}
+	assert.True(t, cfg.Protects("main"))
+	assert.True(t, cfg.Protects("release/1.2"))
+	assert.False(t, cfg.Protects("release/1.2/hotfix"))
+	assert.False(t, cfg.Protects("feature/main"))
+	assert.NoError(t, cfg.Validate())
+
+	assert.Error(t, (&ProtectedBranchesConfig{Patterns: []string{"main"}, Policy: "warn"}).Validate())
+	assert.Error(t, (&ProtectedBranchesConfig{Patterns: []string{"release/["}}).Validate())
+}
+
 func TestDefaultDebugConfig_MaxIterations(t *testing.T) {
 	cfg := DefaultDebugConfig()
 	assert.Equal(t, 50, cfg.MaxIterations, "Default max iterations should be 50")
@@ -607,3 +1007,68 @@ pr_template:
 	assert.Contains(t, template, "## Summary")
 	assert.Contains(t, template, "## Changes")
 }
+
+func TestLoadFromFile_WithToolResults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gitbuddy.yaml")
+	configContent := `
+default_model: deepseek
+models:
+  deepseek:
+    provider: deepseek
+    api_key: sk-test
+    model: deepseek-chat
+debug:
+  tool_results:
+    max_length: 8000
+    keep_patterns: ["^--- FAIL"]
+    tools:
+      git_diff:
+        max_length: 20000
+        tail_ratio: 0.5
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	cfg, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	results := cfg.GetDebugConfig().ToolResults
+	require.NotNil(t, results)
+	assert.Equal(t, 8000, results.MaxLength)
+	assert.Equal(t, []string{"^--- FAIL"}, results.KeepPatterns)
+	assert.Equal(t, ToolResultLimitConfig{MaxLength: 20000, TailRatio: 0.5}, results.Tools["git_diff"])
+}
+
+func TestConfig_LoadPromptPacks(t *testing.T) {
+	dir := t.TempDir()
+	packDir := filepath.Join(dir, "team")
+	require.NoError(t, os.MkdirAll(packDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "all.md"), []byte("Team rules."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "review.md"), []byte("Check error wrapping."), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "packs.json"), []byte(`[{"name":"team","url":"file:///team","commit":"abc"}]`), 0644))
+
+	cfg := &Config{
+		PromptPacks:      []string{"team"},
+		PromptExtensions: map[string]string{"review": "Project rules."},
+	}
+	require.NoError(t, cfg.LoadPromptPacks(promptpack.NewManager(dir)))
+
+	assert.Equal(t, "Team rules.\n\nCheck error wrapping.\n\nProject rules.", cfg.GetPromptExtension("review"))
+	assert.Equal(t, "Team rules.", cfg.GetPromptExtension("commit"))
+
+	// A missing pack is skipped, the others are still loaded
+	cfg.PromptPacks = []string{"missing", "team"}
+	err := cfg.LoadPromptPacks(promptpack.NewManager(dir))
+	assert.ErrorContains(t, err, "failed to load prompt pack missing")
+	assert.Equal(t, "Team rules.", cfg.GetPromptExtension("commit"))
+}
+
+func TestLoad_MissingPromptPack(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configPath := filepath.Join(home, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("prompt_packs: [missing]\n"), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err, "a missing pack doesn't break every command")
+	assert.Equal(t, []string{"missing"}, cfg.PromptPacks)
+	assert.Empty(t, cfg.GetPromptExtension("commit"))
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// SetDefaultModel sets default_model in the configuration file at path to
+// name. Only that line is rewritten, so the comments and the layout of the
+// rest of the file are kept.
+func SetDefaultModel(path, name string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	updated, err := setTopLevelScalar(data, "default_model", name)
+	if err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, updated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// setTopLevelScalar sets the value of key in the top-level mapping of the
+// YAML document data, adding the key at the top when it is missing
+func setTopLevelScalar(data []byte, key, value string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	scalar := strings.TrimSpace(string(encoded))
+
+	if len(doc.Content) == 0 {
+		return append([]byte(key+": "+scalar+"\n"), data...), nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode || len(root.Content) == 0 {
+		return nil, fmt.Errorf("the document is not a mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if k.Value != key {
+			continue
+		}
+		if v.Kind != yaml.ScalarNode || v.Line != k.Line {
+			return nil, fmt.Errorf("%s is not a single-line value", key)
+		}
+		lines := strings.Split(string(data), "\n")
+		line := lines[v.Line-1][:v.Column-1] + scalar
+		if v.LineComment != "" {
+			line += " " + v.LineComment
+		}
+		lines[v.Line-1] = line
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+
+	// Add the key before the first key, after the comments heading the file
+	lines := strings.Split(string(data), "\n")
+	at := root.Content[0].Line - 1
+	if root.Content[0].HeadComment != "" {
+		at -= strings.Count(root.Content[0].HeadComment, "\n") + 1
+	}
+	if at < 0 {
+		at = 0
+	}
+	lines = append(lines[:at], append([]string{key + ": " + scalar}, lines[at:]...)...)
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "replaces the value",
+			content: "# GitBuddy\ndefault_model: deepseek # the cheap one\nmodels:\n  deepseek:\n    provider: deepseek\n",
+			want:    "# GitBuddy\ndefault_model: openai # the cheap one\nmodels:\n  deepseek:\n    provider: deepseek\n",
+		},
+		{
+			name:    "replaces a quoted value",
+			content: "default_model: \"deepseek\"\nlanguage: en\n",
+			want:    "default_model: openai\nlanguage: en\n",
+		},
+		{
+			name:    "adds the key after the heading comments",
+			content: "# GitBuddy\n\n# Models\nmodels:\n  openai:\n    provider: openai\n",
+			want:    "# GitBuddy\n\ndefault_model: openai\n# Models\nmodels:\n  openai:\n    provider: openai\n",
+		},
+		{
+			name:    "ignores nested keys",
+			content: "models:\n  openai:\n    default_model: x\n",
+			want:    "default_model: openai\nmodels:\n  openai:\n    default_model: x\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitbuddy.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			require.NoError(t, SetDefaultModel(path, "openai"))
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestSetDefaultModel_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitbuddy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("default_model:\n  - a\n"), 0600))
+	assert.Error(t, SetDefaultModel(path, "openai"))
+	assert.Error(t, SetDefaultModel(filepath.Join(t.TempDir(), "missing.yaml"), "openai"))
+}
+
+func TestFindConfigFile(t *testing.T) {
+	path, err := FindConfigFile("custom.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "custom.yaml", path)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	_, err = FindConfigFile("")
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(".gitbuddy.yaml", []byte("models: {}\n"), 0600))
+	path, err = FindConfigFile("")
+	require.NoError(t, err)
+	assert.Equal(t, ".gitbuddy.yaml", path)
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WorkflowNames returns the names of the configured workflows, sorted
+func (c *Config) WorkflowNames() []string {
+	names := make([]string, 0, len(c.Workflows))
+	for name := range c.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetWorkflow returns the steps of the named workflow, each split into the
+// command and its arguments
+func (c *Config) GetWorkflow(name string) ([][]string, error) {
+	steps, ok := c.Workflows[name]
+	if !ok {
+		if len(c.Workflows) == 0 {
+			return nil, fmt.Errorf("workflow not found: %s (no workflows configured)", name)
+		}
+		return nil, fmt.Errorf("workflow not found: %s (available: %s)", name, strings.Join(c.WorkflowNames(), ", "))
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("workflow %s has no steps", name)
+	}
+
+	parsed := make([][]string, 0, len(steps))
+	for i, step := range steps {
+		args, err := SplitCommandLine(step)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %d of workflow %s: %w", i+1, name, err)
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("step %d of workflow %s is empty", i+1, name)
+		}
+		parsed = append(parsed, args)
+	}
+	return parsed, nil
+}
+
+// SplitCommandLine splits a command line into arguments like a POSIX shell
+// does: single and double quotes group words, and a backslash escapes the
+// next character outside single quotes. Nothing is expanded.
+func SplitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("trailing backslash in %q", line)
+			}
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
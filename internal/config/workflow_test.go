@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "commit", want: []string{"commit"}},
+		{line: "  review   --severity error ", want: []string{"review", "--severity", "error"}},
+		{line: `review -c "auth module, v2"`, want: []string{"review", "-c", "auth module, v2"}},
+		{line: `pr -c 'say "hi"'`, want: []string{"pr", "-c", `say "hi"`}},
+		{line: `debug it\'s\ broken ""`, want: []string{"debug", "it's broken", ""}},
+		{line: "", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			args, err := SplitCommandLine(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+
+	_, err := SplitCommandLine(`review -c "open`)
+	assert.ErrorContains(t, err, "unterminated quote")
+	_, err = SplitCommandLine(`review \`)
+	assert.ErrorContains(t, err, "trailing backslash")
+}
+
+func TestConfig_GetWorkflow(t *testing.T) {
+	cfg := &Config{Workflows: map[string][]string{
+		"ship":  {"commit", "review --severity error", "pr --base main"},
+		"empty": {},
+		"blank": {"commit", "  "},
+	}}
+
+	steps, err := cfg.GetWorkflow("ship")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"commit"}, {"review", "--severity", "error"}, {"pr", "--base", "main"}}, steps)
+
+	_, err = cfg.GetWorkflow("missing")
+	assert.EqualError(t, err, "workflow not found: missing (available: blank, empty, ship)")
+	_, err = cfg.GetWorkflow("empty")
+	assert.EqualError(t, err, "workflow empty has no steps")
+	_, err = cfg.GetWorkflow("blank")
+	assert.EqualError(t, err, "step 2 of workflow blank is empty")
+
+	_, err = (&Config{}).GetWorkflow("ship")
+	assert.EqualError(t, err, "workflow not found: ship (no workflows configured)")
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// ErrNotRunning is returned by Connect when no daemon serves the directory
+var ErrNotRunning = errors.New("daemon is not running")
+
+// callTimeout bounds a call, so that a stuck daemon doesn't hold up a command
+// longer than computing the state itself would
+const callTimeout = 10 * time.Second
+
+// Client calls the daemon of a directory
+type Client struct {
+	path string
+}
+
+// Connect returns a client of the daemon serving workDir, or ErrNotRunning.
+// It only checks for the socket, so commands pay nothing without a daemon.
+// A socket that isn't the user's is refused, as its daemon could serve
+// anything.
+func Connect(workDir string) (*Client, error) {
+	path := SocketPath(workDir)
+	if err := checkSocket(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotRunning
+		}
+		return nil, fmt.Errorf("refusing to use the daemon: %w", err)
+	}
+	return &Client{path: path}, nil
+}
+
+// RepoMap returns the repository map rendered within maxChars
+func (c *Client) RepoMap(ctx context.Context, maxChars int) (string, error) {
+	resp, err := c.call(ctx, Request{Method: MethodRepoMap, MaxChars: maxChars})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+// StagedDiff returns the diff of the staged changes
+func (c *Client) StagedDiff(ctx context.Context) (string, error) {
+	resp, err := c.call(ctx, Request{Method: MethodStagedDiff})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+// Status returns the status of the daemon
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	resp, err := c.call(ctx, Request{Method: MethodStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, errors.New("daemon returned no status")
+	}
+	return resp.Status, nil
+}
+
+// Stop asks the daemon to exit
+func (c *Client) Stop(ctx context.Context) error {
+	_, err := c.call(ctx, Request{Method: MethodStop})
+	return err
+}
+
+func (c *Client) call(ctx context.Context, req Request) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the daemon: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to the daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response of the daemon: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,412 @@
+// Package daemon keeps the per-repository state the commands start from warm
+// in a background process: the repository map and the staged diff. The
+// daemon answers on a unix socket; commands ask it first and compute the
+// state themselves when no daemon runs.
+//
+// Every request is one JSON line answered by one JSON line on its own
+// connection.
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/repomap"
+)
+
+// Methods of the daemon
+const (
+	MethodRepoMap    = "repo_map"    // Rendered repository map
+	MethodStagedDiff = "staged_diff" // Diff of the staged changes
+	MethodStatus     = "status"      // Status of the daemon
+	MethodStop       = "stop"        // Stops the daemon
+)
+
+// Default intervals of the daemon
+const (
+	DefaultPollInterval       = 5 * time.Second
+	DefaultMinRefreshInterval = 30 * time.Second
+)
+
+// ErrNotReady is returned for the repository map until the daemon finished
+// its first refresh
+var ErrNotReady = errors.New("repository map not ready yet")
+
+// connTimeout bounds how long a connection may take to send its request and
+// receive the response
+const connTimeout = 30 * time.Second
+
+// Request is a call to the daemon
+type Request struct {
+	Method   string `json:"method"`
+	MaxChars int    `json:"max_chars,omitempty"` // For repo_map
+}
+
+// Response answers a Request
+type Response struct {
+	Result string  `json:"result,omitempty"`
+	Status *Status `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Status describes a running daemon
+type Status struct {
+	WorkDir            string    `json:"work_dir"`
+	PID                int       `json:"pid"`
+	StartedAt          time.Time `json:"started_at"`
+	MapRefreshedAt     time.Time `json:"map_refreshed_at"`
+	MapFiles           int       `json:"map_files"`
+	MapRefreshes       int       `json:"map_refreshes"`
+	DiffRequests       int       `json:"diff_requests"`
+	DiffCacheHits      int       `json:"diff_cache_hits"`
+	PollInterval       string    `json:"poll_interval"`
+	MinRefreshInterval string    `json:"min_refresh_interval"`
+}
+
+// Options configures a Server
+type Options struct {
+	WorkDir string
+
+	// PollInterval is how often the repository is checked for changes
+	PollInterval time.Duration
+	// MinRefreshInterval rate-limits the repository map refreshes, which stat
+	// every file of the repository: changes made within the interval wait for
+	// the next refresh
+	MinRefreshInterval time.Duration
+}
+
+// Server keeps the state of one directory warm and serves it
+type Server struct {
+	opts     Options
+	executor git.Executor
+	started  time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+
+	mu           sync.Mutex
+	repoMap      *repomap.Map
+	rendered     map[int]string // Rendered map by character budget
+	mapState     string         // Worktree state the map was refreshed at
+	mapRefreshed time.Time
+	mapRefreshes int
+
+	diffMu        sync.Mutex
+	diff          string
+	diffState     string // Index state the diff was computed at
+	diffRequests  int
+	diffCacheHits int
+}
+
+// NewServer creates a Server
+func NewServer(opts Options) *Server {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = DefaultMinRefreshInterval
+	}
+	return &Server{
+		opts:     opts,
+		executor: git.NewExecutor(opts.WorkDir),
+		started:  time.Now(),
+		stop:     make(chan struct{}),
+	}
+}
+
+// SocketDir returns the directory of the daemon sockets: gitbuddy under
+// $XDG_RUNTIME_DIR when it is set, ~/.gitbuddy/run otherwise. Only the user
+// may enter it, so nobody else can put a socket where commands look for one.
+func SocketDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gitbuddy")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".gitbuddy", "run")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gitbuddy-%d", os.Getuid()))
+}
+
+// SocketPath returns the socket of the daemon serving workDir. Its name is a
+// hash of the directory, as the length of socket paths is limited.
+func SocketPath(workDir string) string {
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+	sum := sha256.Sum256([]byte(workDir))
+	return filepath.Join(SocketDir(), fmt.Sprintf("%x.sock", sum[:6]))
+}
+
+// ensureSocketDir creates the socket directory, or checks that an existing one
+// is a directory of the user that nobody else can enter
+func ensureSocketDir() error {
+	dir := SocketDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	if !info.IsDir() || !ownedByUser(info) {
+		return fmt.Errorf("socket directory %s is not a directory of the current user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict socket directory permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkSocket returns an error unless path is a socket of the current user
+func checkSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s is not a socket", path)
+	}
+	if !ownedByUser(info) {
+		return fmt.Errorf("socket %s belongs to another user", path)
+	}
+	return nil
+}
+
+// Listen opens the socket of workDir, replacing the socket left behind by a
+// daemon that didn't exit cleanly
+func Listen(workDir string) (net.Listener, error) {
+	if err := ensureSocketDir(); err != nil {
+		return nil, err
+	}
+	path := SocketPath(workDir)
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already running for %s", workDir)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Serve keeps the state warm and answers the requests of listener until ctx
+// is done or a stop request arrives. It closes listener.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-s.stop:
+			cancel()
+		}
+		listener.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		s.watch(ctx)
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			cancel()
+			wg.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+// watch polls the repository until ctx is done
+func (s *Server) watch(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll refreshes the repository map when the worktree changed and the rate
+// limit allows it, and recomputes the staged diff when the index changed
+func (s *Server) poll(ctx context.Context) {
+	state, err := git.WorktreeState(ctx, s.opts.WorkDir)
+	if err != nil {
+		log.Debug("Daemon: failed to check the worktree: %v", err)
+	} else {
+		s.mu.Lock()
+		due := state != s.mapState && time.Since(s.mapRefreshed) >= s.opts.MinRefreshInterval
+		s.mu.Unlock()
+		if due {
+			s.refreshMap(ctx, state)
+		}
+	}
+
+	if _, _, err := s.stagedDiff(ctx); err != nil && ctx.Err() == nil {
+		log.Debug("Daemon: failed to compute the staged diff: %v", err)
+	}
+}
+
+// refreshMap updates the cached repository map
+func (s *Server) refreshMap(ctx context.Context, state string) {
+	m, err := repomap.Refresh(ctx, s.opts.WorkDir)
+	if err != nil {
+		log.Debug("Daemon: failed to refresh the repository map: %v", err)
+		if m == nil {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repoMap = m
+	s.rendered = make(map[int]string)
+	s.mapState = state
+	s.mapRefreshed = time.Now()
+	s.mapRefreshes++
+	log.Debug("Daemon: repository map refreshed (%d files)", len(m.Files))
+}
+
+// renderedMap returns the repository map rendered within maxChars, or false
+// before the first refresh finished
+func (s *Server) renderedMap(maxChars int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.repoMap == nil {
+		return "", false
+	}
+	rendered, ok := s.rendered[maxChars]
+	if !ok {
+		rendered = s.repoMap.Render(maxChars)
+		s.rendered[maxChars] = rendered
+	}
+	return rendered, true
+}
+
+// stagedDiff returns the staged diff, computing it again only when the index
+// changed since the cached one
+func (s *Server) stagedDiff(ctx context.Context) (diff string, cached bool, err error) {
+	state, err := git.IndexState(ctx, s.opts.WorkDir)
+	if err != nil {
+		return "", false, err
+	}
+
+	s.diffMu.Lock()
+	defer s.diffMu.Unlock()
+	if state == s.diffState {
+		return s.diff, true, nil
+	}
+	diff, err = s.executor.DiffCached(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	// An index changed while git ran yields a new state on the next call
+	s.diff, s.diffState = diff, state
+	return diff, false, nil
+}
+
+// status reports the state of the daemon
+func (s *Server) status() *Status {
+	s.mu.Lock()
+	status := &Status{
+		WorkDir:            s.opts.WorkDir,
+		PID:                os.Getpid(),
+		StartedAt:          s.started,
+		MapRefreshedAt:     s.mapRefreshed,
+		MapRefreshes:       s.mapRefreshes,
+		PollInterval:       s.opts.PollInterval.String(),
+		MinRefreshInterval: s.opts.MinRefreshInterval.String(),
+	}
+	if s.repoMap != nil {
+		status.MapFiles = len(s.repoMap.Files)
+	}
+	s.mu.Unlock()
+
+	s.diffMu.Lock()
+	status.DiffRequests = s.diffRequests
+	status.DiffCacheHits = s.diffCacheHits
+	s.diffMu.Unlock()
+	return status
+}
+
+// handle answers the request of one connection
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Debug("Daemon: invalid request: %v", err)
+		return
+	}
+	if err := json.NewEncoder(conn).Encode(s.answer(ctx, req)); err != nil {
+		log.Debug("Daemon: failed to send response: %v", err)
+	}
+}
+
+func (s *Server) answer(ctx context.Context, req Request) *Response {
+	switch req.Method {
+	case MethodRepoMap:
+		rendered, ok := s.renderedMap(req.MaxChars)
+		if !ok {
+			return &Response{Error: ErrNotReady.Error()}
+		}
+		return &Response{Result: rendered}
+	case MethodStagedDiff:
+		diff, cached, err := s.stagedDiff(ctx)
+		s.diffMu.Lock()
+		s.diffRequests++
+		if cached {
+			s.diffCacheHits++
+		}
+		s.diffMu.Unlock()
+		if err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{Result: diff}
+	case MethodStatus:
+		return &Response{Status: s.status()}
+	case MethodStop:
+		s.stopOnce.Do(func() { close(s.stop) })
+		return &Response{Result: "stopping"}
+	default:
+		return &Response{Error: fmt.Sprintf("unknown method: %s", req.Method)}
+	}
+}
@@ -0,0 +1,170 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+	} {
+		runGit(t, dir, args...)
+	}
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// startServer serves repo until the test ends
+func startServer(t *testing.T, repo string) *Client {
+	t.Helper()
+	listener, err := Listen(repo)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	server := NewServer(Options{WorkDir: repo, PollInterval: 10 * time.Millisecond, MinRefreshInterval: time.Millisecond})
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	client, err := Connect(repo)
+	require.NoError(t, err)
+	return client
+}
+
+func TestConnect_NotRunning(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	_, err := Connect(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestConnect_NotASocket(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(SocketDir(), 0700))
+	require.NoError(t, os.Symlink(filepath.Join(runtimeDir, "elsewhere.sock"), SocketPath(repo)))
+
+	_, err := Connect(repo)
+	assert.ErrorContains(t, err, "is not a socket")
+}
+
+func TestListen_SocketDir(t *testing.T) {
+	repo := setupTestRepo(t)
+	assert.Equal(t, filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "gitbuddy"), SocketDir())
+
+	// A directory others can enter is restricted
+	require.NoError(t, os.Mkdir(SocketDir(), 0755))
+	listener, err := Listen(repo)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	info, err := os.Stat(SocketDir())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	require.NoError(t, checkSocket(SocketPath(repo)))
+}
+
+func TestServer_RepoMap(t *testing.T) {
+	repo := setupTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "greet.go"), []byte("package greet\n\nfunc Hello() string { return \"hi\" }\n"), 0644))
+	client := startServer(t, repo)
+
+	var rendered string
+	require.Eventually(t, func() bool {
+		var err error
+		rendered, err = client.RepoMap(context.Background(), 4000)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, rendered, "Hello")
+
+	// New files show up with the next refresh
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "bye.go"), []byte("package greet\n\nfunc Bye() {}\n"), 0644))
+	assert.Eventually(t, func() bool {
+		rendered, err := client.RepoMap(context.Background(), 4000)
+		return err == nil && strings.Contains(rendered, "Bye")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_StagedDiff(t *testing.T) {
+	repo := setupTestRepo(t)
+	client := startServer(t, repo)
+	ctx := context.Background()
+
+	diff, err := client.StagedDiff(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.txt"), []byte("hello\n"), 0644))
+	runGit(t, repo, "add", "a.txt")
+
+	// The diff is current as soon as the index changed, without waiting for a poll
+	diff, err = client.StagedDiff(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+hello")
+
+	diff, err = client.StagedDiff(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+hello")
+
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.DiffRequests)
+	assert.GreaterOrEqual(t, status.DiffCacheHits, 1)
+	assert.Equal(t, repo, status.WorkDir)
+}
+
+func TestServer_Stop(t *testing.T) {
+	repo := setupTestRepo(t)
+	listener, err := Listen(repo)
+	require.NoError(t, err)
+
+	_, err = Listen(repo)
+	assert.ErrorContains(t, err, "already running")
+
+	done := make(chan error, 1)
+	go func() { done <- NewServer(Options{WorkDir: repo}).Serve(context.Background(), listener) }()
+
+	client, err := Connect(repo)
+	require.NoError(t, err)
+	require.NoError(t, client.Stop(context.Background()))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+
+	_, err = Connect(repo)
+	assert.ErrorIs(t, err, ErrNotRunning, "the socket is removed on exit")
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	repo := setupTestRepo(t)
+	client := startServer(t, repo)
+	_, err := client.call(context.Background(), Request{Method: "embeddings"})
+	assert.ErrorContains(t, err, "unknown method")
+}
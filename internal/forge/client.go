@@ -0,0 +1,93 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// apiClient sends requests to the REST API of a forge
+type apiClient struct {
+	name    string            // Of the forge in errors, e.g. GitHub
+	baseURL string            // Without a trailing slash
+	headers map[string]string // Sent with every request, e.g. the token
+	client  *http.Client
+}
+
+// newAPIClient creates a client of the API of forge name at baseURL
+func newAPIClient(name, baseURL string, headers map[string]string) *apiClient {
+	return &apiClient{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		headers: headers,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request to path, with payload encoded as JSON unless it is nil,
+// and decodes the JSON response into v
+func (c *apiClient) do(ctx context.Context, method, path string, payload, v interface{}) error {
+	data, err := c.send(ctx, method, path, payload, "")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s API response: %w", c.name, err)
+	}
+	return nil
+}
+
+// send sends a request to path, with payload encoded as JSON unless it is nil,
+// and returns the response body. accept overrides the Accept header when set.
+// Requests other than GET change the forge, so check mode refuses them.
+func (c *apiClient) send(ctx context.Context, method, path string, payload interface{}, accept string) ([]byte, error) {
+	if method != http.MethodGet {
+		if err := sideeffect.Check(fmt.Sprintf("%s %s %s", c.name, method, path)); err != nil {
+			return nil, err
+		}
+	}
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the %s API: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s API returned %s: %s", c.name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s API response: %w", c.name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,82 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Kinds of forges
+const (
+	KindGitHub = "github" // GitHub and GitHub Enterprise
+	KindGitLab = "gitlab" // GitLab.com and self-managed GitLab
+)
+
+// Forge is the API of a code forge for the pull requests (merge requests on
+// GitLab) of a repository
+type Forge interface {
+	// Name returns the name of the forge, e.g. GitHub
+	Name() string
+	// CreatePullRequest opens a pull request, which needs pr.Head pushed
+	CreatePullRequest(ctx context.Context, repo Repository, pr NewPullRequest) (*PullRequest, error)
+	// PullRequestDiff returns the unified diff of pull request number
+	PullRequestDiff(ctx context.Context, repo Repository, number int) (string, error)
+	// PostReview posts comments on pull request number with body as their
+	// summary, listing the comments on lines outside the diff in the summary.
+	// It returns the review and how many comments were posted inline.
+	PostReview(ctx context.Context, repo Repository, number int, body string, comments []InlineComment) (*PullRequestReview, int, error)
+}
+
+// ReviewRequester is a Forge that can request reviews of a pull request from
+// users, given as @user (or @org/team on GitHub). It returns the reviewers
+// requested.
+type ReviewRequester interface {
+	RequestReviewers(ctx context.Context, repo Repository, number int, owners []string) ([]string, error)
+}
+
+// NewPullRequest describes a pull request to open
+type NewPullRequest struct {
+	Title string
+	Body  string // Markdown
+	Head  string // Branch with the changes
+	Base  string // Branch the changes are merged into
+	Draft bool
+}
+
+// PullRequest is a pull request opened with CreatePullRequest
+type PullRequest struct {
+	Number int // The IID on GitLab
+	URL    string
+}
+
+// ValidKind reports whether kind is a known kind of forge
+func ValidKind(kind string) bool {
+	return kind == KindGitHub || kind == KindGitLab
+}
+
+// DetectKind guesses the kind of forge hosting repo from its host: GitLab
+// when the host name says so, GitHub otherwise
+func DetectKind(repo Repository) string {
+	if strings.Contains(repo.Host, "gitlab") {
+		return KindGitLab
+	}
+	return KindGitHub
+}
+
+// New creates a client of the forge of kind hosting repo, using the API at
+// apiURL (the default of repo's host when empty), authenticated with token
+func New(kind string, repo Repository, apiURL, token string) (Forge, error) {
+	switch kind {
+	case KindGitHub:
+		if apiURL == "" {
+			apiURL = repo.APIURL()
+		}
+		return NewGitHub(apiURL, token), nil
+	case KindGitLab:
+		if apiURL == "" {
+			apiURL = repo.GitLabAPIURL()
+		}
+		return NewGitLab(apiURL, token), nil
+	}
+	return nil, fmt.Errorf("unknown forge: %s (valid: github, gitlab)", kind)
+}
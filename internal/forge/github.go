@@ -0,0 +1,225 @@
+// Package forge works with the code forges repositories are hosted on: it
+// identifies the repository of a remote URL, links to the page that opens a
+// pull request, and through the REST APIs of GitHub (and GitHub Enterprise)
+// and GitLab opens pull and merge requests, reads their diffs and posts
+// reviews. It also reads review comments and files issues on GitHub.
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the API of github.com
+const DefaultAPIURL = "https://api.github.com"
+
+// DefaultGitLabAPIURL is the API of gitlab.com
+const DefaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+// perPage is the page size of list requests, the maximum GitHub allows
+const perPage = 100
+
+// Repository identifies a repository on a forge
+type Repository struct {
+	Host  string // e.g. github.com
+	Owner string
+	Name  string
+}
+
+// String returns owner/name
+func (r Repository) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// APIURL returns the API of the repository's host: api.github.com for
+// github.com, /api/v3 of the host for GitHub Enterprise
+func (r Repository) APIURL() string {
+	if r.Host == "" || r.Host == "github.com" {
+		return DefaultAPIURL
+	}
+	return "https://" + r.Host + "/api/v3"
+}
+
+// GitLabAPIURL returns the GitLab API of the repository's host
+func (r Repository) GitLabAPIURL() string {
+	if r.Host == "" || r.Host == "gitlab.com" {
+		return DefaultGitLabAPIURL
+	}
+	return "https://" + r.Host + "/api/v4"
+}
+
+// remotePattern matches the host and path of SSH and HTTPS remote URLs, e.g.
+// git@github.com:owner/repo.git and https://github.com/owner/repo
+var remotePattern = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// ParseRemoteURL parses the repository of a git remote URL
+func ParseRemoteURL(remote string) (Repository, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return Repository{}, fmt.Errorf("unsupported remote URL: %s", remote)
+	}
+	parts := strings.Split(m[2], "/")
+	if len(parts) < 2 {
+		return Repository{}, fmt.Errorf("remote URL has no owner and repository: %s", remote)
+	}
+	return Repository{
+		Host:  m[1],
+		Owner: strings.Join(parts[:len(parts)-1], "/"),
+		Name:  parts[len(parts)-1],
+	}, nil
+}
+
+// ReviewComment is a comment left on a line of a pull request
+type ReviewComment struct {
+	Author      string
+	Body        string
+	Path        string
+	PullRequest int
+	CreatedAt   time.Time
+}
+
+// GitHub is a client of the GitHub REST API
+type GitHub struct {
+	api *apiClient
+}
+
+// NewGitHub creates a client of the API at apiURL (DefaultAPIURL when empty),
+// authenticated with token when it is set
+func NewGitHub(apiURL, token string) *GitHub {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return &GitHub{api: newAPIClient("GitHub", apiURL, headers)}
+}
+
+// Name returns GitHub
+func (g *GitHub) Name() string {
+	return "GitHub"
+}
+
+// apiComment is a pull request review comment of the API
+type apiComment struct {
+	Body           string    `json:"body"`
+	Path           string    `json:"path"`
+	CreatedAt      time.Time `json:"created_at"`
+	PullRequestURL string    `json:"pull_request_url"`
+	User           *struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"user"`
+}
+
+// ReviewComments returns the review comments of people (not bots) on the
+// pull requests of repo created since since, newest first, at most limit
+func (g *GitHub) ReviewComments(ctx context.Context, repo Repository, since time.Time, limit int) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	for page := 1; ; page++ {
+		query := url.Values{
+			"sort":      {"created"},
+			"direction": {"desc"},
+			"per_page":  {fmt.Sprint(perPage)},
+			"page":      {fmt.Sprint(page)},
+		}
+		var batch []apiComment
+		path := fmt.Sprintf("/repos/%s/%s/pulls/comments?%s", repo.Owner, repo.Name, query.Encode())
+		if err := g.get(ctx, path, &batch); err != nil {
+			return nil, err
+		}
+
+		for _, c := range batch {
+			if c.CreatedAt.Before(since) {
+				return comments, nil
+			}
+			if c.User == nil || c.User.Type == "Bot" || strings.HasSuffix(c.User.Login, "[bot]") || strings.TrimSpace(c.Body) == "" {
+				continue
+			}
+			comments = append(comments, ReviewComment{
+				Author:      c.User.Login,
+				Body:        c.Body,
+				Path:        c.Path,
+				PullRequest: pullRequestNumber(c.PullRequestURL),
+				CreatedAt:   c.CreatedAt,
+			})
+			if limit > 0 && len(comments) >= limit {
+				return comments, nil
+			}
+		}
+		if len(batch) < perPage {
+			return comments, nil
+		}
+	}
+}
+
+// Issue is an issue filed with CreateIssue
+type Issue struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+}
+
+// CreateIssue files an issue with title and body, a Markdown text, in repo.
+// It requires a token allowed to create issues.
+func (g *GitHub) CreateIssue(ctx context.Context, repo Repository, title, body string, labels []string) (*Issue, error) {
+	payload := map[string]any{"title": title, "body": body}
+	if len(labels) > 0 {
+		payload["labels"] = labels
+	}
+	var issue Issue
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/issues", repo.Owner, repo.Name), payload, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// CreatePullRequest opens a pull request from pr.Head into pr.Base in repo
+func (g *GitHub) CreatePullRequest(ctx context.Context, repo Repository, pr NewPullRequest) (*PullRequest, error) {
+	payload := map[string]any{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": pr.Base, "draft": pr.Draft}
+	var created struct {
+		Number int    `json:"number"`
+		URL    string `json:"html_url"`
+	}
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls", repo.Owner, repo.Name), payload, &created); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: created.Number, URL: created.URL}, nil
+}
+
+// PullRequestDiff returns the unified diff of pull request number of repo
+func (g *GitHub) PullRequestDiff(ctx context.Context, repo Repository, number int) (string, error) {
+	data, err := g.api.send(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d", repo.Owner, repo.Name, number), nil, "application/vnd.github.diff")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// get decodes the JSON response of a GET request to path
+func (g *GitHub) get(ctx context.Context, path string, v interface{}) error {
+	return g.api.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// do sends a request to path, with payload encoded as JSON unless it is nil,
+// and decodes the JSON response into v
+func (g *GitHub) do(ctx context.Context, method, path string, payload, v interface{}) error {
+	return g.api.do(ctx, method, path, payload, v)
+}
+
+// pullRequestNumber returns the number at the end of a pull request API URL
+func pullRequestNumber(apiURL string) int {
+	var n int
+	if i := strings.LastIndex(apiURL, "/"); i >= 0 {
+		fmt.Sscanf(apiURL[i+1:], "%d", &n)
+	}
+	return n
+}
@@ -0,0 +1,157 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   Repository
+		apiURL string
+	}{
+		{"git@github.com:acme/api.git", Repository{"github.com", "acme", "api"}, DefaultAPIURL},
+		{"https://github.com/acme/api", Repository{"github.com", "acme", "api"}, DefaultAPIURL},
+		{"https://user@github.com/acme/api.git/", Repository{"github.com", "acme", "api"}, DefaultAPIURL},
+		{"ssh://git@ghe.example.com:2222/platform/tools/cli.git", Repository{"ghe.example.com", "platform/tools", "cli"}, "https://ghe.example.com/api/v3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			repo, err := ParseRemoteURL(tt.remote)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, repo)
+			assert.Equal(t, tt.apiURL, repo.APIURL())
+		})
+	}
+
+	_, err := ParseRemoteURL("/srv/git/api.git")
+	assert.Error(t, err)
+}
+
+func TestGitHub_ReviewComments(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	comment := func(login, userType, body string, age time.Duration) string {
+		return fmt.Sprintf(`{"body":%q,"path":"main.go","created_at":%q,"pull_request_url":"https://api.github.com/repos/acme/api/pulls/7","user":{"login":%q,"type":%q}}`,
+			body, now.Add(-age).Format(time.RFC3339), login, userType)
+	}
+
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "/repos/acme/api/pulls/comments", r.URL.Path)
+		assert.Equal(t, "desc", r.URL.Query().Get("direction"))
+		fmt.Fprintf(w, "[%s,%s,%s,%s]",
+			comment("alice", "User", "Wrap this error", time.Hour),
+			comment("dependabot[bot]", "Bot", "Bumped", 2*time.Hour),
+			comment("bob", "User", "Needs a test", 3*time.Hour),
+			comment("carol", "User", "Too old", 90*24*time.Hour))
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	comments, err := NewGitHub(server.URL, "secret").ReviewComments(context.Background(), repo, now.Add(-30*24*time.Hour), 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", auth)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "alice", comments[0].Author)
+	assert.Equal(t, 7, comments[0].PullRequest)
+	assert.Equal(t, "bob", comments[1].Author)
+
+	comments, err = NewGitHub(server.URL, "").ReviewComments(context.Background(), repo, time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Len(t, comments, 1)
+}
+
+func TestGitHub_ReviewComments_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewGitHub(server.URL, "").ReviewComments(context.Background(), Repository{Owner: "acme", Name: "api"}, time.Time{}, 0)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGitHub_CreateIssue(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repos/acme/api/issues", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"number":12,"html_url":"https://github.com/acme/api/issues/12"}`)
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	issue, err := NewGitHub(server.URL, "secret").CreateIssue(context.Background(), repo, "Alert on signature failures", "From the debug report", []string{"gitbuddy"})
+	require.NoError(t, err)
+	assert.Equal(t, &Issue{Number: 12, URL: "https://github.com/acme/api/issues/12"}, issue)
+	assert.Equal(t, map[string]any{"title": "Alert on signature failures", "body": "From the debug report", "labels": []any{"gitbuddy"}}, got)
+}
+
+func TestGitHub_CreatePullRequest(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/repos/acme/api/pulls", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"number":8,"html_url":"https://github.com/acme/api/pull/8"}`)
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	pr, err := NewGitHub(server.URL, "secret").CreatePullRequest(context.Background(), repo,
+		NewPullRequest{Title: "Add login", Body: "Adds the login flow", Head: "feature/login", Base: "main", Draft: true})
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{Number: 8, URL: "https://github.com/acme/api/pull/8"}, pr)
+	assert.Equal(t, map[string]any{"title": "Add login", "body": "Adds the login flow", "head": "feature/login", "base": "main", "draft": true}, got)
+}
+
+func TestGitHub_CheckMode(t *testing.T) {
+	sideeffect.SetBlocked(true)
+	defer sideeffect.SetBlocked(false)
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		fmt.Fprint(w, "diff --git a/auth/limit.go b/auth/limit.go\n")
+	}))
+	defer server.Close()
+
+	// Reading is allowed, writing is refused before the request is sent
+	github := NewGitHub(server.URL, "secret")
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	_, err := github.PullRequestDiff(context.Background(), repo, 8)
+	require.NoError(t, err)
+	_, err = github.CreatePullRequest(context.Background(), repo, NewPullRequest{Title: "Add login", Head: "feature/login", Base: "main"})
+	assert.ErrorIs(t, err, sideeffect.ErrBlocked)
+	_, err = github.CreateIssue(context.Background(), repo, "Alert on signature failures", "", nil)
+	assert.ErrorIs(t, err, sideeffect.ErrBlocked)
+	assert.Equal(t, []string{http.MethodGet}, methods)
+}
+
+func TestGitHub_PullRequestDiff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/acme/api/pulls/8", r.URL.Path)
+		assert.Equal(t, "application/vnd.github.diff", r.Header.Get("Accept"))
+		fmt.Fprint(w, "diff --git a/main.go b/main.go\n")
+	}))
+	defer server.Close()
+
+	diff, err := NewGitHub(server.URL, "").PullRequestDiff(context.Background(), Repository{Owner: "acme", Name: "api"}, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "diff --git a/main.go b/main.go\n", diff)
+}
@@ -0,0 +1,238 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLab is a client of the GitLab REST API
+type GitLab struct {
+	api *apiClient
+}
+
+// NewGitLab creates a client of the API at apiURL (DefaultGitLabAPIURL when
+// empty), authenticated with token when it is set
+func NewGitLab(apiURL, token string) *GitLab {
+	if apiURL == "" {
+		apiURL = DefaultGitLabAPIURL
+	}
+	headers := map[string]string{"Accept": "application/json"}
+	if token != "" {
+		headers["PRIVATE-TOKEN"] = token
+	}
+	return &GitLab{api: newAPIClient("GitLab", apiURL, headers)}
+}
+
+// Name returns GitLab
+func (g *GitLab) Name() string {
+	return "GitLab"
+}
+
+// project returns the API path of repo, identified by its URL-encoded full
+// path, e.g. /projects/group%2Fsubgroup%2Fapi
+func project(repo Repository) string {
+	return "/projects/" + url.PathEscape(repo.String())
+}
+
+// CreatePullRequest opens a merge request from pr.Head into pr.Base in repo.
+// GitLab marks merge requests whose title starts with "Draft:" as drafts.
+func (g *GitLab) CreatePullRequest(ctx context.Context, repo Repository, pr NewPullRequest) (*PullRequest, error) {
+	title := pr.Title
+	if pr.Draft && !strings.HasPrefix(strings.ToLower(title), "draft:") {
+		title = "Draft: " + title
+	}
+	payload := map[string]any{
+		"title":         title,
+		"description":   pr.Body,
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+	}
+	var created struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := g.api.do(ctx, http.MethodPost, project(repo)+"/merge_requests", payload, &created); err != nil {
+		return nil, err
+	}
+	return &PullRequest{Number: created.IID, URL: created.WebURL}, nil
+}
+
+// gitlabDiff is a changed file of a merge request of the API
+type gitlabDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// diffs returns the changed files of merge request number of repo
+func (g *GitLab) diffs(ctx context.Context, repo Repository, number int) ([]gitlabDiff, error) {
+	var diffs []gitlabDiff
+	for page := 1; ; page++ {
+		query := url.Values{"per_page": {fmt.Sprint(perPage)}, "page": {fmt.Sprint(page)}}
+		var batch []gitlabDiff
+		path := fmt.Sprintf("%s/merge_requests/%d/diffs?%s", project(repo), number, query.Encode())
+		if err := g.api.do(ctx, http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, batch...)
+		if len(batch) < perPage {
+			return diffs, nil
+		}
+	}
+}
+
+// PullRequestDiff returns the unified diff of merge request number of repo
+func (g *GitLab) PullRequestDiff(ctx context.Context, repo Repository, number int) (string, error) {
+	diffs, err := g.diffs(ctx, repo, number)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", d.OldPath, d.NewPath)
+		oldPath, newPath := "a/"+d.OldPath, "b/"+d.NewPath
+		switch {
+		case d.NewFile:
+			fmt.Fprintf(&b, "new file mode %s\n", d.BMode)
+			oldPath = "/dev/null"
+		case d.DeletedFile:
+			fmt.Fprintf(&b, "deleted file mode %s\n", d.AMode)
+			newPath = "/dev/null"
+		case d.OldPath != d.NewPath:
+			fmt.Fprintf(&b, "rename from %s\nrename to %s\n", d.OldPath, d.NewPath)
+		}
+		if d.Diff == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n%s", oldPath, newPath, d.Diff)
+		if !strings.HasSuffix(d.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// PostReview posts each comment as a discussion on its line of merge request
+// number, and body as a note. GitLab rejects a discussion on a line outside
+// the diff, so such comments are listed in the note instead. It returns the
+// note and how many comments were posted inline.
+func (g *GitLab) PostReview(ctx context.Context, repo Repository, number int, body string, comments []InlineComment) (*PullRequestReview, int, error) {
+	var mr struct {
+		WebURL   string `json:"web_url"`
+		DiffRefs struct {
+			BaseSHA  string `json:"base_sha"`
+			HeadSHA  string `json:"head_sha"`
+			StartSHA string `json:"start_sha"`
+		} `json:"diff_refs"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/merge_requests/%d", project(repo), number), nil, &mr); err != nil {
+		return nil, 0, err
+	}
+	diffs, err := g.diffs(ctx, repo, number)
+	if err != nil {
+		return nil, 0, err
+	}
+	oldPaths := make(map[string]string)
+	lines := make(map[string]map[int]int)
+	for _, d := range diffs {
+		oldPaths[d.NewPath] = d.OldPath
+		lines[d.NewPath] = patchLines(d.Diff)
+	}
+
+	inline := 0
+	var outside []InlineComment
+	for _, c := range comments {
+		oldLine, ok := lines[c.Path][c.Line]
+		if c.Line == 0 || !ok {
+			outside = append(outside, c)
+			continue
+		}
+		position := map[string]any{
+			"position_type": "text",
+			"base_sha":      mr.DiffRefs.BaseSHA,
+			"head_sha":      mr.DiffRefs.HeadSHA,
+			"start_sha":     mr.DiffRefs.StartSHA,
+			"old_path":      oldPaths[c.Path],
+			"new_path":      c.Path,
+			"new_line":      c.Line,
+		}
+		// Unchanged lines are positioned in both versions
+		if oldLine > 0 {
+			position["old_line"] = oldLine
+		}
+		var discussion struct{}
+		err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/merge_requests/%d/discussions", project(repo), number),
+			map[string]any{"body": c.Body, "position": position}, &discussion)
+		if err != nil {
+			return nil, inline, err
+		}
+		inline++
+	}
+
+	var note struct {
+		ID int64 `json:"id"`
+	}
+	if err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/merge_requests/%d/notes", project(repo), number), map[string]any{"body": withOutsideComments(body, outside)}, &note); err != nil {
+		return nil, inline, err
+	}
+	return &PullRequestReview{ID: note.ID, URL: fmt.Sprintf("%s#note_%d", mr.WebURL, note.ID)}, inline, nil
+}
+
+// RequestReviewers adds users, given as @username, to the reviewers of merge
+// request number. Groups, unknown users and the author of the merge request
+// are skipped. It returns the users requested.
+func (g *GitLab) RequestReviewers(ctx context.Context, repo Repository, number int, owners []string) ([]string, error) {
+	path := fmt.Sprintf("%s/merge_requests/%d", project(repo), number)
+	var mr struct {
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		Reviewers []struct {
+			ID int64 `json:"id"`
+		} `json:"reviewers"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, path, nil, &mr); err != nil {
+		return nil, err
+	}
+
+	// The update replaces the reviewers, so the current ones are kept
+	var ids []int64
+	for _, reviewer := range mr.Reviewers {
+		ids = append(ids, reviewer.ID)
+	}
+	var requested []string
+	for _, owner := range owners {
+		name, ok := strings.CutPrefix(owner, "@")
+		if !ok || strings.Contains(name, "/") || strings.EqualFold(name, mr.Author.Username) {
+			continue
+		}
+		var users []struct {
+			ID int64 `json:"id"`
+		}
+		if err := g.api.do(ctx, http.MethodGet, "/users?username="+url.QueryEscape(name), nil, &users); err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			continue
+		}
+		ids = append(ids, users[0].ID)
+		requested = append(requested, owner)
+	}
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	var updated struct{}
+	if err := g.api.do(ctx, http.MethodPut, path, map[string]any{"reviewer_ids": ids}, &updated); err != nil {
+		return nil, err
+	}
+	return requested, nil
+}
@@ -0,0 +1,144 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	repo := Repository{Host: "gitlab.example.com", Owner: "platform/tools", Name: "cli"}
+	assert.Equal(t, KindGitLab, DetectKind(repo))
+	assert.Equal(t, KindGitHub, DetectKind(Repository{Host: "github.com", Owner: "acme", Name: "api"}))
+	assert.Equal(t, "https://gitlab.example.com/api/v4", repo.GitLabAPIURL())
+	assert.Equal(t, DefaultGitLabAPIURL, Repository{Host: "gitlab.com"}.GitLabAPIURL())
+
+	f, err := New(KindGitLab, repo, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "GitLab", f.Name())
+	assert.Equal(t, "https://gitlab.example.com/api/v4", f.(*GitLab).api.baseURL)
+
+	_, err = New("gitea", repo, "", "")
+	assert.EqualError(t, err, "unknown forge: gitea (valid: github, gitlab)")
+}
+
+func TestGitLab_CreatePullRequest(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/projects/platform%2Ftools%2Fcli/merge_requests", r.URL.EscapedPath())
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"iid":12,"web_url":"https://gitlab.example.com/platform/tools/cli/-/merge_requests/12"}`)
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "gitlab.example.com", Owner: "platform/tools", Name: "cli"}
+	mr, err := NewGitLab(server.URL, "secret").CreatePullRequest(context.Background(), repo,
+		NewPullRequest{Title: "Add login", Body: "Adds the login flow", Head: "feature/login", Base: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{Number: 12, URL: "https://gitlab.example.com/platform/tools/cli/-/merge_requests/12"}, mr)
+	assert.Equal(t, map[string]any{"title": "Add login", "description": "Adds the login flow", "source_branch": "feature/login", "target_branch": "main"}, got)
+
+	_, err = NewGitLab(server.URL, "secret").CreatePullRequest(context.Background(), repo,
+		NewPullRequest{Title: "Add login", Head: "feature/login", Base: "main", Draft: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Draft: Add login", got["title"], "drafts are marked by their title")
+}
+
+func TestGitLab_RequestReviewers(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.EscapedPath() == "/projects/acme%2Fapi/merge_requests/7" && r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"author":{"username":"alice"},"reviewers":[{"id":3}]}`)
+		case r.URL.EscapedPath() == "/projects/acme%2Fapi/merge_requests/7" && r.Method == http.MethodPut:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == "/users" && r.URL.Query().Get("username") == "bob":
+			fmt.Fprint(w, `[{"id":5}]`)
+		case r.URL.Path == "/users":
+			fmt.Fprint(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	requested, err := NewGitLab(server.URL, "secret").RequestReviewers(context.Background(), Repository{Owner: "acme", Name: "api"}, 7,
+		[]string{"@bob", "@alice", "@acme/backend", "@nobody", "carol@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@bob"}, requested)
+	assert.Equal(t, map[string]any{"reviewer_ids": []any{float64(3), float64(5)}}, got, "the current reviewers are kept")
+}
+
+// gitlabDiffs is the diffs of a merge request adding auth/limit.go and
+// changing auth/auth.go
+const gitlabDiffs = `[
+	{"old_path":"auth/auth.go","new_path":"auth/auth.go","a_mode":"100644","b_mode":"100644","diff":"@@ -1,3 +1,3 @@\n package auth\n-const retries = 3\n+const retries = 5\n \n"},
+	{"old_path":"auth/limit.go","new_path":"auth/limit.go","a_mode":"0","b_mode":"100644","new_file":true,"diff":"@@ -0,0 +1 @@\n+package auth\n"}
+]`
+
+func TestGitLab_PullRequestDiff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/acme%2Fapi/merge_requests/7/diffs", r.URL.EscapedPath())
+		fmt.Fprint(w, gitlabDiffs)
+	}))
+	defer server.Close()
+
+	diff, err := NewGitLab(server.URL, "").PullRequestDiff(context.Background(), Repository{Owner: "acme", Name: "api"}, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "diff --git a/auth/auth.go b/auth/auth.go\n--- a/auth/auth.go\n+++ b/auth/auth.go\n@@ -1,3 +1,3 @@\n package auth\n-const retries = 3\n+const retries = 5\n \n"+
+		"diff --git a/auth/limit.go b/auth/limit.go\nnew file mode 100644\n--- /dev/null\n+++ b/auth/limit.go\n@@ -0,0 +1 @@\n+package auth\n", diff)
+}
+
+func TestGitLab_PostReview(t *testing.T) {
+	var discussions []map[string]any
+	var note map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/projects/acme%2Fapi/merge_requests/7":
+			fmt.Fprint(w, `{"web_url":"https://gitlab.com/acme/api/-/merge_requests/7","diff_refs":{"base_sha":"b1","head_sha":"h1","start_sha":"s1"}}`)
+		case "/projects/acme%2Fapi/merge_requests/7/diffs":
+			fmt.Fprint(w, gitlabDiffs)
+		case "/projects/acme%2Fapi/merge_requests/7/discussions":
+			var discussion map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&discussion))
+			discussions = append(discussions, discussion)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":"d1"}`)
+		case "/projects/acme%2Fapi/merge_requests/7/notes":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&note))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":99}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	comments := []InlineComment{
+		{Path: "auth/auth.go", Line: 2, Body: "Why 5?"},
+		{Path: "auth/auth.go", Line: 3, Body: "Blank line"},
+		{Path: "auth/limit.go", Line: 9, Body: "Missing limit"},
+	}
+	review, inline, err := NewGitLab(server.URL, "secret").PostReview(context.Background(), Repository{Host: "gitlab.com", Owner: "acme", Name: "api"}, 7, "3 issues found", comments)
+	require.NoError(t, err)
+	assert.Equal(t, "https://gitlab.com/acme/api/-/merge_requests/7#note_99", review.URL)
+	assert.Equal(t, 2, inline)
+
+	require.Len(t, discussions, 2)
+	assert.Equal(t, map[string]any{
+		"position_type": "text", "base_sha": "b1", "head_sha": "h1", "start_sha": "s1",
+		"old_path": "auth/auth.go", "new_path": "auth/auth.go", "new_line": float64(2),
+	}, discussions[0]["position"], "added lines have no old line")
+	assert.Equal(t, float64(3), discussions[1]["position"].(map[string]any)["old_line"], "unchanged lines are positioned in both versions")
+	assert.Equal(t, "3 issues found\n\n#### Outside the diff\n\n`auth/limit.go:9`\n\nMissing limit", note["body"])
+}
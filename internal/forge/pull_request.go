@@ -0,0 +1,37 @@
+package forge
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// NewPullRequestURL returns the page that opens a pull request (a merge
+// request on GitLab) from branch: GitHub's compare page unless the host is
+// recognized as GitLab or Bitbucket
+func (r Repository) NewPullRequestURL(branch string) string {
+	base := "https://" + r.Host + "/" + r.Owner + "/" + r.Name
+	switch {
+	case strings.Contains(r.Host, "gitlab"):
+		return base + "/-/merge_requests/new?" + url.Values{"merge_request[source_branch]": {branch}}.Encode()
+	case r.Host == "bitbucket.org":
+		return base + "/pull-requests/new?" + url.Values{"source": {branch}}.Encode()
+	default:
+		return base + "/compare/" + branch + "?expand=1"
+	}
+}
+
+// remoteURLPattern matches a URL in the messages a remote prints on push
+var remoteURLPattern = regexp.MustCompile(`^remote:\s+(https?://\S+)`)
+
+// PushMessageURL returns the pull request URL the remote printed during a
+// push, as GitHub and GitLab do for new branches, or ""
+func PushMessageURL(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		m := remoteURLPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m != nil && (strings.Contains(m[1], "/pull") || strings.Contains(m[1], "merge_request")) {
+			return m[1]
+		}
+	}
+	return ""
+}
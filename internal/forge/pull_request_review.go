@@ -0,0 +1,181 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// InlineComment is a comment on a line of the new version of a file in a
+// pull request
+type InlineComment struct {
+	Path string // Relative to the repository root, with forward slashes
+	Line int
+	Body string // Markdown
+}
+
+// PullRequestReview is a review posted with PostReview
+type PullRequestReview struct {
+	ID  int64  `json:"id"`
+	URL string `json:"html_url"`
+}
+
+// apiFile is a changed file of a pull request of the API
+type apiFile struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"`
+}
+
+// CommentableLines returns the lines of the new versions of the files changed
+// by pull request number that can be commented on: the added and context lines
+// of its diff, by file path. Files whose diff GitHub doesn't show, such as
+// binary or very large ones, have none.
+func (g *GitHub) CommentableLines(ctx context.Context, repo Repository, number int) (map[string]map[int]bool, error) {
+	lines := make(map[string]map[int]bool)
+	for page := 1; ; page++ {
+		query := url.Values{"per_page": {fmt.Sprint(perPage)}, "page": {fmt.Sprint(page)}}
+		var batch []apiFile
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/files?%s", repo.Owner, repo.Name, number, query.Encode())
+		if err := g.get(ctx, path, &batch); err != nil {
+			return nil, err
+		}
+		for _, f := range batch {
+			lines[f.Filename] = make(map[int]bool)
+			for line := range patchLines(f.Patch) {
+				lines[f.Filename][line] = true
+			}
+		}
+		if len(batch) < perPage {
+			return lines, nil
+		}
+	}
+}
+
+// PostReview posts comments as a review of pull request number, with body as
+// its summary. GitHub rejects a review commenting on a line outside the diff,
+// so such comments are listed in the summary instead. It returns the review and
+// how many comments were posted inline.
+func (g *GitHub) PostReview(ctx context.Context, repo Repository, number int, body string, comments []InlineComment) (*PullRequestReview, int, error) {
+	lines, err := g.CommentableLines(ctx, repo, number)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	inline := []map[string]any{}
+	var outside []InlineComment
+	for _, c := range comments {
+		if c.Line > 0 && lines[c.Path][c.Line] {
+			inline = append(inline, map[string]any{"path": c.Path, "line": c.Line, "side": "RIGHT", "body": c.Body})
+		} else {
+			outside = append(outside, c)
+		}
+	}
+
+	payload := map[string]any{"event": "COMMENT", "body": withOutsideComments(body, outside), "comments": inline}
+	var review PullRequestReview
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", repo.Owner, repo.Name, number), payload, &review); err != nil {
+		return nil, 0, err
+	}
+	return &review, len(inline), nil
+}
+
+// RequestReviewers requests reviews of pull request number from owners, given
+// as in CODEOWNERS: @user or @org/team. Teams of other organizations than the
+// repository's, email owners and the author of the pull request, whose review
+// GitHub refuses, are skipped. It returns the owners requested.
+func (g *GitHub) RequestReviewers(ctx context.Context, repo Repository, number int, owners []string) ([]string, error) {
+	var pr struct {
+		User *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := g.get(ctx, fmt.Sprintf("/repos/%s/%s/pulls/%d", repo.Owner, repo.Name, number), &pr); err != nil {
+		return nil, err
+	}
+
+	users, teams, requested := []string{}, []string{}, []string(nil)
+	for _, owner := range owners {
+		name, ok := strings.CutPrefix(owner, "@")
+		if !ok {
+			continue
+		}
+		if org, team, isTeam := strings.Cut(name, "/"); isTeam {
+			if strings.EqualFold(org, repo.Owner) {
+				teams = append(teams, team)
+				requested = append(requested, owner)
+			}
+			continue
+		}
+		if pr.User != nil && strings.EqualFold(name, pr.User.Login) {
+			continue
+		}
+		users = append(users, name)
+		requested = append(requested, owner)
+	}
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	payload := map[string]any{"reviewers": users, "team_reviewers": teams}
+	var updated struct{}
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", repo.Owner, repo.Name, number), payload, &updated); err != nil {
+		return nil, err
+	}
+	return requested, nil
+}
+
+// withOutsideComments appends the comments on lines outside the diff of a
+// pull request to the summary of its review
+func withOutsideComments(body string, outside []InlineComment) string {
+	if len(outside) == 0 {
+		return body
+	}
+	sections := make([]string, len(outside))
+	for i, c := range outside {
+		location := c.Path
+		if c.Line > 0 {
+			location += ":" + strconv.Itoa(c.Line)
+		}
+		sections[i] = fmt.Sprintf("`%s`\n\n%s", location, c.Body)
+	}
+	return strings.TrimSpace(body + "\n\n#### Outside the diff\n\n" + strings.Join(sections, "\n\n---\n\n"))
+}
+
+// hunkPattern matches the header of a hunk, capturing the start lines of the
+// old and the new version
+var hunkPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// patchLines returns the added and context lines of the new version of a
+// file in patch, the hunks of its unified diff, mapped to their line in the
+// old version: 0 for added lines
+func patchLines(patch string) map[int]int {
+	lines := make(map[int]int)
+	oldLine, newLine := 0, 0
+	for _, text := range strings.Split(patch, "\n") {
+		if m := hunkPattern.FindStringSubmatch(text); m != nil {
+			oldLine, _ = strconv.Atoi(m[1])
+			newLine, _ = strconv.Atoi(m[2])
+			continue
+		}
+		// Context lines start with a space, so an empty line ends the patch
+		if newLine == 0 || text == "" || strings.HasPrefix(text, `\`) {
+			continue
+		}
+		switch text[0] {
+		case '-':
+			oldLine++
+		case '+':
+			lines[newLine] = 0
+			newLine++
+		default:
+			lines[newLine] = oldLine
+			oldLine++
+			newLine++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchLines(t *testing.T) {
+	patch := "@@ -1,4 +1,5 @@\n package auth\n-import \"fmt\"\n+import (\n+\t\"fmt\"\n+)\n \n@@ -20,2 +22,2 @@ func Login() {\n-\treturn nil\n+\treturn err\n }\n\\ No newline at end of file"
+	assert.Equal(t, map[int]int{1: 1, 2: 0, 3: 0, 4: 0, 5: 3, 22: 0, 23: 21}, patchLines(patch))
+	assert.Empty(t, patchLines(""))
+}
+
+func TestGitHub_PostReview(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/api/pulls/7/files":
+			assert.Equal(t, http.MethodGet, r.Method)
+			fmt.Fprint(w, `[{"filename":"auth/login.go","patch":"@@ -10,2 +10,3 @@\n func Login() {\n+\tquery := \"SELECT \" + name\n }"},{"filename":"logo.png"}]`)
+		case "/repos/acme/api/pulls/7/reviews":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			fmt.Fprint(w, `{"id":42,"html_url":"https://github.com/acme/api/pull/7#pullrequestreview-42"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	comments := []InlineComment{
+		{Path: "auth/login.go", Line: 11, Body: "SQL injection"},
+		{Path: "auth/login.go", Line: 40, Body: "Unused parameter"},
+		{Path: "logo.png", Body: "Large image"},
+	}
+	review, inline, err := NewGitHub(server.URL, "secret").PostReview(context.Background(), repo, 7, "2 issues found", comments)
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequestReview{ID: 42, URL: "https://github.com/acme/api/pull/7#pullrequestreview-42"}, review)
+	assert.Equal(t, 1, inline)
+
+	assert.Equal(t, "COMMENT", got["event"])
+	assert.Equal(t, []any{map[string]any{"path": "auth/login.go", "line": float64(11), "side": "RIGHT", "body": "SQL injection"}}, got["comments"])
+	assert.Equal(t, "2 issues found\n\n#### Outside the diff\n\n`auth/login.go:40`\n\nUnused parameter\n\n---\n\n`logo.png`\n\nLarge image", got["body"])
+}
+
+func TestGitHub_PostReview_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, _, err := NewGitHub(server.URL, "").PostReview(context.Background(), Repository{Owner: "acme", Name: "api"}, 7, "", nil)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGitHub_RequestReviewers(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/api/pulls/7":
+			fmt.Fprint(w, `{"number":7,"user":{"login":"alice"}}`)
+		case "/repos/acme/api/pulls/7/requested_reviewers":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number":7}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := Repository{Host: "github.com", Owner: "acme", Name: "api"}
+	github := NewGitHub(server.URL, "secret")
+	requested, err := github.RequestReviewers(context.Background(), repo, 7,
+		[]string{"@Alice", "@bob", "@acme/payments", "@other/team", "dev@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@bob", "@acme/payments"}, requested)
+	assert.Equal(t, map[string]any{"reviewers": []any{"bob"}, "team_reviewers": []any{"payments"}}, got)
+
+	got = nil
+	requested, err = github.RequestReviewers(context.Background(), repo, 7, []string{"@alice"})
+	require.NoError(t, err)
+	assert.Empty(t, requested)
+	assert.Nil(t, got, "nothing to request")
+}
@@ -0,0 +1,37 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_NewPullRequestURL(t *testing.T) {
+	tests := []struct {
+		repo Repository
+		want string
+	}{
+		{Repository{"github.com", "acme", "api"}, "https://github.com/acme/api/compare/feat/login?expand=1"},
+		{Repository{"gitlab.com", "acme/backend", "api"}, "https://gitlab.com/acme/backend/api/-/merge_requests/new?merge_request%5Bsource_branch%5D=feat%2Flogin"},
+		{Repository{"bitbucket.org", "acme", "api"}, "https://bitbucket.org/acme/api/pull-requests/new?source=feat%2Flogin"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.repo.NewPullRequestURL("feat/login"))
+	}
+}
+
+func TestPushMessageURL(t *testing.T) {
+	github := `remote:
+remote: Create a pull request for 'feature' on GitHub by visiting:
+remote:      https://github.com/acme/api/pull/new/feature
+remote:
+To github.com:acme/api.git
+ * [new branch]      feature -> feature`
+	assert.Equal(t, "https://github.com/acme/api/pull/new/feature", PushMessageURL(github))
+
+	gitlab := `remote: To create a merge request for feature, visit:
+remote:   https://gitlab.com/acme/api/-/merge_requests/new?merge_request%5Bsource_branch%5D=feature`
+	assert.Equal(t, "https://gitlab.com/acme/api/-/merge_requests/new?merge_request%5Bsource_branch%5D=feature", PushMessageURL(gitlab))
+
+	assert.Empty(t, PushMessageURL("remote: See https://docs.example.com/hooks\nEverything up-to-date"))
+}
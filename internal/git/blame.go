@@ -0,0 +1,107 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GrepMatch is a line of a tracked file matched by git grep
+type GrepMatch struct {
+	Path string // Relative to the directory searched from
+	Line int
+	Text string
+}
+
+// BlameLine describes the commit that last changed a line
+type BlameLine struct {
+	Commit     string
+	Author     string
+	AuthorMail string
+	AuthorTime time.Time
+}
+
+// Committed reports whether the line is part of a commit rather than a local change
+func (b BlameLine) Committed() bool {
+	// git blame reports an all-zero hash for lines not committed yet
+	return strings.Trim(b.Commit, "0") != ""
+}
+
+// Grep searches the tracked text files below workDir for an extended regular
+// expression, optionally limited to pathspecs
+func Grep(ctx context.Context, workDir, pattern string, pathspecs ...string) ([]GrepMatch, error) {
+	args := append([]string{"grep", "-n", "-I", "-z", "--no-color", "-E", pattern, "--"}, pathspecs...)
+	out, err := runGitRaw(ctx, workDir, nil, args...)
+	if err != nil {
+		// git grep exits with 1 when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+
+	var matches []GrepMatch
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		// path NUL line NUL text
+		fields := strings.SplitN(scanner.Text(), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		line, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		matches = append(matches, GrepMatch{Path: fields[0], Line: line, Text: fields[2]})
+	}
+	return matches, scanner.Err()
+}
+
+// Blame returns who last changed each line of the working tree version of
+// path, keyed by line number
+func Blame(ctx context.Context, workDir, path string) (map[int]BlameLine, error) {
+	out, err := runGitRaw(ctx, workDir, nil, "blame", "--line-porcelain", "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+
+	lines := make(map[int]BlameLine)
+	var current BlameLine
+	var lineNum int
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The line content ends the entry
+			lines[lineNum] = current
+		case strings.HasPrefix(text, "author "):
+			current.Author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-mail "):
+			current.AuthorMail = strings.Trim(strings.TrimPrefix(text, "author-mail "), "<>")
+		case strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.AuthorTime = time.Unix(seconds, 0)
+			}
+		default:
+			// Entry header: commit original-line final-line [group-size]
+			fields := strings.Fields(text)
+			if len(fields) >= 3 && len(fields[0]) >= 40 {
+				if n, err := strconv.Atoi(fields[2]); err == nil {
+					current = BlameLine{Commit: fields[0]}
+					lineNum = n
+				}
+			}
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrepAndBlame(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.go", "package main\n\n// TODO: handle errors\nfunc main() {}\n")
+	commitFile(t, repoDir, "Initial commit")
+
+	matches, err := Grep(ctx, repoDir, "TODO|FIXME")
+	require.NoError(t, err)
+	assert.Equal(t, []GrepMatch{{Path: "main.go", Line: 3, Text: "// TODO: handle errors"}}, matches)
+
+	matches, err = Grep(ctx, repoDir, "NOT_PRESENT")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Uncommitted lines are reported with an all-zero commit
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n\n// TODO: handle errors\nfunc main() {}\n// FIXME: new\n"), 0644))
+	lines, err := Blame(ctx, repoDir, "main.go")
+	require.NoError(t, err)
+	require.Len(t, lines, 5)
+	assert.True(t, lines[3].Committed())
+	assert.Equal(t, "Test User", lines[3].Author)
+	assert.Equal(t, "test@example.com", lines[3].AuthorMail)
+	assert.False(t, lines[3].AuthorTime.IsZero())
+	assert.False(t, lines[5].Committed())
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// mutatingCommands are the VCS subcommands that change the repository or a
+// remote, refused in check mode. A subcommand mapped to a list only mutates
+// with one of those sub-subcommands, e.g. git notes add but not git notes show.
+var mutatingCommands = map[string]map[string][]string{
+	"git": {
+		"add": nil, "am": nil, "apply": nil, "checkout": nil, "cherry-pick": nil,
+		"commit": nil, "fetch": nil, "merge": nil, "pull": nil, "push": nil,
+		"rebase": nil, "reset": nil, "restore": nil, "revert": nil, "rm": nil,
+		"stash": nil, "switch": nil, "tag": nil, "update-ref": nil,
+		"branch":   {"-c", "-C", "-d", "-D", "-m", "-M", "--copy", "--delete", "--move", "--set-upstream-to", "-u"},
+		"notes":    {"add", "append", "copy", "edit", "merge", "prune", "remove"},
+		"worktree": {"add", "move", "prune", "remove"},
+	},
+	"jj": {
+		"abandon": nil, "commit": nil, "describe": nil, "edit": nil, "new": nil,
+		"rebase": nil, "restore": nil, "split": nil, "squash": nil,
+		"bookmark": {"create", "delete", "forget", "move", "rename", "set", "track"},
+		"git":      {"fetch", "push"},
+	},
+	"sl": {
+		"amend": nil, "commit": nil, "goto": nil, "metaedit": nil, "pull": nil,
+		"push": nil, "rebase": nil, "revert": nil, "hide": nil,
+	},
+}
+
+// checkSideEffect refuses commands that mutate the repository or a remote in
+// check mode (see the sideeffect package)
+func checkSideEffect(name string, args []string) error {
+	if !sideeffect.Blocked() {
+		return nil
+	}
+	sub, rest := subcommand(args)
+	subs, ok := mutatingCommands[name][sub]
+	if !ok {
+		return nil
+	}
+	if subs != nil && !containsAny(rest, subs) {
+		return nil
+	}
+	return sideeffect.Check(name + " " + sub)
+}
+
+// subcommand returns the first argument that isn't a global option, and the
+// arguments after it. -c and -C take a value in git.
+func subcommand(args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-c" || arg == "-C" || arg == "-R" || arg == "--repository":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg, args[i+1:]
+		}
+	}
+	return "", nil
+}
+
+// containsAny reports whether args contains one of values
+func containsAny(args, values []string) bool {
+	for _, arg := range args {
+		for _, v := range values {
+			if arg == v {
+				return true
+			}
+		}
+	}
+	return false
+}
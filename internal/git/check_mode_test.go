@@ -0,0 +1,72 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMode_RefusesMutatingCommands(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	executor := NewExecutor(repoDir)
+	ctx := context.Background()
+	createAndStageFile(t, repoDir, "initial.txt", "initial")
+	commitFile(t, repoDir, "initial commit")
+	createAndStageFile(t, repoDir, "staged.txt", "staged")
+
+	sideeffect.SetBlocked(true)
+	defer sideeffect.SetBlocked(false)
+
+	err := executor.Commit(ctx, "test: blocked")
+	assert.True(t, errors.Is(err, sideeffect.ErrBlocked), "commit: %v", err)
+	_, err = Push(ctx, repoDir, "origin", "main", true)
+	assert.True(t, errors.Is(err, sideeffect.ErrBlocked), "push: %v", err)
+	assert.True(t, errors.Is(CreateBranch(ctx, repoDir, "feature"), sideeffect.ErrBlocked))
+
+	// Reading still works
+	diff, err := executor.DiffCached(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "staged.txt")
+	log, err := executor.Log(ctx, LogOptions{Count: 1})
+	require.NoError(t, err)
+	assert.Contains(t, log, "initial commit")
+
+	sideeffect.SetBlocked(false)
+	require.NoError(t, executor.Commit(ctx, "test: allowed"))
+}
+
+func TestCheckSideEffect(t *testing.T) {
+	sideeffect.SetBlocked(true)
+	defer sideeffect.SetBlocked(false)
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		blocked bool
+	}{
+		{"git commit", "git", []string{"commit", "-m", "x"}, true},
+		{"git commit after global options", "git", []string{"-c", "user.name=x", "--no-pager", "commit"}, true},
+		{"git diff", "git", []string{"diff", "--cached"}, false},
+		{"git -C path status", "git", []string{"-C", "commit", "status"}, false},
+		{"git notes show", "git", []string{"notes", "--ref", "gitbuddy", "show", "HEAD"}, false},
+		{"git notes add", "git", []string{"notes", "--ref", "gitbuddy", "add", "-m", "x"}, true},
+		{"git branch list", "git", []string{"branch", "--list"}, false},
+		{"git branch delete", "git", []string{"branch", "-D", "old"}, true},
+		{"jj log", "jj", []string{"--no-pager", "log"}, false},
+		{"jj commit", "jj", []string{"--no-pager", "--color=never", "commit", "-m", "x"}, true},
+		{"jj git push", "jj", []string{"git", "push"}, true},
+		{"sl bookmarks", "sl", []string{"--color=never", "bookmarks"}, false},
+		{"sl commit", "sl", []string{"--color=never", "commit", "-m", "x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSideEffect(tt.command, tt.args)
+			assert.Equal(t, tt.blocked, errors.Is(err, sideeffect.ErrBlocked), "%v", err)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoRepository is returned by DiffExecutor for operations that need a real repository
+var ErrNoRepository = errors.New("not available: changes were provided as a diff, not read from a repository")
+
+// DiffExecutor is a virtual Executor backed by a unified diff instead of a local
+// repository. It lets the agents run on patches produced elsewhere, such as
+// server-side tooling or other VCS frontends. Operations that need history or
+// a working tree return ErrNoRepository.
+type DiffExecutor struct {
+	diff   string
+	branch string
+	user   string
+}
+
+// DiffExecutorOption configures a DiffExecutor
+type DiffExecutorOption func(*DiffExecutor)
+
+// WithDiffBranch sets the branch name reported by CurrentBranch
+func WithDiffBranch(branch string) DiffExecutorOption {
+	return func(e *DiffExecutor) {
+		e.branch = branch
+	}
+}
+
+// WithDiffUser sets the user name reported by CurrentUser
+func WithDiffUser(user string) DiffExecutorOption {
+	return func(e *DiffExecutor) {
+		e.user = user
+	}
+}
+
+// NewDiffExecutor creates a DiffExecutor serving the given diff as staged changes
+func NewDiffExecutor(diff string, opts ...DiffExecutorOption) *DiffExecutor {
+	e := &DiffExecutor{diff: strings.TrimSpace(diff)}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// ReadDiff reads a unified diff from r and validates that it contains changes
+func ReadDiff(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read diff: %w", err)
+	}
+
+	diff := strings.TrimSpace(string(data))
+	if diff == "" {
+		return "", fmt.Errorf("no diff provided on stdin")
+	}
+	if len(DiffFiles(diff)) == 0 {
+		return "", fmt.Errorf("input is not a unified diff (no file headers found)")
+	}
+	return diff, nil
+}
+
+// DiffFile describes a file changed by a unified diff
+type DiffFile struct {
+	Path   string
+	Status string // added, deleted, renamed or modified
+}
+
+// DiffFiles lists the files changed by a unified diff. Both `git diff` output
+// and plain `diff -u` output (without "diff --git" lines) are supported.
+func DiffFiles(diff string) []DiffFile {
+	var files []DiffFile
+	var current *DiffFile
+	var oldPath string
+	gitStyle := strings.HasPrefix(diff, "diff --git ") || strings.Contains(diff, "\ndiff --git ")
+	inHunk := false
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, DiffFile{Status: "modified"})
+			current = &files[len(files)-1]
+			inHunk = false
+			if parts := strings.SplitN(strings.TrimPrefix(line, "diff --git "), " b/", 2); len(parts) == 2 {
+				current.Path = parts[1]
+			}
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && gitStyle:
+			// Hunk content, including removed lines starting with "--"
+		case strings.HasPrefix(line, "new file mode") && current != nil:
+			current.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode") && current != nil:
+			current.Status = "deleted"
+		case strings.HasPrefix(line, "rename to ") && current != nil:
+			current.Status = "renamed"
+			current.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- "):
+			oldPath = diffHeaderPath(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ "):
+			if !gitStyle {
+				// Each ---/+++ pair starts a new file in plain unified diffs
+				files = append(files, DiffFile{Status: "modified"})
+				current = &files[len(files)-1]
+				inHunk = false
+			}
+			if current == nil {
+				continue
+			}
+			newPath := diffHeaderPath(strings.TrimPrefix(line, "+++ "))
+			switch {
+			case oldPath == "/dev/null":
+				current.Status = "added"
+				current.Path = newPath
+			case newPath == "/dev/null":
+				current.Status = "deleted"
+				current.Path = oldPath
+			case current.Path == "":
+				current.Path = newPath
+			}
+		}
+	}
+	return files
+}
+
+// diffHeaderPath extracts the path from a ---/+++ header line
+func diffHeaderPath(header string) string {
+	// Strip trailing timestamps emitted by diff -u
+	if idx := strings.Index(header, "\t"); idx >= 0 {
+		header = header[:idx]
+	}
+	if header == "/dev/null" {
+		return header
+	}
+	if strings.HasPrefix(header, "a/") || strings.HasPrefix(header, "b/") {
+		return header[2:]
+	}
+	return header
+}
+
+// DiffCached returns the provided diff
+func (e *DiffExecutor) DiffCached(ctx context.Context) (string, error) {
+	return e.diff, nil
+}
+
+// DiffBranches is not available without a repository
+func (e *DiffExecutor) DiffBranches(ctx context.Context, base, head string, opts DiffOptions) (string, error) {
+	return "", ErrNoRepository
+}
+
+// Status summarizes the files changed by the diff in `git status` style
+func (e *DiffExecutor) Status(ctx context.Context) (string, error) {
+	files := DiffFiles(e.diff)
+	if len(files) == 0 {
+		return "nothing to commit", nil
+	}
+
+	var sb strings.Builder
+	if e.branch != "" {
+		sb.WriteString(fmt.Sprintf("On branch %s\n", e.branch))
+	}
+	sb.WriteString("Changes to be committed (from provided diff):\n")
+	for _, f := range files {
+		status := f.Status
+		if status == "added" {
+			status = "new file"
+		}
+		sb.WriteString(fmt.Sprintf("\t%s:   %s\n", status, f.Path))
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// Log returns an empty log since no history is available
+func (e *DiffExecutor) Log(ctx context.Context, opts LogOptions) (string, error) {
+	return "", nil
+}
+
+// LogRange is not available without a repository
+func (e *DiffExecutor) LogRange(ctx context.Context, base, head string) (string, error) {
+	return "", ErrNoRepository
+}
+
+// Show is not available without a repository
+func (e *DiffExecutor) Show(ctx context.Context, ref string) (string, error) {
+	return "", ErrNoRepository
+}
+
+// ListBranches is not available without a repository
+func (e *DiffExecutor) ListBranches(ctx context.Context) (string, error) {
+	return "", ErrNoRepository
+}
+
+// Commit is not available without a repository
+func (e *DiffExecutor) Commit(ctx context.Context, message string) error {
+	return ErrNoRepository
+}
+
+// CurrentBranch returns the configured branch name
+func (e *DiffExecutor) CurrentBranch(ctx context.Context) (string, error) {
+	return e.branch, nil
+}
+
+// CurrentUser returns the configured user name
+func (e *DiffExecutor) CurrentUser(ctx context.Context) (string, error) {
+	return e.user, nil
+}
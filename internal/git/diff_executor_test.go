@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitStyleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+--- removed comment line
++// added
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+
+func TestDiffFiles(t *testing.T) {
+	t.Run("git diff", func(t *testing.T) {
+		assert.Equal(t, []DiffFile{
+			{Path: "main.go", Status: "modified"},
+			{Path: "new.txt", Status: "added"},
+			{Path: "old.txt", Status: "deleted"},
+		}, DiffFiles(gitStyleDiff))
+	})
+
+	t.Run("plain unified diff", func(t *testing.T) {
+		diff := "--- src/app.c\t2024-01-01 00:00:00\n+++ src/app.c\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n" +
+			"--- /dev/null\n+++ src/new.c\n@@ -0,0 +1 @@\n+c\n"
+		assert.Equal(t, []DiffFile{
+			{Path: "src/app.c", Status: "modified"},
+			{Path: "src/new.c", Status: "added"},
+		}, DiffFiles(diff))
+	})
+
+	t.Run("not a diff", func(t *testing.T) {
+		assert.Empty(t, DiffFiles("hello world"))
+	})
+}
+
+func TestReadDiff(t *testing.T) {
+	diff, err := ReadDiff(strings.NewReader("\n" + gitStyleDiff + "\n\n"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(gitStyleDiff), diff)
+
+	_, err = ReadDiff(strings.NewReader("  \n"))
+	assert.ErrorContains(t, err, "no diff provided")
+
+	_, err = ReadDiff(strings.NewReader("just some text"))
+	assert.ErrorContains(t, err, "not a unified diff")
+}
+
+func TestDiffExecutor(t *testing.T) {
+	ctx := context.Background()
+	var executor Executor = NewDiffExecutor(gitStyleDiff, WithDiffBranch("feature"), WithDiffUser("Jane"))
+
+	diff, err := executor.DiffCached(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(gitStyleDiff), diff)
+
+	status, err := executor.Status(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, status, "On branch feature")
+	assert.Contains(t, status, "modified:   main.go")
+	assert.Contains(t, status, "new file:   new.txt")
+	assert.Contains(t, status, "deleted:   old.txt")
+
+	branch, err := executor.CurrentBranch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "feature", branch)
+
+	user, err := executor.CurrentUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", user)
+
+	log, err := executor.Log(ctx, LogOptions{Count: 5})
+	require.NoError(t, err)
+	assert.Empty(t, log)
+
+	assert.ErrorIs(t, executor.Commit(ctx, "feat: x"), ErrNoRepository)
+	_, err = executor.Show(ctx, "HEAD")
+	assert.ErrorIs(t, err, ErrNoRepository)
+}
@@ -16,15 +16,28 @@ type LogOptions struct {
 	Until  string
 	Format string
 	Count  int
+	// NumStat adds per-file insertion/deletion counts (git --numstat); other VCS ignore it
+	NumStat bool
 }
 
-// Executor defines the interface for git command execution
+// DiffOptions represents options for diffing two branches
+type DiffOptions struct {
+	// TwoDot compares the branch tips directly (base..head). By default head is
+	// compared against its merge base with base (base...head), which leaves out
+	// upstream changes merged into base after head forked.
+	TwoDot bool
+	Stat   bool     // Only show the diffstat
+	Paths  []string // Limit the diff to these paths
+}
+
+// Executor defines the interface for version control operations. It is
+// implemented for git, Jujutsu and Sapling (see NewVCSExecutor).
 type Executor interface {
 	// DiffCached returns the diff of staged changes
 	DiffCached(ctx context.Context) (string, error)
 
 	// DiffBranches returns the diff between two branches
-	DiffBranches(ctx context.Context, base, head string) (string, error)
+	DiffBranches(ctx context.Context, base, head string, opts DiffOptions) (string, error)
 
 	// Status returns the current git status
 	Status(ctx context.Context) (string, error)
@@ -54,6 +67,8 @@ type Executor interface {
 // DefaultExecutor is the default implementation of Executor
 type DefaultExecutor struct {
 	workDir string
+
+	stagedDiffSource func(ctx context.Context) (string, error)
 }
 
 // NewExecutor creates a new DefaultExecutor
@@ -63,33 +78,73 @@ func NewExecutor(workDir string) *DefaultExecutor {
 
 // runGit runs a git command and returns the output
 func (e *DefaultExecutor) runGit(ctx context.Context, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Dir = e.workDir
+	return runCommand(ctx, e.workDir, "git", args...)
+}
+
+// runCommand runs a VCS command in dir and returns the trimmed output
+func runCommand(ctx context.Context, dir, name string, args ...string) (string, error) {
+	return runCommandEnv(ctx, dir, parseEnv(), name, args...)
+}
+
+// runCommandEnv runs a VCS command in dir with env (nil = inherited) and
+// returns the trimmed output
+func runCommandEnv(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	if err := checkSideEffect(name, args); err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, stderr.String())
+		return "", fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, stderr.String())
 	}
 
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// SetStagedDiffSource makes DiffCached ask source first, such as a daemon
+// keeping the diff warm. git computes the diff when source fails.
+func (e *DefaultExecutor) SetStagedDiffSource(source func(ctx context.Context) (string, error)) {
+	e.stagedDiffSource = source
+}
+
 // DiffCached returns the diff of staged changes
 func (e *DefaultExecutor) DiffCached(ctx context.Context) (string, error) {
+	if e.stagedDiffSource != nil {
+		if diff, err := e.stagedDiffSource(ctx); err == nil {
+			return diff, nil
+		}
+	}
 	return e.runGit(ctx, "diff", "--cached")
 }
 
 // DiffBranches returns the diff between two branches
-func (e *DefaultExecutor) DiffBranches(ctx context.Context, base, head string) (string, error) {
-	return e.runGit(ctx, "diff", fmt.Sprintf("%s...%s", base, head))
+func (e *DefaultExecutor) DiffBranches(ctx context.Context, base, head string, opts DiffOptions) (string, error) {
+	args := []string{"diff"}
+	if opts.Stat {
+		args = append(args, "--stat")
+	}
+	if opts.TwoDot {
+		args = append(args, fmt.Sprintf("%s..%s", base, head))
+	} else {
+		args = append(args, fmt.Sprintf("%s...%s", base, head))
+	}
+	if len(opts.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, opts.Paths...)
+	}
+	return e.runGit(ctx, args...)
 }
 
 // Status returns the current git status
 func (e *DefaultExecutor) Status(ctx context.Context) (string, error) {
-	return e.runGit(ctx, "status")
+	// Shown to the model as is, so it follows the output language
+	return runCommandEnv(ctx, e.workDir, displayEnv(), "git", "status")
 }
 
 // Log returns the commit log
@@ -118,6 +173,9 @@ func (e *DefaultExecutor) Log(ctx context.Context, opts LogOptions) (string, err
 	if opts.Format != "" {
 		args = append(args, "--format="+opts.Format)
 	}
+	if opts.NumStat {
+		args = append(args, "--numstat")
+	}
 
 	output, err := e.runGit(ctx, args...)
 	if err != nil {
@@ -130,10 +188,28 @@ func (e *DefaultExecutor) Log(ctx context.Context, opts LogOptions) (string, err
 	return output, nil
 }
 
-// Commit executes a git commit with the given message
+// Commit executes a git commit with the given message. When a hook of the
+// repository rejects the commit, the error is a *HookError with the output
+// of the hooks, which git prints on stdout as well as stderr.
 func (e *DefaultExecutor) Commit(ctx context.Context, message string) error {
-	_, err := e.runGit(ctx, "commit", "-m", message)
-	return err
+	args := []string{"commit", "-m", message}
+	if err := checkSideEffect("git", args); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = e.workDir
+	cmd.Env = parseEnv()
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if hookErr := commitHookError(ctx, e.workDir, output.String(), err); hookErr != nil {
+			return hookErr
+		}
+		return fmt.Errorf("git commit failed: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
 }
 
 // Show returns detailed information about a commit
@@ -159,6 +235,26 @@ func (e *DefaultExecutor) CurrentUser(ctx context.Context) (string, error) {
 	return e.runGit(ctx, "config", "user.name")
 }
 
+// StagedFileSize returns the size in bytes of the staged version of path
+func (e *DefaultExecutor) StagedFileSize(ctx context.Context, path string) (int64, error) {
+	out, err := e.runGit(ctx, "cat-file", "-s", ":"+path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(out, 10, 64)
+}
+
+// RevListCount returns how many commits head is ahead of and behind base
+// (see RevListCount)
+func (e *DefaultExecutor) RevListCount(ctx context.Context, base, head string) (ahead, behind int, err error) {
+	return RevListCount(ctx, e.workDir, base, head)
+}
+
+// MergeTree simulates merging head into base (see MergeTree)
+func (e *DefaultExecutor) MergeTree(ctx context.Context, base, head string) (*MergeTreeResult, error) {
+	return MergeTree(ctx, e.workDir, base, head)
+}
+
 // LogRange returns the commit log between two refs (base..head)
 func (e *DefaultExecutor) LogRange(ctx context.Context, base, head string) (string, error) {
 	return e.runGit(ctx, "log", fmt.Sprintf("%s..%s", base, head), "--pretty=format:%h %s")
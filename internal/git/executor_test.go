@@ -2,6 +2,7 @@ package git
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -96,6 +97,24 @@ func TestExecutor_DiffCached_MultipleFiles(t *testing.T) {
 	assert.Contains(t, diff, "file2.go")
 }
 
+func TestExecutor_DiffCached_Source(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	executor := NewExecutor(repoDir)
+	ctx := context.Background()
+	createAndStageFile(t, repoDir, "file.go", "package main")
+
+	executor.SetStagedDiffSource(func(ctx context.Context) (string, error) { return "cached diff", nil })
+	diff, err := executor.DiffCached(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cached diff", diff)
+
+	// git computes the diff when the source fails
+	executor.SetStagedDiffSource(func(ctx context.Context) (string, error) { return "", errors.New("unreachable") })
+	diff, err = executor.DiffCached(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "file.go")
+}
+
 func TestExecutor_Status(t *testing.T) {
 	repoDir := setupTestRepo(t)
 	executor := NewExecutor(repoDir)
@@ -238,11 +257,54 @@ func TestExecutor_DiffBranches(t *testing.T) {
 	cmd.Dir = repoDir
 	cmd.Run()
 
-	diff, err := executor.DiffBranches(ctx, mainBranch, "HEAD")
+	diff, err := executor.DiffBranches(ctx, mainBranch, "HEAD", DiffOptions{})
 	require.NoError(t, err)
 	assert.Contains(t, diff, "feature.txt")
 }
 
+func TestExecutor_DiffBranchesOptions(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	executor := NewExecutor(repoDir)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.txt", "main content")
+	commitFile(t, repoDir, "initial commit")
+	mainBranch, err := executor.CurrentBranch(ctx)
+	require.NoError(t, err)
+
+	cmd := exec.Command("git", "checkout", "-b", "feature")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+	createAndStageFile(t, repoDir, "feature.txt", "feature content")
+	createAndStageFile(t, repoDir, "docs.md", "docs")
+	commitFile(t, repoDir, "feat: add feature")
+
+	// Upstream change merged into the base after the feature branch forked
+	cmd = exec.Command("git", "checkout", mainBranch)
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+	createAndStageFile(t, repoDir, "upstream.txt", "upstream")
+	commitFile(t, repoDir, "upstream change")
+	cmd = exec.Command("git", "checkout", "feature")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+
+	diff, err := executor.DiffBranches(ctx, mainBranch, "HEAD", DiffOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, diff, "upstream.txt", "three-dot diffs against the merge base")
+
+	diff, err = executor.DiffBranches(ctx, mainBranch, "HEAD", DiffOptions{TwoDot: true})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "upstream.txt")
+
+	diff, err = executor.DiffBranches(ctx, mainBranch, "HEAD", DiffOptions{Stat: true, Paths: []string{"feature.txt"}})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "feature.txt | 1 +")
+	assert.Contains(t, diff, "1 file changed")
+	assert.NotContains(t, diff, "docs.md")
+	assert.NotContains(t, diff, "@@")
+}
+
 func TestExecutor_NotAGitRepo(t *testing.T) {
 	tmpDir := t.TempDir()
 	executor := NewExecutor(tmpDir)
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LFSPointerPrefix starts every Git LFS pointer file
+const LFSPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+
+// UntrackedFile is a file git does not track and does not ignore
+type UntrackedFile struct {
+	Path string
+	Size int64
+}
+
+// ConfigValue returns a git config value, or an empty string when it is not set
+func ConfigValue(ctx context.Context, workDir, key string) (string, error) {
+	out, err := runCommand(ctx, workDir, "git", "config", "--get", key)
+	if err != nil {
+		// git config --get exits with 1 when the key is not set
+		if strings.Contains(err.Error(), "exit status 1") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git config %s: %w", key, err)
+	}
+	return out, nil
+}
+
+// IsShallow reports whether the repository is a shallow clone
+func IsShallow(ctx context.Context, workDir string) (bool, error) {
+	out, err := runCommand(ctx, workDir, "git", "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	return out == "true", nil
+}
+
+// HeadBranch returns the branch HEAD points to, even before its first commit,
+// or an empty string when HEAD is detached
+func HeadBranch(ctx context.Context, workDir string) (string, error) {
+	out, err := runCommand(ctx, workDir, "git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		if strings.Contains(err.Error(), "exit status 1") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return out, nil
+}
+
+// UntrackedFiles lists the untracked, non-ignored files of the repository
+// containing workDir, with their sizes
+func UntrackedFiles(ctx context.Context, workDir string) ([]UntrackedFile, error) {
+	root, err := runCommand(ctx, workDir, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	out, err := runGitRaw(ctx, root, nil, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var files []UntrackedFile
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(root, path))
+		if err != nil {
+			continue
+		}
+		files = append(files, UntrackedFile{Path: path, Size: info.Size()})
+	}
+	return files, nil
+}
+
+// LFSPointersInDiff returns the files whose new content in diff is a Git LFS
+// pointer rather than the actual file
+func LFSPointersInDiff(diff string) []string {
+	var files []string
+	var current string
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = ""
+		case strings.HasPrefix(line, "+++ b/"):
+			current = strings.TrimPrefix(line, "+++ b/")
+		case current != "" && line == "+"+LFSPointerPrefix:
+			files = append(files, current)
+			current = ""
+		}
+	}
+	return files
+}
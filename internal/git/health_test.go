@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecks(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	name, err := ConfigValue(ctx, repoDir, "user.name")
+	require.NoError(t, err)
+	assert.Equal(t, "Test User", name)
+	missing, err := ConfigValue(ctx, repoDir, "gitbuddy.missing")
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	// The branch is known before the first commit
+	branch, err := HeadBranch(ctx, repoDir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, branch)
+
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	commitFile(t, repoDir, "Initial commit")
+	createAndStageFile(t, repoDir, "second.go", "package main\n")
+	commitFile(t, repoDir, "Second commit")
+
+	shallow, err := IsShallow(ctx, repoDir)
+	require.NoError(t, err)
+	assert.False(t, shallow)
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	out, err := exec.Command("git", "clone", "--quiet", "--depth", "1", "file://"+repoDir, cloneDir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	shallow, err = IsShallow(ctx, cloneDir)
+	require.NoError(t, err)
+	assert.True(t, shallow)
+
+	cmd := exec.Command("git", "checkout", "--quiet", "--detach")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+	branch, err = HeadBranch(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Empty(t, branch, "detached HEAD")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "data"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "data", "dump.bin"), make([]byte, 2048), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".gitignore"), []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "debug.log"), []byte("ignored"), 0644))
+	files, err := UntrackedFiles(ctx, filepath.Join(repoDir, "data"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []UntrackedFile{{Path: ".gitignore", Size: 6}, {Path: "data/dump.bin", Size: 2048}}, files)
+}
+
+func TestLFSPointersInDiff(t *testing.T) {
+	diff := `diff --git a/assets/logo.png b/assets/logo.png
+new file mode 100644
+index 0000000..b6fc4c6
+--- /dev/null
++++ b/assets/logo.png
+@@ -0,0 +1,3 @@
++version https://git-lfs.github.com/spec/v1
++oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
++size 12345
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1,2 @@
+ # Project
++version https://git-lfs.github.com/spec/v1 is the pointer format
+`
+	assert.Equal(t, []string{"assets/logo.png"}, LFSPointersInDiff(diff))
+	assert.Empty(t, LFSPointersInDiff(""))
+}
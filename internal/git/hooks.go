@@ -0,0 +1,146 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// commitHooks are the hooks run by git commit that can reject the commit
+var commitHooks = []string{"pre-commit", "prepare-commit-msg", "commit-msg"}
+
+// huskyFailure matches the line husky prints when a hook fails, e.g.
+// "husky - pre-commit script failed (code 1)" or, before husky 9,
+// "husky > pre-commit hook failed (add --no-verify to bypass)"
+var huskyFailure = regexp.MustCompile(`husky [->] ([a-z-]+) (?:script|hook) failed(?: \(code (\d+)\))?`)
+
+// gitCommitFailures are messages of git itself, not of a hook, for commits
+// that fail
+var gitCommitFailures = []string{
+	"nothing to commit",
+	"nothing added to commit",
+	"no changes added to commit",
+	"Aborting commit due to empty commit message",
+}
+
+// HookError reports a commit rejected by a hook of the repository, such as
+// a husky or pre-commit check
+type HookError struct {
+	Hook     string // The hook that failed, or the installed commit hooks when it isn't known which
+	ExitCode int
+	Output   string // What git and the hooks printed on stdout and stderr
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("the %s hook rejected the commit (exit code %d)", e.Hook, e.ExitCode)
+}
+
+// CommitHooks returns the installed hooks that can reject a commit. They are
+// looked up where git runs them: core.hooksPath, which husky sets, or
+// .git/hooks.
+func CommitHooks(ctx context.Context, workDir string) ([]string, error) {
+	dir, err := runCommand(ctx, workDir, "git", "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the hooks: %w", err)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workDir, dir)
+	}
+	var hooks []string
+	for _, hook := range commitHooks {
+		info, err := os.Stat(filepath.Join(dir, hook))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// Windows has no executable bit; git runs hooks there through sh
+		if runtime.GOOS == "windows" || info.Mode()&0o111 != 0 {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+// commitHookError returns a *HookError when git commit failed with err and
+// output because of a hook, nil when git itself failed
+func commitHookError(ctx context.Context, workDir string, output string, err error) *HookError {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil
+	}
+	hookErr := &HookError{ExitCode: exitErr.ExitCode(), Output: strings.TrimSpace(output)}
+	if m := huskyFailure.FindStringSubmatch(output); m != nil {
+		hookErr.Hook = m[1]
+		if code, err := strconv.Atoi(m[2]); err == nil {
+			hookErr.ExitCode = code
+		}
+		return hookErr
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "fatal: ") {
+			return nil
+		}
+	}
+	for _, message := range gitCommitFailures {
+		if strings.Contains(output, message) {
+			return nil
+		}
+	}
+	hooks, _ := CommitHooks(ctx, workDir)
+	if len(hooks) == 0 {
+		return nil
+	}
+	hookErr.Hook = strings.Join(hooks, " or ")
+	return hookErr
+}
+
+// StagedFiles lists the files with staged changes
+func StagedFiles(ctx context.Context, workDir string) ([]string, error) {
+	out, err := runGitRaw(ctx, workDir, nil, "diff", "--cached", "--name-only", "-z", "--no-renames")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+	return splitNull(out), nil
+}
+
+// UnstagedFiles lists those of files changed in the working tree since they
+// were staged, such as files a formatter hook rewrote
+func UnstagedFiles(ctx context.Context, workDir string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	out, err := runGitRaw(ctx, workDir, nil, append([]string{"diff", "--name-only", "-z", "--"}, files...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unstaged files: %w", err)
+	}
+	return splitNull(out), nil
+}
+
+// StageFiles stages the current content of files
+func StageFiles(ctx context.Context, workDir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if _, err := runCommand(ctx, workDir, "git", append([]string{"add", "--"}, files...)...); err != nil {
+		return fmt.Errorf("failed to stage files: %w", err)
+	}
+	return nil
+}
+
+// splitNull splits NUL-terminated output into its entries
+func splitNull(out []byte) []string {
+	var entries []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installHook writes an executable hook script into the hooks directory of
+// the repository
+func installHook(t *testing.T, repoDir, hooksDir, name, script string) {
+	t.Helper()
+	dir := filepath.Join(repoDir, hooksDir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+}
+
+func TestExecutor_Commit_HookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need sh")
+	}
+	repoDir := setupTestRepo(t)
+	executor := NewExecutor(repoDir)
+	ctx := context.Background()
+
+	installHook(t, repoDir, ".git/hooks", "pre-commit", "echo 'lint: main.go:3: missing doc comment'\necho 'formatting failed' >&2\nexit 3\n")
+	hooks, err := CommitHooks(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pre-commit"}, hooks)
+
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	err = executor.Commit(ctx, "feat: add main")
+	var hookErr *HookError
+	require.True(t, errors.As(err, &hookErr), "got %v", err)
+	assert.Equal(t, "pre-commit", hookErr.Hook)
+	assert.Equal(t, 1, hookErr.ExitCode, "git reports its own exit code")
+	assert.Contains(t, hookErr.Output, "lint: main.go:3: missing doc comment")
+	assert.Contains(t, hookErr.Output, "formatting failed")
+
+	// A failure of git itself is not blamed on the hook
+	require.NoError(t, os.Remove(filepath.Join(repoDir, ".git/hooks/pre-commit")))
+	require.NoError(t, executor.Commit(ctx, "feat: add main"))
+	installHook(t, repoDir, ".git/hooks", "pre-commit", "exit 0\n")
+	err = executor.Commit(ctx, "nothing staged")
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &hookErr))
+}
+
+func TestExecutor_Commit_HuskyHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need sh")
+	}
+	repoDir := setupTestRepo(t)
+	runGitCmd(t, repoDir, "config", "core.hooksPath", ".husky")
+	installHook(t, repoDir, ".husky", "pre-commit", "exit 0\n")
+	installHook(t, repoDir, ".husky", "commit-msg", "echo 'husky - commit-msg script failed (code 2)' >&2\nexit 2\n")
+	ctx := context.Background()
+
+	hooks, err := CommitHooks(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pre-commit", "commit-msg"}, hooks)
+
+	createAndStageFile(t, repoDir, "a.txt", "a\n")
+	err = NewExecutor(repoDir).Commit(ctx, "bad message")
+	var hookErr *HookError
+	require.True(t, errors.As(err, &hookErr), "got %v", err)
+	assert.Equal(t, "commit-msg", hookErr.Hook)
+	assert.Equal(t, 2, hookErr.ExitCode)
+	assert.Equal(t, "the commit-msg hook rejected the commit (exit code 2)", hookErr.Error())
+}
+
+func TestStagedAndUnstagedFiles(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "a.go", "package a\n")
+	createAndStageFile(t, repoDir, "b.go", "package b\n")
+	staged, err := StagedFiles(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b.go"}, staged)
+
+	// A formatter hook rewrote a.go after it was staged
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.go"), []byte("package a\n\n"), 0644))
+	changed, err := UnstagedFiles(ctx, repoDir, staged)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, changed)
+
+	require.NoError(t, StageFiles(ctx, repoDir, changed))
+	changed, err = UnstagedFiles(ctx, repoDir, staged)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+}
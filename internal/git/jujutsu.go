@@ -0,0 +1,128 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jjLogPlaceholders maps git --format placeholders to jj template expressions
+var jjLogPlaceholders = map[string]string{
+	"%H":  "commit_id",
+	"%h":  "commit_id.short()",
+	"%s":  "description.first_line()",
+	"%an": "author.name()",
+	"%ae": "author.email()",
+	"%ad": `author.timestamp().format("%Y-%m-%d %H:%M:%S")`,
+	"%ai": `author.timestamp().format("%Y-%m-%d %H:%M:%S %z")`,
+}
+
+// JujutsuExecutor implements Executor for Jujutsu (jj) repositories.
+// jj has no staging area, so the working-copy change (@) plays the role of
+// the staged changes and "HEAD" maps to its parent (@-).
+type JujutsuExecutor struct {
+	workDir string
+}
+
+// NewJujutsuExecutor creates a new JujutsuExecutor
+func NewJujutsuExecutor(workDir string) *JujutsuExecutor {
+	return &JujutsuExecutor{workDir: workDir}
+}
+
+// runJJ runs a jj command and returns the output
+func (e *JujutsuExecutor) runJJ(ctx context.Context, args ...string) (string, error) {
+	return runCommand(ctx, e.workDir, "jj", append([]string{"--no-pager", "--color=never"}, args...)...)
+}
+
+// DiffCached returns the diff of the working-copy change
+func (e *JujutsuExecutor) DiffCached(ctx context.Context) (string, error) {
+	return e.runJJ(ctx, "diff", "--git", "-r", "@")
+}
+
+// DiffBranches returns the diff between the fork point of two revisions (or
+// base itself with TwoDot) and head
+func (e *JujutsuExecutor) DiffBranches(ctx context.Context, base, head string, opts DiffOptions) (string, error) {
+	from := fmt.Sprintf("heads(::(%s) & ::(%s))", base, head)
+	if opts.TwoDot {
+		from = base
+	}
+	args := []string{"diff", "--git"}
+	if opts.Stat {
+		args = []string{"diff", "--stat"}
+	}
+	args = append(args, "--from", from, "--to", head)
+	return e.runJJ(ctx, append(args, opts.Paths...)...)
+}
+
+// Status returns the working-copy status
+func (e *JujutsuExecutor) Status(ctx context.Context) (string, error) {
+	return e.runJJ(ctx, "status")
+}
+
+// Log returns the history of the working-copy change's ancestors
+func (e *JujutsuExecutor) Log(ctx context.Context, opts LogOptions) (string, error) {
+	revset := []string{"::@-", "~root()"}
+	if opts.Author != "" {
+		revset = append(revset, "& author("+strconv.Quote(opts.Author)+")")
+	}
+	if opts.Since != "" {
+		revset = append(revset, "& author_date(after:"+strconv.Quote(opts.Since)+")")
+	}
+	if opts.Until != "" {
+		revset = append(revset, "& author_date(before:"+strconv.Quote(opts.Until)+")")
+	}
+
+	args := []string{"log", "--no-graph", "-r", strings.Join(revset, " ")}
+	if opts.Count > 0 {
+		args = append(args, "-n", strconv.Itoa(opts.Count))
+	}
+	if opts.Format != "" {
+		args = append(args, "-T", translateLogFormat(opts.Format, jjLogPlaceholders, strconv.Quote, " ++ "))
+	}
+	return e.runJJ(ctx, args...)
+}
+
+// LogRange returns the changes in head that are not in base (base..head)
+func (e *JujutsuExecutor) LogRange(ctx context.Context, base, head string) (string, error) {
+	return e.runJJ(ctx, "log", "--no-graph", "-r", fmt.Sprintf("(%s)..(%s)", base, head),
+		"-T", `commit_id.short() ++ " " ++ description.first_line() ++ "\n"`)
+}
+
+// Show returns detailed information about a revision
+func (e *JujutsuExecutor) Show(ctx context.Context, ref string) (string, error) {
+	if ref == "" || ref == "HEAD" {
+		ref = "@-"
+	}
+	return e.runJJ(ctx, "show", "--stat", "-r", ref)
+}
+
+// ListBranches returns all bookmarks
+func (e *JujutsuExecutor) ListBranches(ctx context.Context) (string, error) {
+	return e.runJJ(ctx, "bookmark", "list", "--all")
+}
+
+// Commit describes the working-copy change and starts a new one
+func (e *JujutsuExecutor) Commit(ctx context.Context, message string) error {
+	_, err := e.runJJ(ctx, "commit", "-m", message)
+	return err
+}
+
+// CurrentBranch returns the nearest bookmark on the working-copy change's
+// ancestors, or the change ID when there is none
+func (e *JujutsuExecutor) CurrentBranch(ctx context.Context) (string, error) {
+	out, err := e.runJJ(ctx, "log", "--no-graph", "-r", "heads(::@ & bookmarks())", "-T", `bookmarks ++ "\n"`)
+	if err != nil {
+		return "", err
+	}
+	if fields := strings.Fields(out); len(fields) > 0 {
+		// Conflicted or unpushed bookmarks are suffixed with markers such as "*" or "??"
+		return strings.TrimRight(fields[0], "*?"), nil
+	}
+	return e.runJJ(ctx, "log", "--no-graph", "-r", "@", "-T", "change_id.short()")
+}
+
+// CurrentUser returns the configured jj user name
+func (e *JujutsuExecutor) CurrentUser(ctx context.Context) (string, error) {
+	return e.runJJ(ctx, "config", "get", "user.name")
+}
@@ -0,0 +1,34 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// displayLocale is the locale of git output shown to the model as is
+var displayLocale atomic.Value // string
+
+// SetLocale makes git print the output shown to the model, such as git
+// status, in locale (e.g. "zh_CN.UTF-8"). An empty locale keeps the user's
+// environment. Commands whose output is parsed always run in the C locale.
+func SetLocale(locale string) {
+	displayLocale.Store(locale)
+}
+
+// parseEnv is the environment of commands whose output or errors are parsed,
+// pinned to untranslated messages whatever the user's locale
+func parseEnv() []string {
+	return append(os.Environ(), "LC_ALL=C", "LANGUAGE=")
+}
+
+// displayEnv is the environment of commands whose output is shown as is.
+// It is nil, inheriting the user's environment, unless a locale was set.
+func displayEnv() []string {
+	locale, _ := displayLocale.Load().(string)
+	if locale == "" {
+		return nil
+	}
+	language, _, _ := strings.Cut(locale, ".")
+	return append(os.Environ(), "LC_ALL="+locale, "LANGUAGE="+language)
+}
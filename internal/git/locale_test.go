@@ -0,0 +1,20 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleEnv(t *testing.T) {
+	t.Cleanup(func() { SetLocale("") })
+
+	assert.Nil(t, displayEnv(), "the user's environment is inherited by default")
+	assert.Contains(t, parseEnv(), "LC_ALL=C")
+
+	SetLocale("zh_CN.UTF-8")
+	env := displayEnv()
+	assert.Contains(t, env, "LC_ALL=zh_CN.UTF-8")
+	assert.Contains(t, env, "LANGUAGE=zh_CN")
+	assert.Contains(t, parseEnv(), "LC_ALL=C", "parsed output is never localized")
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// logRecordMarker starts each commit header in StructuredLogFormat output
+const logRecordMarker = "--gitbuddy-commit--"
+
+// StructuredLogFormat is the log format parsed by ParseStructuredLog. It only
+// uses placeholders every supported VCS can translate.
+const StructuredLogFormat = logRecordMarker + "\t%H\t%h\t%an\t%ae\t%ai\t%s"
+
+// LogFileStat is the change of one file in a commit
+type LogFileStat struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"`
+}
+
+// LogCommit is a commit parsed from structured log output
+type LogCommit struct {
+	Hash       string        `json:"hash"`
+	ShortHash  string        `json:"short_hash"`
+	Author     string        `json:"author"`
+	Email      string        `json:"email"`
+	Date       string        `json:"date"`
+	Subject    string        `json:"subject"`
+	Files      []LogFileStat `json:"files,omitempty"`
+	Insertions int           `json:"insertions"`
+	Deletions  int           `json:"deletions"`
+}
+
+// ParseStructuredLog parses log output produced with StructuredLogFormat,
+// optionally followed by --numstat lines for each commit
+func ParseStructuredLog(output string) []LogCommit {
+	var commits []LogCommit
+	var current *LogCommit
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, logRecordMarker+"\t") {
+			fields := strings.SplitN(strings.TrimPrefix(line, logRecordMarker+"\t"), "\t", 6)
+			for len(fields) < 6 {
+				fields = append(fields, "")
+			}
+			commits = append(commits, LogCommit{
+				Hash:      fields[0],
+				ShortHash: fields[1],
+				Author:    fields[2],
+				Email:     fields[3],
+				Date:      fields[4],
+				Subject:   fields[5],
+			})
+			current = &commits[len(commits)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		if stat, ok := parseNumstatLine(line); ok {
+			current.Files = append(current.Files, stat)
+			current.Insertions += stat.Insertions
+			current.Deletions += stat.Deletions
+		}
+	}
+	return commits
+}
+
+// parseNumstatLine parses "<insertions>\t<deletions>\t<path>"; binary files use "-" for both counts
+func parseNumstatLine(line string) (LogFileStat, bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return LogFileStat{}, false
+	}
+
+	stat := LogFileStat{Path: parts[2]}
+	if parts[0] == "-" && parts[1] == "-" {
+		stat.Binary = true
+		return stat, true
+	}
+
+	insertions, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return LogFileStat{}, false
+	}
+	deletions, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return LogFileStat{}, false
+	}
+	stat.Insertions = insertions
+	stat.Deletions = deletions
+	return stat, true
+}
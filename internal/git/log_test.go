@@ -0,0 +1,55 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStructuredLog(t *testing.T) {
+	output := logRecordMarker + "\tabc123full\tabc123\tAlice\talice@example.com\t2024-01-02 03:04:05 +0000\tfeat: add\ttabs\n" +
+		"\n" +
+		"10\t2\tmain.go\n" +
+		"-\t-\tlogo.png\n" +
+		"1\t1\tdocs/{old.md => new.md}\n" +
+		logRecordMarker + "\tdef456full\tdef456\tBob\tbob@example.com\t2024-01-01 00:00:00 +0000\tinitial\n"
+
+	commits := ParseStructuredLog(output)
+	require.Len(t, commits, 2)
+
+	first := commits[0]
+	assert.Equal(t, "abc123", first.ShortHash)
+	assert.Equal(t, "Alice", first.Author)
+	assert.Equal(t, "feat: add\ttabs", first.Subject, "the subject is the last field and may contain tabs")
+	require.Len(t, first.Files, 3)
+	assert.Equal(t, LogFileStat{Path: "logo.png", Binary: true}, first.Files[1])
+	assert.Equal(t, "docs/{old.md => new.md}", first.Files[2].Path)
+	assert.Equal(t, 11, first.Insertions)
+	assert.Equal(t, 3, first.Deletions)
+
+	assert.Empty(t, commits[1].Files)
+	assert.Empty(t, ParseStructuredLog(""))
+}
+
+func TestExecutor_LogNumStat(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	executor := NewExecutor(repoDir)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "a.txt", "one\ntwo\n")
+	commitFile(t, repoDir, "first")
+	createAndStageFile(t, repoDir, "a.txt", "one\n")
+	createAndStageFile(t, repoDir, "b.txt", "three\n")
+	commitFile(t, repoDir, "second")
+
+	output, err := executor.Log(ctx, LogOptions{Count: 5, Format: StructuredLogFormat, NumStat: true})
+	require.NoError(t, err)
+
+	commits := ParseStructuredLog(output)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "second", commits[0].Subject)
+	assert.Equal(t, []LogFileStat{{Path: "a.txt", Deletions: 1}, {Path: "b.txt", Insertions: 1}}, commits[0].Files)
+	assert.Equal(t, 2, commits[1].Insertions)
+}
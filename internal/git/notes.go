@@ -0,0 +1,84 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// NotesRef is the notes ref GitBuddy records its metadata under
+const NotesRef = "refs/notes/gitbuddy"
+
+// ErrNoNote is returned by ShowNote when a commit has no GitBuddy note
+var ErrNoNote = errors.New("no gitbuddy note found")
+
+// NoteEntry is one piece of AI metadata attached to a commit
+type NoteEntry struct {
+	Kind             string // commit, review or debug
+	CreatedAt        time.Time
+	Model            string // provider/model
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Summary          string
+	Reference        string // Report path, session ID, ...
+}
+
+// String formats the entry as a note block. Entries appended to the same
+// commit are separated by a blank line.
+func (e NoteEntry) String() string {
+	var sb strings.Builder
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	sb.WriteString(fmt.Sprintf("gitbuddy %s (%s)\n", e.Kind, createdAt.UTC().Format(time.RFC3339)))
+	if e.Model != "" {
+		sb.WriteString(fmt.Sprintf("Model: %s\n", e.Model))
+	}
+	if e.TotalTokens > 0 {
+		sb.WriteString(fmt.Sprintf("Tokens: %d (prompt %d, completion %d)\n", e.TotalTokens, e.PromptTokens, e.CompletionTokens))
+	}
+	if e.Reference != "" {
+		sb.WriteString(fmt.Sprintf("Reference: %s\n", e.Reference))
+	}
+	if summary := strings.TrimSpace(e.Summary); summary != "" {
+		sb.WriteString("\n" + summary + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// AddNote appends an entry to the GitBuddy note of a commit (HEAD when empty)
+func AddNote(ctx context.Context, workDir, commit string, entry NoteEntry) error {
+	if commit == "" {
+		commit = "HEAD"
+	}
+	if _, err := runCommand(ctx, workDir, "git", "notes", "--ref", NotesRef, "append", "-m", entry.String(), commit); err != nil {
+		return fmt.Errorf("failed to add git note: %w", err)
+	}
+	return nil
+}
+
+// ShowNote returns the GitBuddy note of a commit (HEAD when empty)
+func ShowNote(ctx context.Context, workDir, commit string) (string, error) {
+	if commit == "" {
+		commit = "HEAD"
+	}
+	// git notes list exits with 1 when the commit has no note, and with 128
+	// for other errors such as an unknown commit
+	if _, err := runCommand(ctx, workDir, "git", "notes", "--ref", NotesRef, "list", commit); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", fmt.Errorf("%w for %s", ErrNoNote, commit)
+		}
+		return "", fmt.Errorf("failed to read git note: %w", err)
+	}
+	note, err := runCommand(ctx, workDir, "git", "notes", "--ref", NotesRef, "show", commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read git note: %w", err)
+	}
+	return note, nil
+}
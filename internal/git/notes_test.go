@@ -0,0 +1,56 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoteEntry_String(t *testing.T) {
+	entry := NoteEntry{
+		Kind:             "review",
+		CreatedAt:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Model:            "openai/gpt-4o",
+		PromptTokens:     1200,
+		CompletionTokens: 300,
+		TotalTokens:      1500,
+		Summary:          "2 issues found (1 error, 1 warning)\n",
+	}
+
+	assert.Equal(t, "gitbuddy review (2024-01-02T03:04:05Z)\n"+
+		"Model: openai/gpt-4o\n"+
+		"Tokens: 1500 (prompt 1200, completion 300)\n"+
+		"\n"+
+		"2 issues found (1 error, 1 warning)", entry.String())
+}
+
+func TestNotes_AddAndShow(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	commitFile(t, repoDir, "Initial commit")
+
+	_, err := ShowNote(ctx, repoDir, "")
+	require.ErrorIs(t, err, ErrNoNote)
+	_, err = ShowNote(ctx, repoDir, "no-such-commit")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoNote)
+
+	require.NoError(t, AddNote(ctx, repoDir, "", NoteEntry{Kind: "commit", Summary: "Initial commit"}))
+	require.NoError(t, AddNote(ctx, repoDir, "HEAD", NoteEntry{Kind: "review", Reference: "review-123"}))
+
+	note, err := ShowNote(ctx, repoDir, "HEAD")
+	require.NoError(t, err)
+	assert.Contains(t, note, "gitbuddy commit")
+	assert.Contains(t, note, "Initial commit")
+	assert.Contains(t, note, "Reference: review-123", "entries are appended")
+
+	// Notes are kept out of the commit message
+	log, err := NewExecutor(repoDir).Log(ctx, LogOptions{Count: 1, Format: "%B"})
+	require.NoError(t, err)
+	assert.Equal(t, "Initial commit", log)
+}
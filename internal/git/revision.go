@@ -0,0 +1,111 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MergeBase returns the best common ancestor of a and b
+func MergeBase(ctx context.Context, workDir, a, b string) (string, error) {
+	out, err := runCommand(ctx, workDir, "git", "merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the merge base of %s and %s: %w", a, b, err)
+	}
+	return out, nil
+}
+
+// ChangedFiles lists the files changed between base and head, including
+// deleted files. An empty head compares base with the index (the staged
+// changes); otherwise head is compared with its merge base with base.
+func ChangedFiles(ctx context.Context, workDir, base, head string) ([]string, error) {
+	args := []string{"diff", "--name-only", "-z", "--no-renames"}
+	if head == "" {
+		args = append(args, "--cached", base)
+	} else {
+		args = append(args, base+"..."+head)
+	}
+	out, err := runGitRaw(ctx, workDir, nil, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// FileAt returns the content of path at rev, or in the index when rev is
+// empty. ok is false when the file does not exist there.
+func FileAt(ctx context.Context, workDir, rev, path string) (content []byte, ok bool, err error) {
+	object := rev + ":" + path
+	if _, err := runCommand(ctx, workDir, "git", "cat-file", "-e", object); err != nil {
+		return nil, false, nil
+	}
+	content, err = runGitRaw(ctx, workDir, nil, "cat-file", "blob", object)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", object, err)
+	}
+	return content, true, nil
+}
+
+// ListFiles lists the tracked files and the untracked files that aren't
+// ignored, relative to workDir
+func ListFiles(ctx context.Context, workDir string) ([]string, error) {
+	out, err := runGitRaw(ctx, workDir, nil, "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, file := range strings.Split(string(out), "\x00") {
+		// Files with unresolved conflicts are listed once per stage
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// DiffRange returns the diff of revisions, either a range such as main..HEAD
+// or main...HEAD, or a single commit, which is compared with its first
+// parent. paths limit the diff to these files or directories.
+func DiffRange(ctx context.Context, workDir, revisions string, paths ...string) (string, error) {
+	if revisions == "" || strings.HasPrefix(revisions, "-") {
+		return "", fmt.Errorf("invalid revisions: %q", revisions)
+	}
+	args := []string{"diff", revisions}
+	if !strings.Contains(revisions, "..") {
+		// --root shows the first commit as added files, --first-parent a merge as what it brought in
+		args = []string{"diff-tree", "-p", "--root", "-m", "--first-parent", "--no-commit-id", revisions}
+	}
+	out, err := runCommand(ctx, workDir, "git", appendPaths(args, paths)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", revisions, err)
+	}
+	return out, nil
+}
+
+// DiffWorktree returns the diff of the unstaged changes of tracked files,
+// limited to paths when given
+func DiffWorktree(ctx context.Context, workDir string, paths ...string) (string, error) {
+	out, err := runCommand(ctx, workDir, "git", appendPaths([]string{"diff"}, paths)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff the working tree: %w", err)
+	}
+	return out, nil
+}
+
+// appendPaths appends a pathspec of paths to the arguments of a git command
+func appendPaths(args, paths []string) []string {
+	if len(paths) == 0 {
+		return args
+	}
+	return append(append(args, "--"), paths...)
+}
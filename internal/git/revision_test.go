@@ -0,0 +1,107 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevision_ChangedFilesAndFileAt(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "api.go", "package api\n")
+	createAndStageFile(t, repoDir, "old.go", "package api\n")
+	commitFile(t, repoDir, "initial commit")
+	runGitCmd(t, repoDir, "branch", "-M", "main")
+
+	// Staged changes are compared with HEAD
+	createAndStageFile(t, repoDir, "api.go", "package api\n\nfunc New() {}\n")
+	runGitCmd(t, repoDir, "rm", "-q", "old.go")
+	files, err := ChangedFiles(ctx, repoDir, "HEAD", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.go", "old.go"}, files)
+
+	content, ok, err := FileAt(ctx, repoDir, "", "api.go")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "package api\n\nfunc New() {}\n", string(content))
+
+	content, ok, err = FileAt(ctx, repoDir, "HEAD", "api.go")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "package api\n", string(content))
+
+	_, ok, err = FileAt(ctx, repoDir, "", "old.go")
+	require.NoError(t, err)
+	assert.False(t, ok, "deleted from the index")
+
+	// Branches are compared with their merge base
+	runGitCmd(t, repoDir, "checkout", "-q", "-b", "feature")
+	commitFile(t, repoDir, "feature commit")
+	runGitCmd(t, repoDir, "checkout", "-q", "main")
+	createAndStageFile(t, repoDir, "main.go", "package api\n")
+	commitFile(t, repoDir, "main commit")
+
+	files, err = ChangedFiles(ctx, repoDir, "main", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.go", "old.go"}, files, "changes on main are not part of the branch")
+
+	base, err := MergeBase(ctx, repoDir, "main", "feature")
+	require.NoError(t, err)
+	assert.Len(t, base, 40)
+}
+
+func TestRevision_ListFiles(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, ".gitignore", "*.log\n")
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	commitFile(t, repoDir, "initial commit")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "new.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "debug.log"), []byte("ignored\n"), 0644))
+
+	files, err := ListFiles(ctx, repoDir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".gitignore", "main.go", "new.go"}, files)
+}
+
+func TestRevision_DiffRangeAndWorktree(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "a.go", "package a\n")
+	commitFile(t, repoDir, "first commit")
+	createAndStageFile(t, repoDir, "b.go", "package b\n")
+	commitFile(t, repoDir, "second commit")
+
+	diff, err := DiffRange(ctx, repoDir, "HEAD~1")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+package a", "the first commit shows its files as added")
+	assert.NotContains(t, diff, "b.go")
+
+	diff, err = DiffRange(ctx, repoDir, "HEAD~1..HEAD")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+package b")
+	assert.NotContains(t, diff, "a.go")
+
+	diff, err = DiffRange(ctx, repoDir, "HEAD~1..HEAD", "a.go")
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	_, err = DiffRange(ctx, repoDir, "--output=x")
+	assert.Error(t, err)
+
+	// Staged changes are not part of the working tree diff
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0644))
+	createAndStageFile(t, repoDir, "b.go", "package b\n\nfunc B() {}\n")
+	diff, err = DiffWorktree(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+func A() {}")
+	assert.NotContains(t, diff, "func B")
+}
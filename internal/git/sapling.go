@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// slLogPlaceholders maps git --format placeholders to Sapling template keywords
+var slLogPlaceholders = map[string]string{
+	"%H":  "{node}",
+	"%h":  "{node|short}",
+	"%s":  "{desc|firstline}",
+	"%an": "{author|person}",
+	"%ae": "{author|email}",
+	"%ad": "{date|isodate}",
+	"%ai": "{date|isodate}",
+}
+
+// SaplingExecutor implements Executor for Sapling (sl) repositories.
+// Sapling has no staging area, so uncommitted changes play the role of the
+// staged changes and "HEAD" maps to the working copy parent (.).
+type SaplingExecutor struct {
+	workDir string
+}
+
+// NewSaplingExecutor creates a new SaplingExecutor
+func NewSaplingExecutor(workDir string) *SaplingExecutor {
+	return &SaplingExecutor{workDir: workDir}
+}
+
+// runSL runs a Sapling command and returns the output
+func (e *SaplingExecutor) runSL(ctx context.Context, args ...string) (string, error) {
+	return runCommand(ctx, e.workDir, "sl", append([]string{"--color=never", "--pager=never"}, args...)...)
+}
+
+// DiffCached returns the diff of uncommitted changes
+func (e *SaplingExecutor) DiffCached(ctx context.Context) (string, error) {
+	return e.runSL(ctx, "diff", "--git")
+}
+
+// DiffBranches returns the diff between the common ancestor of two revisions
+// (or base itself with TwoDot) and head
+func (e *SaplingExecutor) DiffBranches(ctx context.Context, base, head string, opts DiffOptions) (string, error) {
+	from := fmt.Sprintf("ancestor(%s, %s)", base, head)
+	if opts.TwoDot {
+		from = base
+	}
+	args := []string{"diff", "--git"}
+	if opts.Stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, "-r", from, "-r", head)
+	return e.runSL(ctx, append(args, opts.Paths...)...)
+}
+
+// Status returns the working copy status
+func (e *SaplingExecutor) Status(ctx context.Context) (string, error) {
+	return e.runSL(ctx, "status")
+}
+
+// Log returns the history of the working copy parent's ancestors
+func (e *SaplingExecutor) Log(ctx context.Context, opts LogOptions) (string, error) {
+	args := []string{"log", "-r", "reverse(::.)"}
+	if opts.Count > 0 {
+		args = append(args, "-l", strconv.Itoa(opts.Count))
+	}
+	if opts.Author != "" {
+		args = append(args, "-u", opts.Author)
+	}
+	switch {
+	case opts.Since != "" && opts.Until != "":
+		args = append(args, "-d", fmt.Sprintf("%s to %s", opts.Since, opts.Until))
+	case opts.Since != "":
+		args = append(args, "-d", ">"+opts.Since)
+	case opts.Until != "":
+		args = append(args, "-d", "<"+opts.Until)
+	}
+	if opts.Format != "" {
+		args = append(args, "-T", translateLogFormat(opts.Format, slLogPlaceholders, escapeSLTemplate, ""))
+	}
+	return e.runSL(ctx, args...)
+}
+
+// LogRange returns the commits in head that are not in base (base..head)
+func (e *SaplingExecutor) LogRange(ctx context.Context, base, head string) (string, error) {
+	return e.runSL(ctx, "log", "-r", fmt.Sprintf("only(%s, %s)", head, base), "-T", "{node|short} {desc|firstline}\\n")
+}
+
+// Show returns detailed information about a commit
+func (e *SaplingExecutor) Show(ctx context.Context, ref string) (string, error) {
+	if ref == "" || ref == "HEAD" {
+		ref = "."
+	}
+	return e.runSL(ctx, "show", "--stat", ref)
+}
+
+// ListBranches returns all bookmarks
+func (e *SaplingExecutor) ListBranches(ctx context.Context) (string, error) {
+	return e.runSL(ctx, "bookmarks")
+}
+
+// Commit commits the pending changes
+func (e *SaplingExecutor) Commit(ctx context.Context, message string) error {
+	_, err := e.runSL(ctx, "commit", "-m", message)
+	return err
+}
+
+// CurrentBranch returns the active bookmark, or the short hash of the working
+// copy parent when no bookmark is active
+func (e *SaplingExecutor) CurrentBranch(ctx context.Context) (string, error) {
+	return e.runSL(ctx, "log", "-r", ".", "-T", "{ifeq(activebookmark, '', node|short, activebookmark)}")
+}
+
+// CurrentUser returns the configured Sapling user name
+func (e *SaplingExecutor) CurrentUser(ctx context.Context) (string, error) {
+	return e.runSL(ctx, "config", "ui.username")
+}
+
+// escapeSLTemplate escapes literal text for a Sapling template
+func escapeSLTemplate(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "{", `\{`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShallowCutoff returns the newest commit date at which the history of rev is
+// cut off by a shallow clone after since (any date git log --since accepts).
+// Commits before the cutoff may be missing. The zero time is returned when the
+// repository is not shallow or the history of rev reaches back to since.
+func ShallowCutoff(ctx context.Context, workDir, rev, since string) (time.Time, error) {
+	path, err := runCommand(ctx, workDir, "git", "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to locate the shallow file: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the shallow file: %w", err)
+	}
+	boundaries := make(map[string]bool)
+	for _, hash := range strings.Fields(string(data)) {
+		boundaries[hash] = true
+	}
+	if len(boundaries) == 0 {
+		return time.Time{}, nil
+	}
+
+	args := []string{"log", "--format=%H %ct"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	out, err := runCommand(ctx, workDir, "git", append(args, rev)...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the history of %s: %w", rev, err)
+	}
+
+	var cutoff time.Time
+	for _, line := range strings.Split(out, "\n") {
+		hash, timestamp, ok := strings.Cut(line, " ")
+		if !ok || !boundaries[hash] {
+			continue
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unexpected git log output: %q", line)
+		}
+		if t := time.Unix(seconds, 0); t.After(cutoff) {
+			cutoff = t
+		}
+	}
+	return cutoff, nil
+}
+
+// FetchSince deepens a shallow clone to include all commits after since
+func FetchSince(ctx context.Context, workDir, since string) error {
+	if _, err := runCommand(ctx, workDir, "git", "fetch", "--quiet", "--shallow-since="+since); err != nil {
+		return fmt.Errorf("failed to fetch history since %s: %w", since, err)
+	}
+	return nil
+}
+
+// Unshallow fetches the complete history of a shallow clone
+func Unshallow(ctx context.Context, workDir string) error {
+	if _, err := runCommand(ctx, workDir, "git", "fetch", "--quiet", "--unshallow"); err != nil {
+		return fmt.Errorf("failed to fetch the full history: %w", err)
+	}
+	return nil
+}
+
+// HasMergeBase reports whether a and b share a commit in the local history
+func HasMergeBase(ctx context.Context, workDir, a, b string) bool {
+	_, err := runCommand(ctx, workDir, "git", "merge-base", a, b)
+	return err == nil
+}
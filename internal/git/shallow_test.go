@@ -0,0 +1,38 @@
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShallowCutoff(t *testing.T) {
+	ctx := context.Background()
+	repoDir := setupTestRepo(t)
+	createAndStageFile(t, repoDir, "a.go", "package a\n")
+	commitFile(t, repoDir, "First")
+	createAndStageFile(t, repoDir, "b.go", "package a\n")
+	commitFile(t, repoDir, "Second")
+
+	cutoff, err := ShallowCutoff(ctx, repoDir, "HEAD", "")
+	require.NoError(t, err)
+	assert.True(t, cutoff.IsZero(), "complete history")
+	assert.True(t, HasMergeBase(ctx, repoDir, "HEAD~1", "HEAD"))
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	runGitCmd(t, "", "clone", "--quiet", "--depth", "1", "file://"+repoDir, cloneDir)
+	cutoff, err = ShallowCutoff(ctx, cloneDir, "HEAD", "")
+	require.NoError(t, err)
+	assert.False(t, cutoff.IsZero())
+	cutoff, err = ShallowCutoff(ctx, cloneDir, "HEAD", "1 hour ago")
+	require.NoError(t, err)
+	assert.False(t, cutoff.IsZero())
+
+	require.NoError(t, Unshallow(ctx, cloneDir))
+	cutoff, err = ShallowCutoff(ctx, cloneDir, "HEAD", "")
+	require.NoError(t, err)
+	assert.True(t, cutoff.IsZero())
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IndexState returns a fingerprint of what `git diff --cached` compares: the
+// HEAD commit and the index file. It changes with every commit, checkout and
+// `git add`.
+func IndexState(ctx context.Context, workDir string) (string, error) {
+	indexPath, err := runCommand(ctx, workDir, "git", "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the index: %w", err)
+	}
+	if !filepath.IsAbs(indexPath) {
+		indexPath = filepath.Join(workDir, indexPath)
+	}
+	// An unborn branch has no HEAD commit yet
+	head, _ := runCommand(ctx, workDir, "git", "rev-parse", "-q", "--verify", "HEAD")
+
+	info, err := os.Stat(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return head + " no-index", nil
+		}
+		return "", fmt.Errorf("failed to read the index: %w", err)
+	}
+	return fmt.Sprintf("%s %d %d", head, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// WorktreeState returns a fingerprint of the checkout: the HEAD commit, the
+// status of the changed and untracked files and their sizes and modification
+// times, so that editing an already modified file changes it too
+func WorktreeState(ctx context.Context, workDir string) (string, error) {
+	root, err := runCommand(ctx, workDir, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the repository root: %w", err)
+	}
+	head, _ := runCommand(ctx, workDir, "git", "rev-parse", "-q", "--verify", "HEAD")
+	// Without optional locks, status doesn't refresh the index, which would
+	// change IndexState and contend with the user's git commands
+	out, err := runGitRaw(ctx, workDir, nil, "--no-optional-locks", "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the status: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", head)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		fmt.Fprintf(h, "%s\x00", entry)
+		// "XY path"; the source path of a rename follows as its own entry
+		if len(entry) < 4 || entry[2] != ' ' {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(entry[3:]))); err == nil {
+			fmt.Fprintf(h, "%d %d\x00", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,69 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexState(t *testing.T) {
+	ctx := context.Background()
+	repo := setupTestRepo(t)
+
+	empty, err := IndexState(ctx, repo)
+	require.NoError(t, err)
+
+	createAndStageFile(t, repo, "a.txt", "a")
+	staged, err := IndexState(ctx, repo)
+	require.NoError(t, err)
+	assert.NotEqual(t, empty, staged)
+
+	// Unstaged edits don't touch the index
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.txt"), []byte("edited"), 0644))
+	again, err := IndexState(ctx, repo)
+	require.NoError(t, err)
+	assert.Equal(t, staged, again)
+
+	_, err = IndexState(ctx, t.TempDir())
+	assert.Error(t, err, "not a repository")
+}
+
+func TestWorktreeState(t *testing.T) {
+	ctx := context.Background()
+	repo := setupTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "sub"), 0755))
+
+	initial, err := WorktreeState(ctx, repo)
+	require.NoError(t, err)
+
+	path := filepath.Join(repo, "sub", "a.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a"), 0644))
+	untracked, err := WorktreeState(ctx, repo)
+	require.NoError(t, err)
+	assert.NotEqual(t, initial, untracked)
+
+	// Editing a file that is already untracked changes the state too
+	require.NoError(t, os.WriteFile(path, []byte("longer"), 0644))
+	edited, err := WorktreeState(ctx, filepath.Join(repo, "sub"))
+	require.NoError(t, err)
+	assert.NotEqual(t, untracked, edited)
+
+	cmd := exec.Command("git", "add", ".")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+	cmd = exec.Command("git", "commit", "-m", "add a")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+	committed, err := WorktreeState(ctx, repo)
+	require.NoError(t, err)
+	assert.NotEqual(t, edited, committed)
+
+	same, err := WorktreeState(ctx, repo)
+	require.NoError(t, err)
+	assert.Equal(t, committed, same)
+}
@@ -0,0 +1,159 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrMergeTreeUnsupported is returned by MergeTree when git is older than 2.38
+var ErrMergeTreeUnsupported = errors.New("git merge-tree --write-tree requires git 2.38 or newer")
+
+// MergeTreeResult is the outcome of a merge simulated with git merge-tree
+type MergeTreeResult struct {
+	Clean     bool
+	Tree      string   // Tree object of the merge result (with conflict markers when not clean)
+	Conflicts []string // Files that would conflict
+}
+
+// Upstream returns the upstream of the current branch (e.g. "origin/feature"),
+// or an empty string when none is configured
+func Upstream(ctx context.Context, workDir string) (string, error) {
+	out, err := runCommand(ctx, workDir, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "no upstream") || strings.Contains(msg, "HEAD does not point to a branch") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to resolve upstream: %w", err)
+	}
+	return out, nil
+}
+
+// DefaultBranch returns the repository's default branch, preferring the
+// remote's (origin/HEAD) over local main/master
+func DefaultBranch(ctx context.Context, workDir string) (string, error) {
+	if out, err := runCommand(ctx, workDir, "git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil && out != "" {
+		return out, nil
+	}
+	for _, candidate := range []string{"origin/main", "origin/master", "main", "master"} {
+		if _, err := runCommand(ctx, workDir, "git", "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("failed to determine the default branch (no origin/HEAD, main or master)")
+}
+
+// RevListCount returns how many commits head has that base doesn't (ahead)
+// and how many base has that head doesn't (behind)
+func RevListCount(ctx context.Context, workDir, base, head string) (ahead, behind int, err error) {
+	out, err := runCommand(ctx, workDir, "git", "rev-list", "--left-right", "--count", base+"..."+head)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count commits: %w", err)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	if behind, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	if ahead, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	return ahead, behind, nil
+}
+
+// MergeTree simulates merging head into base without touching the index or
+// working tree and reports the files that would conflict
+func MergeTree(ctx context.Context, workDir, base, head string) (*MergeTreeResult, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-tree", "--write-tree", "--name-only", "--no-messages", base, head)
+	cmd.Dir = workDir
+	cmd.Env = parseEnv()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// Exit code 1 means the merge has conflicts
+	case strings.Contains(stderr.String(), "--write-tree") || strings.Contains(stderr.String(), "usage: git merge-tree"):
+		return nil, ErrMergeTreeUnsupported
+	default:
+		return nil, fmt.Errorf("git merge-tree failed: %w\n%s", err, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	result := &MergeTreeResult{Clean: err == nil, Tree: lines[0]}
+	seen := make(map[string]bool)
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" && !seen[line] {
+			seen[line] = true
+			result.Conflicts = append(result.Conflicts, line)
+		}
+	}
+	return result, nil
+}
+
+// Fetch updates the remote-tracking branches of all remotes
+func Fetch(ctx context.Context, workDir string) error {
+	if _, err := runCommand(ctx, workDir, "git", "fetch", "--quiet", "--all"); err != nil {
+		return fmt.Errorf("failed to fetch: %w", err)
+	}
+	return nil
+}
+
+// Remotes lists the configured remotes
+func Remotes(ctx context.Context, workDir string) ([]string, error) {
+	out, err := runCommand(ctx, workDir, "git", "remote")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	return strings.Fields(out), nil
+}
+
+// Push pushes branch to remote and, with setUpstream, makes it the upstream
+// of the branch. An empty remote pushes the current branch to its upstream.
+// It returns the output of git, including the messages of the remote, such
+// as the URL to open a pull request.
+func Push(ctx context.Context, workDir, remote, branch string, setUpstream bool) (string, error) {
+	args := []string{"push"}
+	if setUpstream {
+		args = append(args, "--set-upstream")
+	}
+	if remote != "" {
+		args = append(args, remote, branch)
+	}
+	if err := checkSideEffect("git", args); err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = workDir
+	cmd.Env = parseEnv()
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return output.String(), fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(output.String()))
+	}
+	return output.String(), nil
+}
+
+// CreateBranch creates branch at HEAD and switches to it, keeping the staged
+// and unstaged changes
+func CreateBranch(ctx context.Context, workDir, branch string) error {
+	if _, err := runCommand(ctx, workDir, "git", "checkout", "-b", branch); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGitCmd(t *testing.T, repoDir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestSync_DivergenceAndMergeTree(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "shared.txt", "base\n")
+	commitFile(t, repoDir, "initial commit")
+	runGitCmd(t, repoDir, "branch", "-M", "main")
+
+	defaultBranch, err := DefaultBranch(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, "main", defaultBranch)
+
+	upstream, err := Upstream(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Empty(t, upstream, "no upstream configured")
+
+	runGitCmd(t, repoDir, "checkout", "-b", "feature")
+	createAndStageFile(t, repoDir, "shared.txt", "feature\n")
+	commitFile(t, repoDir, "feature edit")
+	createAndStageFile(t, repoDir, "feature.txt", "feature\n")
+	commitFile(t, repoDir, "feature file")
+
+	runGitCmd(t, repoDir, "checkout", "main")
+	createAndStageFile(t, repoDir, "other.txt", "other\n")
+	commitFile(t, repoDir, "unrelated main change")
+
+	ahead, behind, err := RevListCount(ctx, repoDir, "main", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 1, behind)
+
+	result, err := MergeTree(ctx, repoDir, "main", "feature")
+	require.NoError(t, err)
+	assert.True(t, result.Clean)
+	assert.Empty(t, result.Conflicts)
+	assert.NotEmpty(t, result.Tree)
+
+	createAndStageFile(t, repoDir, "shared.txt", "main\n")
+	commitFile(t, repoDir, "conflicting main change")
+
+	result, err = MergeTree(ctx, repoDir, "main", "feature")
+	require.NoError(t, err)
+	assert.False(t, result.Clean)
+	assert.Equal(t, []string{"shared.txt"}, result.Conflicts)
+}
+
+func TestPush_SetsUpstream(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+	remoteDir := t.TempDir()
+	runGitCmd(t, remoteDir, "init", "--bare")
+	runGitCmd(t, repoDir, "remote", "add", "origin", remoteDir)
+
+	remotes, err := Remotes(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"origin"}, remotes)
+
+	createAndStageFile(t, repoDir, "a.txt", "a\n")
+	commitFile(t, repoDir, "initial commit")
+	runGitCmd(t, repoDir, "checkout", "-b", "feature")
+
+	_, err = Push(ctx, repoDir, "origin", "feature", true)
+	require.NoError(t, err)
+	upstream, err := Upstream(ctx, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, "origin/feature", upstream)
+
+	// Later pushes go to the upstream
+	createAndStageFile(t, repoDir, "b.txt", "b\n")
+	commitFile(t, repoDir, "second commit")
+	_, err = Push(ctx, repoDir, "", "", false)
+	require.NoError(t, err)
+	ahead, _, err := RevListCount(ctx, repoDir, "origin/feature", "feature")
+	require.NoError(t, err)
+	assert.Zero(t, ahead)
+
+	_, err = Push(ctx, repoDir, "missing", "feature", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported version control systems
+const (
+	VCSAuto    = "auto"
+	VCSGit     = "git"
+	VCSJujutsu = "jj"
+	VCSSapling = "sapling"
+)
+
+// NewVCSExecutor creates an Executor for the given version control system.
+// With VCSAuto (or an empty name) the system is detected from workDir.
+func NewVCSExecutor(workDir, vcs string) (Executor, error) {
+	switch strings.ToLower(vcs) {
+	case "", VCSAuto:
+		return NewVCSExecutor(workDir, DetectVCS(workDir))
+	case VCSGit:
+		return NewExecutor(workDir), nil
+	case VCSJujutsu, "jujutsu":
+		return NewJujutsuExecutor(workDir), nil
+	case VCSSapling, "sl":
+		return NewSaplingExecutor(workDir), nil
+	default:
+		return nil, fmt.Errorf("unsupported VCS: %s (supported: auto, git, jj, sapling)", vcs)
+	}
+}
+
+// DetectVCS walks up from workDir and returns the VCS managing it.
+// Jujutsu and Sapling take precedence over git because both can be
+// colocated with a .git directory. Defaults to git.
+func DetectVCS(workDir string) string {
+	dir, err := filepath.Abs(workDir)
+	if err != nil {
+		return VCSGit
+	}
+
+	for {
+		if isDir(filepath.Join(dir, ".jj")) {
+			return VCSJujutsu
+		}
+		if isDir(filepath.Join(dir, ".sl")) || isDir(filepath.Join(dir, ".git", "sl")) {
+			return VCSSapling
+		}
+		if exists(filepath.Join(dir, ".git")) {
+			return VCSGit
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return VCSGit
+		}
+		dir = parent
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// translateLogFormat converts a git --format string to another VCS's template
+// syntax using the given placeholder mapping. Literal text is passed to
+// literal, which quotes it for the target template language.
+func translateLogFormat(format string, placeholders map[string]string, literal func(string) string, join string) string {
+	// Longest placeholders first so %an is not matched as %a
+	keys := []string{"%H", "%h", "%an", "%ae", "%ad", "%ai", "%s"}
+
+	var parts []string
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, literal(text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); {
+		matched := false
+		for _, key := range keys {
+			if strings.HasPrefix(format[i:], key) {
+				if repl, ok := placeholders[key]; ok {
+					flush()
+					parts = append(parts, repl)
+					i += len(key)
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			text.WriteByte(format[i])
+			i++
+		}
+	}
+	text.WriteString("\n")
+	flush()
+	return strings.Join(parts, join)
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectVCS(t *testing.T) {
+	tests := []struct {
+		name string
+		dirs []string
+		want string
+	}{
+		{name: "git", dirs: []string{".git"}, want: VCSGit},
+		{name: "colocated jj", dirs: []string{".git", ".jj"}, want: VCSJujutsu},
+		{name: "sapling", dirs: []string{".sl"}, want: VCSSapling},
+		{name: "sapling on git clone", dirs: []string{".git/sl"}, want: VCSSapling},
+		{name: "no repository", want: VCSGit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range tt.dirs {
+				require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+			}
+
+			// Detection walks up from subdirectories
+			sub := filepath.Join(root, "pkg", "sub")
+			require.NoError(t, os.MkdirAll(sub, 0755))
+			assert.Equal(t, tt.want, DetectVCS(sub))
+		})
+	}
+}
+
+func TestNewVCSExecutor(t *testing.T) {
+	dir := t.TempDir()
+
+	executor, err := NewVCSExecutor(dir, VCSGit)
+	require.NoError(t, err)
+	assert.IsType(t, &DefaultExecutor{}, executor)
+
+	executor, err = NewVCSExecutor(dir, "jujutsu")
+	require.NoError(t, err)
+	assert.IsType(t, &JujutsuExecutor{}, executor)
+
+	executor, err = NewVCSExecutor(dir, "sl")
+	require.NoError(t, err)
+	assert.IsType(t, &SaplingExecutor{}, executor)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".jj"), 0755))
+	executor, err = NewVCSExecutor(dir, VCSAuto)
+	require.NoError(t, err)
+	assert.IsType(t, &JujutsuExecutor{}, executor)
+
+	_, err = NewVCSExecutor(dir, "svn")
+	assert.ErrorContains(t, err, "unsupported VCS")
+}
+
+func TestTranslateLogFormat(t *testing.T) {
+	t.Run("jj", func(t *testing.T) {
+		got := translateLogFormat("%h|%s|%an", jjLogPlaceholders, strconv.Quote, " ++ ")
+		assert.Equal(t, `commit_id.short() ++ "|" ++ description.first_line() ++ "|" ++ author.name() ++ "\n"`, got)
+	})
+
+	t.Run("sapling", func(t *testing.T) {
+		got := translateLogFormat("%h {x} %s", slLogPlaceholders, escapeSLTemplate, "")
+		assert.Equal(t, `{node|short} \{x} {desc|firstline}\n`, got)
+	})
+
+	t.Run("unknown placeholders are kept literally", func(t *testing.T) {
+		got := translateLogFormat("%x %h", slLogPlaceholders, escapeSLTemplate, "")
+		assert.Equal(t, `%x {node|short}\n`, got)
+	})
+}
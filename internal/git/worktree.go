@@ -0,0 +1,129 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// IsolatedWorktree is a detached worktree in a temporary directory holding a
+// copy of the staged state, so agents can work without touching the user's
+// working tree. Changes made in it are collected with Patch.
+type IsolatedWorktree struct {
+	repoRoot string
+	root     string // Root of the temporary worktree
+	prefix   string // Path of the original working directory relative to the repository root
+	baseTree string // Tree of the staged state the worktree started from
+}
+
+// NewIsolatedWorktree checks out HEAD into a temporary worktree and applies the
+// staged changes of the repository containing workDir. Unstaged and untracked
+// files are not copied.
+func NewIsolatedWorktree(ctx context.Context, workDir string) (*IsolatedWorktree, error) {
+	repoRoot, err := runCommand(ctx, workDir, "git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	prefix, err := runCommand(ctx, workDir, "git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository prefix: %w", err)
+	}
+	if _, err := runCommand(ctx, repoRoot, "git", "rev-parse", "--verify", "HEAD"); err != nil {
+		return nil, fmt.Errorf("isolated mode requires at least one commit: %w", err)
+	}
+
+	staged, err := runGitRaw(ctx, repoRoot, nil, "diff", "--cached", "--binary")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged changes: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "gitbuddy-isolated-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	w := &IsolatedWorktree{repoRoot: repoRoot, root: dir, prefix: prefix}
+	if _, err := runCommand(ctx, repoRoot, "git", "worktree", "add", "--detach", dir, "HEAD"); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	if len(staged) > 0 {
+		if _, err := runGitRaw(ctx, dir, bytes.NewReader(staged), "apply", "--index", "--binary"); err != nil {
+			_ = w.Remove(ctx)
+			return nil, fmt.Errorf("failed to apply staged changes: %w", err)
+		}
+	}
+	if w.baseTree, err = runCommand(ctx, dir, "git", "write-tree"); err != nil {
+		_ = w.Remove(ctx)
+		return nil, fmt.Errorf("failed to record staged state: %w", err)
+	}
+	return w, nil
+}
+
+// Dir returns the directory in the worktree matching the original working directory
+func (w *IsolatedWorktree) Dir() string {
+	return filepath.Join(w.root, filepath.FromSlash(w.prefix))
+}
+
+// RepoRoot returns the root of the original repository
+func (w *IsolatedWorktree) RepoRoot() string {
+	return w.repoRoot
+}
+
+// Patch returns the changes made in the worktree since it was created as a
+// binary-safe patch relative to the repository root. It is empty when nothing
+// changed. Directories named in exclude are left out wherever they are.
+func (w *IsolatedWorktree) Patch(ctx context.Context, exclude ...string) (string, error) {
+	args := []string{"add", "--all", "--", "."}
+	for _, dir := range exclude {
+		args = append(args, ":(exclude,glob)**/"+dir+"/**")
+	}
+	if _, err := runCommand(ctx, w.root, "git", args...); err != nil {
+		return "", fmt.Errorf("failed to stage worktree changes: %w", err)
+	}
+	patch, err := runGitRaw(ctx, w.root, nil, "diff", "--cached", "--binary", w.baseTree)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff worktree: %w", err)
+	}
+	return string(patch), nil
+}
+
+// Remove deletes the worktree and its temporary directory
+func (w *IsolatedWorktree) Remove(ctx context.Context) error {
+	_, err := runCommand(ctx, w.repoRoot, "git", "worktree", "remove", "--force", w.root)
+	if rmErr := os.RemoveAll(w.root); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	if err != nil {
+		// Drop the registration even when the directory was already gone
+		_, _ = runCommand(ctx, w.repoRoot, "git", "worktree", "prune")
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	return nil
+}
+
+// runGitRaw runs git in dir with optional stdin and returns the untrimmed
+// output, which patches need to stay applicable
+func runGitRaw(ctx context.Context, dir string, stdin io.Reader, args ...string) ([]byte, error) {
+	if err := checkSideEffect("git", args); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	cmd.Env = parseEnv()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
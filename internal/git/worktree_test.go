@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsolatedWorktree(t *testing.T) {
+	repoDir := setupTestRepo(t)
+	ctx := context.Background()
+
+	createAndStageFile(t, repoDir, "main.go", "package main\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "pkg"), 0755))
+	createAndStageFile(t, repoDir, "pkg/util.go", "package pkg\n")
+	commitFile(t, repoDir, "Initial commit")
+
+	// Staged changes are copied, unstaged and untracked ones are not
+	createAndStageFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "pkg/util.go"), []byte("package pkg // unstaged\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "notes.txt"), []byte("untracked\n"), 0644))
+
+	w, err := NewIsolatedWorktree(ctx, filepath.Join(repoDir, "pkg"))
+	require.NoError(t, err)
+	defer w.Remove(ctx)
+
+	assert.Equal(t, "pkg", filepath.Base(w.Dir()), "the working directory maps into the worktree")
+	content, err := os.ReadFile(filepath.Join(w.Dir(), "..", "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+	content, err = os.ReadFile(filepath.Join(w.Dir(), "util.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package pkg\n", string(content))
+	assert.NoFileExists(t, filepath.Join(w.Dir(), "..", "notes.txt"))
+
+	patch, err := w.Patch(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, patch, "the staged state is the baseline")
+
+	// Changes in the worktree leave the repository untouched and end up in the patch
+	require.NoError(t, os.WriteFile(filepath.Join(w.Dir(), "util.go"), []byte("package pkg\n\nconst Name = \"x\"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(w.Dir(), "new.go"), []byte("package pkg\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(w.Dir(), ".backups"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(w.Dir(), ".backups", "util.go"), []byte("package pkg\n"), 0644))
+
+	patch, err = w.Patch(ctx, ".backups")
+	require.NoError(t, err)
+	assert.Contains(t, patch, "+++ b/pkg/util.go")
+	assert.Contains(t, patch, "+++ b/pkg/new.go")
+	assert.NotContains(t, patch, "main.go")
+	assert.NotContains(t, patch, ".backups", "excluded directories are left out")
+
+	content, err = os.ReadFile(filepath.Join(repoDir, "pkg/util.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package pkg // unstaged\n", string(content))
+
+	// The patch applies on top of the staged state
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "pkg/util.go"), []byte("package pkg\n"), 0644))
+	patchFile := filepath.Join(t.TempDir(), "isolated.patch")
+	require.NoError(t, os.WriteFile(patchFile, []byte(patch), 0644))
+	cmd := exec.Command("git", "apply", patchFile)
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.FileExists(t, filepath.Join(repoDir, "pkg/new.go"))
+
+	root := filepath.Dir(w.Dir())
+	require.NoError(t, w.Remove(ctx))
+	assert.NoDirExists(t, root)
+	worktrees, err := runCommand(ctx, repoDir, "git", "worktree", "list")
+	require.NoError(t, err)
+	assert.NotContains(t, worktrees, root)
+}
+
+func TestIsolatedWorktree_RequiresCommit(t *testing.T) {
+	repoDir := setupTestRepo(t)
+
+	_, err := NewIsolatedWorktree(context.Background(), repoDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one commit")
+}
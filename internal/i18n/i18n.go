@@ -0,0 +1,57 @@
+// Package i18n maps the free-form output languages accepted by --language
+// (en, zh-CN, chinese, ...) to language codes and the POSIX locales external
+// tools such as git use for their messages.
+package i18n
+
+import "strings"
+
+// languageNames maps language names to codes
+var languageNames = map[string]string{
+	"english":    "en",
+	"chinese":    "zh",
+	"japanese":   "ja",
+	"korean":     "ko",
+	"german":     "de",
+	"french":     "fr",
+	"spanish":    "es",
+	"portuguese": "pt",
+	"russian":    "ru",
+	"italian":    "it",
+}
+
+// locales are the POSIX locales for each language code
+var locales = map[string]string{
+	"en": "C",
+	"zh": "zh_CN.UTF-8",
+	"ja": "ja_JP.UTF-8",
+	"ko": "ko_KR.UTF-8",
+	"de": "de_DE.UTF-8",
+	"fr": "fr_FR.UTF-8",
+	"es": "es_ES.UTF-8",
+	"pt": "pt_BR.UTF-8",
+	"ru": "ru_RU.UTF-8",
+	"it": "it_IT.UTF-8",
+}
+
+// Normalize returns the lowercase language code of language, e.g. "zh" for
+// "zh-CN" or "Chinese"
+func Normalize(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageNames[language]; ok {
+		return code
+	}
+	if i := strings.IndexAny(language, "-_"); i > 0 {
+		return language[:i]
+	}
+	return language
+}
+
+// Locale returns the POSIX locale for language, or "" when it has none.
+// Traditional Chinese variants get zh_TW.
+func Locale(language string) string {
+	switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(language)), "_", "-") {
+	case "zh-tw", "zh-hk", "zh-hant":
+		return "zh_TW.UTF-8"
+	}
+	return locales[Normalize(language)]
+}
@@ -0,0 +1,23 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "zh", Normalize("zh-CN"))
+	assert.Equal(t, "zh", Normalize(" Chinese "))
+	assert.Equal(t, "en", Normalize("en_US"))
+	assert.Equal(t, "ja", Normalize("ja"))
+	assert.Equal(t, "", Normalize(""))
+}
+
+func TestLocale(t *testing.T) {
+	assert.Equal(t, "zh_CN.UTF-8", Locale("zh"))
+	assert.Equal(t, "zh_TW.UTF-8", Locale("zh_TW"))
+	assert.Equal(t, "C", Locale("english"))
+	assert.Equal(t, "", Locale("tlh"))
+	assert.Equal(t, "", Locale(""))
+}
@@ -0,0 +1,122 @@
+package license
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxLicenseSize caps how much of a license file is read
+const maxLicenseSize = 64 * 1024
+
+// licenseFiles are the usual names of license files, in order of preference
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "COPYING", "COPYING.md", "license", "license.md"}
+
+// GoModule returns the license of a Go module from the vendor directory of
+// workDir or the module cache, or an empty string when it isn't available
+// locally or not recognized
+func GoModule(workDir, module, version string) string {
+	dirs := []string{filepath.Join(workDir, "vendor", filepath.FromSlash(module))}
+	if cache := goModCache(); cache != "" {
+		dirs = append(dirs, filepath.Join(cache, filepath.FromSlash(escapeModulePath(module))+"@"+version))
+	}
+	for _, dir := range dirs {
+		if id := licenseInDir(dir); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// NPMPackage returns the license of an installed npm package from the
+// node_modules directory next to manifestDir, or an empty string when the
+// package isn't installed or its license is not recognized
+func NPMPackage(manifestDir, name string) string {
+	dir := filepath.Join(manifestDir, "node_modules", filepath.FromSlash(name))
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err == nil {
+		var pkg struct {
+			License  any `json:"license"`
+			Licenses []struct {
+				Type string `json:"type"`
+			} `json:"licenses"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			switch license := pkg.License.(type) {
+			case string:
+				// "SEE LICENSE IN <file>" points to the license text
+				if !strings.HasPrefix(strings.ToUpper(license), "SEE LICENSE IN") && license != "" {
+					return license
+				}
+			case map[string]any:
+				// Deprecated {"type": "MIT"} form
+				if t, ok := license["type"].(string); ok && t != "" {
+					return t
+				}
+			}
+			var types []string
+			for _, l := range pkg.Licenses {
+				if l.Type != "" {
+					types = append(types, l.Type)
+				}
+			}
+			if len(types) > 0 {
+				return strings.Join(types, " OR ")
+			}
+		}
+	}
+	return licenseInDir(dir)
+}
+
+// licenseInDir identifies the license file of a package directory
+func licenseInDir(dir string) string {
+	for _, name := range licenseFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxLicenseSize))
+		f.Close()
+		if err != nil {
+			continue
+		}
+		if id := Identify(string(data)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// goModCache returns the module cache directory without running the go command
+func goModCache() string {
+	if cache := os.Getenv("GOMODCACHE"); cache != "" {
+		return cache
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	first, _, _ := strings.Cut(gopath, string(os.PathListSeparator))
+	return filepath.Join(first, "pkg", "mod")
+}
+
+// escapeModulePath escapes upper-case letters the way the module cache does,
+// e.g. "github.com/BurntSushi/toml" -> "github.com/!burnt!sushi/toml"
+func escapeModulePath(module string) string {
+	var b strings.Builder
+	for _, r := range module {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headerSearchLines is how far into a file the header is looked for, leaving
+// room for shebangs, build tags and package clauses
+const headerSearchLines = 30
+
+// yearPattern replaces {year} in headers, accepting ranges such as 2019-2024
+const yearPattern = `\d{4}(?:\s*[-,]\s*\d{4})*`
+
+// HasHeader reports whether the first lines of a file contain every non-blank
+// line of header, in order. Comment markers don't matter because lines are
+// matched as substrings, and {year} matches any year or year range.
+func HasHeader(lines []string, header string) bool {
+	var patterns []*regexp.Regexp
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(line), regexp.QuoteMeta("{year}"), yearPattern)
+		patterns = append(patterns, regexp.MustCompile(quoted))
+	}
+	if len(patterns) == 0 {
+		return true
+	}
+
+	if len(lines) > headerSearchLines+len(patterns) {
+		lines = lines[:headerSearchLines+len(patterns)]
+	}
+	next := 0
+	for _, line := range lines {
+		if patterns[next].MatchString(line) {
+			next++
+			if next == len(patterns) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// Package license identifies the licenses of source files and dependencies
+// with local heuristics: SPDX identifiers, the well-known phrases of common
+// license texts, and package metadata in the module cache and node_modules.
+package license
+
+import (
+	"regexp"
+	"strings"
+)
+
+var spdxPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\s*/]+(?:\s+(?:OR|AND|WITH)\s+[^\s*/]+)*)`)
+
+// phrases identify license texts; more specific licenses come first because
+// e.g. the LGPL text also mentions the GPL
+var phrases = []struct {
+	id      string
+	phrases []string // All must appear
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"LGPL-2.0", []string{"GNU LIBRARY GENERAL PUBLIC LICENSE"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"SSPL-1.0", []string{"Server Side Public License"}},
+	{"BUSL-1.1", []string{"Business Source License"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "Version 2.0"}},
+	{"EPL-2.0", []string{"Eclipse Public License", "v 2.0"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+	{"ISC", []string{"Permission to use, copy, modify, and/or distribute this software for any purpose", "provided that the above copyright notice"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"Unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+	{"0BSD", []string{"Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee"}},
+}
+
+// Identify returns the SPDX identifier (or expression) of a license text, or
+// an empty string when the license is not recognized
+func Identify(text string) string {
+	if m := spdxPattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	normalized := strings.Join(strings.Fields(text), " ")
+	upper := strings.ToUpper(normalized)
+	for _, candidate := range phrases {
+		matched := true
+		for _, phrase := range candidate.phrases {
+			if !strings.Contains(upper, strings.ToUpper(phrase)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return candidate.id
+		}
+	}
+	return ""
+}
+
+// Disallowed reports whether an SPDX expression is only satisfiable with a
+// disallowed license, and returns the disallowed license it matched. An
+// identifier matches a disallowed one with the same prefix, so "GPL-3.0"
+// covers "GPL-3.0-only" and "GPL-3.0-or-later". In "A OR B" the dependency
+// can be used under either license; in "A AND B" both apply.
+func Disallowed(expression string, disallowed []string) (string, bool) {
+	expression = strings.NewReplacer("(", " ", ")", " ").Replace(expression)
+	first := ""
+	for _, alternative := range splitOperator(expression, "OR") {
+		matched := ""
+		for _, id := range splitOperator(alternative, "AND") {
+			// "GPL-2.0 WITH Classpath-exception-2.0" is judged by its license
+			id, _, _ = strings.Cut(strings.TrimSpace(id), " ")
+			if d, ok := matchDisallowed(id, disallowed); ok {
+				matched = d
+				break
+			}
+		}
+		if matched == "" {
+			return "", false
+		}
+		if first == "" {
+			first = matched
+		}
+	}
+	return first, first != ""
+}
+
+// matchDisallowed returns the disallowed license that id matches
+func matchDisallowed(id string, disallowed []string) (string, bool) {
+	id = strings.ToUpper(strings.TrimSuffix(id, "+"))
+	if id == "" {
+		return "", false
+	}
+	for _, d := range disallowed {
+		upper := strings.ToUpper(strings.TrimSpace(d))
+		if upper == "" {
+			continue
+		}
+		if id == upper || strings.HasPrefix(id, upper+"-") {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// splitOperator splits an SPDX expression on a case-insensitive operator
+func splitOperator(expression, operator string) []string {
+	fields := strings.Fields(expression)
+	var parts []string
+	var current []string
+	for _, field := range fields {
+		if strings.EqualFold(field, operator) {
+			parts = append(parts, strings.Join(current, " "))
+			current = nil
+			continue
+		}
+		current = append(current, field)
+	}
+	return append(parts, strings.Join(current, " "))
+}
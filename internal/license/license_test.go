@@ -0,0 +1,95 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"spdx", "// SPDX-License-Identifier: Apache-2.0 OR MIT\npackage x", "Apache-2.0 OR MIT"},
+		{"mit", "MIT License\n\nPermission is hereby granted, free of charge, to any person", "MIT"},
+		{"apache", "Apache License\n   Version 2.0, January 2004", "Apache-2.0"},
+		{"gpl3", "GNU GENERAL PUBLIC LICENSE\n Version 3, 29 June 2007", "GPL-3.0"},
+		{"lgpl21", "GNU LESSER GENERAL PUBLIC LICENSE\n Version 2.1, February 1999\n GNU GENERAL PUBLIC LICENSE", "LGPL-2.1"},
+		{"agpl", "GNU AFFERO GENERAL PUBLIC LICENSE\n Version 3", "AGPL-3.0"},
+		{"bsd3", "Redistribution and use in source and binary forms, with or without\nmodification... Neither the name of", "BSD-3-Clause"},
+		{"isc", "Permission to use, copy, modify, and/or distribute this software for any\npurpose with or without fee is hereby granted, provided that the above\ncopyright notice", "ISC"},
+		{"unknown", "All rights reserved.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Identify(tt.text))
+		})
+	}
+}
+
+func TestDisallowed(t *testing.T) {
+	disallowed := []string{"GPL-3.0", "AGPL-3.0"}
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"MIT", ""},
+		{"GPL-3.0", "GPL-3.0"},
+		{"GPL-3.0-or-later", "GPL-3.0"},
+		{"gpl-3.0-only", "GPL-3.0"},
+		{"LGPL-3.0", ""},
+		{"(MIT OR GPL-3.0)", ""},
+		{"AGPL-3.0 OR GPL-3.0-only", "AGPL-3.0"},
+		{"MIT AND GPL-3.0", "GPL-3.0"},
+		{"GPL-3.0 WITH GCC-exception-3.1", "GPL-3.0"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, ok := Disallowed(tt.expression, disallowed)
+		assert.Equal(t, tt.want, got, tt.expression)
+		assert.Equal(t, tt.want != "", ok, tt.expression)
+	}
+}
+
+func TestHasHeader(t *testing.T) {
+	header := "Copyright {year} Acme Inc.\nSPDX-License-Identifier: Apache-2.0"
+	assert.True(t, HasHeader([]string{"#!/usr/bin/env python", "# Copyright 2019-2024 Acme Inc.", "# SPDX-License-Identifier: Apache-2.0"}, header))
+	assert.True(t, HasHeader([]string{"/*", " * Copyright 2024 Acme Inc.", " *", " * SPDX-License-Identifier: Apache-2.0", " */"}, header))
+	assert.False(t, HasHeader([]string{"// SPDX-License-Identifier: Apache-2.0", "// Copyright 2024 Acme Inc."}, header), "lines out of order")
+	assert.False(t, HasHeader([]string{"// Copyright Acme Inc.", "// SPDX-License-Identifier: Apache-2.0"}, header), "missing year")
+	assert.False(t, HasHeader([]string{"package main"}, header))
+	assert.True(t, HasHeader([]string{"package main"}, "  \n"))
+}
+
+func TestDependencyLicenses(t *testing.T) {
+	workDir := t.TempDir()
+	write := func(path, content string) {
+		full := filepath.Join(workDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+
+	write("vendor/example.com/lib/LICENSE", "GNU GENERAL PUBLIC LICENSE\nVersion 3")
+	assert.Equal(t, "GPL-3.0", GoModule(workDir, "example.com/lib", "v1.0.0"))
+
+	cache := filepath.Join(workDir, "modcache")
+	t.Setenv("GOMODCACHE", cache)
+	write("modcache/github.com/!burnt!sushi/toml@v1.3.2/COPYING", "The MIT License\nPermission is hereby granted, free of charge")
+	assert.Equal(t, "MIT", GoModule(workDir, "github.com/BurntSushi/toml", "v1.3.2"))
+	assert.Empty(t, GoModule(workDir, "example.com/missing", "v1.0.0"))
+
+	write("web/node_modules/left-pad/package.json", `{"name": "left-pad", "license": "WTFPL"}`)
+	write("web/node_modules/@scope/pkg/package.json", `{"name": "@scope/pkg", "license": "SEE LICENSE IN LICENSE"}`)
+	write("web/node_modules/@scope/pkg/LICENSE", "GNU AFFERO GENERAL PUBLIC LICENSE")
+	write("web/node_modules/old/package.json", `{"licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}]}`)
+	manifestDir := filepath.Join(workDir, "web")
+	assert.Equal(t, "WTFPL", NPMPackage(manifestDir, "left-pad"))
+	assert.Equal(t, "AGPL-3.0", NPMPackage(manifestDir, "@scope/pkg"))
+	assert.Equal(t, "MIT OR Apache-2.0", NPMPackage(manifestDir, "old"))
+	assert.Empty(t, NPMPackage(manifestDir, "not-installed"))
+}
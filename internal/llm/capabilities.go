@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+// Capabilities describes what a model supports, so that the agents can adapt
+// to it
+type Capabilities struct {
+	Tools       bool // Tool calls are reliable enough for multi-step and interactive flows
+	Vision      bool // Images are accepted as input
+	JSONMode    bool // Output can be constrained to JSON
+	StreamUsage bool // Streamed responses report their token usage
+	MaxContext  int  // Context window in tokens (0 = unknown)
+}
+
+// knownModel holds the capabilities of the models of a provider whose name
+// starts with prefix
+type knownModel struct {
+	prefix       string
+	capabilities Capabilities
+}
+
+// providerCapabilities are the capabilities assumed for unknown models of a
+// provider
+var providerCapabilities = map[string]Capabilities{
+	"openai":   {Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 128000},
+	"deepseek": {Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 64000},
+	"gemini":   {Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 1000000},
+	"grok":     {Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 131072},
+	// Local models differ too much to assume more than a small context
+	"ollama": {MaxContext: 8192},
+}
+
+// knownModels are the capabilities of the models of a provider, matched by the
+// longest name prefix
+var knownModels = map[string][]knownModel{
+	"openai": {
+		{"gpt-4o", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 128000}},
+		{"gpt-4.1", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 1047576}},
+		{"gpt-4-turbo", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 128000}},
+		{"gpt-3.5-turbo", Capabilities{Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 16385}},
+		{"o1", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 200000}},
+		{"o1-mini", Capabilities{StreamUsage: true, MaxContext: 128000}},
+		{"o3", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 200000}},
+		{"o4-mini", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 200000}},
+	},
+	"deepseek": {
+		{"deepseek-chat", Capabilities{Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 64000}},
+		{"deepseek-reasoner", Capabilities{Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 64000}},
+	},
+	"gemini": {
+		{"gemini-1.5-pro", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 2097152}},
+		{"gemini-1.5-flash", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 1048576}},
+		{"gemini-2", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 1048576}},
+	},
+	"grok": {
+		{"grok-2-vision", Capabilities{Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 32768}},
+		{"grok-3", Capabilities{Tools: true, JSONMode: true, StreamUsage: true, MaxContext: 131072}},
+		{"grok-4", Capabilities{Tools: true, Vision: true, JSONMode: true, StreamUsage: true, MaxContext: 256000}},
+	},
+	"ollama": {
+		{"llama3.1", Capabilities{Tools: true, JSONMode: true, MaxContext: 131072}},
+		{"llama3.2", Capabilities{Tools: true, JSONMode: true, MaxContext: 131072}},
+		{"llama3.3", Capabilities{Tools: true, JSONMode: true, MaxContext: 131072}},
+		{"qwen2.5", Capabilities{Tools: true, JSONMode: true, MaxContext: 32768}},
+		{"qwen3", Capabilities{Tools: true, JSONMode: true, MaxContext: 40960}},
+		{"mistral-nemo", Capabilities{Tools: true, JSONMode: true, MaxContext: 131072}},
+		{"llava", Capabilities{Vision: true, JSONMode: true, MaxContext: 4096}},
+	},
+}
+
+// LookupCapabilities returns the known capabilities of model of provider.
+// Unknown models get the defaults of their provider, and models of unknown
+// providers are assumed to support tool calling only.
+func LookupCapabilities(provider, model string) Capabilities {
+	name := strings.ToLower(model)
+	// Ollama tags the size of a model after a colon, e.g. qwen2.5:14b
+	if i := strings.Index(name, ":"); i >= 0 && provider == "ollama" {
+		name = name[:i]
+	}
+	var best *knownModel
+	for i, known := range knownModels[provider] {
+		if strings.HasPrefix(name, known.prefix) && (best == nil || len(known.prefix) > len(best.prefix)) {
+			best = &knownModels[provider][i]
+		}
+	}
+	if best != nil {
+		return best.capabilities
+	}
+	if capabilities, ok := providerCapabilities[provider]; ok {
+		return capabilities
+	}
+	return Capabilities{Tools: true}
+}
+
+// ModelCapabilities returns the capabilities of the configured model, with the
+// overrides of its configuration applied
+func ModelCapabilities(cfg config.ModelConfig) Capabilities {
+	capabilities := LookupCapabilities(cfg.Provider, cfg.Model)
+	overrides := cfg.Capabilities
+	if overrides == nil {
+		return capabilities
+	}
+	if overrides.Tools != nil {
+		capabilities.Tools = *overrides.Tools
+	}
+	if overrides.Vision != nil {
+		capabilities.Vision = *overrides.Vision
+	}
+	if overrides.JSONMode != nil {
+		capabilities.JSONMode = *overrides.JSONMode
+	}
+	if overrides.StreamUsage != nil {
+		capabilities.StreamUsage = *overrides.StreamUsage
+	}
+	if overrides.MaxContext > 0 {
+		capabilities.MaxContext = overrides.MaxContext
+	}
+	return capabilities
+}
+
+// CapabilitiesOf returns the capabilities of the model of provider
+func CapabilitiesOf(provider Provider) Capabilities {
+	return ModelCapabilities(provider.GetConfig())
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	gpt4o := LookupCapabilities("openai", "gpt-4o-mini")
+	assert.True(t, gpt4o.Tools)
+	assert.True(t, gpt4o.Vision)
+	assert.Equal(t, 128000, gpt4o.MaxContext)
+
+	// The longest prefix wins
+	assert.False(t, LookupCapabilities("openai", "o1-mini").Tools)
+	assert.True(t, LookupCapabilities("openai", "o1-2024-12-17").Tools)
+
+	// Ollama models are matched without their tag
+	assert.True(t, LookupCapabilities("ollama", "qwen2.5:14b").Tools)
+	assert.Equal(t, Capabilities{MaxContext: 8192}, LookupCapabilities("ollama", "phi"), "unknown local models get the provider defaults")
+
+	assert.Equal(t, 64000, LookupCapabilities("deepseek", "deepseek-v4").MaxContext)
+	assert.Equal(t, Capabilities{Tools: true}, LookupCapabilities("fake", "model"))
+}
+
+func TestModelCapabilities(t *testing.T) {
+	enabled, disabled := true, false
+	capabilities := ModelCapabilities(config.ModelConfig{
+		Provider: "ollama",
+		Model:    "my-finetune:7b",
+		Capabilities: &config.CapabilitiesConfig{
+			Tools:      &enabled,
+			JSONMode:   &disabled,
+			MaxContext: 32768,
+		},
+	})
+	assert.Equal(t, Capabilities{Tools: true, MaxContext: 32768}, capabilities)
+
+	capabilities = ModelCapabilities(config.ModelConfig{
+		Provider:     "openai",
+		Model:        "gpt-4o",
+		Capabilities: &config.CapabilitiesConfig{Vision: &disabled},
+	})
+	assert.False(t, capabilities.Vision)
+	assert.True(t, capabilities.Tools, "capabilities that aren't overridden are kept")
+	assert.Equal(t, 128000, capabilities.MaxContext)
+}
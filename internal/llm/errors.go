@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exit codes of the user-facing model failures; other errors exit with 1
+const (
+	ExitAuth            = 3
+	ExitQuotaExceeded   = 4
+	ExitContextTooLarge = 5
+	ExitNetwork         = 6
+)
+
+// UserError is a model failure with a remediation hint for the user and the
+// exit code the process ends with
+type UserError interface {
+	error
+	Hint() string
+	ExitCode() int
+}
+
+// failure holds what the user-facing model failures have in common
+type failure struct {
+	Op  string // What failed, e.g. "LLM stream failed"
+	Err error
+}
+
+func (f failure) Error() string { return f.Op + ": " + f.Err.Error() }
+
+func (f failure) Unwrap() error { return f.Err }
+
+// AuthError is a model request rejected because of the API key
+type AuthError struct{ failure }
+
+// Hint tells the user how to fix the failure
+func (e *AuthError) Hint() string {
+	return "The provider rejected the API key. Check api_key of the model in your config (or the environment variable it references) and that the key has access to the model, or pick another model with --model."
+}
+
+// ExitCode returns the exit code of the failure
+func (e *AuthError) ExitCode() int { return ExitAuth }
+
+// QuotaExceededError is a model request refused by the provider's rate limit or quota
+type QuotaExceededError struct{ failure }
+
+// Hint tells the user how to fix the failure
+func (e *QuotaExceededError) Hint() string {
+	return "The provider's rate limit or quota is used up, even after retrying. Wait a few minutes, check the plan and billing of the account, raise retry.max_attempts, or pick another model with --model."
+}
+
+// ExitCode returns the exit code of the failure
+func (e *QuotaExceededError) ExitCode() int { return ExitQuotaExceeded }
+
+// ContextTooLargeError is a model request larger than the model's context window
+type ContextTooLargeError struct{ failure }
+
+// Hint tells the user how to fix the failure
+func (e *ContextTooLargeError) Hint() string {
+	return "The request doesn't fit in the model's context window, even after dropping old tool results. Stage or select fewer files (e.g. review --files), shorten --context, or pick a model with a larger context window with --model."
+}
+
+// ExitCode returns the exit code of the failure
+func (e *ContextTooLargeError) ExitCode() int { return ExitContextTooLarge }
+
+// NetworkError is a model request that could not reach the provider or stalled
+type NetworkError struct{ failure }
+
+// Hint tells the user how to fix the failure
+func (e *NetworkError) Hint() string {
+	return "The model provider could not be reached or stopped responding. Check the network connection, proxy settings (HTTPS_PROXY) and base_url of the model; slow models may need a higher retry.stream_idle_timeout."
+}
+
+// ExitCode returns the exit code of the failure
+func (e *NetworkError) ExitCode() int { return ExitNetwork }
+
+// statusCodePattern finds an HTTP status code in a provider error message,
+// e.g. "error, status code: 401, message: ..."
+var statusCodePattern = regexp.MustCompile(`(?i)status(?:[ _]?code)?[:= ]+(\d{3})\b`)
+
+var (
+	authKeywords = []string{
+		"unauthorized",
+		"invalid api key",
+		"incorrect api key",
+		"invalid_api_key",
+		"api key not valid",
+		"authentication",
+		"permission_denied",
+	}
+	quotaKeywords = []string{
+		"quota",
+		"rate limit",
+		"rate_limit",
+		"too many requests",
+		"resource_exhausted",
+		"insufficient_balance",
+		"billing",
+	}
+	networkKeywords = []string{
+		"connection refused",
+		"connection reset",
+		"no such host",
+		"network is unreachable",
+		"i/o timeout",
+		"tls handshake",
+	}
+)
+
+// ExplainError returns the user-facing failure type of err, an error from a
+// model request described by op, so that the user gets a hint and a distinct
+// exit code. Unrecognized errors, and errors that already are a UserError,
+// are returned as "op: err".
+func ExplainError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var userErr UserError
+	if errors.As(err, &userErr) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	f := failure{Op: op, Err: err}
+	status := statusCode(err)
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, context.Canceled):
+		// Interrupted by the user, nothing to explain
+	case IsContextLengthError(err):
+		return &ContextTooLargeError{f}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden || containsAny(msg, authKeywords):
+		return &AuthError{f}
+	case status == http.StatusTooManyRequests || containsAny(msg, quotaKeywords):
+		return &QuotaExceededError{f}
+	case isNetworkFailure(err) || containsAny(msg, networkKeywords):
+		return &NetworkError{f}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// IsUnavailable reports whether err means the model provider could not be
+// reached or is failing on its side (5xx), as during an outage, as opposed
+// to a problem with the request, the API key or the quota
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var networkErr *NetworkError
+	if errors.As(err, &networkErr) || isNetworkFailure(err) || containsAny(strings.ToLower(err.Error()), networkKeywords) {
+		return true
+	}
+	status := statusCode(err)
+	return status >= http.StatusInternalServerError && status < 600
+}
+
+// statusCode returns the HTTP status code of a provider error, 0 when unknown
+func statusCode(err error) int {
+	var statusErr HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatusCode()
+	}
+	var httpErr interface {
+		error
+		StatusCode() int
+	}
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode()
+	}
+	if m := statusCodePattern.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return code
+	}
+	return 0
+}
+
+// isNetworkFailure reports whether err is a connection failure or a timeout
+func isNetworkFailure(err error) bool {
+	var netErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &netErr) || errors.As(err, &dnsErr) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrStreamIdle)
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
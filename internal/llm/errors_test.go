@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		exitCode int // 0 for errors without a user-facing type
+	}{
+		{name: "status 401", err: &HTTPError{Code: http.StatusUnauthorized, Message: "unauthorized"}, exitCode: ExitAuth},
+		{name: "status in message", err: errors.New("error, status code: 401, message: Incorrect API key provided"), exitCode: ExitAuth},
+		{name: "gemini key", err: errors.New("API key not valid. Please pass a valid API key."), exitCode: ExitAuth},
+		{name: "status 429", err: &HTTPError{Code: http.StatusTooManyRequests, Message: "slow down"}, exitCode: ExitQuotaExceeded},
+		{name: "quota", err: errors.New("You exceeded your current quota, please check your plan and billing details"), exitCode: ExitQuotaExceeded},
+		{name: "context length", err: errors.New("This model's maximum context length is 8192 tokens"), exitCode: ExitContextTooLarge},
+		{name: "dial", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, exitCode: ExitNetwork},
+		{name: "stalled stream", err: fmt.Errorf("%w: no response from the model for 2m0s", ErrStreamIdle), exitCode: ExitNetwork},
+		{name: "canceled", err: context.Canceled},
+		{name: "other", err: errors.New("model returned an empty response")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExplainError("LLM stream failed", tt.err)
+			assert.Equal(t, "LLM stream failed: "+tt.err.Error(), err.Error())
+			assert.ErrorIs(t, err, tt.err)
+
+			var userErr UserError
+			if tt.exitCode == 0 {
+				assert.False(t, errors.As(err, &userErr))
+				return
+			}
+			if assert.True(t, errors.As(fmt.Errorf("commit failed: %w", err), &userErr)) {
+				assert.Equal(t, tt.exitCode, userErr.ExitCode())
+				assert.NotEmpty(t, userErr.Hint())
+			}
+		})
+	}
+
+	assert.NoError(t, ExplainError("LLM stream failed", nil))
+
+	// A failure that already has a hint keeps it instead of being reclassified
+	explained := &NetworkError{failure{Op: "proxy failed", Err: errors.New("unauthorized")}}
+	err := ExplainError("LLM stream failed", explained)
+	var authErr *AuthError
+	assert.False(t, errors.As(err, &authErr))
+	var networkErr *NetworkError
+	if assert.True(t, errors.As(err, &networkErr)) {
+		assert.Same(t, explained, networkErr)
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "dial", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "explained network error", err: ExplainError("LLM stream failed", errors.New("dial tcp: lookup api.openai.com: no such host")), want: true},
+		{name: "status 503", err: &HTTPError{Code: http.StatusServiceUnavailable, Message: "overloaded"}, want: true},
+		{name: "status in message", err: errors.New("error, status code: 502, message: bad gateway"), want: true},
+		{name: "status 401", err: &HTTPError{Code: http.StatusUnauthorized, Message: "unauthorized"}},
+		{name: "quota", err: errors.New("You exceeded your current quota")},
+		{name: "canceled", err: context.Canceled},
+		{name: "nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUnavailable(tt.err))
+		})
+	}
+}
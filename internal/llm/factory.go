@@ -40,3 +40,17 @@ func (f *ProviderFactory) CreateFromConfig(appCfg *config.Config, modelName stri
 	}
 	return f.Create(*modelCfg)
 }
+
+// CreateFallbacks creates the providers of the fallback models of modelCfg,
+// in order (see WithFallbacks)
+func (f *ProviderFactory) CreateFallbacks(appCfg *config.Config, modelCfg config.ModelConfig) ([]Provider, error) {
+	providers := make([]Provider, 0, len(modelCfg.Fallbacks))
+	for _, name := range modelCfg.Fallbacks {
+		provider, err := f.CreateFromConfig(appCfg, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback model %s: %w", name, err)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
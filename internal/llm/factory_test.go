@@ -141,6 +141,30 @@ func TestProviderFactory_CreateFromConfig(t *testing.T) {
 	})
 }
 
+func TestProviderFactory_CreateFallbacks(t *testing.T) {
+	factory := NewProviderFactory()
+	appCfg := &config.Config{
+		Models: map[string]config.ModelConfig{
+			"deepseek": {Provider: "deepseek", APIKey: "sk-test", Model: "deepseek-chat", Fallbacks: []string{"gpt4", "local"}},
+			"gpt4":     {Provider: "openai", APIKey: "sk-openai", Model: "gpt-4o"},
+			"local":    {Provider: "ollama", Model: "qwen2.5:14b"},
+		},
+	}
+
+	providers, err := factory.CreateFallbacks(appCfg, appCfg.Models["deepseek"])
+	require.NoError(t, err)
+	require.Len(t, providers, 2)
+	assert.Equal(t, "openai", providers[0].Name())
+	assert.Equal(t, "ollama", providers[1].Name())
+
+	providers, err = factory.CreateFallbacks(appCfg, appCfg.Models["gpt4"])
+	require.NoError(t, err)
+	assert.Empty(t, providers)
+
+	_, err = factory.CreateFallbacks(appCfg, config.ModelConfig{Fallbacks: []string{"nonexistent"}})
+	assert.Error(t, err)
+}
+
 func TestProvider_GetConfig(t *testing.T) {
 	factory := NewProviderFactory()
 
@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+// FallbackFunc is told when a request moves from one provider of a fallback
+// chain to the next because of err
+type FallbackFunc func(from, to Provider, err error)
+
+// WithFallbacks wraps primary so that a request it fails is sent to the
+// fallback providers in order. A provider with a fallback after it gets the
+// request retried with retry first, so a fallback is only used when the error
+// is not retryable or the retries are exhausted; the last provider of the
+// chain is retried by the agent as usual. A stream failing before its first
+// chunk falls back like a request that failed. Once a chat model falls back,
+// it keeps using that provider for the rest of the run, and Name and GetConfig
+// report the provider that answered the last request. Cancelled requests and
+// requests too large for the context window, which the agents recover from by
+// shrinking the history, don't fall back. onFallback may be nil.
+func WithFallbacks(primary Provider, fallbacks []Provider, retry RetryConfig, onFallback FallbackFunc) Provider {
+	if len(fallbacks) == 0 {
+		return primary
+	}
+	return &fallbackProvider{
+		Provider:   primary,
+		chain:      append([]Provider{primary}, fallbacks...),
+		retry:      retry,
+		onFallback: onFallback,
+	}
+}
+
+type fallbackProvider struct {
+	Provider
+	chain      []Provider
+	retry      RetryConfig
+	onFallback FallbackFunc
+
+	mu     sync.Mutex
+	served int // Index of the provider that answered the last request
+}
+
+func (p *fallbackProvider) Name() string {
+	return p.servedProvider().Name()
+}
+
+func (p *fallbackProvider) GetConfig() config.ModelConfig {
+	return p.servedProvider().GetConfig()
+}
+
+// servedProvider returns the provider that answered the last request, the
+// primary until a request is answered
+func (p *fallbackProvider) servedProvider() Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.chain[p.served]
+}
+
+func (p *fallbackProvider) setServed(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.served = i
+}
+
+func (p *fallbackProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	m := &fallbackModel{provider: p, models: make([]model.ChatModel, len(p.chain))}
+	// The primary model is created now, so configuration errors surface as before
+	if _, err := m.model(ctx, 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fallbackModel sends requests to the active model of a fallback chain.
+// Fallback models are created when they are first needed.
+type fallbackModel struct {
+	provider *fallbackProvider
+
+	mu     sync.Mutex
+	models []model.ChatModel
+	tools  []*schema.ToolInfo
+	active int
+}
+
+func (m *fallbackModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return callWithFallback(ctx, m, func(chatModel model.ChatModel) (*schema.Message, error) {
+		return chatModel.Generate(ctx, input, opts...)
+	})
+}
+
+func (m *fallbackModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return callWithFallback(ctx, m, func(chatModel model.ChatModel) (*schema.StreamReader[*schema.Message], error) {
+		stream, err := chatModel.Stream(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return startStream(stream)
+	})
+}
+
+// startStream waits for the first chunk of stream, so a stream failing before
+// it answers anything fails like a request that couldn't be sent. Errors after
+// the first chunk end the returned stream, since the output already read
+// cannot be taken back.
+func startStream(stream *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
+	chunk, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		stream.Close()
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer writer.Close()
+		defer stream.Close()
+
+		for err == nil {
+			if closed := writer.Send(chunk, nil); closed {
+				return
+			}
+			chunk, err = stream.Recv()
+		}
+		if !errors.Is(err, io.EOF) {
+			writer.Send(nil, err)
+		}
+	}()
+	return reader, nil
+}
+
+// BindTools binds tools to the models created so far and to those created later
+func (m *fallbackModel) BindTools(tools []*schema.ToolInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools = tools
+	for _, chatModel := range m.models {
+		if chatModel == nil {
+			continue
+		}
+		if err := chatModel.BindTools(tools); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callWithFallback calls the active model, moving down the chain while calls fail
+func callWithFallback[T any](ctx context.Context, m *fallbackModel, call func(model.ChatModel) (T, error)) (T, error) {
+	var zero T
+	last := len(m.provider.chain) - 1
+	for {
+		m.mu.Lock()
+		i := m.active
+		m.mu.Unlock()
+
+		chatModel, err := m.model(ctx, i)
+		if err == nil {
+			var result T
+			if i == last {
+				result, err = call(chatModel)
+			} else {
+				result, err = WithRetryResult(ctx, m.provider.retry, func() (T, error) {
+					return call(chatModel)
+				})
+			}
+			if err == nil {
+				m.provider.setServed(i)
+				return result, nil
+			}
+		}
+		if i == last || !canFallBack(ctx, err) {
+			return zero, err
+		}
+
+		m.mu.Lock()
+		m.active = i + 1
+		m.mu.Unlock()
+		if m.provider.onFallback != nil {
+			m.provider.onFallback(m.provider.chain[i], m.provider.chain[i+1], err)
+		}
+	}
+}
+
+// model returns the chat model of the provider at index i of the chain,
+// creating it and binding the tools on first use
+func (m *fallbackModel) model(ctx context.Context, i int) (model.ChatModel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.models[i] != nil {
+		return m.models[i], nil
+	}
+	chatModel, err := m.provider.chain[i].CreateChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if m.tools != nil {
+		if err := chatModel.BindTools(m.tools); err != nil {
+			return nil, err
+		}
+	}
+	m.models[i] = chatModel
+	return chatModel, nil
+}
+
+// canFallBack reports whether a failed request should move to the next provider
+func canFallBack(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !IsContextLengthError(err)
+}
@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingChatModel fails its first failures requests with err
+type failingChatModel struct {
+	fakeChatModel
+	err      error
+	failures int
+	calls    int
+	tools    []*schema.ToolInfo
+}
+
+func (m *failingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return nil, m.err
+	}
+	return m.fakeChatModel.Stream(ctx, input, opts...)
+}
+
+func (m *failingChatModel) BindTools(tools []*schema.ToolInfo) error {
+	m.tools = tools
+	return nil
+}
+
+// brokenStreamChatModel opens streams that fail with err before their first chunk
+type brokenStreamChatModel struct {
+	fakeChatModel
+	err   error
+	calls int
+}
+
+func (m *brokenStreamChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	reader, writer := schema.Pipe[*schema.Message](1)
+	writer.Send(nil, m.err)
+	writer.Close()
+	return reader, nil
+}
+
+func (m *brokenStreamChatModel) BindTools(tools []*schema.ToolInfo) error {
+	return nil
+}
+
+// modelProvider is a fakeProvider reporting the model name
+type modelProvider struct {
+	fakeProvider
+	model string
+}
+
+func (p *modelProvider) GetConfig() config.ModelConfig {
+	return config.ModelConfig{Provider: "fake", Model: p.model}
+}
+
+type httpStatusError int
+
+func (e httpStatusError) Error() string       { return "request failed" }
+func (e httpStatusError) HTTPStatusCode() int { return int(e) }
+
+var fallbackRetry = RetryConfig{Enabled: true, MaxAttempts: 2, BackoffBase: 0, BackoffMax: 0}
+
+func TestWithFallbacks_NoFallbacks(t *testing.T) {
+	provider := &fakeProvider{chatModel: &fakeChatModel{reply: "hello"}}
+	assert.Same(t, Provider(provider), WithFallbacks(provider, nil, fallbackRetry, nil))
+}
+
+func TestWithFallbacks_NonRetryableError(t *testing.T) {
+	primary := &failingChatModel{err: httpStatusError(401), failures: 100}
+	fallback := &failingChatModel{fakeChatModel: fakeChatModel{reply: "hello"}}
+	var switched error
+	provider := WithFallbacks(&fakeProvider{chatModel: primary}, []Provider{&fakeProvider{chatModel: fallback}}, fallbackRetry,
+		func(from, to Provider, err error) { switched = err })
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	tools := []*schema.ToolInfo{{Name: "read_file"}}
+	require.NoError(t, chatModel.BindTools(tools))
+
+	stream, err := chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", content)
+	assert.Equal(t, 1, primary.calls, "a non-retryable error is not retried")
+	assert.Equal(t, httpStatusError(401), switched)
+	assert.Equal(t, tools, fallback.tools)
+
+	// Later requests go straight to the fallback
+	_, err = chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 2, fallback.calls)
+}
+
+func TestWithFallbacks_RetriesExhausted(t *testing.T) {
+	primary := &failingChatModel{err: httpStatusError(503), failures: 100}
+	fallback := &failingChatModel{fakeChatModel: fakeChatModel{reply: "hello"}}
+	provider := WithFallbacks(&fakeProvider{chatModel: primary}, []Provider{&fakeProvider{chatModel: fallback}}, fallbackRetry, nil)
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	_, err = chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, primary.calls, "the primary is retried first")
+	assert.Equal(t, 1, fallback.calls)
+}
+
+func TestWithFallbacks_RecoversWithinRetries(t *testing.T) {
+	primary := &failingChatModel{fakeChatModel: fakeChatModel{reply: "hello"}, err: httpStatusError(503), failures: 1}
+	fallback := &failingChatModel{}
+	provider := WithFallbacks(&fakeProvider{chatModel: primary}, []Provider{&fakeProvider{chatModel: fallback}}, fallbackRetry, nil)
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	_, err = chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, fallback.calls)
+}
+
+func TestWithFallbacks_LastProviderFails(t *testing.T) {
+	primary := &failingChatModel{err: httpStatusError(401), failures: 100}
+	fallback := &failingChatModel{err: httpStatusError(403), failures: 100}
+	provider := WithFallbacks(&fakeProvider{chatModel: primary}, []Provider{&fakeProvider{chatModel: fallback}}, fallbackRetry, nil)
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	_, err = chatModel.Stream(context.Background(), nil)
+	assert.Equal(t, httpStatusError(403), err)
+	assert.Equal(t, 1, fallback.calls, "the agent retries the last provider")
+}
+
+func TestWithFallbacks_KeepsContextLengthErrors(t *testing.T) {
+	contextErr := errors.New("maximum context length is 128000 tokens")
+	primary := &failingChatModel{err: contextErr, failures: 100}
+	fallback := &failingChatModel{fakeChatModel: fakeChatModel{reply: "hello"}}
+	provider := WithFallbacks(&fakeProvider{chatModel: primary}, []Provider{&fakeProvider{chatModel: fallback}}, fallbackRetry, nil)
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	_, err = chatModel.Stream(context.Background(), nil)
+	assert.Equal(t, contextErr, err)
+	assert.Equal(t, 0, fallback.calls)
+}
+
+func TestWithFallbacks_StreamFailsBeforeFirstChunk(t *testing.T) {
+	primary := &brokenStreamChatModel{err: httpStatusError(401)}
+	fallback := &failingChatModel{fakeChatModel: fakeChatModel{reply: "hello"}}
+	provider := WithFallbacks(&modelProvider{fakeProvider{chatModel: primary}, "primary"},
+		[]Provider{&modelProvider{fakeProvider{chatModel: fallback}, "fallback"}}, fallbackRetry, nil)
+
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "primary", provider.GetConfig().Model)
+
+	stream, err := chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", content)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 1, fallback.calls)
+	assert.Equal(t, "fallback", provider.GetConfig().Model, "the model that answered is reported")
+}
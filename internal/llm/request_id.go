@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// RequestID returns the provider's ID of the request a response message or
+// chunk belongs to (e.g. the "openai-request-id" extra of OpenAI-compatible
+// providers), or "" when the provider doesn't report one
+func RequestID(msg *schema.Message) string {
+	if msg == nil {
+		return ""
+	}
+	for key, value := range msg.Extra {
+		key = strings.ToLower(key)
+		if !strings.HasSuffix(key, "request-id") && !strings.HasSuffix(key, "request_id") {
+			continue
+		}
+		if id := fmt.Sprint(value); id != "" && id != "<nil>" {
+			return id
+		}
+	}
+	return ""
+}
+
+// WithRequestID adds the provider's request ID to err, so that a failure can
+// be looked up by the provider's support
+func WithRequestID(err error, id string) error {
+	if err == nil || id == "" {
+		return err
+	}
+	return fmt.Errorf("%w (request ID %s)", err, id)
+}
+
+// WithRequestIDs wraps provider so that errors ending the streams of its chat
+// models name the provider's ID of the failed request, when the provider
+// reported one before failing
+func WithRequestIDs(provider Provider) Provider {
+	return &requestIDProvider{Provider: provider}
+}
+
+type requestIDProvider struct {
+	Provider
+}
+
+func (p *requestIDProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	chatModel, err := p.Provider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &requestIDModel{ChatModel: chatModel}, nil
+}
+
+// requestIDModel adds request IDs to the stream errors of the wrapped chat model
+type requestIDModel struct {
+	model.ChatModel
+}
+
+func (m *requestIDModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	stream, err := m.ChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer writer.Close()
+		defer stream.Close()
+
+		var id string
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				writer.Send(nil, WithRequestID(err, id))
+				return
+			}
+			if id == "" {
+				id = RequestID(chunk)
+			}
+			if closed := writer.Send(chunk, nil); closed {
+				return
+			}
+		}
+	}()
+	return reader, nil
+}
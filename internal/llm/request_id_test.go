@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// droppingChatModel streams a chunk carrying a request ID and then fails
+type droppingChatModel struct {
+	fakeChatModel
+}
+
+func (m *droppingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	reader, writer := schema.Pipe[*schema.Message](2)
+	chunk := schema.AssistantMessage("partial", nil)
+	chunk.Extra = map[string]any{"openai-request-id": "req-123"}
+	writer.Send(chunk, nil)
+	writer.Send(nil, errors.New("connection reset by peer"))
+	writer.Close()
+	return reader, nil
+}
+
+func TestRequestID(t *testing.T) {
+	msg := schema.AssistantMessage("hi", nil)
+	assert.Empty(t, RequestID(msg))
+	assert.Empty(t, RequestID(nil))
+
+	msg.Extra = map[string]any{"openai-request-id": "req-1"}
+	assert.Equal(t, "req-1", RequestID(msg))
+}
+
+func TestWithRequestIDs_NamesRequestInStreamError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	transcript, err := NewTranscript(path, RunInfo{Command: "gitbuddy commit"})
+	require.NoError(t, err)
+
+	provider := WithTranscript(WithRequestIDs(&fakeProvider{chatModel: &droppingChatModel{}}), transcript)
+	chatModel, err := provider.CreateChatModel(ctx)
+	require.NoError(t, err)
+
+	stream, err := chatModel.Stream(ctx, []*schema.Message{schema.UserMessage("Describe")})
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	assert.Equal(t, "partial", content)
+	assert.EqualError(t, err, "connection reset by peer (request ID req-123)")
+	assert.IsType(t, &NetworkError{}, ExplainError("stream read error", err))
+
+	require.NoError(t, transcript.Close(err))
+	entries := readTranscript(t, path)
+	require.Len(t, entries, 4)
+	assert.Equal(t, TranscriptError, entries[2].Type)
+	assert.Equal(t, "req-123", entries[2].RequestID)
+}
@@ -53,8 +53,8 @@ func ClassifyError(err error) ErrorType {
 		return ErrorTypeNonRetryable
 	}
 
-	// Check for context deadline exceeded (timeout - retryable)
-	if errors.Is(err, context.DeadlineExceeded) {
+	// Check for context deadline exceeded or a stalled stream (timeout - retryable)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrStreamIdle) {
 		return ErrorTypeRetryable
 	}
 
@@ -84,19 +84,10 @@ func ClassifyError(err error) ErrorType {
 	}
 
 	// Check error message for context length issues
-	errMsg := strings.ToLower(err.Error())
-	contextKeywords := []string{
-		"context length",
-		"context_length",
-		"maximum context",
-		"token limit",
-		"tokens exceeded",
-	}
-	for _, keyword := range contextKeywords {
-		if strings.Contains(errMsg, keyword) {
-			return ErrorTypeNonRetryable
-		}
+	if IsContextLengthError(err) {
+		return ErrorTypeNonRetryable
 	}
+	errMsg := strings.ToLower(err.Error())
 
 	// Check for timeout in error message
 	if strings.Contains(errMsg, "timeout") {
@@ -107,6 +98,33 @@ func ClassifyError(err error) ErrorType {
 	return ErrorTypeUnknown
 }
 
+// contextLengthKeywords identify provider errors caused by an oversized prompt
+var contextLengthKeywords = []string{
+	"context length",
+	"context_length",
+	"maximum context",
+	"context window",
+	"prompt is too long",
+	"token limit",
+	"tokens exceeded",
+}
+
+// IsContextLengthError reports whether err was caused by the prompt exceeding
+// the model's context window. These errors are not retried as-is, but can be
+// recovered from by shrinking the message history.
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errMsg := strings.ToLower(err.Error())
+	for _, keyword := range contextLengthKeywords {
+		if strings.Contains(errMsg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // classifyHTTPStatus classifies HTTP status codes
 func classifyHTTPStatus(statusCode int) ErrorType {
 	switch statusCode {
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"testing"
@@ -156,6 +157,29 @@ func TestClassifyError_ContextExceeded(t *testing.T) {
 	}
 }
 
+// TestIsContextLengthError tests context length error detection
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "openai", err: errors.New("This model's maximum context length is 128000 tokens"), want: true},
+		{name: "anthropic", err: errors.New("prompt is too long: 210000 tokens > 200000 maximum"), want: true},
+		{name: "wrapped", err: fmt.Errorf("LLM stream failed: %w", &HTTPError{Code: http.StatusBadRequest, Message: "context_length_exceeded"}), want: true},
+		{name: "bad request", err: &HTTPError{Code: http.StatusBadRequest, Message: "bad request"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsContextLengthError(tt.err); got != tt.want {
+				t.Errorf("IsContextLengthError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestClassifyError_UnknownError tests unknown error classification
 func TestClassifyError_UnknownError(t *testing.T) {
 	err := errors.New("some unknown error")
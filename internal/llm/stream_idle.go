@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrStreamIdle is returned when a model stream sends no chunk within the idle timeout
+var ErrStreamIdle = errors.New("stream idle timeout")
+
+// WithStreamIdleTimeout wraps provider so that the streams of the chat models
+// it creates fail when no chunk arrives for timeout, instead of hanging on a
+// dead connection. The stalled request is cancelled. A stall before the first
+// chunk fails Stream itself with ErrStreamIdle, which WithRetry classifies as
+// retryable; a stall after chunks were delivered ends the stream with
+// ErrStreamIdle, since the output already read cannot be taken back. A timeout
+// of 0 returns provider unchanged.
+func WithStreamIdleTimeout(provider Provider, timeout time.Duration) Provider {
+	if timeout <= 0 {
+		return provider
+	}
+	return &idleTimeoutProvider{Provider: provider, timeout: timeout}
+}
+
+type idleTimeoutProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+func (p *idleTimeoutProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	chatModel, err := p.Provider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &idleTimeoutModel{ChatModel: chatModel, timeout: p.timeout}, nil
+}
+
+// idleTimeoutModel cancels the stalled streams of the wrapped chat model
+type idleTimeoutModel struct {
+	model.ChatModel
+	timeout time.Duration
+}
+
+// streamItem is one result of Recv on a model stream
+type streamItem struct {
+	msg *schema.Message
+	err error
+}
+
+func (m *idleTimeoutModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := m.ChatModel.Stream(streamCtx, input, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Wait for the first chunk here, so a request that never starts
+	// answering is retried like one that failed
+	items := receive(streamCtx, stream)
+	chunk, err := m.next(ctx, items)
+	if err != nil && !errors.Is(err, io.EOF) {
+		cancel()
+		return nil, err
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer writer.Close()
+		defer cancel()
+
+		for err == nil {
+			if closed := writer.Send(chunk, nil); closed {
+				return
+			}
+			chunk, err = m.next(ctx, items)
+		}
+		if !errors.Is(err, io.EOF) {
+			writer.Send(nil, err)
+		}
+	}()
+	return reader, nil
+}
+
+// next waits for the next item of a stream, failing with ErrStreamIdle when
+// none arrives within the idle timeout. The end of the stream is io.EOF.
+func (m *idleTimeoutModel) next(ctx context.Context, items <-chan streamItem) (*schema.Message, error) {
+	timer := time.NewTimer(m.timeout)
+	defer timer.Stop()
+	select {
+	case item := <-items:
+		return item.msg, item.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: no response from the model for %s", ErrStreamIdle, m.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// receive reads stream in the background until it ends, fails or ctx is
+// cancelled, so that reads can be abandoned when the stream stalls
+func receive(ctx context.Context, stream *schema.StreamReader[*schema.Message]) <-chan streamItem {
+	items := make(chan streamItem)
+	go func() {
+		defer stream.Close()
+		for {
+			msg, err := stream.Recv()
+			select {
+			case items <- streamItem{msg: msg, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return items
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stallingChatModel streams chunks and then stalls until its request is
+// cancelled. Requests after the first stalls answer normally.
+type stallingChatModel struct {
+	fakeChatModel
+	chunks    []string
+	stalls    int // Number of requests that stall
+	calls     atomic.Int32
+	cancelled atomic.Int32
+}
+
+func (m *stallingChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	call := int(m.calls.Add(1))
+	reader, writer := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer writer.Close()
+		for _, chunk := range m.chunks {
+			writer.Send(schema.AssistantMessage(chunk, nil), nil)
+		}
+		if call > m.stalls {
+			return
+		}
+		<-ctx.Done()
+		m.cancelled.Add(1)
+		writer.Send(nil, ctx.Err())
+	}()
+	return reader, nil
+}
+
+func readAll(stream *schema.StreamReader[*schema.Message]) (string, error) {
+	defer stream.Close()
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return content, nil
+		}
+		if err != nil {
+			return content, err
+		}
+		content += chunk.Content
+	}
+}
+
+func TestWithStreamIdleTimeout_Disabled(t *testing.T) {
+	provider := &fakeProvider{chatModel: &fakeChatModel{reply: "hello"}}
+	assert.Same(t, Provider(provider), WithStreamIdleTimeout(provider, 0))
+}
+
+func TestWithStreamIdleTimeout_PassesChunksThrough(t *testing.T) {
+	inner := &stallingChatModel{chunks: []string{"hel", "lo"}}
+	chatModel, err := WithStreamIdleTimeout(&fakeProvider{chatModel: inner}, time.Second).CreateChatModel(context.Background())
+	require.NoError(t, err)
+
+	stream, err := chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", content)
+}
+
+func TestWithStreamIdleTimeout_RetriesStallBeforeFirstChunk(t *testing.T) {
+	inner := &stallingChatModel{stalls: 1}
+	chatModel, err := WithStreamIdleTimeout(&fakeProvider{chatModel: inner}, 20*time.Millisecond).CreateChatModel(context.Background())
+	require.NoError(t, err)
+
+	retry := RetryConfig{Enabled: true, MaxAttempts: 2}
+	stream, err := WithRetryResult(context.Background(), retry, func() (*schema.StreamReader[*schema.Message], error) {
+		return chatModel.Stream(context.Background(), nil)
+	})
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+	assert.Equal(t, int32(2), inner.calls.Load())
+	assert.Eventually(t, func() bool { return inner.cancelled.Load() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestWithStreamIdleTimeout_FailsStallMidStream(t *testing.T) {
+	inner := &stallingChatModel{chunks: []string{"partial"}, stalls: 1}
+	chatModel, err := WithStreamIdleTimeout(&fakeProvider{chatModel: inner}, 20*time.Millisecond).CreateChatModel(context.Background())
+	require.NoError(t, err)
+
+	stream, err := chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	content, err := readAll(stream)
+	assert.Equal(t, "partial", content)
+	assert.ErrorIs(t, err, ErrStreamIdle)
+	assert.Equal(t, ErrorTypeRetryable, ClassifyError(err))
+	assert.Eventually(t, func() bool { return inner.cancelled.Load() == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,262 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// Transcript entry types
+const (
+	TranscriptRun      = "run"      // Command, version and model of the run
+	TranscriptTools    = "tools"    // Tools bound to the model
+	TranscriptRequest  = "request"  // Messages sent to the model
+	TranscriptResponse = "response" // Message returned by the model
+	TranscriptError    = "error"    // A model call failed
+	TranscriptEnd      = "end"      // The run finished; Error is set when it failed
+)
+
+// transcriptFlushTimeout bounds the wait for streamed responses still being recorded on Close
+const transcriptFlushTimeout = 5 * time.Second
+
+// RunInfo describes the run a transcript belongs to
+type RunInfo struct {
+	Command  string   `json:"command"`
+	Args     []string `json:"args,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	WorkDir  string   `json:"work_dir,omitempty"`
+}
+
+// TranscriptEntry is one line of a transcript file
+type TranscriptEntry struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Call int       `json:"call,omitempty"` // Model call the entry belongs to, starting at 1
+	// Offset is the index of the first message of a request. Messages already
+	// recorded for the previous call are not repeated; a request starting at 0
+	// after earlier calls means the history was rewritten, e.g. compressed.
+	Offset     int               `json:"offset,omitempty"`
+	Messages   []*schema.Message `json:"messages,omitempty"`
+	Tools      []string          `json:"tools,omitempty"`
+	Run        *RunInfo          `json:"run,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"` // Provider's ID of the request, when reported
+}
+
+// Transcript records every request to and response from the model during a
+// run as JSON lines. Entries are written as they happen, so failed and
+// interrupted runs keep everything up to the failure.
+type Transcript struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	calls   int
+	sent    []*schema.Message // Messages of the previous request
+	pending sync.WaitGroup    // Streamed responses still being recorded
+}
+
+// NewTranscript creates the transcript file at path and records the run header
+func NewTranscript(path string, run RunInfo) (*Transcript, error) {
+	if err := sideeffect.Check("write transcript"); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	// Transcripts contain source code and prompts, so keep them private
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %w", err)
+	}
+
+	t := &Transcript{file: file, encoder: json.NewEncoder(file)}
+	if err := t.write(TranscriptEntry{Type: TranscriptRun, Run: &run}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Path returns the path of the transcript file
+func (t *Transcript) Path() string {
+	return t.file.Name()
+}
+
+// Close waits for responses still being streamed, records the outcome of the
+// run and closes the file. runErr is the error the run failed with, if any.
+func (t *Transcript) Close(runErr error) error {
+	done := make(chan struct{})
+	go func() {
+		t.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(transcriptFlushTimeout):
+	}
+
+	entry := TranscriptEntry{Type: TranscriptEnd}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	err := t.write(entry)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if closeErr := t.file.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close transcript: %w", closeErr)
+	}
+	return err
+}
+
+func (t *Transcript) write(entry TranscriptEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// recordRequest records the messages of a model call that were not part of
+// the previous call and returns the call number
+func (t *Transcript) recordRequest(input []*schema.Message) int {
+	t.mu.Lock()
+	t.calls++
+	call := t.calls
+	offset := 0
+	if len(input) >= len(t.sent) {
+		offset = len(t.sent)
+		for i, msg := range t.sent {
+			if input[i] != msg {
+				offset = 0
+				break
+			}
+		}
+	}
+	t.sent = append(t.sent[:0], input...)
+	t.mu.Unlock()
+
+	_ = t.write(TranscriptEntry{Type: TranscriptRequest, Call: call, Offset: offset, Messages: input[offset:]})
+	return call
+}
+
+// recordResult records the response of a model call, or its error. requestID
+// is the provider's ID of the call when known from a failed stream.
+func (t *Transcript) recordResult(call int, msg *schema.Message, err error, start time.Time, requestID string) {
+	entry := TranscriptEntry{Type: TranscriptResponse, Call: call, DurationMs: time.Since(start).Milliseconds(), RequestID: requestID}
+	if err != nil {
+		entry.Type = TranscriptError
+		entry.Error = err.Error()
+	} else {
+		entry.Messages = []*schema.Message{msg}
+		if id := RequestID(msg); id != "" {
+			entry.RequestID = id
+		}
+	}
+	_ = t.write(entry)
+}
+
+// WithTranscript wraps provider so that the chat models it creates record
+// their calls in t
+func WithTranscript(provider Provider, t *Transcript) Provider {
+	return &transcriptProvider{Provider: provider, transcript: t}
+}
+
+type transcriptProvider struct {
+	Provider
+	transcript *Transcript
+}
+
+func (p *transcriptProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	chatModel, err := p.Provider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &transcriptModel{ChatModel: chatModel, transcript: p.transcript}, nil
+}
+
+// transcriptModel records the calls of the wrapped chat model
+type transcriptModel struct {
+	model.ChatModel
+	transcript *Transcript
+}
+
+func (m *transcriptModel) BindTools(tools []*schema.ToolInfo) error {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	_ = m.transcript.write(TranscriptEntry{Type: TranscriptTools, Tools: names})
+	return m.ChatModel.BindTools(tools)
+}
+
+func (m *transcriptModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	call := m.transcript.recordRequest(input)
+	start := time.Now()
+	msg, err := m.ChatModel.Generate(ctx, input, opts...)
+	m.transcript.recordResult(call, msg, err, start, "")
+	return msg, err
+}
+
+func (m *transcriptModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	call := m.transcript.recordRequest(input)
+	start := time.Now()
+	stream, err := m.ChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		m.transcript.recordResult(call, nil, err, start, "")
+		return nil, err
+	}
+
+	// Forward the chunks unchanged and record the full response before the
+	// caller sees the end of the stream, so entries stay in order
+	reader, writer := schema.Pipe[*schema.Message](1)
+	m.transcript.pending.Add(1)
+	go func() {
+		defer m.transcript.pending.Done()
+		defer writer.Close()
+		defer stream.Close()
+
+		var chunks []*schema.Message
+		var requestID string
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				m.transcript.recordResult(call, nil, err, start, requestID)
+				writer.Send(nil, err)
+				return
+			}
+			if requestID == "" {
+				requestID = RequestID(chunk)
+			}
+			chunks = append(chunks, chunk)
+			if closed := writer.Send(chunk, nil); closed {
+				// The caller stopped reading; keep what was received
+				break
+			}
+		}
+		msg, err := schema.ConcatMessages(chunks)
+		m.transcript.recordResult(call, msg, err, start, requestID)
+	}()
+	return reader, nil
+}
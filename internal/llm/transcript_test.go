@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChatModel streams its reply in two chunks and fails Generate
+type fakeChatModel struct {
+	reply string
+}
+
+func (m *fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return nil, errors.New("rate limited")
+}
+
+func (m *fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	half := len(m.reply) / 2
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage(m.reply[:half], nil),
+		schema.AssistantMessage(m.reply[half:], nil),
+	}), nil
+}
+
+func (m *fakeChatModel) BindTools(tools []*schema.ToolInfo) error {
+	return nil
+}
+
+type fakeProvider struct {
+	chatModel model.ChatModel
+}
+
+func (p *fakeProvider) Name() string                  { return "fake" }
+func (p *fakeProvider) GetConfig() config.ModelConfig { return config.ModelConfig{Provider: "fake"} }
+func (p *fakeProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return p.chatModel, nil
+}
+
+func readTranscript(t *testing.T, path string) []TranscriptEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []TranscriptEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry TranscriptEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestTranscript(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "transcripts", "run.jsonl")
+	transcript, err := NewTranscript(path, RunInfo{Command: "gitbuddy review", Model: "test-model"})
+	require.NoError(t, err)
+
+	provider := WithTranscript(&fakeProvider{chatModel: &fakeChatModel{reply: "Looks good"}}, transcript)
+	chatModel, err := provider.CreateChatModel(ctx)
+	require.NoError(t, err)
+	require.NoError(t, chatModel.BindTools([]*schema.ToolInfo{{Name: "read_file"}}))
+
+	// The caller still receives the streamed chunks
+	messages := []*schema.Message{schema.SystemMessage("You review code"), schema.UserMessage("Review this")}
+	stream, err := chatModel.Stream(ctx, messages)
+	require.NoError(t, err)
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		content += chunk.Content
+	}
+	stream.Close()
+	assert.Equal(t, "Looks good", content)
+
+	// Only the new messages of a follow-up call are recorded
+	messages = append(messages, schema.AssistantMessage(content, nil), schema.UserMessage("Thanks"))
+	_, err = chatModel.Generate(ctx, messages)
+	require.Error(t, err)
+
+	// A rewritten history is recorded in full
+	_, _ = chatModel.Generate(ctx, []*schema.Message{schema.UserMessage("Summary")})
+
+	require.NoError(t, transcript.Close(errors.New("review failed")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries := readTranscript(t, path)
+	types := make([]string, len(entries))
+	for i, e := range entries {
+		types[i] = e.Type
+	}
+	assert.Equal(t, []string{
+		TranscriptRun, TranscriptTools,
+		TranscriptRequest, TranscriptResponse,
+		TranscriptRequest, TranscriptError,
+		TranscriptRequest, TranscriptError,
+		TranscriptEnd,
+	}, types)
+
+	assert.Equal(t, "gitbuddy review", entries[0].Run.Command)
+	assert.Equal(t, []string{"read_file"}, entries[1].Tools)
+	assert.Len(t, entries[2].Messages, 2)
+	assert.Equal(t, "Looks good", entries[3].Messages[0].Content)
+	assert.Equal(t, 2, entries[4].Offset)
+	assert.Equal(t, "Thanks", entries[4].Messages[1].Content)
+	assert.Equal(t, "rate limited", entries[5].Error)
+	assert.Equal(t, 3, entries[6].Call)
+	assert.Equal(t, 0, entries[6].Offset)
+	assert.Equal(t, "review failed", entries[8].Error)
+}
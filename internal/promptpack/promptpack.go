@@ -0,0 +1,401 @@
+// Package promptpack installs shareable prompt packs (commit styles, review
+// rulesets, personas) from git repositories into ~/.gitbuddy/prompts.
+package promptpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// ManifestName is the optional manifest at the root of a pack repository
+	ManifestName = "gitbuddy-pack.yaml"
+	// lockFileName records the installed packs and their pinned commits
+	lockFileName = "packs.json"
+)
+
+// PromptKeys are the prompt_extensions keys a pack can provide
+var PromptKeys = []string{"all", "commit", "review", "pr", "report", "debug", "chat", "explain", "gen-tests", "plan-refactor"}
+
+var packNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Manifest describes a pack. Prompts maps prompt keys to files relative to
+// the pack root; without a manifest, <key>.md files in the root or in a
+// prompts/ directory are used.
+type Manifest struct {
+	Name        string            `mapstructure:"name"`
+	Description string            `mapstructure:"description"`
+	Version     string            `mapstructure:"version"`
+	Prompts     map[string]string `mapstructure:"prompts"`
+}
+
+// Pack is a loaded prompt pack
+type Pack struct {
+	Name        string
+	Description string
+	Version     string
+	Prompts     map[string]string // Prompt key -> prompt text
+}
+
+// InstalledPack is a pack recorded in the lock file
+type InstalledPack struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Ref         string    `json:"ref,omitempty"` // Pinned tag, branch or commit; empty follows the default branch
+	Commit      string    `json:"commit"`
+	InstalledAt time.Time `json:"installed_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Manager manages the packs installed in a directory
+type Manager struct {
+	dir string
+}
+
+// NewManager creates a Manager for the packs in dir
+func NewManager(dir string) *Manager {
+	return &Manager{dir: dir}
+}
+
+// DefaultDir returns ~/.gitbuddy/prompts
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".gitbuddy", "prompts"), nil
+}
+
+// Dir returns the directory the packs are installed in
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Install clones a pack from url, checks out ref (default branch when empty)
+// and records the resolved commit. The name defaults to the manifest name or
+// the repository name.
+func (m *Manager) Install(ctx context.Context, url, ref, name string) (*InstalledPack, error) {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(m.dir, ".install-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	checkout := filepath.Join(tmpDir, "pack")
+	if _, err := runGit(ctx, tmpDir, "clone", "--quiet", url, checkout); err != nil {
+		return nil, fmt.Errorf("failed to clone prompt pack: %w", err)
+	}
+	commit, err := checkoutRef(ctx, checkout, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := LoadPack(checkout)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = pack.Name
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(strings.TrimRight(url, "/")), ".git")
+	}
+	if !packNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid prompt pack name: %q (use --name)", name)
+	}
+
+	target := filepath.Join(m.dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("prompt pack %s is already installed (use gitbuddy prompts update %s)", name, name)
+	}
+	if err := os.Rename(checkout, target); err != nil {
+		return nil, fmt.Errorf("failed to install prompt pack: %w", err)
+	}
+
+	now := time.Now()
+	installed := InstalledPack{Name: name, URL: url, Ref: ref, Commit: commit, InstalledAt: now, UpdatedAt: now}
+	if err := m.saveEntry(installed); err != nil {
+		return nil, err
+	}
+	return &installed, nil
+}
+
+// Update fetches an installed pack and checks out ref. An empty ref keeps
+// the pinned ref, or follows the default branch when none is pinned.
+func (m *Manager) Update(ctx context.Context, name, ref string) (*InstalledPack, error) {
+	installed, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = installed.Ref
+	}
+
+	checkout := filepath.Join(m.dir, name)
+	if _, err := runGit(ctx, checkout, "fetch", "--quiet", "--tags", "origin"); err != nil {
+		return nil, fmt.Errorf("failed to fetch prompt pack: %w", err)
+	}
+
+	previous := installed.Commit
+	commit, err := checkoutRef(ctx, checkout, ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := LoadPack(checkout); err != nil {
+		// Keep the previous working version
+		_, _ = runGit(ctx, checkout, "checkout", "--quiet", previous)
+		return nil, err
+	}
+
+	installed.Ref = ref
+	installed.Commit = commit
+	installed.UpdatedAt = time.Now()
+	if err := m.saveEntry(*installed); err != nil {
+		return nil, err
+	}
+	return installed, nil
+}
+
+// Remove uninstalls a pack
+func (m *Manager) Remove(name string) error {
+	if _, err := m.Get(name); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(m.dir, name)); err != nil {
+		return fmt.Errorf("failed to remove prompt pack: %w", err)
+	}
+
+	packs, err := m.List()
+	if err != nil {
+		return err
+	}
+	kept := packs[:0]
+	for _, p := range packs {
+		if p.Name != name {
+			kept = append(kept, p)
+		}
+	}
+	return m.saveLock(kept)
+}
+
+// List returns the installed packs sorted by name
+func (m *Manager) List() ([]InstalledPack, error) {
+	data, err := os.ReadFile(filepath.Join(m.dir, lockFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt pack lock file: %w", err)
+	}
+
+	var packs []InstalledPack
+	if err := json.Unmarshal(data, &packs); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt pack lock file: %w", err)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// Get returns the lock entry of an installed pack
+func (m *Manager) Get(name string) (*InstalledPack, error) {
+	packs, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt pack %s is not installed (run gitbuddy prompts install <git-url> --name %s)", name, name)
+}
+
+// Load loads an installed pack
+func (m *Manager) Load(name string) (*Pack, error) {
+	if _, err := m.Get(name); err != nil {
+		return nil, err
+	}
+	pack, err := LoadPack(filepath.Join(m.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	pack.Name = name
+	return pack, nil
+}
+
+// LoadPack reads the prompts of a pack checked out in dir
+func LoadPack(dir string) (*Pack, error) {
+	var manifest Manifest
+	manifestPath := filepath.Join(dir, ManifestName)
+	if _, err := os.Stat(manifestPath); err == nil {
+		if manifestPath, err = packFile(dir, ManifestName); err != nil {
+			return nil, err
+		}
+		v := viper.New()
+		v.SetConfigFile(manifestPath)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ManifestName, err)
+		}
+		if err := v.Unmarshal(&manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", ManifestName, err)
+		}
+	}
+
+	pack := &Pack{
+		Name:        manifest.Name,
+		Description: manifest.Description,
+		Version:     manifest.Version,
+		Prompts:     make(map[string]string),
+	}
+
+	files := manifest.Prompts
+	if len(files) == 0 {
+		files = conventionalPromptFiles(dir)
+	}
+	for key, file := range files {
+		if !isPromptKey(key) {
+			return nil, fmt.Errorf("unknown prompt key %q in prompt pack (valid: %s)", key, strings.Join(PromptKeys, ", "))
+		}
+		path, err := packFile(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt %s: %w", file, err)
+		}
+		if text := strings.TrimSpace(string(content)); text != "" {
+			pack.Prompts[key] = text
+		}
+	}
+
+	if len(pack.Prompts) == 0 {
+		return nil, fmt.Errorf("prompt pack contains no prompts (expected %s or files like review.md)", ManifestName)
+	}
+	return pack, nil
+}
+
+// packFile returns the path of file in the pack checked out in dir, with
+// symlinks resolved. Files outside the pack are refused: a shared pack could
+// otherwise link a prompt to e.g. ~/.ssh/id_rsa and have it sent to the model.
+func packFile(dir, file string) (string, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve prompt pack directory: %w", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Clean("/"+file)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt %s: %w", file, err)
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("prompt %s links outside the prompt pack", file)
+	}
+	return path, nil
+}
+
+// conventionalPromptFiles finds <key>.md files in the root or prompts/ directory
+func conventionalPromptFiles(dir string) map[string]string {
+	files := make(map[string]string)
+	for _, key := range PromptKeys {
+		for _, candidate := range []string{key + ".md", filepath.Join("prompts", key+".md")} {
+			if _, err := os.Stat(filepath.Join(dir, candidate)); err == nil {
+				files[key] = candidate
+				break
+			}
+		}
+	}
+	return files
+}
+
+func isPromptKey(key string) bool {
+	for _, k := range PromptKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// saveEntry adds or replaces a pack in the lock file
+func (m *Manager) saveEntry(entry InstalledPack) error {
+	packs, err := m.List()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range packs {
+		if packs[i].Name == entry.Name {
+			packs[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		packs = append(packs, entry)
+	}
+	return m.saveLock(packs)
+}
+
+func (m *Manager) saveLock(packs []InstalledPack) error {
+	if packs == nil {
+		packs = []InstalledPack{}
+	}
+	data, err := json.MarshalIndent(packs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompt pack lock file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, lockFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write prompt pack lock file: %w", err)
+	}
+	return nil
+}
+
+// checkoutRef checks out ref (the remote default branch when empty) and
+// returns the resolved commit
+func checkoutRef(ctx context.Context, dir, ref string) (string, error) {
+	target := ref
+	if target == "" {
+		target = "origin/HEAD"
+	} else if _, err := runGit(ctx, dir, "rev-parse", "--verify", "--quiet", "origin/"+ref+"^{commit}"); err == nil {
+		// Branches are pinned to the fetched remote state
+		target = "origin/" + ref
+	}
+
+	if _, err := runGit(ctx, dir, "-c", "advice.detachedHead=false", "checkout", "--quiet", "--detach", target); err != nil {
+		return "", fmt.Errorf("failed to check out %s: %w", target, err)
+	}
+	commit, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve commit: %w", err)
+	}
+	return commit, nil
+}
+
+// runGit runs a git command in dir and returns the trimmed output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
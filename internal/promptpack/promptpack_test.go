@@ -0,0 +1,147 @@
+package promptpack
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// setupPackRepo creates a pack repository with a v1 tag and a newer commit on main
+func setupPackRepo(t *testing.T) string {
+	t.Helper()
+
+	repo := t.TempDir()
+	gitCmd(t, repo, "init", "--quiet", "-b", "main")
+	gitCmd(t, repo, "config", "user.email", "test@example.com")
+	gitCmd(t, repo, "config", "user.name", "Test User")
+
+	writeFile(t, filepath.Join(repo, "review.md"), "Review v1")
+	writeFile(t, filepath.Join(repo, "prompts", "commit.md"), "Use gitmoji")
+	gitCmd(t, repo, "add", "-A")
+	gitCmd(t, repo, "commit", "--quiet", "-m", "v1")
+	gitCmd(t, repo, "tag", "v1")
+
+	writeFile(t, filepath.Join(repo, "review.md"), "Review v2")
+	gitCmd(t, repo, "commit", "--quiet", "-am", "v2")
+	return repo
+}
+
+func TestManager_InstallUpdateRemove(t *testing.T) {
+	repo := setupPackRepo(t)
+	mgr := NewManager(filepath.Join(t.TempDir(), "prompts"))
+	ctx := context.Background()
+
+	installed, err := mgr.Install(ctx, repo, "v1", "team")
+	require.NoError(t, err)
+	assert.Equal(t, "team", installed.Name)
+	assert.Equal(t, "v1", installed.Ref)
+	assert.Len(t, installed.Commit, 40)
+
+	pack, err := mgr.Load("team")
+	require.NoError(t, err)
+	assert.Equal(t, "Review v1", pack.Prompts["review"], "pinned to the tag")
+	assert.Equal(t, "Use gitmoji", pack.Prompts["commit"])
+
+	_, err = mgr.Install(ctx, repo, "", "team")
+	assert.ErrorContains(t, err, "already installed")
+
+	// Updating without a ref keeps the pin
+	updated, err := mgr.Update(ctx, "team", "")
+	require.NoError(t, err)
+	assert.Equal(t, installed.Commit, updated.Commit)
+
+	updated, err = mgr.Update(ctx, "team", "main")
+	require.NoError(t, err)
+	assert.NotEqual(t, installed.Commit, updated.Commit)
+	pack, err = mgr.Load("team")
+	require.NoError(t, err)
+	assert.Equal(t, "Review v2", pack.Prompts["review"])
+
+	packs, err := mgr.List()
+	require.NoError(t, err)
+	require.Len(t, packs, 1)
+	assert.Equal(t, "main", packs[0].Ref)
+
+	require.NoError(t, mgr.Remove("team"))
+	packs, err = mgr.List()
+	require.NoError(t, err)
+	assert.Empty(t, packs)
+	_, err = mgr.Load("team")
+	assert.ErrorContains(t, err, "not installed")
+}
+
+func TestManager_InstallDefaultName(t *testing.T) {
+	repo := setupPackRepo(t)
+	writeFile(t, filepath.Join(repo, ManifestName), "name: acme-style\nprompts:\n  chat: persona.txt\n")
+	writeFile(t, filepath.Join(repo, "persona.txt"), "You are a pirate.")
+	gitCmd(t, repo, "add", "-A")
+	gitCmd(t, repo, "commit", "--quiet", "-m", "manifest")
+
+	mgr := NewManager(t.TempDir())
+	installed, err := mgr.Install(context.Background(), repo, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-style", installed.Name)
+
+	pack, err := mgr.Load("acme-style")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"chat": "You are a pirate."}, pack.Prompts, "the manifest replaces conventional files")
+}
+
+func TestLoadPack_Errors(t *testing.T) {
+	empty := t.TempDir()
+	_, err := LoadPack(empty)
+	assert.ErrorContains(t, err, "no prompts")
+
+	badKey := t.TempDir()
+	writeFile(t, filepath.Join(badKey, ManifestName), "prompts:\n  deploy: deploy.md\n")
+	_, err = LoadPack(badKey)
+	assert.ErrorContains(t, err, "unknown prompt key")
+
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, ManifestName), "prompts:\n  review: ../../etc/passwd\n")
+	_, err = LoadPack(outside)
+	assert.Error(t, err, "paths cannot escape the pack")
+}
+
+func TestLoadPack_Symlinks(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	writeFile(t, secret, "PRIVATE KEY")
+
+	pack := t.TempDir()
+	writeFile(t, filepath.Join(pack, "prompts", "all.md"), "Team rules.")
+	require.NoError(t, os.Symlink(filepath.Join("prompts", "all.md"), filepath.Join(pack, "commit.md")))
+	loaded, err := LoadPack(pack)
+	require.NoError(t, err, "links within the pack are followed")
+	assert.Equal(t, "Team rules.", loaded.Prompts["commit"])
+
+	require.NoError(t, os.Symlink(secret, filepath.Join(pack, "review.md")))
+	_, err = LoadPack(pack)
+	assert.EqualError(t, err, "prompt review.md links outside the prompt pack")
+
+	manifest := t.TempDir()
+	writeFile(t, filepath.Join(manifest, "review.md"), "Check errors.")
+	require.NoError(t, os.Symlink(secret, filepath.Join(manifest, ManifestName)))
+	_, err = LoadPack(manifest)
+	assert.EqualError(t, err, "prompt "+ManifestName+" links outside the prompt pack")
+}
@@ -0,0 +1,134 @@
+// Package redact removes sensitive identifiers from generated output before it
+// leaves the machine, such as PR descriptions and reports posted externally.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Default replacements per category
+const (
+	HostnameReplacement   = "[REDACTED-HOST]"
+	UsernameReplacement   = "[REDACTED-USER]"
+	IdentifierReplacement = "[REDACTED-ID]"
+	PatternReplacement    = "[REDACTED]"
+)
+
+// Rules lists what to redact
+type Rules struct {
+	// Hostnames are matched case-insensitively; a leading "*." matches any subdomain
+	Hostnames []string
+	// Usernames are matched case-insensitively as whole words
+	Usernames []string
+	// Identifiers (customer names, project codes, ...) are matched case-insensitively as whole words
+	Identifiers []string
+	// Patterns are regular expressions matched as-is
+	Patterns []string
+	// Replacement overrides the per-category replacement text
+	Replacement string
+}
+
+// rule is a compiled redaction rule
+type rule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Redactor applies compiled redaction rules to text
+type Redactor struct {
+	rules []rule
+}
+
+// NewRedactor compiles the rules into a Redactor
+func NewRedactor(rules Rules) (*Redactor, error) {
+	r := &Redactor{}
+	add := func(pattern, replacement string) {
+		if rules.Replacement != "" {
+			replacement = rules.Replacement
+		}
+		r.rules = append(r.rules, rule{re: regexp.MustCompile(pattern), replacement: replacement})
+	}
+
+	// Longer literals first so "build.corp.example.com" wins over "example.com"
+	for _, host := range sortedByLength(rules.Hostnames) {
+		if strings.HasPrefix(host, "*.") {
+			add(`(?i)\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.`+regexp.QuoteMeta(host[2:])+`\b`, HostnameReplacement)
+		} else {
+			add(`(?i)`+wordPattern(host), HostnameReplacement)
+		}
+	}
+	for _, user := range sortedByLength(rules.Usernames) {
+		add(`(?i)`+wordPattern(user), UsernameReplacement)
+	}
+	for _, id := range sortedByLength(rules.Identifiers) {
+		add(`(?i)`+wordPattern(id), IdentifierReplacement)
+	}
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		replacement := PatternReplacement
+		if rules.Replacement != "" {
+			replacement = rules.Replacement
+		}
+		r.rules = append(r.rules, rule{re: re, replacement: replacement})
+	}
+	return r, nil
+}
+
+// Redact returns the text with every match replaced, and the number of replacements
+func (r *Redactor) Redact(text string) (string, int) {
+	if r == nil || text == "" {
+		return text, 0
+	}
+
+	count := 0
+	for _, rule := range r.rules {
+		text = rule.re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return rule.replacement
+		})
+	}
+	return text, count
+}
+
+// String redacts text, discarding the replacement count
+func (r *Redactor) String(text string) string {
+	redacted, _ := r.Redact(text)
+	return redacted
+}
+
+// wordPattern matches a literal as a whole word. Word boundaries are only
+// required on edges that are word characters, so "@jdoe" still matches.
+func wordPattern(literal string) string {
+	pattern := regexp.QuoteMeta(literal)
+	if isWordByte(literal[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordByte(literal[len(literal)-1]) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// sortedByLength returns non-empty entries sorted longest first
+func sortedByLength(items []string) []string {
+	var sorted []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			sorted = append(sorted, item)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	return sorted
+}
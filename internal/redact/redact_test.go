@@ -0,0 +1,85 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	redactor, err := NewRedactor(Rules{
+		Hostnames:   []string{"*.corp.example.com", "jenkins.internal"},
+		Usernames:   []string{"jdoe", "@asmith"},
+		Identifiers: []string{"Acme Bank"},
+		Patterns:    []string{`CUST-\d{4}`},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		count int
+	}{
+		{
+			name:  "wildcard hostname",
+			input: "Deployed to build-01.eu.corp.example.com today",
+			want:  "Deployed to [REDACTED-HOST] today",
+			count: 1,
+		},
+		{
+			name:  "exact hostname is case-insensitive",
+			input: "See JENKINS.internal/job/1",
+			want:  "See [REDACTED-HOST]/job/1",
+			count: 1,
+		},
+		{
+			name:  "usernames match whole words only",
+			input: "Reviewed by jdoe and @asmith, not jdoes",
+			want:  "Reviewed by [REDACTED-USER] and [REDACTED-USER], not jdoes",
+			count: 2,
+		},
+		{
+			name:  "identifiers and patterns",
+			input: "Fix for acme bank (CUST-1234)",
+			want:  "Fix for [REDACTED-ID] ([REDACTED])",
+			count: 2,
+		},
+		{
+			name:  "no matches",
+			input: "example.com is public",
+			want:  "example.com is public",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := redactor.Redact(tt.input)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.count, count)
+		})
+	}
+}
+
+func TestRedactor_Replacement(t *testing.T) {
+	redactor, err := NewRedactor(Rules{
+		Usernames:   []string{"jdoe"},
+		Patterns:    []string{`\d{3}-\d{4}`},
+		Replacement: "***",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "*** called ***", redactor.String("jdoe called 555-0100"))
+}
+
+func TestNewRedactor_InvalidPattern(t *testing.T) {
+	_, err := NewRedactor(Rules{Patterns: []string{"("}})
+	assert.ErrorContains(t, err, "invalid redaction pattern")
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var redactor *Redactor
+	got, count := redactor.Redact("unchanged")
+	assert.Equal(t, "unchanged", got)
+	assert.Zero(t, count)
+}
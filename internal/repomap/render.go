@@ -0,0 +1,147 @@
+package repomap
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// symbolCaps are the symbols listed per directory, tried in order until the
+// rendered map fits (-1 = all)
+var symbolCaps = []int{-1, 12, 6, 3, 0}
+
+// directory aggregates the files of one directory
+type directory struct {
+	path     string
+	files    int
+	packages []string
+	types    []string // Types, interfaces and classes
+	funcs    []string
+}
+
+// Render formats the map for a system prompt: entry points first, then one
+// line per directory with its Go packages and key declarations. Symbol lists
+// and then directories are trimmed until the result fits in maxChars
+// (0 = no limit).
+func (m *Map) Render(maxChars int) string {
+	if m == nil || len(m.Files) == 0 {
+		return ""
+	}
+
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var entries []string
+	byDir := make(map[string]*directory)
+	var dirs []*directory
+	for _, p := range paths {
+		file := m.Files[p]
+		if file.Entry {
+			entries = append(entries, p)
+		}
+		dirPath := path.Dir(p)
+		dir := byDir[dirPath]
+		if dir == nil {
+			dir = &directory{path: dirPath}
+			byDir[dirPath] = dir
+			dirs = append(dirs, dir)
+		}
+		dir.files++
+		if file.Package != "" && !contains(dir.packages, file.Package) {
+			dir.packages = append(dir.packages, file.Package)
+		}
+		for _, symbol := range file.Symbols {
+			if symbol.Kind == "func" {
+				dir.funcs = append(dir.funcs, symbol.Name)
+			} else {
+				dir.types = append(dir.types, symbol.Name)
+			}
+		}
+	}
+
+	header := fmt.Sprintf("%d source files in %d directories.\n", len(paths), len(dirs))
+	if len(entries) > 0 {
+		header += "Entry points: " + strings.Join(entries, ", ") + "\n"
+	}
+
+	var lines []string
+	for _, limit := range symbolCaps {
+		lines = lines[:0]
+		for _, dir := range dirs {
+			lines = append(lines, dir.render(limit))
+		}
+		if fits(header, lines, maxChars) {
+			return header + strings.Join(lines, "")
+		}
+	}
+
+	// Even bare directory lines don't fit; keep as many as possible
+	for len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+		more := fmt.Sprintf("- ... %d more directories\n", len(dirs)-len(lines))
+		if fits(header, append(lines[:len(lines):len(lines)], more), maxChars) {
+			return header + strings.Join(lines, "") + more
+		}
+	}
+	return header
+}
+
+// render formats a directory line with at most limit types and limit
+// functions (-1 = all)
+func (d *directory) render(limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- %s/ (%d files", d.path, d.files)
+	if len(d.packages) > 0 {
+		fmt.Fprintf(&b, ", package %s", strings.Join(d.packages, ", "))
+	}
+	b.WriteString(")")
+	if limit != 0 {
+		if types := capNames(d.types, limit); types != "" {
+			b.WriteString(" types: " + types)
+			if len(d.funcs) > 0 {
+				b.WriteString(";")
+			}
+		}
+		if funcs := capNames(d.funcs, limit); funcs != "" {
+			b.WriteString(" funcs: " + funcs)
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// capNames joins up to limit names (-1 = all), noting how many were left out
+func capNames(names []string, limit int) string {
+	if len(names) == 0 {
+		return ""
+	}
+	if limit < 0 || len(names) <= limit {
+		return strings.Join(names, ", ")
+	}
+	return strings.Join(names[:limit], ", ") + fmt.Sprintf(", +%d more", len(names)-limit)
+}
+
+// fits reports whether the header and lines fit in maxChars (0 = no limit)
+func fits(header string, lines []string, maxChars int) bool {
+	if maxChars <= 0 {
+		return true
+	}
+	size := len(header)
+	for _, line := range lines {
+		size += len(line)
+	}
+	return size <= maxChars
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+// Package repomap maintains a lightweight map of a repository (directories,
+// Go packages, key types and functions, entry points) cached in
+// .gitbuddy/repomap.json. Runs only re-index the files whose size or
+// modification time changed, so keeping the map current is cheap.
+package repomap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+)
+
+// DefaultPath is where the map is cached, relative to the repository root
+const DefaultPath = ".gitbuddy/repomap.json"
+
+// formatVersion is bumped when the cached format or the indexing changes, so
+// old caches are rebuilt
+const formatVersion = 1
+
+// maxFileSize skips generated and vendored giants
+const maxFileSize = 512 * 1024
+
+// skippedDirs hold dependencies and fixtures rather than the project's code
+var skippedDirs = map[string]bool{"vendor": true, "node_modules": true, "testdata": true, "third_party": true}
+
+// pythonMainGuard marks a Python script
+var pythonMainGuard = regexp.MustCompile(`(?m)^if\s+__name__\s*==\s*['"]__main__['"]\s*:`)
+
+// Symbol is a key declaration of a file
+type Symbol struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // type, func, class, interface, ...
+}
+
+// File is the indexed summary of one source file
+type File struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Package string    `json:"package,omitempty"` // Go package name
+	Symbols []Symbol  `json:"symbols,omitempty"`
+	Entry   bool      `json:"entry,omitempty"` // Program entry point, e.g. Go func main
+}
+
+// Map is the repository map, keyed by slash-separated path relative to the
+// repository root
+type Map struct {
+	Version   int              `json:"version"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Files     map[string]*File `json:"files"`
+}
+
+// Load reads a cached map. A missing, unreadable or outdated cache yields an
+// empty map, which the next Update rebuilds.
+func Load(path string) *Map {
+	empty := &Map{Version: formatVersion, Files: make(map[string]*File)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Debug("Failed to read repository map: %v", err)
+		}
+		return empty
+	}
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil || m.Version != formatVersion || m.Files == nil {
+		return empty
+	}
+	return &m
+}
+
+// Save writes the map to path, replacing the previous file atomically
+func (m *Map) Save(path string) error {
+	if err := sideeffect.Check("save repository map"); err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode repository map: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create repository map directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write repository map: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write repository map: %w", err)
+	}
+	return nil
+}
+
+// Update re-indexes the source files of workDir that were added or changed
+// since the map was built and drops the ones that are gone. It returns the
+// number of files re-indexed or dropped.
+func (m *Map) Update(ctx context.Context, workDir string) (int, error) {
+	paths, err := sourceFiles(ctx, workDir)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	present := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return changed, err
+		}
+		info, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(p)))
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+			continue
+		}
+		present[p] = true
+		if cached, ok := m.Files[p]; ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		file := indexFile(p, content)
+		file.Size = info.Size()
+		file.ModTime = info.ModTime()
+		m.Files[p] = file
+		changed++
+	}
+	for p := range m.Files {
+		if !present[p] {
+			delete(m.Files, p)
+			changed++
+		}
+	}
+	if changed > 0 {
+		m.UpdatedAt = time.Now()
+	}
+	return changed, nil
+}
+
+// Refresh loads the map cached in workDir, updates it and saves it when
+// anything changed
+func Refresh(ctx context.Context, workDir string) (*Map, error) {
+	cachePath := filepath.Join(workDir, filepath.FromSlash(DefaultPath))
+	m := Load(cachePath)
+	changed, err := m.Update(ctx, workDir)
+	if err != nil {
+		return nil, err
+	}
+	// The updated map is still used in check mode, it just isn't cached
+	if changed > 0 && !sideeffect.Blocked() {
+		log.Debug("Repository map: %d file(s) re-indexed", changed)
+		if err := m.Save(cachePath); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+// sourceFiles lists the indexable files of workDir, using git to respect
+// .gitignore and falling back to walking the directory outside a repository
+func sourceFiles(ctx context.Context, workDir string) ([]string, error) {
+	files, err := git.ListFiles(ctx, workDir)
+	if err != nil {
+		log.Debug("Falling back to walking %s: %v", workDir, err)
+		files = nil
+		walkErr := filepath.WalkDir(workDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if p != workDir && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if rel, err := filepath.Rel(workDir, p); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, fmt.Errorf("failed to list files: %w", walkErr)
+		}
+	}
+
+	var sources []string
+	for _, f := range files {
+		if indexable(f) {
+			sources = append(sources, f)
+		}
+	}
+	return sources, nil
+}
+
+// indexable reports whether a file is a source file outside dependency and
+// fixture directories
+func indexable(p string) bool {
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if skippedDirs[dir] {
+			return false
+		}
+	}
+	base := path.Base(p)
+	if strings.HasSuffix(base, "_test.go") || strings.HasSuffix(base, ".min.js") || strings.Contains(base, ".pb.") {
+		return false
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".go", ".py", ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".java", ".c", ".h", ".cc", ".cpp", ".hpp",
+		".cs", ".rs", ".kt", ".kts", ".swift", ".php", ".scala", ".dart":
+		return true
+	}
+	return false
+}
+
+// indexFile extracts the key declarations of a source file
+func indexFile(p string, content []byte) *File {
+	if path.Ext(p) == ".go" {
+		if file, ok := indexGoFile(content); ok {
+			return file
+		}
+	}
+
+	file := &File{}
+	for _, symbol := range tools.ExtractOutline(p, content) {
+		// Nested declarations and methods are too fine-grained for a map
+		if symbol.Kind == "method" || strings.HasPrefix(symbol.Name, "_") || strings.HasPrefix(symbol.Name, "(") {
+			continue
+		}
+		if symbol.Name == "main" && symbol.Kind == "func" {
+			file.Entry = true
+		}
+		file.Symbols = append(file.Symbols, Symbol{Name: symbol.Name, Kind: symbol.Kind})
+	}
+	if path.Ext(p) == ".py" && pythonMainGuard.Match(content) {
+		file.Entry = true
+	}
+	return file
+}
+
+// indexGoFile records the package and its exported types and functions
+func indexGoFile(content []byte) (*File, bool) {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+
+	file := &File{Package: parsed.Name.Name}
+	for _, decl := range parsed.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil {
+				continue
+			}
+			if parsed.Name.Name == "main" && d.Name.Name == "main" {
+				file.Entry = true
+			}
+			if d.Name.IsExported() {
+				file.Symbols = append(file.Symbols, Symbol{Name: d.Name.Name, Kind: "func"})
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				kind := "type"
+				if _, ok := ts.Type.(*ast.InterfaceType); ok {
+					kind = "interface"
+				}
+				file.Symbols = append(file.Symbols, Symbol{Name: ts.Name.Name, Kind: kind})
+			}
+		}
+	}
+	return file, true
+}
@@ -0,0 +1,110 @@
+package repomap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+}
+
+func TestRefresh(t *testing.T) {
+	// Not a git repository, so the directory is walked
+	workDir := t.TempDir()
+	writeFile(t, workDir, "cmd/tool/main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, workDir, "internal/store/store.go", `package store
+
+type Store struct{}
+
+type Reader interface{ Read() }
+
+func New() *Store { return &Store{} }
+
+func (s *Store) Get() {}
+
+func helper() {}
+`)
+	writeFile(t, workDir, "internal/store/store_test.go", "package store\n")
+	writeFile(t, workDir, "scripts/sync.py", "def run():\n    pass\n\nif __name__ == '__main__':\n    run()\n")
+	writeFile(t, workDir, "vendor/dep/dep.go", "package dep\n")
+	writeFile(t, workDir, "README.md", "# Tool\n")
+
+	ctx := context.Background()
+	m, err := Refresh(ctx, workDir)
+	require.NoError(t, err)
+	assert.Len(t, m.Files, 3)
+	assert.True(t, m.Files["cmd/tool/main.go"].Entry)
+	assert.True(t, m.Files["scripts/sync.py"].Entry)
+	store := m.Files["internal/store/store.go"]
+	assert.Equal(t, "store", store.Package)
+	assert.Equal(t, []Symbol{{"Store", "type"}, {"Reader", "interface"}, {"New", "func"}}, store.Symbols)
+	assert.FileExists(t, filepath.Join(workDir, DefaultPath))
+
+	// Unchanged files are not re-indexed
+	cached := Load(filepath.Join(workDir, DefaultPath))
+	changed, err := cached.Update(ctx, workDir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	// Changed and deleted files are
+	writeFile(t, workDir, "internal/store/store.go", "package store\n\nfunc Open() {}\n")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(workDir, "internal/store/store.go"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(workDir, "scripts/sync.py")))
+	changed, err = cached.Update(ctx, workDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	assert.Equal(t, []Symbol{{"Open", "func"}}, cached.Files["internal/store/store.go"].Symbols)
+	assert.NotContains(t, cached.Files, "scripts/sync.py")
+}
+
+func TestLoad_InvalidCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "repomap.json")
+	assert.Empty(t, Load(cachePath).Files)
+
+	require.NoError(t, os.WriteFile(cachePath, []byte(`{"version": 0, "files": {"a.go": {}}}`), 0644))
+	assert.Empty(t, Load(cachePath).Files, "outdated caches are rebuilt")
+
+	require.NoError(t, os.WriteFile(cachePath, []byte("{"), 0644))
+	assert.Empty(t, Load(cachePath).Files)
+}
+
+func TestMap_Render(t *testing.T) {
+	m := &Map{Files: map[string]*File{
+		"cmd/tool/main.go":    {Package: "main", Entry: true},
+		"internal/store/a.go": {Package: "store", Symbols: []Symbol{{"Store", "type"}, {"New", "func"}, {"Open", "func"}}},
+		"internal/store/b.go": {Package: "store", Symbols: []Symbol{{"Reader", "interface"}}},
+		"web/app.ts":          {Symbols: []Symbol{{"App", "class"}}},
+	}}
+
+	full := m.Render(0)
+	assert.Equal(t, `4 source files in 3 directories.
+Entry points: cmd/tool/main.go
+- cmd/tool/ (1 files, package main)
+- internal/store/ (2 files, package store) types: Store, Reader; funcs: New, Open
+- web/ (1 files) types: App
+`, full)
+
+	// Symbols are trimmed first
+	trimmed := m.Render(len(full) - 1)
+	assert.Contains(t, trimmed, "- internal/store/ (2 files, package store)\n")
+	assert.LessOrEqual(t, len(trimmed), len(full)-1)
+
+	// Then directories
+	tiny := m.Render(120)
+	assert.LessOrEqual(t, len(tiny), 120)
+	assert.True(t, strings.HasSuffix(tiny, "more directories\n"), tiny)
+
+	assert.Empty(t, (&Map{}).Render(0))
+}
@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSummaryLength bounds the summary of a report included in a new session
+const maxSummaryLength = 600
+
+// stopWords are too common in issue descriptions to relate reports
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "when": true, "after": true, "from": true,
+	"that": true, "this": true, "not": true, "are": true, "was": true, "but": true, "into": true,
+	"error": true, "issue": true, "fails": true, "failing": true, "failed": true, "wrong": true,
+	"does": true, "doesn": true, "can": true, "cannot": true, "returns": true, "sometimes": true,
+}
+
+// summaryHeadings mark the section that best summarizes a report
+var summaryHeadings = []string{"summary", "root cause", "conclusion", "总结", "根因"}
+
+// Related returns up to limit reports whose title, issue or files share
+// keywords with issue. A report must match at least two keywords, or every
+// keyword of a short issue, so a single common word doesn't relate reports.
+func Related(reports []*Report, issue string, limit int) []Match {
+	keywords := Keywords(issue)
+	if len(keywords) == 0 {
+		return nil
+	}
+	required := 2
+	if len(keywords) < required {
+		required = len(keywords)
+	}
+
+	var matches []Match
+	for _, report := range reports {
+		heading := strings.ToLower(report.Title + " " + report.Issue + " " + strings.Join(report.Files, " "))
+		body := strings.ToLower(report.Body)
+		matched, score := 0, 0
+		for _, keyword := range keywords {
+			if strings.Contains(heading, keyword) {
+				matched++
+				score += 3
+			} else if strings.Contains(body, keyword) {
+				score++
+			}
+		}
+		if matched >= required {
+			matches = append(matches, Match{Report: report, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Keywords returns the distinct significant words of text, lowercased
+func Keywords(text string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.'
+	})
+	for _, word := range words {
+		word = strings.Trim(word, ".")
+		if len([]rune(word)) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
+
+// Summary returns the summary or root cause section of a report, or its
+// first paragraph, shortened to fit in a prompt
+func Summary(report *Report) string {
+	lines := strings.Split(report.Body, "\n")
+	start := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+		heading := strings.ToLower(line)
+		for _, marker := range summaryHeadings {
+			if strings.Contains(heading, marker) {
+				start = i + 1
+				break
+			}
+		}
+		if start >= 0 {
+			break
+		}
+	}
+
+	var section []string
+	if start >= 0 {
+		for _, line := range lines[start:] {
+			if strings.HasPrefix(line, "#") {
+				break
+			}
+			section = append(section, line)
+		}
+	} else {
+		// First paragraph that isn't a heading
+		for _, line := range lines {
+			if strings.HasPrefix(line, "#") || (strings.TrimSpace(line) == "" && len(section) == 0) {
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				break
+			}
+			section = append(section, line)
+		}
+	}
+
+	summary := strings.TrimSpace(strings.Join(section, "\n"))
+	if runes := []rune(summary); len(runes) > maxSummaryLength {
+		summary = string(runes[:maxSummaryLength-3]) + "..."
+	}
+	return summary
+}
@@ -0,0 +1,41 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeywords(t *testing.T) {
+	assert.Equal(t, []string{"login", "500", "auth.go", "token_cache"},
+		Keywords("Login fails with 500 error in auth.go (token_cache), Login"))
+	assert.Empty(t, Keywords("it is an error"))
+}
+
+func TestRelated(t *testing.T) {
+	saved := []*Report{
+		{ID: 3, Metadata: Metadata{Title: "Checkout page slow", Files: []string{"web/checkout.ts"}}},
+		{ID: 2, Metadata: Metadata{Title: "Login fails after token refresh", Issue: "Login returns 500"}, Body: "The token cache expired early."},
+		{ID: 1, Metadata: Metadata{Title: "Login button misaligned"}},
+	}
+
+	matches := Related(saved, "Login fails with 500 after deploy", 5)
+	require.Len(t, matches, 1, "one shared keyword is not enough")
+	assert.Equal(t, 2, matches[0].Report.ID)
+
+	matches = Related(saved, "checkout", 5)
+	require.Len(t, matches, 1, "short issues need every keyword")
+	assert.Equal(t, 3, matches[0].Report.ID)
+
+	assert.Len(t, Related(saved, "login token", 1), 1)
+	assert.Empty(t, Related(saved, "the error", 5))
+}
+
+func TestSummary(t *testing.T) {
+	report := &Report{Body: "# Login fails\n\nIntro paragraph.\n\n## Root Cause\nThe token cache\nexpired early.\n\n## Fix\nRefresh sooner.\n"}
+	assert.Equal(t, "The token cache\nexpired early.", Summary(report))
+
+	report = &Report{Body: "# Login fails\n\nIntro paragraph\ncontinues.\n\nMore.\n"}
+	assert.Equal(t, "Intro paragraph\ncontinues.", Summary(report))
+}
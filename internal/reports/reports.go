@@ -0,0 +1,118 @@
+// Package reports reads and writes the debug reports saved in the issues
+// directory. Each report starts with YAML front matter describing the
+// investigation, so saved reports can be listed and searched later.
+package reports
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// frontMatterDelimiter opens and closes the front matter block
+const frontMatterDelimiter = "---"
+
+// reportFilePattern matches report file names: issue-<id>-<slug>-<date>.md
+var reportFilePattern = regexp.MustCompile(`^issue-(\d+)-.*?(\d{4}-\d{2}-\d{2})?\.md$`)
+
+// Metadata is the front matter of a report
+type Metadata struct {
+	Title   string   `yaml:"title"`
+	Date    string   `yaml:"date"`
+	Issue   string   `yaml:"issue,omitempty"`   // The issue as the user described it
+	Session string   `yaml:"session,omitempty"` // Session the report came from
+	Files   []string `yaml:"files,omitempty"`   // Files read during the investigation
+	Phases  []string `yaml:"phases,omitempty"`  // Debugging phases, in the order they were entered
+}
+
+// Report is a saved report
+type Report struct {
+	Metadata
+	ID   int
+	Path string
+	Body string // Report content without the front matter
+}
+
+// Format prepends meta as front matter to content
+func Format(meta Metadata, content string) (string, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report metadata: %w", err)
+	}
+	return frontMatterDelimiter + "\n" + string(header) + frontMatterDelimiter + "\n\n" + content, nil
+}
+
+// Parse splits a report into its front matter and body. Reports saved
+// before front matter was written yield empty metadata and the whole content.
+func Parse(data []byte) (Metadata, string) {
+	var meta Metadata
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(content, frontMatterDelimiter+"\n") {
+		return meta, content
+	}
+	rest := content[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end < 0 {
+		return meta, content
+	}
+	if err := yaml.Unmarshal([]byte(rest[:end]), &meta); err != nil {
+		return Metadata{}, content
+	}
+	return meta, strings.TrimLeft(rest[end+len(frontMatterDelimiter)+2:], "\n")
+}
+
+// Load reads the reports saved in dir, newest first. A missing directory has
+// no reports.
+func Load(dir string) ([]*Report, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read issues directory: %w", err)
+	}
+
+	var reports []*Report
+	for _, entry := range entries {
+		matches := reportFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read report %s: %w", entry.Name(), err)
+		}
+		meta, body := Parse(data)
+		report := &Report{Metadata: meta, Path: path, Body: body}
+		report.ID, _ = strconv.Atoi(matches[1])
+		if report.Title == "" {
+			report.Title = firstHeading(body, entry.Name())
+		}
+		if report.Date == "" {
+			report.Date = matches[2]
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID > reports[j].ID })
+	return reports, nil
+}
+
+// firstHeading returns the first markdown heading of body, or fallback
+func firstHeading(body, fallback string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "#") {
+			if title := strings.TrimSpace(strings.TrimLeft(line, "#")); title != "" {
+				return title
+			}
+		}
+	}
+	return fallback
+}
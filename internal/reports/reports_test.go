@@ -0,0 +1,62 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAndParse(t *testing.T) {
+	meta := Metadata{Title: "Login fails", Date: "2026-10-16", Session: "debug-1", Files: []string{"auth.go"}, Phases: []string{"problem_definition", "reporting"}}
+	content, err := Format(meta, "# Login fails\n\nRoot cause: expired key\n")
+	require.NoError(t, err)
+
+	parsed, body := Parse([]byte(content))
+	assert.Equal(t, meta, parsed)
+	assert.Equal(t, "# Login fails\n\nRoot cause: expired key\n", body)
+
+	// Reports saved before front matter was written
+	parsed, body = Parse([]byte("# Old report\n---\n"))
+	assert.Equal(t, Metadata{}, parsed)
+	assert.Equal(t, "# Old report\n---\n", body)
+}
+
+func TestLoadAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	login, err := Format(Metadata{Title: "Login fails", Date: "2026-10-01", Files: []string{"internal/auth/token.go"}},
+		"# Login fails\n\nThe token cache returned expired tokens.\n")
+	require.NoError(t, err)
+	write("issue-001-login-fails-2026-10-01.md", login)
+	write("issue-002-cache-misses-2026-10-05.md", "# Cache misses after deploy\n\nThe cache key ignored the tenant.\n")
+	write("batch-2026-10-05-120000.md", "# Batch Debug Report\n")
+	write("notes.txt", "cache")
+
+	reports, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, 2, reports[0].ID, "newest first")
+	assert.Equal(t, "Cache misses after deploy", reports[0].Title, "title from the first heading")
+	assert.Equal(t, "2026-10-05", reports[0].Date, "date from the file name")
+	assert.Equal(t, "Login fails", reports[1].Title)
+
+	matches := Search(reports, "cache")
+	require.Len(t, matches, 2)
+	assert.Equal(t, 2, matches[0].Report.ID, "title hits rank first")
+	assert.Equal(t, []string{"# Cache misses after deploy", "The cache key ignored the tenant."}, matches[0].Snippets)
+
+	matches = Search(reports, "TOKEN auth")
+	require.Len(t, matches, 1, "every term must match")
+	assert.Equal(t, 1, matches[0].Report.ID)
+
+	assert.Empty(t, Search(reports, "  "))
+
+	missing, err := Load(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
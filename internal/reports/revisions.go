@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+)
+
+// revisionHistoryHeading starts the revision history section that
+// "gitbuddy debug edit" keeps at the end of a report
+const revisionHistoryHeading = "## Revision History"
+
+// Revision is an edit of a saved report
+type Revision struct {
+	Date   string // e.g. "2026-10-16 14:05"
+	Change string // The modification the user asked for
+}
+
+// SplitRevisions splits a report body into its content and the revisions
+// listed in its revision history section
+func SplitRevisions(body string) (string, []Revision) {
+	idx := strings.LastIndex(body, "\n"+revisionHistoryHeading+"\n")
+	if idx < 0 {
+		return body, nil
+	}
+
+	var revisions []Revision
+	for _, line := range strings.Split(body[idx+len(revisionHistoryHeading)+2:], "\n") {
+		cells := strings.Split(strings.Trim(strings.TrimSpace(line), "|"), "|")
+		if len(cells) < 2 {
+			continue
+		}
+		date := strings.TrimSpace(cells[0])
+		change := strings.TrimSpace(strings.Join(cells[1:], "|"))
+		if date == "Date" || strings.Trim(date, "-: ") == "" {
+			continue // Table header and separator
+		}
+		revisions = append(revisions, Revision{Date: date, Change: strings.ReplaceAll(change, `\|`, "|")})
+	}
+	return strings.TrimRight(body[:idx], "\n") + "\n", revisions
+}
+
+// AppendRevisions ends content with a revision history section listing
+// revisions, oldest first
+func AppendRevisions(content string, revisions []Revision) string {
+	if len(revisions) == 0 {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n\n" + revisionHistoryHeading + "\n\n")
+	b.WriteString("| Date | Change |\n|------|--------|\n")
+	for _, r := range revisions {
+		change := strings.ReplaceAll(strings.Join(strings.Fields(r.Change), " "), "|", `\|`)
+		fmt.Fprintf(&b, "| %s | %s |\n", r.Date, change)
+	}
+	return b.String()
+}
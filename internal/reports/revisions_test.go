@@ -0,0 +1,29 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	content, revisions := SplitRevisions("# Login fails\n\nRoot cause: expired key\n")
+	assert.Equal(t, "# Login fails\n\nRoot cause: expired key\n", content)
+	assert.Empty(t, revisions)
+
+	body := AppendRevisions("# Login fails\n\nRoot cause: expired key\n", []Revision{
+		{Date: "2026-10-15 09:30", Change: "Add the\nstack trace"},
+		{Date: "2026-10-16 14:05", Change: "Compare a|b"},
+	})
+	assert.Contains(t, body, "| 2026-10-15 09:30 | Add the stack trace |\n")
+	assert.Contains(t, body, `| 2026-10-16 14:05 | Compare a\|b |`)
+
+	content, revisions = SplitRevisions(body)
+	assert.Equal(t, "# Login fails\n\nRoot cause: expired key\n", content)
+	assert.Equal(t, []Revision{
+		{Date: "2026-10-15 09:30", Change: "Add the stack trace"},
+		{Date: "2026-10-16 14:05", Change: "Compare a|b"},
+	}, revisions)
+
+	assert.Equal(t, "# Report\n", AppendRevisions("# Report\n", nil))
+}
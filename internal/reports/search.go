@@ -0,0 +1,79 @@
+package reports
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSnippets is the number of matching lines shown per report
+const maxSnippets = 2
+
+// maxSnippetLength keeps snippets to one terminal line
+const maxSnippetLength = 120
+
+// Match is a report matching a search
+type Match struct {
+	Report   *Report
+	Score    int
+	Snippets []string // Body lines containing a search term
+}
+
+// Search returns the reports containing every term of query, matched
+// case-insensitively against the title, issue, files and body. Title and
+// file hits rank above body hits; ties keep the newest report first.
+func Search(reports []*Report, query string) []Match {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for _, report := range reports {
+		title := strings.ToLower(report.Title + " " + report.Issue)
+		files := strings.ToLower(strings.Join(report.Files, " "))
+		body := strings.ToLower(report.Body)
+
+		score := 0
+		for _, term := range terms {
+			hits := 5*strings.Count(title, term) + 3*strings.Count(files, term) + strings.Count(body, term)
+			if hits == 0 {
+				score = 0
+				break
+			}
+			score += hits
+		}
+		if score > 0 {
+			matches = append(matches, Match{Report: report, Score: score, Snippets: snippets(report.Body, terms)})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// snippets returns the first body lines containing any of terms
+func snippets(body string, terms []string) []string {
+	var result []string
+	for _, line := range strings.Split(body, "\n") {
+		lower := strings.ToLower(line)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				result = append(result, truncate(strings.TrimSpace(line)))
+				break
+			}
+		}
+		if len(result) == maxSnippets {
+			break
+		}
+	}
+	return result
+}
+
+// truncate shortens a line to maxSnippetLength runes
+func truncate(line string) string {
+	runes := []rune(line)
+	if len(runes) <= maxSnippetLength {
+		return line
+	}
+	return string(runes[:maxSnippetLength-3]) + "..."
+}
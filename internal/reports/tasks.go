@@ -0,0 +1,202 @@
+package reports
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"go.yaml.in/yaml/v3"
+)
+
+// DefaultTasksPath is where the follow-up tasks of reports are kept,
+// relative to the repository
+const DefaultTasksPath = ".gitbuddy/tasks.yaml"
+
+// Kinds of follow-up tasks
+const (
+	TaskSolution   = "solution"
+	TaskPrevention = "prevention"
+)
+
+// Task statuses
+const (
+	TaskOpen = "open"
+	TaskDone = "done"
+)
+
+// taskHeadings map words of section headings to the kind of the tasks listed
+// in the section. Prevention is checked first, so "Preventing regressions"
+// isn't taken for a solution.
+var taskHeadings = []struct {
+	kind  string
+	words []string
+}{
+	{TaskPrevention, []string{"prevent", "预防", "再発防止"}},
+	{TaskSolution, []string{"solution", "fix", "recommendation", "implementation", "action item", "next step", "解决", "修复", "建议", "対策", "解決"}},
+}
+
+// headingPattern matches a Markdown heading
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+
+// listItemPattern matches a list item, an optional checkbox and its text
+var listItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.+)$`)
+
+// Task is an action item of a report
+type Task struct {
+	ID      string    `yaml:"id"`
+	Title   string    `yaml:"title"`
+	Kind    string    `yaml:"kind"`
+	Status  string    `yaml:"status"`
+	Report  string    `yaml:"report,omitempty"` // Path of the report the task comes from
+	Created time.Time `yaml:"created"`
+	Issue   string    `yaml:"issue,omitempty"` // URL of the tracker issue filed for the task
+}
+
+// ExtractTasks returns the items listed under the solution and prevention
+// sections of a report, e.g. "## Solutions" or "### Prevention Measures",
+// including their nested implementation steps. Subsections stay part of the
+// section they are in.
+func ExtractTasks(content string) []Task {
+	var tasks []Task
+	kind, level := "", 0
+	seen := make(map[string]bool)
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			if kind == "" || len(m[1]) <= level {
+				kind, level = taskKind(m[2]), len(m[1])
+			}
+			continue
+		}
+		if kind == "" {
+			continue
+		}
+		m := listItemPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		title := taskTitle(m[1])
+		if title == "" || seen[title] {
+			continue
+		}
+		seen[title] = true
+		tasks = append(tasks, Task{Title: title, Kind: kind, Status: TaskOpen})
+	}
+	return tasks
+}
+
+// taskKind returns the kind of the tasks listed under heading, "" for other
+// sections
+func taskKind(heading string) string {
+	heading = strings.ToLower(heading)
+	for _, h := range taskHeadings {
+		for _, word := range h.words {
+			if strings.Contains(heading, word) {
+				return h.kind
+			}
+		}
+	}
+	return ""
+}
+
+// taskTitle strips the emphasis of a list item and placeholders such as
+// "[Detailed approach]" left from the report template, labelled or not
+func taskTitle(item string) string {
+	title := strings.TrimSpace(strings.NewReplacer("**", "", "__", "").Replace(item))
+	text := title
+	if i := strings.LastIndex(title, ": "); i >= 0 {
+		text = title[i+2:]
+	}
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		return ""
+	}
+	return title
+}
+
+// TaskList is the tasks file
+type TaskList struct {
+	Tasks []Task `yaml:"tasks"`
+}
+
+// LoadTasks reads the tasks file at path; a missing file has no tasks
+func LoadTasks(path string) (*TaskList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &TaskList{}, nil
+		}
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	var list TaskList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &list, nil
+}
+
+// Add appends the tasks of the report at reportPath not in the list yet and
+// returns the pointers to the added tasks, so that callers can record the
+// issues filed for them
+func (l *TaskList) Add(reportPath string, tasks []Task, now time.Time) []*Task {
+	known := make(map[string]bool, len(l.Tasks))
+	for _, task := range l.Tasks {
+		known[task.ID] = true
+	}
+	start := len(l.Tasks)
+	for _, task := range tasks {
+		task.Report = filepath.ToSlash(reportPath)
+		task.ID = taskID(task.Report, task.Title)
+		if known[task.ID] {
+			continue
+		}
+		known[task.ID] = true
+		task.Created = now
+		if task.Status == "" {
+			task.Status = TaskOpen
+		}
+		l.Tasks = append(l.Tasks, task)
+	}
+	added := make([]*Task, 0, len(l.Tasks)-start)
+	for i := start; i < len(l.Tasks); i++ {
+		added = append(added, &l.Tasks[i])
+	}
+	return added
+}
+
+// Save writes the list to path, creating its directory
+func (l *TaskList) Save(path string) error {
+	if err := sideeffect.Check("save follow-up tasks"); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode tasks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create tasks directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	return nil
+}
+
+// taskID identifies a task by its report and title, so extracting the tasks
+// of a report again doesn't add them twice
+func taskID(report, title string) string {
+	sum := sha256.Sum256([]byte(report + "\n" + title))
+	return hex.EncodeToString(sum[:])[:8]
+}
@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const taskReport = `# Login fails after deploy
+
+## Problem Description
+- Users get a 500 on login
+
+## Solutions
+- **Solution 1**: Refresh the signing key on rotation
+  1. Watch the key file in ` + "`auth/keys.go`" + `
+  2. Reload the verifier when it changes
+- **Solution 2**: [Alternative approach]
+
+### Rollout
+- [ ] Deploy behind the ` + "`key_reload`" + ` flag
+
+` + "```sh\n- not a task\n```" + `
+
+## Verification Plan
+- Rotate the key in staging
+
+## Prevention Measures
+* Alert on signature failures
+* Refresh the signing key on rotation
+`
+
+func TestExtractTasks(t *testing.T) {
+	tasks := ExtractTasks(taskReport)
+	assert.Equal(t, []Task{
+		{Title: "Solution 1: Refresh the signing key on rotation", Kind: TaskSolution, Status: TaskOpen},
+		{Title: "Watch the key file in `auth/keys.go`", Kind: TaskSolution, Status: TaskOpen},
+		{Title: "Reload the verifier when it changes", Kind: TaskSolution, Status: TaskOpen},
+		{Title: "Deploy behind the `key_reload` flag", Kind: TaskSolution, Status: TaskOpen},
+		{Title: "Alert on signature failures", Kind: TaskPrevention, Status: TaskOpen},
+		{Title: "Refresh the signing key on rotation", Kind: TaskPrevention, Status: TaskOpen},
+	}, tasks)
+
+	assert.Empty(t, ExtractTasks("# Notes\n\n- nothing to do\n"))
+	assert.Len(t, ExtractTasks("## 解决方案\n1. 增加重试\n"), 1)
+}
+
+func TestTaskList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitbuddy", "tasks.yaml")
+	list, err := LoadTasks(path)
+	require.NoError(t, err)
+	assert.Empty(t, list.Tasks)
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tasks := ExtractTasks(taskReport)
+	added := list.Add("issues/issue-001-login.md", tasks, now)
+	require.Len(t, added, len(tasks))
+	added[0].Issue = "https://github.com/acme/api/issues/7"
+	require.NoError(t, list.Save(path))
+
+	loaded, err := LoadTasks(path)
+	require.NoError(t, err)
+	assert.Equal(t, list.Tasks, loaded.Tasks)
+	assert.Equal(t, "https://github.com/acme/api/issues/7", loaded.Tasks[0].Issue)
+	assert.Equal(t, "issues/issue-001-login.md", loaded.Tasks[0].Report)
+	assert.Len(t, loaded.Tasks[0].ID, 8)
+
+	// Extracting the same report again adds nothing, another report does
+	assert.Empty(t, loaded.Add("issues/issue-001-login.md", tasks, now))
+	assert.Len(t, loaded.Add("issues/issue-002-cache.md", tasks[:1], now), 1)
+}
@@ -0,0 +1,226 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// Method names handled by the server itself
+const (
+	MethodExit          = "exit"
+	MethodCancelRequest = "$/cancelRequest"
+	MethodProgress      = "$/progress"
+)
+
+// HandlerFunc handles a single JSON-RPC method call
+type HandlerFunc func(ctx context.Context, call *Call) (interface{}, error)
+
+// Call represents an in-flight method call
+type Call struct {
+	ID     json.RawMessage
+	Method string
+	Params json.RawMessage
+	server *Server
+}
+
+// ProgressParams is the payload of a $/progress notification
+type ProgressParams struct {
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Message string          `json:"message"`
+}
+
+// Progress sends a $/progress notification tied to this call
+func (c *Call) Progress(message string) {
+	if c.server == nil || message == "" {
+		return
+	}
+	_ = c.server.Notify(MethodProgress, ProgressParams{ID: c.ID, Method: c.Method, Message: message})
+}
+
+// ProgressWriter returns a writer that forwards everything written to it as progress notifications
+func (c *Call) ProgressWriter() io.Writer {
+	return progressWriter{call: c}
+}
+
+// DecodeParams decodes the call parameters into v
+func (c *Call) DecodeParams(v interface{}) error {
+	if len(c.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.Params, v); err != nil {
+		return NewError(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+type progressWriter struct {
+	call *Call
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	w.call.Progress(string(p))
+	return len(p), nil
+}
+
+// Server is a JSON-RPC server reading requests from in and writing responses to out
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	writeMu  sync.Mutex
+	handlers map[string]HandlerFunc
+
+	pendingMu sync.Mutex
+	pending   map[string]context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a new Server
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:       bufio.NewReader(in),
+		out:      out,
+		handlers: make(map[string]HandlerFunc),
+		pending:  make(map[string]context.CancelFunc),
+	}
+}
+
+// Register registers a handler for a method
+func (s *Server) Register(method string, handler HandlerFunc) {
+	s.handlers[method] = handler
+}
+
+// Notify sends a notification to the client
+func (s *Server) Notify(method string, params interface{}) error {
+	return s.write(Notification{JSONRPC: Version, Method: method, Params: params})
+}
+
+func (s *Server) write(v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return WriteMessage(s.out, v)
+}
+
+// Serve processes requests until the input is closed or an exit notification
+// is received. Requests run concurrently; in-flight requests are allowed to
+// finish when the input is closed and are cancelled on exit.
+func (s *Server) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for {
+		body, err := ReadMessage(s.in)
+		if err != nil {
+			s.wg.Wait()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			_ = s.write(Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: NewError(CodeParseError, "parse error: %v", err)})
+			continue
+		}
+
+		switch req.Method {
+		case MethodExit:
+			cancel()
+			s.wg.Wait()
+			return nil
+		case MethodCancelRequest:
+			s.cancelRequest(req.Params)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(req Request) {
+			defer s.wg.Done()
+			s.handle(ctx, req)
+		}(req)
+	}
+}
+
+// handle dispatches a single request and writes its response
+func (s *Server) handle(ctx context.Context, req Request) {
+	if req.JSONRPC != Version || req.Method == "" {
+		if !req.IsNotification() {
+			_ = s.write(Response{JSONRPC: Version, ID: req.ID, Error: NewError(CodeInvalidRequest, "invalid request")})
+		}
+		return
+	}
+
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		if !req.IsNotification() {
+			_ = s.write(Response{JSONRPC: Version, ID: req.ID, Error: NewError(CodeMethodNotFound, "method not found: %s", req.Method)})
+		}
+		return
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !req.IsNotification() {
+		key := string(req.ID)
+		s.pendingMu.Lock()
+		s.pending[key] = cancel
+		s.pendingMu.Unlock()
+		defer func() {
+			s.pendingMu.Lock()
+			delete(s.pending, key)
+			s.pendingMu.Unlock()
+		}()
+	}
+
+	call := &Call{ID: req.ID, Method: req.Method, Params: req.Params, server: s}
+	result, err := handler(callCtx, call)
+	if req.IsNotification() {
+		if err != nil {
+			log.Debug("RPC notification %s failed: %v", req.Method, err)
+		}
+		return
+	}
+
+	resp := Response{JSONRPC: Version, ID: req.ID}
+	if err != nil {
+		var rpcErr *Error
+		switch {
+		case errors.As(err, &rpcErr):
+			resp.Error = rpcErr
+		case errors.Is(err, context.Canceled):
+			resp.Error = NewError(CodeRequestCancelled, "request cancelled")
+		default:
+			resp.Error = NewError(CodeInternalError, "%v", err)
+		}
+	} else {
+		resp.Result = result
+	}
+
+	if err := s.write(resp); err != nil {
+		log.Debug("Failed to write RPC response: %v", err)
+	}
+}
+
+// cancelRequest cancels an in-flight request identified by params.id
+func (s *Server) cancelRequest(params json.RawMessage) {
+	var p struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || len(p.ID) == 0 {
+		return
+	}
+
+	s.pendingMu.Lock()
+	cancel, ok := s.pending[string(p.ID)]
+	s.pendingMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frame(t *testing.T, v interface{}) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, WriteMessage(&buf, v))
+	return buf.String()
+}
+
+func readAll(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	reader := bufio.NewReader(out)
+	var messages []map[string]interface{}
+	for {
+		body, err := ReadMessage(reader)
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &msg))
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestReadWriteMessage_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMessage(&buf, map[string]string{"hello": "world"}))
+	assert.True(t, strings.HasPrefix(buf.String(), "Content-Length: 17\r\n\r\n"))
+
+	body, err := ReadMessage(bufio.NewReader(&buf))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"hello":"world"}`, string(body))
+}
+
+func TestReadMessage_MissingContentLength(t *testing.T) {
+	_, err := ReadMessage(bufio.NewReader(strings.NewReader("Content-Type: x\r\n\r\n{}")))
+	assert.Error(t, err)
+}
+
+func TestServer_Dispatch(t *testing.T) {
+	input := frame(t, Request{JSONRPC: Version, ID: json.RawMessage("1"), Method: "echo", Params: json.RawMessage(`{"text":"hi"}`)}) +
+		frame(t, Request{JSONRPC: Version, ID: json.RawMessage("2"), Method: "unknown"}) +
+		"Content-Length: 5\r\n\r\n{bad}"
+
+	var out bytes.Buffer
+	server := NewServer(strings.NewReader(input), &out)
+	server.Register("echo", func(ctx context.Context, call *Call) (interface{}, error) {
+		var params struct {
+			Text string `json:"text"`
+		}
+		if err := call.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		call.Progress("working")
+		return params.Text, nil
+	})
+
+	require.NoError(t, server.Serve(context.Background()))
+
+	byID := make(map[string]map[string]interface{})
+	var progress []map[string]interface{}
+	for _, msg := range readAll(t, &out) {
+		if msg["method"] == MethodProgress {
+			progress = append(progress, msg)
+			continue
+		}
+		byID[fmt.Sprint(msg["id"])] = msg
+	}
+
+	assert.Equal(t, "hi", byID["1"]["result"])
+	assert.EqualValues(t, CodeMethodNotFound, byID["2"]["error"].(map[string]interface{})["code"])
+	assert.EqualValues(t, CodeParseError, byID["<nil>"]["error"].(map[string]interface{})["code"])
+
+	require.Len(t, progress, 1)
+	params := progress[0]["params"].(map[string]interface{})
+	assert.EqualValues(t, 1, params["id"])
+	assert.Equal(t, "working", params["message"])
+}
+
+func TestServer_HandlerErrors(t *testing.T) {
+	input := frame(t, Request{JSONRPC: Version, ID: json.RawMessage("1"), Method: "fail"}) +
+		frame(t, Request{JSONRPC: Version, ID: json.RawMessage("2"), Method: "invalid"})
+
+	var out bytes.Buffer
+	server := NewServer(strings.NewReader(input), &out)
+	server.Register("fail", func(ctx context.Context, call *Call) (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	server.Register("invalid", func(ctx context.Context, call *Call) (interface{}, error) {
+		return nil, NewError(CodeInvalidParams, "bad params")
+	})
+
+	require.NoError(t, server.Serve(context.Background()))
+
+	byID := make(map[string]map[string]interface{})
+	for _, msg := range readAll(t, &out) {
+		byID[fmt.Sprint(msg["id"])] = msg
+	}
+
+	failErr := byID["1"]["error"].(map[string]interface{})
+	assert.EqualValues(t, CodeInternalError, failErr["code"])
+	assert.Equal(t, "boom", failErr["message"])
+	assert.EqualValues(t, CodeInvalidParams, byID["2"]["error"].(map[string]interface{})["code"])
+}
+
+func TestServer_ExitStopsServing(t *testing.T) {
+	input := frame(t, Request{JSONRPC: Version, Method: MethodExit}) +
+		frame(t, Request{JSONRPC: Version, ID: json.RawMessage("1"), Method: "never"})
+
+	var out bytes.Buffer
+	server := NewServer(strings.NewReader(input), &out)
+	require.NoError(t, server.Serve(context.Background()))
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,419 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/agent/session"
+	"github.com/huimingz/gitbuddy-go/internal/codeowners"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/sideeffect"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+)
+
+// Method names exposed by the service
+const (
+	MethodInitialize     = "initialize"
+	MethodShutdown       = "shutdown"
+	MethodGenerateCommit = "generateCommit"
+	MethodReview         = "review"
+	MethodExplainRange   = "explainRange"
+)
+
+// ServiceOptions contains configuration for Service
+type ServiceOptions struct {
+	Config    *config.Config
+	WorkDir   string
+	ModelName string
+	Version   string
+}
+
+// Service implements the editor-facing RPC methods on top of the agents
+type Service struct {
+	opts ServiceOptions
+}
+
+// NewService creates a new Service
+func NewService(opts ServiceOptions) *Service {
+	return &Service{opts: opts}
+}
+
+// Register registers all service methods on the server
+func (s *Service) Register(server *Server) {
+	server.Register(MethodInitialize, s.initialize)
+	server.Register(MethodShutdown, s.shutdown)
+	server.Register(MethodGenerateCommit, s.generateCommit)
+	server.Register(MethodReview, s.review)
+	server.Register(MethodExplainRange, s.explainRange)
+}
+
+// InitializeResult is returned by the initialize method
+type InitializeResult struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Methods []string `json:"methods"`
+}
+
+// GenerateCommitParams are the parameters of generateCommit
+type GenerateCommitParams struct {
+	Language string `json:"language,omitempty"`
+	Context  string `json:"context,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// GenerateCommitResult is returned by generateCommit
+type GenerateCommitResult struct {
+	*agent.CommitInfo
+	Title      string             `json:"title"`
+	Message    string             `json:"message"`
+	TokenUsage session.TokenUsage `json:"token_usage"`
+	Partial    bool               `json:"partial,omitempty"`
+}
+
+// ReviewParams are the parameters of review
+type ReviewParams struct {
+	Language string   `json:"language,omitempty"`
+	Context  string   `json:"context,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Focus    []string `json:"focus,omitempty"`
+	Model    string   `json:"model,omitempty"`
+}
+
+// ReviewResult is returned by review
+type ReviewResult struct {
+	Issues     []agent.ReviewIssue    `json:"issues"`
+	Summary    string                 `json:"summary"`
+	TokenUsage session.TokenUsage     `json:"token_usage"`
+	Partial    bool                   `json:"partial,omitempty"`
+	Owners     []codeowners.Ownership `json:"owners,omitempty"` // Code owners of the reviewed files
+}
+
+// ExplainRangeParams are the parameters of explainRange
+type ExplainRangeParams struct {
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Question  string `json:"question,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// ExplainRangeResult is returned by explainRange
+type ExplainRangeResult struct {
+	Explanation string             `json:"explanation"`
+	TokenUsage  session.TokenUsage `json:"token_usage"`
+}
+
+func (s *Service) initialize(ctx context.Context, call *Call) (interface{}, error) {
+	return InitializeResult{
+		Name:    "gitbuddy",
+		Version: s.opts.Version,
+		Methods: []string{MethodGenerateCommit, MethodReview, MethodExplainRange},
+	}, nil
+}
+
+func (s *Service) shutdown(ctx context.Context, call *Call) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Service) generateCommit(ctx context.Context, call *Call) (interface{}, error) {
+	var params GenerateCommitParams
+	if err := call.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+
+	provider, err := s.createProvider(params.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	gitExec, err := git.NewVCSExecutor(s.opts.WorkDir, s.opts.Config.GetVCS(""))
+	if err != nil {
+		return nil, err
+	}
+	diff, err := gitExec.DiffCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if diff == "" {
+		return nil, fmt.Errorf("no staged changes found")
+	}
+
+	language := s.opts.Config.GetArtifactLanguage(config.ArtifactCommit, params.Language)
+	progress := call.ProgressWriter()
+	commitAgent, err := agent.NewCommitAgent(agent.CommitAgentOptions{
+		Language:             language,
+		GitExecutor:          gitExec,
+		LLMProvider:          provider,
+		Printer:              newProgressPrinter(call),
+		Output:               progress,
+		RetryConfig:          s.retryConfig(),
+		MaxRepeatedToolCalls: s.opts.Config.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         s.opts.Config.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      s.opts.Config.GetPromptExtension("commit"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit agent: %w", err)
+	}
+
+	response, err := commitAgent.GenerateCommitMessage(ctx, agent.CommitRequest{
+		Language: language,
+		Context:  params.Context,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	if response == nil || response.CommitInfo == nil {
+		return nil, fmt.Errorf("no commit message generated")
+	}
+
+	return GenerateCommitResult{
+		CommitInfo: response.CommitInfo,
+		Title:      response.CommitInfo.Title(),
+		Message:    response.CommitInfo.Message(),
+		Partial:    response.Partial,
+		TokenUsage: tokenUsage(response.PromptTokens, response.CompletionTokens, response.TotalTokens),
+	}, nil
+}
+
+func (s *Service) review(ctx context.Context, call *Call) (interface{}, error) {
+	var params ReviewParams
+	if err := call.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+
+	provider, err := s.createProvider(params.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	gitExec, err := git.NewVCSExecutor(s.opts.WorkDir, s.opts.Config.GetVCS(""))
+	if err != nil {
+		return nil, err
+	}
+	diff, err := gitExec.DiffCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if diff == "" {
+		return nil, fmt.Errorf("no staged changes found")
+	}
+
+	language := s.opts.Config.GetArtifactLanguage(config.ArtifactReview, params.Language)
+	reviewCfg := s.opts.Config.GetReviewConfig()
+	rules, err := agent.SeverityRulesFromConfig(reviewCfg.SeverityRules, "review.severity_rules")
+	if err != nil {
+		return nil, err
+	}
+	migrationRules, err := agent.SeverityRulesFromConfig(reviewCfg.Migrations.SeverityRules, "review.migrations.severity_rules")
+	if err != nil {
+		return nil, err
+	}
+	reviewAgent := agent.NewReviewAgent(agent.ReviewAgentOptions{
+		Language:             language,
+		GitExecutor:          gitExec,
+		LLMProvider:          provider,
+		Printer:              newProgressPrinter(call),
+		Output:               call.ProgressWriter(),
+		WorkDir:              s.opts.WorkDir,
+		MaxLinesPerRead:      reviewCfg.MaxLinesPerRead,
+		RetryConfig:          s.retryConfig(),
+		MaxRepeatedToolCalls: s.opts.Config.GetAgentConfig().MaxRepeatedToolCalls,
+		MaxDiffLines:         s.opts.Config.GetAgentConfig().MaxDiffLines,
+		PromptExtension:      s.opts.Config.GetPromptExtension("review"),
+		FunctionContextLines: reviewCfg.FunctionContextMaxLines,
+	})
+
+	reviewedFiles := params.Files
+	if len(reviewedFiles) == 0 {
+		for _, file := range git.DiffFiles(diff) {
+			reviewedFiles = append(reviewedFiles, file.Path)
+		}
+	}
+	owners, conventions, err := s.changeOwners(reviewedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	req := agent.ReviewRequest{
+		Language:         language,
+		Context:          params.Context,
+		Files:            params.Files,
+		Severity:         params.Severity,
+		Focus:            params.Focus,
+		WorkDir:          s.opts.WorkDir,
+		MaxLines:         reviewCfg.MaxLinesPerRead,
+		SeverityRules:    rules,
+		APIBase:          "HEAD",
+		LicensePolicy:    licensePolicy(reviewCfg.License),
+		OwnerConventions: conventions,
+	}
+	response, err := reviewAgent.Review(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review code: %w", err)
+	}
+
+	if migrationFiles := agent.MigrationFiles(reviewedFiles, reviewCfg.Migrations.Paths); len(migrationFiles) > 0 && !reviewCfg.Migrations.Disabled {
+		req.Files = migrationFiles
+		req.SeverityRules = append(rules, migrationRules...)
+		migrationResponse, err := reviewAgent.ReviewMigrations(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to review migrations: %w", err)
+		}
+		response.Merge(migrationResponse, "Migrations")
+	}
+
+	// Record per-run statistics for `gitbuddy review stats`, as the CLI does
+	if !reviewCfg.DisableMetrics && !sideeffect.Blocked() {
+		metrics := agent.NewReviewMetrics(response, len(reviewedFiles))
+		metrics.Timestamp = time.Now()
+		mc := provider.GetConfig()
+		metrics.Model = mc.Provider + "/" + mc.Model
+		if err := agent.RecordReviewMetrics(s.opts.WorkDir, metrics); err != nil {
+			_ = newProgressPrinter(call).PrintError(fmt.Sprintf("Failed to record review metrics: %v", err))
+		}
+	}
+
+	issues := response.Issues
+	if issues == nil {
+		issues = []agent.ReviewIssue{}
+	}
+	return ReviewResult{
+		Issues:     issues,
+		Summary:    response.Summary,
+		Partial:    response.Partial,
+		TokenUsage: tokenUsage(response.PromptTokens, response.CompletionTokens, response.TotalTokens),
+		Owners:     owners,
+	}, nil
+}
+
+// changeOwners returns the code owners of files and their documented conventions
+func (s *Service) changeOwners(files []string) ([]codeowners.Ownership, map[string]string, error) {
+	ownersCfg := s.opts.Config.GetCodeOwnersConfig()
+	if ownersCfg.Disabled {
+		return nil, nil, nil
+	}
+	file, err := codeowners.Load(s.opts.WorkDir, ownersCfg.Path)
+	if err != nil || file == nil {
+		return nil, nil, err
+	}
+	owners := file.Affected(files)
+	return owners, codeowners.Conventions(ownersCfg.Conventions, owners), nil
+}
+
+func (s *Service) explainRange(ctx context.Context, call *Call) (interface{}, error) {
+	var params ExplainRangeParams
+	if err := call.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if params.FilePath == "" {
+		return nil, NewError(CodeInvalidParams, "file_path is required")
+	}
+	if params.StartLine < 0 || (params.EndLine > 0 && params.EndLine < params.StartLine) {
+		return nil, NewError(CodeInvalidParams, "invalid line range: %d-%d", params.StartLine, params.EndLine)
+	}
+
+	// Editors usually send absolute paths; the read tool expects paths relative to the work dir
+	filePath := params.FilePath
+	if filepath.IsAbs(filePath) {
+		if rel, err := filepath.Rel(s.opts.WorkDir, filePath); err == nil {
+			filePath = rel
+		}
+	}
+
+	provider, err := s.createProvider(params.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	language := s.opts.Config.GetArtifactLanguage(config.ArtifactExplain, params.Language)
+	explainAgent := agent.NewExplainAgent(agent.ExplainAgentOptions{
+		Language:        language,
+		LLMProvider:     provider,
+		Printer:         newProgressPrinter(call),
+		MaxLinesPerRead: s.opts.Config.GetReviewConfig().MaxLinesPerRead,
+		RetryConfig:     s.retryConfig(),
+		PromptExtension: s.opts.Config.GetPromptExtension("explain"),
+	})
+
+	response, err := explainAgent.Explain(ctx, agent.ExplainRequest{
+		FilePath:  filePath,
+		StartLine: params.StartLine,
+		EndLine:   params.EndLine,
+		Question:  params.Question,
+		Language:  language,
+		WorkDir:   s.opts.WorkDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain code: %w", err)
+	}
+
+	return ExplainRangeResult{
+		Explanation: response.Explanation,
+		TokenUsage:  tokenUsage(response.PromptTokens, response.CompletionTokens, response.TotalTokens),
+	}, nil
+}
+
+// createProvider creates the LLM provider for a request (request model > server model > config default)
+func (s *Service) createProvider(model string) (llm.Provider, error) {
+	if model == "" {
+		model = s.opts.ModelName
+	}
+	modelConfig, err := s.opts.Config.GetModel(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model config: %w", err)
+	}
+
+	factory := llm.NewProviderFactory()
+	provider, err := factory.Create(*modelConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	fallbacks, err := factory.CreateFallbacks(s.opts.Config, *modelConfig)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout := time.Duration(s.opts.Config.GetRetryConfig().StreamIdleTimeout) * time.Second
+	for i := range fallbacks {
+		fallbacks[i] = llm.WithRequestIDs(llm.WithStreamIdleTimeout(fallbacks[i], idleTimeout))
+	}
+	return llm.WithFallbacks(llm.WithRequestIDs(llm.WithStreamIdleTimeout(provider, idleTimeout)), fallbacks, s.retryConfig(), nil), nil
+}
+
+// retryConfig converts the configured retry settings to llm.RetryConfig
+func (s *Service) retryConfig() llm.RetryConfig {
+	retryConfigPtr := s.opts.Config.GetRetryConfig()
+	return llm.RetryConfig{
+		Enabled:     retryConfigPtr.Enabled,
+		MaxAttempts: retryConfigPtr.MaxAttempts,
+		BackoffBase: retryConfigPtr.BackoffBase,
+		BackoffMax:  retryConfigPtr.BackoffMax,
+	}
+}
+
+// licensePolicy converts the configured license checks
+func licensePolicy(cfg *config.LicenseConfig) *agent.LicensePolicy {
+	if cfg == nil {
+		return nil
+	}
+	return &agent.LicensePolicy{Header: cfg.Header, HeaderPaths: cfg.HeaderPaths, Disallowed: cfg.Disallowed}
+}
+
+// newProgressPrinter creates a printer that forwards agent output as progress notifications
+func newProgressPrinter(call *Call) *ui.StreamPrinter {
+	return ui.NewStreamPrinter(call.ProgressWriter(), ui.WithColor(false))
+}
+
+func tokenUsage(prompt, completion, total int) session.TokenUsage {
+	return session.TokenUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
@@ -0,0 +1,37 @@
+// Package sideeffect enforces check mode: while it is on, every
+// operation that changes the repository, writes files or talks to other
+// services asks Check first and fails instead of going ahead. The checks
+// live in the shared layers (git commands, file writing tools, persisted
+// state), so new commands and tools built on them comply without knowing
+// about check mode.
+package sideeffect
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrBlocked is returned by Check for every side effect in check mode
+var ErrBlocked = errors.New("not allowed in --check mode")
+
+var blocked atomic.Bool
+
+// SetBlocked turns check mode on or off
+func SetBlocked(enabled bool) {
+	blocked.Store(enabled)
+}
+
+// Blocked reports whether check mode is on
+func Blocked() bool {
+	return blocked.Load()
+}
+
+// Check returns an error wrapping ErrBlocked when check mode is on, naming
+// the refused action, e.g. "git commit"
+func Check(action string) error {
+	if blocked.Load() {
+		return fmt.Errorf("%s: %w", action, ErrBlocked)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package sideeffect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	defer SetBlocked(false)
+
+	assert.False(t, Blocked())
+	assert.NoError(t, Check("git commit"))
+
+	SetBlocked(true)
+	assert.True(t, Blocked())
+	err := Check("git commit")
+	assert.True(t, errors.Is(err, ErrBlocked))
+	assert.Equal(t, "git commit: not allowed in --check mode", err.Error())
+
+	SetBlocked(false)
+	assert.NoError(t, Check("git commit"))
+}
@@ -0,0 +1,158 @@
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureEpoch is the date of the first commit of generated repositories, so
+// that their history is the same in every run
+var fixtureEpoch = time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+// GitRepo is a git repository generated for a test in a temporary directory
+type GitRepo struct {
+	Dir string
+
+	t       testing.TB
+	commits int
+}
+
+// NewGitRepo creates an empty repository on branch main, with an identity
+// configured and signing disabled
+func NewGitRepo(t testing.TB) *GitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &GitRepo{Dir: t.TempDir(), t: t}
+	r.Git("init", "--quiet", "--initial-branch=main")
+	r.Git("config", "user.name", "Test User")
+	r.Git("config", "user.email", "test@example.com")
+	r.Git("config", "commit.gpgsign", "false")
+	return r
+}
+
+// NewSampleRepo creates a repository holding a small Go module with two
+// commits on main, checked out on the branch feature/login with one more
+// commit. Nothing is staged.
+func NewSampleRepo(t testing.TB) *GitRepo {
+	t.Helper()
+	r := NewGitRepo(t)
+	r.WriteFile("go.mod", "module example.com/sample\n\ngo 1.22\n")
+	r.WriteFile("README.md", "# Sample\n\nA sample service.\n")
+	r.WriteFile("auth/auth.go", sampleAuth)
+	r.Git("add", "-A")
+	r.Commit("feat: add authentication")
+	r.WriteFile("auth/auth_test.go", sampleAuthTest)
+	r.Git("add", "-A")
+	r.Commit("test: cover login")
+	r.Git("checkout", "--quiet", "-b", "feature/login")
+	r.Stage("auth/limit.go", sampleLimit)
+	r.Commit("feat(auth): limit login attempts")
+	return r
+}
+
+// Git runs git in the repository and returns its trimmed output. The test
+// fails when git does.
+func (r *GitRepo) Git(args ...string) string {
+	r.t.Helper()
+	out, err := r.run(nil, args...)
+	if err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// WriteFile writes content to path, relative to the repository, creating the
+// directories on the way
+func (r *GitRepo) WriteFile(path, content string) {
+	r.t.Helper()
+	full := filepath.Join(r.Dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		r.t.Fatalf("failed to create directory of %s: %v", path, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		r.t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// Stage writes content to path and stages it
+func (r *GitRepo) Stage(path, content string) {
+	r.t.Helper()
+	r.WriteFile(path, content)
+	r.Git("add", "--", path)
+}
+
+// Commit commits the staged changes with message and returns the hash of the
+// commit. Commits are dated an hour apart from fixtureEpoch on.
+func (r *GitRepo) Commit(message string) string {
+	r.t.Helper()
+	date := fixtureEpoch.Add(time.Duration(r.commits) * time.Hour).Format(time.RFC3339)
+	r.commits++
+	env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+	if out, err := r.run(env, "commit", "--quiet", "--allow-empty", "-m", message); err != nil {
+		r.t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	return r.Git("rev-parse", "HEAD")
+}
+
+// LastCommitMessage returns the message of the commit checked out
+func (r *GitRepo) LastCommitMessage() string {
+	r.t.Helper()
+	return r.Git("log", "-1", "--format=%B")
+}
+
+func (r *GitRepo) run(env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	// Keep the user's configuration out of the fixture
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("failed to run git: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+const sampleAuth = `package auth
+
+import "errors"
+
+// ErrInvalidCredentials is returned for a wrong user name or password
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Login checks the password of user
+func Login(users map[string]string, user, password string) error {
+	if users[user] != password {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+`
+
+const sampleAuthTest = `package auth
+
+import "testing"
+
+func TestLogin(t *testing.T) {
+	users := map[string]string{"ann": "secret"}
+	if err := Login(users, "ann", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Login(users, "ann", "wrong"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+`
+
+const sampleLimit = `package auth
+
+// MaxAttempts is the number of failed logins before an account is locked
+const MaxAttempts = 5
+`
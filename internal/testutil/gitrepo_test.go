@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSampleRepo(t *testing.T) {
+	repo := NewSampleRepo(t)
+	assert.Equal(t, "feature/login", repo.Git("branch", "--show-current"))
+	assert.Equal(t, "feat(auth): limit login attempts\ntest: cover login\nfeat: add authentication", repo.Git("log", "--format=%s"))
+	assert.Equal(t, "2024-01-15T11:00:00+00:00", repo.Git("log", "-1", "--format=%cI"), "commits are dated from the fixture epoch")
+	assert.Empty(t, repo.Git("status", "--short"))
+
+	repo.Stage("auth/limit.go", "package auth\n")
+	assert.Equal(t, "M  auth/limit.go", repo.Git("status", "--short"))
+	hash := repo.Commit("fix(auth): drop the limit")
+	assert.Len(t, hash, 40)
+	assert.Equal(t, "fix(auth): drop the limit", repo.LastCommitMessage())
+	assert.Equal(t, "auth/limit.go", repo.Git("diff", "--name-only", "main"))
+}
@@ -0,0 +1,221 @@
+// Package testutil provides fakes and fixtures for end-to-end tests of the
+// agents and the CLI commands: a provider that plays back a script of model
+// responses, and git repositories generated for a test.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+)
+
+// Turn is a canned response of a ScriptedProvider to one request
+type Turn struct {
+	Content   string
+	ToolCalls []schema.ToolCall
+	Err       error // Returned instead of a response, e.g. to test retries
+	StreamErr error // Breaks the stream of the response, see Break
+}
+
+// Reply returns a turn answering with content
+func Reply(content string) Turn {
+	return Turn{Content: content}
+}
+
+// CallTool returns a turn calling the tool name with args, which are
+// marshalled to JSON unless they are a string already
+func CallTool(name string, args any) Turn {
+	return CallTools(Call(name, args))
+}
+
+// CallTools returns a turn calling several tools at once
+func CallTools(calls ...schema.ToolCall) Turn {
+	return Turn{ToolCalls: calls}
+}
+
+// Call returns a call of the tool name with args for CallTools
+func Call(name string, args any) schema.ToolCall {
+	arguments, ok := args.(string)
+	if !ok {
+		data, err := json.Marshal(args)
+		if err != nil {
+			panic(fmt.Sprintf("testutil: arguments of %s can't be marshalled: %v", name, err))
+		}
+		arguments = string(data)
+	}
+	return schema.ToolCall{Type: "function", Function: schema.FunctionCall{Name: name, Arguments: arguments}}
+}
+
+// Fail returns a turn failing the request with err
+func Fail(err error) Turn {
+	return Turn{Err: err}
+}
+
+// Break returns turn with its stream breaking with err after the content and
+// half of the arguments of the first tool call, e.g. to test recovering from
+// dropped connections. Generate fails with err.
+func Break(turn Turn, err error) Turn {
+	turn.StreamErr = err
+	return turn
+}
+
+// TurnUsage is the token usage reported for each turn
+var TurnUsage = schema.TokenUsage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}
+
+// ScriptedProvider is an llm.Provider whose chat models answer the requests
+// with the turns of a script, in order, whether they are generated or
+// streamed. A request after the last turn fails.
+type ScriptedProvider struct {
+	cfg config.ModelConfig
+
+	mu       sync.Mutex
+	turns    []Turn
+	requests [][]*schema.Message
+	tools    []*schema.ToolInfo
+}
+
+// NewScriptedProvider creates a provider playing back turns
+func NewScriptedProvider(turns ...Turn) *ScriptedProvider {
+	return &ScriptedProvider{
+		cfg:   config.ModelConfig{Provider: "scripted", Model: "scripted-model"},
+		turns: turns,
+	}
+}
+
+// Name returns the provider name
+func (p *ScriptedProvider) Name() string {
+	return p.cfg.Provider
+}
+
+// GetConfig returns the model configuration
+func (p *ScriptedProvider) GetConfig() config.ModelConfig {
+	return p.cfg
+}
+
+// CreateChatModel creates a chat model playing back the script. Chat models
+// of the same provider share the script.
+func (p *ScriptedProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return &scriptedChatModel{provider: p}, nil
+}
+
+// Requests returns the messages of the requests received so far
+func (p *ScriptedProvider) Requests() [][]*schema.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([][]*schema.Message(nil), p.requests...)
+}
+
+// Remaining returns the number of turns not played back yet
+func (p *ScriptedProvider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.turns) - len(p.requests)
+}
+
+// ToolNames returns the names of the tools last bound to a chat model
+func (p *ScriptedProvider) ToolNames() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, 0, len(p.tools))
+	for _, tool := range p.tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+// next records a request and returns the response of its turn, and the
+// error breaking its stream
+func (p *ScriptedProvider) next(input []*schema.Message) (msg *schema.Message, streamErr, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.requests)
+	p.requests = append(p.requests, append([]*schema.Message(nil), input...))
+	if n >= len(p.turns) {
+		return nil, nil, fmt.Errorf("scripted provider: no turn left for request %d (the script has %d)", n+1, len(p.turns))
+	}
+	turn := p.turns[n]
+	if turn.Err != nil {
+		return nil, nil, turn.Err
+	}
+
+	usage := TurnUsage
+	msg = &schema.Message{
+		Role:         schema.Assistant,
+		Content:      turn.Content,
+		ResponseMeta: &schema.ResponseMeta{FinishReason: "stop", Usage: &usage},
+	}
+	for i, call := range turn.ToolCalls {
+		index := i
+		call.Index = &index
+		if call.ID == "" {
+			call.ID = fmt.Sprintf("call_%d_%d", n+1, i+1)
+		}
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+	if len(msg.ToolCalls) > 0 {
+		msg.ResponseMeta.FinishReason = "tool_calls"
+	}
+	return msg, turn.StreamErr, nil
+}
+
+type scriptedChatModel struct {
+	provider *ScriptedProvider
+}
+
+func (m *scriptedChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	msg, streamErr, err := m.provider.next(input)
+	if err == nil && streamErr != nil {
+		return nil, streamErr
+	}
+	return msg, err
+}
+
+func (m *scriptedChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	msg, streamErr, err := m.provider.next(input)
+	if err != nil {
+		return nil, err
+	}
+	// The content and the tool calls arrive in separate chunks, with the
+	// usage in the last one, like the streams of the real providers
+	content := &schema.Message{Role: schema.Assistant, Content: msg.Content}
+	if streamErr != nil {
+		chunks := []*schema.Message{content}
+		if len(msg.ToolCalls) > 0 {
+			call := msg.ToolCalls[0]
+			call.Function.Arguments = call.Function.Arguments[:len(call.Function.Arguments)/2]
+			chunks = append(chunks, &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{call}})
+		}
+		return brokenStream(chunks, streamErr), nil
+	}
+	calls := &schema.Message{Role: schema.Assistant, ToolCalls: msg.ToolCalls, ResponseMeta: msg.ResponseMeta}
+	return schema.StreamReaderFromArray([]*schema.Message{content, calls}), nil
+}
+
+// brokenStream returns a stream of chunks ending with err
+func brokenStream(chunks []*schema.Message, err error) *schema.StreamReader[*schema.Message] {
+	reader, writer := schema.Pipe[*schema.Message](len(chunks) + 1)
+	for _, chunk := range chunks {
+		writer.Send(chunk, nil)
+	}
+	writer.Send(nil, err)
+	writer.Close()
+	return reader
+}
+
+func (m *scriptedChatModel) BindTools(tools []*schema.ToolInfo) error {
+	m.provider.mu.Lock()
+	defer m.provider.mu.Unlock()
+	m.provider.tools = tools
+	return nil
+}
@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptedProvider(t *testing.T) {
+	provider := NewScriptedProvider(
+		CallTools(Call("git_status", map[string]any{}), Call("read_file", `{"file_path":"go.mod"}`)),
+		Reply("done"),
+	)
+	chatModel, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, chatModel.BindTools([]*schema.ToolInfo{{Name: "git_status"}, {Name: "read_file"}}))
+	assert.Equal(t, []string{"git_status", "read_file"}, provider.ToolNames())
+
+	stream, err := chatModel.Stream(context.Background(), []*schema.Message{schema.UserMessage("commit")})
+	require.NoError(t, err)
+	var chunks []*schema.Message
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	require.NoError(t, err)
+	require.Len(t, msg.ToolCalls, 2)
+	assert.Equal(t, "git_status", msg.ToolCalls[0].Function.Name)
+	assert.Equal(t, "{}", msg.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, `{"file_path":"go.mod"}`, msg.ToolCalls[1].Function.Arguments)
+	assert.NotEqual(t, msg.ToolCalls[0].ID, msg.ToolCalls[1].ID)
+	assert.Equal(t, TurnUsage, *msg.ResponseMeta.Usage)
+
+	msg, err = chatModel.Generate(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "done", msg.Content)
+	assert.Zero(t, provider.Remaining())
+
+	_, err = chatModel.Generate(context.Background(), nil)
+	assert.ErrorContains(t, err, "no turn left for request 3")
+	requests := provider.Requests()
+	require.Len(t, requests, 3)
+	assert.Equal(t, "commit", requests[0][0].Content)
+}
+
+func TestScriptedProvider_Fail(t *testing.T) {
+	failure := errors.New("status code: 503")
+	chatModel, err := NewScriptedProvider(Fail(failure), Reply("ok")).CreateChatModel(context.Background())
+	require.NoError(t, err)
+
+	_, err = chatModel.Stream(context.Background(), nil)
+	assert.ErrorIs(t, err, failure)
+	msg, err := chatModel.Generate(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", msg.Content)
+}
+
+func TestScriptedProvider_Break(t *testing.T) {
+	turn := Break(CallTool("submit", map[string]string{"answer": "42"}), io.ErrUnexpectedEOF)
+	chatModel, err := NewScriptedProvider(turn, turn).CreateChatModel(context.Background())
+	require.NoError(t, err)
+
+	stream, err := chatModel.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	var chunks []*schema.Message
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			break
+		}
+		chunks = append(chunks, chunk)
+	}
+	require.Len(t, chunks, 2)
+	assert.Equal(t, `{"answe`, chunks[1].ToolCalls[0].Function.Arguments)
+
+	_, err = chatModel.Generate(context.Background(), nil)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// accessible is set by SetAccessible and read by printers created afterwards
+var accessible atomic.Bool
+
+// SetAccessible switches all output to plain sequential text for screen
+// readers and log files: no colors, emoji or box-drawing lines, every progress
+// message starts with a text prefix (PROGRESS:, TOOL:, RESULT:, ...) and
+// nothing is redrawn in place.
+func SetAccessible(enabled bool) {
+	accessible.Store(enabled)
+	if enabled {
+		color.NoColor = true
+	}
+}
+
+// Accessible reports whether accessible output is enabled
+func Accessible() bool {
+	return accessible.Load()
+}
+
+// WithAccessible overrides the accessible mode set with SetAccessible
+func WithAccessible(enabled bool) StreamPrinterOption {
+	return func(p *StreamPrinter) {
+		p.accessible = enabled
+		if enabled {
+			p.colorEnabled = false
+		}
+	}
+}
+
+// decorate returns fancy normally and plain in accessible mode
+func decorate(fancy, plain string) string {
+	if Accessible() {
+		return plain
+	}
+	return fancy
+}
+
+// plainText removes emoji, pictographs and box-drawing characters from s,
+// which screen readers announce by name
+func plainText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.So, r) || r == '\uFE0F' || r == '\u200D' {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// printPrefixed writes a message on its own line starting with prefix, e.g.
+// "TOOL: read_file". Streamed content that did not end with a newline is
+// terminated first so the prefix always starts the line.
+func (p *StreamPrinter) printPrefixed(prefix, message string) error {
+	if p.midLine {
+		if _, err := fmt.Fprintln(p.writer); err != nil {
+			return err
+		}
+		p.midLine = false
+	}
+	_, err := fmt.Fprintf(p.writer, "%s: %s\n", prefix, plainText(message))
+	return err
+}
+
+// trackLine remembers whether streamed output left the cursor mid-line
+func (p *StreamPrinter) trackLine(s string) {
+	if s != "" {
+		p.midLine = !strings.HasSuffix(s, "\n")
+	}
+}
+
+// printRule prints a separator line. In accessible mode only its leading
+// blank lines are kept.
+func printRule(w io.Writer, clr *color.Color, line string) error {
+	if Accessible() {
+		_, err := fmt.Fprint(w, line[:len(line)-len(strings.TrimLeft(line, "\n"))])
+		return err
+	}
+	_, err := clr.Fprintln(w, line)
+	return err
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPrinter_Accessible(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewStreamPrinter(&buf, WithAccessible(true))
+
+	_ = p.PrintProgress("Agent iteration 1...")
+	_ = p.PrintLLMContent("Let me look")
+	_ = p.PrintToolCall("read_file", nil)
+	_ = p.PrintToolArgStart()
+	_ = p.PrintToolArgChunk(`{"path":`)
+	_ = p.PrintToolArgChunk(`"main.go"}`)
+	_ = p.PrintToolArgEnd()
+	_ = p.PrintToolReturned("read_file", 120, 30)
+	_ = p.PrintToolResult("git_status", "", nil)
+	_ = p.PrintToolResult("git_log", "", errors.New("boom"))
+	_ = p.PrintInfo("📋 Execution plan updated")
+	_ = p.PrintStep(2, "Review")
+	_ = p.PrintStats(&ExecutionStats{
+		StartTime:        time.Unix(0, 0),
+		EndTime:          time.Unix(2, 0),
+		PromptTokens:     100,
+		CompletionTokens: 20,
+		TotalTokens:      120,
+	})
+
+	assert.Equal(t, "PROGRESS: Agent iteration 1...\n"+
+		"Let me look\n"+
+		"TOOL: read_file\n"+
+		`ARGS: {"path":"main.go"}`+"\n"+
+		"RESULT: read_file returned 120 bytes (~30 tokens)\n"+
+		"RESULT: git_status done\n"+
+		"ERROR: Tool git_log failed: boom\n"+
+		"INFO: Execution plan updated\n"+
+		"STEP 2: Review\n"+
+		"STATS: 120 tokens (prompt: 100, completion: 20), time: 2.00s\n", buf.String())
+	assert.NotContains(t, buf.String(), "\x1b[", "no ANSI escapes")
+	assert.NotContains(t, buf.String(), "\r", "no carriage returns")
+}
+
+func TestSetAccessible(t *testing.T) {
+	noColor := color.NoColor
+	t.Cleanup(func() {
+		SetAccessible(false)
+		color.NoColor = noColor
+	})
+
+	var buf bytes.Buffer
+	SetAccessible(true)
+	assert.True(t, Accessible())
+	_ = NewStreamPrinter(&buf).PrintSuccess("Done")
+	assert.Equal(t, "SUCCESS: Done\n", buf.String(), "printers follow the global mode")
+
+	buf.Reset()
+	_ = ShowCommitMessage("feat: add accessible mode", &buf)
+	assert.Equal(t, "\nGenerated Commit Message:\nfeat: add accessible mode\n", buf.String())
+
+	buf.Reset()
+	_ = ShowReviewResult(&struct {
+		Summary string
+		Issues  []ReviewIssue
+	}{Issues: []ReviewIssue{{Severity: "error", Category: "bug", File: "main.go", Line: 3, Title: "Nil map", Suggestion: "Initialize it"}}}, &buf)
+	output := buf.String()
+	assert.Contains(t, output, "[ERROR] Nil map")
+	assert.Contains(t, output, "Location: main.go:3")
+	assert.Contains(t, output, "Category: bug")
+	assert.Contains(t, output, "Suggestion: Initialize it")
+	for _, r := range output {
+		assert.False(t, r > 0x2000, "unexpected symbol %q", r)
+	}
+}
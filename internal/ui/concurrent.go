@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SharedOutput serializes the output of concurrent producers, such as
+// parallel agent runs, on one writer. Each producer writes through its own
+// region, a Prefixed writer or a Panel, so that its output stays readable and
+// attributable.
+type SharedOutput struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewSharedOutput creates a SharedOutput writing to out
+func NewSharedOutput(out io.Writer) *SharedOutput {
+	return &SharedOutput{out: out}
+}
+
+// Write writes p as is, without interleaving with the regions
+func (o *SharedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.out.Write(p)
+}
+
+// Prefixed returns a region that writes every complete line with prefix as
+// soon as it ends, e.g. "[2/5] Reading main.go". Lines of different regions
+// interleave, but never mid-line. Close writes out a trailing incomplete line.
+func (o *SharedOutput) Prefixed(prefix string) *PrefixedWriter {
+	return &PrefixedWriter{output: o, prefix: prefix}
+}
+
+// Panel returns a region that holds its output back and writes it as one
+// block under title when closed, for output that is read afterwards (logs,
+// CI) rather than followed live
+func (o *SharedOutput) Panel(title string) *PanelWriter {
+	return &PanelWriter{output: o, title: title}
+}
+
+// PrefixedWriter is a region of a SharedOutput that prefixes every line
+type PrefixedWriter struct {
+	output *SharedOutput
+	prefix string
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+// Write buffers p and writes out the complete lines
+func (w *PrefixedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buf.Next(i + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Close writes out a trailing incomplete line. It is not named Flush, which
+// the printer calls after every streamed chunk.
+func (w *PrefixedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := append(w.buf.Bytes(), '\n')
+	w.buf.Reset()
+	return w.writeLine(line)
+}
+
+func (w *PrefixedWriter) writeLine(line []byte) error {
+	w.output.mu.Lock()
+	defer w.output.mu.Unlock()
+	_, err := fmt.Fprintf(w.output.out, "%s%s", w.prefix, line)
+	return err
+}
+
+// PanelWriter is a region of a SharedOutput that is written as one block
+type PanelWriter struct {
+	output *SharedOutput
+	title  string
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+// Write buffers p until the panel is closed
+func (w *PanelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, fmt.Errorf("write to closed panel %q", w.title)
+	}
+	return w.buf.Write(p)
+}
+
+// Close writes the title and the buffered output in one piece. Closing a
+// panel again does nothing.
+func (w *PanelWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "── %s\n", w.title)
+	block.Write(w.buf.Bytes())
+	if w.buf.Len() > 0 && !bytes.HasSuffix(w.buf.Bytes(), []byte("\n")) {
+		block.WriteByte('\n')
+	}
+	w.buf.Reset()
+	_, err := w.output.Write(block.Bytes())
+	return err
+}
+
+// Fork returns a printer with the settings of p that writes to w, for a
+// concurrent producer writing to its own region of a SharedOutput. JSON
+// progress events stay on the shared event stream; the raw artifact stream
+// is not inherited.
+func (p *StreamPrinter) Fork(w io.Writer) *StreamPrinter {
+	return &StreamPrinter{
+		writer:       w,
+		colorEnabled: p.colorEnabled,
+		verbose:      p.verbose,
+		events:       p.events,
+		accessible:   p.accessible,
+	}
+}
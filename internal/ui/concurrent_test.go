@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedOutput_Prefixed(t *testing.T) {
+	var out bytes.Buffer
+	w := NewSharedOutput(&out).Prefixed("[1/2] ")
+
+	_, err := w.Write([]byte("Reading "))
+	require.NoError(t, err)
+	assert.Empty(t, out.String(), "incomplete lines are held back")
+
+	_, err = w.Write([]byte("main.go\nDone\npartial"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, "[1/2] Reading main.go\n[1/2] Done\n[1/2] partial\n", out.String())
+}
+
+func TestSharedOutput_Panel(t *testing.T) {
+	var out bytes.Buffer
+	shared := NewSharedOutput(&out)
+	first := shared.Panel("[1/2] Login fails")
+	second := shared.Panel("[2/2] Crash on save")
+
+	_, _ = first.Write([]byte("Reading auth.go\n"))
+	_, _ = second.Write([]byte("Reading save.go\n"))
+	_, _ = first.Write([]byte("Found it"))
+	assert.Empty(t, out.String(), "panels are held back until closed")
+
+	require.NoError(t, second.Close())
+	require.NoError(t, first.Close())
+	require.NoError(t, first.Close())
+	assert.Equal(t, "── [2/2] Crash on save\nReading save.go\n── [1/2] Login fails\nReading auth.go\nFound it\n", out.String())
+
+	_, err := first.Write([]byte("late"))
+	assert.Error(t, err)
+}
+
+func TestSharedOutput_ConcurrentProducers(t *testing.T) {
+	var out bytes.Buffer
+	shared := NewSharedOutput(&out)
+	base := NewStreamPrinter(&out, WithColor(false))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			region := shared.Prefixed(fmt.Sprintf("[%d] ", i))
+			defer region.Close()
+			printer := base.Fork(region)
+			for j := 0; j < 50; j++ {
+				// Streamed in pieces, as LLM tokens are
+				_ = printer.PrintLLMContent("token ")
+				_ = printer.PrintLLMContent(fmt.Sprintf("%d-%d\n", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 200)
+	for _, line := range lines {
+		var i, j, k int
+		_, err := fmt.Sscanf(line, "[%d] token %d-%d", &i, &j, &k)
+		require.NoError(t, err, "garbled line %q", line)
+		assert.Equal(t, i, j, "line %q is attributed to the wrong producer", line)
+	}
+}
+
+func TestStreamPrinter_Fork(t *testing.T) {
+	var out, forked, events bytes.Buffer
+	printer := NewStreamPrinter(&out, WithColor(false), WithVerbose(true), WithProgressJSON(&events))
+
+	fork := printer.Fork(&forked)
+	require.NoError(t, fork.PrintInfo("hello"))
+	assert.Empty(t, out.String())
+	assert.Empty(t, forked.String(), "JSON progress goes to the shared event stream")
+	assert.Contains(t, events.String(), `"message":"hello"`)
+
+	plain := NewStreamPrinter(&out, WithColor(false), WithVerbose(true)).Fork(&forked)
+	require.NoError(t, plain.PrintToolResult("git_diff", "ok", nil))
+	assert.Equal(t, "✓ Tool git_diff completed\n", forked.String())
+}
@@ -59,17 +59,43 @@ func ConfirmWithDefault(message string, defaultYes bool, input io.Reader, output
 	}
 }
 
+// LineReader returns at most one line per Read, so prompts that read the same
+// input one after another don't consume each other's answers
+type LineReader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+// NewLineReader creates a new LineReader
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{r: bufio.NewReader(r)}
+}
+
+// Read reads from the current line of input
+func (l *LineReader) Read(p []byte) (int, error) {
+	if len(l.pending) == 0 {
+		line, err := l.r.ReadSlice('\n')
+		if len(line) == 0 {
+			return 0, err
+		}
+		l.pending = append(l.pending[:0], line...)
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
 // ShowCommitMessage displays a formatted commit message
 func ShowCommitMessage(message string, output io.Writer) error {
 	bold := color.New(color.Bold)
 	cyan := color.New(color.FgCyan)
 
-	_, err := bold.Fprintln(output, "\n📝 Generated Commit Message:")
+	_, err := bold.Fprintln(output, "\n"+decorate("📝 ", "")+"Generated Commit Message:")
 	if err != nil {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "─────────────────────────────")
+	err = printRule(output, cyan, "─────────────────────────────")
 	if err != nil {
 		return err
 	}
@@ -79,7 +105,7 @@ func ShowCommitMessage(message string, output io.Writer) error {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "─────────────────────────────")
+	err = printRule(output, cyan, "─────────────────────────────")
 	return err
 }
 
@@ -96,12 +122,12 @@ func ShowPRDescription(pr PRDescriptionDisplayer, output io.Writer) error {
 	green := color.New(color.FgGreen)
 
 	// Title section
-	_, err := bold.Fprintln(output, "\n📋 Generated PR:")
+	_, err := bold.Fprintln(output, "\n"+decorate("📋 ", "")+"Generated PR:")
 	if err != nil {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "═══════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "═══════════════════════════════════════════════════════════════════════════════")
 	if err != nil {
 		return err
 	}
@@ -116,7 +142,7 @@ func ShowPRDescription(pr PRDescriptionDisplayer, output io.Writer) error {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "───────────────────────────────────────────────────────────────────────────────")
+	err = printRule(output, cyan, "───────────────────────────────────────────────────────────────────────────────")
 	if err != nil {
 		return err
 	}
@@ -127,7 +153,7 @@ func ShowPRDescription(pr PRDescriptionDisplayer, output io.Writer) error {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "═══════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "═══════════════════════════════════════════════════════════════════════════════")
 	return err
 }
 
@@ -143,12 +169,12 @@ func ShowReport(report ReportDisplayer, output io.Writer) error {
 	cyan := color.New(color.FgCyan)
 
 	// Header
-	_, err := bold.Fprintln(output, "\n📊 Generated Development Report:")
+	_, err := bold.Fprintln(output, "\n"+decorate("📊 ", "")+"Generated Development Report:")
 	if err != nil {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "════════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "════════════════════════════════════════════════════════════════════════════════")
 	if err != nil {
 		return err
 	}
@@ -159,7 +185,7 @@ func ShowReport(report ReportDisplayer, output io.Writer) error {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "════════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "════════════════════════════════════════════════════════════════════════════════")
 	return err
 }
 
@@ -172,6 +198,8 @@ type ReviewIssue struct {
 	Title       string
 	Description string
 	Suggestion  string
+
+	EscalatedFrom string // Severity before severity rules raised it
 }
 
 // ReviewResultDisplayer is an interface for review responses that can be displayed
@@ -220,6 +248,8 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 						Title:       getStringField(item, "Title"),
 						Description: getStringField(item, "Description"),
 						Suggestion:  getStringField(item, "Suggestion"),
+
+						EscalatedFrom: getStringField(item, "EscalatedFrom"),
 					}
 					issues = append(issues, issue)
 				}
@@ -228,12 +258,12 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 	}
 
 	// Header
-	_, err := bold.Fprintln(output, "\n🔍 Code Review Results:")
+	_, err := bold.Fprintln(output, "\n"+decorate("🔍 ", "")+"Code Review Results:")
 	if err != nil {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "════════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "════════════════════════════════════════════════════════════════════════════════")
 	if err != nil {
 		return err
 	}
@@ -256,7 +286,7 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 
 	// Summary stats
 	if len(issues) == 0 {
-		_, err = green.Fprintln(output, "✅ No issues found!")
+		_, err = green.Fprintln(output, decorate("✅ ", "")+"No issues found!")
 	} else {
 		_, err = fmt.Fprintf(output, "Found %d issue(s): ", len(issues))
 		if errorCount > 0 {
@@ -274,7 +304,7 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 		return err
 	}
 
-	_, err = cyan.Fprintln(output, "────────────────────────────────────────────────────────────────────────────────")
+	err = printRule(output, cyan, "────────────────────────────────────────────────────────────────────────────────")
 	if err != nil {
 		return err
 	}
@@ -302,7 +332,7 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 			clr := severityColor[issue.Severity]
 
 			// Issue header
-			_, err = clr.Fprintf(output, "\n%s [%s] ", emoji, strings.ToUpper(issue.Severity))
+			_, err = clr.Fprintf(output, "\n%s[%s] ", decorate(emoji+" ", ""), strings.ToUpper(issue.Severity))
 			if err != nil {
 				return err
 			}
@@ -317,14 +347,21 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 				if issue.Line > 0 {
 					location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
 				}
-				_, err = dim.Fprintf(output, "   📍 %s\n", location)
+				_, err = dim.Fprintf(output, "   %s%s\n", decorate("📍 ", "Location: "), location)
+				if err != nil {
+					return err
+				}
+			}
+
+			if issue.EscalatedFrom != "" {
+				_, err = dim.Fprintf(output, "   %sEscalated from %s by severity rules\n", decorate("⬆️  ", ""), issue.EscalatedFrom)
 				if err != nil {
 					return err
 				}
 			}
 
 			// Category
-			_, err = dim.Fprintf(output, "   🏷️  %s\n", issue.Category)
+			_, err = dim.Fprintf(output, "   %s%s\n", decorate("🏷️  ", "Category: "), issue.Category)
 			if err != nil {
 				return err
 			}
@@ -337,7 +374,7 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 
 			// Suggestion
 			if issue.Suggestion != "" {
-				_, err = green.Fprintf(output, "   💡 %s\n", issue.Suggestion)
+				_, err = green.Fprintf(output, "   %s%s\n", decorate("💡 ", "Suggestion: "), issue.Suggestion)
 				if err != nil {
 					return err
 				}
@@ -345,7 +382,7 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 
 			// Add separator between issues (but not after the last one)
 			if i < len(issues)-1 {
-				_, err = dim.Fprintln(output, "   ─ ─ ─ ─ ─ ─ ─ ─ ─ ─")
+				err = printRule(output, dim, "   ─ ─ ─ ─ ─ ─ ─ ─ ─ ─")
 				if err != nil {
 					return err
 				}
@@ -355,12 +392,12 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 
 	// Summary section
 	if summary != "" {
-		_, err = cyan.Fprintln(output, "\n────────────────────────────────────────────────────────────────────────────────")
+		err = printRule(output, cyan, "\n────────────────────────────────────────────────────────────────────────────────")
 		if err != nil {
 			return err
 		}
 
-		_, err = bold.Fprintln(output, "📋 Summary:")
+		_, err = bold.Fprintln(output, decorate("📋 ", "")+"Summary:")
 		if err != nil {
 			return err
 		}
@@ -371,10 +408,41 @@ func ShowReviewResult(review interface{}, output io.Writer) error {
 		}
 	}
 
-	_, err = cyan.Fprintln(output, "════════════════════════════════════════════════════════════════════════════════")
+	err = printRule(output, cyan, "════════════════════════════════════════════════════════════════════════════════")
 	return err
 }
 
+// CodeOwner lists the changed files of one code owner for display
+type CodeOwner struct {
+	Owner string
+	Files []string
+}
+
+// ShowCodeOwners displays the code owners of the changed files
+func ShowCodeOwners(owners []CodeOwner, output io.Writer) error {
+	if len(owners) == 0 {
+		return nil
+	}
+	bold := color.New(color.Bold)
+	dim := color.New(color.FgHiBlack)
+
+	_, err := bold.Fprintln(output, "\n"+decorate("👥 ", "")+"Code Owners:")
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		_, err = fmt.Fprintf(output, "   %s ", owner.Owner)
+		if err != nil {
+			return err
+		}
+		_, err = dim.Fprintf(output, "(%d file(s): %s)\n", len(owner.Files), strings.Join(owner.Files, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getStringField gets a string field from a reflect.Value struct
 func getStringField(v reflect.Value, name string) string {
 	field := v.FieldByName(name)
@@ -462,3 +530,80 @@ func SelectOption(message string, options []string, defaultIndex int, input io.R
 		return choice - 1, nil
 	}
 }
+
+// SelectMultiple presents a list of options to the user and returns which of
+// them are selected. selected marks the options chosen on empty input; "all"
+// chooses every option.
+func SelectMultiple(message string, options []string, selected []bool, input io.Reader, output io.Writer) ([]bool, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("no options provided")
+	}
+
+	defaults := make([]bool, len(options))
+	copy(defaults, selected)
+
+	scanner := bufio.NewScanner(input)
+	bold := color.New(color.Bold)
+	cyan := color.New(color.FgCyan)
+	dim := color.New(color.FgHiBlack)
+
+	for {
+		if _, err := bold.Fprintln(output, message); err != nil {
+			return nil, err
+		}
+
+		var err error
+		for i, option := range options {
+			if defaults[i] {
+				_, err = cyan.Fprintf(output, "  [x] %d) %s\n", i+1, option)
+			} else {
+				_, err = fmt.Fprintf(output, "  [ ] %d) %s\n", i+1, option)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := dim.Fprintf(output, "Enter numbers separated by commas, \"all\", or nothing to keep the marked ones: "); err != nil {
+			return nil, err
+		}
+
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+
+		response := strings.TrimSpace(scanner.Text())
+		if response == "" {
+			return defaults, nil
+		}
+		if strings.EqualFold(response, "all") {
+			all := make([]bool, len(options))
+			for i := range all {
+				all[i] = true
+			}
+			return all, nil
+		}
+
+		choices := make([]bool, len(options))
+		valid := true
+		for _, field := range strings.FieldsFunc(response, func(r rune) bool { return r == ',' || r == ' ' }) {
+			var choice int
+			if _, err := fmt.Sscanf(field, "%d", &choice); err != nil || choice < 1 || choice > len(options) {
+				valid = false
+				break
+			}
+			choices[choice-1] = true
+		}
+		if !valid {
+			_, err = color.New(color.FgRed).Fprintf(output, "Invalid choice. Please enter numbers between 1 and %d\n\n", len(options))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return choices, nil
+	}
+}
@@ -86,6 +86,20 @@ func TestConfirm_EOF(t *testing.T) {
 	assert.False(t, result)
 }
 
+func TestConfirm_LineReader(t *testing.T) {
+	input := NewLineReader(strings.NewReader("n\ny\n"))
+	output := &bytes.Buffer{}
+
+	first, err := Confirm("First?", input, output)
+	require.NoError(t, err)
+	assert.False(t, first)
+	second, err := Confirm("Second?", input, output)
+	require.NoError(t, err)
+	assert.True(t, second)
+	_, err = Confirm("Third?", input, output)
+	assert.Equal(t, io.EOF, err)
+}
+
 func TestConfirmWithDefault_YesDefault(t *testing.T) {
 	input := strings.NewReader("\n")
 	output := &bytes.Buffer{}
@@ -117,3 +131,17 @@ func TestShowCommitMessage(t *testing.T) {
 	assert.Contains(t, outputStr, "feat(auth): add login")
 	assert.Contains(t, outputStr, "JWT authentication")
 }
+
+func TestShowCodeOwners(t *testing.T) {
+	output := &bytes.Buffer{}
+	err := ShowCodeOwners([]CodeOwner{
+		{Owner: "@acme/payments", Files: []string{"payments/charge.go", "payments/refund.go"}},
+	}, output)
+	require.NoError(t, err)
+	assert.Contains(t, output.String(), "Code Owners:")
+	assert.Contains(t, output.String(), "@acme/payments (2 file(s): payments/charge.go, payments/refund.go)")
+
+	output.Reset()
+	require.NoError(t, ShowCodeOwners(nil, output))
+	assert.Empty(t, output.String())
+}
@@ -56,8 +56,9 @@ func (p *MultilinePrompt) ShowWithContext(ctx context.Context, input io.Reader,
 		return "", err
 	}
 
-	// Use readline for better terminal experience if using stdin/stdout
-	if input == os.Stdin && output == os.Stdout {
+	// Use readline for better terminal experience if using stdin/stdout,
+	// except in accessible mode as readline redraws the line in place
+	if input == os.Stdin && output == os.Stdout && !Accessible() {
 		return p.readWithReadline(ctx)
 	}
 
@@ -77,7 +78,7 @@ func (p *MultilinePrompt) displayPrompt(output io.Writer) error {
 	green := color.New(color.FgGreen)
 
 	// Show main prompt
-	_, err := bold.Fprintln(output, fmt.Sprintf("\n🤔 %s", p.Prompt))
+	_, err := bold.Fprintln(output, fmt.Sprintf("\n%s%s", decorate("🤔 ", ""), p.Prompt))
 	if err != nil {
 		return err
 	}
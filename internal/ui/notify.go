@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// windowsNotifyScript shows a balloon notification with the title and
+// message passed in the environment, which saves quoting them for PowerShell
+const windowsNotifyScript = `Add-Type -AssemblyName System.Windows.Forms
+$icon = New-Object System.Windows.Forms.NotifyIcon
+$icon.Icon = [System.Drawing.SystemIcons]::Information
+$icon.Visible = $true
+$icon.ShowBalloonTip(10000, $env:GITBUDDY_NOTIFY_TITLE, $env:GITBUDDY_NOTIFY_MESSAGE, 'Info')
+Start-Sleep -Seconds 10
+$icon.Dispose()`
+
+// Notifier sends desktop notifications about a run once it has taken longer
+// than a threshold, so that users who switched to other work notice when it
+// finishes or waits for them. A nil Notifier does nothing.
+type Notifier struct {
+	after time.Duration
+	start time.Time
+	now   func() time.Time
+	send  func(title, message string) error
+}
+
+// NewNotifier creates a notifier for a run starting now. after <= 0
+// disables notifications.
+func NewNotifier(after time.Duration) *Notifier {
+	return &Notifier{
+		after: after,
+		start: time.Now(),
+		now:   time.Now,
+		send:  SendNotification,
+	}
+}
+
+// Notify sends a notification if the run has taken at least the threshold
+// and reports whether it was sent. Failing to send one is not an error of
+// the run, so it is only reported on stderr.
+func (n *Notifier) Notify(title, message string) bool {
+	if n == nil || n.after <= 0 || n.now().Sub(n.start) < n.after {
+		return false
+	}
+	if err := n.send(title, message); err != nil {
+		fmt.Fprintf(os.Stderr, "Desktop notification failed: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// SendNotification shows a native desktop notification: through osascript
+// on macOS, PowerShell on Windows and notify-send elsewhere
+func SendNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsNotifyScript)
+		cmd.Env = append(os.Environ(), "GITBUDDY_NOTIFY_TITLE="+title, "GITBUDDY_NOTIFY_MESSAGE="+message)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=gitbuddy", title, message)
+	}
+	// The notification stays up on its own, so don't hold up the run for it
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", cmd.Path, err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
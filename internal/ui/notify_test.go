@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier_Notify(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var sent []string
+	n := &Notifier{
+		after: 2 * time.Minute,
+		start: start,
+		now:   func() time.Time { return now },
+		send: func(title, message string) error {
+			sent = append(sent, title+": "+message)
+			return nil
+		},
+	}
+
+	now = start.Add(time.Minute)
+	assert.False(t, n.Notify("gitbuddy debug", "waiting"), "short runs aren't announced")
+
+	now = start.Add(3 * time.Minute)
+	assert.True(t, n.Notify("gitbuddy debug", "finished"))
+	assert.Equal(t, []string{"gitbuddy debug: finished"}, sent)
+
+	n.send = func(title, message string) error { return errors.New("notify-send not found") }
+	assert.False(t, n.Notify("gitbuddy debug", "finished"))
+}
+
+func TestNotifier_Disabled(t *testing.T) {
+	var nilNotifier *Notifier
+	assert.False(t, nilNotifier.Notify("title", "message"))
+
+	n := NewNotifier(0)
+	n.send = func(title, message string) error {
+		t.Fatal("disabled notifiers send nothing")
+		return nil
+	}
+	assert.False(t, n.Notify("title", "message"))
+}
+
+func TestAppleScriptString(t *testing.T) {
+	assert.Equal(t, `"say \"hi\" C:\\tmp"`, appleScriptString(`say "hi" C:\tmp`))
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress event types emitted with WithProgressJSON
+const (
+	EventIteration  = "iteration"   // An agent iteration started
+	EventToolCall   = "tool_call"   // The LLM called a tool
+	EventToolResult = "tool_result" // A tool returned its result
+	EventTokens     = "tokens"      // Cumulative token usage after an LLM call
+	EventPhase      = "phase"       // The debugging phase changed
+	EventThinking   = "thinking"
+	EventStep       = "step"
+	EventProgress   = "progress"
+	EventInfo       = "info"
+	EventSuccess    = "success"
+	EventError      = "error"
+	EventStats      = "stats" // Final statistics of the run
+)
+
+// ProgressEvent is one line of the NDJSON progress stream
+type ProgressEvent struct {
+	Time             time.Time `json:"time"`
+	Event            string    `json:"event"`
+	Message          string    `json:"message,omitempty"`
+	Tool             string    `json:"tool,omitempty"`
+	Bytes            int       `json:"bytes,omitempty"`         // Size of a tool result
+	ResultTokens     int       `json:"result_tokens,omitempty"` // Estimated tokens of a tool result
+	Iteration        int       `json:"iteration,omitempty"`
+	MaxIterations    int       `json:"max_iterations,omitempty"`
+	Phase            string    `json:"phase,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	TotalTokens      int       `json:"total_tokens,omitempty"`
+	DurationMs       int64     `json:"duration_ms,omitempty"`
+}
+
+// WithProgressJSON replaces the human-readable output with one JSON object
+// per event written to w, so wrappers can track progress without parsing
+// terminal output. Streamed LLM content is not emitted.
+func WithProgressJSON(w io.Writer) StreamPrinterOption {
+	return func(p *StreamPrinter) {
+		p.events = &eventWriter{encoder: json.NewEncoder(w)}
+	}
+}
+
+// eventWriter serializes events written from concurrent goroutines
+type eventWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// ProgressJSON reports whether the printer emits JSON events
+func (p *StreamPrinter) ProgressJSON() bool {
+	return p.events != nil
+}
+
+// Emit writes a progress event. It does nothing unless WithProgressJSON is set.
+func (p *StreamPrinter) Emit(event ProgressEvent) error {
+	if p.events == nil {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	return p.events.encoder.Encode(event)
+}
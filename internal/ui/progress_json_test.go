@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeEvents(t *testing.T, data []byte) []ProgressEvent {
+	t.Helper()
+
+	var events []ProgressEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var event ProgressEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), "every line is a JSON object: %s", scanner.Text())
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestStreamPrinter_ProgressJSON(t *testing.T) {
+	var out, events bytes.Buffer
+	printer := NewStreamPrinter(&out, WithProgressJSON(&events))
+	assert.True(t, printer.ProgressJSON())
+
+	require.NoError(t, printer.PrintProgress("Agent iteration 1..."))
+	require.NoError(t, printer.Emit(ProgressEvent{Event: EventIteration, Iteration: 1, MaxIterations: 10}))
+	require.NoError(t, printer.PrintLLMContent("streamed content"))
+	require.NoError(t, printer.PrintToolCall("read_file", nil))
+	require.NoError(t, printer.PrintToolArgChunk(`{"file_path":`))
+	require.NoError(t, printer.PrintToolReturned("read_file", 120, 30))
+	require.NoError(t, printer.PrintToolResult("grep_file", "", errors.New("no such file")))
+	require.NoError(t, printer.Newline())
+
+	start := time.Now()
+	require.NoError(t, printer.PrintStats(&ExecutionStats{StartTime: start, EndTime: start.Add(1500 * time.Millisecond), PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}))
+
+	assert.Empty(t, out.String(), "nothing is written to the regular output")
+
+	got := decodeEvents(t, events.Bytes())
+	require.Len(t, got, 6)
+	assert.Equal(t, EventProgress, got[0].Event)
+	assert.Equal(t, "Agent iteration 1...", got[0].Message)
+	assert.False(t, got[0].Time.IsZero())
+	assert.Equal(t, ProgressEvent{Time: got[1].Time, Event: EventIteration, Iteration: 1, MaxIterations: 10}, got[1])
+	assert.Equal(t, "read_file", got[2].Tool)
+	assert.Equal(t, ProgressEvent{Time: got[3].Time, Event: EventToolResult, Tool: "read_file", Bytes: 120, ResultTokens: 30}, got[3])
+	assert.Equal(t, EventError, got[4].Event)
+	assert.Equal(t, "no such file", got[4].Message)
+	assert.Equal(t, EventStats, got[5].Event)
+	assert.Equal(t, 120, got[5].TotalTokens)
+	assert.Equal(t, int64(1500), got[5].DurationMs)
+}
+
+func TestStreamPrinter_EmitWithoutProgressJSON(t *testing.T) {
+	var out bytes.Buffer
+	printer := NewStreamPrinter(&out, WithColor(false))
+	assert.False(t, printer.ProgressJSON())
+
+	require.NoError(t, printer.Emit(ProgressEvent{Event: EventIteration, Iteration: 1}))
+	require.NoError(t, printer.PrintToolReturned("read_file", 120, 30))
+	assert.Equal(t, "✅ read_file returned 120 bytes (~30 tokens)\n", out.String())
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithRawStream writes the artifact of the run (a report, a PR description)
+// to w undecorated as it is generated, so it can be piped into another
+// renderer. The printer's other output should go to a different writer.
+func WithRawStream(w io.Writer) StreamPrinterOption {
+	return func(p *StreamPrinter) {
+		p.raw = w
+	}
+}
+
+// RawStream reports whether the printer streams the artifact raw
+func (p *StreamPrinter) RawStream() bool {
+	return p.raw != nil
+}
+
+// PrintArtifact writes text of the artifact to the raw stream. It does
+// nothing unless WithRawStream is set.
+func (p *StreamPrinter) PrintArtifact(text string) error {
+	if p.raw == nil {
+		return nil
+	}
+	if _, err := fmt.Fprint(p.raw, text); err != nil {
+		return err
+	}
+	if f, ok := p.raw.(Flusher); ok {
+		_ = f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPrinter_RawStream(t *testing.T) {
+	var out, raw bytes.Buffer
+	printer := NewStreamPrinter(&out, WithColor(false), WithRawStream(&raw))
+
+	assert.True(t, printer.RawStream())
+	_ = printer.PrintProgress("working")
+	_ = printer.PrintArtifact("# Report\n")
+
+	assert.Equal(t, "# Report\n", raw.String())
+	assert.NotContains(t, out.String(), "# Report")
+}
+
+func TestStreamPrinter_PrintArtifactWithoutRawStream(t *testing.T) {
+	var out bytes.Buffer
+	printer := NewStreamPrinter(&out)
+
+	assert.False(t, printer.RawStream())
+	assert.NoError(t, printer.PrintArtifact("# Report\n"))
+	assert.Empty(t, out.String())
+}
@@ -138,3 +138,45 @@ func TestSelectOption_DefaultIndexOutOfRange(t *testing.T) {
 		t.Errorf("expected default to be adjusted to 0, got %d", got)
 	}
 }
+
+func TestSelectMultiple(t *testing.T) {
+	options := []string{"security", "performance", "style", "bugs"}
+	defaults := []bool{true, false, false, true}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []bool
+	}{
+		{name: "keep defaults", input: "\n", want: []bool{true, false, false, true}},
+		{name: "numbers", input: "2, 3\n", want: []bool{false, true, true, false}},
+		{name: "all", input: "ALL\n", want: []bool{true, true, true, true}},
+		{name: "invalid then valid", input: "5\n1 4\n", want: []bool{true, false, false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			got, err := SelectMultiple("Review focus:", options, defaults, strings.NewReader(tt.input), output)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("SelectMultiple() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+			if !strings.Contains(output.String(), "[x] 1) security") {
+				t.Errorf("output should mark the default options, got %q", output.String())
+			}
+		})
+	}
+}
+
+func TestSelectMultiple_EOF(t *testing.T) {
+	_, err := SelectMultiple("Review focus:", []string{"security"}, nil, strings.NewReader(""), &bytes.Buffer{})
+	if err == nil {
+		t.Error("expected error on EOF")
+	}
+}
@@ -44,31 +44,56 @@ type StreamPrinter struct {
 	writer       io.Writer
 	colorEnabled bool
 	verbose      bool
+	events       *eventWriter // Set by WithProgressJSON
+	accessible   bool         // Plain prefixed lines, see SetAccessible
+	midLine      bool         // Accessible mode: streamed output did not end with a newline
+	raw          io.Writer    // Set by WithRawStream
+	width        int          // Set by WithWidth, see SetWidth
+	widthSet     bool
 }
 
 // NewStreamPrinter creates a new StreamPrinter
 func NewStreamPrinter(writer io.Writer, opts ...StreamPrinterOption) *StreamPrinter {
 	p := &StreamPrinter{
 		writer:       writer,
-		colorEnabled: true,
+		colorEnabled: !Accessible(),
 		verbose:      false,
+		accessible:   Accessible(),
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
+	if !p.widthSet {
+		p.width = int(width.Load())
+	}
+	if columns := outputWidth(writer, p.width); columns > 0 {
+		p.writer = newWrapWriter(writer, columns)
+	}
 
 	return p
 }
 
 // PrintToken prints a token from the LLM stream
 func (p *StreamPrinter) PrintToken(token string) error {
+	if p.events != nil {
+		return nil
+	}
+	if p.accessible {
+		p.trackLine(token)
+	}
 	_, err := fmt.Fprint(p.writer, token)
 	return err
 }
 
 // PrintToolCall prints information about a tool being called
 func (p *StreamPrinter) PrintToolCall(name string, args map[string]interface{}) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventToolCall, Tool: name})
+	}
+	if p.accessible {
+		return p.printPrefixed("TOOL", name)
+	}
 	if p.colorEnabled {
 		cyan := color.New(color.FgCyan)
 		_, err := cyan.Fprintf(p.writer, "\n🔧 Calling tool: %s\n", name)
@@ -80,9 +105,18 @@ func (p *StreamPrinter) PrintToolCall(name string, args map[string]interface{})
 
 // PrintToolResult prints the result of a tool call
 func (p *StreamPrinter) PrintToolResult(name string, result string, err error) error {
+	if p.events != nil {
+		if err != nil {
+			return p.Emit(ProgressEvent{Event: EventError, Tool: name, Message: err.Error()})
+		}
+		return p.Emit(ProgressEvent{Event: EventToolResult, Tool: name, Bytes: len(result)})
+	}
 	if err != nil {
 		return p.PrintError(fmt.Sprintf("Tool %s failed: %v", name, err))
 	}
+	if p.accessible {
+		return p.printPrefixed("RESULT", name+" done")
+	}
 
 	if p.verbose {
 		if p.colorEnabled {
@@ -104,8 +138,25 @@ func (p *StreamPrinter) PrintToolResult(name string, result string, err error) e
 	return e
 }
 
+// PrintToolReturned reports the size of a tool result
+func (p *StreamPrinter) PrintToolReturned(name string, bytes, tokens int) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventToolResult, Tool: name, Bytes: bytes, ResultTokens: tokens})
+	}
+	if p.accessible {
+		return p.printPrefixed("RESULT", fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
+	}
+	return p.PrintSuccess(fmt.Sprintf("%s returned %d bytes (~%d tokens)", name, bytes, tokens))
+}
+
 // PrintThinking prints thinking/planning information
 func (p *StreamPrinter) PrintThinking(message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventThinking, Message: message})
+	}
+	if p.accessible {
+		return p.printPrefixed("THINKING", message)
+	}
 	if p.colorEnabled {
 		gray := color.New(color.FgHiBlack)
 		_, err := gray.Fprintf(p.writer, "💭 %s\n", message)
@@ -117,6 +168,12 @@ func (p *StreamPrinter) PrintThinking(message string) error {
 
 // PrintStep prints a step in the process
 func (p *StreamPrinter) PrintStep(step int, message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventStep, Message: message, Iteration: step})
+	}
+	if p.accessible {
+		return p.printPrefixed(fmt.Sprintf("STEP %d", step), message)
+	}
 	if p.colorEnabled {
 		blue := color.New(color.FgBlue)
 		_, err := blue.Fprintf(p.writer, "📋 Step %d: %s\n", step, message)
@@ -128,6 +185,12 @@ func (p *StreamPrinter) PrintStep(step int, message string) error {
 
 // PrintProgress prints a progress message
 func (p *StreamPrinter) PrintProgress(message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventProgress, Message: message})
+	}
+	if p.accessible {
+		return p.printPrefixed("PROGRESS", message)
+	}
 	if p.colorEnabled {
 		yellow := color.New(color.FgYellow)
 		_, err := yellow.Fprintf(p.writer, "⏳ %s\n", message)
@@ -139,6 +202,12 @@ func (p *StreamPrinter) PrintProgress(message string) error {
 
 // PrintInfo prints an info message
 func (p *StreamPrinter) PrintInfo(message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventInfo, Message: message})
+	}
+	if p.accessible {
+		return p.printPrefixed("INFO", message)
+	}
 	if p.colorEnabled {
 		cyan := color.New(color.FgCyan)
 		_, err := cyan.Fprintf(p.writer, "ℹ️  %s\n", message)
@@ -150,6 +219,12 @@ func (p *StreamPrinter) PrintInfo(message string) error {
 
 // PrintSuccess prints a success message
 func (p *StreamPrinter) PrintSuccess(message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventSuccess, Message: message})
+	}
+	if p.accessible {
+		return p.printPrefixed("SUCCESS", message)
+	}
 	if p.colorEnabled {
 		green := color.New(color.FgGreen)
 		_, err := green.Fprintf(p.writer, "✅ %s\n", message)
@@ -167,6 +242,12 @@ type Flusher interface {
 // PrintLLMContent prints content from LLM (for streaming responses)
 // It flushes the output immediately if the writer supports it
 func (p *StreamPrinter) PrintLLMContent(content string) error {
+	if p.events != nil {
+		return nil
+	}
+	if p.accessible {
+		p.trackLine(content)
+	}
 	var err error
 	if p.colorEnabled {
 		white := color.New(color.FgWhite)
@@ -185,6 +266,12 @@ func (p *StreamPrinter) PrintLLMContent(content string) error {
 
 // PrintError prints an error message
 func (p *StreamPrinter) PrintError(message string) error {
+	if p.events != nil {
+		return p.Emit(ProgressEvent{Event: EventError, Message: message})
+	}
+	if p.accessible {
+		return p.printPrefixed("ERROR", message)
+	}
 	if p.colorEnabled {
 		red := color.New(color.FgRed)
 		_, err := red.Fprintf(p.writer, "❌ Error: %s\n", message)
@@ -199,10 +286,24 @@ func (p *StreamPrinter) PrintStats(stats *ExecutionStats) error {
 	if stats == nil {
 		return nil
 	}
+	if p.events != nil {
+		return p.Emit(ProgressEvent{
+			Event:            EventStats,
+			PromptTokens:     stats.PromptTokens,
+			CompletionTokens: stats.CompletionTokens,
+			TotalTokens:      stats.TotalTokens,
+			DurationMs:       stats.Duration().Milliseconds(),
+		})
+	}
 
 	duration := stats.Duration()
 	durationStr := formatDuration(duration)
 
+	if p.accessible {
+		return p.printPrefixed("STATS", fmt.Sprintf("%d tokens (prompt: %d, completion: %d), time: %s",
+			stats.TotalTokens, stats.PromptTokens, stats.CompletionTokens, durationStr))
+	}
+
 	if p.colorEnabled {
 		dim := color.New(color.FgHiBlack)
 		_, err := dim.Fprintf(p.writer, "\n📊 Stats: %d tokens (prompt: %d, completion: %d) | Time: %s\n",
@@ -217,6 +318,10 @@ func (p *StreamPrinter) PrintStats(stats *ExecutionStats) error {
 
 // Newline prints a newline
 func (p *StreamPrinter) Newline() error {
+	if p.events != nil {
+		return nil
+	}
+	p.midLine = false
 	_, err := fmt.Fprintln(p.writer)
 	return err
 }
@@ -224,6 +329,12 @@ func (p *StreamPrinter) Newline() error {
 // PrintToolArgChunk prints a chunk of tool call arguments in real-time
 // This allows users to see the tool call parameters as they stream in
 func (p *StreamPrinter) PrintToolArgChunk(chunk string) error {
+	if p.events != nil {
+		return nil
+	}
+	if p.accessible {
+		p.trackLine(chunk)
+	}
 	var err error
 	if p.colorEnabled {
 		dim := color.New(color.FgHiBlack)
@@ -242,6 +353,20 @@ func (p *StreamPrinter) PrintToolArgChunk(chunk string) error {
 
 // PrintToolArgStart prints the start of tool arguments display
 func (p *StreamPrinter) PrintToolArgStart() error {
+	if p.events != nil {
+		return nil
+	}
+	if p.accessible {
+		// Arguments stream in on the ARGS line
+		if p.midLine {
+			if _, err := fmt.Fprintln(p.writer); err != nil {
+				return err
+			}
+		}
+		p.midLine = true
+		_, err := fmt.Fprint(p.writer, "ARGS: ")
+		return err
+	}
 	if p.colorEnabled {
 		dim := color.New(color.FgHiBlack)
 		_, err := dim.Fprint(p.writer, "   └─ ")
@@ -253,6 +378,10 @@ func (p *StreamPrinter) PrintToolArgStart() error {
 
 // PrintToolArgEnd prints the end of tool arguments display
 func (p *StreamPrinter) PrintToolArgEnd() error {
+	if p.events != nil {
+		return nil
+	}
+	p.midLine = false
 	_, err := fmt.Fprintln(p.writer)
 	return err
 }
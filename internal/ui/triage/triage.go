@@ -0,0 +1,266 @@
+// Package triage provides an interactive terminal UI for triaging review issues.
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/agent/tools"
+)
+
+// ErrAborted is returned when the user quits triage without saving
+var ErrAborted = errors.New("triage aborted")
+
+// DefaultContextLines is the number of source lines shown around an issue
+const DefaultContextLines = 6
+
+// Options configures the triage UI
+type Options struct {
+	Diff         string    // Reviewed diff, used to show the hunk of each issue
+	WorkDir      string    // Working directory, used to show source context
+	ContextLines int       // Source lines shown before and after the issue line
+	Input        io.Reader // Defaults to stdin
+	Output       io.Writer // Defaults to stdout
+}
+
+// detailMode selects what the detail pane shows for the current issue
+type detailMode int
+
+const (
+	detailDescription detailMode = iota
+	detailDiff
+	detailSource
+)
+
+var detailModeNames = []string{"description", "diff", "source"}
+
+// model is the Bubble Tea model for the triage list
+type model struct {
+	result  *agent.TriageResult
+	opts    Options
+	cursor  int
+	detail  detailMode
+	height  int
+	aborted bool
+	done    bool
+}
+
+// Run shows the triage UI for the given result and records the user's decisions
+// in place. It returns ErrAborted if the user quits with Ctrl+C.
+func Run(ctx context.Context, result *agent.TriageResult, opts Options) (*agent.TriageResult, error) {
+	if result == nil || len(result.Issues) == 0 {
+		return result, nil
+	}
+
+	m := newModel(result, opts)
+	programOpts := []tea.ProgramOption{tea.WithContext(ctx), tea.WithAltScreen()}
+	if opts.Input != nil {
+		programOpts = append(programOpts, tea.WithInput(opts.Input))
+	}
+	if opts.Output != nil {
+		programOpts = append(programOpts, tea.WithOutput(opts.Output))
+	}
+
+	final, err := tea.NewProgram(m, programOpts...).Run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run triage UI: %w", err)
+	}
+	if final.(*model).aborted {
+		return nil, ErrAborted
+	}
+	return result, nil
+}
+
+func newModel(result *agent.TriageResult, opts Options) *model {
+	if opts.ContextLines <= 0 {
+		opts.ContextLines = DefaultContextLines
+	}
+	return &model{result: result, opts: opts, height: 24}
+}
+
+// Init implements the Bubble Tea Model interface
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements the Bubble Tea Model interface
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.aborted = true
+			return m, tea.Quit
+		case "q", "esc":
+			m.done = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.result.Issues)-1 {
+				m.cursor++
+			}
+		case "enter", "tab":
+			m.detail = (m.detail + 1) % detailMode(len(detailModeNames))
+		case "f":
+			m.mark(agent.TriageFix)
+		case "i":
+			m.mark(agent.TriageIgnore)
+		case "d":
+			m.mark(agent.TriageDefer)
+		case "u":
+			m.mark(agent.TriagePending)
+		}
+	}
+	return m, nil
+}
+
+// mark records a decision for the current issue and moves to the next one
+func (m *model) mark(decision string) {
+	m.result.Issues[m.cursor].Decision = decision
+	if decision != agent.TriagePending && m.cursor < len(m.result.Issues)-1 {
+		m.cursor++
+	}
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	detailStyle   = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("63")).
+			Padding(0, 1)
+
+	decisionBadges = map[string]string{
+		agent.TriagePending: "[ ? ]",
+		agent.TriageFix:     "[FIX]",
+		agent.TriageIgnore:  "[IGN]",
+		agent.TriageDefer:   "[DEF]",
+	}
+	severityColors = map[string]lipgloss.Color{
+		agent.SeverityError:   lipgloss.Color("196"),
+		agent.SeverityWarning: lipgloss.Color("214"),
+		agent.SeverityInfo:    lipgloss.Color("39"),
+	}
+)
+
+// View implements the Bubble Tea Model interface
+func (m *model) View() string {
+	if m.done || m.aborted {
+		return ""
+	}
+
+	var sb strings.Builder
+	counts := m.result.Counts()
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("Review Triage — %d fix · %d ignore · %d defer · %d pending",
+		counts[agent.TriageFix], counts[agent.TriageIgnore], counts[agent.TriageDefer], counts[agent.TriagePending])))
+	sb.WriteString("\n\n")
+
+	// Keep the list to roughly a third of the screen so the detail pane fits
+	listHeight := max(m.height/3, 3)
+	start := max(m.cursor-listHeight/2, 0)
+	end := min(start+listHeight, len(m.result.Issues))
+	start = max(end-listHeight, 0)
+
+	for i := start; i < end; i++ {
+		line := m.issueLine(i)
+		if i == m.cursor {
+			sb.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			sb.WriteString("  " + line)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(detailStyle.Render(m.detailView()))
+	sb.WriteString("\n")
+	sb.WriteString(helpStyle.Render(fmt.Sprintf("↑/↓ navigate · f fix · i ignore · d defer · u undo · enter %s view · q save & quit · ctrl+c abort",
+		detailModeNames[(int(m.detail)+1)%len(detailModeNames)])))
+	return sb.String()
+}
+
+// issueLine renders the list entry for an issue
+func (m *model) issueLine(i int) string {
+	issue := m.result.Issues[i]
+	severity := lipgloss.NewStyle().Foreground(severityColors[issue.Severity]).Render(fmt.Sprintf("%-7s", issue.Severity))
+	return fmt.Sprintf("%s %s %s %s", decisionBadges[issue.Decision], severity, issueLocation(issue.ReviewIssue), issue.Title)
+}
+
+// detailView renders the detail pane for the current issue
+func (m *model) detailView() string {
+	issue := m.result.Issues[m.cursor].ReviewIssue
+
+	switch m.detail {
+	case detailDiff:
+		hunk := tools.DiffHunkAt(m.opts.Diff, issue.File, issue.Line)
+		if hunk == "" {
+			return "No diff hunk found for " + issueLocation(issue)
+		}
+		return hunk
+	case detailSource:
+		return m.sourceContext(issue)
+	default:
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s · %s · %s\n\n", issue.Severity, issue.Category, issueLocation(issue)))
+		sb.WriteString(issue.Description)
+		if issue.Suggestion != "" {
+			sb.WriteString("\n\nSuggestion: " + issue.Suggestion)
+		}
+		return sb.String()
+	}
+}
+
+// sourceContext renders the source lines around the issue line
+func (m *model) sourceContext(issue agent.ReviewIssue) string {
+	if issue.File == "" || issue.Line <= 0 {
+		return "No source location for this issue"
+	}
+
+	content, err := os.ReadFile(filepath.Join(m.opts.WorkDir, issue.File))
+	if err != nil {
+		return fmt.Sprintf("Cannot read %s: %v", issue.File, err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if issue.Line > len(lines) {
+		return fmt.Sprintf("Line %d is past the end of %s", issue.Line, issue.File)
+	}
+
+	from := max(issue.Line-m.opts.ContextLines, 1)
+	to := min(issue.Line+m.opts.ContextLines, len(lines))
+	var sb strings.Builder
+	for i := from; i <= to; i++ {
+		marker := " "
+		if i == issue.Line {
+			marker = ">"
+		}
+		sb.WriteString(fmt.Sprintf("%s%6d | %s\n", marker, i, lines[i-1]))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// issueLocation formats an issue's file and line
+func issueLocation(issue agent.ReviewIssue) string {
+	if issue.File == "" {
+		return "(general)"
+	}
+	if issue.Line > 0 {
+		return fmt.Sprintf("%s:%d", issue.File, issue.Line)
+	}
+	return issue.File
+}
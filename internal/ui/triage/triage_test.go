@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+)
+
+func newTestModel(t *testing.T, opts Options) *model {
+	t.Helper()
+	result := agent.NewTriageResult(&agent.ReviewResponse{
+		Issues: []agent.ReviewIssue{
+			{Severity: agent.SeverityError, Category: "bug", File: "main.go", Line: 3, Title: "nil deref", Description: "x may be nil"},
+			{Severity: agent.SeverityWarning, Category: "style", File: "main.go", Line: 5, Title: "naming"},
+			{Severity: agent.SeverityInfo, Category: "suggestion", Title: "general note"},
+		},
+	})
+	return newModel(result, opts)
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestModel_MarkDecisions(t *testing.T) {
+	m := newTestModel(t, Options{})
+
+	m.Update(key("f"))
+	assert.Equal(t, 1, m.cursor, "marking advances to the next issue")
+	m.Update(key("i"))
+	m.Update(key("d"))
+	assert.Equal(t, 2, m.cursor, "cursor stays on the last issue")
+
+	assert.Equal(t, agent.TriageFix, m.result.Issues[0].Decision)
+	assert.Equal(t, agent.TriageIgnore, m.result.Issues[1].Decision)
+	assert.Equal(t, agent.TriageDefer, m.result.Issues[2].Decision)
+
+	m.Update(key("up"))
+	m.Update(key("u"))
+	assert.Equal(t, agent.TriagePending, m.result.Issues[1].Decision)
+	assert.Equal(t, 1, m.cursor)
+}
+
+func TestModel_Quit(t *testing.T) {
+	m := newTestModel(t, Options{})
+	_, cmd := m.Update(key("q"))
+	assert.NotNil(t, cmd)
+	assert.True(t, m.done)
+	assert.False(t, m.aborted)
+
+	m = newTestModel(t, Options{})
+	m.Update(key("ctrl+c"))
+	assert.True(t, m.aborted)
+}
+
+func TestModel_DetailViews(t *testing.T) {
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "main.go"), []byte("package main\n\nvar x *int\n\nfunc f() {}\n"), 0644))
+
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,3 @@\n package main\n \n+var x *int\n"
+	m := newTestModel(t, Options{Diff: diff, WorkDir: workDir, ContextLines: 1})
+
+	assert.Contains(t, m.View(), "x may be nil")
+	assert.Contains(t, m.View(), "main.go:3")
+
+	m.Update(key("enter"))
+	assert.Contains(t, m.View(), "+var x *int")
+
+	m.Update(key("enter"))
+	view := m.View()
+	assert.Contains(t, view, ">     3 | var x *int")
+	assert.NotContains(t, view, "func f()")
+
+	// Issues without a location have no diff or source
+	m.cursor = 2
+	assert.Contains(t, m.View(), "No source location")
+	m.Update(key("enter"))
+	assert.Contains(t, m.View(), "general note")
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// NoWrap disables wrapping in SetWidth and WithWidth
+const NoWrap = -1
+
+// minWrapWidth is the narrowest width output is wrapped at; narrower
+// terminals wrap by themselves
+const minWrapWidth = 20
+
+// maxHeadRunes is how much of a line is kept to indent its continuation lines
+const maxHeadRunes = 24
+
+// width is set by SetWidth and read by printers created afterwards
+var width atomic.Int64
+
+// SetWidth sets the column printers wrap their output at: 0 detects the
+// width of terminals (other writers aren't wrapped), NoWrap disables wrapping
+func SetWidth(columns int) {
+	width.Store(int64(columns))
+}
+
+// WithWidth overrides the width set with SetWidth
+func WithWidth(columns int) StreamPrinterOption {
+	return func(p *StreamPrinter) {
+		p.width = columns
+		p.widthSet = true
+	}
+}
+
+// outputWidth returns the column to wrap the output to w at, 0 for none
+func outputWidth(w io.Writer, columns int) int {
+	switch {
+	case columns == 0:
+		f, ok := w.(*os.File)
+		if !ok || !term.IsTerminal(f.Fd()) {
+			return 0
+		}
+		detected, _, err := term.GetSize(f.Fd())
+		if err != nil {
+			return 0
+		}
+		columns = detected
+	case columns < 0:
+		return 0
+	}
+	if columns < minWrapWidth {
+		return 0
+	}
+	return columns
+}
+
+// listMarkerPattern matches the indentation and marker of a list item, under
+// whose text its continuation lines are indented
+var listMarkerPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+
+// wrapWriter soft-wraps the text written through it at width columns,
+// breaking lines at spaces. Escape sequences take no columns, and
+// continuation lines are indented like the line they continue, under the
+// text of a list item. Text is passed on as it arrives, except for spaces,
+// which are held until it is known whether the next word fits on the line.
+// A word that continues the previous write and no longer fits is broken at
+// the end of the line, since text already written can't be moved.
+type wrapWriter struct {
+	w      io.Writer
+	width  int
+	col    int    // Column of the cursor, not counting held spaces
+	spaces int    // Spaces held back
+	head   []rune // Start of the current line, for the indentation
+	escape bool   // Inside an escape sequence
+	csi    bool   // Inside a control sequence (ESC [)
+	rest   []byte // Incomplete UTF-8 sequence at the end of the last write
+}
+
+func newWrapWriter(w io.Writer, width int) *wrapWriter {
+	return &wrapWriter{w: w, width: width}
+}
+
+// Write wraps p and writes it. It always reports p as written when the
+// underlying writer accepts the wrapped text.
+func (ww *wrapWriter) Write(p []byte) (int, error) {
+	data := append(ww.rest, p...)
+	ww.rest = nil
+	var out []byte
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(data[i:]) {
+			ww.rest = append([]byte(nil), data[i:]...)
+			break
+		}
+		chunk := data[i : i+size]
+		i += size
+
+		switch {
+		case ww.escape:
+			out = append(out, chunk...)
+			ww.endEscape(r)
+		case r == '\x1b':
+			out = append(out, chunk...)
+			ww.escape = true
+		case r == '\n' || r == '\r':
+			ww.spaces = 0
+			out = append(out, chunk...)
+			ww.col = 0
+			ww.head = ww.head[:0]
+		case r == ' ':
+			ww.spaces++
+			ww.remember(r)
+		default:
+			rw := runewidth.RuneWidth(r)
+			if ww.spaces > 0 {
+				// The start of a word: break before it unless it fits
+				if ww.col > ww.indent() && ww.col+ww.spaces+ww.wordWidth(r, data[i:]) > ww.width {
+					out = ww.newline(out)
+				} else {
+					for ; ww.spaces > 0; ww.spaces-- {
+						out = append(out, ' ')
+						ww.col++
+					}
+				}
+			} else if ww.col+rw > ww.width && ww.col > ww.indent() {
+				out = ww.newline(out)
+			}
+			out = append(out, chunk...)
+			ww.col += rw
+			ww.remember(r)
+		}
+	}
+	if _, err := ww.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush flushes the underlying writer when it supports flushing
+func (ww *wrapWriter) Flush() error {
+	if f, ok := ww.w.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// endEscape tracks the end of an escape sequence at r: the final byte of a
+// control sequence, or the character after ESC for other sequences
+func (ww *wrapWriter) endEscape(r rune) {
+	if !ww.csi && r == '[' {
+		ww.csi = true
+		return
+	}
+	if !ww.csi || (r >= 0x40 && r <= 0x7e) {
+		ww.escape, ww.csi = false, false
+	}
+}
+
+// newline starts a continuation line, dropping the held spaces
+func (ww *wrapWriter) newline(out []byte) []byte {
+	indent := ww.indent()
+	ww.spaces = 0
+	out = append(out, '\n')
+	for i := 0; i < indent; i++ {
+		out = append(out, ' ')
+	}
+	ww.col = indent
+	return out
+}
+
+// indent returns the indentation of continuation lines of the current line:
+// its leading spaces, or up to the text of a list item
+func (ww *wrapWriter) indent() int {
+	head := string(ww.head)
+	n := len(head) - len(trimLeftSpaces(head))
+	if m := listMarkerPattern.FindString(head); m != "" {
+		n = runewidth.StringWidth(m)
+	}
+	if n > ww.width/2 {
+		return 0
+	}
+	return n
+}
+
+// remember records r as part of the start of the line
+func (ww *wrapWriter) remember(r rune) {
+	if len(ww.head) < maxHeadRunes {
+		ww.head = append(ww.head, r)
+	}
+}
+
+// wordWidth returns the width of the word starting with first and going on in
+// rest, as far as it was written
+func (ww *wrapWriter) wordWidth(first rune, rest []byte) int {
+	n := runewidth.RuneWidth(first)
+	for len(rest) > 0 {
+		r, size := utf8.DecodeRune(rest)
+		if r == ' ' || r == '\n' || r == '\r' || r == '\x1b' || r == utf8.RuneError {
+			break
+		}
+		n += runewidth.RuneWidth(r)
+		rest = rest[size:]
+	}
+	return n
+}
+
+func trimLeftSpaces(s string) string {
+	for len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+		s = s[1:]
+	}
+	return s
+}
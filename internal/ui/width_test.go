@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		writes []string
+		want   string
+	}{
+		{
+			name:   "breaks at spaces",
+			width:  20,
+			writes: []string{"The cache key ignores the tenant of the request."},
+			want:   "The cache key\nignores the tenant\nof the request.",
+		},
+		{
+			name:   "streamed tokens",
+			width:  20,
+			writes: []string{"The", " cache", " key", " ignores", " the", " tenant", " of", " the", " request."},
+			want:   "The cache key\nignores the tenant\nof the request.",
+		},
+		{
+			name:   "keeps newlines and indentation",
+			width:  20,
+			writes: []string{"Root cause:\n\n    if key == nil {\n"},
+			want:   "Root cause:\n\n    if key == nil {\n",
+		},
+		{
+			name:   "indents list items",
+			width:  24,
+			writes: []string{"- Include the tenant in the cache key\n  12. Rotate the signing key on deploy"},
+			want:   "- Include the tenant in\n  the cache key\n  12. Rotate the signing\n      key on deploy",
+		},
+		{
+			name:   "breaks long words",
+			width:  20,
+			writes: []string{"See internal/agent/debug_agent.go"},
+			want:   "See\ninternal/agent/debug\n_agent.go",
+		},
+		{
+			name:   "escape sequences take no columns",
+			width:  20,
+			writes: []string{"\x1b[37mThe cache key ignores\x1b[0m"},
+			want:   "\x1b[37mThe cache key\nignores\x1b[0m",
+		},
+		{
+			name:   "wide characters",
+			width:  20,
+			writes: []string{"缓存键 忽略了 请求的 租户信息 导致 数据混乱"},
+			want:   "缓存键 忽略了 请求的\n租户信息 导致\n数据混乱",
+		},
+		{
+			name:   "split multibyte rune",
+			width:  20,
+			writes: []string{"caf\xc3", "\xa9 ok"},
+			want:   "café ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newWrapWriter(&buf, tt.width)
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				assert.NoError(t, err)
+				assert.Equal(t, len(s), n)
+			}
+			assert.Equal(t, tt.want, buf.String())
+			for _, line := range strings.Split(buf.String(), "\n") {
+				assert.LessOrEqual(t, len([]rune(line)), tt.width)
+			}
+		})
+	}
+}
+
+func TestStreamPrinter_Width(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewStreamPrinter(&buf, WithColor(false), WithWidth(30))
+	_ = p.PrintLLMContent("The token cache returned expired tokens after the key rotation.\n")
+	_ = p.PrintInfo("📋 Current Tasks:\n  1. ⏳ Check how the signing key is reloaded on rotation")
+	assert.Equal(t, "The token cache returned\nexpired tokens after the key\nrotation.\n"+
+		"ℹ️  📋 Current Tasks:\n  1. ⏳ Check how the signing\n     key is reloaded on\n     rotation\n", buf.String())
+
+	// Buffers aren't terminals, so they are only wrapped at a set width
+	buf.Reset()
+	long := strings.Repeat("word ", 40)
+	_ = NewStreamPrinter(&buf, WithColor(false)).PrintLLMContent(long)
+	assert.Equal(t, long, buf.String())
+	buf.Reset()
+	_ = NewStreamPrinter(&buf, WithColor(false), WithWidth(NoWrap)).PrintLLMContent(long)
+	assert.Equal(t, long, buf.String())
+}
+
+func TestOutputWidth(t *testing.T) {
+	assert.Equal(t, 100, outputWidth(&bytes.Buffer{}, 100))
+	assert.Zero(t, outputWidth(&bytes.Buffer{}, 0))
+	assert.Zero(t, outputWidth(&bytes.Buffer{}, NoWrap))
+	assert.Zero(t, outputWidth(&bytes.Buffer{}, 10), "too narrow to wrap")
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Zero(t, outputWidth(f, 0), "files aren't terminals")
+}
@@ -0,0 +1,201 @@
+// Package usage records the tokens and cost of model requests in a ledger,
+// a local file or a backend shared by a team, and enforces the monthly
+// quotas configured in quota.
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry is the usage of one model in one run
+type Entry struct {
+	Time             time.Time `json:"time"`
+	User             string    `json:"user,omitempty"`    // git user.email, or the OS user
+	Project          string    `json:"project,omitempty"` // Name of the repository
+	Model            string    `json:"model"`             // Name of the model in the config
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	Cost             float64   `json:"cost,omitempty"` // USD, from the model's prices
+}
+
+// Tokens returns the prompt and completion tokens of the entry
+func (e Entry) Tokens() int64 {
+	return e.PromptTokens + e.CompletionTokens
+}
+
+// Ledger stores usage entries
+type Ledger interface {
+	// Entries returns the entries recorded at or after since
+	Entries(ctx context.Context, since time.Time) ([]Entry, error)
+	// Append records entries
+	Append(ctx context.Context, entries []Entry) error
+}
+
+// MonthStart returns the start of the calendar month of t, in t's location
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// FileLedger stores entries as JSON lines in a file
+type FileLedger struct {
+	path string
+}
+
+// NewFileLedger creates a ledger in the file at path, created on the first Append
+func NewFileLedger(path string) *FileLedger {
+	return &FileLedger{path: path}
+}
+
+// Entries returns the entries of the file recorded at or after since.
+// Lines that don't parse, e.g. from an interrupted write, are skipped.
+func (l *FileLedger) Entries(ctx context.Context, since time.Time) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// Append adds entries to the end of the file. Unlike other writes it is
+// allowed in --check mode, so that checks count towards the quota too.
+func (l *FileLedger) Append(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode usage: %w", err)
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	// One write per run, so concurrent runs don't interleave lines
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// RemoteLedger stores entries in a usage backend shared by a team
+type RemoteLedger struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewRemoteLedger creates a ledger of the backend at endpoint, authenticated
+// with token when it is set
+func NewRemoteLedger(endpoint, token string) *RemoteLedger {
+	return &RemoteLedger{
+		url:    endpoint,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Entries returns the entries the backend recorded at or after since
+func (l *RemoteLedger) Entries(ctx context.Context, since time.Time) ([]Entry, error) {
+	endpoint, err := url.Parse(l.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid usage backend URL: %w", err)
+	}
+	query := endpoint.Query()
+	query.Set("since", since.UTC().Format(time.RFC3339))
+	endpoint.RawQuery = query.Encode()
+
+	var entries []Entry
+	if err := l.do(ctx, http.MethodGet, endpoint.String(), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Append sends entries to the backend, in --check mode too
+func (l *RemoteLedger) Append(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+	return l.do(ctx, http.MethodPost, l.url, body, nil)
+}
+
+// do sends a request to the backend and decodes its JSON response into v
+// unless v is nil
+func (l *RemoteLedger) do(ctx context.Context, method, endpoint string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach usage backend: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("usage backend returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode usage backend response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage", "usage.jsonl")
+	ledger := NewFileLedger(path)
+	ctx := context.Background()
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	entries, err := ledger.Entries(ctx, march)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing ledger has no entries")
+
+	require.NoError(t, ledger.Append(ctx, []Entry{
+		{Time: march.Add(-time.Hour), Model: "deepseek", PromptTokens: 10},
+		{Time: march.Add(time.Hour), Model: "deepseek", PromptTokens: 100, CompletionTokens: 20, Cost: 0.5},
+	}))
+	// An interrupted write leaves a partial line behind
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString("{\"time\":\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, ledger.Append(ctx, []Entry{{Time: march.Add(2 * time.Hour), Model: "openai", CompletionTokens: 5}}))
+
+	entries, err = ledger.Entries(ctx, march)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(120), entries[0].Tokens())
+	assert.Equal(t, 0.5, entries[0].Cost)
+	assert.Equal(t, "openai", entries[1].Model)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRemoteLedger(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var posted []Entry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, "team-a", r.URL.Query().Get("team"))
+			assert.Equal(t, "2026-03-01T00:00:00Z", r.URL.Query().Get("since"))
+			_ = json.NewEncoder(w).Encode([]Entry{{Time: since, User: "ann@example.com", Model: "deepseek", PromptTokens: 42}})
+		case http.MethodPost:
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ledger := NewRemoteLedger(server.URL+"/usage?team=team-a", "secret")
+	entries, err := ledger.Entries(context.Background(), since)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ann@example.com", entries[0].User)
+
+	require.NoError(t, ledger.Append(context.Background(), []Entry{{Time: since, Model: "openai", CompletionTokens: 7}}))
+	require.Len(t, posted, 1)
+	assert.Equal(t, int64(7), posted[0].CompletionTokens)
+}
+
+func TestRemoteLedger_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewRemoteLedger(server.URL, "").Entries(context.Background(), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Contains(t, err.Error(), "invalid token")
+}
+
+func TestMonthStart(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, loc), MonthStart(time.Date(2026, 10, 16, 13, 5, 0, 0, loc)))
+}
@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+)
+
+// WithTracker wraps provider, configured under the name model, so that its
+// chat models refuse requests once a quota is used up and record the tokens
+// of their responses in t
+func WithTracker(provider llm.Provider, model string, t *Tracker) llm.Provider {
+	return &trackedProvider{Provider: provider, model: model, tracker: t}
+}
+
+type trackedProvider struct {
+	llm.Provider
+	model   string
+	tracker *Tracker
+}
+
+func (p *trackedProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	chatModel, err := p.Provider.CreateChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mc := p.GetConfig()
+	return &trackedModel{
+		ChatModel:   chatModel,
+		model:       p.model,
+		tracker:     p.tracker,
+		inputPrice:  mc.InputPrice,
+		outputPrice: mc.OutputPrice,
+	}, nil
+}
+
+// trackedModel checks the quota before and records the usage after each call
+type trackedModel struct {
+	model.ChatModel
+	model       string
+	tracker     *Tracker
+	inputPrice  float64 // USD per million tokens
+	outputPrice float64
+}
+
+func (m *trackedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := m.tracker.Check(m.model); err != nil {
+		return nil, err
+	}
+	msg, err := m.ChatModel.Generate(ctx, input, opts...)
+	if msg != nil {
+		m.record(msg)
+	}
+	return msg, err
+}
+
+func (m *trackedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := m.tracker.Check(m.model); err != nil {
+		return nil, err
+	}
+	stream, err := m.ChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderWithConvert(stream, func(chunk *schema.Message) (*schema.Message, error) {
+		m.record(chunk)
+		return chunk, nil
+	}), nil
+}
+
+// record adds the token usage reported with msg
+func (m *trackedModel) record(msg *schema.Message) {
+	if msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return
+	}
+	prompt := int64(msg.ResponseMeta.Usage.PromptTokens)
+	completion := int64(msg.ResponseMeta.Usage.CompletionTokens)
+	cost := (float64(prompt)*m.inputPrice + float64(completion)*m.outputPrice) / 1e6
+	m.tracker.Record(m.model, prompt, completion, cost)
+}
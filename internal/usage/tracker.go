@@ -0,0 +1,220 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/log"
+)
+
+// WarnRatio is the share of a limit from which the user is warned
+const WarnRatio = 0.8
+
+// Amount is a number of tokens and their cost in USD
+type Amount struct {
+	Tokens int64
+	Cost   float64
+}
+
+// Quota is the monthly limits a tracker enforces; zero limits nothing
+type Quota struct {
+	Total  Amount            // All models together
+	Models map[string]Amount // Single models by name
+}
+
+// QuotaExceededError is a model request refused because a monthly quota is used up
+type QuotaExceededError struct {
+	Model string // Model of the quota, "" for the quota of all models
+	Used  string // e.g. "1200345 tokens" or "$50.12"
+	Limit string
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Model == "" {
+		return fmt.Sprintf("monthly usage quota used up: %s of %s", e.Used, e.Limit)
+	}
+	return fmt.Sprintf("monthly usage quota of model %s used up: %s of %s", e.Model, e.Used, e.Limit)
+}
+
+// Hint tells the user how to fix the failure
+func (e *QuotaExceededError) Hint() string {
+	return "The monthly quota configured in quota is used up. Wait for the next month, ask whoever manages the config to raise the limit, pick another model with --model, or pass --ignore-quota to go over it for this run."
+}
+
+// ExitCode returns the exit code of the failure
+func (e *QuotaExceededError) ExitCode() int { return llm.ExitQuotaExceeded }
+
+// limitStatus is the usage of one configured limit
+type limitStatus struct {
+	key   string // Identifies the limit for warnings, e.g. "deepseek/cost"
+	model string
+	used  string
+	limit string
+	ratio float64
+}
+
+// Tracker counts the usage of the month against the quota, refusing model
+// requests once a limit is reached, and keeps the usage of the current run
+// for the ledger
+type Tracker struct {
+	mu      sync.Mutex
+	quota   Quota
+	enforce bool
+	warn    func(msg string)
+	warned  map[string]bool
+	total   Amount
+	byModel map[string]Amount
+	run     map[string]*Entry
+}
+
+// NewTracker creates a tracker of quota. history is the usage recorded this
+// month. With enforce false, limits that are reached are only warned about.
+// warn shows warnings, log.Warn when nil; limits already near or over in
+// history are warned about right away.
+func NewTracker(quota Quota, history []Entry, enforce bool, warn func(msg string)) *Tracker {
+	if warn == nil {
+		warn = func(msg string) { log.Warn("%s", msg) }
+	}
+	t := &Tracker{
+		quota:   quota,
+		enforce: enforce,
+		warn:    warn,
+		warned:  make(map[string]bool),
+		byModel: make(map[string]Amount),
+		run:     make(map[string]*Entry),
+	}
+	for _, entry := range history {
+		t.add(entry.Model, entry.Tokens(), entry.Cost)
+	}
+	t.mu.Lock()
+	t.warnLocked()
+	t.mu.Unlock()
+	return t
+}
+
+// Check returns a *QuotaExceededError when a limit that applies to model is reached
+func (t *Tracker) Check(model string) error {
+	if !t.enforce {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, status := range t.statusLocked() {
+		if status.ratio >= 1 && (status.model == "" || status.model == model) {
+			return &QuotaExceededError{Model: status.model, Used: status.used, Limit: status.limit}
+		}
+	}
+	return nil
+}
+
+// Record adds the usage of a request to model and warns about limits it
+// brings near or over
+func (t *Tracker) Record(model string, promptTokens, completionTokens int64, cost float64) {
+	if promptTokens == 0 && completionTokens == 0 && cost == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.run[model]
+	if !ok {
+		entry = &Entry{Model: model}
+		t.run[model] = entry
+	}
+	entry.PromptTokens += promptTokens
+	entry.CompletionTokens += completionTokens
+	entry.Cost += cost
+	t.addLocked(model, promptTokens+completionTokens, cost)
+	t.warnLocked()
+}
+
+// Pending returns the usage recorded since the last call, one entry per
+// model stamped with now, for the ledger
+func (t *Tracker) Pending(now time.Time) []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]Entry, 0, len(t.run))
+	for _, entry := range t.run {
+		entry.Time = now
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Model < entries[j].Model })
+	t.run = make(map[string]*Entry)
+	return entries
+}
+
+func (t *Tracker) add(model string, tokens int64, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addLocked(model, tokens, cost)
+}
+
+func (t *Tracker) addLocked(model string, tokens int64, cost float64) {
+	t.total.Tokens += tokens
+	t.total.Cost += cost
+	used := t.byModel[model]
+	used.Tokens += tokens
+	used.Cost += cost
+	t.byModel[model] = used
+}
+
+// warnLocked warns once about each limit reaching WarnRatio, and once more
+// about limits that are exceeded without being enforced
+func (t *Tracker) warnLocked() {
+	for _, status := range t.statusLocked() {
+		name := "the monthly usage quota"
+		if status.model != "" {
+			name = "the monthly usage quota of model " + status.model
+		}
+		switch {
+		case status.ratio >= 1 && !t.enforce && !t.warned[status.key+"/exceeded"]:
+			t.warned[status.key+"/exceeded"] = true
+			t.warned[status.key] = true
+			t.warn(fmt.Sprintf("%s is exceeded: %s of %s", name, status.used, status.limit))
+		case status.ratio >= WarnRatio && !t.warned[status.key]:
+			t.warned[status.key] = true
+			t.warn(fmt.Sprintf("%.0f%% of %s is used: %s of %s", status.ratio*100, name, status.used, status.limit))
+		}
+	}
+}
+
+// statusLocked returns the usage of every configured limit, the quota of
+// all models first
+func (t *Tracker) statusLocked() []limitStatus {
+	statuses := amountStatus("", t.total, t.quota.Total)
+	models := make([]string, 0, len(t.quota.Models))
+	for model := range t.quota.Models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		statuses = append(statuses, amountStatus(model, t.byModel[model], t.quota.Models[model])...)
+	}
+	return statuses
+}
+
+// amountStatus returns the usage of the token and cost limits that are set in limit
+func amountStatus(model string, used, limit Amount) []limitStatus {
+	var statuses []limitStatus
+	if limit.Tokens > 0 {
+		statuses = append(statuses, limitStatus{
+			key:   model + "/tokens",
+			model: model,
+			used:  fmt.Sprintf("%d tokens", used.Tokens),
+			limit: fmt.Sprintf("%d tokens", limit.Tokens),
+			ratio: float64(used.Tokens) / float64(limit.Tokens),
+		})
+	}
+	if limit.Cost > 0 {
+		statuses = append(statuses, limitStatus{
+			key:   model + "/cost",
+			model: model,
+			used:  fmt.Sprintf("$%.2f", used.Cost),
+			limit: fmt.Sprintf("$%.2f", limit.Cost),
+			ratio: used.Cost / limit.Cost,
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,146 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_Check(t *testing.T) {
+	quota := Quota{
+		Total:  Amount{Cost: 10},
+		Models: map[string]Amount{"deepseek": {Tokens: 1000}},
+	}
+	var warnings []string
+	tracker := NewTracker(quota, []Entry{
+		{Model: "deepseek", PromptTokens: 500},
+		{Model: "openai", PromptTokens: 5000, Cost: 2},
+	}, true, func(msg string) { warnings = append(warnings, msg) })
+	assert.Empty(t, warnings)
+	assert.NoError(t, tracker.Check("deepseek"))
+
+	tracker.Record("deepseek", 250, 100, 0)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "85% of the monthly usage quota of model deepseek is used: 850 tokens of 1000 tokens", warnings[0])
+	tracker.Record("deepseek", 10, 0, 0)
+	assert.Len(t, warnings, 1, "each limit is warned about once")
+
+	tracker.Record("deepseek", 200, 0, 0)
+	var quotaErr *QuotaExceededError
+	require.ErrorAs(t, tracker.Check("deepseek"), &quotaErr)
+	assert.Equal(t, "monthly usage quota of model deepseek used up: 1060 tokens of 1000 tokens", quotaErr.Error())
+	assert.Equal(t, llm.ExitQuotaExceeded, quotaErr.ExitCode())
+	assert.NoError(t, tracker.Check("openai"), "other models have no limit of their own")
+
+	tracker.Record("openai", 0, 0, 8)
+	assert.ErrorAs(t, tracker.Check("openai"), &quotaErr)
+	assert.Equal(t, "", quotaErr.Model)
+	assert.Contains(t, quotaErr.Error(), "$10.00 of $10.00")
+}
+
+func TestTracker_NotEnforced(t *testing.T) {
+	var warnings []string
+	tracker := NewTracker(Quota{Total: Amount{Tokens: 100}}, []Entry{{Model: "deepseek", PromptTokens: 120}}, false,
+		func(msg string) { warnings = append(warnings, msg) })
+
+	assert.NoError(t, tracker.Check("deepseek"))
+	assert.Equal(t, []string{"the monthly usage quota is exceeded: 120 tokens of 100 tokens"}, warnings)
+}
+
+func TestTracker_Pending(t *testing.T) {
+	tracker := NewTracker(Quota{}, nil, true, nil)
+	tracker.Record("openai", 10, 5, 0.01)
+	tracker.Record("deepseek", 1, 2, 0)
+	tracker.Record("openai", 20, 0, 0.02)
+	tracker.Record("openai", 0, 0, 0)
+
+	now := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	entries := tracker.Pending(now)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{Time: now, Model: "deepseek", PromptTokens: 1, CompletionTokens: 2}, entries[0])
+	assert.Equal(t, "openai", entries[1].Model)
+	assert.Equal(t, int64(35), entries[1].Tokens())
+	assert.InDelta(t, 0.03, entries[1].Cost, 1e-9)
+	assert.Empty(t, tracker.Pending(now), "recorded entries are only returned once")
+}
+
+// usageChatModel streams a reply in two chunks reporting usage
+type usageChatModel struct {
+	calls int
+}
+
+func (m *usageChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls++
+	return &schema.Message{Role: schema.Assistant, Content: "hello", ResponseMeta: &schema.ResponseMeta{
+		Usage: &schema.TokenUsage{PromptTokens: 1000, CompletionTokens: 100},
+	}}, nil
+}
+
+func (m *usageChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	return schema.StreamReaderFromArray([]*schema.Message{
+		{Role: schema.Assistant, Content: "hel"},
+		{Role: schema.Assistant, Content: "lo", ResponseMeta: &schema.ResponseMeta{
+			Usage: &schema.TokenUsage{PromptTokens: 1000, CompletionTokens: 100},
+		}},
+	}), nil
+}
+
+func (m *usageChatModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+type usageProvider struct {
+	chatModel *usageChatModel
+}
+
+func (p *usageProvider) Name() string { return "deepseek" }
+
+func (p *usageProvider) GetConfig() config.ModelConfig {
+	return config.ModelConfig{Provider: "deepseek", Model: "deepseek-chat", InputPrice: 1, OutputPrice: 2}
+}
+
+func (p *usageProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return p.chatModel, nil
+}
+
+func TestWithTracker(t *testing.T) {
+	chatModel := &usageChatModel{}
+	tracker := NewTracker(Quota{Models: map[string]Amount{"deepseek": {Tokens: 2000}}}, nil, true, func(string) {})
+	provider := WithTracker(&usageProvider{chatModel: chatModel}, "deepseek", tracker)
+
+	tracked, err := provider.CreateChatModel(context.Background())
+	require.NoError(t, err)
+	stream, err := tracked.Stream(context.Background(), nil)
+	require.NoError(t, err)
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	_, err = tracked.Generate(context.Background(), nil)
+	require.NoError(t, err)
+
+	// The quota is used up, so the next request doesn't reach the model
+	_, err = tracked.Stream(context.Background(), nil)
+	var userErr llm.UserError
+	require.ErrorAs(t, llm.ExplainError("LLM stream failed", err), &userErr)
+	assert.Equal(t, llm.ExitQuotaExceeded, userErr.ExitCode())
+	assert.Contains(t, userErr.Hint(), "--ignore-quota")
+	assert.Equal(t, 2, chatModel.calls)
+
+	entries := tracker.Pending(time.Now())
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(2000), entries[0].PromptTokens)
+	assert.Equal(t, int64(200), entries[0].CompletionTokens)
+	assert.InDelta(t, 0.0024, entries[0].Cost, 1e-9)
+}
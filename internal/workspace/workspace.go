@@ -0,0 +1,84 @@
+// Package workspace resolves the directory commands and their agents work in:
+// an absolute, symlink-free path, and the root of the repository containing
+// it, so that paths from diffs and tools resolve the same way from any
+// subdirectory.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned by Root for a directory outside any repository
+var ErrNotRepository = errors.New("not inside a git, Jujutsu or Sapling repository")
+
+// repositoryMarkers are the entries that make a directory a repository root.
+// .git is a file in worktrees and submodules.
+var repositoryMarkers = []string{".jj", ".sl", ".git"}
+
+// Resolve returns dir ("" for the current directory) as an absolute path
+// with symlinks resolved, and checks that it is a directory
+func Resolve(dir string) (string, error) {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		dir = wd
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return resolved, nil
+}
+
+// Root returns the root of the repository containing the resolved directory
+// dir, or ErrNotRepository
+func Root(dir string) (string, error) {
+	for current := dir; ; {
+		for _, marker := range repositoryMarkers {
+			if _, err := os.Stat(filepath.Join(current, marker)); err == nil {
+				return current, nil
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", ErrNotRepository
+		}
+		current = parent
+	}
+}
+
+// RootRelative converts paths given relative to dir, such as file arguments
+// on the command line, to paths relative to root. Absolute paths and paths
+// outside root are returned unchanged.
+func RootRelative(root, dir string, paths []string) []string {
+	converted := make([]string, len(paths))
+	for i, path := range paths {
+		converted[i] = path
+		if path == "" || filepath.IsAbs(path) {
+			continue
+		}
+		rel, err := filepath.Rel(root, filepath.Join(dir, path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		converted[i] = filepath.ToSlash(rel)
+	}
+	return converted
+}
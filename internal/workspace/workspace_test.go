@@ -0,0 +1,62 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	repo := filepath.Join(dir, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "internal"), 0755))
+	require.NoError(t, os.Symlink(repo, filepath.Join(dir, "link")))
+
+	resolved, err := Resolve(filepath.Join(dir, "link", "internal"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "internal"), resolved)
+
+	t.Chdir(filepath.Join(dir, "link"))
+	resolved, err = Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, repo, resolved)
+
+	_, err = Resolve(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), nil, 0644))
+	_, err = Resolve(filepath.Join(repo, "file.txt"))
+	assert.ErrorContains(t, err, "not a directory")
+}
+
+func TestRoot(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "internal", "cli")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	_, err := Root(nested)
+	assert.ErrorIs(t, err, ErrNotRepository)
+
+	// A worktree has a .git file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git"), []byte("gitdir: /src/app/.git/worktrees/x\n"), 0644))
+	root, err := Root(nested)
+	require.NoError(t, err)
+	assert.Equal(t, dir, root)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "internal", ".jj"), 0755))
+	root, err = Root(nested)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "internal"), root)
+}
+
+func TestRootRelative(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+	dir := filepath.Join(root, "internal")
+
+	assert.Equal(t,
+		[]string{"internal/cli/root.go", "go.mod", filepath.FromSlash("/etc/hosts"), "../../other/main.go"},
+		RootRelative(root, dir, []string{"cli/root.go", "../go.mod", filepath.FromSlash("/etc/hosts"), "../../other/main.go"}))
+}
@@ -0,0 +1,339 @@
+// Package gitbuddy embeds gitbuddy's commit, review and debug agents in other
+// Go programs, such as bots and server apps, instead of running the binary.
+//
+//	provider, err := llm.NewProvider(llm.ModelConfig{Provider: "openai", Model: "gpt-4o", APIKey: key})
+//	if err != nil {
+//		return err
+//	}
+//	client, err := gitbuddy.New(gitbuddy.Options{Provider: provider, RepoDir: "/src/app"})
+//	if err != nil {
+//		return err
+//	}
+//	msg, err := client.GenerateCommit(ctx, gitbuddy.CommitRequest{})
+//
+// The types of this package are the stable API; they don't change with the
+// internal packages the agents are built from. The agents work on the staged
+// changes of RepoDir and don't read the gitbuddy config file.
+package gitbuddy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/huimingz/gitbuddy-go/internal/agent"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	"github.com/huimingz/gitbuddy-go/internal/git"
+	internalllm "github.com/huimingz/gitbuddy-go/internal/llm"
+	"github.com/huimingz/gitbuddy-go/internal/ui"
+	"github.com/huimingz/gitbuddy-go/internal/workspace"
+	"github.com/huimingz/gitbuddy-go/pkg/llm"
+)
+
+// ErrNoStagedChanges is returned by GenerateCommit and Review when nothing is staged
+var ErrNoStagedChanges = errors.New("no staged changes found")
+
+// Options configures a Client
+type Options struct {
+	Provider llm.Provider // Required
+	RepoDir  string       // Directory in the repository to work in (default: the current directory)
+	VCS      string       // git, jj, sapling or auto (default: auto)
+	Language string       // Output language, e.g. "en" or "zh" (default: en)
+	Progress io.Writer    // Receives the agents' progress output (default: discarded)
+
+	// MaxRetries is the number of attempts of a failed model request
+	// (0 = default of 3, negative = no retries)
+	MaxRetries int
+	// PromptExtension is project-specific guidance appended to the system prompts
+	PromptExtension string
+}
+
+// Client runs the agents on one repository. Each call creates its own agent,
+// so calls may run concurrently; their progress output then interleaves.
+type Client struct {
+	opts     Options
+	executor git.Executor
+	retry    internalllm.RetryConfig
+}
+
+// New creates a Client
+func New(opts Options) (*Client, error) {
+	if opts.Provider == nil {
+		return nil, errors.New("provider is required")
+	}
+	dir, err := workspace.Resolve(opts.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+	if root, err := workspace.Root(dir); err == nil {
+		dir = root
+	}
+	opts.RepoDir = dir
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.Progress == nil {
+		opts.Progress = io.Discard
+	}
+
+	executor, err := git.NewVCSExecutor(opts.RepoDir, opts.VCS)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := internalllm.DefaultRetryConfig()
+	switch {
+	case opts.MaxRetries < 0:
+		retry.Enabled = false
+	case opts.MaxRetries > 0:
+		retry.MaxAttempts = opts.MaxRetries
+	}
+	return &Client{opts: opts, executor: executor, retry: retry}, nil
+}
+
+// TokenUsage is the number of tokens a call used
+type TokenUsage struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+	Total      int `json:"total"`
+}
+
+// CommitRequest asks for a commit message for the staged changes
+type CommitRequest struct {
+	Context string // Additional context, e.g. the issue being fixed
+}
+
+// CommitMessage is a Conventional Commits message
+type CommitMessage struct {
+	Type        string     `json:"type"`
+	Scope       string     `json:"scope,omitempty"`
+	Description string     `json:"description"`
+	Body        string     `json:"body,omitempty"`
+	Footer      string     `json:"footer,omitempty"`
+	Title       string     `json:"title"`   // First line, e.g. "feat(auth): add login"
+	Message     string     `json:"message"` // Complete message
+	Partial     bool       `json:"partial,omitempty"`
+	Usage       TokenUsage `json:"usage"`
+}
+
+// GenerateCommit generates a commit message for the staged changes
+func (c *Client) GenerateCommit(ctx context.Context, req CommitRequest) (*CommitMessage, error) {
+	if err := c.checkStaged(ctx); err != nil {
+		return nil, err
+	}
+
+	commitAgent, err := agent.NewCommitAgent(agent.CommitAgentOptions{
+		Language:        c.opts.Language,
+		GitExecutor:     c.executor,
+		LLMProvider:     c.provider(),
+		Printer:         c.printer(),
+		Output:          c.opts.Progress,
+		RetryConfig:     c.retry,
+		PromptExtension: c.opts.PromptExtension,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit agent: %w", err)
+	}
+
+	response, err := commitAgent.GenerateCommitMessage(ctx, agent.CommitRequest{Language: c.opts.Language, Context: req.Context})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	if response == nil || response.CommitInfo == nil {
+		return nil, errors.New("no commit message generated")
+	}
+
+	info := response.CommitInfo
+	return &CommitMessage{
+		Type:        info.Type,
+		Scope:       info.Scope,
+		Description: info.Description,
+		Body:        info.Body,
+		Footer:      info.Footer,
+		Title:       info.Title(),
+		Message:     info.Message(),
+		Partial:     response.Partial,
+		Usage:       TokenUsage{Prompt: response.PromptTokens, Completion: response.CompletionTokens, Total: response.TotalTokens},
+	}, nil
+}
+
+// Review severities, from the most to the least severe
+const (
+	SeverityError   = agent.SeverityError
+	SeverityWarning = agent.SeverityWarning
+	SeverityInfo    = agent.SeverityInfo
+)
+
+// ReviewRequest asks for a review of the staged changes
+type ReviewRequest struct {
+	Context  string   // Additional context
+	Files    []string // Files to review (default: all staged files)
+	Focus    []string // Focus areas, e.g. security, performance
+	Severity string   // Minimum severity of the reported issues (default: all)
+}
+
+// ReviewIssue is a problem found by the review
+type ReviewIssue struct {
+	Severity    string `json:"severity"` // error, warning or info
+	Category    string `json:"category"` // bug, security, performance, style or suggestion
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// Review is the result of a review
+type Review struct {
+	Issues  []ReviewIssue `json:"issues"`
+	Summary string        `json:"summary"`
+	Partial bool          `json:"partial,omitempty"`
+	Usage   TokenUsage    `json:"usage"`
+}
+
+// Review reviews the staged changes
+func (c *Client) Review(ctx context.Context, req ReviewRequest) (*Review, error) {
+	if err := c.checkStaged(ctx); err != nil {
+		return nil, err
+	}
+	switch req.Severity {
+	case "", SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return nil, fmt.Errorf("invalid severity level: %s (valid: error, warning, info)", req.Severity)
+	}
+
+	reviewAgent := agent.NewReviewAgent(agent.ReviewAgentOptions{
+		Language:        c.opts.Language,
+		GitExecutor:     c.executor,
+		LLMProvider:     c.provider(),
+		Printer:         c.printer(),
+		Output:          c.opts.Progress,
+		WorkDir:         c.opts.RepoDir,
+		RetryConfig:     c.retry,
+		PromptExtension: c.opts.PromptExtension,
+	})
+	response, err := reviewAgent.Review(ctx, agent.ReviewRequest{
+		Language: c.opts.Language,
+		Context:  req.Context,
+		Files:    req.Files,
+		Focus:    req.Focus,
+		Severity: req.Severity,
+		WorkDir:  c.opts.RepoDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review code: %w", err)
+	}
+
+	review := &Review{
+		Issues:  make([]ReviewIssue, 0, len(response.Issues)),
+		Summary: response.Summary,
+		Partial: response.Partial,
+		Usage:   TokenUsage{Prompt: response.PromptTokens, Completion: response.CompletionTokens, Total: response.TotalTokens},
+	}
+	for _, issue := range response.Issues {
+		review.Issues = append(review.Issues, ReviewIssue{
+			Severity:    issue.Severity,
+			Category:    issue.Category,
+			File:        issue.File,
+			Line:        issue.Line,
+			Title:       issue.Title,
+			Description: issue.Description,
+			Suggestion:  issue.Suggestion,
+		})
+	}
+	return review, nil
+}
+
+// DebugRequest asks for an investigation of an issue in the repository
+type DebugRequest struct {
+	Issue         string   // Required, e.g. "Login fails with a 500 after the token expires"
+	Context       string   // Additional context, e.g. logs
+	Files         []string // Files to start from
+	ReportDir     string   // Directory the report is saved to (default: issues/ in RepoDir)
+	MaxIterations int      // Agent iterations (0 = default)
+	MaxTokens     int      // Token budget (0 = unlimited)
+}
+
+// DebugResult is the report of an investigation
+type DebugResult struct {
+	Report     string     `json:"report"`      // Markdown
+	ReportPath string     `json:"report_path"` // Where the report was saved
+	Partial    bool       `json:"partial,omitempty"`
+	Usage      TokenUsage `json:"usage"`
+}
+
+// Debug investigates an issue and writes a report. It runs without
+// interaction: when the iterations run out, the partial report is returned.
+func (c *Client) Debug(ctx context.Context, req DebugRequest) (*DebugResult, error) {
+	if strings.TrimSpace(req.Issue) == "" {
+		return nil, errors.New("issue description is required")
+	}
+	reportDir := req.ReportDir
+	if reportDir == "" {
+		reportDir = filepath.Join(c.opts.RepoDir, "issues")
+	}
+
+	debugAgent := agent.NewDebugAgent(agent.DebugAgentOptions{
+		Language:        c.opts.Language,
+		GitExecutor:     c.executor,
+		LLMProvider:     c.provider(),
+		Printer:         c.printer(),
+		Output:          c.opts.Progress,
+		Input:           strings.NewReader(""),
+		WorkDir:         c.opts.RepoDir,
+		IssuesDir:       reportDir,
+		RetryConfig:     c.retry,
+		PromptExtension: c.opts.PromptExtension,
+	})
+	response, err := debugAgent.Debug(ctx, agent.DebugRequest{
+		Issue:         req.Issue,
+		Language:      c.opts.Language,
+		Context:       req.Context,
+		Files:         req.Files,
+		WorkDir:       c.opts.RepoDir,
+		IssuesDir:     reportDir,
+		MaxIterations: req.MaxIterations,
+		MaxTokens:     req.MaxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to debug issue: %w", err)
+	}
+	return &DebugResult{
+		Report:     response.Report,
+		ReportPath: response.FilePath,
+		Partial:    response.Partial,
+		Usage:      TokenUsage{Prompt: response.PromptTokens, Completion: response.CompletionTokens, Total: response.TotalTokens},
+	}, nil
+}
+
+// checkStaged returns ErrNoStagedChanges when nothing is staged
+func (c *Client) checkStaged(ctx context.Context) error {
+	diff, err := c.executor.DiffCached(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if diff == "" {
+		return ErrNoStagedChanges
+	}
+	return nil
+}
+
+func (c *Client) printer() *ui.StreamPrinter {
+	return ui.NewStreamPrinter(c.opts.Progress, ui.WithColor(false))
+}
+
+func (c *Client) provider() internalllm.Provider {
+	return providerAdapter{c.opts.Provider}
+}
+
+// providerAdapter makes a public provider usable by the agents
+type providerAdapter struct {
+	llm.Provider
+}
+
+func (p providerAdapter) GetConfig() config.ModelConfig {
+	return config.ModelConfig{Provider: p.Name(), Model: p.Model()}
+}
@@ -0,0 +1,110 @@
+package gitbuddy
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// submitModel answers every request by submitting a commit message
+type submitModel struct{}
+
+func (m *submitModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.reply(), nil
+}
+
+func (m *submitModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{m.reply()}), nil
+}
+
+func (m *submitModel) BindTools(tools []*schema.ToolInfo) error { return nil }
+
+func (m *submitModel) reply() *schema.Message {
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call-1",
+		Function: schema.FunctionCall{Name: "submit_commit", Arguments: `{"type":"feat","scope":"greet","description":"add greeting"}`},
+	}})
+}
+
+type fakeProvider struct{}
+
+func (p *fakeProvider) Name() string  { return "fake" }
+func (p *fakeProvider) Model() string { return "fake-1" }
+func (p *fakeProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return &submitModel{}, nil
+}
+
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+	}
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{})
+	assert.Error(t, err, "a provider is required")
+
+	_, err = New(Options{Provider: &fakeProvider{}, VCS: "svn"})
+	assert.Error(t, err)
+
+	client, err := New(Options{Provider: &fakeProvider{}, RepoDir: t.TempDir(), MaxRetries: -1})
+	require.NoError(t, err)
+	assert.Equal(t, "en", client.opts.Language)
+	assert.False(t, client.retry.Enabled)
+}
+
+func TestClient_GenerateCommit(t *testing.T) {
+	repo := setupTestRepo(t)
+	client, err := New(Options{Provider: &fakeProvider{}, RepoDir: repo, VCS: "git"})
+	require.NoError(t, err)
+
+	_, err = client.GenerateCommit(context.Background(), CommitRequest{})
+	assert.ErrorIs(t, err, ErrNoStagedChanges)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "greet.go"), []byte("package greet\n"), 0644))
+	cmd := exec.Command("git", "add", "greet.go")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+
+	msg, err := client.GenerateCommit(context.Background(), CommitRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "feat", msg.Type)
+	assert.Equal(t, "feat(greet): add greeting", msg.Title)
+	assert.False(t, msg.Partial)
+}
+
+func TestClient_Review_InvalidSeverity(t *testing.T) {
+	repo := setupTestRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n"), 0644))
+	cmd := exec.Command("git", "add", "a.go")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+
+	client, err := New(Options{Provider: &fakeProvider{}, RepoDir: repo, VCS: "git"})
+	require.NoError(t, err)
+	_, err = client.Review(context.Background(), ReviewRequest{Severity: "critical"})
+	assert.ErrorContains(t, err, "invalid severity level")
+}
+
+func TestClient_Debug_RequiresIssue(t *testing.T) {
+	client, err := New(Options{Provider: &fakeProvider{}, RepoDir: t.TempDir(), VCS: "git"})
+	require.NoError(t, err)
+	_, err = client.Debug(context.Background(), DebugRequest{Issue: "  "})
+	assert.Error(t, err)
+}
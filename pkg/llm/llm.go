@@ -0,0 +1,79 @@
+// Package llm is the public model provider layer of gitbuddy. It creates chat
+// models for the supported providers (openai, deepseek, ollama, grok,
+// gemini), and defines the Provider interface that programs embedding
+// gitbuddy implement to bring their own models.
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/huimingz/gitbuddy-go/internal/config"
+	internalllm "github.com/huimingz/gitbuddy-go/internal/llm"
+)
+
+// ModelConfig selects a model of a provider
+type ModelConfig struct {
+	Provider string // openai, deepseek, ollama, grok or gemini
+	Model    string // e.g. gpt-4o
+	APIKey   string // Not needed for ollama
+	BaseURL  string // Optional, e.g. for OpenAI-compatible gateways
+}
+
+// Provider creates the chat models the agents talk to
+type Provider interface {
+	// Name returns the provider name, e.g. "openai"
+	Name() string
+
+	// Model returns the model name, e.g. "gpt-4o"
+	Model() string
+
+	// CreateChatModel creates an Eino chat model. The agents bind their
+	// tools to it, so it must support tool calling.
+	CreateChatModel(ctx context.Context) (model.ChatModel, error)
+}
+
+// NewProvider creates the provider of a built-in model. Errors ending a
+// response stream name the provider's request ID when it reported one.
+func NewProvider(cfg ModelConfig) (Provider, error) {
+	modelConfig := config.ModelConfig{
+		Provider: cfg.Provider,
+		Model:    cfg.Model,
+		APIKey:   cfg.APIKey,
+		BaseURL:  cfg.BaseURL,
+	}
+	if err := modelConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid model config: %w", err)
+	}
+	provider, err := internalllm.NewProviderFactory().Create(modelConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM provider: %w", err)
+	}
+	return &builtinProvider{provider: internalllm.WithRequestIDs(provider)}, nil
+}
+
+// builtinProvider exposes a provider of internal/llm
+type builtinProvider struct {
+	provider internalllm.Provider
+}
+
+func (p *builtinProvider) Name() string { return p.provider.Name() }
+
+func (p *builtinProvider) Model() string { return p.provider.GetConfig().Model }
+
+func (p *builtinProvider) CreateChatModel(ctx context.Context) (model.ChatModel, error) {
+	return p.provider.CreateChatModel(ctx)
+}
+
+// UserError is a model failure with a remediation hint (an invalid API key,
+// an exhausted quota, a request too large for the context window, an
+// unreachable provider). Use errors.As to find it in the errors returned by
+// the agents.
+type UserError = internalllm.UserError
+
+// IsUnavailable reports whether err means the model provider could not be
+// reached or failed on its side (5xx), as during an outage
+func IsUnavailable(err error) bool {
+	return internalllm.IsUnavailable(err)
+}
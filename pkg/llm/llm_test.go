@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(ModelConfig{Provider: "openai", Model: "gpt-4o"})
+	assert.ErrorContains(t, err, "api_key is required")
+
+	_, err = NewProvider(ModelConfig{Provider: "acme", Model: "x"})
+	assert.ErrorContains(t, err, "unsupported provider")
+
+	provider, err := NewProvider(ModelConfig{Provider: "ollama", Model: "qwen2.5:14b", BaseURL: "http://localhost:11434/v1"})
+	require.NoError(t, err)
+	assert.Equal(t, "ollama", provider.Name())
+	assert.Equal(t, "qwen2.5:14b", provider.Model())
+}
+
+func TestIsUnavailable(t *testing.T) {
+	assert.True(t, IsUnavailable(errors.New("dial tcp: connection refused")))
+	assert.False(t, IsUnavailable(errors.New("invalid api key")))
+}